@@ -0,0 +1,247 @@
+// Package apicompat compares the exported API of two versions of a
+// package and reports incompatible changes.
+package apicompat // import "honnef.co/go/tools/apicompat"
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// A Change describes a single difference between two versions of an
+// API.
+type Change struct {
+	// Name is the name of the affected object, e.g. "Foo" or
+	// "(*Foo).Bar".
+	Name string
+	// Message describes the change.
+	Message string
+	// Compatible reports whether the change is backwards compatible.
+	Compatible bool
+}
+
+func (c Change) String() string {
+	return c.Name + ": " + c.Message
+}
+
+type comparer struct {
+	changes []Change
+}
+
+func (c *comparer) incompatible(name, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{Name: name, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *comparer) compatible(name, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{Name: name, Message: fmt.Sprintf(format, args...), Compatible: true})
+}
+
+// typeString formats types so that they can be compared across two
+// independently type-checked versions of a package.
+func typeString(T types.Type) string {
+	return types.TypeString(T, func(pkg *types.Package) string { return pkg.Path() })
+}
+
+// Compare compares the exported API of two versions of a package,
+// sorted by name.
+func Compare(old, new *types.Package) []Change {
+	c := &comparer{}
+	for _, name := range old.Scope().Names() {
+		oobj := old.Scope().Lookup(name)
+		if !oobj.Exported() {
+			continue
+		}
+		nobj := new.Scope().Lookup(name)
+		if nobj == nil || !nobj.Exported() {
+			c.incompatible(name, "removed")
+			continue
+		}
+		c.object(name, oobj, nobj)
+	}
+	for _, name := range new.Scope().Names() {
+		nobj := new.Scope().Lookup(name)
+		if nobj.Exported() && old.Scope().Lookup(name) == nil {
+			c.compatible(name, "added")
+		}
+	}
+	sort.Stable(byName(c.changes))
+	return c.changes
+}
+
+type byName []Change
+
+func (cs byName) Len() int           { return len(cs) }
+func (cs byName) Less(i, j int) bool { return cs[i].Name < cs[j].Name }
+func (cs byName) Swap(i, j int)      { cs[i], cs[j] = cs[j], cs[i] }
+
+func kind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Const:
+		return "constant"
+	case *types.Var:
+		return "variable"
+	case *types.Func:
+		return "function"
+	case *types.TypeName:
+		return "type"
+	default:
+		return "object"
+	}
+}
+
+func (c *comparer) object(name string, oobj, nobj types.Object) {
+	if kind(oobj) != kind(nobj) {
+		c.incompatible(name, "changed from %s to %s", kind(oobj), kind(nobj))
+		return
+	}
+	switch oobj := oobj.(type) {
+	case *types.Const:
+		nobj := nobj.(*types.Const)
+		if ot, nt := typeString(oobj.Type()), typeString(nobj.Type()); ot != nt {
+			c.incompatible(name, "type changed from %s to %s", ot, nt)
+		}
+		if !constant.Compare(oobj.Val(), token.EQL, nobj.Val()) {
+			c.incompatible(name, "value changed from %s to %s", oobj.Val(), nobj.Val())
+		}
+	case *types.Var:
+		if ot, nt := typeString(oobj.Type()), typeString(nobj.Type()); ot != nt {
+			c.incompatible(name, "type changed from %s to %s", ot, nt)
+		}
+	case *types.Func:
+		if ot, nt := typeString(oobj.Type()), typeString(nobj.Type()); ot != nt {
+			c.incompatible(name, "signature changed from %s to %s", ot, nt)
+		}
+	case *types.TypeName:
+		c.typ(name, oobj.Type(), nobj.Type())
+	}
+}
+
+func (c *comparer) typ(name string, old, new types.Type) {
+	ou, nu := old.Underlying(), new.Underlying()
+	switch ou := ou.(type) {
+	case *types.Struct:
+		nu, ok := nu.(*types.Struct)
+		if !ok {
+			c.incompatible(name, "changed from struct to %s", typeString(new.Underlying()))
+			return
+		}
+		c.structType(name, ou, nu)
+	case *types.Interface:
+		nu, ok := nu.(*types.Interface)
+		if !ok {
+			c.incompatible(name, "changed from interface to %s", typeString(new.Underlying()))
+			return
+		}
+		c.interfaceType(name, ou, nu)
+	default:
+		if ot, nt := typeString(ou), typeString(nu); ot != nt {
+			c.incompatible(name, "underlying type changed from %s to %s", ot, nt)
+		}
+	}
+
+	if _, ok := ou.(*types.Interface); ok {
+		return
+	}
+	c.methods(name, old, new)
+}
+
+func (c *comparer) structType(name string, old, new *types.Struct) {
+	fields := map[string]*types.Var{}
+	for i := 0; i < new.NumFields(); i++ {
+		fields[new.Field(i).Name()] = new.Field(i)
+	}
+	for i := 0; i < old.NumFields(); i++ {
+		of := old.Field(i)
+		if !of.Exported() {
+			continue
+		}
+		fname := name + "." + of.Name()
+		nf, ok := fields[of.Name()]
+		if !ok || !nf.Exported() {
+			c.incompatible(fname, "field removed")
+			continue
+		}
+		if ot, nt := typeString(of.Type()), typeString(nf.Type()); ot != nt {
+			c.incompatible(fname, "field type changed from %s to %s", ot, nt)
+		}
+	}
+	for i := 0; i < new.NumFields(); i++ {
+		nf := new.Field(i)
+		if !nf.Exported() {
+			continue
+		}
+		found := false
+		for j := 0; j < old.NumFields(); j++ {
+			if old.Field(j).Name() == nf.Name() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.compatible(name+"."+nf.Name(), "field added")
+		}
+	}
+}
+
+func (c *comparer) interfaceType(name string, old, new *types.Interface) {
+	methods := map[string]*types.Func{}
+	for i := 0; i < new.NumMethods(); i++ {
+		methods[new.Method(i).Name()] = new.Method(i)
+	}
+	for i := 0; i < old.NumMethods(); i++ {
+		om := old.Method(i)
+		mname := name + "." + om.Name()
+		nm, ok := methods[om.Name()]
+		if !ok {
+			c.incompatible(mname, "method removed from interface")
+			continue
+		}
+		if ot, nt := typeString(om.Type()), typeString(nm.Type()); ot != nt {
+			c.incompatible(mname, "signature changed from %s to %s", ot, nt)
+		}
+		delete(methods, om.Name())
+	}
+	for mname, m := range methods {
+		if m.Exported() {
+			c.incompatible(name+"."+mname, "method added to interface, breaking existing implementations")
+		} else {
+			c.incompatible(name+"."+mname, "unexported method added to interface, preventing implementations outside the package")
+		}
+	}
+}
+
+// methods compares the method sets of *old and *new, which include
+// the methods of old and new.
+func (c *comparer) methods(name string, old, new types.Type) {
+	oset := types.NewMethodSet(types.NewPointer(old))
+	nset := types.NewMethodSet(types.NewPointer(new))
+	for i := 0; i < oset.Len(); i++ {
+		om := oset.At(i).Obj()
+		if !om.Exported() {
+			continue
+		}
+		recv := name
+		if _, ok := om.Type().(*types.Signature).Recv().Type().(*types.Pointer); ok {
+			recv = "*" + name
+		}
+		mname := fmt.Sprintf("(%s).%s", recv, om.Name())
+		// Look the method up by name, not by object; the packages
+		// differ between the two versions.
+		var nm types.Object
+		for j := 0; j < nset.Len(); j++ {
+			if nset.At(j).Obj().Name() == om.Name() {
+				nm = nset.At(j).Obj()
+				break
+			}
+		}
+		if nm == nil {
+			c.incompatible(mname, "method removed")
+			continue
+		}
+		if ot, nt := typeString(om.Type()), typeString(nm.Type()); ot != nt {
+			c.incompatible(mname, "signature changed from %s to %s", ot, nt)
+		}
+	}
+}
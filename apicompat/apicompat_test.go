@@ -0,0 +1,106 @@
+package apicompat
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func check(t *testing.T, src string) *types.Package {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "pkg.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := (&types.Config{}).Check("example.com/pkg", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+const oldSrc = `package pkg
+
+const C = 1
+const D = "d"
+
+var V int
+
+func F(int) string { return "" }
+func G() {}
+
+type S struct {
+	A int
+	B string
+	c bool
+}
+
+func (S) M()  {}
+func (*S) N() {}
+
+type I interface {
+	Foo()
+}
+
+type T int
+`
+
+const newSrc = `package pkg
+
+const C = 2
+const D = "d"
+
+var V int64
+
+func F(int) string { return "" }
+func H() {}
+
+type S struct {
+	A int
+	c bool
+	E float64
+}
+
+func (S) M() {}
+
+type I interface {
+	Foo()
+	Bar()
+}
+
+type T string
+`
+
+func TestCompare(t *testing.T) {
+	want := []string{
+		"C: value changed from 1 to 2",
+		"G: removed",
+		"H: added",
+		"I.Bar: method added to interface, breaking existing implementations",
+		"S.B: field removed",
+		"(*S).N: method removed",
+		"S.E: field added",
+		"T: underlying type changed from int to string",
+		"V: type changed from int to int64",
+	}
+	changes := Compare(check(t, oldSrc), check(t, newSrc))
+	got := map[string]bool{}
+	for _, c := range changes {
+		got[c.String()] = true
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("missing change %q", w)
+		}
+	}
+	if len(changes) != len(want) {
+		t.Errorf("got %d changes, want %d: %v", len(changes), len(want), changes)
+	}
+	for _, c := range changes {
+		if c.Compatible != (c.Message == "added" || c.Message == "field added") {
+			t.Errorf("%s: wrong compatibility %t", c, c.Compatible)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// apicompat reports incompatible changes to the exported API of a
+// package between two git revisions.
+package main // import "honnef.co/go/tools/cmd/apicompat"
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/build"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"honnef.co/go/tools/apicompat"
+
+	"golang.org/x/tools/go/loader"
+)
+
+var (
+	fOld  string
+	fNew  string
+	fAll  bool
+	fTags string
+)
+
+func init() {
+	flag.StringVar(&fOld, "old", "", "Git `revision` of the old version")
+	flag.StringVar(&fNew, "new", "", "Git `revision` of the new version (default: the working tree)")
+	flag.BoolVar(&fAll, "all", false, "Also print compatible changes")
+	flag.StringVar(&fTags, "tags", "", "List of `build tags`")
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s -old <revision> [flags] <package>\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 || fOld == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := build.Default
+	ctx.BuildTags = strings.Fields(fTags)
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	bpkg, err := ctx.Import(flag.Arg(0), cwd, build.FindOnly)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	old, err := loadRevision(&ctx, bpkg, fOld)
+	if err != nil {
+		log.Fatalf("couldn't load %s at %s: %s", bpkg.ImportPath, fOld, err)
+	}
+	var new *types.Package
+	if fNew == "" {
+		new, err = load(&ctx, bpkg.ImportPath, bpkg.Dir)
+	} else {
+		new, err = loadRevision(&ctx, bpkg, fNew)
+	}
+	if err != nil {
+		log.Fatalf("couldn't load new version of %s: %s", bpkg.ImportPath, err)
+	}
+
+	incompatible := false
+	for _, change := range apicompat.Compare(old, new) {
+		if change.Compatible && !fAll {
+			continue
+		}
+		if !change.Compatible {
+			incompatible = true
+		}
+		fmt.Println(change)
+	}
+	if incompatible {
+		os.Exit(1)
+	}
+}
+
+// loadRevision extracts the package's files at the given git revision
+// into a temporary directory and type-checks them.
+func loadRevision(ctx *build.Context, bpkg *build.Package, rev string) (*types.Package, error) {
+	top, err := git(bpkg.Dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	top = strings.TrimSpace(top)
+	rel, err := filepath.Rel(top, bpkg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+	files, err := git(top, "ls-tree", "--name-only", rev+":"+rel)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := ioutil.TempDir("", "apicompat")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	for _, name := range strings.Split(strings.TrimSpace(files), "\n") {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		data, err := git(top, "show", rev+":"+path.Join(rel, name))
+		if err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(filepath.Join(tmp, name), []byte(data), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return load(ctx, bpkg.ImportPath, tmp)
+}
+
+// load type-checks the package in dir as if it had the given import
+// path.
+func load(ctx *build.Context, importPath string, dir string) (*types.Package, error) {
+	bpkg, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range bpkg.GoFiles {
+		files = append(files, filepath.Join(dir, name))
+	}
+	for _, name := range bpkg.CgoFiles {
+		files = append(files, filepath.Join(dir, name))
+	}
+	conf := loader.Config{
+		Build:       ctx,
+		AllowErrors: true,
+		TypeChecker: types.Config{Error: func(error) {}},
+	}
+	conf.CreateFromFilenames(importPath, files...)
+	lprog, err := conf.Load()
+	if err != nil {
+		return nil, err
+	}
+	return lprog.Created[0].Pkg, nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,266 @@
+// deadcode reports functions that can never be executed.
+package main // import "honnef.co/go/tools/cmd/deadcode"
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"honnef.co/go/tools/callgraph/rta"
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/ssa"
+	"honnef.co/go/tools/ssa/ssautil"
+
+	"github.com/kisielk/gotool"
+	"golang.org/x/tools/go/loader"
+)
+
+var (
+	fTags     string
+	fTests    bool
+	fExported bool
+)
+
+func init() {
+	flag.StringVar(&fTags, "tags", "", "List of `build tags`")
+	flag.BoolVar(&fTests, "tests", true, "Use tests, benchmarks and examples as entry points")
+	flag.BoolVar(&fExported, "exported", false, "Use the exported functions and methods of non-main packages as entry points")
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] [packages]\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = usage
+	flag.Parse()
+
+	ctx := build.Default
+	ctx.BuildTags = strings.Fields(fTags)
+	dead, err := deadcode(&ctx, gotool.ImportPaths(flag.Args()), fTests, fExported)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, line := range dead {
+		fmt.Println(line)
+	}
+	if len(dead) > 0 {
+		os.Exit(1)
+	}
+}
+
+// deadcode loads the packages at paths and returns a description of
+// each of their functions that can't be reached from an entry point,
+// sorted by position. tests and exported correspond to the flags of
+// the same name.
+func deadcode(ctx *build.Context, paths []string, tests, exported bool) ([]string, error) {
+	conf := loader.Config{
+		Build:      ctx,
+		ParserMode: parser.ParseComments,
+	}
+	for _, path := range paths {
+		if tests {
+			conf.ImportWithTests(path)
+		} else {
+			conf.Import(path)
+		}
+	}
+	lprog, err := conf.Load()
+	if err != nil {
+		return nil, err
+	}
+	prog := ssautil.CreateProgram(lprog, 0)
+	prog.Build()
+
+	initial := map[*types.Package]bool{}
+	for _, pkg := range lprog.InitialPackages() {
+		initial[pkg.Pkg] = true
+	}
+
+	var roots []*ssa.Function
+	hasEntryPoint := false
+	for pkg := range initial {
+		spkg := prog.Package(pkg)
+		for _, fn := range entryPoints(lprog.Fset, spkg, tests, exported) {
+			roots = append(roots, fn)
+			if fn.Synthetic == "" || runsOwnCode(fn) {
+				hasEntryPoint = true
+			}
+		}
+	}
+	if !hasEntryPoint {
+		// Every package has an initializer, but one that only
+		// initializes the imported packages isn't an entry point
+		// worth analyzing.
+		return nil, errors.New("no entry points found; use -exported to analyze libraries without tests")
+	}
+	res := rta.Analyze(roots, false)
+	for _, fn := range roots {
+		// RTA doesn't consider the roots themselves reachable.
+		res.Reachable[fn] = struct{ AddrTaken bool }{}
+	}
+
+	var dead []*ssa.Function
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Synthetic != "" || fn.Parent() != nil || fn.Pkg == nil || !initial[fn.Pkg.Pkg] {
+			continue
+		}
+		if _, ok := res.Reachable[fn]; ok {
+			continue
+		}
+		if isGenerated(lprog, fn.Pos()) {
+			continue
+		}
+		dead = append(dead, fn)
+	}
+	sort.Sort(byPosition{lprog.Fset, dead})
+	var out []string
+	for _, fn := range dead {
+		out = append(out, fmt.Sprintf("%s: %s is unreachable", position(lprog.Fset, fn.Pos()), name(fn)))
+	}
+	return out, nil
+}
+
+// entryPoints returns the functions in pkg that the program can start
+// executing at.
+func entryPoints(fset *token.FileSet, pkg *ssa.Package, tests, exported bool) []*ssa.Function {
+	var out []*ssa.Function
+	if fn := pkg.Func("init"); fn != nil {
+		out = append(out, fn)
+	}
+	isMain := pkg.Pkg.Name() == "main"
+	if isMain {
+		if fn := pkg.Func("main"); fn != nil {
+			out = append(out, fn)
+		}
+	}
+	for _, mem := range pkg.Members {
+		fn, ok := mem.(*ssa.Function)
+		if !ok {
+			continue
+		}
+		if tests && isTest(fset, fn) {
+			out = append(out, fn)
+			continue
+		}
+		if exported && !isMain && ast.IsExported(fn.Name()) {
+			out = append(out, fn)
+		}
+	}
+	if exported && !isMain {
+		for _, mem := range pkg.Members {
+			T, ok := mem.(*ssa.Type)
+			if !ok || !ast.IsExported(T.Name()) {
+				continue
+			}
+			for _, typ := range []types.Type{T.Type(), types.NewPointer(T.Type())} {
+				mset := pkg.Prog.MethodSets.MethodSet(typ)
+				for i := 0; i < mset.Len(); i++ {
+					sel := mset.At(i)
+					if !ast.IsExported(sel.Obj().Name()) {
+						continue
+					}
+					if fn := pkg.Prog.MethodValue(sel); fn != nil {
+						out = append(out, fn)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// runsOwnCode reports whether the package initializer fn calls
+// functions of its package, such as init functions or the functions
+// that variables are initialized with.
+func runsOwnCode(fn *ssa.Function) bool {
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			call, ok := instr.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			if callee := call.Call.StaticCallee(); callee != nil && callee.Pkg == fn.Pkg {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isTest(fset *token.FileSet, fn *ssa.Function) bool {
+	if !strings.HasSuffix(fset.Position(fn.Pos()).Filename, "_test.go") {
+		return false
+	}
+	name := fn.Name()
+	for _, prefix := range []string{"Test", "Benchmark", "Example"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isGenerated(lprog *loader.Program, pos token.Pos) bool {
+	tf := lprog.Fset.File(pos)
+	for _, pkg := range lprog.InitialPackages() {
+		for _, f := range pkg.Files {
+			if lprog.Fset.File(f.Pos()) == tf {
+				return lint.IsGenerated(f)
+			}
+		}
+	}
+	return false
+}
+
+type byPosition struct {
+	fset *token.FileSet
+	fns  []*ssa.Function
+}
+
+func (ps byPosition) Len() int {
+	return len(ps.fns)
+}
+
+func (ps byPosition) Less(i int, j int) bool {
+	pi, pj := ps.fset.Position(ps.fns[i].Pos()), ps.fset.Position(ps.fns[j].Pos())
+	if pi.Filename != pj.Filename {
+		return pi.Filename < pj.Filename
+	}
+	return pi.Offset < pj.Offset
+}
+
+func (ps byPosition) Swap(i int, j int) {
+	ps.fns[i], ps.fns[j] = ps.fns[j], ps.fns[i]
+}
+
+func name(fn *ssa.Function) string {
+	if recv := fn.Signature.Recv(); recv != nil {
+		return fmt.Sprintf("(%s).%s", types.TypeString(recv.Type(), types.RelativeTo(fn.Pkg.Pkg)), fn.Name())
+	}
+	return fn.Name()
+}
+
+func position(fset *token.FileSet, pos token.Pos) string {
+	position := fset.Position(pos)
+	name := position.Filename
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, name); err == nil && len(rel) < len(name) {
+			name = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d", name, position.Line, position.Column)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"go/build"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testContext(t *testing.T) *build.Context {
+	gopath, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := build.Default
+	ctx.GOPATH = gopath
+	return &ctx
+}
+
+func TestDeadcode(t *testing.T) {
+	tests := []struct {
+		path     string
+		tests    bool
+		exported bool
+		want     []string
+	}{
+		{
+			path:  "prog",
+			tests: true,
+			want: []string{
+				"testdata/src/prog/main.go:14:17: (square).perimeter is unreachable",
+				"testdata/src/prog/main.go:33:6: legacy is unreachable",
+				"testdata/src/prog/main.go:37:6: legacyHelper is unreachable",
+			},
+		},
+		{
+			path:  "lib",
+			tests: true,
+			want: []string{
+				"testdata/src/lib/lib.go:9:6: Unused is unreachable",
+				"testdata/src/lib/lib.go:11:6: unusedHelper is unreachable",
+				"testdata/src/lib/lib.go:13:6: orphan is unreachable",
+				"testdata/src/lib/lib.go:18:10: (T).Method is unreachable",
+			},
+		},
+		{
+			path:     "lib",
+			tests:    true,
+			exported: true,
+			want: []string{
+				"testdata/src/lib/lib.go:13:6: orphan is unreachable",
+			},
+		},
+		{
+			path:     "nolib",
+			exported: true,
+			want:     nil,
+		},
+		{
+			// an init function is an entry point of its own
+			path: "initonly",
+			want: []string{
+				"testdata/src/initonly/initonly.go:9:6: unused is unreachable",
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := deadcode(testContext(t), []string{tt.path}, tt.tests, tt.exported)
+		if err != nil {
+			t.Errorf("%s: %s", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s (tests=%t, exported=%t): got\n%s\nwant\n%s", tt.path, tt.tests, tt.exported,
+				strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestDeadcodeNoEntryPoints(t *testing.T) {
+	_, err := deadcode(testContext(t), []string{"nolib"}, true, false)
+	if err == nil || !strings.Contains(err.Error(), "no entry points") {
+		t.Errorf("got error %v, want one about missing entry points", err)
+	}
+}
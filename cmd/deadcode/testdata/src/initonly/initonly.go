@@ -0,0 +1,9 @@
+package initonly
+
+func init() {
+	setup()
+}
+
+func setup() {}
+
+func unused() {}
@@ -0,0 +1,18 @@
+package lib
+
+// Used is called by a test.
+func Used() int { return helper() }
+
+func helper() int { return 1 }
+
+// Unused is exported, but only an entry point with -exported.
+func Unused() int { return unusedHelper() }
+
+func unusedHelper() int { return 2 }
+
+func orphan() {}
+
+type T struct{}
+
+// Method is an exported method of an exported type.
+func (T) Method() {}
@@ -0,0 +1,9 @@
+package lib
+
+import "testing"
+
+func TestUsed(t *testing.T) {
+	if Used() != 1 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,3 @@
+package nolib
+
+func Fn() {}
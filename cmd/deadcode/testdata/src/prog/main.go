@@ -0,0 +1,39 @@
+package main
+
+import "fmt"
+
+type shape interface {
+	area() float64
+}
+
+type square struct{ side float64 }
+
+func (s square) area() float64 { return s.side * s.side }
+
+// perimeter is never called, not even through the interface.
+func (s square) perimeter() float64 { return 4 * s.side }
+
+func init() {
+	register()
+}
+
+func register() {}
+
+func main() {
+	var s shape = square{2}
+	fmt.Println(s.area())
+	fmt.Println(format(1))
+}
+
+func format(n int) string {
+	return fmt.Sprint(n)
+}
+
+// legacy is only called by legacyHelper, which is dead itself.
+func legacy() {
+	legacyHelper()
+}
+
+func legacyHelper() {
+	legacy()
+}
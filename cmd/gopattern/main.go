@@ -0,0 +1,170 @@
+// gopattern searches Go packages for code matching a structural
+// pattern.
+package main // import "honnef.co/go/tools/cmd/gopattern"
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"honnef.co/go/tools/pattern"
+	"honnef.co/go/tools/ssa"
+	"honnef.co/go/tools/ssa/ssautil"
+
+	"github.com/kisielk/gotool"
+	"golang.org/x/tools/go/loader"
+)
+
+var (
+	fTags  string
+	fTests bool
+	fCount bool
+	fSSA   bool
+)
+
+func init() {
+	flag.StringVar(&fTags, "tags", "", "List of `build tags`")
+	flag.BoolVar(&fTests, "tests", true, "Include tests")
+	flag.BoolVar(&fCount, "c", false, "Only print the number of matches")
+	flag.BoolVar(&fSSA, "ssa", false, "Match calls by the values of their arguments")
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] <pattern> [packages]\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	pat, err := pattern.Parse(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if fSSA && !pat.IsCall() {
+		log.Fatal("-ssa requires a pattern consisting of a single call")
+	}
+
+	ctx := build.Default
+	ctx.BuildTags = strings.Fields(fTags)
+	conf := loader.Config{
+		Build:      &ctx,
+		ParserMode: parser.ParseComments,
+	}
+	paths := gotool.ImportPaths(flag.Args()[1:])
+	if len(paths) > 0 && strings.HasSuffix(paths[0], ".go") {
+		conf.CreateFromFilenames("adhoc", paths...)
+	} else {
+		for _, path := range paths {
+			if fTests {
+				conf.ImportWithTests(path)
+			} else {
+				conf.Import(path)
+			}
+		}
+	}
+	lprog, err := conf.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if fSSA {
+		n := findCalls(lprog, pat)
+		if n == 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	n := 0
+	for _, pkg := range lprog.InitialPackages() {
+		for _, f := range pkg.Files {
+			for _, m := range pat.Find(&pkg.Info, f) {
+				n++
+				if fCount {
+					continue
+				}
+				fmt.Printf("%s: %s\n", position(lprog.Fset, m.Pos()), render(lprog.Fset, m))
+			}
+		}
+	}
+	if fCount {
+		fmt.Println(n)
+	}
+	if n == 0 {
+		os.Exit(1)
+	}
+}
+
+func position(fset *token.FileSet, pos token.Pos) string {
+	position := fset.Position(pos)
+	name := position.Filename
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, name); err == nil && len(rel) < len(name) {
+			name = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d", name, position.Line, position.Column)
+}
+
+// render prints the matched code on a single line.
+func render(fset *token.FileSet, m pattern.Match) string {
+	var parts []string
+	for _, node := range m.Nodes {
+		buf := &bytes.Buffer{}
+		printer.Fprint(buf, fset, node)
+		parts = append(parts, strings.Join(strings.Fields(buf.String()), " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// findCalls prints the calls in the initial packages that match pat
+// in SSA form and returns their number.
+func findCalls(lprog *loader.Program, pat *pattern.Pattern) int {
+	prog := ssautil.CreateProgram(lprog, 0)
+	prog.Build()
+	initial := map[*ssa.Package]bool{}
+	for _, pkg := range lprog.InitialPackages() {
+		if spkg := prog.Package(pkg.Pkg); spkg != nil {
+			initial[spkg] = true
+		}
+	}
+	var matches []pattern.CallMatch
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Pkg == nil || !initial[fn.Pkg] || fn.Synthetic != "" {
+			continue
+		}
+		matches = append(matches, pat.FindCalls(fn)...)
+	}
+	sort.Sort(byPos(matches))
+	if fCount {
+		fmt.Println(len(matches))
+		return len(matches)
+	}
+	for _, m := range matches {
+		fmt.Printf("%s: %s\n", position(lprog.Fset, m.Pos()), m.Call.Common())
+	}
+	return len(matches)
+}
+
+type byPos []pattern.CallMatch
+
+func (s byPos) Len() int           { return len(s) }
+func (s byPos) Less(i, j int) bool { return s[i].Pos() < s[j].Pos() }
+func (s byPos) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
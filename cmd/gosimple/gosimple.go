@@ -9,10 +9,8 @@ import (
 
 func main() {
 	fs := lintutil.FlagSet("gosimple")
-	gen := fs.Bool("generated", false, "Check generated code")
 	fs.Parse(os.Args[1:])
 	c := simple.NewChecker()
-	c.CheckGenerated = *gen
 
 	lintutil.ProcessFlagSet(c, fs)
 }
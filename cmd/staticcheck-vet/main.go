@@ -0,0 +1,30 @@
+// staticcheck-vet runs the checks of staticcheck, gosimple and unused
+// as a vet tool:
+//
+//	go vet -vettool=$(which staticcheck-vet) ./...
+package main // import "honnef.co/go/tools/cmd/staticcheck-vet"
+
+import (
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/lint/lintanalysis"
+	"honnef.co/go/tools/simple"
+	"honnef.co/go/tools/staticcheck"
+	"honnef.co/go/tools/unused"
+)
+
+func main() {
+	var analyzers []*analysis.Analyzer
+	analyzers = append(analyzers, lintanalysis.Analyzers("staticcheck", func() lint.Checker {
+		return staticcheck.NewChecker()
+	})...)
+	analyzers = append(analyzers, lintanalysis.Analyzers("gosimple", func() lint.Checker {
+		return simple.NewChecker()
+	})...)
+	analyzers = append(analyzers, lintanalysis.Analyzers("unused", func() lint.Checker {
+		return unused.NewLintChecker(unused.NewChecker(unused.CheckAll))
+	})...)
+	unitchecker.Main(analyzers...)
+}
@@ -3,17 +3,106 @@
 package main // import "honnef.co/go/tools/cmd/staticcheck"
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"honnef.co/go/tools/lint/lintutil"
 	"honnef.co/go/tools/staticcheck"
+	"honnef.co/go/tools/staticcheck/vrp"
+	"honnef.co/go/tools/taint"
 )
 
 func main() {
 	fs := lintutil.FlagSet("staticcheck")
-	gen := fs.Bool("generated", false, "Check generated code")
+	printfFuncs := fs.String("printf.funcs", "", "Comma-separated list of additional printf-style `functions`, such as (*example.com/log.Logger).Infof")
+	decoders := fs.String("decode.funcs", "", "Comma-separated list of additional `functions` that decode external input into their pointer arguments, such as (*example.com/rpc.Conn).ReadRequest")
+	resources := fs.String("resources", "", "Comma-separated list of additional `resources` that have to be released, each written as constructor:releaser[:releaser...], such as (*example.com/pool.Pool).Get:Release")
+	structTags := fs.String("structtags", "", "Read additional struct tag schemas from `file`, a JSON array of objects with the fields Name, Named, Required, Options and Keys")
+	taintConfig := fs.String("taint", "", "Read additional taint sources, sinks and sanitizers from `file`, a JSON object with the fields SourceFuncs, SourceTypes, Sanitizers and Sinks")
+	vrpThresholds := fs.String("vrp.thresholds", "", "Comma-separated list of additional `integers` that value range analysis widens bounds to, such as limits of loops")
+	vrpIterations := fs.Int("vrp.iterations", 0, "Limit the work of value range analysis to `n` steps per loop, trading precision for speed; 0 means no limit")
+	debugVRP := fs.String("debug.vrp", "", "Write the vrp constraint graph of `function` to standard error, in Graphviz format")
 	fs.Parse(os.Args[1:])
 	c := staticcheck.NewChecker()
-	c.CheckGenerated = *gen
+	if *printfFuncs != "" {
+		c.PrintfWrappers = strings.Split(*printfFuncs, ",")
+	}
+	if *decoders != "" {
+		c.Decoders = strings.Split(*decoders, ",")
+	}
+	if *resources != "" {
+		for _, res := range strings.Split(*resources, ",") {
+			fields := strings.Split(res, ":")
+			c.Resources = append(c.Resources, staticcheck.Resource{
+				Constructor: fields[0],
+				Releasers:   fields[1:],
+			})
+		}
+	}
+	if *structTags != "" {
+		schemas, err := readTagSchemas(*structTags)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		c.StructTags = schemas
+	}
+	if *taintConfig != "" {
+		conf, err := readTaintConfig(*taintConfig)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		c.Taint = conf
+	}
+	if *vrpThresholds != "" {
+		for _, s := range strings.Split(*vrpThresholds, ",") {
+			n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid -vrp.thresholds value %q\n", s)
+				os.Exit(1)
+			}
+			c.VRPThresholds = append(c.VRPThresholds, vrp.NewZ(n))
+		}
+	}
+	c.VRPMaxIterations = *vrpIterations
+	c.DebugVRP = *debugVRP
+	c.DebugVRPOutput = os.Stderr
 	lintutil.ProcessFlagSet(c, fs)
 }
+
+func readTagSchemas(path string) ([]staticcheck.TagSchema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var schemas []staticcheck.TagSchema
+	if err := json.NewDecoder(f).Decode(&schemas); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %s", path, err)
+	}
+	return schemas, nil
+}
+
+// readTaintConfig reads a taint configuration from path and adds it
+// to the default one.
+func readTaintConfig(path string) (*taint.Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var extra taint.Config
+	if err := json.NewDecoder(f).Decode(&extra); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %s", path, err)
+	}
+	conf := taint.DefaultConfig()
+	conf.SourceFuncs = append(conf.SourceFuncs, extra.SourceFuncs...)
+	conf.SourceTypes = append(conf.SourceTypes, extra.SourceTypes...)
+	conf.Sanitizers = append(conf.Sanitizers, extra.Sanitizers...)
+	conf.Sinks = append(conf.Sinks, extra.Sinks...)
+	return conf, nil
+}
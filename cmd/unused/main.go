@@ -3,11 +3,19 @@
 package main // import "honnef.co/go/tools/cmd/unused"
 
 import (
+	"go/build"
+	"go/token"
 	"log"
 	"os"
+	"sort"
+	"strings"
 
+	"honnef.co/go/tools/lint"
 	"honnef.co/go/tools/lint/lintutil"
 	"honnef.co/go/tools/unused"
+
+	"github.com/kisielk/gotool"
+	"golang.org/x/tools/refactor/importgraph"
 )
 
 var (
@@ -19,6 +27,9 @@ var (
 	fDebug        string
 	fWholeProgram bool
 	fReflection   bool
+	fRdeps        bool
+	fConfigs      string
+	fFrameworks   string
 )
 
 func newChecker(mode unused.CheckMode) *unused.Checker {
@@ -34,6 +45,17 @@ func newChecker(mode unused.CheckMode) *unused.Checker {
 
 	checker.WholeProgram = fWholeProgram
 	checker.ConsiderReflection = fReflection
+	checker.Frameworks = nil
+	for _, name := range strings.Split(fFrameworks, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := unused.ReflectionFrameworks[name]; !ok {
+			log.Fatalf("unknown framework %q", name)
+		}
+		checker.Frameworks = append(checker.Frameworks, name)
+	}
 	return checker
 }
 
@@ -49,6 +71,10 @@ func main() {
 	fs.StringVar(&fDebug, "debug", "", "Write a debug graph to `file`. Existing files will be overwritten.")
 	fs.BoolVar(&fWholeProgram, "exported", false, "Treat arguments as a program and report unused exported identifiers")
 	fs.BoolVar(&fReflection, "reflect", true, "Consider identifiers as used when it's likely they'll be accessed via reflection")
+	fs.BoolVar(&fRdeps, "rdeps", false, "Check the arguments together with their reverse dependencies in GOPATH and report exported identifiers that none of them use. Implies -exported")
+	fs.StringVar(&fConfigs, "configs", "", "Check the packages under each of these whitespace-separated build `configurations`, written as goos/goarch[,tag...], and only report identifiers that are unused under all of them")
+	fs.StringVar(&fFrameworks, "frameworks", strings.Join(unused.NewChecker(0).Frameworks, ","),
+		"Comma-separated list of `frameworks` whose use of reflection -reflect considers")
 	fs.Parse(os.Args[1:])
 
 	var mode unused.CheckMode
@@ -68,7 +94,81 @@ func main() {
 		mode |= unused.CheckVariables
 	}
 
-	checker := newChecker(mode)
-	l := unused.NewLintChecker(checker)
-	lintutil.ProcessFlagSet(l, fs)
+	var importers map[string]bool
+	if fRdeps {
+		rdeps := reverseDependencies(fs.Lookup("tags").Value.String(), fs.Args())
+		importers = map[string]bool{}
+		for _, rdep := range rdeps {
+			importers[rdep] = true
+		}
+		// Load the reverse dependencies alongside the arguments.
+		args := append([]string(nil), os.Args[1:len(os.Args)-fs.NArg()]...)
+		pkgs := fs.Args()
+		if len(pkgs) == 0 {
+			pkgs = []string{"."}
+		}
+		fs.Parse(append(append(args, pkgs...), rdeps...))
+	}
+	var checkers []*unused.LintChecker
+	newLintChecker := func() lint.Checker {
+		checker := newChecker(mode)
+		if fRdeps {
+			checker.WholeProgram = true
+			checker.Importers = importers
+		}
+		l := unused.NewLintChecker(checker)
+		checkers = append(checkers, l)
+		return l
+	}
+	var opts lintutil.Options
+	if fConfigs != "" {
+		configs, err := lintutil.ParseBuildConfigs(fConfigs)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Configs = configs
+	}
+	opts.Object = func(pos token.Position) (interface{}, bool) {
+		for _, l := range checkers {
+			if obj, ok := l.Object(pos); ok {
+				return obj, true
+			}
+		}
+		return nil, false
+	}
+	lintutil.ProcessFlagSetOptions(newLintChecker, fs, opts)
+}
+
+// reverseDependencies returns the packages in GOPATH that directly
+// import any of the packages matched by args, not counting the
+// matched packages themselves.
+func reverseDependencies(tags string, args []string) []string {
+	ctx := build.Default
+	ctx.BuildTags = strings.Fields(tags)
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	checked := map[string]bool{}
+	for _, path := range gotool.ImportPaths(args) {
+		bpkg, err := ctx.Import(path, wd, build.FindOnly)
+		if err != nil {
+			continue
+		}
+		checked[bpkg.ImportPath] = true
+	}
+	_, reverse, _ := importgraph.Build(&ctx)
+	var rdeps []string
+	seen := map[string]bool{}
+	for path := range checked {
+		for rdep := range reverse[path] {
+			if checked[rdep] || seen[rdep] {
+				continue
+			}
+			seen[rdep] = true
+			rdeps = append(rdeps, rdep)
+		}
+	}
+	sort.Strings(rdeps)
+	return rdeps
 }
@@ -0,0 +1,679 @@
+// Package concurrency models the goroutines, channel operations and
+// mutexes of a function and approximates the happens-before relation
+// between the operations of different goroutines.
+//
+// The analysis is intraprocedural: it starts at a function and
+// follows go statements into the functions they spawn, but it doesn't
+// look into other calls. Objects (channels, mutexes, wait groups and
+// variables) are identified by the SSA values they originate from,
+// with free variables and parameters of spawned functions mapped back
+// to the values that were bound to them.
+//
+// The happens-before relation is conservative in the sense that it
+// only reports orderings that are guaranteed by the Go memory model,
+// according to what the analysis understands. Goroutines started in
+// loops are modeled as a single goroutine.
+package concurrency // import "honnef.co/go/tools/concurrency"
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+
+	"honnef.co/go/tools/ssa"
+)
+
+// maxDepth limits the number of synchronization edges followed when
+// computing the happens-before relation.
+const maxDepth = 8
+
+// A Goroutine is a function running in its own goroutine.
+type Goroutine struct {
+	Fn *ssa.Function
+	// Parent is the goroutine that started this goroutine, or nil
+	// for the function the analysis started at.
+	Parent *Goroutine
+	// Spawn is the go statement that started the goroutine, or nil
+	// for the function the analysis started at.
+	Spawn *ssa.Go
+	// InLoop reports whether the go statement is part of a loop,
+	// meaning that multiple instances of the goroutine may run
+	// concurrently.
+	InLoop   bool
+	Children []*Goroutine
+
+	bindings map[ssa.Value]ssa.Value
+}
+
+func (g *Goroutine) String() string {
+	return g.Fn.String()
+}
+
+// OpKind is the kind of a synchronizing operation.
+type OpKind int
+
+const (
+	Send OpKind = iota
+	Recv
+	Close
+	Lock
+	Unlock
+	Done
+	Wait
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case Send:
+		return "send"
+	case Recv:
+		return "receive"
+	case Close:
+		return "close"
+	case Lock:
+		return "lock"
+	case Unlock:
+		return "unlock"
+	case Done:
+		return "done"
+	case Wait:
+		return "wait"
+	default:
+		return fmt.Sprintf("OpKind(%d)", int(k))
+	}
+}
+
+// An Object identifies a channel, mutex, wait group or variable.
+// Root is the SSA value the object originates from and Path describes
+// how the object was derived from it: '*' for a load and '.N' for the
+// address of the Nth field.
+type Object struct {
+	Root ssa.Value
+	Path string
+}
+
+func (obj Object) String() string {
+	return obj.Root.Name() + obj.Path
+}
+
+// An Op is a synchronizing operation.
+type Op struct {
+	Kind      OpKind
+	Instr     ssa.Instruction
+	Object    Object
+	Goroutine *Goroutine
+	// Deferred reports whether the operation is executed by a
+	// deferred call, i.e. when the goroutine's function returns.
+	Deferred bool
+}
+
+// An Access is a load or store of a variable that is shared between
+// goroutines.
+type Access struct {
+	Instr     ssa.Instruction
+	Variable  *ssa.Alloc
+	Write     bool
+	Goroutine *Goroutine
+}
+
+// Analysis is the result of analyzing a function.
+type Analysis struct {
+	// Goroutines are all goroutines, starting with the analyzed
+	// function.
+	Goroutines []*Goroutine
+	Ops        []*Op
+	// Accesses are the loads and stores of local variables that
+	// have been captured by at least one spawned goroutine.
+	Accesses []*Access
+
+	goroutines map[*ssa.Function]*Goroutine
+	index      map[ssa.Instruction]int
+	assigned   map[*ssa.Alloc]ssa.Value
+}
+
+// Analyze analyzes fn and all goroutines it spawns, directly or
+// indirectly.
+func Analyze(fn *ssa.Function) *Analysis {
+	a := &Analysis{
+		goroutines: map[*ssa.Function]*Goroutine{},
+		index:      map[ssa.Instruction]int{},
+		assigned:   map[*ssa.Alloc]ssa.Value{},
+	}
+	a.addGoroutine(&Goroutine{Fn: fn})
+	for _, g := range a.Goroutines {
+		a.collectOps(g)
+	}
+	a.collectAccesses()
+	return a
+}
+
+func (a *Analysis) addGoroutine(g *Goroutine) {
+	if _, ok := a.goroutines[g.Fn]; ok || g.Fn.Blocks == nil {
+		// Either the function has no body, or it is started by more
+		// than one go statement, which we don't model.
+		return
+	}
+	a.goroutines[g.Fn] = g
+	a.Goroutines = append(a.Goroutines, g)
+	if g.Parent != nil {
+		g.Parent.Children = append(g.Parent.Children, g)
+	}
+	for _, b := range g.Fn.Blocks {
+		for i, instr := range b.Instrs {
+			a.index[instr] = i
+		}
+	}
+	for _, b := range g.Fn.Blocks {
+		for _, instr := range b.Instrs {
+			gostmt, ok := instr.(*ssa.Go)
+			if !ok {
+				continue
+			}
+			child := &Goroutine{
+				Parent:   g,
+				Spawn:    gostmt,
+				InLoop:   inLoop(b),
+				bindings: map[ssa.Value]ssa.Value{},
+			}
+			switch v := gostmt.Call.Value.(type) {
+			case *ssa.MakeClosure:
+				child.Fn = v.Fn.(*ssa.Function)
+				for i, fv := range child.Fn.FreeVars {
+					child.bindings[fv] = v.Bindings[i]
+				}
+			case *ssa.Function:
+				child.Fn = v
+			default:
+				continue
+			}
+			if gostmt.Call.IsInvoke() {
+				continue
+			}
+			for i, param := range child.Fn.Params {
+				if i < len(gostmt.Call.Args) {
+					child.bindings[param] = gostmt.Call.Args[i]
+				}
+			}
+			a.addGoroutine(child)
+		}
+	}
+}
+
+// inLoop reports whether b is part of a cycle in the control flow
+// graph.
+func inLoop(b *ssa.BasicBlock) bool {
+	seen := map[*ssa.BasicBlock]bool{}
+	var search func(*ssa.BasicBlock) bool
+	search = func(x *ssa.BasicBlock) bool {
+		if x == b {
+			return true
+		}
+		if seen[x] {
+			return false
+		}
+		seen[x] = true
+		for _, succ := range x.Succs {
+			if search(succ) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, succ := range b.Succs {
+		if search(succ) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Analysis) collectOps(g *Goroutine) {
+	add := func(kind OpKind, instr ssa.Instruction, v ssa.Value, deferred bool) {
+		a.Ops = append(a.Ops, &Op{
+			Kind:      kind,
+			Instr:     instr,
+			Object:    a.Object(g, v),
+			Goroutine: g,
+			Deferred:  deferred,
+		})
+	}
+	for _, b := range g.Fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch instr := instr.(type) {
+			case *ssa.Send:
+				add(Send, instr, instr.Chan, false)
+			case *ssa.UnOp:
+				if instr.Op == token.ARROW {
+					add(Recv, instr, instr.X, false)
+				}
+			case *ssa.Select:
+				for _, state := range instr.States {
+					if state.Dir == types.SendOnly {
+						add(Send, instr, state.Chan, false)
+					} else {
+						add(Recv, instr, state.Chan, false)
+					}
+				}
+			case *ssa.Call:
+				if kind, v, ok := callOp(&instr.Call); ok {
+					add(kind, instr, v, false)
+				}
+			case *ssa.Defer:
+				if kind, v, ok := callOp(&instr.Call); ok {
+					add(kind, instr, v, true)
+				}
+			}
+		}
+	}
+}
+
+func callOp(call *ssa.CallCommon) (OpKind, ssa.Value, bool) {
+	if builtin, ok := call.Value.(*ssa.Builtin); ok {
+		if builtin.Name() == "close" && len(call.Args) == 1 {
+			return Close, call.Args[0], true
+		}
+		return 0, nil, false
+	}
+	fn := call.StaticCallee()
+	if fn == nil || len(call.Args) == 0 {
+		return 0, nil, false
+	}
+	switch fn.RelString(nil) {
+	case "(*sync.Mutex).Lock", "(*sync.RWMutex).Lock", "(*sync.RWMutex).RLock":
+		return Lock, call.Args[0], true
+	case "(*sync.Mutex).Unlock", "(*sync.RWMutex).Unlock", "(*sync.RWMutex).RUnlock":
+		return Unlock, call.Args[0], true
+	case "(*sync.WaitGroup).Done":
+		return Done, call.Args[0], true
+	case "(*sync.WaitGroup).Wait":
+		return Wait, call.Args[0], true
+	}
+	return 0, nil, false
+}
+
+func (a *Analysis) collectAccesses() {
+	captured := map[*ssa.Alloc]bool{}
+	for _, g := range a.Goroutines {
+		if g.Parent == nil {
+			continue
+		}
+		for _, fv := range g.Fn.FreeVars {
+			obj := a.Object(g, fv)
+			if alloc, ok := obj.Root.(*ssa.Alloc); ok && obj.Path == "" {
+				captured[alloc] = true
+			}
+		}
+	}
+	if len(captured) == 0 {
+		return
+	}
+	for _, g := range a.Goroutines {
+		for _, b := range g.Fn.Blocks {
+			for _, instr := range b.Instrs {
+				var addr ssa.Value
+				write := false
+				switch instr := instr.(type) {
+				case *ssa.UnOp:
+					if instr.Op != token.MUL {
+						continue
+					}
+					addr = instr.X
+				case *ssa.Store:
+					addr = instr.Addr
+					write = true
+				default:
+					continue
+				}
+				obj := a.Object(g, addr)
+				alloc, ok := obj.Root.(*ssa.Alloc)
+				if !ok || obj.Path != "" || !captured[alloc] {
+					continue
+				}
+				a.Accesses = append(a.Accesses, &Access{
+					Instr:     instr,
+					Variable:  alloc,
+					Write:     write,
+					Goroutine: g,
+				})
+			}
+		}
+	}
+}
+
+// Object returns the object that v, a value in goroutine g, refers
+// to.
+func (a *Analysis) Object(g *Goroutine, v ssa.Value) Object {
+	for g != nil {
+		if bound, ok := g.bindings[v]; ok {
+			v = bound
+			g = g.Parent
+			continue
+		}
+		switch w := v.(type) {
+		case *ssa.ChangeType:
+			v = w.X
+			continue
+		case *ssa.Sigma:
+			v = w.X
+			continue
+		case *ssa.MakeInterface:
+			v = w.X
+			continue
+		case *ssa.UnOp:
+			if w.Op != token.MUL {
+				break
+			}
+			obj := a.Object(g, w.X)
+			if alloc, ok := obj.Root.(*ssa.Alloc); ok && obj.Path == "" {
+				if val, ok := a.assignedOnce(alloc); ok {
+					// Loading from a variable that is only ever
+					// assigned once always yields the same value.
+					return a.Object(a.goroutines[alloc.Parent()], val)
+				}
+			}
+			obj.Path += "*"
+			return obj
+		case *ssa.FieldAddr:
+			obj := a.Object(g, w.X)
+			obj.Path += fmt.Sprintf(".%d", w.Field)
+			return obj
+		}
+		break
+	}
+	return Object{Root: v}
+}
+
+// assignedOnce returns the value that is stored in alloc if it is
+// the only value ever stored in it and the variable's address
+// doesn't escape.
+func (a *Analysis) assignedOnce(alloc *ssa.Alloc) (ssa.Value, bool) {
+	if val, ok := a.assigned[alloc]; ok {
+		return val, val != nil
+	}
+	var val ssa.Value
+	stores := 0
+	escapes := false
+	var visit func(addr ssa.Value)
+	visit = func(addr ssa.Value) {
+		refs := addr.Referrers()
+		if refs == nil {
+			escapes = true
+			return
+		}
+		for _, ref := range *refs {
+			switch ref := ref.(type) {
+			case *ssa.UnOp:
+				if ref.Op != token.MUL {
+					escapes = true
+				}
+			case *ssa.Store:
+				if ref.Addr != addr {
+					escapes = true
+				}
+				stores++
+				val = ref.Val
+			case *ssa.DebugRef:
+			case *ssa.MakeClosure:
+				fn := ref.Fn.(*ssa.Function)
+				for i, b := range ref.Bindings {
+					if b == addr {
+						visit(fn.FreeVars[i])
+					}
+				}
+			default:
+				escapes = true
+			}
+		}
+	}
+	visit(alloc)
+	if escapes || stores != 1 {
+		val = nil
+	}
+	a.assigned[alloc] = val
+	return val, val != nil
+}
+
+// Escapes reports whether obj, a channel created by one of the
+// analyzed goroutines, may be used by code that the analysis doesn't
+// see. This is the case when it is passed to a function other than
+// one started as a goroutine, stored anywhere but in a local
+// variable, or converted in ways the analysis doesn't follow.
+func (a *Analysis) Escapes(obj Object) bool {
+	for _, g := range a.Goroutines {
+		var vals []ssa.Value
+		for _, p := range g.Fn.Params {
+			vals = append(vals, p)
+		}
+		for _, fv := range g.Fn.FreeVars {
+			vals = append(vals, fv)
+		}
+		for _, b := range g.Fn.Blocks {
+			for _, instr := range b.Instrs {
+				if v, ok := instr.(ssa.Value); ok {
+					vals = append(vals, v)
+				}
+			}
+		}
+		for _, v := range vals {
+			if a.Object(g, v) == obj && a.valueEscapes(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *Analysis) valueEscapes(v ssa.Value) bool {
+	refs := v.Referrers()
+	if refs == nil {
+		return true
+	}
+	for _, ref := range *refs {
+		switch ref := ref.(type) {
+		case *ssa.DebugRef, *ssa.ChangeType, *ssa.MakeInterface, *ssa.Sigma:
+			// Conversions and σ-nodes refer to the same object and
+			// are checked on their own.
+		case *ssa.UnOp:
+			if ref.Op != token.ARROW {
+				return true
+			}
+		case *ssa.Send:
+			if ref.X == v {
+				return true
+			}
+		case *ssa.Select:
+			for _, state := range ref.States {
+				if state.Send == v {
+					return true
+				}
+			}
+		case *ssa.Store:
+			alloc, ok := ref.Addr.(*ssa.Alloc)
+			if !ok || ref.Val != v {
+				return true
+			}
+			if _, ok := a.assignedOnce(alloc); !ok {
+				return true
+			}
+		case *ssa.Call:
+			if _, _, ok := callOp(&ref.Call); !ok {
+				if !isBuiltin(&ref.Call, "len", "cap") {
+					return true
+				}
+			}
+		case *ssa.Defer:
+			if kind, _, ok := callOp(&ref.Call); !ok || kind != Close {
+				return true
+			}
+		case *ssa.Go:
+			if !a.spawns(ref) {
+				return true
+			}
+		case *ssa.MakeClosure:
+			closure := ref
+			crefs := closure.Referrers()
+			if crefs == nil || len(*crefs) == 0 {
+				return true
+			}
+			for _, cref := range *crefs {
+				gostmt, ok := cref.(*ssa.Go)
+				if !ok || gostmt.Call.Value != closure || !a.spawns(gostmt) {
+					return true
+				}
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// spawns reports whether gostmt starts one of the analyzed
+// goroutines.
+func (a *Analysis) spawns(gostmt *ssa.Go) bool {
+	for _, g := range a.Goroutines {
+		if g.Spawn == gostmt {
+			return true
+		}
+	}
+	return false
+}
+
+func isBuiltin(call *ssa.CallCommon, names ...string) bool {
+	builtin, ok := call.Value.(*ssa.Builtin)
+	if !ok {
+		return false
+	}
+	for _, name := range names {
+		if builtin.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Goroutine returns the goroutine that executes instr, or nil if the
+// instruction isn't part of the analysis.
+func (a *Analysis) Goroutine(instr ssa.Instruction) *Goroutine {
+	return a.goroutines[instr.Parent()]
+}
+
+// precedes reports whether x, if it executes, does so before y, which
+// must be part of the same function. This is the case if x dominates y.
+func (a *Analysis) precedes(x, y ssa.Instruction) bool {
+	bx, by := x.Block(), y.Block()
+	if bx == by {
+		return a.index[x] < a.index[y]
+	}
+	return bx.Dominates(by)
+}
+
+func isRelease(kind OpKind) bool {
+	return kind == Send || kind == Close || kind == Done
+}
+
+func pairs(release, acquire OpKind) bool {
+	switch release {
+	case Send, Close:
+		return acquire == Recv
+	case Done:
+		return acquire == Wait
+	}
+	return false
+}
+
+// HappensBefore reports whether x is guaranteed to happen before y.
+//
+// Within a single goroutine, x happens before y if x dominates y.
+// Across goroutines, x happens before y if x happens before the go
+// statement that started y's goroutine, or if x happens before an
+// operation that synchronizes with another operation that in turn
+// happens before y. Channel sends and closes synchronize with
+// receives, and calls to WaitGroup.Done synchronize with calls to
+// WaitGroup.Wait.
+func (a *Analysis) HappensBefore(x, y ssa.Instruction) bool {
+	return a.happensBefore(x, y, 0)
+}
+
+func (a *Analysis) happensBefore(x, y ssa.Instruction, depth int) bool {
+	if depth > maxDepth {
+		return false
+	}
+	gx, gy := a.Goroutine(x), a.Goroutine(y)
+	if gx == nil || gy == nil {
+		return false
+	}
+	if gx == gy {
+		return x != y && a.precedes(x, y)
+	}
+	for g := gy; g.Parent != nil; g = g.Parent {
+		if g.Parent == gx {
+			return a.precedes(x, g.Spawn)
+		}
+	}
+	for _, rel := range a.Ops {
+		if rel.Goroutine != gx || !isRelease(rel.Kind) {
+			continue
+		}
+		if !rel.Deferred && rel.Instr != x && !a.precedes(x, rel.Instr) {
+			continue
+		}
+		for _, acq := range a.Ops {
+			if acq.Goroutine == gx || !pairs(rel.Kind, acq.Kind) || acq.Object != rel.Object {
+				continue
+			}
+			if acq.Instr == y || a.happensBefore(acq.Instr, y, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LocksHeld returns the mutexes that are held when instr executes.
+// A mutex is held if a call to Lock precedes the instruction and no
+// call to Unlock comes between the two.
+func (a *Analysis) LocksHeld(instr ssa.Instruction) []Object {
+	g := a.Goroutine(instr)
+	var out []Object
+	for _, lock := range a.Ops {
+		if lock.Kind != Lock || lock.Goroutine != g || lock.Deferred || !a.precedes(lock.Instr, instr) {
+			continue
+		}
+		released := false
+		for _, unlock := range a.Ops {
+			if unlock.Kind != Unlock || unlock.Goroutine != g || unlock.Deferred || unlock.Object != lock.Object {
+				continue
+			}
+			if a.precedes(lock.Instr, unlock.Instr) && a.precedes(unlock.Instr, instr) {
+				released = true
+				break
+			}
+		}
+		if !released {
+			out = append(out, lock.Object)
+		}
+	}
+	return out
+}
+
+// Concurrent reports whether x and y may execute concurrently, i.e.
+// whether neither happens before the other and they aren't protected
+// by a common mutex.
+func (a *Analysis) Concurrent(x, y ssa.Instruction) bool {
+	gx, gy := a.Goroutine(x), a.Goroutine(y)
+	if gx == gy && !gx.InLoop {
+		return false
+	}
+	if gx != gy && (a.HappensBefore(x, y) || a.HappensBefore(y, x)) {
+		return false
+	}
+	for _, lx := range a.LocksHeld(x) {
+		for _, ly := range a.LocksHeld(y) {
+			if lx == ly {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,212 @@
+package concurrency_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/loader"
+	"honnef.co/go/tools/concurrency"
+	"honnef.co/go/tools/ssa"
+	"honnef.co/go/tools/ssa/ssautil"
+)
+
+var markerRe = regexp.MustCompile(`@(\w+)`)
+
+func TestAnalyze(t *testing.T) {
+	conf := loader.Config{ParserMode: parser.ParseComments}
+	f, err := conf.ParseFile("testdata/concurrency.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := ssautil.CreateProgram(iprog, 0)
+	pkg := prog.Package(iprog.Created[0].Pkg)
+	pkg.Build()
+	fset := iprog.Fset
+
+	// the positions of the markers
+	markers := map[string][]token.Pos{}
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			if m := markerRe.FindStringSubmatch(c.Text); m != nil {
+				markers[m[1]] = append(markers[m[1]], c.Pos())
+			}
+		}
+	}
+
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		fn := pkg.Func(decl.Name.Name)
+		var wants []string
+		for _, cg := range f.Comments {
+			if cg.Pos() < decl.Pos() || cg.Pos() >= decl.End() {
+				continue
+			}
+			for _, line := range strings.Split(cg.Text(), "\n") {
+				if strings.HasPrefix(line, "want ") {
+					wants = append(wants, strings.TrimPrefix(line, "want "))
+				}
+			}
+		}
+		if len(wants) == 0 {
+			t.Errorf("%s: no expectations", fn)
+			continue
+		}
+		c := &analysisTest{t: t, fn: fn, a: concurrency.Analyze(fn), fset: fset, markers: markers}
+		for _, want := range wants {
+			c.check(want)
+		}
+	}
+}
+
+type analysisTest struct {
+	t       *testing.T
+	fn      *ssa.Function
+	a       *concurrency.Analysis
+	fset    *token.FileSet
+	markers map[string][]token.Pos
+}
+
+// instr returns the instruction marked by name: the first operation
+// on the marker's line, or else the first access of a shared
+// variable.
+func (c *analysisTest) instr(name string) ssa.Instruction {
+	line := 0
+	for _, pos := range c.markers[name] {
+		if pos >= c.fn.Syntax().Pos() && pos < c.fn.Syntax().End() {
+			line = c.fset.Position(pos).Line
+		}
+	}
+	if line == 0 {
+		c.t.Fatalf("%s: no marker @%s", c.fn, name)
+	}
+	for _, op := range c.a.Ops {
+		if c.inFunc(op.Instr) && c.fset.Position(op.Instr.Pos()).Line == line {
+			return op.Instr
+		}
+	}
+	for _, acc := range c.a.Accesses {
+		if c.inFunc(acc.Instr) && c.fset.Position(acc.Instr.Pos()).Line == line {
+			return acc.Instr
+		}
+	}
+	c.t.Fatalf("%s: no operation or access at @%s on line %d", c.fn, name, line)
+	return nil
+}
+
+// inFunc reports whether instr belongs to the analyzed function or
+// one of its anonymous functions.
+func (c *analysisTest) inFunc(instr ssa.Instruction) bool {
+	pos := instr.Parent().Pos()
+	return pos >= c.fn.Syntax().Pos() && pos < c.fn.Syntax().End()
+}
+
+func (c *analysisTest) objectNames(objs []concurrency.Object) []string {
+	var out []string
+	for _, obj := range objs {
+		out = append(out, c.objectName(obj))
+	}
+	return out
+}
+
+// objectName returns the name of the local variable or channel obj
+// is derived from.
+func (c *analysisTest) objectName(obj concurrency.Object) string {
+	if alloc, ok := obj.Root.(*ssa.Alloc); ok && alloc.Comment != "" {
+		return alloc.Comment + strings.TrimPrefix(obj.Path, "*")
+	}
+	for _, b := range c.fn.Blocks {
+		for _, instr := range b.Instrs {
+			store, ok := instr.(*ssa.Store)
+			if !ok || store.Val != obj.Root {
+				continue
+			}
+			if alloc, ok := store.Addr.(*ssa.Alloc); ok && alloc.Comment != "" {
+				return alloc.Comment + obj.Path
+			}
+		}
+	}
+	return obj.String()
+}
+
+func (c *analysisTest) check(want string) {
+	t := c.t
+	switch {
+	case strings.HasPrefix(want, "goroutines:"):
+		var got []string
+		for _, g := range c.a.Goroutines[1:] {
+			s := g.Fn.Name()
+			if g.InLoop {
+				s += "*"
+			}
+			got = append(got, s)
+		}
+		if s := strings.Join(got, ", "); s != strings.TrimSpace(strings.TrimPrefix(want, "goroutines:")) {
+			t.Errorf("%s: got goroutines %q, %s", c.fn, s, want)
+		}
+	case strings.HasPrefix(want, "ops:"):
+		var got []string
+		for _, op := range c.a.Ops {
+			got = append(got, op.Kind.String()+" "+c.objectName(op.Object))
+		}
+		if s := strings.Join(got, ", "); s != strings.TrimSpace(strings.TrimPrefix(want, "ops:")) {
+			t.Errorf("%s: got ops %q, %s", c.fn, s, want)
+		}
+	case strings.HasPrefix(want, "locks "):
+		parts := strings.SplitN(strings.TrimPrefix(want, "locks "), ":", 2)
+		got := strings.Join(c.objectNames(c.a.LocksHeld(c.instr(parts[0]))), ", ")
+		if got != strings.TrimSpace(parts[1]) {
+			t.Errorf("%s: got locks %q at @%s, %s", c.fn, got, parts[0], want)
+		}
+	case strings.HasPrefix(want, "escapes "), strings.HasPrefix(want, "!escapes "):
+		fields := strings.Fields(want)
+		instr := c.instr(fields[1])
+		var obj concurrency.Object
+		found := false
+		for _, op := range c.a.Ops {
+			if op.Instr == instr {
+				obj, found = op.Object, true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s: no operation at @%s", c.fn, fields[1])
+			return
+		}
+		if got := c.a.Escapes(obj); got != (fields[0] == "escapes") {
+			t.Errorf("%s: Escapes(%s) = %t, %s", c.fn, fields[1], got, want)
+		}
+	default:
+		fields := strings.Fields(want)
+		if len(fields) != 3 {
+			t.Errorf("%s: malformed expectation %q", c.fn, want)
+			return
+		}
+		x, y := c.instr(fields[0]), c.instr(fields[2])
+		var got bool
+		rel := strings.TrimPrefix(fields[1], "!")
+		switch rel {
+		case "before":
+			got = c.a.HappensBefore(x, y)
+		case "concurrent":
+			got = c.a.Concurrent(x, y)
+		default:
+			t.Errorf("%s: unknown relation in %q", c.fn, want)
+			return
+		}
+		if got != !strings.HasPrefix(fields[1], "!") {
+			t.Errorf("%s: %s %s %s is %t, %s", c.fn, fields[0], rel, fields[2], got, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// +build ignore
+
+package main
+
+// This file is the input to TestAnalyze in concurrency_test.go.
+//
+// A comment '// @name' marks the synchronizing operation, or else the
+// access of a shared variable, on its line. Comments starting with
+// 'want' in a function describe the analysis of that function:
+//
+//	want goroutines: g1, g2...     the spawned goroutines, in order;
+//	                               a trailing '*' means InLoop
+//	want ops: kind obj, ...        the operations, by goroutine
+//	want a before b                a happens before b
+//	want a !before b               a doesn't happen before b
+//	want a concurrent b            a and b may run concurrently
+//	want a !concurrent b           a and b can't run concurrently
+//	want locks a: obj, ...         the mutexes held at a
+//	want escapes a                 the object of the operation a escapes
+//	want !escapes a                the object of the operation a doesn't
+//	                               escape
+
+import "sync"
+
+func Spawn() {
+	// want goroutines: Spawn$1
+	// want a before b
+	// want b !before a
+	// want b concurrent c
+	x := 0
+	x = 1 // @a
+	go func() {
+		_ = x // @b
+	}()
+	x = 2 // @c
+}
+
+func Channel() {
+	// want goroutines: Channel$1
+	// want ops: receive ch, send ch
+	// want a before c
+	// want c !before a
+	// want a !concurrent c
+	// want !escapes r
+	ch := make(chan int)
+	x := 0
+	go func() {
+		x = 1   // @a
+		ch <- 1 // @b
+	}()
+	<-ch  // @r
+	_ = x // @c
+}
+
+func NoSync() {
+	// want a !before b
+	// want b !before a
+	// want a concurrent b
+	x := 0
+	go func() {
+		x = 1 // @a
+	}()
+	x = 2 // @b
+	_ = x
+}
+
+func Close() {
+	// want ops: receive done, close done
+	// want a before b
+	done := make(chan struct{})
+	x := 0
+	go func() {
+		defer close(done)
+		x = 1 // @a
+	}()
+	<-done
+	_ = x // @b
+}
+
+func WaitGroup() {
+	// want ops: wait wg, done wg
+	// want a before b
+	// want a !concurrent b
+	var wg sync.WaitGroup
+	x := 0
+	wg.Add(1)
+	go func() {
+		x = 1 // @a
+		wg.Done()
+	}()
+	wg.Wait()
+	_ = x // @b
+}
+
+func Mutex() {
+	// want ops: lock mu, unlock mu, lock mu, unlock mu
+	// want locks a: mu
+	// want locks c:
+	// want a !before b
+	// want a !concurrent b
+	// want c concurrent d
+	var mu sync.Mutex
+	x := 0
+	go func() {
+		mu.Lock()
+		x = 1 // @a
+		mu.Unlock()
+		x = 3 // @c
+	}()
+	mu.Lock()
+	x = 2 // @b
+	mu.Unlock()
+	_ = x // @d
+}
+
+func Loop(items []int) {
+	// want goroutines: Loop$1*
+	// want a concurrent a
+	n := 0
+	for range items {
+		go func() {
+			n++ // @a
+		}()
+	}
+}
+
+func Nested() {
+	// want goroutines: Nested$1, Nested$1$1
+	// want a before b
+	x := 0
+	x = 1 // @a
+	go func() {
+		go func() {
+			_ = x // @b
+		}()
+	}()
+}
+
+func Transitive() {
+	// want goroutines: Transitive$1, Transitive$2
+	// want a before b
+	// want b !before a
+	ch1 := make(chan int)
+	ch2 := make(chan int)
+	x := 0
+	go func() {
+		x = 1 // @a
+		ch1 <- 1
+	}()
+	go func() {
+		<-ch1
+		ch2 <- 1
+	}()
+	<-ch2
+	_ = x // @b
+}
+
+func Escapes(f func(chan int)) {
+	// want escapes r
+	ch := make(chan int)
+	f(ch)
+	<-ch // @r
+}
@@ -0,0 +1,36 @@
+// Package deprecated records when objects in the standard library
+// were deprecated.
+package deprecated // import "honnef.co/go/tools/deprecated"
+
+// A Deprecation describes when an object was deprecated. Versions are
+// minor versions of Go 1, with 0 meaning that the object was
+// deprecated before Go 1 or that no alternative exists.
+type Deprecation struct {
+	// DeprecatedSince is the version in which the object was
+	// deprecated.
+	DeprecatedSince int
+	// AlternativeAvailableSince is the version in which the
+	// recommended alternative became available.
+	AlternativeAvailableSince int
+}
+
+// Stdlib maps objects in the standard library to their deprecation.
+// Package-level objects are keyed by their path and name, such as
+// os.SEEK_SET, methods by their full name, such as
+// (*net/http.Transport).CancelRequest, and struct fields by their
+// struct's type and name, such as (net/http.Request).Cancel.
+var Stdlib = map[string]Deprecation{
+	"image/jpeg.Reader":                   {4, 0},
+	"syscall.StringByteSlice":             {1, 1},
+	"syscall.StringBytePtr":               {1, 1},
+	"compress/flate.ReadError":            {6, 6},
+	"compress/flate.WriteError":           {6, 6},
+	"(*net/http.Transport).CancelRequest": {6, 5},
+	"(net/http.Transport).Dial":           {7, 7},
+	"(net/http.Request).Cancel":           {7, 7},
+	"(net.Dialer).Cancel":                 {7, 7},
+	"net/http.ErrWriteAfterFlush":         {7, 0},
+	"os.SEEK_SET":                         {7, 7},
+	"os.SEEK_CUR":                         {7, 7},
+	"os.SEEK_END":                         {7, 7},
+}
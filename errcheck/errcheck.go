@@ -22,6 +22,12 @@ func (c *Checker) Funcs() map[string]lint.Func {
 	}
 }
 
+func (c *Checker) Versions() map[string]lint.CheckVersion {
+	return map[string]lint.CheckVersion{
+		"ERR1000": {Introduced: "2017.1"},
+	}
+}
+
 func (c *Checker) Init(prog *lint.Program) {
 	c.funcDescs = functions.NewDescriptions(prog.SSA)
 }
@@ -43,7 +43,7 @@ type Description struct {
 	// The function is known to never return (panics notwithstanding)
 	Infinite bool
 	// Variable ranges
-	Ranges vrp.Ranges
+	Ranges *Ranges
 	Loops  []Loop
 	// Function returns an error as its last argument, but it is
 	// always nil
@@ -51,6 +51,21 @@ type Description struct {
 	ConcreteReturnTypes []*types.Tuple
 }
 
+// Ranges computes the ranges of a function's values on demand. It is
+// safe for concurrent use.
+type Ranges struct {
+	mu sync.Mutex
+	g  *vrp.Graph
+}
+
+// Get returns the range of v, solving as much of the function's
+// constraint graph as is needed.
+func (r *Ranges) Get(v ssa.Value) vrp.Range {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.g.Query(v)
+}
+
 type descriptionEntry struct {
 	ready  chan struct{}
 	result Description
@@ -60,12 +75,27 @@ type Descriptions struct {
 	CallGraph *callgraph.Graph
 	mu        sync.Mutex
 	cache     map[*ssa.Function]*descriptionEntry
+	summaries *vrp.Summaries
+	// terminatesCache records which functions terminate, see
+	// terminates.
+	terminatesCache map[*ssa.Function]bool
+
+	// OnRanges, if set, is called with each function's vrp graph
+	// after it has been solved.
+	OnRanges func(fn *ssa.Function, g *vrp.Graph)
+	// Thresholds and MaxIterations configure the widening of all vrp
+	// graphs, see vrp.Graph. They must be set before the first call
+	// to Get.
+	Thresholds    []vrp.Z
+	MaxIterations int
 }
 
 func NewDescriptions(prog *ssa.Program) *Descriptions {
 	return &Descriptions{
-		CallGraph: static.CallGraph(prog),
-		cache:     map[*ssa.Function]*descriptionEntry{},
+		CallGraph:       static.CallGraph(prog),
+		cache:           map[*ssa.Function]*descriptionEntry{},
+		summaries:       vrp.NewSummaries(),
+		terminatesCache: map[*ssa.Function]bool{},
 	}
 }
 
@@ -82,8 +112,17 @@ func (d *Descriptions) Get(fn *ssa.Function) Description {
 		{
 			fd.result = stdlibDescs[fn.RelString(nil)]
 			fd.result.Pure = fd.result.Pure || d.IsPure(fn)
-			fd.result.Infinite = fd.result.Infinite || !terminates(fn)
-			fd.result.Ranges = vrp.BuildGraph(fn).Solve()
+			fd.result.Infinite = fd.result.Infinite || !d.terminates(fn)
+			g := vrp.BuildGraph(fn)
+			g.Wrap = true
+			g.Summaries = d.summaries
+			g.Thresholds = d.Thresholds
+			g.MaxIterations = d.MaxIterations
+			fd.result.Ranges = &Ranges{g: g}
+			if d.OnRanges != nil {
+				g.Solve()
+				d.OnRanges(fn, g)
+			}
 			fd.result.Loops = findLoops(fn)
 			fd.result.NilError = fd.result.NilError || IsNilError(fn)
 			fd.result.ConcreteReturnTypes = concreteReturnTypes(fn)
@@ -2,23 +2,111 @@ package functions
 
 import "honnef.co/go/tools/ssa"
 
+// exits lists functions that never return, even though their
+// implementation has paths that do, or that wrap such functions.
+// Listing the wrappers means we don't depend on their bodies being
+// available.
+var exits = map[string]bool{
+	"os.Exit":        true,
+	"runtime.Goexit": true,
+	"syscall.Exit":   true,
+
+	"log.Fatal":                 true,
+	"log.Fatalf":                true,
+	"log.Fatalln":               true,
+	"log.Panic":                 true,
+	"log.Panicf":                true,
+	"log.Panicln":               true,
+	"(*log.Logger).Fatal":       true,
+	"(*log.Logger).Fatalf":      true,
+	"(*log.Logger).Fatalln":     true,
+	"(*log.Logger).Panic":       true,
+	"(*log.Logger).Panicf":      true,
+	"(*log.Logger).Panicln":     true,
+	"(*testing.common).FailNow": true,
+	"(*testing.common).Fatal":   true,
+	"(*testing.common).Fatalf":  true,
+	"(*testing.common).SkipNow": true,
+	"(*testing.common).Skip":    true,
+	"(*testing.common).Skipf":   true,
+}
+
 // terminates reports whether fn is supposed to return, that is if it
 // has at least one theoretic path that returns from the function.
-// Explicit panics do not count as terminating.
-func terminates(fn *ssa.Function) bool {
-	if fn.Blocks == nil {
+// Explicit panics do not count as terminating, and neither do calls
+// to functions that don't terminate, such as os.Exit or log.Fatal.
+func (d *Descriptions) terminates(fn *ssa.Function) bool {
+	ret, _ := d.terminates1(fn, map[*ssa.Function]bool{})
+	return ret
+}
+
+// terminates1 implements terminates. active holds the functions
+// whose analysis is in progress; calls to them are assumed to
+// return. Results that depend on such an assumption are reported as
+// cyclic and aren't cached, as they depend on where the analysis
+// started.
+func (d *Descriptions) terminates1(fn *ssa.Function, active map[*ssa.Function]bool) (ret, cyclic bool) {
+	if exits[fn.RelString(nil)] {
+		return false, false
+	}
+	if fn.Blocks == nil || fn.Recover != nil {
 		// assuming that a function terminates is the conservative
-		// choice
-		return true
+		// choice. Functions that recover from panics may return
+		// even if all their paths panic.
+		return true, false
 	}
+	d.mu.Lock()
+	ret, ok := d.terminatesCache[fn]
+	d.mu.Unlock()
+	if ok {
+		return ret, false
+	}
+	if active[fn] {
+		return true, true
+	}
+	active[fn] = true
+	defer delete(active, fn)
 
-	for _, block := range fn.Blocks {
-		if len(block.Instrs) == 0 {
+	// Walk the blocks that are reachable without passing a call
+	// that doesn't return.
+	seen := map[*ssa.BasicBlock]bool{}
+	queue := []*ssa.BasicBlock{fn.Blocks[0]}
+	for len(queue) > 0 && !ret {
+		b := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if seen[b] || len(b.Instrs) == 0 {
+			continue
+		}
+		seen[b] = true
+		stops := false
+		for _, instr := range b.Instrs {
+			call, ok := instr.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			callee := call.Common().StaticCallee()
+			if callee == nil {
+				continue
+			}
+			calleeRet, calleeCyclic := d.terminates1(callee, active)
+			cyclic = cyclic || calleeCyclic
+			if !calleeRet {
+				stops = true
+				break
+			}
+		}
+		if stops {
 			continue
 		}
-		if _, ok := block.Instrs[len(block.Instrs)-1].(*ssa.Return); ok {
-			return true
+		if _, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); ok {
+			ret = true
 		}
+		queue = append(queue, b.Succs...)
+	}
+	if !cyclic {
+		d.mu.Lock()
+		d.terminatesCache[fn] = ret
+		d.mu.Unlock()
 	}
-	return false
+	return ret, cyclic
 }
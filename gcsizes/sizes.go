@@ -6,10 +6,7 @@
 // to the rules used by the gc compiler.
 package gcsizes // import "honnef.co/go/tools/gcsizes"
 
-import (
-	"go/build"
-	"go/types"
-)
+import "go/types"
 
 type Sizes struct {
 	WordSize int64
@@ -20,7 +17,7 @@ type Sizes struct {
 func ForArch(arch string) *Sizes {
 	wordSize := int64(8)
 	maxAlign := int64(8)
-	switch build.Default.GOARCH {
+	switch arch {
 	case "386", "arm":
 		wordSize, maxAlign = 4, 4
 	case "amd64p32":
@@ -0,0 +1,240 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+type edit struct {
+	start, end int
+	text       string
+}
+
+type byStart []edit
+
+func (es byStart) Len() int           { return len(es) }
+func (es byStart) Less(i, j int) bool { return es[i].start < es[j].start }
+func (es byStart) Swap(i, j int)      { es[i], es[j] = es[j], es[i] }
+
+// ApplyFixes applies the suggested fixes of ps to the files they
+// refer to and returns the new, gofmt'd contents of each changed
+// file, keyed by file name. Imports that were used before the fixes
+// but aren't anymore are removed. Fixes are applied in order and as a
+// whole; a fix is skipped if any of its edits overlaps with an edit
+// of an earlier fix, unless the two edits are identical. fixed
+// reports which of ps had their fix applied.
+//
+// The contents of files are obtained from readFile, which defaults
+// to ioutil.ReadFile. ApplyFixes doesn't write to disk.
+func ApplyFixes(fset *token.FileSet, ps []Problem, readFile func(name string) ([]byte, error)) (files map[string][]byte, fixed []bool, err error) {
+	if readFile == nil {
+		readFile = ioutil.ReadFile
+	}
+	edits := map[string][]edit{}
+	fixed = make([]bool, len(ps))
+	for i, p := range ps {
+		if p.Fix == nil || len(p.Fix.Edits) == 0 {
+			continue
+		}
+		var name string
+		var es []edit
+		for _, e := range p.Fix.Edits {
+			start := fset.Position(e.Pos)
+			end := fset.Position(e.End)
+			if !start.IsValid() || !end.IsValid() || start.Filename != end.Filename {
+				es = nil
+				break
+			}
+			if name == "" {
+				name = start.Filename
+			} else if name != start.Filename {
+				// fixes spanning multiple files aren't supported
+				es = nil
+				break
+			}
+			es = append(es, edit{start.Offset, end.Offset, e.NewText})
+		}
+		if es == nil {
+			continue
+		}
+		// the same problem may be reported more than once, for
+		// example for a package and its test variant, so edits
+		// identical to ones we already have aren't conflicts.
+		var add []edit
+		ok := true
+		for _, e := range es {
+			dup, overlaps := overlap(edits[name], e)
+			if overlaps {
+				ok = false
+				break
+			}
+			if !dup {
+				add = append(add, e)
+			}
+		}
+		if !ok {
+			continue
+		}
+		fixed[i] = true
+		edits[name] = append(edits[name], add...)
+	}
+
+	files = map[string][]byte{}
+	for name, es := range edits {
+		src, err := readFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, e := range es {
+			if e.text == "" {
+				es[i] = deleteLine(src, e)
+			}
+		}
+		sort.Stable(byStart(es))
+		var out []byte
+		last := 0
+		for _, e := range es {
+			if e.start < last || e.end > len(src) {
+				return nil, nil, fmt.Errorf("%s: invalid edit at offset %d", name, e.start)
+			}
+			out = append(out, src[last:e.start]...)
+			out = append(out, e.text...)
+			last = e.end
+		}
+		out = append(out, src[last:]...)
+		formatted, err := format.Source(out)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: couldn't format fixed source: %s", name, err)
+		}
+		formatted, err = pruneImports(src, formatted)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: couldn't remove imports: %s", name, err)
+		}
+		files[name] = formatted
+	}
+	return files, fixed, nil
+}
+
+// overlap reports whether e overlaps with any of es, and whether e
+// is identical to one of es. Identical edits don't count as
+// overlapping.
+func overlap(es []edit, e edit) (duplicate bool, overlaps bool) {
+	for _, other := range es {
+		if other == e {
+			return true, false
+		}
+		if e.start < other.end && other.start < e.end {
+			return false, true
+		}
+		if e.start == other.start && (e.start == e.end || other.start == other.end) {
+			// insertions at the same offset as another edit have an
+			// ambiguous order
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// deleteLine extends the deletion e to the whole line if that line
+// would otherwise be left empty.
+func deleteLine(src []byte, e edit) edit {
+	if e.end > len(src) {
+		return e
+	}
+	start, end := e.start, e.end
+	for start > 0 && (src[start-1] == ' ' || src[start-1] == '\t') {
+		start--
+	}
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t' || src[end] == '\r') {
+		end++
+	}
+	if (start > 0 && src[start-1] != '\n') || (end < len(src) && src[end] != '\n') {
+		return e
+	}
+	if end < len(src) {
+		end++
+	}
+	return edit{start, end, ""}
+}
+
+// pruneImports removes the imports of fixed that orig uses but fixed
+// doesn't use anymore. Without type information, an unnamed import
+// is assumed to be referred to by the last element of its path; an
+// import that doesn't follow this convention is never removed, as
+// it doesn't appear to be used in orig, either.
+func pruneImports(orig, fixed []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	of, err := parser.ParseFile(fset, "", orig, 0)
+	if err != nil {
+		return nil, err
+	}
+	ff, err := parser.ParseFile(fset, "", fixed, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	before := usedPackageNames(of)
+	after := usedPackageNames(ff)
+	pruned := false
+	for _, spec := range ff.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		switch name {
+		case "_", ".", "C":
+			// imported for their side effects, their exported
+			// identifiers or by cgo
+			continue
+		}
+		if !before[name] || after[name] {
+			continue
+		}
+		var local string
+		if spec.Name != nil {
+			local = spec.Name.Name
+		}
+		if astutil.DeleteNamedImport(fset, ff, local, path) {
+			pruned = true
+		}
+	}
+	if !pruned {
+		return fixed, nil
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, ff); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// usedPackageNames returns the unresolved identifiers of f that are
+// used as the operand of a selector expression, which includes all
+// references to imported packages.
+func usedPackageNames(f *ast.File) map[string]bool {
+	names := map[string]bool{}
+	ast.Inspect(f, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+			names[ident.Name] = true
+		}
+		return true
+	})
+	return names
+}
@@ -0,0 +1,236 @@
+package lint
+
+import (
+	"go/format"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fixFiles holds the sources of files that fixes are applied to, as
+// they'd be read from disk.
+type fixFiles struct {
+	fset *token.FileSet
+	srcs map[string]string
+	base map[string]int
+}
+
+func newFixFiles(srcs map[string]string) *fixFiles {
+	fs := &fixFiles{token.NewFileSet(), srcs, map[string]int{}}
+	for name, src := range srcs {
+		f := fs.fset.AddFile(name, -1, len(src))
+		f.SetLinesForContent([]byte(src))
+		fs.base[name] = f.Base()
+	}
+	return fs
+}
+
+func (fs *fixFiles) readFile(name string) ([]byte, error) {
+	src, ok := fs.srcs[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(src), nil
+}
+
+// edit returns an edit that replaces the n-th occurrence, counting
+// from 0, of old in the file name with text.
+func (fs *fixFiles) edit(name, old string, n int, text string) TextEdit {
+	src := fs.srcs[name]
+	off := 0
+	for i := 0; ; i++ {
+		j := strings.Index(src[off:], old)
+		if j < 0 {
+			panic("no occurrence of " + old)
+		}
+		if i == n {
+			off += j
+			break
+		}
+		off += j + len(old)
+	}
+	pos := token.Pos(fs.base[name] + off)
+	return TextEdit{pos, pos + token.Pos(len(old)), text}
+}
+
+func (fs *fixFiles) insert(name, before, text string) TextEdit {
+	e := fs.edit(name, before, 0, text)
+	e.End = e.Pos
+	return e
+}
+
+func fixProblem(edits ...TextEdit) Problem {
+	return Problem{Fix: &SuggestedFix{Edits: edits}}
+}
+
+const fixSrc = `package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+func fn(s string) {
+	if strings.HasPrefix(s, "a") {
+		s = s[1:]
+	}
+	fmt.Println(s)
+	for _ = range s {
+	}
+}
+`
+
+func TestApplyFixesSeveral(t *testing.T) {
+	fs := newFixFiles(map[string]string{"a.go": fixSrc, "b.go": "package pkg\n\nvar x = 1 + 1\n"})
+	ps := []Problem{
+		fixProblem(
+			fs.edit("a.go", `if strings.HasPrefix(s, "a") {`, 0, `s = strings.TrimPrefix(s, "a")`),
+			fs.edit("a.go", "\t\ts = s[1:]\n\t}", 0, ""),
+		),
+		fixProblem(fs.edit("a.go", "for _ = range s", 0, "for range s")),
+		{Text: "no fix"},
+		fixProblem(fs.edit("b.go", "1 + 1", 0, "2")),
+	}
+	files, fixed, err := ApplyFixes(fs.fset, ps, fs.readFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFixed := []bool{true, true, false, true}
+	for i := range wantFixed {
+		if fixed[i] != wantFixed[i] {
+			t.Errorf("fixed[%d] = %t, want %t", i, fixed[i], wantFixed[i])
+		}
+	}
+	want := map[string]string{
+		"a.go": `package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+func fn(s string) {
+	s = strings.TrimPrefix(s, "a")
+	fmt.Println(s)
+	for range s {
+	}
+}
+`,
+		"b.go": "package pkg\n\nvar x = 2\n",
+	}
+	if len(files) != len(want) {
+		t.Errorf("changed %d files, want %d", len(files), len(want))
+	}
+	for name, src := range want {
+		if got := string(files[name]); got != src {
+			t.Errorf("%s: got\n%s\nwant\n%s", name, got, src)
+		}
+	}
+}
+
+func TestApplyFixesConflicts(t *testing.T) {
+	fs := newFixFiles(map[string]string{"a.go": fixSrc})
+	ps := []Problem{
+		fixProblem(fs.edit("a.go", "for _ = range s", 0, "for range s")),
+		// overlaps with the first fix
+		fixProblem(fs.edit("a.go", "_ = range", 0, "_ = range")),
+		// inserts at the start of the first fix's edit; the order
+		// of the two would be ambiguous
+		fixProblem(fs.insert("a.go", "for _ = range s", "// loop\n\t")),
+		// one of its edits conflicts, so none of them are applied
+		fixProblem(
+			fs.edit("a.go", "fmt.Println(s)", 0, "fmt.Print(s)"),
+			fs.edit("a.go", "range s", 0, "range s[1:]"),
+		),
+		// the same fix reported twice, e.g. for a package and its
+		// test variant
+		fixProblem(fs.edit("a.go", "s[1:]", 0, "s[2:]")),
+		fixProblem(fs.edit("a.go", "s[1:]", 0, "s[2:]")),
+	}
+	files, fixed, err := ApplyFixes(fs.fset, ps, fs.readFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFixed := []bool{true, false, false, false, true, true}
+	for i := range wantFixed {
+		if fixed[i] != wantFixed[i] {
+			t.Errorf("fixed[%d] = %t, want %t", i, fixed[i], wantFixed[i])
+		}
+	}
+	want := strings.Replace(strings.Replace(fixSrc, "for _ = range s", "for range s", 1), "s[1:]", "s[2:]", 1)
+	if got := string(files["a.go"]); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestApplyFixesIdempotent(t *testing.T) {
+	fs := newFixFiles(map[string]string{"a.go": fixSrc})
+	ps := []Problem{
+		fixProblem(
+			fs.edit("a.go", `if strings.HasPrefix(s, "a") {`, 0, `s = strings.TrimPrefix(s, "a")`),
+			fs.edit("a.go", "\t\ts = s[1:]\n\t}", 0, ""),
+		),
+		fixProblem(fs.edit("a.go", "for _ = range s", 0, "for range s")),
+	}
+	files, _, err := ApplyFixes(fs.fset, ps, fs.readFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := files["a.go"]
+
+	// the output is formatted, so running gofmt afterwards doesn't
+	// change it
+	formatted, err := format.Source(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != string(first) {
+		t.Errorf("output isn't gofmt'd:\n%s", first)
+	}
+
+	// applying the fixes once more, as if the problems had been
+	// reported again, doesn't change the result
+	files, fixed, err := ApplyFixes(fs.fset, append(ps, ps...), fs.readFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ok := range fixed {
+		if !ok {
+			t.Errorf("fix %d wasn't applied", i)
+		}
+	}
+	if string(files["a.go"]) != string(first) {
+		t.Errorf("got\n%s\nwant\n%s", files["a.go"], first)
+	}
+
+	// the fixed file has nothing left to fix: the same fixes,
+	// computed against it, are no-ops
+	fs2 := newFixFiles(map[string]string{"a.go": string(first)})
+	ps2 := []Problem{fixProblem(fs2.edit("a.go", "for range s", 0, "for range s"))}
+	files, _, err = ApplyFixes(fs2.fset, ps2, fs2.readFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(files["a.go"]) != string(first) {
+		t.Errorf("fixing a fixed file changed it:\n%s", files["a.go"])
+	}
+}
+
+func TestApplyFixesPrunesImports(t *testing.T) {
+	fs := newFixFiles(map[string]string{"a.go": fixSrc})
+	ps := []Problem{
+		fixProblem(fs.edit("a.go", `strings.HasPrefix(s, "a")`, 0, `len(s) > 0 && s[0] == 'a'`)),
+	}
+	files, _, err := ApplyFixes(fs.fset, ps, fs.readFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(files["a.go"])
+	if strings.Contains(got, `"strings"`) {
+		t.Errorf("strings is still imported:\n%s", got)
+	}
+	if !strings.Contains(got, `import "fmt"`) && !strings.Contains(got, "\t\"fmt\"") {
+		t.Errorf("fmt isn't imported anymore:\n%s", got)
+	}
+}
@@ -16,11 +16,14 @@ import (
 	"go/token"
 	"go/types"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/loader"
 	"honnef.co/go/tools/ssa"
@@ -47,10 +50,30 @@ type Program struct {
 	AllFunctions     []*ssa.Function
 	Files            []*ast.File
 	Info             *types.Info
-	GoVersion        int
+	// GoVersion is the oldest minor Go version that any of Packages
+	// targets.
+	GoVersion int
+	Generated GeneratedPolicy
+	// Facts, if not nil, stores the facts of checkers that implement
+	// FactChecker. See Linter.Facts.
+	Facts Facts
 
 	tokenFileMap map[*token.File]*ast.File
 	astFileMap   map[*ast.File]*Pkg
+	generated    map[*ast.File]bool
+}
+
+// GeneratedPolicy controls the treatment of generated files, as
+// identified by IsGenerated. The zero value analyzes generated files
+// but doesn't report problems in them.
+type GeneratedPolicy struct {
+	// Report causes problems in generated files to be reported.
+	Report bool
+	// IgnoreUses causes checkers to disregard uses of identifiers in
+	// generated files, so that identifiers that are only used by
+	// generated code count as unused. Together with Report being
+	// false, generated files are skipped entirely.
+	IgnoreUses bool
 }
 
 type Func func(*Job)
@@ -59,6 +82,59 @@ type Func func(*Job)
 type Problem struct {
 	Position token.Pos // position in source file
 	Text     string    // the prose that describes the problem
+	Check    string    // the check that found the problem
+	End      token.Pos // end of the code the problem is about, if known
+
+	// Related lists secondary positions that help explain the
+	// problem, such as the declaration of an identifier.
+	Related []RelatedInformation
+
+	// Fix, if not nil, is a mechanical rewrite of the source that
+	// resolves the problem.
+	Fix *SuggestedFix
+}
+
+// RelatedInformation is a secondary position of a problem, described
+// by a message.
+type RelatedInformation struct {
+	Pos     token.Pos
+	Message string
+}
+
+// A SuggestedFix is a set of edits that, applied together, resolve
+// a problem.
+type SuggestedFix struct {
+	Edits []TextEdit
+}
+
+// A TextEdit replaces the source in the range [Pos, End) with
+// NewText.
+type TextEdit struct {
+	Pos     token.Pos
+	End     token.Pos
+	NewText string
+}
+
+// Replace adds an edit to p's fix that replaces node with text, and
+// returns p.
+func (p *Problem) Replace(node ast.Node, text string) *Problem {
+	return p.Edit(node.Pos(), node.End(), text)
+}
+
+// Edit adds an edit to p's fix that replaces the source in the range
+// [pos, end) with text, and returns p.
+func (p *Problem) Edit(pos, end token.Pos, text string) *Problem {
+	if p.Fix == nil {
+		p.Fix = &SuggestedFix{}
+	}
+	p.Fix.Edits = append(p.Fix.Edits, TextEdit{pos, end, text})
+	return p
+}
+
+// Relate adds a related position to p and returns p.
+func (p *Problem) Relate(pos token.Pos, message string) *Problem {
+	p.Related = append(p.Related, RelatedInformation{pos, message})
+	return p
 }
 
 func (p *Problem) String() string {
@@ -70,11 +146,116 @@ type Checker interface {
 	Funcs() map[string]Func
 }
 
+// Version is the release of the tools that is being built. Releases
+// are named after the year and a counter, e.g. 2017.1.
+const Version = "2017.2"
+
+// CheckVersion records the releases in which a check was introduced
+// and in which its behavior last changed in a way that may cause it
+// to report new problems.
+type CheckVersion struct {
+	Introduced string
+	// Changed is the empty string if the check hasn't changed since
+	// it was introduced.
+	Changed string
+}
+
+// Since reports whether the check was introduced or changed after
+// the given release.
+func (v CheckVersion) Since(release string) bool {
+	return CompareVersions(v.Introduced, release) > 0 ||
+		(v.Changed != "" && CompareVersions(v.Changed, release) > 0)
+}
+
+// A VersionedChecker is a Checker that knows the versions of its
+// checks.
+type VersionedChecker interface {
+	Checker
+	Versions() map[string]CheckVersion
+}
+
+// An SSAModeChecker is a Checker whose checks need SSA form built
+// with additional options, such as the σ-nodes that range analysis
+// relies on.
+type SSAModeChecker interface {
+	Checker
+	SSAMode() ssa.BuilderMode
+}
+
+// Facts gives checks access to facts about the objects of other
+// packages, recorded by the same checker when it checked them, and
+// lets them record facts about the objects of the package being
+// checked, like the methods of analysis.Pass. Drivers that check one
+// package at a time, with only the types of its dependencies, set it;
+// the lint tools check all packages together, with their sources,
+// and leave it nil.
+type Facts interface {
+	ImportObjectFact(obj types.Object, fact analysis.Fact) bool
+	ExportObjectFact(obj types.Object, fact analysis.Fact)
+}
+
+// A FactChecker is a Checker that records facts about objects, of
+// the types that FactTypes returns, for use by checks of the
+// packages that import them.
+type FactChecker interface {
+	Checker
+	FactTypes() []analysis.Fact
+}
+
+// CompareVersions compares two releases, returning -1, 0 or 1 if a is
+// older than, the same as or newer than b. Malformed components
+// compare as zero.
+func CompareVersions(a, b string) int {
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for len(pa) < len(pb) {
+		pa = append(pa, "0")
+	}
+	for len(pb) < len(pa) {
+		pb = append(pb, "0")
+	}
+	for i := range pa {
+		na, _ := strconv.Atoi(pa[i])
+		nb, _ := strconv.Atoi(pb[i])
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+	}
+	return 0
+}
+
 // A Linter lints Go source code.
 type Linter struct {
 	Checker   Checker
 	Ignores   []Ignore
 	GoVersion int
+	Generated GeneratedPolicy
+
+	// Enabled, if not nil, reports whether a check is enabled for
+	// the file with the given name. Problems in files a check isn't
+	// enabled for are dropped, and checks that aren't enabled for
+	// any of the files being linted aren't run at all.
+	Enabled func(check, filename string) bool
+
+	// PackageGoVersion, if not nil, returns the minor Go version
+	// that pkg targets, overriding GoVersion for that package.
+	PackageGoVersion func(pkg *loader.PackageInfo) int
+
+	// Facts, if not nil, is made available to the checker as
+	// Program.Facts. It is set when checking a single package whose
+	// dependencies have no sources.
+	Facts Facts
+
+	// Concurrency is the maximum number of checks that run at the
+	// same time. It defaults to GOMAXPROCS.
+	Concurrency int
+	// MemoryLimit, if not zero, is the heap size in bytes above which
+	// no further checks are started until running ones finish. At
+	// least one check is always running.
+	MemoryLimit uint64
 }
 
 func (l *Linter) ignore(j *Job, p Problem) bool {
@@ -82,6 +263,12 @@ func (l *Linter) ignore(j *Job, p Problem) bool {
 	f := j.Program.tokenFileMap[tf]
 	pkg := j.Program.astFileMap[f].Pkg
 
+	if !j.Program.Generated.Report && j.Program.generated[f] {
+		return true
+	}
+	if l.Enabled != nil && !l.Enabled(j.check, tf.Name()) {
+		return true
+	}
 	for _, ig := range l.Ignores {
 		pkgpath := pkg.Path()
 		if strings.HasSuffix(pkgpath, "_test") {
@@ -100,6 +287,31 @@ func (l *Linter) ignore(j *Job, p Problem) bool {
 	return false
 }
 
+// overMemoryLimit reports whether the heap exceeds the memory limit,
+// even after collecting garbage.
+func (l *Linter) overMemoryLimit() bool {
+	if l.MemoryLimit == 0 {
+		return false
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc <= l.MemoryLimit {
+		return false
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc > l.MemoryLimit
+}
+
+func (l *Linter) enabledAnywhere(prog *Program, check string) bool {
+	for _, f := range prog.Files {
+		if l.Enabled(check, prog.SSA.Fset.File(f.Pos()).Name()) {
+			return true
+		}
+	}
+	return false
+}
+
 func (j *Job) File(node Positioner) *ast.File {
 	return j.Program.tokenFileMap[j.Program.SSA.Fset.File(node.Pos())]
 }
@@ -135,19 +347,35 @@ func (ps byPosition) Swap(i int, j int) {
 }
 
 func (l *Linter) Lint(lprog *loader.Program) []Problem {
-	ssaprog := ssautil.CreateProgram(lprog, ssa.GlobalDebug)
+	mode := ssa.GlobalDebug
+	if mc, ok := l.Checker.(SSAModeChecker); ok {
+		mode |= mc.SSAMode()
+	}
+	ssaprog := ssautil.CreateProgram(lprog, mode)
 	ssaprog.Build()
 	pkgMap := map[*ssa.Package]*Pkg{}
 	var pkgs []*Pkg
 	for _, pkginfo := range lprog.InitialPackages() {
 		ssapkg := ssaprog.Package(pkginfo.Pkg)
 		pkg := &Pkg{
-			Package: ssapkg,
-			Info:    pkginfo,
+			Package:   ssapkg,
+			Info:      pkginfo,
+			GoVersion: l.GoVersion,
+		}
+		if l.PackageGoVersion != nil {
+			pkg.GoVersion = l.PackageGoVersion(pkginfo)
 		}
 		pkgMap[ssapkg] = pkg
 		pkgs = append(pkgs, pkg)
 	}
+	// checks that aren't concerned with a particular package
+	// target the oldest version of all packages
+	version := l.GoVersion
+	for i, pkg := range pkgs {
+		if i == 0 || pkg.GoVersion < version {
+			version = pkg.GoVersion
+		}
+	}
 	prog := &Program{
 		SSA:      ssaprog,
 		Prog:     lprog,
@@ -160,9 +388,12 @@ func (l *Linter) Lint(lprog *loader.Program) []Problem {
 			Selections: map[*ast.SelectorExpr]*types.Selection{},
 			Scopes:     map[ast.Node]*types.Scope{},
 		},
-		GoVersion:    l.GoVersion,
+		GoVersion:    version,
+		Generated:    l.Generated,
+		Facts:        l.Facts,
 		tokenFileMap: map[*token.File]*ast.File{},
 		astFileMap:   map[*ast.File]*Pkg{},
+		generated:    map[*ast.File]bool{},
 	}
 	for fn := range ssautil.AllFunctions(ssaprog) {
 		prog.AllFunctions = append(prog.AllFunctions, fn)
@@ -185,6 +416,7 @@ func (l *Linter) Lint(lprog *loader.Program) []Problem {
 			tf := lprog.Fset.File(f.Pos())
 			prog.tokenFileMap[tf] = f
 			prog.astFileMap[f] = pkgMap[ssapkg]
+			prog.generated[f] = IsGenerated(f)
 		}
 	}
 	for _, pkginfo := range lprog.InitialPackages() {
@@ -212,6 +444,9 @@ func (l *Linter) Lint(lprog *loader.Program) []Problem {
 	funcs := l.Checker.Funcs()
 	var keys []string
 	for k := range funcs {
+		if l.Enabled != nil && !l.enabledAnywhere(prog, k) {
+			continue
+		}
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
@@ -224,16 +459,50 @@ func (l *Linter) Lint(lprog *loader.Program) []Problem {
 		}
 		jobs = append(jobs, j)
 	}
+	n := l.Concurrency
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+	running := 0
 	wg := &sync.WaitGroup{}
 	for _, j := range jobs {
+		mu.Lock()
+		for {
+			for running >= n {
+				cond.Wait()
+			}
+			if running == 0 {
+				break
+			}
+			// sample memory without holding mu, which the running
+			// jobs need to finish, and only once each time a job
+			// finishes
+			r := running
+			mu.Unlock()
+			over := l.overMemoryLimit()
+			mu.Lock()
+			if !over {
+				break
+			}
+			for running >= r {
+				cond.Wait()
+			}
+		}
+		running++
+		mu.Unlock()
+
 		wg.Add(1)
 		go func(j *Job) {
 			defer wg.Done()
-			fn := funcs[j.check]
-			if fn == nil {
-				return
+			if fn := funcs[j.check]; fn != nil {
+				fn(j)
 			}
-			fn(j)
+			mu.Lock()
+			running--
+			cond.Broadcast()
+			mu.Unlock()
 		}(j)
 	}
 	wg.Wait()
@@ -255,6 +524,8 @@ func (l *Linter) Lint(lprog *loader.Program) []Problem {
 type Pkg struct {
 	*ssa.Package
 	Info *loader.PackageInfo
+	// GoVersion is the minor Go version the package targets.
+	GoVersion int
 }
 
 type packager interface {
@@ -296,6 +567,12 @@ func (j *Job) Errorf(n Positioner, format string, args ...interface{}) *Problem
 	problem := Problem{
 		Position: n.Pos(),
 		Text:     fmt.Sprintf(format, args...) + fmt.Sprintf(" (%s)", j.check),
+		Check:    j.check,
+	}
+	if n, ok := n.(interface {
+		End() token.Pos
+	}); ok {
+		problem.End = n.End()
 	}
 	j.problems = append(j.problems, problem)
 	return &j.problems[len(j.problems)-1]
@@ -395,7 +672,24 @@ func (j *Job) NodePackage(node Positioner) *Pkg {
 	return j.Program.astFileMap[f]
 }
 
+var generatedRx = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// IsGenerated reports whether f is a generated file. Files are
+// generated if a comment before the package clause is the canonical
+// "// Code generated ... DO NOT EDIT." line. For files predating that
+// convention, a first comment mentioning "Code generated by" or "DO
+// NOT EDIT" marks them as generated, too.
 func IsGenerated(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() >= f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if generatedRx.MatchString(c.Text) {
+				return true
+			}
+		}
+	}
 	comments := f.Comments
 	if len(comments) > 0 {
 		comment := comments[0].Text()
@@ -405,10 +699,44 @@ func IsGenerated(f *ast.File) bool {
 	return false
 }
 
+// FilterGenerated returns files without the generated ones, unless
+// problems in generated files are to be reported. Checks that only
+// look at the syntax of individual files use it to avoid wasted
+// work.
+func (j *Job) FilterGenerated(files []*ast.File) []*ast.File {
+	if j.Program.Generated.Report {
+		return files
+	}
+	var out []*ast.File
+	for _, f := range files {
+		if !j.Program.generated[f] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// IsGenerated reports whether node is in a generated file.
+func (j *Job) IsGenerated(node Positioner) bool {
+	return j.Program.generated[j.File(node)]
+}
+
+// IsGoVersion reports whether all packages being linted target Go
+// 1.minor or newer.
 func (j *Job) IsGoVersion(minor int) bool {
 	return j.Program.GoVersion >= minor
 }
 
+// IsGoVersionAt reports whether the package that node is in targets
+// Go 1.minor or newer.
+func (j *Job) IsGoVersionAt(node Positioner, minor int) bool {
+	pkg := j.NodePackage(node)
+	if pkg == nil {
+		return j.IsGoVersion(minor)
+	}
+	return pkg.GoVersion >= minor
+}
+
 func (j *Job) IsFunctionCallName(node ast.Node, name string) bool {
 	call, ok := node.(*ast.CallExpr)
 	if !ok {
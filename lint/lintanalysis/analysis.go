@@ -0,0 +1,192 @@
+// Package lintanalysis runs the checks of a lint.Checker as analyzers
+// of the golang.org/x/tools/go/analysis framework, so that they can
+// be run by go vet -vettool and other drivers, tested with
+// analysistest and combined with third-party analyzers.
+//
+// Drivers analyze one package at a time, with only the export data of
+// its dependencies, so checks see the package like the lint tools see
+// a single package whose dependencies have no source: SSA is built for
+// the package, and its dependencies are represented by their types.
+// Checkers that implement lint.FactChecker record what they need to
+// know about dependencies, such as the deprecation notices of their
+// objects, as facts while checking them. Checks that analyze several
+// packages together, such as unused in whole program mode, find fewer
+// problems than when run by the lint tools.
+package lintanalysis // import "honnef.co/go/tools/lint/lintanalysis"
+
+import (
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/loader"
+	"honnef.co/go/tools/lint"
+)
+
+// Analyzers returns an analyzer for each check of the checkers
+// returned by newChecker, named after the check, such as SA1000. All
+// of them depend on an analyzer called name, which runs all checks
+// once per package and has no diagnostics of its own. It comes first
+// in the returned slice, so that drivers expose its flags, such as
+// -go, which sets the targeted Go version. newChecker is called once
+// per package, as checkers store the state of the program they check.
+func Analyzers(name string, newChecker func() lint.Checker) []*analysis.Analyzer {
+	c := newChecker()
+	var versions map[string]lint.CheckVersion
+	if vc, ok := c.(lint.VersionedChecker); ok {
+		versions = vc.Versions()
+	}
+	var ids []string
+	for id := range c.Funcs() {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var version string
+	run := &analysis.Analyzer{
+		Name: name,
+		Doc:  fmt.Sprintf("runs the checks of %s\n\nThe checks of %s report the problems this analyzer finds.", name, name),
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			v := 0
+			if version != "" {
+				var err error
+				if v, err = lint.ParseGoVersion(version); err != nil {
+					return nil, fmt.Errorf("-%s.go: %s", name, err)
+				}
+			}
+			return lintPass(pass, newChecker(), v), nil
+		},
+		ResultType: reflect.TypeOf([]lint.Problem(nil)),
+	}
+	if fc, ok := c.(lint.FactChecker); ok {
+		run.FactTypes = fc.FactTypes()
+	}
+	run.Flags.StringVar(&version, "go", "", "Target Go `version` in the format '1.x', instead of the one declared by the package's go.mod file or, failing that, the Go release the driver was built with")
+	out := []*analysis.Analyzer{run}
+	for _, id := range ids {
+		id := id
+		doc := fmt.Sprintf("%s check %s", name, id)
+		if v := versions[id]; v.Introduced != "" {
+			doc += fmt.Sprintf("\n\nIntroduced in release %s.", v.Introduced)
+		}
+		out = append(out, &analysis.Analyzer{
+			Name:     id,
+			Doc:      doc,
+			Requires: []*analysis.Analyzer{run},
+			Run: func(pass *analysis.Pass) (interface{}, error) {
+				for _, p := range pass.ResultOf[run].([]lint.Problem) {
+					if p.Check == id {
+						pass.Report(diagnostic(p))
+					}
+				}
+				return nil, nil
+			},
+		})
+	}
+	return out
+}
+
+// lintPass checks the package of pass with c, targeting the Go
+// version version, or the package's version if it is zero.
+func lintPass(pass *analysis.Pass, c lint.Checker, version int) []lint.Problem {
+	if version == 0 {
+		version = goVersion(pass)
+	}
+	l := &lint.Linter{
+		Checker:   c,
+		GoVersion: version,
+		Facts:     passFacts{pass},
+	}
+	return l.Lint(program(pass))
+}
+
+// passFacts stores the facts of checkers in a pass.
+type passFacts struct {
+	pass *analysis.Pass
+}
+
+func (f passFacts) ImportObjectFact(obj types.Object, fact analysis.Fact) bool {
+	return f.pass.ImportObjectFact(obj, fact)
+}
+
+func (f passFacts) ExportObjectFact(obj types.Object, fact analysis.Fact) {
+	f.pass.ExportObjectFact(obj, fact)
+}
+
+// program returns a program consisting of the package of pass, with
+// source, and its dependencies, without.
+func program(pass *analysis.Pass) *loader.Program {
+	info := &loader.PackageInfo{
+		Pkg:                   pass.Pkg,
+		Importable:            true,
+		TransitivelyErrorFree: true,
+		Files:                 pass.Files,
+		Info:                  *pass.TypesInfo,
+	}
+	lprog := &loader.Program{
+		Fset:        pass.Fset,
+		Imported:    map[string]*loader.PackageInfo{pass.Pkg.Path(): info},
+		AllPackages: map[*types.Package]*loader.PackageInfo{pass.Pkg: info},
+	}
+	var add func(pkgs []*types.Package)
+	add = func(pkgs []*types.Package) {
+		for _, pkg := range pkgs {
+			if _, ok := lprog.AllPackages[pkg]; ok {
+				continue
+			}
+			lprog.AllPackages[pkg] = &loader.PackageInfo{
+				Pkg:                   pkg,
+				Importable:            true,
+				TransitivelyErrorFree: true,
+			}
+			add(pkg.Imports())
+		}
+	}
+	add(pass.Pkg.Imports())
+	return lprog
+}
+
+func diagnostic(p lint.Problem) analysis.Diagnostic {
+	d := analysis.Diagnostic{
+		Pos:      p.Position,
+		End:      p.End,
+		Category: p.Check,
+		Message:  strings.TrimSuffix(p.Text, " ("+p.Check+")"),
+	}
+	for _, r := range p.Related {
+		d.Related = append(d.Related, analysis.RelatedInformation{
+			Pos:     r.Pos,
+			Message: r.Message,
+		})
+	}
+	if p.Fix != nil {
+		fix := analysis.SuggestedFix{Message: d.Message}
+		for _, e := range p.Fix.Edits {
+			fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{
+				Pos:     e.Pos,
+				End:     e.End,
+				NewText: []byte(e.NewText),
+			})
+		}
+		d.SuggestedFixes = []analysis.SuggestedFix{fix}
+	}
+	return d
+}
+
+// goVersion returns the minor version of the Go release the package
+// of pass targets: the one declared by the go directive of the
+// closest go.mod file or, failing that, the release the driver was
+// built with.
+func goVersion(pass *analysis.Pass) int {
+	if len(pass.Files) > 0 {
+		dir := filepath.Dir(pass.Fset.File(pass.Files[0].Pos()).Name())
+		if v, ok := lint.ModuleGoVersion(dir); ok {
+			return v
+		}
+	}
+	return lint.ToolchainGoVersion()
+}
@@ -0,0 +1,63 @@
+package lintanalysis
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/simple"
+	"honnef.co/go/tools/staticcheck"
+)
+
+func analyzer(t *testing.T, as []*analysis.Analyzer, name string) *analysis.Analyzer {
+	for _, a := range as {
+		if a.Name == name {
+			return a
+		}
+	}
+	t.Fatalf("no analyzer %s", name)
+	return nil
+}
+
+func staticcheckAnalyzers() []*analysis.Analyzer {
+	return Analyzers("staticcheck", func() lint.Checker { return staticcheck.NewChecker() })
+}
+
+func simpleAnalyzers() []*analysis.Analyzer {
+	return Analyzers("gosimple", func() lint.Checker { return simple.NewChecker() })
+}
+
+func TestAnalyzers(t *testing.T) {
+	as := staticcheckAnalyzers()
+	if as[0].Name != "staticcheck" || len(as[0].FactTypes) == 0 {
+		t.Errorf("first analyzer is %s with %d fact types, want staticcheck with facts", as[0].Name, len(as[0].FactTypes))
+	}
+	for _, a := range as[1:] {
+		if err := analysis.Validate([]*analysis.Analyzer{a}); err != nil {
+			t.Errorf("invalid analyzer %s: %s", a.Name, err)
+		}
+		if len(a.Requires) != 1 || a.Requires[0] != as[0] {
+			t.Errorf("analyzer %s doesn't require staticcheck", a.Name)
+		}
+	}
+}
+
+// TestDeprecatedFacts checks that deprecation notices reach the
+// importers of a package as facts, as the importers only see the
+// types of their dependencies.
+func TestDeprecatedFacts(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer(t, staticcheckAnalyzers(), "SA1019"), "use")
+}
+
+func TestGoVersion(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer(t, simpleAnalyzers(), "S1034"), "replace", "oldmod")
+}
+
+func TestGoVersionFlag(t *testing.T) {
+	as := simpleAnalyzers()
+	if err := as[0].Flags.Set("go", "1.11"); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), analyzer(t, as, "S1034"), "replaceflag")
+}
@@ -0,0 +1,15 @@
+package dep
+
+// Old does nothing.
+//
+// Deprecated: Use New instead.
+func Old() {}
+
+// New does nothing.
+func New() {}
+
+type T struct {
+	// Deprecated: Use G instead.
+	F int
+	G int
+}
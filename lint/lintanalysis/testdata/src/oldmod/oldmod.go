@@ -0,0 +1,8 @@
+package oldmod
+
+import "strings"
+
+func fn(s string) string {
+	// strings.ReplaceAll was added in Go 1.12
+	return strings.Replace(s, "a", "b", -1)
+}
@@ -0,0 +1,7 @@
+package replace
+
+import "strings"
+
+func fn(s string) string {
+	return strings.Replace(s, "a", "b", -1) // want `should use strings.ReplaceAll`
+}
@@ -0,0 +1,8 @@
+package replaceflag
+
+import "strings"
+
+func fn(s string) string {
+	// checked with -gosimple.go=1.11
+	return strings.Replace(s, "a", "b", -1)
+}
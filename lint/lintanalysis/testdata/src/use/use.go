@@ -0,0 +1,12 @@
+package use
+
+import "dep"
+
+func fn() {
+	dep.Old() // want `dep.Old is deprecated: Use New instead.`
+	dep.New()
+
+	var t dep.T
+	_ = t.F // want `t.F is deprecated: Use G instead.`
+	_ = t.G
+}
@@ -0,0 +1,161 @@
+package lintutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A baseline is a set of known problems that aren't reported.
+// Problems are identified by their file, check, message and the line
+// of code they are on, but not by their line number, so that they
+// remain known when unrelated code is added or removed. Identical
+// problems are counted, so that adding another instance of a known
+// problem gets reported.
+type baseline struct {
+	// dir is the directory of the baseline file. File names are
+	// relative to it.
+	dir     string
+	entries map[baselineKey]int
+	lines   map[string][]string
+}
+
+type baselineKey struct {
+	File    string `json:"file"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+	Context string `json:"context"`
+}
+
+type baselineEntry struct {
+	baselineKey
+	Count int `json:"count"`
+}
+
+type baselineFile struct {
+	Version  int             `json:"version"`
+	Problems []baselineEntry `json:"problems"`
+}
+
+type byBaselineKey []baselineEntry
+
+func (es byBaselineKey) Len() int      { return len(es) }
+func (es byBaselineKey) Swap(i, j int) { es[i], es[j] = es[j], es[i] }
+func (es byBaselineKey) Less(i, j int) bool {
+	a, b := es[i], es[j]
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.Check != b.Check {
+		return a.Check < b.Check
+	}
+	if a.Message != b.Message {
+		return a.Message < b.Message
+	}
+	return a.Context < b.Context
+}
+
+func newBaseline(path string) (*baseline, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return &baseline{
+		dir:     filepath.Dir(abs),
+		entries: map[baselineKey]int{},
+		lines:   map[string][]string{},
+	}, nil
+}
+
+func readBaseline(path string) (*baseline, error) {
+	b, err := newBaseline(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f baselineFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("couldn't parse baseline %s: %s", path, err)
+	}
+	if f.Version != 1 {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", f.Version, path)
+	}
+	for _, e := range f.Problems {
+		b.entries[e.baselineKey] += e.Count
+	}
+	return b, nil
+}
+
+// writeBaseline writes a baseline of ps to path.
+func writeBaseline(path string, ps []positioned) error {
+	b, err := newBaseline(path)
+	if err != nil {
+		return err
+	}
+	for _, p := range ps {
+		b.entries[b.key(p)]++
+	}
+	f := baselineFile{Version: 1, Problems: []baselineEntry{}}
+	for k, n := range b.entries {
+		f.Problems = append(f.Problems, baselineEntry{k, n})
+	}
+	sort.Sort(byBaselineKey(f.Problems))
+	data, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0666)
+}
+
+// filter returns the problems of ps that aren't in the baseline.
+func (b *baseline) filter(ps []positioned) []positioned {
+	var out []positioned
+	for _, p := range ps {
+		k := b.key(p)
+		if b.entries[k] > 0 {
+			b.entries[k]--
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func (b *baseline) key(p positioned) baselineKey {
+	file := p.pos.Filename
+	if abs, err := filepath.Abs(file); err == nil {
+		if rel, err := filepath.Rel(b.dir, abs); err == nil {
+			file = rel
+		}
+	}
+	return baselineKey{
+		File:    filepath.ToSlash(file),
+		Check:   p.Check,
+		Message: strings.TrimSuffix(p.Text, " ("+p.Check+")"),
+		Context: b.line(p.pos.Filename, p.pos.Line),
+	}
+}
+
+// line returns the trimmed source of a line.
+func (b *baseline) line(filename string, line int) string {
+	lines, ok := b.lines[filename]
+	if !ok {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		lines = strings.Split(string(data), "\n")
+		b.lines[filename] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
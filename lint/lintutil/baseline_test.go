@@ -0,0 +1,214 @@
+package lintutil
+
+import (
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"honnef.co/go/tools/lint"
+)
+
+const baselineSrc = `package pkg
+
+func fn() {
+	var x int
+	x = x
+	println(x)
+}
+`
+
+// baselineDir returns a temporary directory containing a.go with the
+// source src.
+func baselineDir(t *testing.T, src string) string {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0666); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func baselineProblem(dir string, line int, check, msg string) positioned {
+	return positioned{
+		pos:     token.Position{Filename: filepath.Join(dir, "a.go"), Line: line, Column: 2},
+		Problem: lint.Problem{Text: msg + " (" + check + ")", Check: check},
+	}
+}
+
+func TestBaselineRoundTrip(t *testing.T) {
+	dir := baselineDir(t, baselineSrc)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "baseline.json")
+
+	ps := []positioned{
+		baselineProblem(dir, 5, "SA4018", "self-assignment of x to x"),
+		baselineProblem(dir, 4, "S1021", "should merge variable declaration with assignment on next line"),
+		baselineProblem(dir, 5, "SA4018", "self-assignment of x to x"),
+	}
+	if err := writeBaseline(path, ps); err != nil {
+		t.Fatal(err)
+	}
+	b, err := readBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[baselineKey]int{
+		{"a.go", "S1021", "should merge variable declaration with assignment on next line", "var x int"}: 1,
+		{"a.go", "SA4018", "self-assignment of x to x", "x = x"}:                                         2,
+	}
+	if !reflect.DeepEqual(b.entries, want) {
+		t.Errorf("got entries %v, want %v", b.entries, want)
+	}
+	if got := b.filter(ps); len(got) != 0 {
+		t.Errorf("baseline didn't filter %v", got)
+	}
+
+	// writing the same problems in a different order produces the
+	// same file
+	data1, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps[0], ps[1] = ps[1], ps[0]
+	if err := writeBaseline(path, ps); err != nil {
+		t.Fatal(err)
+	}
+	data2, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data1) != string(data2) {
+		t.Errorf("baseline depends on the order of problems:\n%s\n%s", data1, data2)
+	}
+}
+
+func TestBaselineLineShift(t *testing.T) {
+	dir := baselineDir(t, baselineSrc)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "baseline.json")
+
+	if err := writeBaseline(path, []positioned{baselineProblem(dir, 5, "SA4018", "self-assignment of x to x")}); err != nil {
+		t.Fatal(err)
+	}
+	// add code above the problem, moving it from line 5 to line 7,
+	// and another instance of it further down
+	src := `package pkg
+
+func fn0() {}
+
+func fn() {
+	var x int
+	x = x
+	println(x)
+	x = x
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	b, err := readBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := []positioned{
+		baselineProblem(dir, 7, "SA4018", "self-assignment of x to x"),
+		baselineProblem(dir, 9, "SA4018", "self-assignment of x to x"),
+	}
+	got := b.filter(ps)
+	if len(got) != 1 {
+		t.Fatalf("got %d problems, want 1: %v", len(got), got)
+	}
+	if got[0].pos.Line != 9 {
+		t.Errorf("reported the problem on line %d, want the new one on line 9", got[0].pos.Line)
+	}
+}
+
+func TestBaselineStale(t *testing.T) {
+	dir := baselineDir(t, baselineSrc)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "baseline.json")
+
+	old := []positioned{
+		baselineProblem(dir, 4, "S1021", "should merge variable declaration with assignment on next line"),
+		baselineProblem(dir, 5, "SA4018", "self-assignment of x to x"),
+	}
+	if err := writeBaseline(path, old); err != nil {
+		t.Fatal(err)
+	}
+	// the self-assignment gets fixed, and the same mistake is made
+	// in a different way
+	src := `package pkg
+
+func fn() {
+	var x int
+	x, _ = x, 0
+	println(x)
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	b, err := readBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := []positioned{
+		baselineProblem(dir, 4, "S1021", "should merge variable declaration with assignment on next line"),
+		baselineProblem(dir, 5, "SA4018", "self-assignment of x to x"),
+	}
+	// the stale entry of the fixed problem doesn't hide the new one,
+	// even though check, message and line number are the same
+	got := b.filter(ps)
+	if len(got) != 1 || got[0].Check != "SA4018" {
+		t.Fatalf("got %v, want the self-assignment", got)
+	}
+
+	// writing a new baseline drops the stale entry
+	if err := writeBaseline(path, ps[:1]); err != nil {
+		t.Fatal(err)
+	}
+	b, err = readBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[baselineKey]int{
+		{"a.go", "S1021", "should merge variable declaration with assignment on next line", "var x int"}: 1,
+	}
+	if !reflect.DeepEqual(b.entries, want) {
+		t.Errorf("got entries %v, want %v", b.entries, want)
+	}
+}
+
+func TestReadBaselineErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"invalid.json", `{"version": 1, "problems": [`},
+		{"version.json", `{"version": 2, "problems": []}`},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := ioutil.WriteFile(path, []byte(tt.data), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readBaseline(path); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+	if _, err := readBaseline(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing file: expected an error")
+	}
+}
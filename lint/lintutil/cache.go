@@ -0,0 +1,451 @@
+package lintutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/build"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"honnef.co/go/tools/lint"
+)
+
+// A cache stores the problems found by earlier runs in each of the
+// checked packages, keyed by a hash of everything that affects them,
+// as computed by keys. Like the go build cache, entries that
+// haven't been used for a while are removed automatically.
+type cache struct {
+	dir string
+}
+
+const (
+	// trimInterval is how often the cache is trimmed.
+	trimInterval = 24 * time.Hour
+	// trimLimit is how long entries are kept after their last use.
+	trimLimit = 5 * 24 * time.Hour
+	// mtimeInterval is how often the modification time of entries
+	// is updated when they are used.
+	mtimeInterval = time.Hour
+)
+
+// cacheDir returns the directory of the cache, which is
+// $STATICCHECK_CACHE or a directory in the user's cache directory.
+func cacheDir() string {
+	if dir := os.Getenv("STATICCHECK_CACHE"); dir != "" {
+		return dir
+	}
+	var dir string
+	switch runtime.GOOS {
+	case "windows":
+		dir = os.Getenv("LocalAppData")
+	case "darwin":
+		if home := os.Getenv("HOME"); home != "" {
+			dir = filepath.Join(home, "Library", "Caches")
+		}
+	default:
+		dir = os.Getenv("XDG_CACHE_HOME")
+		if dir == "" && os.Getenv("HOME") != "" {
+			dir = filepath.Join(os.Getenv("HOME"), ".cache")
+		}
+	}
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "staticcheck")
+}
+
+func openCache() (*cache, error) {
+	dir := cacheDir()
+	if dir == "" {
+		return nil, fmt.Errorf("couldn't determine a cache directory; set $STATICCHECK_CACHE")
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	c := &cache{dir}
+	c.trim()
+	return c, nil
+}
+
+func (c *cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+"-d")
+}
+
+// cachedProblem is the representation of a problem in the cache.
+type cachedProblem struct {
+	Pos     token.Position
+	End     token.Position
+	Related []cachedRelated
+	Text    string
+	Check   string
+}
+
+type cachedRelated struct {
+	Pos     token.Position
+	Message string
+}
+
+// get returns the problems stored under key.
+func (c *cache) get(key string) ([]positioned, bool) {
+	name := c.path(key)
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, false
+	}
+	var cps []cachedProblem
+	if err := json.Unmarshal(data, &cps); err != nil {
+		return nil, false
+	}
+	if fi, err := os.Stat(name); err == nil && time.Since(fi.ModTime()) > mtimeInterval {
+		now := time.Now()
+		os.Chtimes(name, now, now)
+	}
+	ps := make([]positioned, len(cps))
+	for i, cp := range cps {
+		ps[i] = positioned{
+			pos: cp.Pos,
+			end: cp.End,
+			Problem: lint.Problem{
+				Text:  cp.Text,
+				Check: cp.Check,
+			},
+		}
+		for _, r := range cp.Related {
+			ps[i].related = append(ps[i].related, relatedPosition{r.Pos, r.Message})
+		}
+	}
+	return ps, true
+}
+
+// put stores ps under key.
+func (c *cache) put(key string, ps []positioned) error {
+	cps := make([]cachedProblem, len(ps))
+	for i, p := range ps {
+		cps[i] = cachedProblem{
+			Pos:   p.pos,
+			End:   p.end,
+			Text:  p.Text,
+			Check: p.Check,
+		}
+		for _, r := range p.related {
+			cps[i].Related = append(cps[i].Related, cachedRelated{r.pos, r.message})
+		}
+	}
+	data, err := json.Marshal(cps)
+	if err != nil {
+		return err
+	}
+	name := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		return err
+	}
+	// write to a temporary file first, so that concurrent runs never
+	// see partial entries
+	tmp, err := writeTempFile(filepath.Dir(name), "tmp", data)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// trim removes entries that haven't been used for trimLimit, at most
+// once per trimInterval.
+func (c *cache) trim() {
+	now := time.Now()
+	marker := filepath.Join(c.dir, "trim.txt")
+	if fi, err := os.Stat(marker); err == nil && now.Sub(fi.ModTime()) < trimInterval {
+		return
+	}
+	subdirs, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, sub := range subdirs {
+		if !sub.IsDir() {
+			continue
+		}
+		dir := filepath.Join(c.dir, sub.Name())
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if now.Sub(e.ModTime()) > trimLimit {
+				os.Remove(filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	ioutil.WriteFile(marker, []byte(fmt.Sprintf("%d\n", now.Unix())), 0666)
+}
+
+// uncachedFlags are the flags that don't affect which problems are
+// found.
+var uncachedFlags = map[string]bool{
+	"f":              true,
+	"since-version":  true,
+	"baseline":       true,
+	"baseline.write": true,
+	"cache":          true,
+	"memory":         true,
+	"patch":          true,
+	"fail":           true,
+}
+
+// A packageKey is the cache key of one of the packages being
+// checked.
+type packageKey struct {
+	// path is the package's path as given on the command line.
+	path string
+	dir  string
+	key  string
+	// importers are the paths of the other packages being checked
+	// that import the package, directly or indirectly.
+	importers []string
+}
+
+// keys computes the cache keys of the packages paths, as loaded
+// by ctx. It returns an error if any of the packages can't be found,
+// in which case the loader will report a better one.
+//
+// The problems in a package depend on the package and its
+// dependencies, and on the code that uses it, such as callers of
+// its functions. A package's key therefore covers the sources of
+// the package, of the packages being checked that import it, and of
+// all of their dependencies, along with the configuration and go.mod
+// files that apply to them. It also covers the flags, the contents
+// of files named by flags, the executable and the version of Go.
+func (c *cache) keys(tool string, ctx *build.Context, paths []string, tests bool, fs *flag.FlagSet) ([]packageKey, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "lint cache v2\n%s %s %s\n", tool, lint.Version, runtime.Version())
+	exe, err := c.executableHash()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(h, "executable %s\n", exe)
+	fmt.Fprintf(h, "%s %s %q\n", ctx.GOOS, ctx.GOARCH, ctx.BuildTags)
+	if wd, err := os.Getwd(); err == nil {
+		// problems are reported relative to the working directory
+		fmt.Fprintf(h, "wd %s\n", wd)
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		if uncachedFlags[f.Name] || err != nil {
+			return
+		}
+		fmt.Fprintf(h, "flag %s=%s\n", f.Name, f.Value)
+		// flags that name files, such as -structtags, say so in
+		// their usage
+		if name, _ := flag.UnquoteUsage(f); (name == "file" || name == "files") && f.Value.String() != "" {
+			for _, file := range strings.Split(f.Value.String(), ",") {
+				if err = hashFile(h, file); err != nil {
+					return
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	base := h.Sum(nil)
+
+	// collect the source files of the packages and their
+	// dependencies, keyed by directory
+	files := map[string][]string{}
+	imports := map[string][]string{}
+	var visit func(path, srcDir string, initial bool) (string, error)
+	visit = func(path, srcDir string, initial bool) (string, error) {
+		pkg, err := ctx.Import(path, srcDir, 0)
+		if err != nil {
+			if _, ok := err.(*build.NoGoError); !ok {
+				return "", err
+			}
+		}
+		if _, ok := files[pkg.Dir]; ok && !initial {
+			return pkg.Dir, nil
+		}
+		names := append(append([]string(nil), pkg.GoFiles...), pkg.CgoFiles...)
+		deps := pkg.Imports
+		if initial && tests {
+			names = append(append(names, pkg.TestGoFiles...), pkg.XTestGoFiles...)
+			deps = append(append(append([]string(nil), deps...), pkg.TestImports...), pkg.XTestImports...)
+		}
+		files[pkg.Dir] = nil
+		for _, name := range names {
+			files[pkg.Dir] = append(files[pkg.Dir], filepath.Join(pkg.Dir, name))
+		}
+		imports[pkg.Dir] = nil
+		for _, imp := range deps {
+			if imp == "C" {
+				continue
+			}
+			dir, err := visit(imp, pkg.Dir, false)
+			if err != nil {
+				return "", err
+			}
+			imports[pkg.Dir] = append(imports[pkg.Dir], dir)
+		}
+		return pkg.Dir, nil
+	}
+	wd, _ := os.Getwd()
+	keys := make([]packageKey, len(paths))
+	for i, path := range paths {
+		dir, err := visit(path, wd, true)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = packageKey{path: path, dir: dir}
+	}
+
+	// deps returns dir and its transitive dependencies
+	deps := map[string]map[string]bool{}
+	var depsOf func(dir string) map[string]bool
+	depsOf = func(dir string) map[string]bool {
+		if out, ok := deps[dir]; ok {
+			return out
+		}
+		out := map[string]bool{dir: true}
+		deps[dir] = out
+		for _, imp := range imports[dir] {
+			for d := range depsOf(imp) {
+				out[d] = true
+			}
+		}
+		return out
+	}
+	// hashes caches the hashes of the files of a directory
+	hashes := map[string][]byte{}
+	for i := range keys {
+		pk := &keys[i]
+		covered := map[string]bool{}
+		for d := range depsOf(pk.dir) {
+			covered[d] = true
+		}
+		for _, other := range keys {
+			if other.dir != pk.dir && depsOf(other.dir)[pk.dir] {
+				pk.importers = append(pk.importers, other.path)
+				for d := range depsOf(other.dir) {
+					covered[d] = true
+				}
+			}
+		}
+		var dirs []string
+		for d := range covered {
+			dirs = append(dirs, d)
+		}
+		sort.Strings(dirs)
+
+		h := sha256.New()
+		h.Write(base)
+		fmt.Fprintf(h, "arg %s\n", pk.path)
+		for _, dir := range dirs {
+			sum, ok := hashes[dir]
+			if !ok {
+				var err error
+				sum, err = hashDir(dir, files[dir])
+				if err != nil {
+					return nil, err
+				}
+				hashes[dir] = sum
+			}
+			fmt.Fprintf(h, "dir %s %x\n", dir, sum)
+		}
+		pk.key = hex.EncodeToString(h.Sum(nil))
+	}
+	return keys, nil
+}
+
+// hashDir hashes files, the source files of the package in dir, and
+// the configuration and go.mod files that apply to dir.
+func hashDir(dir string, files []string) ([]byte, error) {
+	h := sha256.New()
+	for d := dir; ; {
+		for _, name := range []string{ConfigName, "go.mod"} {
+			if _, err := os.Stat(filepath.Join(d, name)); err == nil {
+				files = append(files, filepath.Join(d, name))
+			}
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+	}
+	for _, name := range files {
+		if err := hashFile(h, name); err != nil {
+			return nil, err
+		}
+	}
+	return h.Sum(nil), nil
+}
+
+func hashFile(h io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Fprintf(h, "file %s\n", name)
+	_, err = io.Copy(h, f)
+	return err
+}
+
+var (
+	exeOnce sync.Once
+	exeSum  string
+	exeErr  error
+)
+
+// executableHash returns the hash of the running executable, so that
+// rebuilding the tools with changed checks invalidates the cache even
+// if the version stays the same. Hashing a large binary takes a
+// while, so the hash is computed once per process and remembered in
+// the cache, keyed by the executable's path, size and modification
+// time.
+func (c *cache) executableHash() (string, error) {
+	exeOnce.Do(func() {
+		exe, err := os.Executable()
+		if err != nil {
+			exeErr = err
+			return
+		}
+		fi, err := os.Stat(exe)
+		if err != nil {
+			exeErr = err
+			return
+		}
+		k := sha256.Sum256([]byte(fmt.Sprintf("executable %s %d %d", exe, fi.Size(), fi.ModTime().UnixNano())))
+		key := hex.EncodeToString(k[:])
+		name := filepath.Join(c.dir, key[:2], key+"-x")
+		if data, err := ioutil.ReadFile(name); err == nil && len(data) == sha256.Size*2 {
+			exeSum = string(data)
+			return
+		}
+		h := sha256.New()
+		if exeErr = hashFile(h, exe); exeErr != nil {
+			return
+		}
+		exeSum = hex.EncodeToString(h.Sum(nil))
+		if err := os.MkdirAll(filepath.Dir(name), 0777); err == nil {
+			if tmp, err := writeTempFile(filepath.Dir(name), "tmp", []byte(exeSum)); err == nil {
+				if os.Rename(tmp, name) != nil {
+					os.Remove(tmp)
+				}
+			}
+		}
+	})
+	return exeSum, exeErr
+}
@@ -0,0 +1,66 @@
+package lintutil
+
+import (
+	"go/token"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"honnef.co/go/tools/lint"
+)
+
+func tempCache(t *testing.T) *cache {
+	dir, err := ioutil.TempDir("", "lintutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cache{dir}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	c := tempCache(t)
+	defer os.RemoveAll(c.dir)
+	ps := []positioned{
+		{
+			pos:     token.Position{Filename: "a.go", Offset: 10, Line: 2, Column: 3},
+			end:     token.Position{Filename: "a.go", Offset: 15, Line: 2, Column: 8},
+			related: []relatedPosition{{token.Position{Filename: "b.go", Line: 1, Column: 1}, "declared here"}},
+			Problem: lint.Problem{Text: "something is wrong (SA1000)", Check: "SA1000"},
+		},
+	}
+	key := "0123456789abcdef"
+	if _, ok := c.get(key); ok {
+		t.Fatal("got an entry from an empty cache")
+	}
+	if err := c.put(key, ps); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := c.get(key)
+	if !ok {
+		t.Fatal("entry missing after put")
+	}
+	if !reflect.DeepEqual(got, ps) {
+		t.Errorf("got %+v, want %+v", got, ps)
+	}
+
+	if err := c.put(key, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := c.get(key); !ok || len(got) != 0 {
+		t.Errorf("got %v, %t for an empty entry", got, ok)
+	}
+}
+
+func TestExecutableHash(t *testing.T) {
+	c := tempCache(t)
+	defer os.RemoveAll(c.dir)
+	h1, err := c.executableHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, _ := c.executableHash()
+	if len(h1) != 64 || h1 != h2 {
+		t.Errorf("got hashes %q and %q", h1, h2)
+	}
+}
@@ -0,0 +1,337 @@
+package lintutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/loader"
+)
+
+// ConfigName is the name of configuration files.
+const ConfigName = "staticcheck.conf"
+
+// A Config is the configuration stored in a configuration file. It
+// applies to the files in the directory of the configuration file
+// and all of its subdirectories. Configurations in subdirectories
+// are merged with the ones of their parents.
+type Config struct {
+	// Checks enables and disables checks. Each entry is a check ID,
+	// a glob pattern matching check IDs, such as SA4* for a group of
+	// checks, or "all". Entries prefixed with a '-' disable the
+	// matching checks. Entries are applied in order, after those of
+	// the configurations of parent directories. All checks are
+	// enabled by default.
+	Checks []string
+	// Exclude lists glob patterns of slash-separated paths, relative
+	// to the directory of the configuration file, in which no
+	// problems are reported. A pattern that matches a directory
+	// excludes all files in it.
+	Exclude []string
+	// Severity sets the severity of checks. Each entry has the form
+	// pattern=severity, where pattern is a check ID, glob pattern or
+	// "all" as in Checks, and severity is error, warning or info.
+	// Entries are applied in order, after those of the
+	// configurations of parent directories. Problems are warnings
+	// by default.
+	Severity []string
+	// GoVersion is the minor Go version that the packages target,
+	// such as 9 for Go 1.9, overriding the go directive of go.mod
+	// files. Zero means unset. Configurations in subdirectories
+	// override the ones of their parents.
+	GoVersion int
+
+	dir string
+}
+
+// The severities of problems.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityInfo    = "info"
+)
+
+func validSeverity(s string) bool {
+	switch s {
+	case severityError, severityWarning, severityInfo:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseConfig parses a configuration file. Configuration files are
+// written in a subset of TOML: the keys checks, exclude and severity,
+// each assigned an array of strings, and the key go, assigned a string
+// such as "1.9". dir is the directory the configuration applies to.
+func ParseConfig(data []byte, dir string) (*Config, error) {
+	cfg := &Config{dir: dir}
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineno := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq == -1 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineno)
+		}
+		key := strings.TrimSpace(line[:eq])
+		value := strings.TrimSpace(line[eq+1:])
+		// arrays may span several lines
+		for strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]") && i+1 < len(lines) {
+			i++
+			value += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+		if key == "go" {
+			v, err := strconv.Unquote(value)
+			if err != nil || !strings.HasPrefix(value, `"`) {
+				return nil, fmt.Errorf("line %d: expected a string, got %s", lineno, value)
+			}
+			var version versionFlag
+			if err := version.Set(v); err != nil {
+				return nil, fmt.Errorf("line %d: invalid Go version %q, expected 1.x", lineno, v)
+			}
+			cfg.GoVersion = int(version)
+			continue
+		}
+		values, err := parseStringArray(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineno, err)
+		}
+		switch key {
+		case "checks":
+			cfg.Checks = values
+		case "exclude":
+			cfg.Exclude = values
+		case "severity":
+			for _, v := range values {
+				pattern, sev := splitSeverity(v)
+				if pattern == "" || !validSeverity(sev) {
+					return nil, fmt.Errorf("line %d: invalid severity %q, expected check=error, check=warning or check=info", lineno, v)
+				}
+			}
+			cfg.Severity = values
+		case "initialisms":
+			// style linters use it, but no check here looks at names
+			return nil, fmt.Errorf("line %d: initialisms isn't supported, as no check looks at naming style", lineno)
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", lineno, key)
+		}
+	}
+	return cfg, nil
+}
+
+// splitSeverity splits an entry of Config.Severity into its pattern
+// and severity.
+func splitSeverity(s string) (pattern, sev string) {
+	i := strings.LastIndex(s, "=")
+	if i == -1 {
+		return "", ""
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+}
+
+// matchCheck reports whether pattern, an entry of Config.Checks
+// without its '-' prefix, matches check.
+func matchCheck(pattern, check string) bool {
+	if pattern == "all" {
+		pattern = "*"
+	}
+	m, _ := filepath.Match(pattern, check)
+	return m
+}
+
+// stripComment removes a comment from a line, ignoring # in strings.
+func stripComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func parseStringArray(s string) ([]string, error) {
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("expected an array of strings, got %s", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	var out []string
+	for s != "" {
+		if s[0] != '"' {
+			return nil, fmt.Errorf("expected a string, got %s", s)
+		}
+		end := 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		v, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", s[:end+1])
+		}
+		out = append(out, v)
+		s = strings.TrimSpace(s[end+1:])
+		if s == "" {
+			break
+		}
+		if s[0] != ',' {
+			return nil, fmt.Errorf("expected a comma, got %s", s)
+		}
+		// a trailing comma is allowed
+		s = strings.TrimSpace(s[1:])
+	}
+	return out, nil
+}
+
+func (cfg *Config) excludes(filename string) bool {
+	rel, err := filepath.Rel(cfg.dir, filename)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range cfg.Exclude {
+		for p := rel; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			if m, _ := path.Match(pattern, p); m {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// configs finds and caches the configurations of directories.
+type configs struct {
+	// dirs maps directories to the configurations that apply to
+	// them, outermost first.
+	dirs map[string][]*Config
+}
+
+// load loads the configurations of all files in the program.
+func (cs *configs) load(lprog *loader.Program) error {
+	if cs.dirs == nil {
+		cs.dirs = map[string][]*Config{}
+	}
+	for _, pkg := range lprog.InitialPackages() {
+		for _, f := range pkg.Files {
+			dir, err := filepath.Abs(filepath.Dir(lprog.Fset.File(f.Pos()).Name()))
+			if err != nil {
+				return err
+			}
+			if _, err := cs.dir(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cs *configs) dir(dir string) ([]*Config, error) {
+	if cfgs, ok := cs.dirs[dir]; ok {
+		return cfgs, nil
+	}
+	var cfgs []*Config
+	if parent := filepath.Dir(dir); parent != dir {
+		var err error
+		cfgs, err = cs.dir(parent)
+		if err != nil {
+			return nil, err
+		}
+	}
+	name := filepath.Join(dir, ConfigName)
+	data, err := ioutil.ReadFile(name)
+	if err == nil {
+		cfg, err := ParseConfig(data, dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		cfgs = append(cfgs[:len(cfgs):len(cfgs)], cfg)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	cs.dirs[dir] = cfgs
+	return cfgs, nil
+}
+
+// enabled reports whether check is enabled for the file filename.
+// The configurations of the file's directory must have been loaded.
+func (cs *configs) enabled(check, filename string) bool {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return true
+	}
+	cfgs := cs.dirs[filepath.Dir(abs)]
+	on := true
+	for _, cfg := range cfgs {
+		if cfg.excludes(abs) {
+			return false
+		}
+		for _, c := range cfg.Checks {
+			disable := strings.HasPrefix(c, "-")
+			if matchCheck(strings.TrimPrefix(c, "-"), check) {
+				on = !disable
+			}
+		}
+	}
+	return on
+}
+
+// goVersion returns the Go version that the configurations of dir
+// set, if any. The configurations of dir must have been loaded.
+func (cs *configs) goVersion(dir string) (int, bool) {
+	cfgs := cs.dirs[dir]
+	for i := len(cfgs) - 1; i >= 0; i-- {
+		if cfgs[i].GoVersion != 0 {
+			return cfgs[i].GoVersion, true
+		}
+	}
+	return 0, false
+}
+
+// severity returns the severity of problems of check in the file
+// filename. Unlike enabled, it loads the configurations it needs, as
+// cached problems are reported without loading the program.
+func (cs *configs) severity(check, filename string) string {
+	sev := severityWarning
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return sev
+	}
+	if cs.dirs == nil {
+		cs.dirs = map[string][]*Config{}
+	}
+	cfgs, err := cs.dir(filepath.Dir(abs))
+	if err != nil {
+		return sev
+	}
+	for _, cfg := range cfgs {
+		for _, s := range cfg.Severity {
+			pattern, v := splitSeverity(s)
+			if matchCheck(pattern, check) {
+				sev = v
+			}
+		}
+	}
+	return sev
+}
@@ -0,0 +1,171 @@
+package lintutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Config
+	}{
+		{
+			name: "empty",
+			data: "\n# only a comment\n\n",
+			want: Config{},
+		},
+		{
+			name: "all keys",
+			data: `checks = ["all", "-SA1000", "S1*"]
+exclude = ["vendor", "*_gen.go"] # generated
+severity = ["SA4006=error", "all = info"]
+go = "1.9"
+`,
+			want: Config{
+				Checks:    []string{"all", "-SA1000", "S1*"},
+				Exclude:   []string{"vendor", "*_gen.go"},
+				Severity:  []string{"SA4006=error", "all = info"},
+				GoVersion: 9,
+			},
+		},
+		{
+			name: "patch release",
+			data: `go = "1.21.3"`,
+			want: Config{GoVersion: 21},
+		},
+		{
+			name: "empty array",
+			data: `checks = []`,
+			want: Config{},
+		},
+		{
+			name: "multi-line array with trailing comma and comments",
+			data: `checks = [
+	"SA1000", # first
+	"-SA1001",
+]`,
+			want: Config{Checks: []string{"SA1000", "-SA1001"}},
+		},
+		{
+			name: "quoting",
+			data: `exclude = ["a#b", "c\"d", "e,f", "x\\y"] # "quoted"`,
+			want: Config{Exclude: []string{"a#b", `c"d`, "e,f", `x\y`}},
+		},
+		{
+			name: "whitespace",
+			data: "   checks=[\"a\" ,\"b\"]   ",
+			want: Config{Checks: []string{"a", "b"}},
+		},
+		{
+			name: "later keys win",
+			data: "checks = [\"a\"]\nchecks = [\"b\"]",
+			want: Config{Checks: []string{"b"}},
+		},
+	}
+	for _, tt := range tests {
+		cfg, err := ParseConfig([]byte(tt.data), "dir")
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		tt.want.dir = "dir"
+		if !reflect.DeepEqual(*cfg, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *cfg, tt.want)
+		}
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{`checks`, "line 1: expected key = value"},
+		{"\nchecks = \"SA1000\"", "line 2: expected an array of strings"},
+		{`checks = [SA1000]`, "expected a string"},
+		{`checks = ["SA1000" "SA1001"]`, "expected a comma"},
+		{`checks = ["SA1000`, "expected an array of strings"},
+		{`checks = ["SA1000]`, "unterminated string"},
+		{"checks = [\n\"SA1000\",\n", "expected an array of strings"},
+		{`checks = ["a",, "b"]`, "expected a string"},
+		{`unknown = []`, `unknown key "unknown"`},
+		{`initialisms = ["ID"]`, "initialisms isn't supported"},
+		{`severity = ["SA1000"]`, "invalid severity"},
+		{`severity = ["SA1000=fatal"]`, "invalid severity"},
+		{`go = 1.9`, "expected a string"},
+		{`go = "2.0"`, "invalid Go version"},
+		{`go = "1.x"`, "invalid Go version"},
+	}
+	for _, tt := range tests {
+		_, err := ParseConfig([]byte(tt.data), "dir")
+		if err == nil {
+			t.Errorf("ParseConfig(%q) succeeded, want an error", tt.data)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ParseConfig(%q) = %q, want an error containing %q", tt.data, err, tt.err)
+		}
+	}
+}
+
+func TestConfigsMerge(t *testing.T) {
+	root, err := ioutil.TempDir("", "lintutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	sub := filepath.Join(root, "sub")
+	if err := os.MkdirAll(filepath.Join(sub, "gen"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	write := func(dir, data string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, ConfigName), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(root, `checks = ["-SA1*"]
+severity = ["all=error"]
+go = "1.8"`)
+	write(sub, `checks = ["SA1000"]
+exclude = ["gen"]
+severity = ["SA1000=info"]`)
+
+	cs := &configs{dirs: map[string][]*Config{}}
+	if _, err := cs.dir(filepath.Join(sub, "gen")); err != nil {
+		t.Fatal(err)
+	}
+	rootFile := filepath.Join(root, "a.go")
+	subFile := filepath.Join(sub, "a.go")
+	genFile := filepath.Join(sub, "gen", "a.go")
+	enabled := []struct {
+		check, file string
+		want        bool
+	}{
+		{"SA1000", rootFile, false},
+		{"SA1001", rootFile, false},
+		{"SA2000", rootFile, true},
+		{"SA1000", subFile, true},
+		{"SA1001", subFile, false},
+		{"SA2000", genFile, false},
+	}
+	for _, tt := range enabled {
+		if got := cs.enabled(tt.check, tt.file); got != tt.want {
+			t.Errorf("enabled(%s, %s) = %t, want %t", tt.check, tt.file, got, tt.want)
+		}
+	}
+	if got := cs.severity("SA1000", subFile); got != severityInfo {
+		t.Errorf("severity of SA1000 in sub is %s, want info", got)
+	}
+	if got := cs.severity("SA2000", subFile); got != severityError {
+		t.Errorf("severity of SA2000 in sub is %s, want error", got)
+	}
+	if v, ok := cs.goVersion(sub); !ok || v != 8 {
+		t.Errorf("Go version of sub is %d, %t, want 8", v, ok)
+	}
+}
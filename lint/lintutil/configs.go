@@ -0,0 +1,154 @@
+package lintutil
+
+import (
+	"fmt"
+	"go/build"
+	"go/token"
+	"sort"
+	"strings"
+
+	"honnef.co/go/tools/lint"
+
+	"golang.org/x/tools/go/loader"
+)
+
+// A BuildConfig is a target platform and a set of build tags to load
+// packages for.
+type BuildConfig struct {
+	GOOS   string
+	GOARCH string
+	// Tags are used in addition to the ones specified with -tags.
+	Tags []string
+}
+
+func (cfg BuildConfig) String() string {
+	s := cfg.GOOS + "/" + cfg.GOARCH
+	if len(cfg.Tags) > 0 {
+		s += "," + strings.Join(cfg.Tags, ",")
+	}
+	return s
+}
+
+// ParseBuildConfigs parses a whitespace-separated list of build
+// configurations. Each configuration is written as goos/goarch,
+// optionally followed by comma-separated build tags, for example
+// "linux/amd64 windows/386,purego".
+func ParseBuildConfigs(s string) ([]BuildConfig, error) {
+	var cfgs []BuildConfig
+	for _, field := range strings.Fields(s) {
+		parts := strings.Split(field, ",")
+		platform := strings.Split(parts[0], "/")
+		if len(platform) != 2 || platform[0] == "" || platform[1] == "" {
+			return nil, fmt.Errorf("malformed build configuration %q, expected goos/goarch[,tag...]", field)
+		}
+		cfg := BuildConfig{GOOS: platform[0], GOARCH: platform[1]}
+		for _, tag := range parts[1:] {
+			if tag != "" {
+				cfg.Tags = append(cfg.Tags, tag)
+			}
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	return cfgs, nil
+}
+
+// positioned is a problem together with its resolved positions,
+// which remain meaningful once the program it was found in is gone.
+type positioned struct {
+	pos     token.Position
+	end     token.Position
+	related []relatedPosition
+	lint.Problem
+}
+
+type relatedPosition struct {
+	pos     token.Position
+	message string
+}
+
+func newPositioned(fset *token.FileSet, p lint.Problem) positioned {
+	out := positioned{pos: fset.Position(p.Position), Problem: p}
+	if p.End.IsValid() {
+		out.end = fset.Position(p.End)
+	}
+	for _, r := range p.Related {
+		out.related = append(out.related, relatedPosition{fset.Position(r.Pos), r.Message})
+	}
+	return out
+}
+
+type byPosition []positioned
+
+func (ps byPosition) Len() int      { return len(ps) }
+func (ps byPosition) Swap(i, j int) { ps[i], ps[j] = ps[j], ps[i] }
+func (ps byPosition) Less(i, j int) bool {
+	pi, pj := ps[i].pos, ps[j].pos
+	if pi.Filename != pj.Filename {
+		return pi.Filename < pj.Filename
+	}
+	if pi.Line != pj.Line {
+		return pi.Line < pj.Line
+	}
+	if pi.Column != pj.Column {
+		return pi.Column < pj.Column
+	}
+	return ps[i].Text < ps[j].Text
+}
+
+// lintConfigs loads and checks the packages under each of configs
+// and returns the problems found under all configurations that
+// compile the files the problems are in.
+func (runner *runner) lintConfigs(newChecker func() lint.Checker, configs []BuildConfig, load func(*build.Context) *loader.Program) []positioned {
+	type key struct {
+		file   string
+		offset int
+		text   string
+	}
+	// found counts the configurations a problem was found under,
+	// compiled the configurations a file was compiled under.
+	found := map[key]int{}
+	compiled := map[string]int{}
+	first := map[key]positioned{}
+	for _, cfg := range configs {
+		ctx := build.Default
+		ctx.GOOS = cfg.GOOS
+		ctx.GOARCH = cfg.GOARCH
+		ctx.BuildTags = append(append([]string(nil), runner.tags...), cfg.Tags...)
+		if cfg.GOOS != build.Default.GOOS || cfg.GOARCH != build.Default.GOARCH {
+			// cgo can't process files for other platforms
+			ctx.CgoEnabled = false
+		}
+		// the previous checker holds on to the previous program;
+		// release it before loading the next one
+		runner.checker = newChecker()
+		lprog := load(&ctx)
+		for _, pkg := range lprog.InitialPackages() {
+			for _, f := range pkg.Files {
+				compiled[lprog.Fset.File(f.Pos()).Name()]++
+			}
+		}
+
+		seen := map[key]bool{}
+		for _, p := range runner.lint(lprog) {
+			pp := newPositioned(lprog.Fset, p)
+			k := key{pp.pos.Filename, pp.pos.Offset, p.Text}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			if found[k] == 0 {
+				first[k] = pp
+			}
+			found[k]++
+		}
+	}
+
+	var out []positioned
+	for k, n := range found {
+		if n == compiled[k.file] {
+			out = append(out, first[k])
+		}
+	}
+	sort.Sort(byPosition(out))
+	return out
+}
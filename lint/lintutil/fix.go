@@ -0,0 +1,221 @@
+package lintutil
+
+import (
+	"fmt"
+	"go/build"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+
+	"honnef.co/go/tools/lint"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/loader"
+)
+
+// maxFixRounds limits how often fixAll checks the packages again
+// after applying fixes.
+const maxFixRounds = 10
+
+// fixAll checks the packages and applies the suggested fixes in
+// memory, repeating until no more fixes apply. Applying fixes may
+// cause new problems, such as an identifier that was only used by
+// deleted code, and fixes that overlapped with earlier ones can only
+// be applied in a later round. Afterwards, the fixed files are
+// written to disk, or printed as diffs, followed by a summary on
+// standard error. fixAll returns the problems that remain, and the
+// program they were found in.
+func (runner *runner) fixAll(newChecker func() lint.Checker, ctx *build.Context, load func(*build.Context) *loader.Program) (*loader.Program, []lint.Problem) {
+	overlay := map[string][]byte{}
+	var lprog *loader.Program
+	var ps []lint.Problem
+	fixed := 0
+	for i := 0; i < maxFixRounds; i++ {
+		if i > 0 {
+			// release the previous round's program before loading
+			// the next one
+			lprog = nil
+			runner.checker = newChecker()
+		}
+		lprog = load(buildutil.OverlayContext(ctx, overlay))
+		all := runner.lint(lprog)
+		var files map[string][]byte
+		ps, files = runner.fixes(lprog, all, overlay)
+		if len(files) == 0 {
+			break
+		}
+		fixed += len(all) - len(ps)
+		for name, src := range files {
+			overlay[name] = src
+		}
+	}
+	runner.writeFixes(lprog, overlay)
+	if fixed > 0 {
+		verb := "fixed"
+		if runner.diff {
+			verb = "would fix"
+		}
+		fmt.Fprintf(os.Stderr, "%s %d problems in %d files, %d problems remain\n", verb, fixed, len(overlay), len(ps))
+	}
+	return lprog, ps
+}
+
+// fixes applies the suggested fixes of ps to the files of lprog,
+// whose contents are taken from overlay or the disk, and returns the
+// problems that weren't fixed and the new contents of the changed
+// files.
+func (runner *runner) fixes(lprog *loader.Program, ps []lint.Problem, overlay map[string][]byte) ([]lint.Problem, map[string][]byte) {
+	// Problems in generated files may be reported, but fixing them
+	// would be undone by the next run of the generator.
+	generated := map[*token.File]bool{}
+	for _, pkg := range lprog.InitialPackages() {
+		for _, f := range pkg.Files {
+			if lint.IsGenerated(f) {
+				generated[lprog.Fset.File(f.Pos())] = true
+			}
+		}
+	}
+	fixable := make([]lint.Problem, len(ps))
+	copy(fixable, ps)
+	for i := range fixable {
+		if generated[lprog.Fset.File(fixable[i].Position)] {
+			fixable[i].Fix = nil
+		}
+	}
+	readFile := func(name string) ([]byte, error) {
+		if src, ok := overlay[name]; ok {
+			return src, nil
+		}
+		return ioutil.ReadFile(name)
+	}
+	files, fixed, err := lint.ApplyFixes(lprog.Fset, fixable, readFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		runner.unclean = true
+		return ps, nil
+	}
+	var out []lint.Problem
+	for i, p := range ps {
+		if !fixed[i] {
+			out = append(out, p)
+		}
+	}
+	return out, files
+}
+
+// writeFixes writes the fixed files to disk, or prints them as
+// unified diffs. Files that were left without any declarations are
+// removed, unless they document their package or are all that is
+// left of it.
+func (runner *runner) writeFixes(lprog *loader.Program, files map[string][]byte) {
+	remove := map[string]bool{}
+	for _, pkg := range lprog.InitialPackages() {
+		var empty []string
+		keep := false
+		for _, f := range pkg.Files {
+			name := lprog.Fset.File(f.Pos()).Name()
+			if len(f.Decls) > 0 || f.Doc != nil {
+				keep = true
+				continue
+			}
+			if _, ok := files[name]; ok {
+				empty = append(empty, name)
+			}
+		}
+		if keep {
+			for _, name := range empty {
+				remove[name] = true
+			}
+		}
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		src := files[name]
+		if runner.diff {
+			orig, err := ioutil.ReadFile(name)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				runner.unclean = true
+				continue
+			}
+			label := shortPath(name)
+			newLabel := label
+			if remove[name] {
+				src = nil
+				newLabel = os.DevNull
+			}
+			d, err := diff(label, newLabel, orig, src)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "computing diff: %s\n", err)
+				runner.unclean = true
+				continue
+			}
+			os.Stdout.Write(d)
+			continue
+		}
+		if remove[name] {
+			if err := os.Remove(name); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				runner.unclean = true
+			}
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			runner.unclean = true
+			continue
+		}
+		if err := ioutil.WriteFile(name, src, fi.Mode()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			runner.unclean = true
+		}
+	}
+}
+
+// diff returns a unified diff of b1 and b2, computed by the diff
+// command, the same way gofmt -d does.
+func diff(label1, label2 string, b1, b2 []byte) ([]byte, error) {
+	f1, err := writeTempFile("", "lint", b1)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f1)
+
+	f2, err := writeTempFile("", "lint", b2)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f2)
+
+	data, err := exec.Command("diff", "-u", "--label", label1, "--label", label2, f1, f2).CombinedOutput()
+	if len(data) > 0 {
+		// diff exits with a non-zero status when the files don't
+		// match. Ignore that failure as long as we get output.
+		return data, nil
+	}
+	return data, err
+}
+
+func writeTempFile(dir, prefix string, data []byte) (string, error) {
+	file, err := ioutil.TempFile(dir, prefix)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	if err1 := file.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
@@ -0,0 +1,322 @@
+package lintutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"honnef.co/go/tools/lint"
+)
+
+// formatFlag is the value of the -f flag. It also records the name of
+// the tool, which some formats include in their output.
+type formatFlag struct {
+	tool   string
+	format string
+}
+
+func (f *formatFlag) String() string   { return f.format }
+func (f *formatFlag) Get() interface{} { return f }
+
+func (f *formatFlag) Set(s string) error {
+	switch s {
+	case "text", "json", "sarif":
+		f.format = s
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q", s)
+	}
+}
+
+// A formatter prints problems in a particular output format.
+type formatter interface {
+	// format formats a problem of severity sev. isNew reports
+	// whether it was found by a check newer than -since-version.
+	format(p positioned, sev string, isNew bool)
+	// flush is called after all problems have been formatted.
+	flush()
+}
+
+// newFormatter returns a formatter that writes problems to w.
+func newFormatter(w io.Writer, f *formatFlag, checker lint.Checker, object func(token.Position) (interface{}, bool)) formatter {
+	switch f.format {
+	case "json":
+		return jsonFormatter{enc: json.NewEncoder(w), object: object}
+	case "sarif":
+		return &sarifFormatter{w: w, tool: f.tool, checker: checker}
+	default:
+		return textFormatter{w}
+	}
+}
+
+type textFormatter struct {
+	w io.Writer
+}
+
+func (f textFormatter) format(p positioned, sev string, isNew bool) {
+	switch {
+	case isNew:
+		fmt.Fprintf(f.w, "%v: [new] %s\n", relativePositionString(p.pos), p.Text)
+	case sev != severityWarning:
+		// warnings are the default and aren't marked
+		fmt.Fprintf(f.w, "%v: [%s] %s\n", relativePositionString(p.pos), sev, p.Text)
+	default:
+		fmt.Fprintf(f.w, "%v: %s\n", relativePositionString(p.pos), p.Text)
+	}
+}
+
+func (textFormatter) flush() {}
+
+// jsonFormatter prints problems as JSON objects, one per line.
+type jsonFormatter struct {
+	enc *json.Encoder
+	// object, if not nil, describes the object a problem is about.
+	object func(pos token.Position) (interface{}, bool)
+}
+
+type jsonPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Offset int    `json:"offset"`
+}
+
+type jsonRelated struct {
+	Position jsonPosition `json:"position"`
+	Message  string       `json:"message"`
+}
+
+type jsonProblem struct {
+	Check    string        `json:"check"`
+	Severity string        `json:"severity"`
+	Position jsonPosition  `json:"position"`
+	End      *jsonPosition `json:"end,omitempty"`
+	Message  string        `json:"message"`
+	Related  []jsonRelated `json:"related,omitempty"`
+	New      bool          `json:"new,omitempty"`
+	Object   interface{}   `json:"object,omitempty"`
+}
+
+func newJSONPosition(pos token.Position) jsonPosition {
+	return jsonPosition{
+		File:   pos.Filename,
+		Line:   pos.Line,
+		Column: pos.Column,
+		Offset: pos.Offset,
+	}
+}
+
+func (f jsonFormatter) format(p positioned, sev string, isNew bool) {
+	out := jsonProblem{
+		Check:    p.Check,
+		Severity: sev,
+		Position: newJSONPosition(p.pos),
+		Message:  strings.TrimSuffix(p.Text, " ("+p.Check+")"),
+		New:      isNew,
+	}
+	if p.end.IsValid() {
+		end := newJSONPosition(p.end)
+		out.End = &end
+	}
+	for _, r := range p.related {
+		out.Related = append(out.Related, jsonRelated{newJSONPosition(r.pos), r.message})
+	}
+	if f.object != nil {
+		if obj, ok := f.object(p.pos); ok {
+			out.Object = obj
+		}
+	}
+	if err := f.enc.Encode(out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func (jsonFormatter) flush() {}
+
+// sarifFormatter prints problems as a SARIF 2.1.0 log, the format
+// used by GitHub code scanning and other dashboards. As a log is a
+// single JSON document, it is only printed once all problems are
+// known.
+type sarifFormatter struct {
+	w       io.Writer
+	tool    string
+	checker lint.Checker
+	results []sarifResult
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID         string              `json:"id"`
+	Properties sarifRuleProperties `json:"properties"`
+}
+
+type sarifRuleProperties struct {
+	Introduced string `json:"introduced,omitempty"`
+	Changed    string `json:"changed,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	RuleIndex        int             `json:"ruleIndex"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               *int                  `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+func newSARIFLocation(pos, end token.Position) sarifLocation {
+	loc := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: sarifURI(pos.Filename)},
+			Region: sarifRegion{
+				StartLine:   pos.Line,
+				StartColumn: pos.Column,
+			},
+		},
+	}
+	if end.IsValid() && end.Filename == pos.Filename {
+		loc.PhysicalLocation.Region.EndLine = end.Line
+		loc.PhysicalLocation.Region.EndColumn = end.Column
+	}
+	return loc
+}
+
+// sarifLevel returns the SARIF level of a severity.
+func sarifLevel(sev string) string {
+	if sev == severityInfo {
+		return "note"
+	}
+	return sev
+}
+
+func (f *sarifFormatter) format(p positioned, sev string, isNew bool) {
+	res := sarifResult{
+		RuleID:    p.Check,
+		Level:     sarifLevel(sev),
+		Message:   sarifMessage{Text: strings.TrimSuffix(p.Text, " ("+p.Check+")")},
+		Locations: []sarifLocation{newSARIFLocation(p.pos, p.end)},
+	}
+	for i, r := range p.related {
+		loc := newSARIFLocation(r.pos, token.Position{})
+		id := i + 1
+		loc.ID = &id
+		loc.Message = &sarifMessage{Text: r.message}
+		res.RelatedLocations = append(res.RelatedLocations, loc)
+	}
+	f.results = append(f.results, res)
+}
+
+func (f *sarifFormatter) flush() {
+	var versions map[string]lint.CheckVersion
+	if vc, ok := f.checker.(lint.VersionedChecker); ok {
+		versions = vc.Versions()
+	}
+	var ids []string
+	for id := range f.checker.Funcs() {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	indices := map[string]int{}
+	rules := make([]sarifRule, len(ids))
+	for i, id := range ids {
+		indices[id] = i
+		rules[i] = sarifRule{
+			ID: id,
+			Properties: sarifRuleProperties{
+				Introduced: versions[id].Introduced,
+				Changed:    versions[id].Changed,
+			},
+		}
+	}
+	results := f.results
+	if results == nil {
+		// an empty run has an empty array of results, not a null one
+		results = []sarifResult{}
+	}
+	for i := range results {
+		results[i].RuleIndex = indices[results[i].RuleID]
+	}
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           f.tool,
+				Version:        lint.Version,
+				InformationURI: "https://honnef.co/go/tools",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	b, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	fmt.Fprintf(f.w, "%s\n", b)
+}
+
+// sarifURI returns the URI of a file. Files below the working
+// directory use relative URIs, which tools resolve against the root
+// of the checked out repository.
+func sarifURI(filename string) string {
+	path := filepath.ToSlash(shortPath(filename))
+	if filepath.IsAbs(filename) && path == filepath.ToSlash(filename) {
+		return (&url.URL{Scheme: "file", Path: path}).String()
+	}
+	return (&url.URL{Path: path}).String()
+}
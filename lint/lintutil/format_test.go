@@ -0,0 +1,207 @@
+package lintutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"honnef.co/go/tools/lint"
+)
+
+var formatUpdate = flag.Bool("format.update", false, "update the golden files in testdata/format")
+
+type formatChecker struct{}
+
+func (formatChecker) Init(*lint.Program) {}
+
+func (formatChecker) Funcs() map[string]lint.Func {
+	return map[string]lint.Func{
+		"SA1000": nil,
+		"SA4006": nil,
+		"S1000":  nil,
+	}
+}
+
+func (formatChecker) Versions() map[string]lint.CheckVersion {
+	return map[string]lint.CheckVersion{
+		"SA1000": {Introduced: "2017.1"},
+		"SA4006": {Introduced: "2017.1", Changed: "2017.2"},
+		"S1000":  {Introduced: "2017.1"},
+	}
+}
+
+type formatProblem struct {
+	positioned
+	sev   string
+	isNew bool
+}
+
+func formatProblems() []formatProblem {
+	pos := func(line, col, off int) token.Position {
+		return token.Position{Filename: "pkg/a.go", Line: line, Column: col, Offset: off}
+	}
+	return []formatProblem{
+		{
+			positioned: positioned{
+				pos:     pos(3, 2, 20),
+				end:     pos(3, 12, 30),
+				Problem: lint.Problem{Text: "should use a simple channel send/receive instead of select with a single case (S1000)", Check: "S1000"},
+			},
+			sev: severityWarning,
+		},
+		{
+			positioned: positioned{
+				pos: pos(5, 6, 40),
+				related: []relatedPosition{
+					{pos(7, 2, 60), "overwritten here"},
+				},
+				Problem: lint.Problem{Text: "this value of x is never used (SA4006)", Check: "SA4006"},
+			},
+			sev:   severityError,
+			isNew: true,
+		},
+		{
+			positioned: positioned{
+				pos:     pos(9, 13, 80),
+				Problem: lint.Problem{Text: "error parsing regexp: missing closing ) (SA1000)", Check: "SA1000"},
+			},
+			sev: severityInfo,
+		},
+	}
+}
+
+func testFormat(t *testing.T, format string, object func(token.Position) (interface{}, bool)) []byte {
+	buf := &bytes.Buffer{}
+	f := newFormatter(buf, &formatFlag{tool: "staticcheck", format: format}, formatChecker{}, object)
+	for _, p := range formatProblems() {
+		f.format(p.positioned, p.sev, p.isNew)
+	}
+	f.flush()
+	got := buf.Bytes()
+
+	golden := filepath.Join("testdata", "format", format+".golden")
+	if *formatUpdate {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("-f %s printed\n%s\nwant\n%s", format, got, want)
+	}
+	return got
+}
+
+func TestFormatText(t *testing.T) {
+	testFormat(t, "text", nil)
+}
+
+func TestFormatJSON(t *testing.T) {
+	object := func(pos token.Position) (interface{}, bool) {
+		if pos.Line != 5 {
+			return nil, false
+		}
+		return map[string]string{"name": "x"}, true
+	}
+	out := testFormat(t, "json", object)
+
+	// each line is a JSON object
+	for _, line := range bytes.Split(bytes.TrimSuffix(out, []byte("\n")), []byte("\n")) {
+		var v map[string]interface{}
+		if err := json.Unmarshal(line, &v); err != nil {
+			t.Errorf("%s: %s", line, err)
+		}
+	}
+}
+
+func TestFormatSARIF(t *testing.T) {
+	out := testFormat(t, "sarif", nil)
+
+	// check the parts of the SARIF 2.1.0 schema that consumers rely
+	// on, independently of the golden file
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Message   struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(out, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" {
+		t.Errorf("got version %q, want 2.1.0", log.Version)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("got %d runs, want 1", len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "staticcheck" {
+		t.Errorf("got tool %q, want staticcheck", run.Tool.Driver.Name)
+	}
+	ps := formatProblems()
+	if len(run.Results) != len(ps) {
+		t.Fatalf("got %d results, want %d", len(run.Results), len(ps))
+	}
+	levels := []string{"warning", "error", "note"}
+	for i, res := range run.Results {
+		p := ps[i]
+		if res.RuleID != p.Check {
+			t.Errorf("result %d: got ruleId %q, want %q", i, res.RuleID, p.Check)
+		}
+		if res.RuleIndex >= len(run.Tool.Driver.Rules) || run.Tool.Driver.Rules[res.RuleIndex].ID != p.Check {
+			t.Errorf("result %d: ruleIndex %d doesn't refer to %s", i, res.RuleIndex, p.Check)
+		}
+		if res.Level != levels[i] {
+			t.Errorf("result %d: got level %q, want %q", i, res.Level, levels[i])
+		}
+		if res.Message.Text == "" {
+			t.Errorf("result %d: empty message", i)
+		}
+		if len(res.Locations) != 1 {
+			t.Errorf("result %d: got %d locations, want 1", i, len(res.Locations))
+			continue
+		}
+		loc := res.Locations[0].PhysicalLocation
+		if loc.ArtifactLocation.URI != "pkg/a.go" {
+			t.Errorf("result %d: got uri %q, want pkg/a.go", i, loc.ArtifactLocation.URI)
+		}
+		if loc.Region.StartLine != p.pos.Line || loc.Region.StartColumn != p.pos.Column {
+			t.Errorf("result %d: got region %d:%d, want %d:%d", i,
+				loc.Region.StartLine, loc.Region.StartColumn, p.pos.Line, p.pos.Column)
+		}
+	}
+}
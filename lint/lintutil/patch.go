@@ -0,0 +1,226 @@
+package lintutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A patch records the lines that a unified diff adds or modifies, by
+// file. Only problems on these lines are reported, which allows
+// enforcing the checks on changes to code that doesn't pass them yet.
+type patch struct {
+	// files maps the slash-separated names of the changed files, as
+	// they appear in the diff, to their added lines.
+	files map[string]map[int]bool
+}
+
+// readPatch reads a unified diff from the file name, or from
+// standard input if name is "-".
+func readPatch(name string) (*patch, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	p, err := parsePatch(r)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse patch %s: %s", name, err)
+	}
+	return p, nil
+}
+
+// parsePatch parses a unified diff, as produced by diff -u and git
+// diff. Only the names of the new files and the added lines of hunks
+// are used; everything else, such as git's extended headers, is
+// ignored.
+func parsePatch(r io.Reader) (*patch, error) {
+	p := &patch{files: map[string]map[int]bool{}}
+	var (
+		oldName string
+		// git is set by the diff --git header of the current file,
+		// and gitPrefix if its names have the a/ and b/ prefixes
+		git, gitPrefix bool
+		lines          map[int]bool
+		line           int
+		// oldLeft and newLeft are the numbers of lines of the
+		// current hunk that haven't been seen yet
+		oldLeft, newLeft int
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<30)
+	lineno := 0
+	for sc.Scan() {
+		lineno++
+		text := sc.Text()
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				lines[line] = true
+				line++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				oldLeft--
+			case strings.HasPrefix(text, " "), text == "":
+				line++
+				oldLeft--
+				newLeft--
+			case strings.HasPrefix(text, `\`):
+				// \ No newline at end of file
+			default:
+				return nil, fmt.Errorf("line %d: unexpected line in hunk", lineno)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			git = true
+			gitPrefix = strings.HasPrefix(text[len("diff --git "):], "a/")
+			oldName = ""
+		case strings.HasPrefix(text, "--- "):
+			oldName = patchFileName(text[len("--- "):])
+		case strings.HasPrefix(text, "+++ "):
+			name := patchFileName(text[len("+++ "):])
+			// git prefixes names with a/ and b/, unless run with
+			// --no-prefix; the old name of added files is /dev/null
+			strip := gitPrefix
+			if !git {
+				strip = strings.HasPrefix(oldName, "a/") || oldName == "/dev/null"
+			}
+			if strip && strings.HasPrefix(name, "b/") {
+				name = name[len("b/"):]
+			}
+			lines = nil
+			if name != "/dev/null" {
+				name = path.Clean(filepath.ToSlash(name))
+				lines = p.files[name]
+				if lines == nil {
+					lines = map[int]bool{}
+					p.files[name] = lines
+				}
+			}
+		case strings.HasPrefix(text, "@@ "):
+			if lines == nil {
+				if oldName == "" {
+					return nil, fmt.Errorf("line %d: hunk without file header", lineno)
+				}
+				// the file is deleted; its hunk has no added lines,
+				// but has to be skipped
+				lines = map[int]bool{}
+			}
+			var err error
+			line, oldLeft, newLeft, err = parseHunkHeader(text)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineno, err)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// patchFileName returns the name of a file in a --- or +++ header,
+// without the timestamp that diff -u appends after a tab.
+func patchFileName(s string) string {
+	if i := strings.Index(s, "\t"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if unq, err := strconv.Unquote(s); err == nil {
+		// git quotes names with unusual characters
+		s = unq
+	}
+	return s
+}
+
+// parseHunkHeader parses a header such as @@ -1,5 +1,7 @@ and
+// returns the first line of the new range and the lengths of both
+// ranges.
+func parseHunkHeader(s string) (start, oldLen, newLen int, err error) {
+	fields := strings.Fields(s)
+	if len(fields) < 4 || fields[3] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header %q", s)
+	}
+	_, oldLen, err = parseRange(fields[1][1:])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	start, newLen, err = parseRange(fields[2][1:])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return start, oldLen, newLen, nil
+}
+
+// parseRange parses a range such as 12,5. The length defaults to 1.
+func parseRange(s string) (start, n int, err error) {
+	n = 1
+	if i := strings.Index(s, ","); i >= 0 {
+		n, err = strconv.Atoi(s[i+1:])
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed range %q", s)
+		}
+		s = s[:i]
+	}
+	start, err = strconv.Atoi(s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed range %q", s)
+	}
+	return start, n, nil
+}
+
+// lines returns the added lines of the file filename. Names in diffs
+// are usually relative to the root of a repository, not to the
+// working directory, so a file matches a name in the diff if its
+// absolute path ends with it.
+func (p *patch) lines(filename string) map[int]bool {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		abs = filename
+	}
+	abs = filepath.ToSlash(abs)
+	var best string
+	for name := range p.files {
+		if (abs == name || strings.HasSuffix(abs, "/"+name)) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return p.files[best]
+}
+
+// filter returns the problems of ps that span at least one added
+// line.
+func (p *patch) filter(ps []positioned) []positioned {
+	var out []positioned
+	for _, pp := range ps {
+		lines := p.lines(pp.pos.Filename)
+		if lines == nil {
+			continue
+		}
+		last := pp.pos.Line
+		if pp.end.IsValid() && pp.end.Filename == pp.pos.Filename && pp.end.Line > last {
+			last = pp.end.Line
+		}
+		for l := pp.pos.Line; l <= last; l++ {
+			if lines[l] {
+				out = append(out, pp)
+				break
+			}
+		}
+	}
+	return out
+}
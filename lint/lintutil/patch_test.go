@@ -0,0 +1,153 @@
+package lintutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  map[string][]int
+	}{
+		{
+			name: "modified",
+			patch: `diff --git a/foo/foo.go b/foo/foo.go
+index 1111111..2222222 100644
+--- a/foo/foo.go
++++ b/foo/foo.go
+@@ -1,4 +1,5 @@
+ package foo
+-var x = 1
++var x = 2
++var y = 3
+ 
+ func f() {}
+@@ -10,2 +11,2 @@ func g() {
+ 	a()
+-	b()
++	c()
+`,
+			want: map[string][]int{"foo/foo.go": {2, 3, 12}},
+		},
+		{
+			name: "new file",
+			patch: `diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,3 @@
++package foo
++
++func f() {}
+`,
+			want: map[string][]int{"new.go": {1, 2, 3}},
+		},
+		{
+			name: "new file without git header",
+			patch: `--- /dev/null
++++ b/new.go
+@@ -0,0 +1,1 @@
++package foo
+`,
+			want: map[string][]int{"new.go": {1}},
+		},
+		{
+			name: "deleted file",
+			patch: `diff --git a/old.go b/old.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package foo
+-
+diff --git a/foo.go b/foo.go
+--- a/foo.go
++++ b/foo.go
+@@ -1 +1 @@
+-package foo
++package bar
+`,
+			want: map[string][]int{"foo.go": {1}},
+		},
+		{
+			name: "rename",
+			patch: `diff --git a/old.go b/dir/new.go
+similarity index 90%
+rename from old.go
+rename to dir/new.go
+index 1111111..2222222 100644
+--- a/old.go
++++ b/dir/new.go
+@@ -1,3 +1,3 @@
+ package foo
+ 
+-func f() {}
++func g() {}
+diff --git a/a.go b/b.go
+similarity index 100%
+rename from a.go
+rename to b.go
+`,
+			want: map[string][]int{"dir/new.go": {3}},
+		},
+		{
+			name: "no prefix",
+			patch: `diff --git b/foo.go b/foo.go
+--- b/foo.go
++++ b/foo.go
+@@ -1 +1,2 @@
+ package foo
++var x int
+`,
+			want: map[string][]int{"b/foo.go": {2}},
+		},
+		{
+			name: "p0",
+			patch: `--- foo/foo.go.orig	2017-10-01 12:00:00.000000000 +0200
++++ foo/foo.go	2017-10-01 12:01:00.000000000 +0200
+@@ -1,2 +1,2 @@
+ package foo
+-var x = 1
++var x = 2
+`,
+			want: map[string][]int{"foo/foo.go": {2}},
+		},
+	}
+	for _, tt := range tests {
+		p, err := parsePatch(strings.NewReader(tt.patch))
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		got := map[string][]int{}
+		for name, lines := range p.files {
+			got[name] = []int{}
+			for l := 1; l <= 100; l++ {
+				if lines[l] {
+					got[name] = append(got[name], l)
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParsePatchErrors(t *testing.T) {
+	tests := []string{
+		"@@ -1 +1 @@\n+x\n",
+		"--- a/x.go\n+++ b/x.go\n@@ -1 +1\n",
+		"--- a/x.go\n+++ b/x.go\n@@ -1,2 +1,2 @@\n x\n?\n",
+	}
+	for _, patch := range tests {
+		if _, err := parsePatch(strings.NewReader(patch)); err == nil {
+			t.Errorf("parsePatch(%q) succeeded, want an error", patch)
+		}
+	}
+}
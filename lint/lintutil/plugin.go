@@ -0,0 +1,421 @@
+package lintutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"strings"
+	"sync"
+
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/ssa"
+)
+
+// PluginSymbol is the name of the function that Go plugins export.
+// Its type must be func() lint.Checker, and it is called once per
+// checked program, like the checker constructors of the tools.
+const PluginSymbol = "NewChecker"
+
+// loadPlugin loads the plugin at path and returns its checker
+// constructor. Files ending in .so are Go plugins, built with
+// go build -buildmode=plugin against the same version of these
+// tools; all other files are executables that implement the external
+// checker protocol.
+func loadPlugin(path string) (func() lint.Checker, error) {
+	if strings.HasSuffix(path, ".so") {
+		return loadGoPlugin(path)
+	}
+	return loadExternal(path)
+}
+
+func loadGoPlugin(path string) (func() lint.Checker, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, err
+	}
+	switch fn := sym.(type) {
+	case func() lint.Checker:
+		return fn, nil
+	case *func() lint.Checker:
+		return *fn, nil
+	default:
+		return nil, fmt.Errorf("plugin %s: %s has type %T, want func() lint.Checker", path, PluginSymbol, sym)
+	}
+}
+
+// withPlugins returns a constructor of checkers that combine the
+// checks of newChecker and of the plugins. Check IDs must be unique.
+func withPlugins(newChecker func() lint.Checker, paths []string) (func() lint.Checker, error) {
+	plugins := make([]func() lint.Checker, len(paths))
+	ids := map[string]string{}
+	for id := range newChecker().Funcs() {
+		ids[id] = ""
+	}
+	for i, path := range paths {
+		fn, err := loadPlugin(path)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load plugin %s: %s", path, err)
+		}
+		for id := range fn().Funcs() {
+			if other, ok := ids[id]; ok {
+				if other == "" {
+					return nil, fmt.Errorf("check %s of plugin %s conflicts with a built-in check", id, path)
+				}
+				return nil, fmt.Errorf("check %s of plugin %s conflicts with plugin %s", id, path, other)
+			}
+			ids[id] = path
+		}
+		plugins[i] = fn
+	}
+	return func() lint.Checker {
+		cs := multiChecker{newChecker()}
+		for _, fn := range plugins {
+			cs = append(cs, fn())
+		}
+		return cs
+	}, nil
+}
+
+// An errChecker is a checker whose checks can fail as a whole, such
+// as an external checker that exits with an error. Err returns the
+// error of the last checked program, if any.
+type errChecker interface {
+	lint.Checker
+	Err() error
+}
+
+// multiChecker combines the checks of several checkers.
+type multiChecker []lint.Checker
+
+func (cs multiChecker) Init(prog *lint.Program) {
+	for _, c := range cs {
+		c.Init(prog)
+	}
+}
+
+func (cs multiChecker) Funcs() map[string]lint.Func {
+	out := map[string]lint.Func{}
+	for _, c := range cs {
+		for id, fn := range c.Funcs() {
+			out[id] = fn
+		}
+	}
+	return out
+}
+
+func (cs multiChecker) SSAMode() ssa.BuilderMode {
+	var mode ssa.BuilderMode
+	for _, c := range cs {
+		if mc, ok := c.(lint.SSAModeChecker); ok {
+			mode |= mc.SSAMode()
+		}
+	}
+	return mode
+}
+
+func (cs multiChecker) Err() error {
+	var msgs []string
+	for _, c := range cs {
+		if ec, ok := c.(errChecker); ok {
+			if err := ec.Err(); err != nil {
+				msgs = append(msgs, err.Error())
+			}
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+func (cs multiChecker) Versions() map[string]lint.CheckVersion {
+	out := map[string]lint.CheckVersion{}
+	for _, c := range cs {
+		if vc, ok := c.(lint.VersionedChecker); ok {
+			for id, v := range vc.Versions() {
+				out[id] = v
+			}
+		}
+	}
+	return out
+}
+
+// External checkers are executables that read a single JSON request
+// from standard input and write a single JSON response to standard
+// output. They are run once with a describe request, to learn their
+// checks, and once per checked program with a check request. A
+// non-zero exit status is an error, and whatever the checker wrote
+// to standard error is reported with it.
+type externalRequest struct {
+	Version int    `json:"version"`
+	Method  string `json:"method"` // "describe" or "check"
+
+	// The fields of check requests.
+	Go       string            `json:"go,omitempty"`
+	Packages []externalPackage `json:"packages,omitempty"`
+}
+
+type externalPackage struct {
+	Path  string   `json:"path"`
+	Dir   string   `json:"dir"`
+	Files []string `json:"files"`
+}
+
+type externalDescription struct {
+	Checks []externalCheck `json:"checks"`
+}
+
+type externalCheck struct {
+	ID         string `json:"id"`
+	Introduced string `json:"introduced,omitempty"`
+	Changed    string `json:"changed,omitempty"`
+}
+
+type externalResult struct {
+	Problems []externalProblem `json:"problems"`
+}
+
+// externalProblem is a problem found by an external checker. Lines
+// and columns start at 1; columns count bytes. If an offset is
+// given, it takes precedence.
+type externalProblem struct {
+	Check    string        `json:"check"`
+	Position jsonPosition  `json:"position"`
+	End      *jsonPosition `json:"end,omitempty"`
+	Message  string        `json:"message"`
+	Related  []jsonRelated `json:"related,omitempty"`
+}
+
+func loadExternal(path string) (func() lint.Checker, error) {
+	var desc externalDescription
+	if err := callExternal(path, externalRequest{Version: 1, Method: "describe"}, &desc); err != nil {
+		return nil, err
+	}
+	for _, c := range desc.Checks {
+		if c.ID == "" {
+			return nil, fmt.Errorf("%s describes a check without an ID", path)
+		}
+	}
+	return func() lint.Checker {
+		return &externalChecker{path: path, checks: desc.Checks}
+	}, nil
+}
+
+func callExternal(path string, req externalRequest, resp interface{}) error {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s: %s", path, err, msg)
+		}
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return fmt.Errorf("%s: invalid response: %s", path, err)
+	}
+	return nil
+}
+
+// externalChecker runs an external checker once, when the first of
+// its checks runs, and hands out the problems by check.
+type externalChecker struct {
+	path   string
+	checks []externalCheck
+
+	prog     *lint.Program
+	once     sync.Once
+	problems map[string][]externalFound
+	err      error
+}
+
+func (c *externalChecker) Init(prog *lint.Program) { c.prog = prog }
+
+func (c *externalChecker) Err() error { return c.err }
+
+func (c *externalChecker) Funcs() map[string]lint.Func {
+	out := map[string]lint.Func{}
+	for _, check := range c.checks {
+		id := check.ID
+		out[id] = func(j *lint.Job) { c.check(j, id) }
+	}
+	return out
+}
+
+func (c *externalChecker) Versions() map[string]lint.CheckVersion {
+	out := map[string]lint.CheckVersion{}
+	for _, check := range c.checks {
+		out[check.ID] = lint.CheckVersion{
+			Introduced: check.Introduced,
+			Changed:    check.Changed,
+		}
+	}
+	return out
+}
+
+func (c *externalChecker) run() {
+	fset := c.prog.SSA.Fset
+	req := externalRequest{
+		Version: 1,
+		Method:  "check",
+		Go:      fmt.Sprintf("1.%d", c.prog.GoVersion),
+	}
+	for _, pkg := range c.prog.Packages {
+		epkg := externalPackage{Path: pkg.Package.Pkg.Path()}
+		for _, f := range pkg.Info.Files {
+			name := fset.File(f.Pos()).Name()
+			if abs, err := filepath.Abs(name); err == nil {
+				name = abs
+			}
+			epkg.Files = append(epkg.Files, name)
+		}
+		if len(epkg.Files) > 0 {
+			epkg.Dir = filepath.Dir(epkg.Files[0])
+		}
+		req.Packages = append(req.Packages, epkg)
+	}
+	var res externalResult
+	if err := callExternal(c.path, req, &res); err != nil {
+		c.err = err
+		return
+	}
+	// resolve positions here, as checks run concurrently and the
+	// index caches file contents
+	files := newFileIndex(fset)
+	c.problems = map[string][]externalFound{}
+	var outside []externalProblem
+	for _, p := range res.Problems {
+		pos := files.pos(p.Position)
+		if pos == token.NoPos {
+			outside = append(outside, p)
+			continue
+		}
+		found := externalFound{span: span{pos: pos}, message: p.Message}
+		if p.End != nil {
+			found.span.end = files.pos(*p.End)
+		}
+		for _, r := range p.Related {
+			if pos := files.pos(r.Position); pos != token.NoPos {
+				found.related = append(found.related, externalRelated{pos, r.Message})
+			}
+		}
+		c.problems[p.Check] = append(c.problems[p.Check], found)
+	}
+	if len(outside) > 0 {
+		p := outside[0]
+		c.err = fmt.Errorf("%s: %d problems outside of the checked files, such as %s:%d", c.path, len(outside), p.Position.File, p.Position.Line)
+	}
+}
+
+func (c *externalChecker) check(j *lint.Job, id string) {
+	c.once.Do(c.run)
+	for _, found := range c.problems[id] {
+		problem := j.Errorf(found.span, "%s", found.message)
+		for _, r := range found.related {
+			problem.Relate(r.pos, r.message)
+		}
+	}
+}
+
+// externalFound is a problem of an external checker whose positions
+// have been resolved.
+type externalFound struct {
+	span    span
+	message string
+	related []externalRelated
+}
+
+type externalRelated struct {
+	pos     token.Pos
+	message string
+}
+
+// span is a range of source, for reporting problems at positions
+// rather than nodes. Its end may be unknown.
+type span struct {
+	pos, end token.Pos
+}
+
+func (s span) Pos() token.Pos { return s.pos }
+func (s span) End() token.Pos { return s.end }
+
+// fileIndex maps file names and positions of external checkers to
+// token positions.
+type fileIndex struct {
+	files map[string]*token.File
+	data  map[string][]byte
+}
+
+func newFileIndex(fset *token.FileSet) *fileIndex {
+	idx := &fileIndex{
+		files: map[string]*token.File{},
+		data:  map[string][]byte{},
+	}
+	fset.Iterate(func(f *token.File) bool {
+		name := f.Name()
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+		idx.files[name] = f
+		return true
+	})
+	return idx
+}
+
+// pos returns the token position of p, or token.NoPos if p isn't in
+// one of the files.
+func (idx *fileIndex) pos(p jsonPosition) token.Pos {
+	name := p.File
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
+	}
+	f := idx.files[name]
+	if f == nil {
+		return token.NoPos
+	}
+	offset := p.Offset
+	if offset == 0 && p.Line > 0 {
+		offset = idx.offset(name, p.Line, p.Column)
+	}
+	if offset < 0 || offset > f.Size() {
+		return token.NoPos
+	}
+	return f.Pos(offset)
+}
+
+// offset returns the offset of a line and column in a file, or -1.
+func (idx *fileIndex) offset(name string, line, column int) int {
+	data, ok := idx.data[name]
+	if !ok {
+		data, _ = ioutil.ReadFile(name)
+		idx.data[name] = data
+	}
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(data[offset:], '\n')
+		if i == -1 {
+			return -1
+		}
+		offset += i + 1
+	}
+	if column > 1 {
+		offset += column - 1
+	}
+	return offset
+}
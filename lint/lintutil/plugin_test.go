@@ -0,0 +1,186 @@
+package lintutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"honnef.co/go/tools/lint"
+
+	"golang.org/x/tools/go/loader"
+)
+
+// externalEnv is set to the behavior the test binary should have
+// when it's run as an external checker.
+const externalEnv = "LINTUTIL_TEST_EXTERNAL"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(externalEnv); mode != "" {
+		os.Exit(fakeExternal(mode))
+	}
+	os.Exit(m.Run())
+}
+
+// fakeExternal implements the external checker protocol. In the
+// mode ok, it reports a problem on the first line of the first file
+// of each package. The mode malformed writes invalid JSON, and the
+// modes fail and failcheck exit with an error when asked to describe
+// their checks and when asked to check packages, respectively.
+func fakeExternal(mode string) int {
+	var req externalRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if req.Version != 1 {
+		fmt.Fprintf(os.Stderr, "unsupported version %d\n", req.Version)
+		return 2
+	}
+	if mode == "fail" || (mode == "failcheck" && req.Method == "check") {
+		fmt.Fprintln(os.Stderr, "something went wrong")
+		return 1
+	}
+	if mode == "malformed" {
+		fmt.Println(`{"checks": [`)
+		return 0
+	}
+
+	var resp interface{}
+	switch req.Method {
+	case "describe":
+		resp = externalDescription{Checks: []externalCheck{
+			{ID: "X1000", Introduced: "2017.2"},
+			{ID: "X1001", Introduced: "2017.2"},
+		}}
+	case "check":
+		var res externalResult
+		for _, pkg := range req.Packages {
+			if len(pkg.Files) == 0 {
+				continue
+			}
+			file := pkg.Files[0]
+			res.Problems = append(res.Problems, externalProblem{
+				Check:    "X1000",
+				Position: jsonPosition{File: file, Line: 1, Column: 1},
+				End:      &jsonPosition{File: file, Line: 1, Column: 8},
+				Message:  "package " + pkg.Path + " is checked",
+				Related: []jsonRelated{
+					{Position: jsonPosition{File: file, Line: 3, Column: 6}, Message: "fn declared here"},
+				},
+			})
+		}
+		resp = res
+	default:
+		fmt.Fprintf(os.Stderr, "unknown method %q\n", req.Method)
+		return 2
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// loadFakeExternal loads the test binary as an external checker that
+// behaves as mode.
+func loadFakeExternal(mode string) (func() lint.Checker, error) {
+	os.Setenv(externalEnv, mode)
+	defer os.Unsetenv(externalEnv)
+	return loadExternal(os.Args[0])
+}
+
+func TestExternalChecker(t *testing.T) {
+	newChecker, err := loadFakeExternal("ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newChecker()
+	if vc, ok := c.(lint.VersionedChecker); !ok {
+		t.Error("external checker doesn't have versions")
+	} else if v := vc.Versions()["X1001"]; v.Introduced != "2017.2" {
+		t.Errorf("got version %+v for X1001", v)
+	}
+
+	dir, err := ioutil.TempDir("", "external")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "a.go")
+	if err := ioutil.WriteFile(name, []byte("package pkg\n\nfunc fn() {}\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	conf := &loader.Config{ParserMode: parser.ParseComments}
+	conf.CreateFromFilenames("pkg", name)
+	lprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv(externalEnv, "ok")
+	defer os.Unsetenv(externalEnv)
+	l := &lint.Linter{Checker: c}
+	ps := l.Lint(lprog)
+	if err := c.(errChecker).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 1 {
+		t.Fatalf("got %d problems, want 1: %v", len(ps), ps)
+	}
+	p := ps[0]
+	if p.Check != "X1000" || p.Text != "package pkg is checked (X1000)" {
+		t.Errorf("got problem %q of %s", p.Text, p.Check)
+	}
+	pos := lprog.Fset.Position(p.Position)
+	end := lprog.Fset.Position(p.End)
+	if pos.Line != 1 || pos.Column != 1 || end.Line != 1 || end.Column != 8 {
+		t.Errorf("got range %s-%s, want 1:1-1:8", pos, end)
+	}
+	if len(p.Related) != 1 {
+		t.Fatalf("got %d related positions, want 1", len(p.Related))
+	}
+	if rel := lprog.Fset.Position(p.Related[0].Pos); rel.Line != 3 || rel.Column != 6 {
+		t.Errorf("got related position %s, want 3:6", rel)
+	}
+}
+
+func TestExternalCheckerErrors(t *testing.T) {
+	if _, err := loadFakeExternal("malformed"); err == nil || !strings.Contains(err.Error(), "invalid response") {
+		t.Errorf("malformed response: got error %v", err)
+	}
+	if _, err := loadFakeExternal("fail"); err == nil || !strings.Contains(err.Error(), "something went wrong") {
+		t.Errorf("non-zero exit: got error %v, want one that includes standard error", err)
+	}
+
+	// an external checker that fails while checking packages
+	// reports the error through Err
+	newChecker, err := loadFakeExternal("failcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newChecker()
+	conf := &loader.Config{}
+	f, err := conf.ParseFile("a.go", "package pkg\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("pkg", f)
+	lprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(externalEnv, "failcheck")
+	defer os.Unsetenv(externalEnv)
+	l := &lint.Linter{Checker: c}
+	if ps := l.Lint(lprog); len(ps) != 0 {
+		t.Errorf("got problems %v", ps)
+	}
+	if err := c.(errChecker).Err(); err == nil || !strings.Contains(err.Error(), "something went wrong") {
+		t.Errorf("got error %v, want one that includes standard error", err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -42,7 +43,31 @@ type runner struct {
 	checker lint.Checker
 	tags    []string
 	ignores []lint.Ignore
-	version int
+	// version is the targeted Go version. Unless explicitVersion is
+	// set, packages target the version set by their configuration
+	// or go.mod file instead, if any.
+	version         int
+	explicitVersion bool
+	since           string
+	diff            bool
+	memory          uint64
+	// failOn is the set of severities that cause a non-zero exit
+	// status.
+	failOn    map[string]bool
+	generated lint.GeneratedPolicy
+	formatter formatter
+	configs   configs
+
+	// baseline, if not nil, suppresses known problems. If
+	// writeBaseline is set, the problems are written to it instead
+	// of being reported.
+	baseline      *baseline
+	baselinePath  string
+	writeBaseline bool
+
+	// patch, if not nil, limits the reported problems to the lines
+	// changed by a diff.
+	patch *patch
 
 	unclean bool
 }
@@ -95,16 +120,7 @@ func (v *versionFlag) String() string {
 }
 
 func (v *versionFlag) Set(s string) error {
-	if len(s) < 3 {
-		return errors.New("invalid Go version")
-	}
-	if s[0] != '1' {
-		return errors.New("invalid Go version")
-	}
-	if s[1] != '.' {
-		return errors.New("invalid Go version")
-	}
-	i, err := strconv.Atoi(s[2:])
+	i, err := lint.ParseGoVersion(s)
 	*v = versionFlag(i)
 	return err
 }
@@ -113,6 +129,52 @@ func (v *versionFlag) Get() interface{} {
 	return int(*v)
 }
 
+// parseSeverities parses a comma-separated list of severities.
+func parseSeverities(s string) (map[string]bool, error) {
+	out := map[string]bool{}
+	for _, sev := range strings.Split(s, ",") {
+		sev = strings.TrimSpace(sev)
+		if sev == "" {
+			continue
+		}
+		if !validSeverity(sev) {
+			return nil, fmt.Errorf("invalid severity %q, expected error, warning or info", sev)
+		}
+		out[sev] = true
+	}
+	return out, nil
+}
+
+// parseSize parses a size in bytes, such as 512MB or 4GB. Units are
+// powers of 1024.
+func parseSize(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	units := []struct {
+		suffix string
+		n      uint64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+	num, mult := strings.ToUpper(strings.TrimSpace(s)), uint64(1)
+	for _, u := range units {
+		if strings.HasSuffix(num, u.suffix) {
+			num, mult = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.n
+			break
+		}
+	}
+	n, err := strconv.ParseUint(num, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
+
 func FlagSet(name string) *flag.FlagSet {
 	flags := flag.NewFlagSet("", flag.ExitOnError)
 	flags.Usage = usage(name, flags)
@@ -120,78 +182,351 @@ func FlagSet(name string) *flag.FlagSet {
 	flags.String("tags", "", "List of `build tags`")
 	flags.String("ignore", "", "Space separated list of checks to ignore, in the following format: 'import/path/file.go:Check1,Check2,...' Both the import path and file name sections support globbing, e.g. 'os/exec/*_test.go'")
 	flags.Bool("tests", true, "Include tests")
+	flags.String("since-version", "", "Mark problems found by checks that were added or changed after this `release`; they don't affect the exit status")
+	flags.String("fail", "error,warning", "Comma-separated list of `severities` (error, warning and info) of problems that cause a non-zero exit status")
+	flags.Bool("fix", false, "Apply suggested fixes to the source files instead of reporting the problems they resolve")
+	flags.Bool("diff", false, "Print the changes -fix would make as unified diffs instead of applying them; implies -fix")
+	flags.Var(&formatFlag{tool: name, format: "text"}, "f", "Output `format` (valid choices are 'text', 'json' and 'sarif')")
+	flags.String("baseline", "", "Only report problems that aren't recorded in the baseline `file`")
+	flags.Bool("baseline.write", false, "Record the problems in the file given with -baseline instead of reporting them")
+	flags.String("patch", "", "Only report problems on lines added or modified by the unified diff in `file`, or read from standard input if file is '-'")
+	flags.Bool("cache", true, "Reuse the problems found by earlier runs, stored in $STATICCHECK_CACHE or the user's cache directory. If all packages are unchanged, nothing is loaded or checked; otherwise the changed packages and the checked packages that import them are loaded, with all their dependencies, and checked again")
+	flags.String("memory", "", "Don't start further checks while more than `size` bytes of memory are in use, such as 4GB; 0 means no limit")
+	flags.String("plugins", "", "Comma-separated list of plugin `files` with additional checks: Go plugins ending in .so, or executables implementing the external checker protocol")
+	flags.Bool("generated", false, "Report problems in generated code")
+	flags.Bool("generated.uses", true, "Consider identifiers that are used by generated code as used. Together with -generated=false, this skips generated code entirely")
 
-	tags := build.Default.ReleaseTags
-	v := tags[len(tags)-1][2:]
-	version := new(versionFlag)
-	if err := version.Set(v); err != nil {
-		panic(fmt.Sprintf("internal error: %s", err))
-	}
+	version := versionFlag(lint.ToolchainGoVersion())
 
-	flags.Var(version, "go", "Target Go `version` in the format '1.x'")
+	flags.Var(&version, "go", "Target Go `version` in the format '1.x' for all packages. Defaults to the go key of a package's staticcheck.conf, the go directive of its closest go.mod, or the version of the Go toolchain")
 	return flags
 }
 
 func ProcessFlagSet(c lint.Checker, fs *flag.FlagSet) {
+	ProcessFlagSetOptions(func() lint.Checker { return c }, fs, Options{})
+}
+
+// Options customize ProcessFlagSetOptions.
+type Options struct {
+	// Configs, if not empty, causes the packages to be loaded and
+	// checked once for each configuration, with a new checker each
+	// time. A problem is only reported if it is found under all of
+	// the configurations that compile the file it is in. This suits
+	// checks that report the absence of something, such as uses of
+	// an identifier, which may be present in code for other
+	// platforms.
+	Configs []BuildConfig
+	// Object, if not nil, returns a description of the object that
+	// the problem at pos is about, which -f json prints as the
+	// problem's object field. It may depend on the state of the
+	// checker, so JSON output isn't cached when it is set.
+	Object func(pos token.Position) (interface{}, bool)
+}
+
+// ProcessFlagSetOptions is like ProcessFlagSet, but uses checkers
+// returned by newChecker and is customized by opts.
+func ProcessFlagSetOptions(newChecker func() lint.Checker, fs *flag.FlagSet, opts Options) {
+	configs := opts.Configs
 	tags := fs.Lookup("tags").Value.(flag.Getter).Get().(string)
 	ignore := fs.Lookup("ignore").Value.(flag.Getter).Get().(string)
 	tests := fs.Lookup("tests").Value.(flag.Getter).Get().(bool)
 	version := fs.Lookup("go").Value.(flag.Getter).Get().(int)
+	since := fs.Lookup("since-version").Value.(flag.Getter).Get().(string)
+	fix := fs.Lookup("fix").Value.(flag.Getter).Get().(bool)
+	diff := fs.Lookup("diff").Value.(flag.Getter).Get().(bool)
+	generated := fs.Lookup("generated").Value.(flag.Getter).Get().(bool)
+	generatedUses := fs.Lookup("generated.uses").Value.(flag.Getter).Get().(bool)
+	format := fs.Lookup("f").Value.(flag.Getter).Get().(*formatFlag)
+	baselinePath := fs.Lookup("baseline").Value.(flag.Getter).Get().(string)
+	writeBaseline := fs.Lookup("baseline.write").Value.(flag.Getter).Get().(bool)
+	useCache := fs.Lookup("cache").Value.(flag.Getter).Get().(bool)
+	memory := fs.Lookup("memory").Value.(flag.Getter).Get().(string)
+	patchPath := fs.Lookup("patch").Value.(flag.Getter).Get().(string)
+	fail := fs.Lookup("fail").Value.(flag.Getter).Get().(string)
+	plugins := fs.Lookup("plugins").Value.(flag.Getter).Get().(string)
+	explicitVersion := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "go" {
+			explicitVersion = true
+		}
+	})
 
 	ignores, err := parseIgnore(ignore)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	failOn, err := parseSeverities(fail)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	memoryLimit, err := parseSize(memory)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if diff {
+		fix = true
+	}
+	if plugins != "" {
+		newChecker, err = withPlugins(newChecker, strings.Split(plugins, ","))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		// plugins may depend on anything, which the cache can't
+		// know about
+		useCache = false
+	}
+	if fix && len(configs) > 0 {
+		fmt.Fprintln(os.Stderr, "-fix and -diff can't be used when checking multiple build configurations")
+		os.Exit(1)
+	}
 	runner := &runner{
-		checker: c,
-		tags:    strings.Fields(tags),
-		ignores: ignores,
-		version: version,
+		checker:         newChecker(),
+		tags:            strings.Fields(tags),
+		ignores:         ignores,
+		version:         version,
+		since:           since,
+		explicitVersion: explicitVersion,
+		diff:            diff,
+		memory:          memoryLimit,
+		failOn:          failOn,
+		generated: lint.GeneratedPolicy{
+			Report:     generated,
+			IgnoreUses: !generatedUses,
+		},
+	}
+	if writeBaseline && baselinePath == "" {
+		fmt.Fprintln(os.Stderr, "-baseline.write requires -baseline")
+		os.Exit(1)
+	}
+	if writeBaseline && fix {
+		fmt.Fprintln(os.Stderr, "-baseline.write can't be used with -fix or -diff")
+		os.Exit(1)
 	}
+	runner.baselinePath = baselinePath
+	runner.writeBaseline = writeBaseline
+	if baselinePath != "" && !writeBaseline {
+		runner.baseline, err = readBaseline(baselinePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if patchPath != "" {
+		if fix || writeBaseline {
+			fmt.Fprintln(os.Stderr, "-patch can't be used with -fix, -diff or -baseline.write")
+			os.Exit(1)
+		}
+		runner.patch, err = readPatch(patchPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	runner.formatter = newFormatter(os.Stdout, format, runner.checker, opts.Object)
 	paths := gotool.ImportPaths(fs.Args())
 	goFiles, err := runner.resolveRelative(paths)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		runner.unclean = true
 	}
-	ctx := build.Default
-	ctx.BuildTags = runner.tags
-	conf := &loader.Config{
-		Build:      &ctx,
-		ParserMode: parser.ParseComments,
-		ImportPkgs: map[string]bool{},
-	}
-	if goFiles {
-		conf.CreateFromFilenames("adhoc", paths...)
+	loadPaths := func(ctx *build.Context, paths []string) *loader.Program {
+		conf := &loader.Config{
+			Build:      ctx,
+			ParserMode: parser.ParseComments,
+			ImportPkgs: map[string]bool{},
+		}
+		if goFiles {
+			conf.CreateFromFilenames("adhoc", paths...)
+		} else {
+			for _, path := range paths {
+				conf.ImportPkgs[path] = tests
+			}
+		}
 		lprog, err := conf.Load()
 		if err != nil {
 			log.Fatal(err)
 		}
-		ps := runner.lint(lprog)
-		for _, p := range ps {
-			runner.unclean = true
-			pos := lprog.Fset.Position(p.Position)
-			fmt.Printf("%v: %s\n", relativePositionString(pos), p.Text)
+		return lprog
+	}
+	load := func(ctx *build.Context) *loader.Program {
+		return loadPaths(ctx, paths)
+	}
+	if len(configs) == 0 {
+		ctx := build.Default
+		ctx.BuildTags = runner.tags
+		if fix {
+			runner.printProblems(runner.fixAll(newChecker, &ctx, load))
+		} else if useCache && !goFiles && (opts.Object == nil || format.format != "json") {
+			// object descriptions depend on the state of the
+			// checker, which a cached run doesn't have
+			runner.printPositioned(runner.lintCached(format.tool, &ctx, paths, tests, fs, loadPaths))
+		} else {
+			lprog := load(&ctx)
+			runner.printProblems(lprog, runner.lint(lprog))
 		}
 	} else {
-		for _, path := range paths {
-			conf.ImportPkgs[path] = tests
-		}
-		lprog, err := conf.Load()
+		runner.printPositioned(runner.lintConfigs(newChecker, configs, load))
+	}
+	if runner.unclean {
+		os.Exit(1)
+	}
+}
+
+// packageGoVersion returns the Go version that pkg targets: the one
+// set by the configuration of its directory or, failing that, the one
+// declared by the closest go.mod file, so that suggestions don't
+// require a newer Go release than the project supports. The -go flag
+// overrides both. The configurations of pkg's directory must have
+// been loaded.
+func (runner *runner) packageGoVersion(fset *token.FileSet, pkg *loader.PackageInfo) int {
+	if runner.explicitVersion || len(pkg.Files) == 0 {
+		return runner.version
+	}
+	dir, err := filepath.Abs(filepath.Dir(fset.File(pkg.Files[0].Pos()).Name()))
+	if err != nil {
+		return runner.version
+	}
+	if v, ok := runner.configs.goVersion(dir); ok {
+		return v
+	}
+	if v, ok := lint.ModuleGoVersion(dir); ok {
+		return v
+	}
+	return runner.version
+}
+
+// lintCached returns the problems of the packages paths from the
+// cache, or checks them and adds them to the cache. Only the packages
+// whose entries are missing are checked again, along with the
+// packages that import them, since those can affect their problems.
+func (runner *runner) lintCached(tool string, ctx *build.Context, paths []string, tests bool, fs *flag.FlagSet, load func(*build.Context, []string) *loader.Program) []positioned {
+	c, err := openCache()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "not using the cache:", err)
+	}
+	var keys []packageKey
+	if c != nil {
+		keys, err = c.keys(tool, ctx, paths, tests, fs)
 		if err != nil {
-			log.Fatal(err)
+			c = nil
+		}
+	}
+	if c == nil {
+		lprog := load(ctx, paths)
+		return runner.positionAll(lprog, runner.lint(lprog))
+	}
+
+	var out []positioned
+	stale := map[string]bool{}
+	cached := make([][]positioned, len(keys))
+	for i, pk := range keys {
+		ps, ok := c.get(pk.key)
+		if !ok {
+			stale[pk.path] = true
+			for _, imp := range pk.importers {
+				stale[imp] = true
+			}
+		}
+		cached[i] = ps
+	}
+	var check []packageKey
+	for i, pk := range keys {
+		if stale[pk.path] {
+			check = append(check, pk)
+		} else {
+			out = append(out, cached[i]...)
 		}
-		ps := runner.lint(lprog)
-		for _, p := range ps {
+	}
+	if len(check) == 0 {
+		// sort like a run without the cache would
+		sort.Sort(byPosition(out))
+		return out
+	}
+
+	checkPaths := make([]string, len(check))
+	for i, pk := range check {
+		checkPaths[i] = pk.path
+	}
+	lprog := load(ctx, checkPaths)
+	ps := runner.positionAll(lprog, runner.lint(lprog))
+	out = append(out, ps...)
+
+	// file the problems under the packages of their files; the
+	// remaining few, such as those without a position, go to the
+	// first package
+	byDir := map[string][]positioned{}
+	for _, p := range ps {
+		dir, err := filepath.Abs(filepath.Dir(p.pos.Filename))
+		if err != nil || p.pos.Filename == "" {
+			dir = ""
+		}
+		byDir[dir] = append(byDir[dir], p)
+	}
+	groups := make([][]positioned, len(check))
+	for i, pk := range check {
+		groups[i] = byDir[pk.dir]
+		delete(byDir, pk.dir)
+	}
+	for _, rest := range byDir {
+		groups[0] = append(groups[0], rest...)
+	}
+	for i, pk := range check {
+		if err := c.put(pk.key, groups[i]); err != nil {
+			fmt.Fprintln(os.Stderr, "couldn't write to the cache:", err)
+			break
+		}
+	}
+	sort.Sort(byPosition(out))
+	return out
+}
+
+func (runner *runner) positionAll(lprog *loader.Program, ps []lint.Problem) []positioned {
+	out := make([]positioned, len(ps))
+	for i, p := range ps {
+		out[i] = newPositioned(lprog.Fset, p)
+	}
+	return out
+}
+
+func (runner *runner) printProblems(lprog *loader.Program, ps []lint.Problem) {
+	runner.printPositioned(runner.positionAll(lprog, ps))
+}
+
+func (runner *runner) printPositioned(ps []positioned) {
+	if runner.writeBaseline {
+		if err := writeBaseline(runner.baselinePath, ps); err != nil {
+			fmt.Fprintln(os.Stderr, err)
 			runner.unclean = true
-			pos := lprog.Fset.Position(p.Position)
-			fmt.Printf("%v: %s\n", relativePositionString(pos), p.Text)
 		}
+		return
 	}
-	if runner.unclean {
-		os.Exit(1)
+	if runner.patch != nil {
+		ps = runner.patch.filter(ps)
+	}
+	if runner.baseline != nil {
+		ps = runner.baseline.filter(ps)
+	}
+	var versions map[string]lint.CheckVersion
+	if vc, ok := runner.checker.(lint.VersionedChecker); ok {
+		versions = vc.Versions()
+	}
+	for _, p := range ps {
+		isNew := runner.since != "" && versions[p.Check].Since(runner.since)
+		sev := severityInfo
+		if !isNew {
+			// problems of new checks don't affect the exit status
+			sev = runner.configs.severity(p.Check, p.pos.Filename)
+		}
+		if runner.failOn[sev] {
+			runner.unclean = true
+		}
+		runner.formatter.format(p, sev, isNew)
 	}
+	runner.formatter.flush()
 }
 
 func shortPath(path string) string {
@@ -227,10 +562,27 @@ func ProcessArgs(name string, c lint.Checker, args []string) {
 }
 
 func (runner *runner) lint(lprog *loader.Program) []lint.Problem {
+	if err := runner.configs.load(lprog); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	l := &lint.Linter{
-		Checker:   runner.checker,
-		Ignores:   runner.ignores,
-		GoVersion: runner.version,
+		Checker:     runner.checker,
+		Ignores:     runner.ignores,
+		GoVersion:   runner.version,
+		Generated:   runner.generated,
+		Enabled:     runner.configs.enabled,
+		MemoryLimit: runner.memory,
+		PackageGoVersion: func(pkg *loader.PackageInfo) int {
+			return runner.packageGoVersion(lprog.Fset, pkg)
+		},
+	}
+	ps := l.Lint(lprog)
+	if ec, ok := runner.checker.(errChecker); ok {
+		if err := ec.Err(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			runner.unclean = true
+		}
 	}
-	return l.Lint(lprog)
+	return ps
 }
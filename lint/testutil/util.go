@@ -28,7 +28,61 @@ import (
 var lintMatch = flag.String("lint.match", "", "restrict testdata matches to this pattern")
 
 func TestAll(t *testing.T, c lint.Checker, dir string) {
-	baseDir := filepath.Join("testdata", dir)
+	lprog, files, sources := load(t, filepath.Join("testdata", dir), *lintMatch)
+
+	for version, fis := range files {
+		l := &lint.Linter{Checker: c, GoVersion: version}
+
+		res := l.Lint(lprog)
+		for _, fi := range fis {
+			name := fi.Name()
+			src := sources[name]
+
+			ins := parseInstructions(t, name, src)
+
+			for _, in := range ins {
+				ok := false
+				for i, p := range res {
+					pos := lprog.Fset.Position(p.Position)
+					if pos.Line != in.Line || filepath.Base(pos.Filename) != name {
+						continue
+					}
+					if in.Match.MatchString(p.Text) {
+						if in.Replacement != "" {
+							checkFix(t, lprog.Fset, name, in, p)
+						}
+						checkRelated(t, lprog.Fset, name, in, p)
+						// remove this problem from ps
+						copy(res[i:], res[i+1:])
+						res = res[:len(res)-1]
+
+						//t.Logf("/%v/ matched at %s:%d", in.Match, fi.Name(), in.Line)
+						ok = true
+						break
+					}
+				}
+				if !ok {
+					t.Errorf("Lint failed at %s:%d; /%v/ did not match", name, in.Line, in.Match)
+				}
+			}
+		}
+		for _, p := range res {
+			pos := lprog.Fset.Position(p.Position)
+			name := filepath.Base(pos.Filename)
+			for _, fi := range fis {
+				if name == fi.Name() {
+					t.Errorf("Unexpected problem at %s: %v", pos, p.Text)
+					break
+				}
+			}
+		}
+	}
+}
+
+// load loads all files in baseDir, each file as its own package, and
+// groups the files that match the pattern match by the Go version
+// they target.
+func load(t *testing.T, baseDir, match string) (*loader.Program, map[int][]os.FileInfo, map[string][]byte) {
 	fis, err := ioutil.ReadDir(baseDir)
 	if err != nil {
 		t.Fatalf("ioutil.ReadDir: %v", err)
@@ -36,9 +90,9 @@ func TestAll(t *testing.T, c lint.Checker, dir string) {
 	if len(fis) == 0 {
 		t.Fatalf("no files in %v", baseDir)
 	}
-	rx, err := regexp.Compile(*lintMatch)
+	rx, err := regexp.Compile(match)
 	if err != nil {
-		t.Fatalf("Bad -lint.match value %q: %v", *lintMatch, err)
+		t.Fatalf("Bad -lint.match value %q: %v", match, err)
 	}
 
 	files := map[int][]os.FileInfo{}
@@ -68,6 +122,9 @@ func TestAll(t *testing.T, c lint.Checker, dir string) {
 	}
 	sources := map[string][]byte{}
 	for _, fi := range fis {
+		if !strings.HasSuffix(fi.Name(), ".go") {
+			continue
+		}
 		filename := path.Join(baseDir, fi.Name())
 		src, err := ioutil.ReadFile(filename)
 		if err != nil {
@@ -87,56 +144,102 @@ func TestAll(t *testing.T, c lint.Checker, dir string) {
 	if err != nil {
 		t.Fatalf("error loading program: %s", err)
 	}
+	return lprog, files, sources
+}
 
-	for version, fis := range files {
-		l := &lint.Linter{Checker: c, GoVersion: version}
-
-		res := l.Lint(lprog)
-		for _, fi := range fis {
-			name := fi.Name()
-			src := sources[name]
-
-			ins := parseInstructions(t, name, src)
-
-			for _, in := range ins {
-				ok := false
-				for i, p := range res {
-					pos := lprog.Fset.Position(p.Position)
-					if pos.Line != in.Line || filepath.Base(pos.Filename) != name {
-						continue
-					}
-					if in.Match.MatchString(p.Text) {
-						// remove this problem from ps
-						copy(res[i:], res[i+1:])
-						res = res[:len(res)-1]
+// checkFix checks that applying the suggested fix of p turns the
+// line of the instruction into its replacement. The MATCH comment
+// isn't part of the comparison.
+func checkFix(t *testing.T, fset *token.FileSet, name string, in instruction, p lint.Problem) {
+	if p.Fix == nil {
+		t.Errorf("Lint failed at %s:%d; no suggested fix", name, in.Line)
+		return
+	}
+	files, _, err := lint.ApplyFixes(fset, []lint.Problem{p}, nil)
+	if err != nil {
+		t.Errorf("Lint failed at %s:%d; couldn't apply fix: %s", name, in.Line, err)
+		return
+	}
+	for filename, src := range files {
+		lines := strings.Split(string(src), "\n")
+		if filepath.Base(filename) != name || in.Line > len(lines) {
+			continue
+		}
+		line := lines[in.Line-1]
+		if i := strings.Index(line, "// MATCH"); i >= 0 {
+			line = line[:i]
+		}
+		if got := strings.TrimSpace(line); got != in.Replacement {
+			t.Errorf("Lint failed at %s:%d; fix produced %q, want %q", name, in.Line, got, in.Replacement)
+		}
+		return
+	}
+	t.Errorf("Lint failed at %s:%d; fix didn't change %s", name, in.Line, name)
+}
 
-						//t.Logf("/%v/ matched at %s:%d", in.Match, fi.Name(), in.Line)
-						ok = true
-						break
-					}
-				}
-				if !ok {
-					t.Errorf("Lint failed at %s:%d; /%v/ did not match", name, in.Line, in.Match)
-				}
+// checkRelated checks that p has related information at each of the
+// positions of the instruction.
+func checkRelated(t *testing.T, fset *token.FileSet, name string, in instruction, p lint.Problem) {
+outer:
+	for _, want := range in.Related {
+		for _, r := range p.Related {
+			pos := fset.Position(r.Pos)
+			if filepath.Base(pos.Filename) == name && pos.Line == want.Line &&
+				(want.Column == 0 || pos.Column == want.Column) {
+				continue outer
 			}
 		}
-		for _, p := range res {
-			pos := lprog.Fset.Position(p.Position)
-			name := filepath.Base(pos.Filename)
-			for _, fi := range fis {
-				if name == fi.Name() {
-					t.Errorf("Unexpected problem at %s: %v", pos, p.Text)
-					break
-				}
-			}
+		var got []string
+		for _, r := range p.Related {
+			pos := fset.Position(r.Pos)
+			got = append(got, fmt.Sprintf("%d:%d", pos.Line, pos.Column))
 		}
+		t.Errorf("Lint failed at %s:%d; no related information at %s, got %v", name, in.Line, want, got)
 	}
 }
 
 type instruction struct {
 	Line        int            // the line number this applies to
 	Match       *regexp.Regexp // what pattern to match
-	Replacement string         // what the suggested replacement line should be
+	Replacement string         // what the line should be after applying the suggested fix
+	Related     []relatedLine  // positions that the problem must have related information at
+}
+
+// relatedLine is the position of related information, written as
+// related LINE or related LINE:COLUMN after the pattern of an
+// instruction.
+type relatedLine struct {
+	Line, Column int
+}
+
+func (r relatedLine) String() string {
+	if r.Column == 0 {
+		return strconv.Itoa(r.Line)
+	}
+	return fmt.Sprintf("%d:%d", r.Line, r.Column)
+}
+
+var relatedRe = regexp.MustCompile(`\brelated (\d+)(?::(\d+))?`)
+
+// extractRelated returns the related positions that follow the
+// pattern of an instruction.
+func extractRelated(line string) []relatedLine {
+	if i := strings.Index(line, "/ -> `"); i >= 0 {
+		line = line[:i+1]
+	}
+	if i := strings.LastIndexAny(line, `/"`); i >= 0 {
+		line = line[i+1:]
+	}
+	var out []relatedLine
+	for _, m := range relatedRe.FindAllStringSubmatch(line, -1) {
+		var r relatedLine
+		r.Line, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			r.Column, _ = strconv.Atoi(m[2])
+		}
+		out = append(out, r)
+	}
+	return out
 }
 
 // parseInstructions parses instructions from the comments in a Go source file.
@@ -185,6 +288,7 @@ func parseInstructions(t *testing.T, filename string, src []byte) []instruction
 				Line:        matchLine,
 				Match:       rx,
 				Replacement: repl,
+				Related:     extractRelated(line),
 			})
 		}
 	}
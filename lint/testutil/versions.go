@@ -0,0 +1,176 @@
+package testutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"honnef.co/go/tools/lint"
+)
+
+var versionsUpdate = flag.String("versions.update", "", "record the testdata of all checks as of this `release` in testdata/versions.golden")
+
+const versionsGolden = "versions.golden"
+
+// CheckFiles maps the checks of c to their files in testdata. Files
+// named after the function implementing a check, optionally followed
+// by an underscore and a suffix, e.g. CheckRegexps.go and
+// CheckDeprecated_go18.go, belong to that check; all other files
+// belong to the checks that report problems in them. Checks without
+// files are omitted.
+func CheckFiles(t *testing.T, c lint.Checker) map[string][]string {
+	lprog, files, _ := load(t, "testdata", "")
+	owned := map[string]map[string]bool{}
+	own := func(id, name string) {
+		if owned[id] == nil {
+			owned[id] = map[string]bool{}
+		}
+		owned[id][name] = true
+	}
+	named := map[string]bool{}
+	for id, fn := range c.Funcs() {
+		name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+		name = strings.TrimSuffix(name[strings.LastIndex(name, ".")+1:], "-fm")
+		for _, fis := range files {
+			for _, fi := range fis {
+				if fi.Name() == name+".go" ||
+					(strings.HasPrefix(fi.Name(), name+"_") && strings.HasSuffix(fi.Name(), ".go")) {
+					own(id, fi.Name())
+					named[fi.Name()] = true
+				}
+			}
+		}
+	}
+	for version, fis := range files {
+		names := map[string]bool{}
+		for _, fi := range fis {
+			names[fi.Name()] = true
+		}
+		l := &lint.Linter{Checker: c, GoVersion: version}
+		for _, p := range l.Lint(lprog) {
+			name := filepath.Base(lprog.Fset.Position(p.Position).Filename)
+			if names[name] && !named[name] {
+				own(p.Check, name)
+			}
+		}
+	}
+	out := map[string][]string{}
+	for id, names := range owned {
+		for name := range names {
+			out[id] = append(out[id], name)
+		}
+	}
+	return out
+}
+
+// TestVersions checks that the versions of the checks of c account
+// for changes to their testdata. testdata/versions.golden records a
+// hash of the testdata of every check as of the last release; if
+// the testdata of a check differs from it, the check has to have
+// been introduced or changed after that release. files maps checks
+// to their files in testdata, see CheckFiles.
+//
+// When cutting a release, the hashes are updated with
+// -versions.update=<release>.
+func TestVersions(t *testing.T, c lint.VersionedChecker, files map[string][]string) {
+	hashes := map[string]string{}
+	for id, names := range files {
+		h, err := hashFiles(names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[id] = h
+	}
+	golden := filepath.Join("testdata", versionsGolden)
+
+	if *versionsUpdate != "" {
+		var ids []string
+		for id := range hashes {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		buf := &bytes.Buffer{}
+		fmt.Fprintln(buf, "# check, release, hash of its testdata as of the release")
+		for _, id := range ids {
+			fmt.Fprintf(buf, "%s %s %s\n", id, *versionsUpdate, hashes[id])
+		}
+		if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	type entry struct{ release, hash string }
+	recorded := map[string]entry{}
+	f, err := os.Open(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			t.Fatalf("%s: malformed line %q", golden, line)
+		}
+		recorded[fields[0]] = entry{fields[1], fields[2]}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	versions := c.Versions()
+	for id, hash := range hashes {
+		v, ok := versions[id]
+		if !ok {
+			t.Errorf("%s has no version", id)
+			continue
+		}
+		e, ok := recorded[id]
+		if !ok {
+			if v.Introduced != lint.Version {
+				t.Errorf("%s was introduced in %s, but %s has no record of it", id, v.Introduced, golden)
+			}
+			continue
+		}
+		if hash != e.hash && !v.Since(e.release) {
+			t.Errorf("the testdata of %s changed since %s, but the check's version didn't; set Changed to %q",
+				id, e.release, lint.Version)
+		}
+	}
+}
+
+var matchComment = regexp.MustCompile(`[ \t]*// MATCH.*`)
+
+func hashFiles(names []string) (string, error) {
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			return "", err
+		}
+		// Only the code matters, not the problems expected of it,
+		// which may belong to other checks.
+		data = matchComment.ReplaceAll(data, nil)
+		fmt.Fprintf(h, "%d\x00", len(data))
+		h.Write(data)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)[:12]), nil
+}
@@ -0,0 +1,66 @@
+package lint
+
+import (
+	"errors"
+	"go/build"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ParseGoVersion parses a Go version in the format 1.x, or 1.x.y,
+// and returns its minor version x.
+func ParseGoVersion(s string) (int, error) {
+	if !strings.HasPrefix(s, "1.") {
+		return 0, errors.New("invalid Go version")
+	}
+	minor := s[2:]
+	if i := strings.Index(minor, "."); i >= 0 {
+		// ignore the patch release, as in 1.21.3
+		minor = minor[:i]
+	}
+	v, err := strconv.Atoi(minor)
+	if err != nil {
+		return 0, errors.New("invalid Go version")
+	}
+	return v, nil
+}
+
+// ToolchainGoVersion returns the minor version of the Go release the
+// program was built with.
+func ToolchainGoVersion() int {
+	tags := build.Default.ReleaseTags
+	v, err := ParseGoVersion(tags[len(tags)-1][len("go"):])
+	if err != nil {
+		panic("internal error: unexpected release tag " + tags[len(tags)-1])
+	}
+	return v
+}
+
+// ModuleGoVersion returns the minor Go version declared by the go
+// directive of the go.mod file closest to dir, if any.
+func ModuleGoVersion(dir string) (int, bool) {
+	for {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(b), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) != 2 || fields[0] != "go" {
+					continue
+				}
+				v, err := ParseGoVersion(fields[1])
+				if err != nil {
+					return 0, false
+				}
+				return v, true
+			}
+			return 0, false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return 0, false
+		}
+		dir = parent
+	}
+}
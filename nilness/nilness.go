@@ -0,0 +1,218 @@
+// Package nilness tracks which pointers, maps, channels, functions,
+// slices and interfaces of a function are known to be nil or non-nil.
+//
+// The analysis is intraprocedural. Values are non-nil if the
+// instruction producing them can't produce nil, such as taking the
+// address of a variable, and nil if they are the nil constant.
+// Comparisons against nil refine the nilness of the compared value in
+// the blocks that are only reachable through the respective branch,
+// and dereferencing a value shows it to be non-nil in all code that
+// the dereference dominates.
+package nilness // import "honnef.co/go/tools/nilness"
+
+import (
+	"go/token"
+
+	"honnef.co/go/tools/ssa"
+)
+
+// Nilness is an element of the lattice nil / non-nil / unknown.
+type Nilness int
+
+const (
+	Unknown Nilness = iota
+	Nil
+	NonNil
+)
+
+func (n Nilness) String() string {
+	switch n {
+	case Nil:
+		return "nil"
+	case NonNil:
+		return "non-nil"
+	default:
+		return "unknown"
+	}
+}
+
+// A fact records the nilness of a value. Deref is the instruction
+// that dereferenced the value, if that is how the fact was learned.
+type fact struct {
+	value   ssa.Value
+	nilness Nilness
+	deref   ssa.Instruction
+}
+
+// Analysis is the result of analyzing a function.
+type Analysis struct {
+	fn *ssa.Function
+	// facts are the facts that hold on entry to each block.
+	facts map[*ssa.BasicBlock][]fact
+}
+
+// Analyze analyzes fn.
+func Analyze(fn *ssa.Function) *Analysis {
+	a := &Analysis{
+		fn:    fn,
+		facts: map[*ssa.BasicBlock][]fact{},
+	}
+	if len(fn.Blocks) > 0 {
+		a.visit(fn.Blocks[0], nil)
+	}
+	return a
+}
+
+// visit records the facts that hold on entry to b and visits the
+// blocks b immediately dominates.
+func (a *Analysis) visit(b *ssa.BasicBlock, facts []fact) {
+	a.facts[b] = facts
+	for _, instr := range b.Instrs {
+		if v := Dereferenced(instr); v != nil {
+			facts = append(facts, fact{v, NonNil, instr})
+		}
+	}
+	for _, child := range b.Dominees() {
+		childFacts := facts
+		if f, ok := branchFact(b, child); ok {
+			childFacts = append(childFacts[:len(childFacts):len(childFacts)], f)
+		}
+		a.visit(child, childFacts)
+	}
+}
+
+// branchFact returns the fact learned by taking the edge from b to
+// succ, if b ends in a comparison against nil and succ can only be
+// reached through that edge.
+func branchFact(b, succ *ssa.BasicBlock) (fact, bool) {
+	if len(succ.Preds) != 1 || len(b.Instrs) == 0 {
+		return fact{}, false
+	}
+	If, ok := b.Instrs[len(b.Instrs)-1].(*ssa.If)
+	if !ok {
+		return fact{}, false
+	}
+	cond, ok := If.Cond.(*ssa.BinOp)
+	if !ok || (cond.Op != token.EQL && cond.Op != token.NEQ) {
+		return fact{}, false
+	}
+	v := cond.X
+	if isNilConst(v) {
+		v = cond.Y
+	} else if !isNilConst(cond.Y) {
+		return fact{}, false
+	}
+	isNil := (cond.Op == token.EQL) == (succ == b.Succs[0])
+	if isNil {
+		return fact{value: v, nilness: Nil}, true
+	}
+	return fact{value: v, nilness: NonNil}, true
+}
+
+func isNilConst(v ssa.Value) bool {
+	k, ok := v.(*ssa.Const)
+	return ok && k.IsNil()
+}
+
+// Dereferenced returns the value that instr dereferences, or nil.
+// Execution only continues past such an instruction if the value
+// isn't nil.
+func Dereferenced(instr ssa.Instruction) ssa.Value {
+	switch instr := instr.(type) {
+	case *ssa.UnOp:
+		if instr.Op == token.MUL {
+			return instr.X
+		}
+	case *ssa.FieldAddr:
+		return instr.X
+	case *ssa.Store:
+		return instr.Addr
+	case *ssa.MapUpdate:
+		return instr.Map
+	case *ssa.Call:
+		if instr.Call.IsInvoke() {
+			return instr.Call.Value
+		}
+		if instr.Call.StaticCallee() == nil {
+			if _, ok := instr.Call.Value.(*ssa.Builtin); !ok {
+				return instr.Call.Value
+			}
+		}
+	}
+	return nil
+}
+
+// At returns the nilness of v at the point where instr executes.
+func (a *Analysis) At(instr ssa.Instruction, v ssa.Value) Nilness {
+	n, _ := a.lookup(instr, v, map[ssa.Value]bool{})
+	return n
+}
+
+// DereferencedBefore returns an instruction that dereferences v and
+// always executes before instr, or nil if there is no such
+// instruction.
+func (a *Analysis) DereferencedBefore(instr ssa.Instruction, v ssa.Value) ssa.Instruction {
+	_, deref := a.lookup(instr, v, map[ssa.Value]bool{})
+	return deref
+}
+
+func (a *Analysis) lookup(instr ssa.Instruction, v ssa.Value, seen map[ssa.Value]bool) (Nilness, ssa.Instruction) {
+	if n := intrinsic(v); n != Unknown {
+		return n, nil
+	}
+	b := instr.Block()
+	for _, other := range b.Instrs {
+		if other == instr {
+			break
+		}
+		if Dereferenced(other) == v {
+			return NonNil, other
+		}
+	}
+	facts := a.facts[b]
+	for i := len(facts) - 1; i >= 0; i-- {
+		if facts[i].value == v {
+			return facts[i].nilness, facts[i].deref
+		}
+	}
+	switch v := v.(type) {
+	case *ssa.ChangeType:
+		return a.lookup(instr, v.X, seen)
+	case *ssa.Sigma:
+		return a.lookup(instr, v.X, seen)
+	case *ssa.Phi:
+		if seen[v] {
+			return Unknown, nil
+		}
+		seen[v] = true
+		var n Nilness
+		for i, edge := range v.Edges {
+			pred := v.Block().Preds[i]
+			var en Nilness
+			if len(pred.Instrs) > 0 {
+				en, _ = a.lookup(pred.Instrs[len(pred.Instrs)-1], edge, seen)
+			}
+			if en == Unknown || (i > 0 && en != n) {
+				return Unknown, nil
+			}
+			n = en
+		}
+		return n, nil
+	}
+	return Unknown, nil
+}
+
+// intrinsic returns the nilness of v that follows from the
+// instruction that produces it.
+func intrinsic(v ssa.Value) Nilness {
+	switch v := v.(type) {
+	case *ssa.Const:
+		if v.IsNil() {
+			return Nil
+		}
+	case *ssa.Alloc, *ssa.Global, *ssa.Function, *ssa.FieldAddr, *ssa.IndexAddr,
+		*ssa.MakeChan, *ssa.MakeClosure, *ssa.MakeInterface, *ssa.MakeMap, *ssa.MakeSlice:
+		return NonNil
+	}
+	return Unknown
+}
@@ -0,0 +1,267 @@
+package pattern
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+// Bindings maps the names of named wildcards to the nodes they
+// matched.
+type Bindings map[string]ast.Node
+
+// A Match describes a single successful match of a pattern.
+type Match struct {
+	// Nodes are the matched nodes. For patterns that consist of a
+	// list of statements, this is the matched run of statements.
+	Nodes    []ast.Node
+	Bindings Bindings
+}
+
+// Pos returns the position of the first matched node.
+func (m Match) Pos() token.Pos { return m.Nodes[0].Pos() }
+
+// End returns the end position of the last matched node.
+func (m Match) End() token.Pos { return m.Nodes[len(m.Nodes)-1].End() }
+
+// MatchNode reports whether node matches a pattern consisting of a
+// single expression or statement. Info is used to check the types of
+// typed wildcards and to resolve package names; it may be nil, in
+// which case typed wildcards never match.
+func (p *Pattern) MatchNode(info *types.Info, node ast.Node) (Bindings, bool) {
+	if len(p.Nodes) != 1 {
+		return nil, false
+	}
+	m := &matcher{p: p, info: info, b: Bindings{}}
+	if !m.node(p.Nodes[0], node) {
+		return nil, false
+	}
+	return m.b, true
+}
+
+// Find returns all matches of the pattern in root, in source order.
+func (p *Pattern) Find(info *types.Info, root ast.Node) []Match {
+	var out []Match
+	ast.Inspect(root, func(node ast.Node) bool {
+		if node == nil {
+			return true
+		}
+		if len(p.Nodes) == 1 {
+			if b, ok := p.MatchNode(info, node); ok {
+				out = append(out, Match{Nodes: []ast.Node{node}, Bindings: b})
+			}
+			return true
+		}
+
+		var list []ast.Stmt
+		switch node := node.(type) {
+		case *ast.BlockStmt:
+			list = node.List
+		case *ast.CaseClause:
+			list = node.Body
+		case *ast.CommClause:
+			list = node.Body
+		default:
+			return true
+		}
+	stmts:
+		for i := 0; i+len(p.Nodes) <= len(list); i++ {
+			m := &matcher{p: p, info: info, b: Bindings{}}
+			for j, pn := range p.Nodes {
+				if !m.node(pn, list[i+j]) {
+					continue stmts
+				}
+			}
+			var nodes []ast.Node
+			for _, stmt := range list[i : i+len(p.Nodes)] {
+				nodes = append(nodes, stmt)
+			}
+			out = append(out, Match{Nodes: nodes, Bindings: m.b})
+		}
+		return true
+	})
+	return out
+}
+
+type matcher struct {
+	p    *Pattern
+	info *types.Info
+	b    Bindings
+}
+
+var (
+	posType    = reflect.TypeOf(token.NoPos)
+	objectType = reflect.TypeOf((*ast.Object)(nil))
+	scopeType  = reflect.TypeOf((*ast.Scope)(nil))
+	commentsTy = reflect.TypeOf((*ast.CommentGroup)(nil))
+)
+
+func (m *matcher) node(pattern, node ast.Node) bool {
+	if isNil(pattern) || isNil(node) {
+		return isNil(pattern) && isNil(node)
+	}
+	if _, ok := pattern.(*ast.ParenExpr); !ok {
+		// Redundant parentheses in the code don't change its
+		// meaning and shouldn't prevent a match.
+		node = unparen(node)
+	}
+	if m.wildcard(pattern, node) {
+		return true
+	}
+
+	switch pattern := pattern.(type) {
+	case *ast.Ident:
+		node, ok := node.(*ast.Ident)
+		return ok && pattern.Name == node.Name
+	case *ast.BasicLit:
+		node, ok := node.(*ast.BasicLit)
+		return ok && pattern.Kind == node.Kind && pattern.Value == node.Value
+	case *ast.ParenExpr:
+		// Parentheses in the pattern are only there to group.
+		return m.node(pattern.X, unparen(node))
+	case *ast.SelectorExpr:
+		node, ok := node.(*ast.SelectorExpr)
+		if !ok || pattern.Sel.Name != node.Sel.Name {
+			return false
+		}
+		if m.packageName(pattern.X, node.X) {
+			return true
+		}
+		return m.node(pattern.X, node.X)
+	}
+
+	pv, nv := reflect.ValueOf(pattern), reflect.ValueOf(node)
+	if pv.Type() != nv.Type() {
+		return false
+	}
+	return m.fields(pv.Elem(), nv.Elem())
+}
+
+// fields structurally compares two nodes of the same type, ignoring
+// positions, comments and scope information.
+func (m *matcher) fields(pv, nv reflect.Value) bool {
+	if pv.Type() != nv.Type() {
+		return false
+	}
+	switch pv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if pv.IsNil() || nv.IsNil() {
+			return pv.IsNil() && nv.IsNil()
+		}
+		pn, ok1 := pv.Interface().(ast.Node)
+		nn, ok2 := nv.Interface().(ast.Node)
+		if ok1 && ok2 {
+			return m.node(pn, nn)
+		}
+		return m.fields(pv.Elem(), nv.Elem())
+	case reflect.Slice:
+		if pv.Len() != nv.Len() {
+			return false
+		}
+		for i := 0; i < pv.Len(); i++ {
+			if !m.fields(pv.Index(i), nv.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < pv.NumField(); i++ {
+			switch pv.Type().Field(i).Type {
+			case posType, objectType, scopeType, commentsTy:
+				continue
+			}
+			if !m.fields(pv.Field(i), nv.Field(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return pv.Interface() == nv.Interface()
+	}
+}
+
+func (m *matcher) wildcard(pattern, node ast.Node) bool {
+	ident, ok := pattern.(*ast.Ident)
+	if !ok || !strings.HasPrefix(ident.Name, wildcardPrefix) {
+		return false
+	}
+	w := m.p.wildcards[ident.Name]
+	expr, ok := node.(ast.Expr)
+	if !ok {
+		return false
+	}
+	if w.Type != "" && !m.hasType(expr, w.Type) {
+		return false
+	}
+	if w.Name == "" {
+		return true
+	}
+	if prev, ok := m.b[w.Name]; ok {
+		// Code never contains wildcards, so this is a plain
+		// structural comparison.
+		return m.node(prev, node)
+	}
+	m.b[w.Name] = node
+	return true
+}
+
+func (m *matcher) hasType(expr ast.Expr, want string) bool {
+	if m.info == nil {
+		return false
+	}
+	return typeMatches(m.info.TypeOf(expr), want)
+}
+
+// typeMatches reports whether T is the type written as want.
+func typeMatches(T types.Type, want string) bool {
+	if T == nil {
+		return false
+	}
+	byName := types.TypeString(T, func(pkg *types.Package) string { return pkg.Name() })
+	byPath := types.TypeString(T, func(pkg *types.Package) string { return pkg.Path() })
+	clean := func(s string) string { return strings.Replace(s, " ", "", -1) }
+	if clean(byName) == want || clean(byPath) == want {
+		return true
+	}
+	// byte and rune are aliases; accept either spelling.
+	alias := strings.NewReplacer("byte", "uint8", "rune", "int32")
+	return alias.Replace(clean(byName)) == alias.Replace(want)
+}
+
+// packageName reports whether pattern, an identifier, names the
+// package that node refers to, either by name or by import path.
+func (m *matcher) packageName(pattern, node ast.Expr) bool {
+	pident, ok := pattern.(*ast.Ident)
+	if !ok || m.info == nil {
+		return false
+	}
+	nident, ok := node.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	pkg, ok := m.info.Uses[nident].(*types.PkgName)
+	if !ok {
+		return false
+	}
+	return pkg.Imported().Name() == pident.Name || pkg.Imported().Path() == pident.Name
+}
+
+func unparen(node ast.Node) ast.Node {
+	for {
+		paren, ok := node.(*ast.ParenExpr)
+		if !ok {
+			return node
+		}
+		node = paren.X
+	}
+}
+
+func isNil(node ast.Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
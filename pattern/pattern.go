@@ -0,0 +1,222 @@
+// Package pattern implements a small structural search language for
+// Go code.
+//
+// A pattern is ordinary Go syntax – an expression, a statement or a
+// list of statements – that may contain wildcards:
+//
+//	_          matches any expression
+//	_:T        matches any expression of type T
+//	$x         matches any expression and binds it to x
+//	$x:T       like $x, but the expression must be of type T
+//
+// Repeated uses of the same named wildcard must match identical
+// code, so that the pattern `$x = $x` finds self-assignments.
+// Types are written in Go syntax and may refer to packages either by
+// name or by import path, e.g. `_:*http.Request` or
+// `_:*net/http.Request`.
+package pattern // import "honnef.co/go/tools/pattern"
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+const wildcardPrefix = "gopattern_wildcard_"
+
+// Wildcard describes a single wildcard in a pattern.
+type Wildcard struct {
+	// Name is the name the wildcard binds to, or the empty string
+	// for anonymous wildcards.
+	Name string
+	// Type is the type the matched expression must have, or the
+	// empty string if any type is acceptable.
+	Type string
+}
+
+// A Pattern is a parsed pattern, ready to be matched against Go code.
+type Pattern struct {
+	// Src is the pattern as written by the user.
+	Src string
+	// Nodes are the parsed nodes of the pattern. A pattern consisting
+	// of a single expression or statement has exactly one node.
+	Nodes []ast.Node
+
+	wildcards map[string]Wildcard
+}
+
+// Parse parses a pattern.
+func Parse(src string) (*Pattern, error) {
+	rewritten, wildcards, err := rewriteWildcards(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pattern{
+		Src:       src,
+		wildcards: wildcards,
+	}
+
+	if expr, err := parser.ParseExpr(rewritten); err == nil {
+		p.Nodes = []ast.Node{expr}
+		return p, nil
+	}
+
+	// Not an expression, try parsing a list of statements instead.
+	file := "package p; func _() {\n" + rewritten + "\n}"
+	f, err := parser.ParseFile(token.NewFileSet(), "", file, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse pattern %q: %s", src, err)
+	}
+	body := f.Decls[0].(*ast.FuncDecl).Body
+	if len(body.List) == 0 {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	for _, stmt := range body.List {
+		p.Nodes = append(p.Nodes, stmt)
+	}
+	if len(p.Nodes) == 1 {
+		if stmt, ok := p.Nodes[0].(*ast.ExprStmt); ok {
+			p.Nodes[0] = stmt.X
+		}
+	}
+	return p, nil
+}
+
+// MustParse is like Parse but panics if the pattern cannot be parsed.
+// It simplifies the initialization of global variables holding
+// patterns.
+func MustParse(src string) *Pattern {
+	p, err := Parse(src)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *Pattern) String() string {
+	return p.Src
+}
+
+// rewriteWildcards replaces all wildcards in src with identifiers
+// that can be parsed as ordinary Go code.
+func rewriteWildcards(src string) (string, map[string]Wildcard, error) {
+	type tok struct {
+		pos token.Pos
+		tok token.Token
+		lit string
+	}
+
+	var errs scanner.ErrorList
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), func(pos token.Position, msg string) {
+		// '$' is not valid Go; we handle it ourselves.
+		if strings.Contains(msg, "'$'") {
+			return
+		}
+		errs.Add(pos, msg)
+	}, 0)
+
+	var toks []tok
+	for {
+		pos, t, lit := s.Scan()
+		if t == token.EOF {
+			break
+		}
+		toks = append(toks, tok{pos, t, lit})
+	}
+	if len(errs) > 0 {
+		return "", nil, errs.Err()
+	}
+
+	offset := func(pos token.Pos) int { return file.Offset(pos) }
+	end := func(t tok) int {
+		switch {
+		case t.tok == token.SEMICOLON:
+			// Either an explicit or an automatically inserted
+			// semicolon; both occupy a single byte.
+			return offset(t.pos) + 1
+		case t.lit != "":
+			return offset(t.pos) + len(t.lit)
+		default:
+			return offset(t.pos) + len(t.tok.String())
+		}
+	}
+
+	wildcards := map[string]Wildcard{}
+	var buf bytes.Buffer
+	last := 0
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		var name string
+		switch {
+		case t.tok == token.IDENT && t.lit == "_":
+		case t.tok == token.ILLEGAL && t.lit == "$" && i+1 < len(toks) && toks[i+1].tok == token.IDENT &&
+			offset(toks[i+1].pos) == offset(t.pos)+1:
+			i++
+			name = toks[i].lit
+		default:
+			continue
+		}
+		start := offset(t.pos)
+		stop := end(toks[i])
+
+		var typ string
+		if i+2 < len(toks) && toks[i+1].tok == token.COLON &&
+			offset(toks[i+1].pos) == stop && offset(toks[i+2].pos) == stop+1 &&
+			startsType(toks[i+2].tok) {
+			// Consume the type, which extends until the first
+			// unbalanced closing bracket, separator or operator
+			// that cannot be part of a type.
+			j := i + 2
+			depth := 0
+		loop:
+			for ; j < len(toks); j++ {
+				switch toks[j].tok {
+				case token.LPAREN, token.LBRACK, token.LBRACE:
+					depth++
+				case token.RPAREN, token.RBRACK, token.RBRACE:
+					if depth == 0 {
+						break loop
+					}
+					depth--
+				case token.MUL, token.PERIOD, token.ARROW, token.ELLIPSIS:
+				default:
+					if depth == 0 && toks[j].tok.IsOperator() {
+						break loop
+					}
+				}
+			}
+			typ = strings.Replace(src[end(toks[i+1]):end(toks[j-1])], " ", "", -1)
+			stop = end(toks[j-1])
+			i = j - 1
+		} else if i+2 == len(toks) && toks[i+1].tok == token.COLON && offset(toks[i+1].pos) == stop {
+			return "", nil, fmt.Errorf("missing type for wildcard at offset %d", start)
+		}
+
+		id := fmt.Sprintf("%s%d", wildcardPrefix, len(wildcards))
+		wildcards[id] = Wildcard{Name: name, Type: typ}
+		buf.WriteString(src[last:start])
+		buf.WriteString(id)
+		last = stop
+	}
+	buf.WriteString(src[last:])
+	return buf.String(), wildcards, nil
+}
+
+// startsType reports whether t can be the first token of a type. A
+// wildcard is only typed if its colon is immediately followed by
+// such a token, so that blanks in `case _:` and `s[_:]` stay blanks.
+func startsType(t token.Token) bool {
+	switch t {
+	case token.IDENT, token.MUL, token.LBRACK, token.MAP, token.CHAN,
+		token.FUNC, token.STRUCT, token.INTERFACE, token.ARROW, token.LPAREN:
+		return true
+	}
+	return false
+}
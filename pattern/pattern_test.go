@@ -0,0 +1,163 @@
+package pattern
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"honnef.co/go/tools/ssa"
+)
+
+const src = `package pkg
+
+func f(x int, s string, b []byte) {
+	_ = x + 1
+	_ = s + "a"
+	x = x
+	x = (x)
+	s = s + s
+	if x != 0 {
+		return
+	}
+	println(len(b), len(s))
+	x++
+	x--
+	switch x {
+	case 1:
+		_ = s[1:]
+	}
+}
+`
+
+func check(t *testing.T) (*ast.File, *types.Info) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "pkg.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Uses:  map[*ast.Ident]types.Object{},
+		Defs:  map[*ast.Ident]types.Object{},
+	}
+	if _, err := (&types.Config{}).Check("pkg", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+	return f, info
+}
+
+func TestFind(t *testing.T) {
+	f, info := check(t)
+	tests := []struct {
+		pattern string
+		matches int
+	}{
+		{`_ + 1`, 1},
+		{`_:int + _`, 1},
+		{`_:string + _`, 2},
+		{`$x = $x`, 2},
+		{`$x = $x + $x`, 1},
+		{`len(_:[]byte)`, 1},
+		{`len(_:[]uint8)`, 1},
+		{`len(_)`, 2},
+		{`if _ != 0 { return }`, 1},
+		{"x++\nx--", 1},
+		{"x--\nx++", 0},
+		{`y = y`, 0},
+		{`s[_:]`, 1},
+		{`_[_:]`, 1},
+		{`switch x { case _: _ = _ }`, 1},
+		{`switch _ { case _: _ = s[_:] }`, 1},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.pattern)
+		if err != nil {
+			t.Errorf("%q: %s", tt.pattern, err)
+			continue
+		}
+		if got := len(p.Find(info, f)); got != tt.matches {
+			t.Errorf("%q: got %d matches, want %d", tt.pattern, got, tt.matches)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{``, `_:`, `foo(`} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+const callSrc = `package pkg
+
+func g(format string, args ...interface{}) {}
+
+func h(b bool) {
+	f := "%d"
+	if b {
+		f = "%x"
+	}
+	g(f, 1)
+	g("%d", 2, "a")
+	x := 3
+	g("%s", x)
+	g("%s")
+	println(len("abc"), len(f))
+}
+`
+
+func TestFindCalls(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "pkg.go", callSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Implicits:  map[ast.Node]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Scopes:     map[ast.Node]*types.Scope{},
+	}
+	pkg, err := (&types.Config{}).Check("pkg", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := ssa.NewProgram(fset, 0)
+	spkg := prog.CreatePackage(pkg, []*ast.File{f}, info, false)
+	spkg.Build()
+	fn := spkg.Func("h")
+
+	tests := []struct {
+		pattern string
+		matches int
+	}{
+		{`g(_, _)`, 2},
+		{`g(_, _:int)`, 2},
+		{`g(_, _:string)`, 0},
+		{`g(_, _:int, _:string)`, 1},
+		{`g("%d", _)`, 0},
+		{`g("%d", 2, "a")`, 1},
+		{`g("%s", 3)`, 1},
+		{`g("%s")`, 1},
+		{`g(_, _...)`, 4},
+		{`println(3, len(_))`, 1},
+		{`println(_:int, _:int)`, 1},
+		{`len(_)`, 1},
+		{`$f($x, $x)`, 0},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.pattern)
+		if err != nil {
+			t.Errorf("%q: %s", tt.pattern, err)
+			continue
+		}
+		if got := len(p.FindCalls(fn)); got != tt.matches {
+			t.Errorf("%q: got %d matches, want %d", tt.pattern, got, tt.matches)
+		}
+	}
+}
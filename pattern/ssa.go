@@ -0,0 +1,304 @@
+package pattern
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"sort"
+	"strings"
+
+	"honnef.co/go/tools/ssa"
+)
+
+// ValueBindings maps the names of named wildcards to the SSA values
+// they matched.
+type ValueBindings map[string]ssa.Value
+
+// A CallMatch describes a call that matches a pattern in SSA form.
+type CallMatch struct {
+	Call     ssa.CallInstruction
+	Bindings ValueBindings
+}
+
+// Pos returns the position of the matched call.
+func (m CallMatch) Pos() token.Pos { return m.Call.Pos() }
+
+// IsCall reports whether the pattern is a single call expression,
+// the only kind of pattern that can be matched against SSA form.
+func (p *Pattern) IsCall() bool {
+	if len(p.Nodes) != 1 {
+		return false
+	}
+	_, ok := p.Nodes[0].(*ast.CallExpr)
+	return ok
+}
+
+// MatchCall reports whether call matches a pattern consisting of a
+// single call expression.
+//
+// Unlike MatchNode, MatchCall compares the values that flow into the
+// call rather than the syntax of its arguments: a literal in the
+// pattern matches any constant argument of that value, including one
+// that was first assigned to a local variable, and the arguments of
+// variadic functions are matched individually. Typed wildcards match
+// arguments by the type they have before being converted to an
+// interface type. Arguments that are neither constants nor calls can
+// only be matched by wildcards.
+func (p *Pattern) MatchCall(call ssa.CallInstruction) (ValueBindings, bool) {
+	if !p.IsCall() {
+		return nil, false
+	}
+	m := &ssaMatcher{p: p, b: ValueBindings{}}
+	if !m.call(p.Nodes[0].(*ast.CallExpr), call.Common()) {
+		return nil, false
+	}
+	return m.b, true
+}
+
+// FindCalls returns all calls in fn that match the pattern, in
+// source order. Anonymous functions are searched separately.
+func (p *Pattern) FindCalls(fn *ssa.Function) []CallMatch {
+	var out []CallMatch
+	for _, b := range fn.Blocks {
+		for _, ins := range b.Instrs {
+			call, ok := ins.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			if vb, ok := p.MatchCall(call); ok {
+				out = append(out, CallMatch{Call: call, Bindings: vb})
+			}
+		}
+	}
+	sort.Sort(byPos(out))
+	return out
+}
+
+type byPos []CallMatch
+
+func (s byPos) Len() int           { return len(s) }
+func (s byPos) Less(i, j int) bool { return s[i].Pos() < s[j].Pos() }
+func (s byPos) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type ssaMatcher struct {
+	p *Pattern
+	b ValueBindings
+}
+
+func (m *ssaMatcher) call(pattern *ast.CallExpr, call *ssa.CallCommon) bool {
+	args, ok := m.callee(unparenExpr(pattern.Fun), call)
+	if !ok {
+		return false
+	}
+	sig := call.Signature()
+	if sig.Variadic() && !pattern.Ellipsis.IsValid() && len(args) > 0 {
+		rest, ok := variadicArgs(args[len(args)-1])
+		if !ok {
+			return false
+		}
+		args = append(args[:len(args)-1:len(args)-1], rest...)
+	}
+	if len(args) != len(pattern.Args) {
+		return false
+	}
+	for i, arg := range pattern.Args {
+		if !m.value(arg, args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// callee matches the function part of a call pattern and returns the
+// arguments of the call, without the receiver.
+func (m *ssaMatcher) callee(pattern ast.Expr, call *ssa.CallCommon) ([]ssa.Value, bool) {
+	if m.wildcard(pattern, call.Value) {
+		return call.Args, true
+	}
+	switch pattern := pattern.(type) {
+	case *ast.Ident:
+		switch v := call.Value.(type) {
+		case *ssa.Builtin:
+			return call.Args, v.Name() == pattern.Name
+		case *ssa.Function:
+			return call.Args, v.Signature.Recv() == nil && v.Parent() == nil && v.Name() == pattern.Name
+		}
+	case *ast.SelectorExpr:
+		if call.IsInvoke() {
+			if call.Method.Name() != pattern.Sel.Name || !m.value(pattern.X, call.Value) {
+				return nil, false
+			}
+			return call.Args, true
+		}
+		fn := call.StaticCallee()
+		if fn == nil || fn.Name() != pattern.Sel.Name {
+			return nil, false
+		}
+		if fn.Signature.Recv() != nil {
+			if len(call.Args) == 0 || !m.value(pattern.X, call.Args[0]) {
+				return nil, false
+			}
+			return call.Args[1:], true
+		}
+		pkg, ok := pattern.X.(*ast.Ident)
+		if !ok || fn.Pkg == nil {
+			return nil, false
+		}
+		if fn.Pkg.Pkg.Name() != pkg.Name && fn.Pkg.Pkg.Path() != pkg.Name {
+			return nil, false
+		}
+		return call.Args, true
+	}
+	return nil, false
+}
+
+func (m *ssaMatcher) value(pattern ast.Expr, v ssa.Value) bool {
+	pattern = unparenExpr(pattern)
+	if m.wildcard(pattern, v) {
+		return true
+	}
+	if mi, ok := v.(*ssa.MakeInterface); ok {
+		v = mi.X
+	}
+	switch pattern := pattern.(type) {
+	case *ast.CallExpr:
+		call, ok := v.(*ssa.Call)
+		return ok && m.call(pattern, call.Common())
+	case *ast.Ident:
+		c, ok := v.(*ssa.Const)
+		if !ok {
+			return false
+		}
+		switch pattern.Name {
+		case "nil":
+			return c.Value == nil
+		case "true", "false":
+			return c.Value != nil && c.Value.Kind() == constant.Bool &&
+				constant.BoolVal(c.Value) == (pattern.Name == "true")
+		}
+		return false
+	}
+	lit, ok := literal(pattern)
+	if !ok {
+		return false
+	}
+	c, ok := v.(*ssa.Const)
+	return ok && constantsEqual(c.Value, lit)
+}
+
+func (m *ssaMatcher) wildcard(pattern ast.Expr, v ssa.Value) bool {
+	ident, ok := pattern.(*ast.Ident)
+	if !ok || !strings.HasPrefix(ident.Name, wildcardPrefix) {
+		return false
+	}
+	w := m.p.wildcards[ident.Name]
+	if w.Type != "" {
+		if mi, ok := v.(*ssa.MakeInterface); ok && !typeMatches(v.Type(), w.Type) {
+			v = mi.X
+		}
+		if !typeMatches(v.Type(), w.Type) {
+			return false
+		}
+	}
+	if w.Name == "" {
+		return true
+	}
+	if prev, ok := m.b[w.Name]; ok {
+		return sameValue(prev, v)
+	}
+	m.b[w.Name] = v
+	return true
+}
+
+// variadicArgs returns the individual arguments that make up the
+// variadic slice v, if they can be determined.
+func variadicArgs(v ssa.Value) ([]ssa.Value, bool) {
+	switch v := v.(type) {
+	case *ssa.Const:
+		// No variadic arguments at all.
+		return nil, v.Value == nil
+	case *ssa.Slice:
+		alloc, ok := v.X.(*ssa.Alloc)
+		if !ok {
+			return nil, false
+		}
+		args := map[int64]ssa.Value{}
+		for _, ref := range *alloc.Referrers() {
+			idx, ok := ref.(*ssa.IndexAddr)
+			if !ok {
+				continue
+			}
+			k, ok := idx.Index.(*ssa.Const)
+			if !ok {
+				return nil, false
+			}
+			for _, ref := range *idx.Referrers() {
+				if store, ok := ref.(*ssa.Store); ok && store.Addr == idx {
+					args[k.Int64()] = store.Val
+				}
+			}
+		}
+		out := make([]ssa.Value, len(args))
+		for i := range out {
+			arg, ok := args[int64(i)]
+			if !ok {
+				return nil, false
+			}
+			out[i] = arg
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// literal returns the value of a basic literal, optionally negated.
+func literal(expr ast.Expr) (constant.Value, bool) {
+	neg := false
+	if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.SUB {
+		neg = true
+		expr = unparenExpr(u.X)
+	}
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok {
+		return nil, false
+	}
+	v := constant.MakeFromLiteral(lit.Value, lit.Kind, 0)
+	if v.Kind() == constant.Unknown {
+		return nil, false
+	}
+	if neg {
+		v = constant.UnaryOp(token.SUB, v, 0)
+	}
+	return v, true
+}
+
+func constantsEqual(x, y constant.Value) bool {
+	if x == nil || y == nil {
+		return x == nil && y == nil
+	}
+	numeric := func(k constant.Kind) bool {
+		return k == constant.Int || k == constant.Float || k == constant.Complex
+	}
+	if x.Kind() != y.Kind() && !(numeric(x.Kind()) && numeric(y.Kind())) {
+		return false
+	}
+	return constant.Compare(x, token.EQL, y)
+}
+
+// sameValue reports whether two values bound to the same wildcard
+// are the same. Distinct constants of equal value are the same.
+func sameValue(x, y ssa.Value) bool {
+	if x == y {
+		return true
+	}
+	cx, ok1 := x.(*ssa.Const)
+	cy, ok2 := y.(*ssa.Const)
+	if ok1 && ok2 {
+		return constantsEqual(cx.Value, cy.Value)
+	}
+	return false
+}
+
+func unparenExpr(expr ast.Expr) ast.Expr {
+	return unparen(expr).(ast.Expr)
+}
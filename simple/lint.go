@@ -2,6 +2,7 @@
 package simple // import "honnef.co/go/tools/simple"
 
 import (
+	"fmt"
 	"go/ast"
 	"go/constant"
 	"go/token"
@@ -13,12 +14,12 @@ import (
 
 	"honnef.co/go/tools/lint"
 
+	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/types/typeutil"
 )
 
 type Checker struct {
-	CheckGenerated bool
-	MS             *typeutil.MethodSetCache
+	MS *typeutil.MethodSetCache
 }
 
 func NewChecker() *Checker {
@@ -57,20 +58,125 @@ func (c *Checker) Funcs() map[string]lint.Func {
 		"S1024": c.LintTimeUntil,
 		"S1025": c.LintRedundantSprintf,
 		"S1026": c.LintStringCopy,
+		"S1027": c.LintStringConcatInLoop,
+		"S1028": c.LintSearchLoop,
+		"S1029": c.LintSortSlice,
+		"S1030": c.LintMapKeysLoop,
+		"S1031": c.LintTimeComparison,
+		"S1032": c.LintEqualFold,
+		"S1033": c.LintSortHelper,
+		"S1034": c.LintReplaceAll,
+		"S1035": c.LintStringsCut,
+		"S1036": c.LintMinMax,
+		"S1037": c.LintFprintf,
+		"S1038": c.LintIfElseChain,
+		"S1039": c.LintRedundantGuardWithRange,
 	}
 }
 
-func (c *Checker) filterGenerated(files []*ast.File) []*ast.File {
-	if c.CheckGenerated {
-		return files
+func (c *Checker) Versions() map[string]lint.CheckVersion {
+	return map[string]lint.CheckVersion{
+		"S1000": {Introduced: "2017.1", Changed: "2017.2"},
+		"S1001": {Introduced: "2017.1", Changed: "2017.2"},
+		"S1002": {Introduced: "2017.1"},
+		"S1003": {Introduced: "2017.1"},
+		"S1004": {Introduced: "2017.1"},
+		"S1005": {Introduced: "2017.1"},
+		"S1006": {Introduced: "2017.1"},
+		"S1007": {Introduced: "2017.1"},
+		"S1008": {Introduced: "2017.1"},
+		"S1009": {Introduced: "2017.1", Changed: "2017.2"},
+		"S1010": {Introduced: "2017.1"},
+		"S1011": {Introduced: "2017.1", Changed: "2017.2"},
+		"S1012": {Introduced: "2017.1"},
+		"S1013": {Introduced: "2017.1"},
+		"S1014": {Introduced: "2017.1"},
+		"S1015": {Introduced: "2017.1"},
+		"S1016": {Introduced: "2017.1"},
+		"S1017": {Introduced: "2017.1"},
+		"S1018": {Introduced: "2017.1"},
+		"S1019": {Introduced: "2017.1"},
+		"S1020": {Introduced: "2017.1"},
+		"S1021": {Introduced: "2017.1"},
+		"S1022": {Introduced: "2017.1"},
+		"S1023": {Introduced: "2017.1"},
+		"S1024": {Introduced: "2017.1"},
+		"S1025": {Introduced: "2017.1"},
+		"S1026": {Introduced: "2017.1"},
+		"S1027": {Introduced: "2017.2"},
+		"S1028": {Introduced: "2017.2"},
+		"S1029": {Introduced: "2017.2"},
+		"S1030": {Introduced: "2017.2"},
+		"S1031": {Introduced: "2017.2"},
+		"S1032": {Introduced: "2017.2"},
+		"S1033": {Introduced: "2017.2"},
+		"S1034": {Introduced: "2017.2"},
+		"S1035": {Introduced: "2017.2"},
+		"S1036": {Introduced: "2017.2"},
+		"S1037": {Introduced: "2017.2"},
+		"S1038": {Introduced: "2017.2"},
+		"S1039": {Introduced: "2017.2"},
 	}
-	var out []*ast.File
-	for _, f := range files {
-		if !lint.IsGenerated(f) {
-			out = append(out, f)
+}
+
+// rangeClause returns the for range clause that receives from the
+// same channel as clause, the only case of a select in an otherwise
+// empty infinite loop. A clause of the form v, ok := <-ch only
+// qualifies if it leaves the loop when ok is false.
+func rangeClause(j *lint.Job, clause *ast.CommClause) (string, bool) {
+	var lhs []ast.Expr
+	var recv ast.Expr
+	tok := token.DEFINE
+	switch comm := clause.Comm.(type) {
+	case *ast.ExprStmt:
+		recv = comm.X
+	case *ast.AssignStmt:
+		lhs, recv, tok = comm.Lhs, comm.Rhs[0], comm.Tok
+	default:
+		return "", false
+	}
+	unary, ok := recv.(*ast.UnaryExpr)
+	if !ok || unary.Op != token.ARROW {
+		return "", false
+	}
+	ch := j.Render(unary.X)
+	switch len(lhs) {
+	case 0:
+		return fmt.Sprintf("for range %s", ch), true
+	case 1:
+		if lint.IsBlank(lhs[0]) {
+			return fmt.Sprintf("for range %s", ch), true
+		}
+		return fmt.Sprintf("for %s %s range %s", j.Render(lhs[0]), tok, ch), true
+	case 2:
+		// if !ok { return } or if !ok { break loop }
+		if len(clause.Body) == 0 {
+			return "", false
+		}
+		ifstmt, ok := clause.Body[0].(*ast.IfStmt)
+		if !ok || ifstmt.Init != nil || ifstmt.Else != nil || len(ifstmt.Body.List) != 1 {
+			return "", false
+		}
+		not, ok := ifstmt.Cond.(*ast.UnaryExpr)
+		if !ok || not.Op != token.NOT || j.Render(not.X) != j.Render(lhs[1]) {
+			return "", false
+		}
+		switch exit := ifstmt.Body.List[0].(type) {
+		case *ast.ReturnStmt:
+		case *ast.BranchStmt:
+			// An unlabeled break only leaves the select.
+			if exit.Tok != token.BREAK || exit.Label == nil {
+				return "", false
+			}
+		default:
+			return "", false
 		}
+		if lint.IsBlank(lhs[0]) {
+			return fmt.Sprintf("for range %s", ch), true
+		}
+		return fmt.Sprintf("for %s %s range %s", j.Render(lhs[0]), tok, ch), true
 	}
-	return out
+	return "", false
 }
 
 func (c *Checker) LintSingleCaseSelect(j *lint.Job) {
@@ -86,18 +192,26 @@ func (c *Checker) LintSingleCaseSelect(j *lint.Job) {
 	fn := func(node ast.Node) bool {
 		switch v := node.(type) {
 		case *ast.ForStmt:
+			if v.Init != nil || v.Cond != nil || v.Post != nil {
+				return true
+			}
 			if len(v.Body.List) != 1 {
 				return true
 			}
 			if !isSingleSelect(v.Body.List[0]) {
 				return true
 			}
-			if _, ok := v.Body.List[0].(*ast.SelectStmt).Body.List[0].(*ast.CommClause).Comm.(*ast.SendStmt); ok {
+			clause := v.Body.List[0].(*ast.SelectStmt).Body.List[0].(*ast.CommClause)
+			if _, ok := clause.Comm.(*ast.SendStmt); ok {
 				// Don't suggest using range for channel sends
 				return true
 			}
+			rng, ok := rangeClause(j, clause)
+			if !ok {
+				return true
+			}
 			seen[v.Body.List[0]] = struct{}{}
-			j.Errorf(node, "should use for range instead of for { select {} }")
+			j.Errorf(node, "should use '%s' instead of for { select {} }", rng)
 		case *ast.SelectStmt:
 			if _, ok := seen[v]; ok {
 				return true
@@ -110,7 +224,7 @@ func (c *Checker) LintSingleCaseSelect(j *lint.Job) {
 		}
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -164,9 +278,12 @@ func (c *Checker) LintLoopCopy(j *lint.Job) {
 		}
 
 		if rhs, ok := stmt.Rhs[0].(*ast.IndexExpr); ok {
-			rx, ok := rhs.X.(*ast.Ident)
-			_ = rx
-			if !ok {
+			if _, ok := rhs.X.(*ast.Ident); !ok {
+				return true
+			}
+			if j.Render(rhs.X) != j.Render(loop.X) {
+				// copy(dst, src) copies min(len(dst), len(src))
+				// elements, not len(loop.X)
 				return true
 			}
 			ridx, ok := rhs.Index.(*ast.Ident)
@@ -187,10 +304,11 @@ func (c *Checker) LintLoopCopy(j *lint.Job) {
 		} else {
 			return true
 		}
-		j.Errorf(loop, "should use copy() instead of a loop")
+		r := fmt.Sprintf("copy(%s, %s)", j.Render(lhs.X), j.Render(loop.X))
+		j.Errorf(loop, "should use %s instead of a loop", r).Replace(loop, r)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -229,10 +347,10 @@ func (c *Checker) LintIfBoolCmp(j *lint.Job) {
 		if (l1-len(r))%2 == 1 {
 			r = "!" + r
 		}
-		j.Errorf(expr, "should omit comparison to bool constant, can be simplified to %s", r)
+		j.Errorf(expr, "should omit comparison to bool constant, can be simplified to %s", r).Replace(expr, r)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -300,11 +418,12 @@ func (c *Checker) LintStringsContains(j *lint.Job) {
 		if !b {
 			prefix = "!"
 		}
-		j.Errorf(node, "should use %s%s.%s(%s) instead", prefix, pkgIdent.Name, newFunc, j.RenderArgs(call.Args))
+		r := fmt.Sprintf("%s%s.%s(%s)", prefix, pkgIdent.Name, newFunc, j.RenderArgs(call.Args))
+		j.Errorf(node, "should use %s instead", r).Replace(node, r)
 
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -334,10 +453,11 @@ func (c *Checker) LintBytesCompare(j *lint.Job) {
 		if expr.Op == token.NEQ {
 			prefix = "!"
 		}
-		j.Errorf(node, "should use %sbytes.Equal(%s) instead", prefix, args)
+		r := fmt.Sprintf("%sbytes.Equal(%s)", prefix, args)
+		j.Errorf(node, "should use %s instead", r).Replace(node, r)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -354,7 +474,7 @@ func (c *Checker) LintRanges(j *lint.Job) {
 
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -374,7 +494,7 @@ func (c *Checker) LintForTrue(j *lint.Job) {
 		j.Errorf(loop, "should use for {} instead of for true {}")
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -434,7 +554,7 @@ func (c *Checker) LintRegexpRaw(j *lint.Job) {
 		j.Errorf(call, "should use raw string (`...`) with regexp.%s to avoid having to escape twice", sel.Sel.Name)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -501,19 +621,18 @@ func (c *Checker) LintIfReturn(j *lint.Job) {
 		j.Errorf(n1, "should use 'return <expr>' instead of 'if <expr> { return <bool> }; return <bool>'")
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
 
 // LintRedundantNilCheckWithLen checks for the following reduntant nil-checks:
 //
-//   if x == nil || len(x) == 0 {}
-//   if x != nil && len(x) != 0 {}
-//   if x != nil && len(x) == N {} (where N != 0)
-//   if x != nil && len(x) > N {}
-//   if x != nil && len(x) >= N {} (where N != 0)
-//
+//	if x == nil || len(x) == 0 {}
+//	if x != nil && len(x) != 0 {}
+//	if x != nil && len(x) == N {} (where N != 0)
+//	if x != nil && len(x) > N {}
+//	if x != nil && len(x) >= N {} (where N != 0)
 func (c *Checker) LintRedundantNilCheckWithLen(j *lint.Job) {
 	isConstZero := func(expr ast.Expr) (isConst bool, isZero bool) {
 		_, ok := expr.(*ast.BasicLit)
@@ -620,7 +739,7 @@ func (c *Checker) LintRedundantNilCheckWithLen(j *lint.Job) {
 		// finally check that xx type is one of array, slice, map or chan
 		// this is to prevent false positive in case if xx is a pointer to an array
 		var nilType string
-		switch j.Program.Info.TypeOf(xx).(type) {
+		switch j.Program.Info.TypeOf(xx).Underlying().(type) {
 		case *types.Slice:
 			nilType = "nil slices"
 		case *types.Map:
@@ -630,10 +749,10 @@ func (c *Checker) LintRedundantNilCheckWithLen(j *lint.Job) {
 		default:
 			return true
 		}
-		j.Errorf(expr, "should omit nil check; len() for %s is defined as zero", nilType)
+		j.Errorf(expr, "should omit nil check; len() for %s is defined as zero", nilType).Replace(expr, j.Render(y))
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -666,10 +785,10 @@ func (c *Checker) LintSlicing(j *lint.Job) {
 		if !ok || arg.Obj != s.Obj {
 			return true
 		}
-		j.Errorf(n, "should omit second index in slice, s[a:len(s)] is identical to s[a:]")
+		j.Errorf(n, "should omit second index in slice, s[a:len(s)] is identical to s[a:]").Replace(n.High, "")
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -697,11 +816,20 @@ func (c *Checker) LintLoopAppend(j *lint.Job) {
 		if !ok {
 			return true
 		}
-		if !lint.IsBlank(loop.Key) {
-			return true
-		}
-		val, ok := loop.Value.(*ast.Ident)
-		if !ok {
+		// Either for _, v := range x { ... v ... } or
+		// for i := range x { ... x[i] ... }
+		var val *ast.Ident
+		if lint.IsBlank(loop.Key) {
+			val, ok = loop.Value.(*ast.Ident)
+			if !ok {
+				return true
+			}
+		} else if loop.Value == nil || lint.IsBlank(loop.Value) {
+			val, ok = loop.Key.(*ast.Ident)
+			if !ok {
+				return true
+			}
+		} else {
 			return true
 		}
 		if len(loop.Body.List) != 1 {
@@ -749,18 +877,23 @@ func (c *Checker) LintLoopAppend(j *lint.Job) {
 			return true
 		}
 
-		el, ok := call.Args[1].(*ast.Ident)
-		if !ok {
-			return true
+		el := call.Args[1]
+		if val == loop.Key {
+			index, ok := el.(*ast.IndexExpr)
+			if !ok || j.Render(index.X) != j.Render(loop.X) {
+				return true
+			}
+			el = index.Index
 		}
-		if j.Program.Info.ObjectOf(val) != j.Program.Info.ObjectOf(el) {
+		if ident, ok := el.(*ast.Ident); !ok || j.Program.Info.ObjectOf(val) != j.Program.Info.ObjectOf(ident) {
 			return true
 		}
-		j.Errorf(loop, "should replace loop with %s = append(%s, %s...)",
+		r := fmt.Sprintf("%s = append(%s, %s...)",
 			j.Render(stmt.Lhs[0]), j.Render(call.Args[0]), j.Render(loop.X))
+		j.Errorf(loop, "should replace loop with %s", r).Replace(loop, r)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -781,18 +914,16 @@ func (c *Checker) LintTimeSince(j *lint.Job) {
 		if sel.Sel.Name != "Sub" {
 			return true
 		}
-		j.Errorf(call, "should use time.Since instead of time.Now().Sub")
+		r := fmt.Sprintf("time.Since(%s)", j.RenderArgs(call.Args))
+		j.Errorf(call, "should use %s instead of %s", r, j.Render(call)).Replace(call, r)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
 
 func (c *Checker) LintTimeUntil(j *lint.Job) {
-	if !j.IsGoVersion(8) {
-		return
-	}
 	fn := func(node ast.Node) bool {
 		call, ok := node.(*ast.CallExpr)
 		if !ok {
@@ -804,10 +935,96 @@ func (c *Checker) LintTimeUntil(j *lint.Job) {
 		if !j.IsFunctionCallName(call.Args[0], "time.Now") {
 			return true
 		}
-		j.Errorf(call, "should use time.Until instead of t.Sub(time.Now())")
+		r := fmt.Sprintf("time.Until(%s)", j.Render(call.Fun.(*ast.SelectorExpr).X))
+		j.Errorf(call, "should use %s instead of %s", r, j.Render(call)).Replace(call, r)
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if !j.IsGoVersionAt(f, 8) {
+			continue
+		}
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintTimeComparison(j *lint.Job) {
+	// elapsed returns t if expr computes the time elapsed since t,
+	// and remaining returns t if expr computes the time until t.
+	elapsed := func(expr ast.Expr) ast.Expr {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return nil
+		}
+		if j.IsFunctionCallName(call, "time.Since") {
+			return call.Args[0]
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Sub" && j.IsFunctionCallName(sel.X, "time.Now") {
+			return call.Args[0]
+		}
+		return nil
+	}
+	remaining := func(expr ast.Expr) ast.Expr {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return nil
+		}
+		if j.IsFunctionCallName(call, "time.Until") {
+			return call.Args[0]
+		}
+		if j.IsFunctionCallName(call, "(time.Time).Sub") && j.IsFunctionCallName(call.Args[0], "time.Now") {
+			return call.Fun.(*ast.SelectorExpr).X
+		}
+		return nil
+	}
+	flip := map[token.Token]token.Token{
+		token.GTR: token.LSS,
+		token.LSS: token.GTR,
+		token.GEQ: token.LEQ,
+		token.LEQ: token.GEQ,
+	}
+	fn := func(node ast.Node) bool {
+		expr, ok := node.(*ast.BinaryExpr)
+		if !ok {
+			return true
+		}
+		op, ok := flip[expr.Op]
+		if !ok {
+			return true
+		}
+		d, zero := expr.X, expr.Y
+		if n, ok := j.ExprToInt(zero); !ok || n != 0 {
+			d, zero = expr.Y, expr.X
+			if n, ok := j.ExprToInt(zero); !ok || n != 0 {
+				return true
+			}
+		} else {
+			op = expr.Op
+		}
+		// Normalize to the time elapsed since t.
+		t := elapsed(d)
+		if t == nil {
+			t = remaining(d)
+			if t == nil {
+				return true
+			}
+			op = flip[op]
+		}
+		var want string
+		switch op {
+		case token.GTR:
+			want = "time.Now().After(%s)"
+		case token.LSS:
+			want = "time.Now().Before(%s)"
+		case token.GEQ:
+			want = "!time.Now().Before(%s)"
+		case token.LEQ:
+			want = "!time.Now().After(%s)"
+		}
+		r := fmt.Sprintf(want, j.Render(t))
+		j.Errorf(expr, "should use %s instead of %s", r, j.Render(expr)).Replace(expr, r)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -926,7 +1143,7 @@ func (c *Checker) LintSimplerReturn(j *lint.Job) {
 		ast.Inspect(node, fn2)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn1)
 	}
 }
@@ -952,11 +1169,14 @@ func (c *Checker) LintReceiveIntoBlank(j *lint.Job) {
 			if expr.Op != token.ARROW {
 				continue
 			}
-			j.Errorf(lh, "'_ = <-ch' can be simplified to '<-ch'")
+			p := j.Errorf(lh, "'_ = <-ch' can be simplified to '<-ch'")
+			if len(stmt.Lhs) == 1 {
+				p.Replace(stmt, j.Render(rh))
+			}
 		}
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1055,7 +1275,7 @@ func (c *Checker) LintFormatInt(j *lint.Job) {
 		}
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1154,7 +1374,7 @@ func (c *Checker) LintSimplerStructConversion(j *lint.Job) {
 		j.Errorf(node, "should use type conversion instead of struct literal")
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1340,7 +1560,7 @@ func (c *Checker) LintTrim(j *lint.Job) {
 		j.Errorf(ifstmt, "should replace this if statement with an unconditional %s.%s", pkg, replacement)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1442,10 +1662,11 @@ func (c *Checker) LintLoopSlide(j *lint.Job) {
 			return true
 		}
 
-		j.Errorf(loop, "should use copy(%s[:%s], %s[%s:]) instead", j.Render(bs1), j.Render(biny), j.Render(bs1), j.Render(add1))
+		r := fmt.Sprintf("copy(%s[:%s], %s[%s:])", j.Render(bs1), j.Render(biny), j.Render(bs1), j.Render(add1))
+		j.Errorf(loop, "should use %s instead", r).Replace(loop, r)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1467,17 +1688,19 @@ func (c *Checker) LintMakeLenCap(j *lint.Job) {
 				break
 			}
 			if lint.IsZero(call.Args[1]) {
-				j.Errorf(call.Args[1], "should use make(%s) instead", j.Render(call.Args[0]))
+				j.Errorf(call.Args[1], "should use make(%s) instead", j.Render(call.Args[0])).
+					Edit(call.Args[0].End(), call.Args[1].End(), "")
 			}
 		case 3:
 			// make(T, len, cap)
 			if j.Render(call.Args[1]) == j.Render(call.Args[2]) {
-				j.Errorf(call.Args[1], "should use make(%s, %s) instead", j.Render(call.Args[0]), j.Render(call.Args[1]))
+				j.Errorf(call.Args[1], "should use make(%s, %s) instead", j.Render(call.Args[0]), j.Render(call.Args[1])).
+					Edit(call.Args[1].End(), call.Args[2].End(), "")
 			}
 		}
 		return false
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1536,7 +1759,7 @@ func (c *Checker) LintAssertNotNil(j *lint.Job) {
 		j.Errorf(ifstmt, "when %s is true, %s can't be nil", j.Render(assignIdent), j.Render(assertIdent))
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1587,7 +1810,7 @@ func (c *Checker) LintDeclareAssign(j *lint.Job) {
 		}
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1620,10 +1843,11 @@ func (c *Checker) LintBlankOK(j *lint.Job) {
 		}
 		cp := *assign
 		cp.Lhs = cp.Lhs[0:1]
-		j.Errorf(assign, "should write %s instead of %s", j.Render(&cp), j.Render(assign))
+		r := j.Render(&cp)
+		j.Errorf(assign, "should write %s instead of %s", r, j.Render(assign)).Replace(assign, r)
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1641,10 +1865,10 @@ func (c *Checker) LintRedundantBreak(j *lint.Job) {
 		if !ok || branch.Tok != token.BREAK || branch.Label != nil {
 			return true
 		}
-		j.Errorf(branch, "redundant break statement")
+		j.Errorf(branch, "redundant break statement").Replace(branch, "")
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1704,7 +1928,7 @@ func (c *Checker) LintRedundantSprintf(j *lint.Job) {
 		}
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -1735,7 +1959,7 @@ func (c *Checker) LintStringCopy(j *lint.Job) {
 				return true
 			}
 			j.Errorf(x, "should use %s instead of %s",
-				j.Render(want), j.Render(x))
+				j.Render(want), j.Render(x)).Replace(x, j.Render(want))
 		case *ast.CallExpr: // string([]byte(s))
 			bt, ok := j.Program.Info.TypeOf(x.Fun).(*types.Basic)
 			if !ok || bt.Kind() != types.String {
@@ -1758,11 +1982,1347 @@ func (c *Checker) LintStringCopy(j *lint.Job) {
 				break
 			}
 			j.Errorf(x, "should use %s instead of %s",
-				j.Render(nested.Args[0]), j.Render(x))
+				j.Render(nested.Args[0]), j.Render(x)).Replace(x, j.Render(nested.Args[0]))
+		}
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintStringConcatInLoop(j *lint.Job) {
+	isString := func(expr ast.Expr) bool {
+		T := j.Program.Info.TypeOf(expr)
+		if T == nil {
+			return false
+		}
+		basic, ok := T.Underlying().(*types.Basic)
+		return ok && basic.Kind() == types.String
+	}
+	// fromBytes returns the method of strings.Builder that writes
+	// the value that expr converts to a string, if it converts a
+	// byte slice, byte or rune.
+	fromBytes := func(expr ast.Expr) string {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 || !isString(call.Fun) {
+			return ""
+		}
+		switch T := j.Program.Info.TypeOf(call.Args[0]).Underlying().(type) {
+		case *types.Slice:
+			return "Write"
+		case *types.Basic:
+			switch T.Kind() {
+			case types.Byte:
+				return "WriteByte"
+			case types.Rune:
+				return "WriteRune"
+			}
+		}
+		return ""
+	}
+	// accumulated returns the variable that stmt appends to and the
+	// value it appends.
+	accumulated := func(stmt *ast.AssignStmt) (*ast.Ident, ast.Expr) {
+		if len(stmt.Lhs) != 1 || len(stmt.Rhs) != 1 {
+			return nil, nil
+		}
+		ident, ok := stmt.Lhs[0].(*ast.Ident)
+		if !ok || !isString(ident) {
+			return nil, nil
+		}
+		switch stmt.Tok {
+		case token.ADD_ASSIGN:
+			return ident, stmt.Rhs[0]
+		case token.ASSIGN:
+			bin, ok := stmt.Rhs[0].(*ast.BinaryExpr)
+			if !ok || bin.Op != token.ADD {
+				return nil, nil
+			}
+			// s = s + a + b parses as (s + a) + b
+			x := bin
+			for {
+				inner, ok := x.X.(*ast.BinaryExpr)
+				if !ok || inner.Op != token.ADD {
+					break
+				}
+				x = inner
+			}
+			other, ok := x.X.(*ast.Ident)
+			if !ok || j.Program.Info.ObjectOf(other) != j.Program.Info.ObjectOf(ident) {
+				return nil, nil
+			}
+			return ident, x.Y
+		}
+		return nil, nil
+	}
+
+	// refersTo reports whether node refers to obj.
+	refersTo := func(node ast.Node, obj types.Object) bool {
+		found := false
+		ast.Inspect(node, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && j.Program.Info.ObjectOf(ident) == obj {
+				found = true
+			}
+			return !found
+		})
+		return found
+	}
+	// builderFix adds edits to p that turn obj into a strings.Builder.
+	// This requires obj to be a local variable that starts out empty,
+	// that is only appended to in body, and that is only read after
+	// loop, in a file that imports strings.
+	builderFix := func(p *lint.Problem, loop ast.Node, body *ast.BlockStmt, obj types.Object) {
+		if obj.Parent() == nil || obj.Parent() == obj.Pkg().Scope() || !types.Identical(obj.Type(), types.Typ[types.String]) {
+			return
+		}
+		f := j.File(obj)
+		if f == nil {
+			return
+		}
+		pkg := ""
+		for _, imp := range f.Imports {
+			if imp.Path.Value != `"strings"` {
+				continue
+			}
+			pkg = "strings"
+			if imp.Name != nil {
+				pkg = imp.Name.Name
+			}
+		}
+		if pkg == "" || pkg == "_" || pkg == "." {
+			return
+		}
+
+		var edits []lint.TextEdit
+		path, _ := astutil.PathEnclosingInterval(f, obj.Pos(), obj.Pos())
+		if len(path) < 2 {
+			return
+		}
+		switch decl := path[1].(type) {
+		case *ast.ValueSpec:
+			if len(decl.Names) != 1 || len(decl.Values) != 0 || decl.Type == nil {
+				return
+			}
+			edits = append(edits, lint.TextEdit{Pos: decl.Type.Pos(), End: decl.Type.End(), NewText: pkg + ".Builder"})
+		case *ast.AssignStmt:
+			if decl.Tok != token.DEFINE || len(decl.Lhs) != 1 || len(decl.Rhs) != 1 {
+				return
+			}
+			if lit, ok := decl.Rhs[0].(*ast.BasicLit); !ok || lit.Value != `""` {
+				return
+			}
+			edits = append(edits, lint.TextEdit{Pos: decl.Pos(), End: decl.End(), NewText: fmt.Sprintf("var %s %s.Builder", obj.Name(), pkg)})
+		default:
+			return
+		}
+
+		// Rewrite the accumulations, and remember the uses of obj
+		// they account for.
+		handled := map[*ast.Ident]bool{}
+		ast.Inspect(body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.AssignStmt:
+				ident, _ := accumulated(node)
+				if ident == nil || j.Program.Info.ObjectOf(ident) != obj {
+					return true
+				}
+				// Collect the addends of s += a or s = s + a + b.
+				var addends []ast.Expr
+				switch node.Tok {
+				case token.ADD_ASSIGN:
+					addends = []ast.Expr{node.Rhs[0]}
+				case token.ASSIGN:
+					x := node.Rhs[0].(*ast.BinaryExpr)
+					for {
+						addends = append([]ast.Expr{x.Y}, addends...)
+						inner, ok := x.X.(*ast.BinaryExpr)
+						if !ok || inner.Op != token.ADD {
+							break
+						}
+						x = inner
+					}
+					handled[x.X.(*ast.Ident)] = true
+				}
+				var args []string
+				for _, addend := range addends {
+					if refersTo(addend, obj) {
+						return true
+					}
+					T := j.Program.Info.TypeOf(addend)
+					if T == nil || !types.Identical(types.Default(T), types.Typ[types.String]) {
+						return true
+					}
+					args = append(args, j.Render(addend))
+				}
+				handled[ident] = true
+				edits = append(edits, lint.TextEdit{
+					Pos:     node.Pos(),
+					End:     node.End(),
+					NewText: fmt.Sprintf("%s.WriteString(%s)", obj.Name(), strings.Join(args, " + ")),
+				})
+			}
+			return true
+		})
+
+		for ident, o := range j.Program.Info.Uses {
+			if o != obj || handled[ident] {
+				continue
+			}
+			if ident.Pos() < loop.End() {
+				return
+			}
+			path, _ := astutil.PathEnclosingInterval(f, ident.Pos(), ident.End())
+			if len(path) < 2 {
+				return
+			}
+			switch parent := path[1].(type) {
+			case *ast.AssignStmt:
+				for _, lhs := range parent.Lhs {
+					if lhs == ident {
+						return
+					}
+				}
+			case *ast.UnaryExpr:
+				if parent.Op == token.AND {
+					return
+				}
+			}
+			edits = append(edits, lint.TextEdit{Pos: ident.Pos(), End: ident.End(), NewText: obj.Name() + ".String()"})
+		}
+		for _, e := range edits {
+			p.Edit(e.Pos, e.End, e.NewText)
+		}
+	}
+
+	seen := map[types.Object]bool{}
+	fn := func(loop ast.Node) bool {
+		var body *ast.BlockStmt
+		switch loop := loop.(type) {
+		case *ast.ForStmt:
+			body = loop.Body
+		case *ast.RangeStmt:
+			body = loop.Body
+		default:
+			return true
+		}
+		ast.Inspect(body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.AssignStmt:
+				ident, value := accumulated(node)
+				if ident == nil {
+					return true
+				}
+				obj := j.Program.Info.ObjectOf(ident)
+				if obj == nil || seen[obj] || obj.Pos() >= loop.Pos() && obj.Pos() < loop.End() {
+					// Variables declared in the loop don't accumulate
+					// across iterations.
+					return true
+				}
+				seen[obj] = true
+				if !j.IsGoVersionAt(node, 10) {
+					j.Errorf(node, "should use bytes.Buffer instead of concatenating strings in a loop")
+				} else if method := fromBytes(value); method != "" {
+					// writing the converted value as a string would
+					// copy it once more
+					j.Errorf(node, "should use strings.Builder and its %s method instead of concatenating strings in a loop", method)
+				} else {
+					p := j.Errorf(node, "should use strings.Builder instead of concatenating strings in a loop")
+					builderFix(p, loop, body, obj)
+				}
+			}
+			return true
+		})
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		ast.Inspect(f, fn)
+	}
+}
+
+// dependsOn reports whether expr refers to any of objs or contains a
+// function call, whose result may change between evaluations.
+func dependsOn(j *lint.Job, expr ast.Expr, objs ...types.Object) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.CallExpr:
+			if tv, ok := j.Program.Info.Types[node.Fun]; !ok || !tv.IsType() {
+				found = true
+			}
+		case *ast.Ident:
+			obj := j.Program.Info.ObjectOf(node)
+			for _, o := range objs {
+				if obj == o {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isIdentObj reports whether expr is an identifier referring to obj.
+func isIdentObj(j *lint.Job, expr ast.Expr, obj types.Object) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && obj != nil && j.Program.Info.ObjectOf(ident) == obj
+}
+
+// isLiteral reports whether expr is the constant lit, e.g. "true" or
+// "-1".
+func isLiteral(j *lint.Job, expr ast.Expr, lit string) bool {
+	return j.Render(expr) == lit
+}
+
+func (c *Checker) LintSearchLoop(j *lint.Job) {
+	objOf := func(expr ast.Expr) types.Object {
+		ident, ok := expr.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			return nil
+		}
+		return j.Program.Info.ObjectOf(ident)
+	}
+	// initializes reports whether stmt sets obj to value.
+	initializes := func(stmt ast.Stmt, obj types.Object, value string) bool {
+		switch stmt := stmt.(type) {
+		case *ast.AssignStmt:
+			return len(stmt.Lhs) == 1 && len(stmt.Rhs) == 1 &&
+				isIdentObj(j, stmt.Lhs[0], obj) && isLiteral(j, stmt.Rhs[0], value)
+		case *ast.DeclStmt:
+			gen, ok := stmt.Decl.(*ast.GenDecl)
+			if !ok || len(gen.Specs) != 1 {
+				return false
+			}
+			spec, ok := gen.Specs[0].(*ast.ValueSpec)
+			if !ok || len(spec.Names) != 1 || !isIdentObj(j, spec.Names[0], obj) {
+				return false
+			}
+			if len(spec.Values) == 0 {
+				return value == "false"
+			}
+			return len(spec.Values) == 1 && isLiteral(j, spec.Values[0], value)
+		}
+		return false
+	}
+	check := func(prev ast.Stmt, rng *ast.RangeStmt, next ast.Stmt) {
+		if rng.Tok != token.DEFINE || rng.Value == nil {
+			return
+		}
+		switch j.Program.Info.TypeOf(rng.X).Underlying().(type) {
+		case *types.Slice, *types.Array:
+		default:
+			return
+		}
+		if len(rng.Body.List) != 1 {
+			return
+		}
+		ifstmt, ok := rng.Body.List[0].(*ast.IfStmt)
+		if !ok || ifstmt.Init != nil || ifstmt.Else != nil {
+			return
+		}
+		cond, ok := ifstmt.Cond.(*ast.BinaryExpr)
+		if !ok || cond.Op != token.EQL {
+			return
+		}
+		key, value := objOf(rng.Key), objOf(rng.Value)
+		var x ast.Expr
+		switch {
+		case isIdentObj(j, cond.X, value):
+			x = cond.Y
+		case isIdentObj(j, cond.Y, value):
+			x = cond.X
+		default:
+			return
+		}
+		if dependsOn(j, x, key, value) {
+			return
+		}
+		call := func(fn string) string {
+			return fmt.Sprintf("slices.%s(%s, %s)", fn, j.Render(rng.X), j.Render(x))
+		}
+
+		body := ifstmt.Body.List
+		switch len(body) {
+		case 1:
+			// if v == x { return true }; return false
+			// if v == x { return i }; return -1
+			ret, ok := body[0].(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				return
+			}
+			next, ok := next.(*ast.ReturnStmt)
+			if !ok || len(next.Results) != 1 {
+				return
+			}
+			switch {
+			case isLiteral(j, ret.Results[0], "true") && isLiteral(j, next.Results[0], "false"):
+				j.Errorf(rng, "should use 'return %s' instead of a loop", call("Contains"))
+			case isIdentObj(j, ret.Results[0], key) && isLiteral(j, next.Results[0], "-1"):
+				j.Errorf(rng, "should use 'return %s' instead of a loop", call("Index"))
+			}
+		case 2:
+			// found := false; for ... { if v == x { found = true; break } }
+			// idx := -1; for i, v := range ... { if v == x { idx = i; break } }
+			if br, ok := body[1].(*ast.BranchStmt); !ok || br.Tok != token.BREAK || br.Label != nil {
+				return
+			}
+			assign, ok := body[0].(*ast.AssignStmt)
+			if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+				return
+			}
+			dst := objOf(assign.Lhs[0])
+			if dst == nil || prev == nil {
+				return
+			}
+			switch {
+			case isLiteral(j, assign.Rhs[0], "true") && initializes(prev, dst, "false"):
+				j.Errorf(rng, "should use '%s := %s' instead of a loop", dst.Name(), call("Contains"))
+			case isIdentObj(j, assign.Rhs[0], key) && initializes(prev, dst, "-1"):
+				j.Errorf(rng, "should use '%s := %s' instead of a loop", dst.Name(), call("Index"))
+			}
+		}
+	}
+	fn := func(node ast.Node) bool {
+		block, ok := node.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		for i, stmt := range block.List {
+			rng, ok := stmt.(*ast.RangeStmt)
+			if !ok {
+				continue
+			}
+			var prev, next ast.Stmt
+			if i > 0 {
+				prev = block.List[i-1]
+			}
+			if i < len(block.List)-1 {
+				next = block.List[i+1]
+			}
+			check(prev, rng, next)
+		}
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if !j.IsGoVersionAt(f, 21) {
+			continue
+		}
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintSortSlice(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		if !j.IsFunctionCallNameAny(call, "sort.Slice", "sort.SliceStable") {
+			return true
+		}
+		slice, ok := j.Program.Info.TypeOf(call.Args[0]).Underlying().(*types.Slice)
+		if !ok {
+			return true
+		}
+		if basic, ok := slice.Elem().Underlying().(*types.Basic); !ok || basic.Info()&types.IsOrdered == 0 {
+			return true
+		}
+		lit, ok := call.Args[1].(*ast.FuncLit)
+		if !ok || len(lit.Body.List) != 1 || len(lit.Type.Params.List) != 1 || len(lit.Type.Params.List[0].Names) != 2 {
+			return true
+		}
+		ret, ok := lit.Body.List[0].(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			return true
+		}
+		cmp, ok := ret.Results[0].(*ast.BinaryExpr)
+		if !ok || cmp.Op != token.LSS {
+			return true
+		}
+		// The less function must be s[i] < s[j]
+		names := lit.Type.Params.List[0].Names
+		for k, side := range []ast.Expr{cmp.X, cmp.Y} {
+			index, ok := side.(*ast.IndexExpr)
+			if !ok || j.Render(index.X) != j.Render(call.Args[0]) {
+				return true
+			}
+			if !isIdentObj(j, index.Index, j.Program.Info.ObjectOf(names[k])) {
+				return true
+			}
+		}
+		j.Errorf(call, "should use slices.Sort(%s) instead of %s", j.Render(call.Args[0]), j.Render(call.Fun))
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if !j.IsGoVersionAt(f, 21) {
+			continue
+		}
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintMapKeysLoop(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		rng, ok := node.(*ast.RangeStmt)
+		if !ok || rng.Tok != token.DEFINE || len(rng.Body.List) != 1 {
+			return true
+		}
+		if _, ok := j.Program.Info.TypeOf(rng.X).Underlying().(*types.Map); !ok {
+			return true
+		}
+		assign, ok := rng.Body.List[0].(*ast.AssignStmt)
+		if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return true
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 || call.Ellipsis.IsValid() {
+			return true
+		}
+		if ident, ok := call.Fun.(*ast.Ident); !ok || ident.Name != "append" {
+			return true
+		} else if _, ok := j.Program.Info.ObjectOf(ident).(*types.Builtin); !ok {
+			return true
+		}
+		dst := j.Render(assign.Lhs[0])
+		if j.Render(call.Args[0]) != dst {
+			return true
+		}
+		var key, value types.Object
+		if ident, ok := rng.Key.(*ast.Ident); ok && ident.Name != "_" {
+			key = j.Program.Info.ObjectOf(ident)
+		}
+		if ident, ok := rng.Value.(*ast.Ident); ok && ident.Name != "_" {
+			value = j.Program.Info.ObjectOf(ident)
+		}
+		var seq string
+		switch {
+		case value == nil && isIdentObj(j, call.Args[1], key):
+			seq = "Keys"
+		case key == nil && isIdentObj(j, call.Args[1], value):
+			seq = "Values"
+		default:
+			return true
+		}
+		j.Errorf(rng, "should use '%s = slices.AppendSeq(%s, maps.%s(%s))' instead of a loop", dst, dst, seq, j.Render(rng.X))
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if !j.IsGoVersionAt(f, 23) {
+			continue
+		}
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintEqualFold(j *lint.Job) {
+	uses := map[types.Object]int{}
+	for _, obj := range j.Program.Info.Uses {
+		uses[obj]++
+	}
+	// folded returns the string that expr converts to lower or upper
+	// case, and the name of the function it uses. Variables holding
+	// the converted string qualify if the comparison is their only
+	// use.
+	inits := map[types.Object]ast.Expr{}
+	folded := func(expr ast.Expr) (string, ast.Expr) {
+		if ident, ok := expr.(*ast.Ident); ok {
+			obj := j.Program.Info.ObjectOf(ident)
+			if uses[obj] != 1 || inits[obj] == nil {
+				return "", nil
+			}
+			expr = inits[obj]
+		}
+		call, ok := expr.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return "", nil
+		}
+		switch {
+		case j.IsFunctionCallName(call, "strings.ToLower"):
+			return "ToLower", call.Args[0]
+		case j.IsFunctionCallName(call, "strings.ToUpper"):
+			return "ToUpper", call.Args[0]
+		}
+		return "", nil
+	}
+	fn := func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			if node.Tok == token.DEFINE && len(node.Lhs) == 1 && len(node.Rhs) == 1 {
+				if ident, ok := node.Lhs[0].(*ast.Ident); ok {
+					inits[j.Program.Info.ObjectOf(ident)] = node.Rhs[0]
+				}
+			}
+		case *ast.BinaryExpr:
+			if node.Op != token.EQL && node.Op != token.NEQ {
+				return true
+			}
+			fn1, a := folded(node.X)
+			fn2, b := folded(node.Y)
+			if fn1 == "" || fn1 != fn2 {
+				return true
+			}
+			prefix := ""
+			if node.Op == token.NEQ {
+				prefix = "!"
+			}
+			r := fmt.Sprintf("%sstrings.EqualFold(%s, %s)", prefix, j.Render(a), j.Render(b))
+			p := j.Errorf(node, "should use %s instead of %s", r, j.Render(node))
+			_, ok1 := node.X.(*ast.CallExpr)
+			_, ok2 := node.Y.(*ast.CallExpr)
+			if ok1 && ok2 {
+				// Rewriting the variable form would leave the
+				// variables unused.
+				p.Replace(node, r)
+			}
+		}
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintSortHelper(j *lint.Job) {
+	// The receivers of T's methods don't count as uses of T.
+	methods := map[*types.TypeName]map[string]*ast.FuncDecl{}
+	receivers := map[*ast.Ident]bool{}
+	for _, f := range j.Program.Files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 {
+				continue
+			}
+			ident, ok := fn.Recv.List[0].Type.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			tn, ok := j.Program.Info.ObjectOf(ident).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			receivers[ident] = true
+			if methods[tn] == nil {
+				methods[tn] = map[string]*ast.FuncDecl{}
+			}
+			methods[tn][fn.Name.Name] = fn
+		}
+	}
+	uses := map[*types.TypeName]int{}
+	for ident, obj := range j.Program.Info.Uses {
+		if tn, ok := obj.(*types.TypeName); ok && !receivers[ident] {
+			uses[tn]++
+		}
+	}
+
+	// isBoilerplate reports whether Len and Swap are implemented the
+	// way sort.Slice does it.
+	isBoilerplate := func(decls map[string]*ast.FuncDecl) bool {
+		if len(decls) != 3 || decls["Len"] == nil || decls["Less"] == nil || decls["Swap"] == nil {
+			return false
+		}
+		recv := func(fn *ast.FuncDecl) string {
+			if len(fn.Recv.List[0].Names) != 1 {
+				return ""
+			}
+			return fn.Recv.List[0].Names[0].Name
+		}
+		params := func(fn *ast.FuncDecl) []string {
+			var names []string
+			for _, field := range fn.Type.Params.List {
+				for _, name := range field.Names {
+					names = append(names, name.Name)
+				}
+			}
+			return names
+		}
+		l := decls["Len"]
+		if len(l.Body.List) != 1 || j.Render(l.Body.List[0]) != fmt.Sprintf("return len(%s)", recv(l)) {
+			return false
+		}
+		sw := decls["Swap"]
+		p := params(sw)
+		if len(sw.Body.List) != 1 || len(p) != 2 {
+			return false
+		}
+		r := recv(sw)
+		want := fmt.Sprintf("%[1]s[%[2]s], %[1]s[%[3]s] = %[1]s[%[3]s], %[1]s[%[2]s]", r, p[0], p[1])
+		return j.Render(sw.Body.List[0]) == want
+	}
+
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return true
+		}
+		var helper string
+		switch {
+		case j.IsFunctionCallName(call, "sort.Sort"):
+			helper = "sort.Slice"
+		case j.IsFunctionCallName(call, "sort.Stable"):
+			helper = "sort.SliceStable"
+		default:
+			return true
+		}
+		conv, ok := call.Args[0].(*ast.CallExpr)
+		if !ok || len(conv.Args) != 1 {
+			return true
+		}
+		ident, ok := conv.Fun.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		tn, ok := j.Program.Info.ObjectOf(ident).(*types.TypeName)
+		if !ok || tn.Pkg() != j.NodePackage(call).Pkg || uses[tn] != 1 {
+			return true
+		}
+		if _, ok := tn.Type().Underlying().(*types.Slice); !ok {
+			return true
+		}
+		if !isBoilerplate(methods[tn]) {
+			return true
+		}
+		p := j.Errorf(call, "should use %s(%s, func(i, j int) bool { ... }) instead of implementing sort.Interface on %s; its Len, Less and Swap methods can then be removed",
+			helper, j.Render(conv.Args[0]), tn.Name())
+		// The type and its methods only become unused once the call
+		// has been rewritten, so unused can't report them yet.
+		p.Relate(tn.Pos(), fmt.Sprintf("type %s becomes unused", tn.Name()))
+		for _, name := range []string{"Len", "Less", "Swap"} {
+			p.Relate(methods[tn][name].Name.Pos(), fmt.Sprintf("method %s.%s becomes unused", tn.Name(), name))
+		}
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if !j.IsGoVersionAt(f, 8) {
+			continue
+		}
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintReplaceAll(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) != 4 {
+			return true
+		}
+		var pkg string
+		switch {
+		case j.IsFunctionCallName(call, "strings.Replace"):
+			pkg = "strings"
+		case j.IsFunctionCallName(call, "bytes.Replace"):
+			pkg = "bytes"
+		default:
+			return true
+		}
+		if n, ok := j.ExprToInt(call.Args[3]); !ok || n != -1 {
+			return true
+		}
+		r := fmt.Sprintf("%s.ReplaceAll(%s)", pkg, j.RenderArgs(call.Args[:3]))
+		j.Errorf(call, "should use %s instead of %s", r, j.Render(call)).Replace(call, r)
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if !j.IsGoVersionAt(f, 12) {
+			continue
+		}
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintStringsCut(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		ifstmt, ok := node.(*ast.IfStmt)
+		if !ok {
+			return true
+		}
+		// if i := strings.Index(s, sep); i >= 0 { ... }
+		init, ok := ifstmt.Init.(*ast.AssignStmt)
+		if !ok || init.Tok != token.DEFINE || len(init.Lhs) != 1 || len(init.Rhs) != 1 {
+			return true
+		}
+		call, ok := init.Rhs[0].(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		var pkg string
+		switch {
+		case j.IsFunctionCallName(call, "strings.Index"):
+			pkg = "strings"
+		case j.IsFunctionCallName(call, "bytes.Index"):
+			pkg = "bytes"
+		default:
+			return true
+		}
+		ident, ok := init.Lhs[0].(*ast.Ident)
+		if !ok {
+			return true
+		}
+		idx := j.Program.Info.ObjectOf(ident)
+		str, sep := call.Args[0], call.Args[1]
+		if dependsOn(j, str) || dependsOn(j, sep) {
+			return true
+		}
+
+		cond, ok := ifstmt.Cond.(*ast.BinaryExpr)
+		if !ok || !isIdentObj(j, cond.X, idx) {
+			return true
+		}
+		n, ok := j.ExprToInt(cond.Y)
+		if !ok {
+			return true
+		}
+		switch {
+		case cond.Op == token.GEQ && n == 0:
+		case cond.Op == token.GTR && n == -1:
+		case cond.Op == token.NEQ && n == -1:
+		default:
+			return true
+		}
+
+		sepLen := -1
+		if s, ok := j.ExprToString(sep); ok {
+			sepLen = len(s)
+		}
+		// isAfter reports whether expr is i+len(sep).
+		isAfter := func(expr ast.Expr) bool {
+			bin, ok := expr.(*ast.BinaryExpr)
+			if !ok || bin.Op != token.ADD || !isIdentObj(j, bin.X, idx) {
+				return false
+			}
+			if n, ok := j.ExprToInt(bin.Y); ok {
+				return int(n) == sepLen
+			}
+			return j.Render(bin.Y) == fmt.Sprintf("len(%s)", j.Render(sep))
+		}
+		// All uses of i must be in s[:i] or s[i+len(sep):]
+		slices := 0
+		other := false
+		ast.Inspect(ifstmt.Body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.SliceExpr:
+				if j.Render(node.X) != j.Render(str) || node.Slice3 {
+					return true
+				}
+				if node.Low == nil && isIdentObj(j, node.High, idx) ||
+					node.High == nil && node.Low != nil && isAfter(node.Low) {
+					slices++
+					return false
+				}
+			case *ast.Ident:
+				if j.Program.Info.ObjectOf(node) == idx {
+					other = true
+				}
+			}
+			return true
+		})
+		if slices == 0 || other {
+			return true
+		}
+		j.Errorf(ifstmt, "should use %s.Cut(%s, %s) instead of %s.Index and slicing", pkg, j.Render(str), j.Render(sep), pkg)
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if !j.IsGoVersionAt(f, 18) {
+			continue
+		}
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintMinMax(j *lint.Job) {
+	// assignment returns the operands of stmt if it is of the form
+	// lhs = rhs, or lhs := rhs if tok is token.DEFINE.
+	assignment := func(stmt ast.Stmt, tok token.Token) (lhs, rhs ast.Expr, ok bool) {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return nil, nil, false
+		}
+		if assign.Tok != token.ASSIGN && assign.Tok != tok {
+			return nil, nil, false
+		}
+		return assign.Lhs[0], assign.Rhs[0], true
+	}
+	// ordered reports whether expr is free of function calls and of
+	// an integer or string type identical to that of x. min and max
+	// treat NaNs and negative zero differently from comparisons, so
+	// floats don't qualify.
+	ordered := func(expr, x ast.Expr) bool {
+		if dependsOn(j, expr) {
+			return false
+		}
+		T := j.Program.Info.TypeOf(expr)
+		if T == nil || !types.Identical(T, j.Program.Info.TypeOf(x)) {
+			return false
+		}
+		basic, ok := T.Underlying().(*types.Basic)
+		return ok && basic.Info()&(types.IsInteger|types.IsString) != 0
+	}
+	// builtin returns the name of the builtin that computes cond ? v
+	// : the other operand of cond, if any and if it isn't shadowed.
+	builtin := func(cond ast.Expr, v ast.Expr) (string, *ast.BinaryExpr) {
+		bin, ok := cond.(*ast.BinaryExpr)
+		if !ok {
+			return "", nil
+		}
+		var greater bool
+		switch bin.Op {
+		case token.GTR, token.GEQ:
+			greater = true
+		case token.LSS, token.LEQ:
+			greater = false
+		default:
+			return "", nil
+		}
+		x, y := j.Render(bin.X), j.Render(bin.Y)
+		switch j.Render(v) {
+		case x:
+		case y:
+			greater = !greater
+		default:
+			return "", nil
+		}
+		if x == y {
+			return "", nil
+		}
+		name := "min"
+		if greater {
+			name = "max"
+		}
+		scope := j.NodePackage(cond).Pkg.Scope().Innermost(cond.Pos())
+		if scope == nil {
+			return "", nil
+		}
+		if _, obj := scope.LookupParent(name, cond.Pos()); obj == nil {
+			return "", nil
+		} else if _, ok := obj.(*types.Builtin); !ok {
+			return "", nil
+		}
+		return name, bin
+	}
+	call := func(name string, bin *ast.BinaryExpr) string {
+		return fmt.Sprintf("%s(%s, %s)", name, j.Render(bin.X), j.Render(bin.Y))
+	}
+
+	// if statements in else branches can't be replaced with
+	// assignments
+	elseIfs := map[*ast.IfStmt]bool{}
+	fn := func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.IfStmt:
+			if els, ok := node.Else.(*ast.IfStmt); ok {
+				elseIfs[els] = true
+			}
+			if elseIfs[node] || node.Init != nil || len(node.Body.List) != 1 {
+				return true
+			}
+			lhs, v, ok := assignment(node.Body.List[0], token.ASSIGN)
+			if !ok || dependsOn(j, lhs) {
+				return true
+			}
+			name, bin := builtin(node.Cond, v)
+			if bin == nil || !ordered(bin.X, lhs) || !ordered(bin.Y, lhs) {
+				return true
+			}
+			if node.Else == nil {
+				// if x > hi { x = hi }
+				if j.Render(lhs) != j.Render(bin.X) && j.Render(lhs) != j.Render(bin.Y) {
+					return true
+				}
+				r := fmt.Sprintf("%s = %s", j.Render(lhs), call(name, bin))
+				j.Errorf(node, "should use '%s' instead of an if statement", r).Replace(node, r)
+				return true
+			}
+			// if a > b { x = a } else { x = b }
+			els, ok := node.Else.(*ast.BlockStmt)
+			if !ok || len(els.List) != 1 {
+				return true
+			}
+			lhs2, v2, ok := assignment(els.List[0], token.ASSIGN)
+			if !ok || j.Render(lhs2) != j.Render(lhs) {
+				return true
+			}
+			other := bin.Y
+			if j.Render(v) == j.Render(bin.Y) {
+				other = bin.X
+			}
+			if j.Render(v2) != j.Render(other) {
+				return true
+			}
+			r := fmt.Sprintf("%s = %s", j.Render(lhs), call(name, bin))
+			j.Errorf(node, "should use '%s' instead of if/else", r).Replace(node, r)
+		case *ast.BlockStmt:
+			// x := b; if a > b { x = a }
+			if len(node.List) < 2 {
+				return true
+			}
+			for i, stmt := range node.List[:len(node.List)-1] {
+				lhs, v, ok := assignment(stmt, token.DEFINE)
+				if !ok {
+					continue
+				}
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				obj := j.Program.Info.ObjectOf(ident)
+				ifstmt, ok := node.List[i+1].(*ast.IfStmt)
+				if !ok || ifstmt.Init != nil || ifstmt.Else != nil || len(ifstmt.Body.List) != 1 {
+					continue
+				}
+				lhs2, v2, ok := assignment(ifstmt.Body.List[0], token.ASSIGN)
+				if !ok || !isIdentObj(j, lhs2, obj) {
+					continue
+				}
+				name, bin := builtin(ifstmt.Cond, v2)
+				if bin == nil || !ordered(bin.X, lhs) || !ordered(bin.Y, lhs) {
+					continue
+				}
+				if dependsOn(j, bin.X, obj) || dependsOn(j, bin.Y, obj) {
+					// handled by the if statement case
+					continue
+				}
+				if j.Render(v) != j.Render(bin.X) && j.Render(v) != j.Render(bin.Y) || j.Render(v) == j.Render(v2) {
+					continue
+				}
+				r := fmt.Sprintf("%s %s %s", ident.Name, stmt.(*ast.AssignStmt).Tok, call(name, bin))
+				j.Errorf(stmt, "should use '%s' instead of an assignment and an if statement", r).Edit(stmt.Pos(), ifstmt.End(), r)
+			}
+		}
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if !j.IsGoVersionAt(f, 21) {
+			continue
+		}
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintFprintf(j *lint.Job) {
+	printers := map[string]string{
+		"fmt.Sprint":   "Fprint",
+		"fmt.Sprintf":  "Fprintf",
+		"fmt.Sprintln": "Fprintln",
+	}
+	// sprint returns expr if it is a call of one of the fmt.Sprint
+	// functions, and the name of the equivalent fmt.Fprint function.
+	sprint := func(expr ast.Expr) (*ast.CallExpr, string) {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return nil, ""
+		}
+		for name, fprint := range printers {
+			if j.IsFunctionCallName(call, name) {
+				return call, fprint
+			}
+		}
+		return nil, ""
+	}
+	byteSlice := types.NewSlice(types.Typ[types.Byte])
+	// isWriter reports whether expr implements io.Writer.
+	isWriter := func(expr ast.Expr) bool {
+		T := j.Program.Info.TypeOf(expr)
+		if T == nil {
+			return false
+		}
+		m := c.MS.MethodSet(T).Lookup(nil, "Write")
+		if m == nil {
+			return false
+		}
+		sig := m.Type().(*types.Signature)
+		return sig.Params().Len() == 1 && !sig.Variadic() &&
+			types.Identical(sig.Params().At(0).Type(), byteSlice) &&
+			sig.Results().Len() == 2 &&
+			types.Identical(sig.Results().At(0).Type(), types.Typ[types.Int]) &&
+			types.Identical(sig.Results().At(1).Type(), types.Universe.Lookup("error").Type())
+	}
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		var w, arg ast.Expr
+		switch {
+		case j.IsFunctionCallName(call, "io.WriteString") && len(call.Args) == 2:
+			// io.WriteString(w, fmt.Sprintf(...))
+			w, arg = call.Args[0], call.Args[1]
+		default:
+			// w.Write([]byte(fmt.Sprintf(...)))
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "Write" || len(call.Args) != 1 {
+				return true
+			}
+			if s, ok := j.Program.Info.Selections[sel]; !ok || s.Kind() != types.MethodVal {
+				return true
+			}
+			conv, ok := call.Args[0].(*ast.CallExpr)
+			if !ok || len(conv.Args) != 1 {
+				return true
+			}
+			tv, ok := j.Program.Info.Types[conv.Fun]
+			if !ok || !tv.IsType() || !types.Identical(tv.Type, byteSlice) {
+				return true
+			}
+			w, arg = sel.X, conv.Args[0]
+		}
+		if !isWriter(w) {
+			return true
+		}
+		sp, fprint := sprint(arg)
+		if sp == nil {
+			return true
+		}
+		args := append([]ast.Expr{w}, sp.Args...)
+		ellipsis := ""
+		if sp.Ellipsis.IsValid() {
+			ellipsis = "..."
+		}
+		r := fmt.Sprintf("%s.%s(%s%s)", j.Render(sp.Fun.(*ast.SelectorExpr).X), fprint, j.RenderArgs(args), ellipsis)
+		j.Errorf(call, "should use %s instead of formatting into a string first", r).Replace(call, r)
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) LintIfElseChain(j *lint.Job) {
+	// breaks reports whether body contains a break statement that
+	// would break out of a switch statement replacing the chain.
+	breaks := func(body *ast.BlockStmt) bool {
+		found := false
+		var fn func(node ast.Node) bool
+		fn = func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.BranchStmt:
+				if node.Tok == token.BREAK && node.Label == nil {
+					found = true
+				}
+			case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.FuncLit:
+				return false
+			}
+			return !found
+		}
+		ast.Inspect(body, fn)
+		return found
+	}
+	// values returns the constants that cond compares x with, if cond
+	// is of the form x == c1 || x == c2 || ...
+	var values func(cond ast.Expr, x ast.Expr) []ast.Expr
+	values = func(cond ast.Expr, x ast.Expr) []ast.Expr {
+		bin, ok := cond.(*ast.BinaryExpr)
+		if !ok {
+			return nil
+		}
+		switch bin.Op {
+		case token.LOR:
+			l := values(bin.X, x)
+			r := values(bin.Y, x)
+			if l == nil || r == nil {
+				return nil
+			}
+			return append(l, r...)
+		case token.EQL:
+			v, other := bin.Y, bin.X
+			if j.Program.Info.Types[v].Value == nil {
+				v, other = bin.X, bin.Y
+			}
+			if j.Program.Info.Types[v].Value == nil || j.Render(other) != j.Render(x) {
+				return nil
+			}
+			return []ast.Expr{v}
+		}
+		return nil
+	}
+	// subject returns the non-constant operand of the first
+	// comparison in cond.
+	var subject func(cond ast.Expr) ast.Expr
+	subject = func(cond ast.Expr) ast.Expr {
+		bin, ok := cond.(*ast.BinaryExpr)
+		if !ok {
+			return nil
+		}
+		switch bin.Op {
+		case token.LOR:
+			return subject(bin.X)
+		case token.EQL:
+			if j.Program.Info.Types[bin.Y].Value != nil {
+				return bin.X
+			}
+			if j.Program.Info.Types[bin.X].Value != nil {
+				return bin.Y
+			}
+		}
+		return nil
+	}
+	elseIfs := map[*ast.IfStmt]bool{}
+	fn := func(node ast.Node) bool {
+		ifstmt, ok := node.(*ast.IfStmt)
+		if !ok {
+			return true
+		}
+		if els, ok := ifstmt.Else.(*ast.IfStmt); ok {
+			elseIfs[els] = true
+		}
+		if elseIfs[ifstmt] {
+			return true
+		}
+		x := subject(ifstmt.Cond)
+		if x == nil || dependsOn(j, x) {
+			return true
+		}
+		var chain []*ast.IfStmt
+		var cases [][]ast.Expr
+		var els *ast.BlockStmt
+		seen := map[string]bool{}
+		for stmt := ifstmt; stmt != nil; {
+			if stmt.Init != nil || breaks(stmt.Body) {
+				return true
+			}
+			vs := values(stmt.Cond, x)
+			if vs == nil {
+				return true
+			}
+			for _, v := range vs {
+				k := j.Program.Info.Types[v].Value.ExactString()
+				if seen[k] {
+					// duplicate cases don't compile
+					return true
+				}
+				seen[k] = true
+			}
+			chain = append(chain, stmt)
+			cases = append(cases, vs)
+			switch e := stmt.Else.(type) {
+			case *ast.IfStmt:
+				stmt = e
+			case *ast.BlockStmt:
+				if breaks(e) {
+					return true
+				}
+				els = e
+				stmt = nil
+			default:
+				stmt = nil
+			}
+		}
+		if len(chain) < 3 {
+			return true
+		}
+
+		p := j.Errorf(ifstmt, "should use 'switch %s { ... }' instead of an if-else chain", j.Render(x))
+		var edits []lint.TextEdit
+		for i, stmt := range chain {
+			text := fmt.Sprintf("case %s:", j.RenderArgs(cases[i]))
+			pos := stmt.If
+			if i == 0 {
+				text = fmt.Sprintf("switch %s {\n%s", j.Render(x), text)
+			} else {
+				pos = chain[i-1].Body.Rbrace
+			}
+			edits = append(edits, lint.TextEdit{Pos: pos, End: stmt.Body.Lbrace + 1, NewText: text})
+		}
+		if els != nil {
+			edits = append(edits, lint.TextEdit{Pos: chain[len(chain)-1].Body.Rbrace, End: els.Lbrace + 1, NewText: "default:"})
+		}
+		f := j.File(ifstmt)
+		for _, e := range edits {
+			if hasComments(f, e.Pos, e.End) {
+				// rewriting the conditions would lose comments
+				return true
+			}
+		}
+		for _, e := range edits {
+			p.Edit(e.Pos, e.End, e.NewText)
+		}
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		ast.Inspect(f, fn)
+	}
+}
+
+// hasComments reports whether any of f's comments lie within [pos,
+// end).
+func hasComments(f *ast.File, pos, end token.Pos) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() < end && cg.End() > pos {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) LintRedundantGuardWithRange(j *lint.Job) {
+	// guarded returns the expression that cond checks for being
+	// non-nil or non-empty, and whether it is a nil check.
+	guarded := func(cond ast.Expr) (x ast.Expr, isNil bool) {
+		bin, ok := cond.(*ast.BinaryExpr)
+		if !ok {
+			return nil, false
+		}
+		if bin.Op == token.NEQ && j.IsNil(bin.Y) {
+			// x != nil
+			return bin.X, true
+		}
+		call, ok := bin.X.(*ast.CallExpr)
+		if !ok || !lint.IsIdent(call.Fun, "len") || len(call.Args) != 1 {
+			return nil, false
+		}
+		if _, ok := j.Program.Info.ObjectOf(call.Fun.(*ast.Ident)).(*types.Builtin); !ok {
+			return nil, false
+		}
+		if n, ok := j.ExprToInt(bin.Y); !ok || n != 0 || (bin.Op != token.GTR && bin.Op != token.NEQ) {
+			return nil, false
+		}
+		// len(x) > 0, len(x) != 0
+		return call.Args[0], false
+	}
+	fn := func(node ast.Node) bool {
+		ifstmt, ok := node.(*ast.IfStmt)
+		if !ok || ifstmt.Init != nil || ifstmt.Else != nil || len(ifstmt.Body.List) != 1 {
+			return true
+		}
+		rng, ok := ifstmt.Body.List[0].(*ast.RangeStmt)
+		if !ok {
+			return true
+		}
+		x, isNil := guarded(ifstmt.Cond)
+		if x == nil || dependsOn(j, x) || j.Render(x) != j.Render(rng.X) {
+			return true
+		}
+		var typ string
+		switch T := j.Program.Info.TypeOf(x).Underlying().(type) {
+		case *types.Slice:
+			typ = "slices"
+		case *types.Map:
+			typ = "maps"
+		case *types.Basic:
+			if T.Info()&types.IsString == 0 || isNil {
+				return true
+			}
+			typ = "strings"
+		default:
+			// ranging over a nil channel blocks forever
+			return true
+		}
+		var p *lint.Problem
+		if isNil {
+			p = j.Errorf(ifstmt, "should omit nil check; loops over nil %s run zero times", typ)
+		} else {
+			p = j.Errorf(ifstmt, "should omit length check; loops over empty %s run zero times", typ)
+		}
+		if !hasComments(j.File(ifstmt), ifstmt.Pos(), rng.Pos()) && !hasComments(j.File(ifstmt), rng.End(), ifstmt.End()) {
+			p.Edit(ifstmt.Pos(), rng.Pos(), "").Edit(rng.End(), ifstmt.End(), "")
 		}
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -9,3 +9,8 @@ import (
 func TestAll(t *testing.T) {
 	testutil.TestAll(t, NewChecker(), "")
 }
+
+func TestVersions(t *testing.T) {
+	c := NewChecker()
+	testutil.TestVersions(t, c, testutil.CheckFiles(t, c))
+}
@@ -0,0 +1,21 @@
+package pkg
+
+import "strings"
+
+func fn(a, b string) {
+	_ = strings.ToLower(a) == strings.ToLower(b) // MATCH /should use strings\.EqualFold\(a, b\) instead of strings\.ToLower\(a\) == strings\.ToLower\(b\)/ -> `_ = strings.EqualFold(a, b)`
+	_ = strings.ToUpper(a) != strings.ToUpper(b) // MATCH "should use !strings.EqualFold(a, b) instead of"
+	_ = strings.ToLower(a) == strings.ToUpper(b)
+	_ = strings.ToLower(a) == b
+
+	la := strings.ToLower(a)
+	lb := strings.ToLower(b)
+	if la == lb { // MATCH "should use strings.EqualFold(a, b) instead of la == lb"
+	}
+
+	lc := strings.ToLower(a)
+	ld := strings.ToLower(b)
+	if lc == ld {
+		println(lc)
+	}
+}
@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+type notWriter struct{}
+
+func (notWriter) Write(s string) {}
+
+type ptrWriter struct{}
+
+func (*ptrWriter) Write(b []byte) (int, error) { return 0, nil }
+
+func fn(w io.Writer, buf *bytes.Buffer, nw notWriter, pw ptrWriter, args []interface{}) {
+	w.Write([]byte(fmt.Sprintf("%d", 1))) // MATCH /should use fmt\.Fprintf\(w, "%d", 1\) instead of formatting into a string first/ -> `fmt.Fprintf(w, "%d", 1)`
+	n, err := os.Stdout.Write([]byte(fmt.Sprint(1, 2))) // MATCH /should use fmt\.Fprint\(os\.Stdout, 1, 2\)/ -> `n, err := fmt.Fprint(os.Stdout, 1, 2)`
+	_, _ = n, err
+	buf.Write([]byte(fmt.Sprintln("a"))) // MATCH /should use fmt\.Fprintln\(buf, "a"\)/
+	io.WriteString(w, fmt.Sprintf("%x", 2)) // MATCH /should use fmt\.Fprintf\(w, "%x", 2\)/ -> `fmt.Fprintf(w, "%x", 2)`
+	w.Write([]byte(fmt.Sprintf("%v %v", args...))) // MATCH /should use fmt\.Fprintf\(w, "%v %v", args\.\.\.\)/
+
+	w.Write([]byte("foo"))
+	io.WriteString(w, "foo")
+	nw.Write(fmt.Sprintf("%d", 1))
+	pw.Write([]byte(fmt.Sprintf("%d", 1)))
+	buf.WriteString(fmt.Sprintf("%d", 1))
+}
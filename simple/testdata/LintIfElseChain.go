@@ -0,0 +1,111 @@
+package pkg
+
+const (
+	A = iota
+	B
+	C
+)
+
+func fn(x int, s string, xs []int) {
+	if x == 1 { // MATCH /should use 'switch x { \.\.\. }' instead of an if-else chain/
+		println(1)
+	} else if x == 2 {
+		println(2)
+	} else if 3 == x || x == 4 {
+		println(3)
+	} else {
+		println(4)
+	}
+
+	if s == "a" { // MATCH /should use 'switch s { \.\.\. }'/ -> `switch s {`
+		// a
+		println("a")
+	} else if s == "b" {
+		println("b")
+	} else if s == "c" {
+		println("c")
+	}
+
+	if x == A { // MATCH /should use 'switch x { \.\.\. }'/
+		println()
+	} else if x == B /* b */ {
+		println()
+	} else if x == C {
+		println()
+	}
+
+	// too short
+	if x == 1 {
+		println(1)
+	} else if x == 2 {
+		println(2)
+	}
+
+	// different variables
+	if x == 1 {
+		println(1)
+	} else if len(s) == 2 {
+		println(2)
+	} else if x == 3 {
+		println(3)
+	}
+
+	// not equality
+	if x == 1 {
+		println(1)
+	} else if x > 2 {
+		println(2)
+	} else if x == 3 {
+		println(3)
+	}
+
+	// duplicate cases
+	if x == 1 {
+		println(1)
+	} else if x == 2 {
+		println(2)
+	} else if x == 1 {
+		println(3)
+	}
+
+	// init statements
+	if y := x; y == 1 {
+		println(1)
+	} else if y == 2 {
+		println(2)
+	} else if y == 3 {
+		println(3)
+	}
+
+	// side effects
+	if f() == 1 {
+		println(1)
+	} else if f() == 2 {
+		println(2)
+	} else if f() == 3 {
+		println(3)
+	}
+
+	for range xs {
+		// break would refer to the switch
+		if x == 1 {
+			break
+		} else if x == 2 {
+			println(2)
+		} else if x == 3 {
+			println(3)
+		}
+
+		if x == 1 { // MATCH /should use 'switch x { \.\.\. }'/
+			for {
+				break
+			}
+		} else if x == 2 {
+			println(2)
+		} else if x == 3 {
+			println(3)
+		}
+	}
+}
+
+func f() int { return 0 }
@@ -5,7 +5,7 @@ func fn() {
 	var bs []int
 	var offset int
 
-	for i := 0; i < n; i++ { // MATCH "should use copy(bs[:n], bs[offset:]) instead"
+	for i := 0; i < n; i++ { // MATCH /should use copy\(bs\[:n\], bs\[offset:\]\) instead/ -> `copy(bs[:n], bs[offset:])`
 		bs[i] = bs[offset+i]
 	}
 
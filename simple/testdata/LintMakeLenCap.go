@@ -9,10 +9,10 @@ func fn() {
 	_ = make([]int, 0)       // length is mandatory for slices, don't suggest removal
 	_ = make(s, 0)           // length is mandatory for slices, don't suggest removal
 	_ = make(chan int, c)    // constant of 0 may be due to debugging, math or platform-specific code
-	_ = make(chan int, 0)    // MATCH "should use make(chan int) instead"
+	_ = make(chan int, 0)    // MATCH /should use make\(chan int\) instead/ -> `_ = make(chan int)`
 	_ = make(ch, 0)          // MATCH "should use make(ch) instead"
 	_ = make(map[int]int, 0) // MATCH "should use make(map[int]int) instead"
-	_ = make([]int, 1, 1)    // MATCH "should use make([]int, 1) instead"
+	_ = make([]int, 1, 1)    // MATCH /should use make\(\[\]int, 1\) instead/ -> `_ = make([]int, 1)`
 	_ = make([]int, x, x)    // MATCH "should use make([]int, x) instead"
 	_ = make([]int, 1, 2)
 	_ = make([]int, x, y)
@@ -0,0 +1,9 @@
+package pkg
+
+func fn(m map[string]int) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,22 @@
+package pkg
+
+func fn(m map[string]int) ([]string, []int) {
+	keys := make([]string, 0, len(m))
+	for k := range m { // MATCH "should use 'keys = slices.AppendSeq(keys, maps.Keys(m))' instead of a loop"
+		keys = append(keys, k)
+	}
+	var values []int
+	for _, v := range m { // MATCH "should use 'values = slices.AppendSeq(values, maps.Values(m))' instead of a loop"
+		values = append(values, v)
+	}
+	var pairs []string
+	for k, v := range m {
+		pairs = append(pairs, k)
+		_ = v
+	}
+	for k, v := range m {
+		values = append(values, v)
+		_ = k
+	}
+	return keys, values
+}
@@ -0,0 +1,11 @@
+package pkg
+
+func fn2(a, b int) int {
+	var x int
+	if a > b {
+		x = a
+	} else {
+		x = b
+	}
+	return x
+}
@@ -0,0 +1,94 @@
+package pkg
+
+func fn(a, b, lo, hi int, f1, f2 float64, s1, s2 string) {
+	var x int
+	if a > b { // MATCH /should use 'x = max\(a, b\)' instead of if\/else/ -> `x = max(a, b)`
+		x = a
+	} else {
+		x = b
+	}
+	if a < b { // MATCH /should use 'x = max\(a, b\)' instead of if\/else/
+		x = b
+	} else {
+		x = a
+	}
+	if a >= b { // MATCH /should use 'x = min\(a, b\)' instead of if\/else/
+		x = b
+	} else {
+		x = a
+	}
+
+	if x > hi { // MATCH /should use 'x = min\(x, hi\)' instead of an if statement/ -> `x = min(x, hi)`
+		x = hi
+	}
+	if x < lo { // MATCH /should use 'x = max\(x, lo\)' instead of an if statement/
+		x = lo
+	}
+	if lo > x { // MATCH /should use 'x = max\(lo, x\)' instead of an if statement/
+		x = lo
+	}
+
+	y := b // MATCH /should use 'y := max\(a, b\)' instead of an assignment and an if statement/ -> `y := max(a, b)`
+	if a > b {
+		y = a
+	}
+	_ = y
+
+	var s string
+	if s1 < s2 { // MATCH /should use 's = min\(s1, s2\)'/
+		s = s1
+	} else {
+		s = s2
+	}
+	_ = s
+
+	// floats compare differently with NaN
+	var f float64
+	if f1 > f2 {
+		f = f1
+	} else {
+		f = f2
+	}
+	_ = f
+
+	// not a min or max
+	if a > b {
+		x = b
+	} else {
+		x = b
+	}
+	if a > b {
+		x = lo
+	}
+	if a == b {
+		x = a
+	} else {
+		x = b
+	}
+
+	// side effects
+	var m map[int]int
+	if x > m[g()] {
+		x = m[g()]
+	}
+
+	if a > b {
+	} else if x > hi {
+		x = hi
+	}
+	_ = x
+}
+
+func g() int { return 0 }
+
+func shadowed(a, b int) int {
+	max := func(a, b int) int { return 0 }
+	_ = max
+	x := 0
+	if a > b {
+		x = a
+	} else {
+		x = b
+	}
+	return x
+}
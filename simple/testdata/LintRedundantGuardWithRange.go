@@ -0,0 +1,59 @@
+package pkg
+
+type Set map[string]struct{}
+
+func fn(s []int, m Set, str string, ch chan int, pa *[4]int) {
+	// MATCH:7 /should omit nil check; loops over nil slices run zero times/ -> `for _, v := range s {`
+	if s != nil {
+		for _, v := range s {
+			println(v)
+		}
+	}
+	if m != nil { // MATCH /should omit nil check; loops over nil maps run zero times/
+		for k := range m {
+			println(k)
+		}
+	}
+	if len(s) > 0 { // MATCH /should omit length check; loops over empty slices run zero times/
+		for range s {
+		}
+	}
+	if len(str) != 0 { // MATCH /should omit length check; loops over empty strings run zero times/
+		for _, r := range str {
+			println(r)
+		}
+	}
+	if s != nil { // MATCH /should omit nil check/
+		// comment
+		for range s {
+		}
+	}
+
+	if ch != nil {
+		for range ch {
+		}
+	}
+	if pa != nil {
+		for range pa {
+		}
+	}
+	if s != nil {
+		for range s {
+		}
+		println()
+	}
+	if s != nil {
+		for range m {
+		}
+	}
+	if len(s) > 1 {
+		for range s {
+		}
+	}
+	if s != nil {
+		for range s {
+		}
+	} else {
+		println()
+	}
+}
@@ -0,0 +1,7 @@
+package pkg
+
+import "strings"
+
+func fn(s string) {
+	_ = strings.Replace(s, "a", "b", -1)
+}
@@ -0,0 +1,12 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+)
+
+func fn(s string, b []byte) {
+	_ = strings.Replace(s, "a", "b", -1) // MATCH /should use strings\.ReplaceAll\(s, "a", "b"\) instead of strings\.Replace\(s, "a", "b", -1\)/ -> `_ = strings.ReplaceAll(s, "a", "b")`
+	_ = bytes.Replace(b, []byte("a"), []byte("b"), -1) // MATCH "should use bytes.ReplaceAll("
+	_ = strings.Replace(s, "a", "b", 1)
+}
@@ -0,0 +1,10 @@
+package pkg
+
+func fn1(s []string, x string) bool {
+	for _, v := range s {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
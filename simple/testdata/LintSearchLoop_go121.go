@@ -0,0 +1,89 @@
+package pkg
+
+func fn1(s []string, x string) bool {
+	for _, v := range s { // MATCH "should use 'return slices.Contains(s, x)' instead of a loop"
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+func fn2(s []int, x int) int {
+	for i, v := range s { // MATCH "should use 'return slices.Index(s, x)' instead of a loop"
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func fn3(s []int, x int) bool {
+	found := false
+	for _, v := range s { // MATCH "should use 'found := slices.Contains(s, x)' instead of a loop"
+		if v == x {
+			found = true
+			break
+		}
+	}
+	return found
+}
+
+func fn4(s []int, x int) int {
+	idx := -1
+	for i, v := range s { // MATCH "should use 'idx := slices.Index(s, x)' instead of a loop"
+		if v == x {
+			idx = i
+			break
+		}
+	}
+	return idx
+}
+
+func fn5(s []int, x int) bool {
+	for i, v := range s {
+		if v == x+i {
+			return true
+		}
+	}
+	return false
+}
+
+func fn6(s []int, x int) bool {
+	for _, v := range s {
+		if v == x {
+			return false
+		}
+	}
+	return true
+}
+
+func fn7(m map[string]int, x int) bool {
+	for _, v := range m {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+func fn8(s []int, x int, found bool) bool {
+	for _, v := range s {
+		if v == x {
+			found = true
+			break
+		}
+	}
+	return found
+}
+
+func get() int { return 0 }
+
+func fn9(s []int) bool {
+	for _, v := range s {
+		if v == get() {
+			return true
+		}
+	}
+	return false
+}
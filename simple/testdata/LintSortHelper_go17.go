@@ -0,0 +1,13 @@
+package pkg
+
+import "sort"
+
+type byLen []string
+
+func (a byLen) Len() int           { return len(a) }
+func (a byLen) Less(i, j int) bool { return len(a[i]) < len(a[j]) }
+func (a byLen) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+func fn(s []string) {
+	sort.Sort(byLen(s))
+}
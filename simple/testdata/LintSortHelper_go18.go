@@ -0,0 +1,42 @@
+package pkg
+
+import "sort"
+
+type Person struct {
+	Name string
+	Age  int
+}
+
+type byName []Person
+
+func (a byName) Len() int           { return len(a) }
+func (a byName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+func (a byName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+type byAge []Person
+
+func (a byAge) Len() int           { return len(a) }
+func (a byAge) Less(i, j int) bool { return a[i].Age < a[j].Age }
+func (a byAge) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+type reused []Person
+
+func (a reused) Len() int           { return len(a) }
+func (a reused) Less(i, j int) bool { return a[i].Age < a[j].Age }
+func (a reused) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+type counting []Person
+
+func (a counting) Len() int           { return len(a) }
+func (a counting) Less(i, j int) bool { return a[i].Age < a[j].Age }
+func (a counting) Swap(i, j int)      { a[i], a[j] = a[j], a[i]; swaps++ }
+
+var swaps int
+
+func fn(people []Person) {
+	sort.Sort(byName(people))  // MATCH "should use sort.Slice(people, func(i, j int) bool { ... }) instead of implementing sort.Interface on byName"
+	sort.Stable(byAge(people)) // MATCH "should use sort.SliceStable(people, func(i, j int) bool { ... })"
+	sort.Sort(reused(people))
+	sort.Sort(reused(people))
+	sort.Sort(counting(people))
+}
@@ -0,0 +1,15 @@
+package pkg
+
+import "sort"
+
+type T struct{ n int }
+
+type Names []string
+
+func fn(ints []int, names Names, ts []T) {
+	sort.Slice(ints, func(i, j int) bool { return ints[i] < ints[j] })         // MATCH "should use slices.Sort(ints) instead of sort.Slice"
+	sort.SliceStable(names, func(a, b int) bool { return names[a] < names[b] }) // MATCH "should use slices.Sort(names) instead of sort.SliceStable"
+	sort.Slice(ints, func(i, j int) bool { return ints[i] > ints[j] })
+	sort.Slice(ints, func(i, j int) bool { return ints[j] < ints[i] })
+	sort.Slice(ts, func(i, j int) bool { return ts[i].n < ts[j].n })
+}
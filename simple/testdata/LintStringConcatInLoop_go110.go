@@ -0,0 +1,75 @@
+package pkg
+
+import "strings"
+
+func fn(parts []string, data [][]byte) string {
+	var s string
+	for _, p := range parts {
+		s += p // MATCH /should use strings.Builder instead of concatenating strings in a loop/ -> `s.WriteString(p)`
+		s += ","
+	}
+
+	var t string
+	for i := 0; i < 10; i++ {
+		t = t + parts[i] + "," // MATCH /should use strings.Builder/ -> `t.WriteString(parts[i] + ",")`
+	}
+
+	var u string
+	for _, d := range data {
+		u += string(d) // MATCH "should use strings.Builder and its Write method instead of concatenating strings in a loop"
+	}
+
+	for _, p := range parts {
+		var line string
+		line += p
+		p += "x"
+		_ = line
+	}
+
+	var v string
+	for _, p := range parts {
+		v = p + v
+	}
+
+	var w string
+	for range parts {
+		func() {
+			w += "x"
+		}()
+	}
+	return s + t + u + v + w
+}
+
+func fn2(parts []string) []string {
+	x := ""
+	for _, p := range parts {
+		x += strings.ToUpper(p) // MATCH /should use strings.Builder/ -> `x.WriteString(strings.ToUpper(p))`
+	}
+
+	var y string
+	for _, p := range parts {
+		if len(y) > 10 {
+			break
+		}
+		y += p // MATCH "should use strings.Builder"
+	}
+
+	var z string
+	for _, p := range parts {
+		z += p // MATCH "should use strings.Builder"
+	}
+	z = ""
+	return []string{x, y, z}
+}
+
+func fn3(s string) (string, string) {
+	var runes string
+	for _, r := range s {
+		runes += string(r) // MATCH "should use strings.Builder and its WriteRune method"
+	}
+	var bytes string
+	for i := 0; i < len(s); i++ {
+		bytes += string(s[i]) // MATCH "should use strings.Builder and its WriteByte method"
+	}
+	return runes, bytes
+}
@@ -0,0 +1,9 @@
+package pkg
+
+func fn(parts []string) string {
+	var s string
+	for _, p := range parts {
+		s += p // MATCH "should use bytes.Buffer instead of concatenating strings in a loop"
+	}
+	return s
+}
@@ -0,0 +1,10 @@
+package pkg
+
+import "strings"
+
+func fn(s, sep string) string {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,38 @@
+package pkg
+
+import "strings"
+
+func fn1(s, sep string) (string, string) {
+	if i := strings.Index(s, sep); i >= 0 { // MATCH "should use strings.Cut(s, sep) instead of strings.Index and slicing"
+		return s[:i], s[i+len(sep):]
+	}
+	return s, ""
+}
+
+func fn2(s string) string {
+	if i := strings.Index(s, "="); i != -1 { // MATCH /should use strings\.Cut\(s, "="\) instead of strings\.Index and slicing/
+		return s[i+1:]
+	}
+	return ""
+}
+
+func fn3(s string) (string, int) {
+	if i := strings.Index(s, "="); i >= 0 {
+		return s[:i], i
+	}
+	return s, -1
+}
+
+func fn4(s string) string {
+	if i := strings.Index(s, "=="); i >= 0 {
+		return s[i+1:]
+	}
+	return ""
+}
+
+func fn5(s, t string) string {
+	if i := strings.Index(s, "="); i >= 0 {
+		return t[:i]
+	}
+	return ""
+}
@@ -0,0 +1,15 @@
+package pkg
+
+import "time"
+
+func fn(t time.Time) {
+	_ = time.Since(t) > 0         // MATCH "should use time.Now().After(t) instead of time.Since(t) > 0"
+	_ = time.Since(t) < 0         // MATCH "should use time.Now().Before(t) instead of time.Since(t) < 0"
+	_ = 0 < time.Since(t)         // MATCH "should use time.Now().After(t) instead of 0 < time.Since(t)"
+	_ = time.Until(t) > 0         // MATCH "should use time.Now().Before(t) instead of time.Until(t) > 0"
+	_ = time.Until(t) <= 0        // MATCH /should use !time\.Now\(\)\.Before\(t\) instead of time\.Until\(t\) <= 0/ -> `_ = !time.Now().Before(t)`
+	_ = t.Sub(time.Now()) < 0     // MATCH "should use time.Now().After(t) instead of t.Sub(time.Now()) < 0"
+	_ = time.Since(t) > time.Hour
+	_ = t.Sub(t) > 0
+	_ = time.Since(t) == 0
+}
@@ -11,7 +11,7 @@ func fn() {
 	}
 	if fn1() == true { // MATCH "simplified to fn1()"
 	}
-	if fn1() != true { // MATCH "simplified to !fn1()"
+	if fn1() != true { // MATCH /simplified to !fn1\(\)/ -> `if !fn1() {`
 	}
 	if fn1() == false { // MATCH "simplified to !fn1()"
 	}
@@ -20,7 +20,7 @@ func fn() {
 	if fn1() && (fn1() || fn1()) || (fn1() && fn1()) == true { // MATCH "simplified to (fn1() && fn1())"
 	}
 
-	if (fn1() && fn2()) == false { // MATCH "simplified to !(fn1() && fn2())"
+	if (fn1() && fn2()) == false { // MATCH /simplified to !\(fn1\(\) && fn2\(\)\)/ -> `if !(fn1() && fn2()) {`
 	}
 
 	var y bool
@@ -11,7 +11,7 @@ func fn() {
 	_ = strings.IndexRune("", 'x') > 0
 	_ = strings.IndexRune("", 'x') >= -1
 	_ = strings.IndexRune("", 'x') != -1 // MATCH / strings.ContainsRune/
-	_ = strings.IndexRune("", 'x') == -1 // MATCH /!strings.ContainsRune/
+	_ = strings.IndexRune("", 'x') == -1 // MATCH /!strings.ContainsRune/ -> `_ = !strings.ContainsRune("", 'x')`
 	_ = strings.IndexRune("", 'x') != 0
 	_ = strings.IndexRune("", 'x') < 0 // MATCH /!strings.ContainsRune/
 
@@ -24,7 +24,7 @@ func fn() {
 	_ = strings.IndexAny("", "") != 0
 	_ = strings.IndexAny("", "") < 0 // MATCH /!strings.ContainsAny/
 
-	_ = strings.Index("", "") > -1 // MATCH / strings.Contains/
+	_ = strings.Index("", "") > -1 // MATCH / strings.Contains/ -> `_ = strings.Contains("", "")`
 	_ = strings.Index("", "") >= 0 // MATCH / strings.Contains/
 	_ = strings.Index("", "") > 0
 	_ = strings.Index("", "") >= -1
@@ -27,4 +27,12 @@ func fn() {
 		m[i] = v
 	}
 
+	var b5 []byte
+	for i := range b1 { // MATCH /should use copy\(b5, b1\) instead of a loop/ -> `copy(b5, b1)`
+		b5[i] = b1[i]
+	}
+	for i := range b1 {
+		b5[i] = b2[i]
+	}
+
 }
@@ -76,3 +76,17 @@ func fn5() {
 	}
 	_ = out
 }
+
+func fn6() {
+	var a, b, c []int
+	for i := range a { // MATCH /should replace loop with b = append\(b, a\.\.\.\)/ -> `b = append(b, a...)`
+		b = append(b, a[i])
+	}
+	for i := range a {
+		b = append(b, c[i])
+	}
+	for i, v := range a {
+		b = append(b, a[i])
+		_ = v
+	}
+}
@@ -28,6 +28,11 @@ func fn() {
 	if ch != nil && len(ch) == 5 { // MATCH /should omit nil check/
 	}
 
+	type Ints []int
+	var ints Ints
+	if ints != nil && len(ints) > 0 { // MATCH /should omit nil check; len\(\) for nil slices is defined as zero/ -> `if len(ints) > 0 {`
+	}
+
 	if pa == nil || len(pa) == 0 { // nil check cannot be removed with pointer to an array
 	}
 	if s == nil || len(m) == 0 { // different variables
@@ -3,10 +3,10 @@ package pkg
 func fn() {
 	var ch chan int
 	<-ch
-	_ = <-ch // MATCH /_ = <-ch/
+	_ = <-ch // MATCH /_ = <-ch/ -> `<-ch`
 	select {
 	case <-ch:
-	case _ = <-ch: // MATCH /_ = <-ch/
+	case _ = <-ch: // MATCH /_ = <-ch/ -> `case <-ch:`
 	}
 	x := <-ch
 	y, _ := <-ch, <-ch // MATCH /_ = <-ch/
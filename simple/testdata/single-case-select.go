@@ -6,14 +6,14 @@ func fn() {
 	case <-ch:
 	}
 outer:
-	for { // MATCH /should use for range/
+	for { // MATCH "should use 'for range ch' instead of for { select {} }"
 		select {
 		case <-ch:
 			break outer
 		}
 	}
 
-	for { // MATCH /should use for range/
+	for { // MATCH "should use 'for x := range ch' instead of for { select {} }"
 		select {
 		case x := <-ch:
 			_ = x
@@ -25,4 +25,41 @@ outer:
 		case ch <- 0:
 		}
 	}
+
+loop:
+	for { // MATCH "should use 'for x := range ch' instead of for { select {} }"
+		select {
+		case x, ok := <-ch:
+			if !ok {
+				break loop
+			}
+			_ = x
+		}
+	}
+
+	for {
+		select { // MATCH /should use a simple channel send\/receive/
+		case x, ok := <-ch:
+			if !ok {
+				break
+			}
+			_ = x
+		}
+	}
+
+	for {
+		select { // MATCH /should use a simple channel send\/receive/
+		case x, ok := <-ch:
+			if !ok {
+				ch = nil
+			}
+			_ = x
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		select { // MATCH /should use a simple channel send/
+		case <-ch:
+		}
+	}
 }
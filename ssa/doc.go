@@ -87,6 +87,7 @@
 //   *RunDefers                         ✔
 //   *Select            ✔               ✔
 //   *Send                              ✔
+//   *Sigma             ✔               ✔
 //   *Slice             ✔               ✔
 //   *Store                             ✔
 //   *Type                                              ✔ (type)
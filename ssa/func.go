@@ -341,6 +341,9 @@ func (f *Function) finishBody() {
 		// numberRegisters(f)
 		// f.WriteTo(os.Stderr)
 		lift(f)
+		if f.Prog.mode&Sigmas != 0 {
+			insertSigmas(f)
+		}
 	}
 
 	f.namedResults = nil // (used by lifting)
@@ -29,6 +29,7 @@ const (
 	BuildSerially                                // Build packages serially, not in parallel.
 	GlobalDebug                                  // Enable debug info for all packages
 	BareInits                                    // Build init functions without guards or calls to dependent inits
+	Sigmas                                       // Insert σ-nodes for the values that branch conditions compare
 )
 
 const BuilderModeDoc = `Options controlling the SSA builder.
@@ -41,6 +42,7 @@ S	log [S]ource locations as SSA builder progresses.
 L	build distinct packages seria[L]ly instead of in parallel.
 N	build [N]aive SSA form: don't replace local loads/stores with registers.
 I	build bare [I]nit functions: no init guards or calls to dependent inits.
+R	insert σ-nodes for [R]ange analysis of the values that branches compare.
 `
 
 func (m BuilderMode) String() string {
@@ -66,6 +68,9 @@ func (m BuilderMode) String() string {
 	if m&BuildSerially != 0 {
 		buf.WriteByte('L')
 	}
+	if m&Sigmas != 0 {
+		buf.WriteByte('R')
+	}
 	return buf.String()
 }
 
@@ -88,6 +93,8 @@ func (m *BuilderMode) Set(s string) error {
 			mode |= NaiveForm
 		case 'L':
 			mode |= BuildSerially
+		case 'R':
+			mode |= Sigmas
 		default:
 			return fmt.Errorf("unknown BuilderMode option: %q", c)
 		}
@@ -178,7 +178,13 @@ func (s *sanity) checkInstr(idx int, instr Instruction) {
 	case *DebugRef:
 	case *BlankStore:
 	case *Sigma:
-		// TODO(adonovan): implement checks.
+		if len(s.block.Preds) != 1 {
+			s.errorf("σ-node in block with %d predecessors", len(s.block.Preds))
+		} else if pred := s.block.Preds[0]; len(pred.Instrs) == 0 {
+			s.errorf("σ-node follows empty block %s", pred)
+		} else if _, ok := pred.Instrs[len(pred.Instrs)-1].(*If); !ok {
+			s.errorf("σ-node follows block %s, which doesn't end in an If", pred)
+		}
 	default:
 		panic(fmt.Sprintf("Unknown instruction type: %T", instr))
 	}
@@ -0,0 +1,153 @@
+package ssa
+
+import (
+	"go/token"
+	"go/types"
+)
+
+// insertSigmas inserts σ-nodes for the values compared by branch
+// conditions, so that analyses such as value range propagation can
+// tell values apart by the branch they flow through.
+//
+// For a block ending in 'if x op y', where op is a comparison, each
+// successor that has no other predecessors gets a σ-node for every
+// non-constant operand, as well as for the argument of an operand of
+// the form len(v) or cap(v). All uses of the operand dominated by
+// the successor are renamed to the σ-node.
+//
+// Only integers, strings, slices and channels get σ-nodes. Builders
+// only insert them in the Sigmas mode.
+//
+// Preconditions: referrers and the dominator tree are built.
+func insertSigmas(fn *Function) {
+	for _, b := range fn.Blocks {
+		if len(b.Instrs) == 0 {
+			continue
+		}
+		ifi, ok := b.Instrs[len(b.Instrs)-1].(*If)
+		if !ok {
+			continue
+		}
+		cond, ok := ifi.Cond.(*BinOp)
+		if !ok {
+			continue
+		}
+		switch cond.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		default:
+			continue
+		}
+		var xs []Value
+		for _, v := range [...]Value{cond.X, cond.Y} {
+			xs = appendSigmaOperand(xs, v)
+			if call, ok := v.(*Call); ok {
+				if builtin, ok := call.Call.Value.(*Builtin); ok && (builtin.Name() == "len" || builtin.Name() == "cap") {
+					xs = appendSigmaOperand(xs, call.Call.Args[0])
+				}
+			}
+		}
+		for i, succ := range b.Succs {
+			if len(succ.Preds) != 1 || succ == b {
+				continue
+			}
+			for _, x := range xs {
+				insertSigma(succ, x, i == 0)
+			}
+		}
+	}
+}
+
+func appendSigmaOperand(xs []Value, v Value) []Value {
+	if _, ok := v.(*Const); ok {
+		return xs
+	}
+	switch T := v.Type().Underlying().(type) {
+	case *types.Basic:
+		if T.Info()&(types.IsInteger|types.IsString) == 0 {
+			return xs
+		}
+	case *types.Slice, *types.Chan:
+	default:
+		return xs
+	}
+	for _, x := range xs {
+		if x == v {
+			return xs
+		}
+	}
+	return append(xs, v)
+}
+
+// insertSigma inserts a σ-node for x at the start of b, which must
+// have a single predecessor, and renames all uses of x that b
+// dominates. No σ-node is inserted if there are no such uses.
+func insertSigma(b *BasicBlock, x Value, branch bool) {
+	refs := x.Referrers()
+	if refs == nil {
+		return
+	}
+	sigma := &Sigma{X: x, Branch: branch}
+	sigma.setType(x.Type())
+	sigma.block = b
+
+	var kept []Instruction
+	seen := map[Instruction]bool{}
+	for _, instr := range *refs {
+		if seen[instr] {
+			// An instruction that uses x more than once is referred
+			// to once per use.
+			if usesValue(instr, x) {
+				kept = append(kept, instr)
+			}
+			continue
+		}
+		seen[instr] = true
+		renamed := false
+		if phi, ok := instr.(*Phi); ok {
+			for j, edge := range phi.Edges {
+				if edge == x && b.Dominates(phi.block.Preds[j]) {
+					phi.Edges[j] = sigma
+					renamed = true
+				}
+			}
+		} else if instr.Block() != nil && b.Dominates(instr.Block()) {
+			for _, op := range instr.Operands(nil) {
+				if *op == x {
+					*op = sigma
+					renamed = true
+				}
+			}
+		}
+		if renamed {
+			sigma.referrers = append(sigma.referrers, instr)
+		}
+		if !renamed || usesValue(instr, x) {
+			kept = append(kept, instr)
+		}
+	}
+	if len(sigma.referrers) == 0 {
+		return
+	}
+	*refs = append(kept, sigma)
+
+	// σ-nodes go after the φ-nodes of the block.
+	i := 0
+	for i < len(b.Instrs) {
+		if _, ok := b.Instrs[i].(*Phi); !ok {
+			break
+		}
+		i++
+	}
+	b.Instrs = append(b.Instrs, nil)
+	copy(b.Instrs[i+1:], b.Instrs[i:])
+	b.Instrs[i] = sigma
+}
+
+func usesValue(instr Instruction, x Value) bool {
+	for _, op := range instr.Operands(nil) {
+		if *op == x {
+			return true
+		}
+	}
+	return false
+}
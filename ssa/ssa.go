@@ -507,12 +507,28 @@ type Alloc struct {
 var _ Instruction = (*Sigma)(nil)
 var _ Value = (*Sigma)(nil)
 
+// The Sigma instruction represents an SSA σ-node, which renames X in
+// the successor of a conditional branch that X's value was tested
+// in. Branch is the outcome of the test that leads to the successor,
+// which has no other predecessors. σ-nodes let analyses such as value
+// range propagation associate what the test established with the
+// value, and are only inserted for integers, strings, slices and
+// channels compared by the branch condition, and only when building
+// in the Sigmas mode.
+//
+// Pos() returns token.NoPos.
+//
+// Example printed form:
+// 	t2 = σ [t0.true]
+//
 type Sigma struct {
 	register
 	X      Value
 	Branch bool
 }
 
+// Value returns the value that p refines, looking through other
+// σ-nodes.
 func (p *Sigma) Value() Value {
 	v := p.X
 	for {
@@ -520,7 +536,7 @@ func (p *Sigma) Value() Value {
 		if !ok {
 			break
 		}
-		v = sigma
+		v = sigma.X
 	}
 	return v
 }
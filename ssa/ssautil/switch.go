@@ -73,17 +73,17 @@ func (sw *Switch) String() string {
 	if sw.ConstCases != nil {
 		fmt.Fprintf(&buf, "switch %s {\n", sw.X.Name())
 		for _, c := range sw.ConstCases {
-			fmt.Fprintf(&buf, "case %s: %s\n", c.Value, c.Body.Instrs[0])
+			fmt.Fprintf(&buf, "case %s: %s\n", c.Value, firstInstr(c.Body))
 		}
 	} else {
 		fmt.Fprintf(&buf, "switch %s.(type) {\n", sw.X.Name())
 		for _, c := range sw.TypeCases {
 			fmt.Fprintf(&buf, "case %s %s: %s\n",
-				c.Binding.Name(), c.Type, c.Body.Instrs[0])
+				c.Binding.Name(), c.Type, firstInstr(c.Body))
 		}
 	}
 	if sw.Default != nil {
-		fmt.Fprintf(&buf, "default: %s\n", sw.Default.Instrs[0])
+		fmt.Fprintf(&buf, "default: %s\n", firstInstr(sw.Default))
 	}
 	fmt.Fprintf(&buf, "}")
 	return buf.String()
@@ -114,7 +114,7 @@ func Switches(fn *ssa.Function) []Switch {
 	for _, b := range fn.DomPreorder() {
 		if x, k := isComparisonBlock(b); x != nil {
 			// Block b starts a switch.
-			sw := Switch{Start: b, X: x}
+			sw := Switch{Start: b, X: unsigma(x)}
 			valueSwitch(&sw, k, seen)
 			if len(sw.ConstCases) > 1 {
 				switches = append(switches, sw)
@@ -136,7 +136,7 @@ func Switches(fn *ssa.Function) []Switch {
 func valueSwitch(sw *Switch, k *ssa.Const, seen map[*ssa.BasicBlock]bool) {
 	b := sw.Start
 	x := sw.X
-	for x == sw.X {
+	for unsigma(x) == unsigma(sw.X) {
 		if seen[b] {
 			break
 		}
@@ -148,7 +148,7 @@ func valueSwitch(sw *Switch, k *ssa.Const, seen map[*ssa.BasicBlock]bool) {
 			Value: k,
 		})
 		b = b.Succs[1]
-		if len(b.Instrs) > 2 {
+		if numInstrs(b) > 2 {
 			// Block b contains not just 'if x == k',
 			// so it may have side effects that
 			// make it unsafe to elide.
@@ -180,7 +180,7 @@ func typeSwitch(sw *Switch, y ssa.Value, T types.Type, seen map[*ssa.BasicBlock]
 			Binding: y,
 		})
 		b = b.Succs[1]
-		if len(b.Instrs) > 4 {
+		if numInstrs(b) > 4 {
 			// Block b contains not just
 			//  {TypeAssert; Extract #0; Extract #1; If}
 			// so it may have side effects that
@@ -197,6 +197,36 @@ func typeSwitch(sw *Switch, y ssa.Value, T types.Type, seen map[*ssa.BasicBlock]
 	sw.Default = b
 }
 
+// firstInstr returns the first instruction of b that isn't a σ-node.
+func firstInstr(b *ssa.BasicBlock) ssa.Instruction {
+	for _, ins := range b.Instrs {
+		if _, ok := ins.(*ssa.Sigma); !ok {
+			return ins
+		}
+	}
+	return nil
+}
+
+// unsigma returns the value that v, which may be a σ-node, refines.
+func unsigma(v ssa.Value) ssa.Value {
+	if sigma, ok := v.(*ssa.Sigma); ok {
+		return sigma.Value()
+	}
+	return v
+}
+
+// numInstrs returns the number of instructions in b, not counting
+// σ-nodes, which have no side effects.
+func numInstrs(b *ssa.BasicBlock) int {
+	n := 0
+	for _, ins := range b.Instrs {
+		if _, ok := ins.(*ssa.Sigma); !ok {
+			n++
+		}
+	}
+	return n
+}
+
 // isComparisonBlock returns the operands (v, k) if a block ends with
 // a comparison v==k, where k is a compile-time constant.
 //
@@ -2,6 +2,8 @@
 package staticcheck // import "honnef.co/go/tools/staticcheck"
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/build"
@@ -9,19 +11,28 @@ import (
 	"go/token"
 	"go/types"
 	htmltemplate "html/template"
+	"io"
 	"net/http"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	texttemplate "text/template"
+	"unicode/utf8"
 
+	"honnef.co/go/tools/concurrency"
+	"honnef.co/go/tools/deprecated"
 	"honnef.co/go/tools/functions"
 	"honnef.co/go/tools/gcsizes"
 	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/nilness"
 	"honnef.co/go/tools/ssa"
 	"honnef.co/go/tools/staticcheck/vrp"
+	"honnef.co/go/tools/taint"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ast/astutil"
 )
 
@@ -48,12 +59,36 @@ func unmarshalPointer(name string, arg int) CallCheck {
 	}
 }
 
+func jsonType(name string, arg int) CallCheck {
+	return func(call *Call) {
+		arg := call.Args[arg]
+		if err := ValidateJSONType(arg.Value.Value.Type()); err != nil {
+			arg.Invalid(fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+}
+
 func pointlessIntMath(call *Call) {
 	if ConvertedFromInt(call.Args[0].Value) {
 		call.Invalid(fmt.Sprintf("calling %s on a converted integer is pointless", lint.CallName(call.Instr.Common())))
 	}
 }
 
+func timeLayout(arg int, parse bool) CallCheck {
+	return func(call *Call) {
+		arg := call.Args[arg]
+		if parse {
+			if err := ValidateTimeLayout(arg.Value); err != nil {
+				arg.Invalid(err.Error())
+				return
+			}
+		}
+		if err := ValidateTimeLayoutElements(arg.Value); err != nil {
+			arg.Invalid(err.Error())
+		}
+	}
+}
+
 func checkValidHostPort(arg int) CallCheck {
 	return func(call *Call) {
 		if !ValidHostPort(call.Args[arg].Value) {
@@ -69,19 +104,16 @@ var (
 	}
 
 	checkTimeParseRules = map[string]CallCheck{
-		"time.Parse": func(call *Call) {
-			arg := call.Args[0]
-			err := ValidateTimeLayout(arg.Value)
-			if err != nil {
-				arg.Invalid(err.Error())
-			}
-		},
+		"time.Parse":               timeLayout(0, true),
+		"time.ParseInLocation":     timeLayout(0, true),
+		"(time.Time).Format":       timeLayout(0, false),
+		"(time.Time).AppendFormat": timeLayout(1, false),
 	}
 
 	checkEncodingBinaryRules = map[string]CallCheck{
 		"encoding/binary.Write": func(call *Call) {
 			arg := call.Args[2]
-			if !CanBinaryMarshal(call.Job, arg.Value) {
+			if !CanBinaryMarshal(call.Job, call.Instr.Common(), arg.Value) {
 				arg.Invalid(fmt.Sprintf("value of type %s cannot be used with binary.Write", arg.Value.Value.Type()))
 			}
 		},
@@ -136,6 +168,14 @@ var (
 		"(*encoding/json.Decoder).Decode": unmarshalPointer("Decode", 0),
 	}
 
+	checkJSONTypeRules = map[string]CallCheck{
+		"encoding/json.Marshal":           jsonType("json.Marshal", 0),
+		"encoding/json.MarshalIndent":     jsonType("json.MarshalIndent", 0),
+		"(*encoding/json.Encoder).Encode": jsonType("Encode", 0),
+		"encoding/json.Unmarshal":         jsonType("json.Unmarshal", 1),
+		"(*encoding/json.Decoder).Decode": jsonType("Decode", 0),
+	}
+
 	checkUnbufferedSignalChanRules = map[string]CallCheck{
 		"os/signal.Notify": func(call *Call) {
 			arg := call.Args[0]
@@ -172,17 +212,88 @@ var (
 	}
 
 	checkRegexpMatchLoopRules = map[string]CallCheck{
-		"regexp.Match":       loopedRegexp("regexp.Match"),
-		"regexp.MatchReader": loopedRegexp("regexp.MatchReader"),
-		"regexp.MatchString": loopedRegexp("regexp.MatchString"),
+		"regexp.Match":            loopedRegexp("regexp.Match"),
+		"regexp.MatchReader":      loopedRegexp("regexp.MatchReader"),
+		"regexp.MatchString":      loopedRegexp("regexp.MatchString"),
+		"regexp.Compile":          repeatedCompile("regexp.Compile"),
+		"regexp.CompilePOSIX":     repeatedCompile("regexp.CompilePOSIX"),
+		"regexp.MustCompile":      repeatedCompile("regexp.MustCompile"),
+		"regexp.MustCompilePOSIX": repeatedCompile("regexp.MustCompilePOSIX"),
+	}
+
+	checkErrorsRules = map[string]CallCheck{
+		"errors.As": func(call *Call) {
+			arg := call.Args[1]
+			if err := ValidateErrorsAsTarget(arg.Value.Value); err != nil {
+				arg.Invalid(err.Error())
+			}
+		},
+		"errors.Is": func(call *Call) {
+			arg := call.Args[1]
+			if IsNewError(arg.Value.Value) {
+				arg.Invalid("errors.Is compares against a newly constructed error, which never matches; compare against a package-level sentinel error instead")
+			}
+		},
+	}
+
+	checkDeepEqualRules = map[string]CallCheck{
+		"reflect.DeepEqual": func(call *Call) {
+			for _, arg := range call.Args {
+				T := arg.Value.Value.Type()
+				switch DeepEqualHazard(T) {
+				case "func":
+					call.Invalid(fmt.Sprintf("reflect.DeepEqual on values of type %s, which contains functions; functions are never deeply equal unless both are nil", T))
+					return
+				case "time.Time":
+					call.Invalid(fmt.Sprintf("reflect.DeepEqual on values of type %s, which contains time.Time; equal instants may differ in location and monotonic clock reading, use the Equal method or a comparison package such as go-cmp instead", T))
+					return
+				}
+			}
+		},
 	}
 )
 
 type Checker struct {
-	CheckGenerated bool
+	// Taint configures the sources, sinks and sanitizers used by the
+	// security checks. If nil, taint.DefaultConfig is used.
+	Taint *taint.Config
+	// PrintfWrappers lists additional functions that take a
+	// printf-style format string, followed by its arguments as the
+	// final variadic parameter. Functions are identified by their
+	// fully qualified names, such as (*example.com/log.Logger).Infof.
+	PrintfWrappers []string
+	// Decoders lists additional functions that fill the values their
+	// pointer arguments point to with external input, like
+	// encoding/json.Unmarshal. SA5014 flags unchecked type assertions
+	// on data decoded by them.
+	Decoders []string
+	// Resources lists additional kinds of values that SA5015 expects
+	// to be released on all paths, such as connections of a
+	// third-party database driver.
+	Resources []Resource
+	// StructTags describes additional struct tags to be checked by
+	// SA1026, such as the tags of ORMs and validation libraries.
+	StructTags []TagSchema
+	// VRPThresholds are additional values that vrp widens ranges
+	// to before giving up on a bound, such as limits that loops in
+	// the checked code count up to.
+	VRPThresholds []vrp.Z
+	// VRPMaxIterations limits the work vrp spends on each loop of a
+	// function, trading precision for speed. Zero means no limit.
+	VRPMaxIterations int
+	// DebugVRP names a function whose vrp constraint graph should be
+	// written to DebugVRPOutput in the Graphviz dot format.
+	DebugVRP       string
+	DebugVRPOutput io.Writer
 	funcDescs      *functions.Descriptions
 	deprecatedObjs map[types.Object]string
 	nodeFns        map[ast.Node]*ssa.Function
+
+	concurrencyOnce sync.Once
+	concurrency     []*concurrency.Analysis
+
+	taintOnce sync.Once
+	taint     []*taint.Analysis
 }
 
 func NewChecker() *Checker {
@@ -215,14 +326,33 @@ func (c *Checker) Funcs() map[string]lint.Func {
 		"SA1021": c.callChecker(checkBytesEqualIPRules),
 		"SA1022": c.CheckFlagUsage,
 		"SA1023": c.CheckWriterBufferModified,
+		"SA1024": c.CheckErrorfWrapVerb,
+		"SA1025": c.callChecker(checkDeepEqualRules),
+		"SA1026": c.CheckStructTags,
+		"SA1027": c.CheckUnsafePointerConversion,
+		"SA1028": c.callChecker(checkJSONTypeRules),
+		"SA1029": c.CheckUnalignedAtomic,
+		"SA1030": c.callChecker(checkErrorsRules),
+		"SA1031": c.CheckWrappedErrorComparison,
 
 		"SA2000": c.CheckWaitgroupAdd,
 		"SA2001": c.CheckEmptyCriticalSection,
 		"SA2002": c.CheckConcurrentTesting,
 		"SA2003": c.CheckDeferLock,
+		"SA2004": c.CheckCapturedVariableRace,
+		"SA2005": c.CheckSendOnClosedChannel,
+		"SA2006": c.CheckDoubleClose,
+		"SA2007": c.CheckAbandonedSend,
+		"SA2008": c.CheckWaitWhileLocked,
+		"SA2009": c.CheckCopiedLock,
+		"SA2010": c.CheckUnbalancedLock,
+		"SA2011": c.CheckBlockingSend,
 
 		"SA3000": c.CheckTestMainExit,
 		"SA3001": c.CheckBenchmarkN,
+		"SA3002": c.CheckBenchmarkUsesN,
+		"SA3003": c.CheckBenchmarkSetup,
+		"SA3004": c.CheckBenchmarkTimerBalance,
 
 		"SA4000": c.CheckLhsRhsIdentical,
 		"SA4001": c.CheckIneffectiveCopy,
@@ -243,6 +373,9 @@ func (c *Checker) Funcs() map[string]lint.Func {
 		"SA4015": c.callChecker(checkMathIntRules),
 		"SA4016": c.CheckSillyBitwiseOps,
 		"SA4017": c.CheckPureFunctions,
+		"SA4018": c.CheckPredeterminedComparison,
+		"SA4019": c.CheckImpossibleTypeAssertion,
+		"SA4020": c.CheckUnreachableCode,
 
 		"SA5000": c.CheckNilMaps,
 		"SA5001": c.CheckEarlyDefer,
@@ -250,36 +383,169 @@ func (c *Checker) Funcs() map[string]lint.Func {
 		"SA5003": c.CheckDeferInInfiniteLoop,
 		"SA5004": c.CheckLoopEmptyDefault,
 		"SA5005": c.CheckCyclicFinalizer,
-		// "SA5006": c.CheckSliceOutOfBounds,
+		"SA5006": c.CheckSliceOutOfBounds,
 		"SA5007": c.CheckInfiniteRecursion,
+		"SA5008": c.CheckDivisionByZero,
+		"SA5009": c.CheckLossyIntConversion,
+		"SA5010": c.CheckMakeSize,
+		"SA5011": c.CheckExitSkipsDefers,
+		"SA5012": c.CheckAppendAliasing,
+		"SA5013": c.CheckNilDereference,
+		"SA5014": c.CheckUncheckedAssertion,
+		"SA5015": c.CheckResourceLeak,
+		"SA5016": c.CheckLoopVariableCapture,
 
 		"SA6000": c.callChecker(checkRegexpMatchLoopRules),
 		"SA6001": c.CheckMapBytesKey,
 
+		"SA7000": c.CheckCommandInjection,
+		"SA7001": c.CheckSQLInjection,
+		"SA7002": c.CheckInterpolatedShellCommand,
+		"SA7003": c.CheckTemplateInjection,
+		"SA7004": c.CheckLogForging,
+		"SA7005": c.CheckWeakCrypto,
+		"SA7006": c.CheckInsecureRandom,
+		"SA7007": c.CheckInsecureTLSConfig,
+		"SA7008": c.CheckStaticNonce,
+
 		"SA9000": c.callChecker(checkDubiousSyncPoolSizeRules),
 		"SA9001": c.CheckDubiousDeferInChannelRangeLoop,
 		"SA9002": c.CheckNonOctalFileMode,
 		"SA9003": c.CheckEmptyBranch,
+		"SA9004": c.CheckDeferredReleaseInLoop,
+		"SA9005": c.CheckIntToStringConversion,
+		"SA9006": c.CheckShadowedError,
 	}
 }
 
-func (c *Checker) filterGenerated(files []*ast.File) []*ast.File {
-	if c.CheckGenerated {
-		return files
-	}
-	var out []*ast.File
-	for _, f := range files {
-		if !lint.IsGenerated(f) {
-			out = append(out, f)
-		}
+func (c *Checker) Versions() map[string]lint.CheckVersion {
+	return map[string]lint.CheckVersion{
+		"SA1000": {Introduced: "2017.1"},
+		"SA1001": {Introduced: "2017.1"},
+		"SA1002": {Introduced: "2017.1", Changed: "2017.2"},
+		"SA1003": {Introduced: "2017.1"},
+		"SA1004": {Introduced: "2017.1"},
+		"SA1005": {Introduced: "2017.1"},
+		"SA1006": {Introduced: "2017.1"},
+		"SA1007": {Introduced: "2017.1"},
+		"SA1008": {Introduced: "2017.1"},
+		"SA1010": {Introduced: "2017.1"},
+		"SA1011": {Introduced: "2017.1"},
+		"SA1012": {Introduced: "2017.1"},
+		"SA1013": {Introduced: "2017.1"},
+		"SA1014": {Introduced: "2017.1"},
+		"SA1015": {Introduced: "2017.1", Changed: "2017.2"},
+		"SA1016": {Introduced: "2017.1"},
+		"SA1017": {Introduced: "2017.1"},
+		"SA1018": {Introduced: "2017.1"},
+		"SA1019": {Introduced: "2017.1", Changed: "2017.2"},
+		"SA1020": {Introduced: "2017.1"},
+		"SA1021": {Introduced: "2017.1"},
+		"SA1022": {Introduced: "2017.1"},
+		"SA1023": {Introduced: "2017.1"},
+		"SA1024": {Introduced: "2017.2"},
+		"SA1025": {Introduced: "2017.2"},
+		"SA1026": {Introduced: "2017.2"},
+		"SA1027": {Introduced: "2017.2"},
+		"SA1028": {Introduced: "2017.2"},
+		"SA1029": {Introduced: "2017.2"},
+		"SA1030": {Introduced: "2017.2"},
+		"SA1031": {Introduced: "2017.2"},
+		"SA2000": {Introduced: "2017.1", Changed: "2017.2"},
+		"SA2001": {Introduced: "2017.1"},
+		"SA2002": {Introduced: "2017.1", Changed: "2017.2"},
+		"SA2003": {Introduced: "2017.1"},
+		"SA2004": {Introduced: "2017.2"},
+		"SA2005": {Introduced: "2017.2"},
+		"SA2006": {Introduced: "2017.2"},
+		"SA2007": {Introduced: "2017.2"},
+		"SA2008": {Introduced: "2017.2"},
+		"SA2009": {Introduced: "2017.2"},
+		"SA2010": {Introduced: "2017.2"},
+		"SA2011": {Introduced: "2017.2"},
+		"SA3000": {Introduced: "2017.1"},
+		"SA3001": {Introduced: "2017.1"},
+		"SA3002": {Introduced: "2017.2"},
+		"SA3003": {Introduced: "2017.2"},
+		"SA3004": {Introduced: "2017.2"},
+		"SA4000": {Introduced: "2017.1"},
+		"SA4001": {Introduced: "2017.1"},
+		"SA4002": {Introduced: "2017.1"},
+		"SA4003": {Introduced: "2017.1"},
+		"SA4004": {Introduced: "2017.1"},
+		"SA4005": {Introduced: "2017.1"},
+		"SA4006": {Introduced: "2017.1"},
+		"SA4008": {Introduced: "2017.1"},
+		"SA4009": {Introduced: "2017.1"},
+		"SA4011": {Introduced: "2017.1"},
+		"SA4012": {Introduced: "2017.1"},
+		"SA4013": {Introduced: "2017.1"},
+		"SA4014": {Introduced: "2017.1"},
+		"SA4015": {Introduced: "2017.1"},
+		"SA4016": {Introduced: "2017.1"},
+		"SA4017": {Introduced: "2017.1"},
+		"SA4018": {Introduced: "2017.2"},
+		"SA4019": {Introduced: "2017.2"},
+		"SA4020": {Introduced: "2017.2"},
+		"SA5000": {Introduced: "2017.1"},
+		"SA5001": {Introduced: "2017.1"},
+		"SA5002": {Introduced: "2017.1"},
+		"SA5003": {Introduced: "2017.1"},
+		"SA5004": {Introduced: "2017.1"},
+		"SA5005": {Introduced: "2017.1"},
+		"SA5006": {Introduced: "2017.2"},
+		"SA5007": {Introduced: "2017.1", Changed: "2017.2"},
+		"SA5008": {Introduced: "2017.2"},
+		"SA5009": {Introduced: "2017.2"},
+		"SA5010": {Introduced: "2017.2"},
+		"SA5011": {Introduced: "2017.2"},
+		"SA5012": {Introduced: "2017.2"},
+		"SA5013": {Introduced: "2017.2"},
+		"SA5014": {Introduced: "2017.2"},
+		"SA5015": {Introduced: "2017.2"},
+		"SA5016": {Introduced: "2017.2"},
+		"SA6000": {Introduced: "2017.1", Changed: "2017.2"},
+		"SA6001": {Introduced: "2017.1"},
+		"SA7000": {Introduced: "2017.2"},
+		"SA7001": {Introduced: "2017.2"},
+		"SA7002": {Introduced: "2017.2"},
+		"SA7003": {Introduced: "2017.2"},
+		"SA7004": {Introduced: "2017.2"},
+		"SA7005": {Introduced: "2017.2"},
+		"SA7006": {Introduced: "2017.2"},
+		"SA7007": {Introduced: "2017.2"},
+		"SA7008": {Introduced: "2017.2"},
+		"SA9000": {Introduced: "2017.1"},
+		"SA9001": {Introduced: "2017.1"},
+		"SA9002": {Introduced: "2017.1"},
+		"SA9003": {Introduced: "2017.1"},
+		"SA9004": {Introduced: "2017.2"},
+		"SA9005": {Introduced: "2017.2"},
+		"SA9006": {Introduced: "2017.2"},
 	}
-	return out
 }
 
 func (c *Checker) Init(prog *lint.Program) {
+	if c.Taint == nil {
+		c.Taint = taint.DefaultConfig()
+	}
 	c.funcDescs = functions.NewDescriptions(prog.SSA)
+	c.funcDescs.Thresholds = c.VRPThresholds
+	c.funcDescs.MaxIterations = c.VRPMaxIterations
+	if c.DebugVRP != "" {
+		c.funcDescs.OnRanges = func(fn *ssa.Function, g *vrp.Graph) {
+			if fn.String() == c.DebugVRP {
+				g.WriteDot(c.DebugVRPOutput)
+			}
+		}
+	}
 	c.deprecatedObjs = map[types.Object]string{}
 	c.nodeFns = map[ast.Node]*ssa.Function{}
+	// The analyses are computed lazily, once per program.
+	c.concurrencyOnce = sync.Once{}
+	c.concurrency = nil
+	c.taintOnce = sync.Once{}
+	c.taint = nil
 
 	fns := prog.AllFunctions
 
@@ -298,6 +564,14 @@ func (c *Checker) Init(prog *lint.Program) {
 	}
 	pwg.Wait()
 
+	if c.DebugVRP != "" {
+		for _, fn := range fns {
+			if fn.String() == c.DebugVRP {
+				c.funcDescs.Get(fn)
+			}
+		}
+	}
+
 	c.nodeFns = lint.NodeFns(prog.Packages)
 
 	chDeprecated := make(chan struct {
@@ -343,6 +617,17 @@ func (c *Checker) Init(prog *lint.Program) {
 	for dep := range chDeprecated {
 		c.deprecatedObjs[dep.obj] = dep.msg
 	}
+	if prog.Facts != nil {
+		// make the deprecation notices available to the importers
+		// of the package, which only see its types
+		for _, pkg := range prog.Packages {
+			for obj, msg := range c.deprecatedObjs {
+				if msg != "" && obj.Pkg() == pkg.Pkg {
+					prog.Facts.ExportObjectFact(obj, &deprecatedFact{msg})
+				}
+			}
+		}
+	}
 }
 
 // TODO(adonovan): make this a method: func (*token.File) Contains(token.Pos)
@@ -425,6 +710,21 @@ func (c *Checker) isInLoop(b *ssa.BasicBlock) bool {
 	return false
 }
 
+// isCalledInLoop reports whether one of fn's callers calls it from
+// inside a loop.
+func (c *Checker) isCalledInLoop(fn *ssa.Function) bool {
+	node, ok := c.funcDescs.CallGraph.Nodes[fn]
+	if !ok {
+		return false
+	}
+	for _, edge := range node.In {
+		if edge.Site != nil && c.isInLoop(edge.Site.Block()) {
+			return true
+		}
+	}
+	return false
+}
+
 func applyStdlibKnowledge(fn *ssa.Function) {
 	if len(fn.Blocks) == 0 {
 		return
@@ -605,10 +905,16 @@ func (c *Checker) CheckWaitgroupAdd(j *lint.Job) {
 		if !ok {
 			return true
 		}
-		if len(fun.Body.List) == 0 {
-			return true
+		// Deferred calls such as defer wg.Done() don't run before
+		// the Add, so look past them.
+		var first ast.Stmt
+		for _, stmt := range fun.Body.List {
+			if _, ok := stmt.(*ast.DeferStmt); !ok {
+				first = stmt
+				break
+			}
 		}
-		stmt, ok := fun.Body.List[0].(*ast.ExprStmt)
+		stmt, ok := first.(*ast.ExprStmt)
 		if !ok {
 			return true
 		}
@@ -720,50 +1026,74 @@ func (c *Checker) CheckDubiousDeferInChannelRangeLoop(j *lint.Job) {
 	}
 }
 
-func (c *Checker) CheckTestMainExit(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		if !isTestMain(j, node) {
-			return true
+func (c *Checker) CheckDeferredReleaseInLoop(j *lint.Job) {
+	isRelease := func(call *ast.CallExpr) (string, bool) {
+		if types.TypeString(j.Program.Info.TypeOf(call.Fun), nil) == "context.CancelFunc" {
+			return j.Render(call.Fun), true
 		}
-
-		arg := j.Program.Info.ObjectOf(node.(*ast.FuncDecl).Type.Params.List[0].Names[0])
-		callsRun := false
-		fn2 := func(node ast.Node) bool {
-			call, ok := node.(*ast.CallExpr)
-			if !ok {
-				return true
-			}
-			sel, ok := call.Fun.(*ast.SelectorExpr)
-			if !ok {
-				return true
-			}
-			ident, ok := sel.X.(*ast.Ident)
-			if !ok {
-				return true
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return "", false
+		}
+		switch sel.Sel.Name {
+		case "Close", "Unlock", "RUnlock", "Release", "Stop":
+			return j.Render(call.Fun), true
+		}
+		return "", false
+	}
+	reported := map[*ast.DeferStmt]bool{}
+	fn := func(node ast.Node) bool {
+		var body *ast.BlockStmt
+		switch loop := node.(type) {
+		case *ast.ForStmt:
+			body = loop.Body
+			if loop.Cond == nil {
+				// Defers in loops that never exit are flagged by
+				// CheckDeferInInfiniteLoop.
+				mightExit := false
+				ast.Inspect(body, func(node ast.Node) bool {
+					switch stmt := node.(type) {
+					case *ast.ReturnStmt:
+						mightExit = true
+					case *ast.BranchStmt:
+						if stmt.Tok == token.BREAK {
+							mightExit = true
+						}
+					case *ast.FuncLit:
+						return false
+					}
+					return true
+				})
+				if !mightExit {
+					return true
+				}
 			}
-			if arg != j.Program.Info.ObjectOf(ident) {
+		case *ast.RangeStmt:
+			if _, ok := j.Program.Info.TypeOf(loop.X).Underlying().(*types.Chan); ok {
+				// Handled by CheckDubiousDeferInChannelRangeLoop.
 				return true
 			}
-			if sel.Sel.Name == "Run" {
-				callsRun = true
-				return false
-			}
+			body = loop.Body
+		default:
 			return true
 		}
-		ast.Inspect(node.(*ast.FuncDecl).Body, fn2)
-
-		callsExit := false
-		fn3 := func(node ast.Node) bool {
-			if j.IsFunctionCallName(node, "os.Exit") {
-				callsExit = true
+		fn2 := func(node ast.Node) bool {
+			switch stmt := node.(type) {
+			case *ast.DeferStmt:
+				name, ok := isRelease(stmt.Call)
+				if !ok || reported[stmt] {
+					return true
+				}
+				reported[stmt] = true
+				j.Errorf(stmt, "deferred call to %s in a loop only runs when the surrounding function returns; consider wrapping the loop body in a function literal", name)
+			case *ast.FuncLit:
+				// Wrapping the loop body in a function literal is the
+				// idiomatic way of running defers once per iteration.
 				return false
 			}
 			return true
 		}
-		ast.Inspect(node.(*ast.FuncDecl).Body, fn3)
-		if !callsExit && callsRun {
-			j.Errorf(node, "TestMain should call os.Exit to set exit code")
-		}
+		ast.Inspect(body, fn2)
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -771,97 +1101,173 @@ func (c *Checker) CheckTestMainExit(j *lint.Job) {
 	}
 }
 
-func isTestMain(j *lint.Job, node ast.Node) bool {
-	decl, ok := node.(*ast.FuncDecl)
-	if !ok {
-		return false
-	}
-	if decl.Name.Name != "TestMain" {
-		return false
-	}
-	if len(decl.Type.Params.List) != 1 {
-		return false
-	}
-	arg := decl.Type.Params.List[0]
-	if len(arg.Names) != 1 {
-		return false
+func (c *Checker) CheckLoopVariableCapture(j *lint.Job) {
+	// captured returns the first loop variable used in lit.
+	captured := func(lit *ast.FuncLit, vars map[types.Object]bool) *ast.Ident {
+		var ident *ast.Ident
+		ast.Inspect(lit.Body, func(node ast.Node) bool {
+			if id, ok := node.(*ast.Ident); ok && ident == nil && vars[j.Program.Info.Uses[id]] {
+				ident = id
+			}
+			return ident == nil
+		})
+		return ident
 	}
-	typ := j.Program.Info.TypeOf(arg.Type)
-	return typ != nil && typ.String() == "*testing.M"
-}
-
-func (c *Checker) CheckExec(j *lint.Job) {
 	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
+		vars := map[types.Object]bool{}
+		var body *ast.BlockStmt
+		switch loop := node.(type) {
+		case *ast.RangeStmt:
+			if loop.Tok != token.DEFINE {
+				return true
+			}
+			for _, expr := range []ast.Expr{loop.Key, loop.Value} {
+				if ident, ok := expr.(*ast.Ident); ok && ident.Name != "_" {
+					vars[j.Program.Info.Defs[ident]] = true
+				}
+			}
+			body = loop.Body
+		case *ast.ForStmt:
+			assign, ok := loop.Init.(*ast.AssignStmt)
+			if !ok || assign.Tok != token.DEFINE {
+				return true
+			}
+			for _, expr := range assign.Lhs {
+				if ident, ok := expr.(*ast.Ident); ok && ident.Name != "_" {
+					vars[j.Program.Info.Defs[ident]] = true
+				}
+			}
+			body = loop.Body
+		default:
 			return true
 		}
-		if !j.IsFunctionCallName(call, "os/exec.Command") {
+		delete(vars, nil)
+		if len(vars) == 0 {
 			return true
 		}
-		val, ok := j.ExprToString(call.Args[0])
-		if !ok {
-			return true
+
+		// Goroutines that are waited for within the same iteration
+		// can't observe later iterations.
+		waits := false
+		ast.Inspect(body, func(node ast.Node) bool {
+			if call, ok := node.(*ast.CallExpr); ok {
+				if fn, ok := calledFunc(j, call); ok && fn.FullName() == "(*sync.WaitGroup).Wait" {
+					waits = true
+				}
+			}
+			return !waits
+		})
+
+		report := func(lit *ast.FuncLit, what string) {
+			if ident := captured(lit, vars); ident != nil {
+				j.Errorf(ident, "loop variable %s captured by func literal %s; all iterations share the same variable, pass it as an argument or copy it with %s := %s",
+					ident.Name, what, ident.Name, ident.Name)
+			}
 		}
-		if !strings.Contains(val, " ") || strings.Contains(val, `\`) {
+		ast.Inspect(body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.GoStmt:
+				if lit, ok := node.Call.Fun.(*ast.FuncLit); ok && !waits {
+					report(lit, "in go statement")
+				}
+			case *ast.DeferStmt:
+				if lit, ok := node.Call.Fun.(*ast.FuncLit); ok {
+					report(lit, "in defer statement")
+				}
+			case *ast.CallExpr:
+				if ident, ok := node.Fun.(*ast.Ident); !ok || ident.Name != "append" {
+					return true
+				}
+				if _, ok := j.Program.Info.Uses[node.Fun.(*ast.Ident)].(*types.Builtin); !ok {
+					return true
+				}
+				for _, arg := range node.Args[1:] {
+					if lit, ok := arg.(*ast.FuncLit); ok {
+						report(lit, "that is appended to a slice")
+					}
+				}
+			case *ast.AssignStmt:
+				for i, lhs := range node.Lhs {
+					if _, ok := lhs.(*ast.IndexExpr); !ok || len(node.Rhs) != len(node.Lhs) {
+						continue
+					}
+					if lit, ok := node.Rhs[i].(*ast.FuncLit); ok {
+						report(lit, "that is stored in "+j.Render(lhs))
+					}
+				}
+			}
 			return true
-		}
-		j.Errorf(call.Args[0], "first argument to exec.Command looks like a shell command, but a program name or path are expected")
+		})
 		return true
 	}
-	for _, f := range j.Program.Files {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if j.IsGoVersionAt(f, 22) {
+			// Since Go 1.22, each iteration has its own loop variables.
+			continue
+		}
 		ast.Inspect(f, fn)
 	}
 }
 
-func (c *Checker) CheckLoopEmptyDefault(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		loop, ok := node.(*ast.ForStmt)
-		if !ok || len(loop.Body.List) != 1 || loop.Cond != nil || loop.Init != nil {
-			return true
-		}
-		sel, ok := loop.Body.List[0].(*ast.SelectStmt)
-		if !ok {
-			return true
-		}
-		for _, c := range sel.Body.List {
-			if comm, ok := c.(*ast.CommClause); ok && comm.Comm == nil && len(comm.Body) == 0 {
-				j.Errorf(comm, "should not have an empty default case in a for+select loop. The loop will spin.")
+// conflictingMethod returns a method of I that exists in J with the
+// same name but a different signature, making it impossible for a
+// type to implement both interfaces.
+func conflictingMethod(I, J *types.Interface) (*types.Func, *types.Func, bool) {
+	for i := 0; i < I.NumMethods(); i++ {
+		m1 := I.Method(i)
+		for k := 0; k < J.NumMethods(); k++ {
+			m2 := J.Method(k)
+			if m1.Name() == m2.Name() && !types.Identical(m1.Type(), m2.Type()) {
+				return m1, m2, true
 			}
 		}
-		return true
-	}
-	for _, f := range j.Program.Files {
-		ast.Inspect(f, fn)
 	}
+	return nil, nil, false
 }
 
-func (c *Checker) CheckLhsRhsIdentical(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		op, ok := node.(*ast.BinaryExpr)
+func (c *Checker) CheckImpossibleTypeAssertion(j *lint.Job) {
+	qualifier := func(pkg *types.Package) string { return pkg.Name() }
+	check := func(x ast.Expr, T ast.Expr) {
+		I, ok := j.Program.Info.TypeOf(x).Underlying().(*types.Interface)
 		if !ok {
-			return true
+			return
 		}
-		switch op.Op {
-		case token.EQL, token.NEQ:
-			if basic, ok := j.Program.Info.TypeOf(op.X).(*types.Basic); ok {
-				if kind := basic.Kind(); kind == types.Float32 || kind == types.Float64 {
-					// f == f and f != f might be used to check for NaN
-					return true
+		J, ok := j.Program.Info.TypeOf(T).Underlying().(*types.Interface)
+		if !ok {
+			return
+		}
+		if m1, m2, ok := conflictingMethod(I, J); ok {
+			j.Errorf(T, "impossible type assertion: %s and %s have conflicting %s methods, %s and %s",
+				types.TypeString(j.Program.Info.TypeOf(x), qualifier), types.TypeString(j.Program.Info.TypeOf(T), qualifier),
+				m1.Name(), types.TypeString(m1.Type(), qualifier), types.TypeString(m2.Type(), qualifier))
+		}
+	}
+	fn := func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.TypeAssertExpr:
+			if node.Type != nil {
+				check(node.X, node.Type)
+			}
+		case *ast.TypeSwitchStmt:
+			var assert *ast.TypeAssertExpr
+			switch stmt := node.Assign.(type) {
+			case *ast.ExprStmt:
+				assert, _ = stmt.X.(*ast.TypeAssertExpr)
+			case *ast.AssignStmt:
+				assert, _ = stmt.Rhs[0].(*ast.TypeAssertExpr)
+			}
+			if assert == nil {
+				return true
+			}
+			for _, clause := range node.Body.List {
+				for _, T := range clause.(*ast.CaseClause).List {
+					if j.Program.Info.Types[T].IsNil() {
+						continue
+					}
+					check(assert.X, T)
 				}
 			}
-		case token.SUB, token.QUO, token.AND, token.REM, token.OR, token.XOR, token.AND_NOT,
-			token.LAND, token.LOR, token.LSS, token.GTR, token.LEQ, token.GEQ:
-		default:
-			// For some ops, such as + and *, it can make sense to
-			// have identical operands
-			return true
-		}
-
-		if j.Render(op.X) != j.Render(op.Y) {
-			return true
 		}
-		j.Errorf(op, "identical expressions on the left and right side of the '%s' operator", op.Op)
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -869,232 +1275,273 @@ func (c *Checker) CheckLhsRhsIdentical(j *lint.Job) {
 	}
 }
 
-func (c *Checker) CheckScopedBreak(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		var body *ast.BlockStmt
-		switch node := node.(type) {
-		case *ast.ForStmt:
-			body = node.Body
-		case *ast.RangeStmt:
-			body = node.Body
-		default:
-			return true
+// CheckUnreachableCode flags code that can never run, beyond what the
+// compiler and vet know about: statements following calls to
+// functions that never return, branches that are dead because of
+// constant conditions, and select cases on channels that are always
+// nil.
+func (c *Checker) CheckUnreachableCode(j *lint.Job) {
+	// neverReturns returns the name of the function called by stmt
+	// if that function never returns.
+	neverReturns := func(stmt ast.Stmt) (string, bool) {
+		expr, ok := stmt.(*ast.ExprStmt)
+		if !ok {
+			return "", false
 		}
-		for _, stmt := range body.List {
-			var blocks [][]ast.Stmt
-			switch stmt := stmt.(type) {
-			case *ast.SwitchStmt:
-				for _, c := range stmt.Body.List {
-					blocks = append(blocks, c.(*ast.CaseClause).Body)
-				}
-			case *ast.SelectStmt:
-				for _, c := range stmt.Body.List {
-					blocks = append(blocks, c.(*ast.CommClause).Body)
-				}
-			default:
-				continue
-			}
-
-			for _, body := range blocks {
-				if len(body) == 0 {
-					continue
-				}
-				lasts := []ast.Stmt{body[len(body)-1]}
-				// TODO(dh): unfold all levels of nested block
-				// statements, not just a single level if statement
-				if ifs, ok := lasts[0].(*ast.IfStmt); ok {
-					if len(ifs.Body.List) == 0 {
-						continue
-					}
-					lasts[0] = ifs.Body.List[len(ifs.Body.List)-1]
-
-					if block, ok := ifs.Else.(*ast.BlockStmt); ok {
-						if len(block.List) != 0 {
-							lasts = append(lasts, block.List[len(block.List)-1])
-						}
-					}
-				}
-				for _, last := range lasts {
-					branch, ok := last.(*ast.BranchStmt)
-					if !ok || branch.Tok != token.BREAK || branch.Label != nil {
-						continue
-					}
-					j.Errorf(branch, "ineffective break statement. Did you mean to break out of the outer loop?")
-				}
-			}
-		}
-		return true
-	}
-	for _, f := range j.Program.Files {
-		ast.Inspect(f, fn)
-	}
-}
-
-func (c *Checker) CheckUnsafePrintf(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
+		call, ok := expr.X.(*ast.CallExpr)
 		if !ok {
-			return true
-		}
-		if !j.IsFunctionCallNameAny(call, "fmt.Printf", "fmt.Sprintf", "log.Printf") {
-			return true
-		}
-		if len(call.Args) != 1 {
-			return true
+			return "", false
 		}
-		switch call.Args[0].(type) {
-		case *ast.CallExpr, *ast.Ident:
-		default:
-			return true
+		var obj types.Object
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			obj = j.Program.Info.ObjectOf(fun)
+		case *ast.SelectorExpr:
+			obj = j.Program.Info.ObjectOf(fun.Sel)
 		}
-		j.Errorf(call.Args[0], "printf-style function with dynamic first argument and no further arguments should use print-style function instead")
-		return true
-	}
-	for _, f := range j.Program.Files {
-		ast.Inspect(f, fn)
-	}
-}
-
-func (c *Checker) CheckEarlyDefer(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		block, ok := node.(*ast.BlockStmt)
+		fn, ok := obj.(*types.Func)
 		if !ok {
-			return true
+			// this includes the builtin panic, which vet knows
+			// about already
+			return "", false
 		}
-		if len(block.List) < 2 {
-			return true
+		ssafn := j.Program.SSA.FuncValue(fn)
+		if ssafn == nil || !c.funcDescs.Get(ssafn).Infinite {
+			return "", false
 		}
-		for i, stmt := range block.List {
-			if i == len(block.List)-1 {
-				break
-			}
-			assign, ok := stmt.(*ast.AssignStmt)
-			if !ok {
-				continue
-			}
-			if len(assign.Rhs) != 1 {
-				continue
-			}
-			if len(assign.Lhs) < 2 {
-				continue
-			}
-			if lhs, ok := assign.Lhs[len(assign.Lhs)-1].(*ast.Ident); ok && lhs.Name == "_" {
-				continue
-			}
-			call, ok := assign.Rhs[0].(*ast.CallExpr)
-			if !ok {
-				continue
-			}
-			sig, ok := j.Program.Info.TypeOf(call.Fun).(*types.Signature)
+		return j.Render(call.Fun), true
+	}
+	checkList := func(list []ast.Stmt) {
+		for i := 0; i+1 < len(list); i++ {
+			name, ok := neverReturns(list[i])
 			if !ok {
 				continue
 			}
-			if sig.Results().Len() < 2 {
-				continue
-			}
-			last := sig.Results().At(sig.Results().Len() - 1)
-			// FIXME(dh): check that it's error from universe, not
-			// another type of the same name
-			if last.Type().String() != "error" {
+			next := list[i+1]
+			if _, ok := next.(*ast.LabeledStmt); ok {
+				// reachable via goto
 				continue
 			}
-			lhs, ok := assign.Lhs[0].(*ast.Ident)
-			if !ok {
+			if _, ok := next.(*ast.EmptyStmt); ok {
 				continue
 			}
-			def, ok := block.List[i+1].(*ast.DeferStmt)
+			j.Errorf(next, "this code is unreachable because %s never returns", name)
+			return
+		}
+	}
+	// constantCond reports the value of cond if it is a constant
+	// that doesn't depend on named constants. Conditions on named
+	// constants, such as a debug flag, are a common way of disabling
+	// code.
+	constantCond := func(cond ast.Expr) (bool, bool) {
+		if cond == nil {
+			return false, false
+		}
+		if ident, ok := cond.(*ast.Ident); ok && (ident.Name == "true" || ident.Name == "false") {
+			// a deliberate way of disabling or forcing code
+			return false, false
+		}
+		tv := j.Program.Info.Types[cond]
+		if tv.Value == nil || tv.Value.Kind() != constant.Bool {
+			return false, false
+		}
+		named := false
+		ast.Inspect(cond, func(node ast.Node) bool {
+			ident, ok := node.(*ast.Ident)
 			if !ok {
-				continue
+				return true
 			}
-			sel, ok := def.Call.Fun.(*ast.SelectorExpr)
-			if !ok {
-				continue
+			if obj, ok := j.Program.Info.ObjectOf(ident).(*types.Const); ok && obj.Parent() != types.Universe {
+				named = true
 			}
-			ident, ok := selectorX(sel).(*ast.Ident)
+			return !named
+		})
+		if named {
+			return false, false
+		}
+		return constant.BoolVal(tv.Value), true
+	}
+	fn := func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.BlockStmt:
+			checkList(node.List)
+		case *ast.CaseClause:
+			checkList(node.Body)
+		case *ast.CommClause:
+			checkList(node.Body)
+		case *ast.IfStmt:
+			b, ok := constantCond(node.Cond)
 			if !ok {
-				continue
+				break
 			}
-			if ident.Obj != lhs.Obj {
-				continue
+			if !b {
+				j.Errorf(node.Body, "condition is always false, the body is unreachable")
+			} else if node.Else != nil {
+				j.Errorf(node.Else, "condition is always true, the else branch is unreachable")
 			}
-			if sel.Sel.Name != "Close" {
-				continue
+		case *ast.ForStmt:
+			if b, ok := constantCond(node.Cond); ok && !b {
+				j.Errorf(node.Body, "condition is always false, the loop body is unreachable")
 			}
-			j.Errorf(def, "should check returned error before deferring %s", j.Render(def.Call))
 		}
 		return true
 	}
-	for _, f := range j.Program.Files {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
-}
 
-func selectorX(sel *ast.SelectorExpr) ast.Node {
-	switch x := sel.X.(type) {
-	case *ast.SelectorExpr:
-		return selectorX(x)
-	default:
-		return x
+	for _, ssafn := range j.Program.InitialFunctions {
+		var a *nilness.Analysis
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				sel, ok := ins.(*ssa.Select)
+				if !ok {
+					continue
+				}
+				if a == nil {
+					a = nilness.Analyze(ssafn)
+				}
+				for _, state := range sel.States {
+					if state.DebugNode == nil || a.At(sel, state.Chan) != nilness.Nil {
+						continue
+					}
+					if state.Dir == types.SendOnly {
+						j.Errorf(state.DebugNode, "this select case is unreachable because sending on a nil channel blocks forever")
+					} else {
+						j.Errorf(state.DebugNode, "this select case is unreachable because receiving from a nil channel blocks forever")
+					}
+				}
+			}
+		}
 	}
 }
 
-func (c *Checker) CheckEmptyCriticalSection(j *lint.Job) {
-	// Initially it might seem like this check would be easier to
-	// implement in SSA. After all, we're only checking for two
-	// consecutive method calls. In reality, however, there may be any
-	// number of other instructions between the lock and unlock, while
-	// still constituting an empty critical section. For example,
-	// given `m.x().Lock(); m.x().Unlock()`, there will be a call to
-	// x(). In the AST-based approach, this has a tiny potential for a
-	// false positive (the second call to x might be doing work that
-	// is protected by the mutex). In an SSA-based approach, however,
-	// it would miss a lot of real bugs.
+func isUnsafePointer(T types.Type) bool {
+	basic, ok := T.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.UnsafePointer
+}
 
-	mutexParams := func(s ast.Stmt) (x ast.Expr, funcName string, ok bool) {
-		expr, ok := s.(*ast.ExprStmt)
-		if !ok {
-			return nil, "", false
-		}
-		call, ok := expr.X.(*ast.CallExpr)
+func isUintptr(T types.Type) bool {
+	basic, ok := T.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Uintptr
+}
+
+func (c *Checker) CheckUnsafePointerConversion(j *lint.Job) {
+	// defs maps variables to the value they were declared with.
+	defs := map[types.Object]ast.Expr{}
+	isReflectPointer := func(expr ast.Expr) bool {
+		call, ok := expr.(*ast.CallExpr)
 		if !ok {
-			return nil, "", false
+			return false
 		}
-		sel, ok := call.Fun.(*ast.SelectorExpr)
+		fn, ok := calledFunc(j, call)
 		if !ok {
-			return nil, "", false
+			return false
 		}
-
-		fn, ok := j.Program.Info.ObjectOf(sel.Sel).(*types.Func)
-		if !ok {
-			return nil, "", false
+		switch fn.FullName() {
+		case "(reflect.Value).Pointer", "(reflect.Value).UnsafeAddr":
+			return true
 		}
-		sig := fn.Type().(*types.Signature)
-		if sig.Params().Len() != 0 || sig.Results().Len() != 0 {
-			return nil, "", false
+		return false
+	}
+	// origin returns the expression a uintptr is derived from: a
+	// conversion of an unsafe.Pointer, a call of
+	// reflect.Value.Pointer or UnsafeAddr, a variable holding a
+	// uintptr, or nil if it can't be determined.
+	var origin func(expr ast.Expr) ast.Expr
+	origin = func(expr ast.Expr) ast.Expr {
+		if j.Program.Info.Types[expr].Value != nil {
+			return nil
+		}
+		switch expr := expr.(type) {
+		case *ast.ParenExpr:
+			return origin(expr.X)
+		case *ast.BinaryExpr:
+			switch expr.Op {
+			case token.ADD, token.SUB, token.AND_NOT:
+				if o := origin(expr.X); o != nil {
+					return o
+				}
+				return origin(expr.Y)
+			}
+		case *ast.CallExpr:
+			if len(expr.Args) == 1 && j.Program.Info.Types[expr.Fun].IsType() &&
+				isUnsafePointer(j.Program.Info.TypeOf(expr.Args[0])) {
+				return expr
+			}
+			if isReflectPointer(expr) {
+				return expr
+			}
+		case *ast.SelectorExpr:
+			// The Data fields of reflect.SliceHeader and
+			// reflect.StringHeader may be converted, too.
+			if sel, ok := j.Program.Info.Selections[expr]; ok && sel.Kind() == types.FieldVal && expr.Sel.Name == "Data" {
+				T := sel.Recv()
+				if ptr, ok := T.(*types.Pointer); ok {
+					T = ptr.Elem()
+				}
+				switch types.TypeString(T, nil) {
+				case "reflect.SliceHeader", "reflect.StringHeader":
+					return expr
+				}
+			}
+		case *ast.Ident:
+			if obj, ok := j.Program.Info.Uses[expr].(*types.Var); ok && isUintptr(obj.Type()) {
+				return expr
+			}
 		}
-
-		return sel.X, fn.Name(), true
+		return nil
 	}
-
-	fn := func(node ast.Node) bool {
-		block, ok := node.(*ast.BlockStmt)
+	check := func(conv *ast.CallExpr) {
+		ident, ok := origin(conv.Args[0]).(*ast.Ident)
 		if !ok {
-			return true
+			return
 		}
-		if len(block.List) < 2 {
-			return true
+		def := defs[j.Program.Info.Uses[ident]]
+		if def == nil {
+			return
 		}
-		for i := range block.List[:len(block.List)-1] {
-			sel1, method1, ok1 := mutexParams(block.List[i])
-			sel2, method2, ok2 := mutexParams(block.List[i+1])
-
-			if !ok1 || !ok2 || j.Render(sel1) != j.Render(sel2) {
-				continue
+		if isReflectPointer(def) {
+			j.Errorf(conv, "the result of %s has to be converted to unsafe.Pointer in the same expression as the call; stored in %s, the object may be moved or freed before the conversion",
+				j.Render(def.(*ast.CallExpr).Fun), ident.Name)
+			return
+		}
+		if origin(def) == nil {
+			// For example the address of memory that was mapped
+			// with syscall.Mmap.
+			return
+		}
+		j.Errorf(conv, "converting %s, a uintptr stored in a variable, to unsafe.Pointer is invalid; the object may be moved or freed before the conversion, do the conversions and the arithmetic in a single expression",
+			ident.Name)
+	}
+	fn := func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			if node.Tok == token.DEFINE && len(node.Lhs) == len(node.Rhs) {
+				for i, lhs := range node.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						if obj := j.Program.Info.Defs[ident]; obj != nil {
+							defs[obj] = node.Rhs[i]
+						}
+					}
+				}
 			}
-			if (method1 == "Lock" && method2 == "Unlock") ||
-				(method1 == "RLock" && method2 == "RUnlock") {
-				j.Errorf(block.List[i+1], "empty critical section")
+		case *ast.ValueSpec:
+			if len(node.Names) == len(node.Values) {
+				for i, ident := range node.Names {
+					if obj := j.Program.Info.Defs[ident]; obj != nil {
+						defs[obj] = node.Values[i]
+					}
+				}
+			}
+		case *ast.CallExpr:
+			if len(node.Args) != 1 || !j.Program.Info.Types[node.Fun].IsType() {
+				return true
 			}
+			if !isUnsafePointer(j.Program.Info.TypeOf(node.Fun)) || !isUintptr(j.Program.Info.TypeOf(node.Args[0])) {
+				return true
+			}
+			check(node)
 		}
 		return true
 	}
@@ -1103,303 +1550,373 @@ func (c *Checker) CheckEmptyCriticalSection(j *lint.Job) {
 	}
 }
 
-func (c *Checker) CheckIneffectiveCopy(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		if unary, ok := node.(*ast.UnaryExpr); ok {
-			if _, ok := unary.X.(*ast.StarExpr); ok && unary.Op == token.AND {
-				j.Errorf(unary, "&*x will be simplified to x. It will not copy x.")
+// wrappedOSErrors maps errors that functions in package os always
+// wrap, in *PathError, *LinkError or *SyscallError, to the function
+// that unwraps them.
+var wrappedOSErrors = map[string]string{
+	"ErrExist":      "os.IsExist",
+	"ErrNotExist":   "os.IsNotExist",
+	"ErrPermission": "os.IsPermission",
+}
+
+// wrapsError reports whether v is, or may be, the result of a call to
+// fmt.Errorf that wraps another error with %w.
+func wrapsError(v ssa.Value, seen map[ssa.Value]bool) bool {
+	if seen[v] {
+		return false
+	}
+	seen[v] = true
+	switch v := v.(type) {
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			if wrapsError(edge, seen) {
+				return true
 			}
 		}
-
-		if star, ok := node.(*ast.StarExpr); ok {
-			if unary, ok := star.X.(*ast.UnaryExpr); ok && unary.Op == token.AND {
-				j.Errorf(star, "*&x will be simplified to x. It will not copy x.")
+	case *ssa.Call:
+		if !lint.IsCallTo(v.Common(), "fmt.Errorf") {
+			return false
+		}
+		format, ok := v.Common().Args[0].(*ssa.Const)
+		if !ok || format.Value == nil || format.Value.Kind() != constant.String {
+			return false
+		}
+		verbs, _ := parsePrintfVerbs(constant.StringVal(format.Value))
+		for _, verb := range verbs {
+			if verb.verb == 'w' {
+				return true
 			}
 		}
-		return true
-	}
-	for _, f := range j.Program.Files {
-		ast.Inspect(f, fn)
 	}
+	return false
 }
 
-func (c *Checker) CheckDiffSizeComparison(j *lint.Job) {
+// osError returns the function in package os that returned v, or
+// nil.
+func osError(v ssa.Value) *ssa.Function {
+	if extract, ok := v.(*ssa.Extract); ok {
+		v = extract.Tuple
+	}
+	call, ok := v.(*ssa.Call)
+	if !ok {
+		return nil
+	}
+	callee := call.Common().StaticCallee()
+	if callee == nil || callee.Pkg == nil || callee.Pkg.Pkg.Path() != "os" {
+		return nil
+	}
+	return callee
+}
+
+func (c *Checker) CheckWrappedErrorComparison(j *lint.Job) {
+	errorType := types.Universe.Lookup("error").Type()
+	sentinel := func(v ssa.Value) *ssa.Global {
+		load, ok := v.(*ssa.UnOp)
+		if !ok || load.Op != token.MUL {
+			return nil
+		}
+		global, ok := load.X.(*ssa.Global)
+		if !ok || !types.Identical(v.Type(), errorType) {
+			return nil
+		}
+		return global
+	}
 	for _, ssafn := range j.Program.InitialFunctions {
 		for _, b := range ssafn.Blocks {
 			for _, ins := range b.Instrs {
 				binop, ok := ins.(*ssa.BinOp)
-				if !ok {
+				if !ok || (binop.Op != token.EQL && binop.Op != token.NEQ) {
 					continue
 				}
-				if binop.Op != token.EQL && binop.Op != token.NEQ {
+				global, other := sentinel(binop.Y), binop.X
+				if global == nil {
+					global, other = sentinel(binop.X), binop.Y
+				}
+				if global == nil {
 					continue
 				}
-				_, ok1 := binop.X.(*ssa.Slice)
-				_, ok2 := binop.Y.(*ssa.Slice)
-				if !ok1 && !ok2 {
+				name := global.Pkg.Pkg.Name() + "." + global.Name()
+				if wrapsError(other, map[ssa.Value]bool{}) {
+					j.Errorf(binop, "comparing with %s doesn't match errors wrapped with %%w; use errors.Is instead", binop.Op)
 					continue
 				}
-				r := c.funcDescs.Get(ssafn).Ranges
-				r1, ok1 := r.Get(binop.X).(vrp.StringInterval)
-				r2, ok2 := r.Get(binop.Y).(vrp.StringInterval)
-				if !ok1 || !ok2 {
+				if global.Pkg.Pkg.Path() != "os" {
 					continue
 				}
-				if r1.Length.Intersection(r2.Length).Empty() {
-					j.Errorf(binop, "comparing strings of different sizes for equality will always return false")
+				fn, ok := wrappedOSErrors[global.Name()]
+				if !ok {
+					continue
+				}
+				if callee := osError(other); callee != nil {
+					j.Errorf(binop, "os.%s wraps %s in another error, so comparing with %s never matches; use %s instead", callee.Name(), name, binop.Op, fn)
 				}
 			}
 		}
 	}
 }
 
-func (c *Checker) CheckCanonicalHeaderKey(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		assign, ok := node.(*ast.AssignStmt)
-		if ok {
-			// TODO(dh): This risks missing some Header reads, for
-			// example in `h1["foo"] = h2["foo"]` – these edge
-			// cases are probably rare enough to ignore for now.
-			for _, expr := range assign.Lhs {
-				op, ok := expr.(*ast.IndexExpr)
+func (c *Checker) CheckUnalignedAtomic(j *lint.Job) {
+	fns := map[string]bool{}
+	for _, op := range []string{"Add", "Load", "Store", "Swap", "CompareAndSwap"} {
+		fns["sync/atomic."+op+"Int64"] = true
+		fns["sync/atomic."+op+"Uint64"] = true
+	}
+	arches := []string{"386", "arm"}
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				call, ok := ins.(*ssa.Call)
 				if !ok {
 					continue
 				}
-				if hasType(j, op.X, "net/http.Header") {
-					return false
+				name := lint.CallName(call.Common())
+				if !fns[name] {
+					continue
+				}
+				field, ok := call.Common().Args[0].(*ssa.FieldAddr)
+				if !ok {
+					continue
+				}
+				var unaligned []string
+				for _, arch := range arches {
+					sizes := gcsizes.ForArch(arch)
+					// Allocated structs are 64-bit aligned, so the
+					// field's offset in the outermost struct
+					// determines its alignment.
+					var offset int64
+					for v := ssa.Value(field); ; {
+						fa, ok := v.(*ssa.FieldAddr)
+						if !ok {
+							break
+						}
+						T := fa.X.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct)
+						var fields []*types.Var
+						for i := 0; i < T.NumFields(); i++ {
+							fields = append(fields, T.Field(i))
+						}
+						offset += sizes.Offsetsof(fields)[fa.Field]
+						v = fa.X
+					}
+					if offset%8 != 0 {
+						unaligned = append(unaligned, fmt.Sprintf("%s (offset %d)", arch, offset))
+					}
 				}
+				if len(unaligned) == 0 {
+					continue
+				}
+				T := field.X.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct)
+				j.Errorf(call, "%s on field %s, which isn't 64-bit aligned on %s; 64-bit atomic operations on 32-bit platforms require 64-bit alignment, move the field to the start of the struct",
+					strings.TrimPrefix(name, "sync/"), T.Field(field.Field).Name(), strings.Join(unaligned, " and "))
 			}
+		}
+	}
+}
+
+func (c *Checker) CheckIntToStringConversion(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
 			return true
 		}
-		op, ok := node.(*ast.IndexExpr)
-		if !ok {
+		if !j.Program.Info.Types[call.Fun].IsType() {
 			return true
 		}
-		if !hasType(j, op.X, "net/http.Header") {
+		if T, ok := j.Program.Info.TypeOf(call.Fun).Underlying().(*types.Basic); !ok || T.Kind() != types.String {
 			return true
 		}
-		s, ok := j.ExprToString(op.Index)
-		if !ok {
+		tv := j.Program.Info.Types[call.Args[0]]
+		if tv.Value != nil {
+			// Constants, such as string(65), are usually meant as
+			// runes.
 			return true
 		}
-		if s == http.CanonicalHeaderKey(s) {
+		T, ok := tv.Type.Underlying().(*types.Basic)
+		if !ok || T.Info()&types.IsInteger == 0 {
 			return true
 		}
-		j.Errorf(op, "keys in http.Header are canonicalized, %q is not canonical; fix the constant or use http.CanonicalHeaderKey", s)
+		// byte and rune are aliases of uint8 and int32, but go/types
+		// keeps their names around.
+		if T.Name() == "rune" || T.Name() == "byte" {
+			return true
+		}
+		alt := "strconv.FormatInt"
+		switch {
+		case T.Kind() == types.Int:
+			alt = "strconv.Itoa"
+		case T.Info()&types.IsUnsigned != 0:
+			alt = "strconv.FormatUint"
+		}
+		j.Errorf(call, "conversion from %s to string yields a string of one rune, not a decimal number; use %s, or convert to rune if this is intended",
+			tv.Type, alt)
 		return true
 	}
-	for _, f := range j.Program.Files {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
 
-func (c *Checker) CheckBenchmarkN(j *lint.Job) {
-	fn := func(node ast.Node) bool {
+// shadowedErrors returns, for each error variable declared in node,
+// the error variables declared with := in nested scopes that shadow
+// it.
+func shadowedErrors(j *lint.Job, node ast.Node) map[*types.Var][]*types.Var {
+	errorType := types.Universe.Lookup("error").Type()
+	shadows := map[*types.Var][]*types.Var{}
+	ast.Inspect(node, func(node ast.Node) bool {
 		assign, ok := node.(*ast.AssignStmt)
-		if !ok {
-			return true
-		}
-		if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
-			return true
-		}
-		sel, ok := assign.Lhs[0].(*ast.SelectorExpr)
-		if !ok {
-			return true
-		}
-		if sel.Sel.Name != "N" {
+		if !ok || assign.Tok != token.DEFINE {
 			return true
 		}
-		if !hasType(j, sel.X, "*testing.B") {
-			return true
+		for _, lhs := range assign.Lhs {
+			ident, ok := lhs.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			inner, ok := j.Program.Info.Defs[ident].(*types.Var)
+			if !ok || inner.Parent() == nil || !types.Identical(inner.Type(), errorType) {
+				continue
+			}
+			_, obj := inner.Parent().Parent().LookupParent(inner.Name(), inner.Pos())
+			outer, ok := obj.(*types.Var)
+			if !ok || outer.Parent() == outer.Pkg().Scope() || !types.Identical(outer.Type(), errorType) {
+				continue
+			}
+			shadows[outer] = append(shadows[outer], inner)
 		}
-		j.Errorf(assign, "should not assign to %s", j.Render(sel))
 		return true
-	}
-	for _, f := range j.Program.Files {
-		ast.Inspect(f, fn)
-	}
+	})
+	return shadows
 }
 
-func (c *Checker) CheckIneffecitiveFieldAssignments(j *lint.Job) {
+func (c *Checker) CheckShadowedError(j *lint.Job) {
 	for _, ssafn := range j.Program.InitialFunctions {
-		// fset := j.Program.SSA.Fset
-		// if fset.File(f.File.Pos()) != fset.File(ssafn.Pos()) {
-		// 	continue
-		// }
-		if ssafn.Signature.Recv() == nil {
-			continue
-		}
-
-		if len(ssafn.Blocks) == 0 {
-			// External function
-			continue
+		var body *ast.BlockStmt
+		switch node := ssafn.Syntax().(type) {
+		case *ast.FuncDecl:
+			body = node.Body
+		case *ast.FuncLit:
+			body = node.Body
 		}
-
-		reads := map[*ssa.BasicBlock]map[ssa.Value]bool{}
-		writes := map[*ssa.BasicBlock]map[ssa.Value]bool{}
-
-		recv := ssafn.Params[0]
-		if _, ok := recv.Type().Underlying().(*types.Struct); !ok {
+		if body == nil {
 			continue
 		}
-		recvPtrs := map[ssa.Value]bool{
-			recv: true,
-		}
-		if len(ssafn.Locals) == 0 || ssafn.Locals[0].Heap {
+		shadows := shadowedErrors(j, body)
+		if len(shadows) == 0 {
 			continue
 		}
-		blocks := ssafn.DomPreorder()
-		for _, block := range blocks {
-			if writes[block] == nil {
-				writes[block] = map[ssa.Value]bool{}
+		// assignments records the positions at which the shadowed
+		// variables are assigned to.
+		assigned := map[*ast.Ident]bool{}
+		assignments := map[*types.Var][]token.Pos{}
+		ast.Inspect(body, func(node ast.Node) bool {
+			if assign, ok := node.(*ast.AssignStmt); ok {
+				for _, lhs := range assign.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						assigned[ident] = true
+						if obj, ok := j.Program.Info.Uses[ident].(*types.Var); ok {
+							assignments[obj] = append(assignments[obj], ident.Pos())
+						}
+					}
+				}
 			}
-			if reads[block] == nil {
-				reads[block] = map[ssa.Value]bool{}
+			return true
+		})
+		assignedBetween := func(v *types.Var, start, end token.Pos) bool {
+			for _, pos := range assignments[v] {
+				if pos > start && pos < end {
+					return true
+				}
 			}
+			return false
+		}
 
+		// Only report the first read of each shadowed variable.
+		type report struct {
+			ident *ast.Ident
+			inner *types.Var
+		}
+		reports := map[*types.Var]report{}
+		a := nilness.Analyze(ssafn)
+		for _, block := range ssafn.Blocks {
 			for _, ins := range block.Instrs {
-				switch ins := ins.(type) {
-				case *ssa.Store:
-					if recvPtrs[ins.Val] {
-						recvPtrs[ins.Addr] = true
-					}
-					fa, ok := ins.Addr.(*ssa.FieldAddr)
-					if !ok {
-						continue
-					}
-					if !recvPtrs[fa.X] {
-						continue
-					}
-					writes[block][fa] = true
-				case *ssa.UnOp:
-					if ins.Op != token.MUL {
-						continue
-					}
-					if recvPtrs[ins.X] {
-						reads[block][ins] = true
+				ref, ok := ins.(*ssa.DebugRef)
+				if !ok || ref.IsAddr {
+					continue
+				}
+				ident, ok := ref.Expr.(*ast.Ident)
+				if !ok || assigned[ident] {
+					continue
+				}
+				outer, ok := j.Program.Info.Uses[ident].(*types.Var)
+				if !ok {
+					continue
+				}
+				for _, inner := range shadows[outer] {
+					if inner.Parent().End() > ident.Pos() || inner.Pos() < outer.Pos() ||
+						assignedBetween(outer, inner.Parent().End(), ident.Pos()) {
 						continue
 					}
-					fa, ok := ins.X.(*ssa.FieldAddr)
-					if !ok {
-						continue
+					if a.At(ref, ref.X) != nilness.Nil {
+						break
 					}
-					if !recvPtrs[fa.X] {
-						continue
+					if r, ok := reports[outer]; !ok || ident.Pos() < r.ident.Pos() {
+						reports[outer] = report{ident, inner}
 					}
-					reads[block][fa] = true
+					break
 				}
 			}
 		}
-
-		for block, writes := range writes {
-			seen := map[*ssa.BasicBlock]bool{}
-			var hasRead func(block *ssa.BasicBlock, write *ssa.FieldAddr) bool
-			hasRead = func(block *ssa.BasicBlock, write *ssa.FieldAddr) bool {
-				seen[block] = true
-				for read := range reads[block] {
-					switch ins := read.(type) {
-					case *ssa.FieldAddr:
-						if ins.Field == write.Field && read.Pos() > write.Pos() {
-							return true
-						}
-					case *ssa.UnOp:
-						if ins.Pos() >= write.Pos() {
-							return true
-						}
-					}
-				}
-				for _, succ := range block.Succs {
-					if !seen[succ] {
-						if hasRead(succ, write) {
-							return true
-						}
-					}
-				}
-				return false
-			}
-			for write := range writes {
-				fa := write.(*ssa.FieldAddr)
-				if !hasRead(block, fa) {
-					name := recv.Type().Underlying().(*types.Struct).Field(fa.Field).Name()
-					j.Errorf(fa, "ineffective assignment to field %s", name)
-				}
-			}
+		for _, r := range reports {
+			j.Errorf(r.ident, "%s is always nil here, the %s declared in an inner scope shadows it; did you mean to use = instead of :=?",
+				r.ident.Name, r.inner.Name()).
+				Relate(r.inner.Pos(), "shadowing declaration")
 		}
 	}
 }
 
-func (c *Checker) CheckUnreadVariableValues(j *lint.Job) {
+func (c *Checker) CheckTestMainExit(j *lint.Job) {
 	fn := func(node ast.Node) bool {
-		switch node.(type) {
-		case *ast.FuncDecl, *ast.FuncLit:
-		default:
+		if !isTestMain(j, node) {
 			return true
 		}
 
-		ssafn := c.nodeFns[node]
-		if ssafn == nil {
-			return true
-		}
-		if lint.IsExample(ssafn) {
-			return true
-		}
-		ast.Inspect(node, func(node ast.Node) bool {
-			assign, ok := node.(*ast.AssignStmt)
+		arg := j.Program.Info.ObjectOf(node.(*ast.FuncDecl).Type.Params.List[0].Names[0])
+		callsRun := false
+		fn2 := func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
 			if !ok {
 				return true
 			}
-			if len(assign.Lhs) > 1 && len(assign.Rhs) == 1 {
-				// Either a function call with multiple return values,
-				// or a comma-ok assignment
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			ident, ok := sel.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if arg != j.Program.Info.ObjectOf(ident) {
+				return true
+			}
+			if sel.Sel.Name == "Run" {
+				callsRun = true
+				return false
+			}
+			return true
+		}
+		ast.Inspect(node.(*ast.FuncDecl).Body, fn2)
 
-				val, _ := ssafn.ValueForExpr(assign.Rhs[0])
-				if val == nil {
-					return true
-				}
-				refs := val.Referrers()
-				if refs == nil {
-					return true
-				}
-				for _, ref := range *refs {
-					ex, ok := ref.(*ssa.Extract)
-					if !ok {
-						continue
-					}
-					exrefs := ex.Referrers()
-					if exrefs == nil {
-						continue
-					}
-					if len(lint.FilterDebug(*exrefs)) == 0 {
-						lhs := assign.Lhs[ex.Index]
-						if ident, ok := lhs.(*ast.Ident); !ok || ok && ident.Name == "_" {
-							continue
-						}
-						j.Errorf(lhs, "this value of %s is never used", lhs)
-					}
-				}
-				return true
-			}
-			for i, lhs := range assign.Lhs {
-				rhs := assign.Rhs[i]
-				if ident, ok := lhs.(*ast.Ident); !ok || ok && ident.Name == "_" {
-					continue
-				}
-				val, _ := ssafn.ValueForExpr(rhs)
-				if val == nil {
-					continue
-				}
-
-				refs := val.Referrers()
-				if refs == nil {
-					// TODO investigate why refs can be nil
-					return true
-				}
-				if len(lint.FilterDebug(*refs)) == 0 {
-					j.Errorf(lhs, "this value of %s is never used", lhs)
-				}
+		callsExit := false
+		fn3 := func(node ast.Node) bool {
+			if j.IsFunctionCallName(node, "os.Exit") {
+				callsExit = true
+				return false
 			}
 			return true
-		})
+		}
+		ast.Inspect(node.(*ast.FuncDecl).Body, fn3)
+		if !callsExit && callsRun {
+			j.Errorf(node, "TestMain should call os.Exit to set exit code")
+		}
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -1407,95 +1924,42 @@ func (c *Checker) CheckUnreadVariableValues(j *lint.Job) {
 	}
 }
 
-func (c *Checker) CheckPredeterminedBooleanExprs(j *lint.Job) {
-	for _, ssafn := range j.Program.InitialFunctions {
-		for _, block := range ssafn.Blocks {
-			for _, ins := range block.Instrs {
-				ssabinop, ok := ins.(*ssa.BinOp)
-				if !ok {
-					continue
-				}
-				switch ssabinop.Op {
-				case token.GTR, token.LSS, token.EQL, token.NEQ, token.LEQ, token.GEQ:
-				default:
-					continue
-				}
-
-				xs, ok1 := consts(ssabinop.X, nil, nil)
-				ys, ok2 := consts(ssabinop.Y, nil, nil)
-				if !ok1 || !ok2 || len(xs) == 0 || len(ys) == 0 {
-					continue
-				}
-
-				trues := 0
-				for _, x := range xs {
-					for _, y := range ys {
-						if x.Value == nil {
-							if y.Value == nil {
-								trues++
-							}
-							continue
-						}
-						if constant.Compare(x.Value, ssabinop.Op, y.Value) {
-							trues++
-						}
-					}
-				}
-				b := trues != 0
-				if trues == 0 || trues == len(xs)*len(ys) {
-					j.Errorf(ssabinop, "binary expression is always %t for all possible values (%s %s %s)",
-						b, xs, ssabinop.Op, ys)
-				}
-			}
-		}
+func isTestMain(j *lint.Job, node ast.Node) bool {
+	decl, ok := node.(*ast.FuncDecl)
+	if !ok {
+		return false
 	}
-}
-
-func (c *Checker) CheckNilMaps(j *lint.Job) {
-	for _, ssafn := range j.Program.InitialFunctions {
-		for _, block := range ssafn.Blocks {
-			for _, ins := range block.Instrs {
-				mu, ok := ins.(*ssa.MapUpdate)
-				if !ok {
-					continue
-				}
-				c, ok := mu.Map.(*ssa.Const)
-				if !ok {
-					continue
-				}
-				if c.Value != nil {
-					continue
-				}
-				j.Errorf(mu, "assignment to nil map")
-			}
-		}
+	if decl.Name.Name != "TestMain" {
+		return false
+	}
+	if len(decl.Type.Params.List) != 1 {
+		return false
+	}
+	arg := decl.Type.Params.List[0]
+	if len(arg.Names) != 1 {
+		return false
 	}
+	typ := j.Program.Info.TypeOf(arg.Type)
+	return typ != nil && typ.String() == "*testing.M"
 }
 
-func (c *Checker) CheckUnsignedComparison(j *lint.Job) {
+func (c *Checker) CheckExec(j *lint.Job) {
 	fn := func(node ast.Node) bool {
-		expr, ok := node.(*ast.BinaryExpr)
+		call, ok := node.(*ast.CallExpr)
 		if !ok {
 			return true
 		}
-		tx := j.Program.Info.TypeOf(expr.X)
-		basic, ok := tx.Underlying().(*types.Basic)
-		if !ok {
+		if !j.IsFunctionCallName(call, "os/exec.Command") {
 			return true
 		}
-		if (basic.Info() & types.IsUnsigned) == 0 {
+		val, ok := j.ExprToString(call.Args[0])
+		if !ok {
 			return true
 		}
-		lit, ok := expr.Y.(*ast.BasicLit)
-		if !ok || lit.Value != "0" {
+		if !strings.Contains(val, " ") || strings.Contains(val, `\`) {
 			return true
 		}
-		switch expr.Op {
-		case token.GEQ:
-			j.Errorf(expr, "unsigned values are always >= 0")
-		case token.LSS:
-			j.Errorf(expr, "unsigned values are never < 0")
-		}
+		j.Errorf(call.Args[0], "first argument to exec.Command looks like a shell command, but a program name or path are expected")
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -1503,364 +1967,649 @@ func (c *Checker) CheckUnsignedComparison(j *lint.Job) {
 	}
 }
 
-func consts(val ssa.Value, out []*ssa.Const, visitedPhis map[string]bool) ([]*ssa.Const, bool) {
-	if visitedPhis == nil {
-		visitedPhis = map[string]bool{}
-	}
-	var ok bool
-	switch val := val.(type) {
-	case *ssa.Phi:
-		if visitedPhis[val.Name()] {
-			break
+// taintAnalyses returns the taint analyses of all functions that
+// call at least one sink.
+func (c *Checker) taintAnalyses(j *lint.Job) []*taint.Analysis {
+	c.taintOnce.Do(func() {
+		sinks := map[string]bool{}
+		for _, sink := range c.Taint.Sinks {
+			sinks[sink.Func] = true
+		}
+		for _, ssafn := range j.Program.InitialFunctions {
+			if callsAny(ssafn, sinks) {
+				c.taint = append(c.taint, taint.Analyze(ssafn, c.Taint))
+			}
 		}
-		visitedPhis[val.Name()] = true
-		vals := val.Operands(nil)
-		for _, phival := range vals {
-			out, ok = consts(*phival, out, visitedPhis)
+	})
+	return c.taint
+}
+
+func callsAny(fn *ssa.Function, names map[string]bool) bool {
+	for _, block := range fn.Blocks {
+		for _, ins := range block.Instrs {
+			call, ok := ins.(ssa.CallInstruction)
 			if !ok {
-				return nil, false
+				continue
+			}
+			if call.Common().IsInvoke() {
+				if names[call.Common().Method.FullName()] {
+					return true
+				}
+				continue
+			}
+			if names[lint.CallName(call.Common())] {
+				return true
 			}
 		}
-	case *ssa.Const:
-		out = append(out, val)
-	case *ssa.Convert:
-		out, ok = consts(val.X, out, visitedPhis)
+	}
+	return false
+}
+
+var shells = map[string]bool{
+	"sh":   true,
+	"bash": true,
+	"zsh":  true,
+	"dash": true,
+	"ksh":  true,
+	"csh":  true,
+	"fish": true,
+}
+
+// variadicArgs returns the values stored in the slice that was
+// implicitly created for the variadic arguments of a call. It returns
+// nil if v isn't such a slice.
+func variadicArgs(v ssa.Value) []ssa.Value {
+	slice, ok := v.(*ssa.Slice)
+	if !ok {
+		return nil
+	}
+	alloc, ok := slice.X.(*ssa.Alloc)
+	if !ok {
+		return nil
+	}
+	arr, ok := deref(alloc.Type()).Underlying().(*types.Array)
+	if !ok {
+		return nil
+	}
+	out := make([]ssa.Value, arr.Len())
+	for _, ref := range *alloc.Referrers() {
+		ia, ok := ref.(*ssa.IndexAddr)
 		if !ok {
-			return nil, false
+			continue
+		}
+		idx, ok := ia.Index.(*ssa.Const)
+		if !ok {
+			return nil
+		}
+		i := idx.Int64()
+		for _, ref := range *ia.Referrers() {
+			if store, ok := ref.(*ssa.Store); ok && i < int64(len(out)) {
+				out[i] = store.Val
+			}
 		}
-	default:
-		return nil, false
 	}
-	if len(out) < 2 {
-		return out, true
+	return out
+}
+
+// shellScript returns the argument of a call to exec.Command that
+// holds the script that the shell should execute, or nil if the
+// command doesn't invoke a shell with -c.
+func shellScript(call *ssa.CallCommon) ssa.Value {
+	name, ok := call.Args[0].(*ssa.Const)
+	if !ok || name.Value == nil || name.Value.Kind() != constant.String {
+		return nil
 	}
-	uniq := []*ssa.Const{out[0]}
-	for _, val := range out[1:] {
-		if val.Value == uniq[len(uniq)-1].Value {
+	if !shells[filepath.Base(constant.StringVal(name.Value))] {
+		return nil
+	}
+	args := variadicArgs(call.Args[1])
+	for i, arg := range args {
+		k, ok := arg.(*ssa.Const)
+		if !ok || k.Value == nil || k.Value.Kind() != constant.String {
 			continue
 		}
-		uniq = append(uniq, val)
+		if constant.StringVal(k.Value) == "-c" && i+1 < len(args) {
+			return args[i+1]
+		}
 	}
-	return uniq, true
+	return nil
 }
 
-func (c *Checker) CheckLoopCondition(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		loop, ok := node.(*ast.ForStmt)
-		if !ok {
-			return true
-		}
-		if loop.Init == nil || loop.Cond == nil || loop.Post == nil {
-			return true
+func (c *Checker) CheckCommandInjection(j *lint.Job) {
+	for _, a := range c.taintAnalyses(j) {
+		for _, flow := range a.Flows {
+			if flow.Sink.Kind != "exec" {
+				continue
+			}
+			common := flow.Call.Common()
+			switch flow.Arg {
+			case 0:
+				j.Errorf(flow.Call, "the program executed by %s is controlled by untrusted input", qualifiedCallName(common))
+			case 1:
+				if !lint.IsCallTo(common, "os/exec.Command") {
+					continue
+				}
+				if script := shellScript(common); script != nil && a.Tainted(script) {
+					j.Errorf(flow.Call, "the shell command executed by %s is constructed from untrusted input; pass arguments to the program directly instead", qualifiedCallName(common))
+				}
+			}
 		}
-		init, ok := loop.Init.(*ast.AssignStmt)
-		if !ok || len(init.Lhs) != 1 || len(init.Rhs) != 1 {
-			return true
+	}
+}
+
+func (c *Checker) CheckSQLInjection(j *lint.Job) {
+	for _, a := range c.taintAnalyses(j) {
+		for _, flow := range a.Flows {
+			if flow.Sink.Kind != "sql" {
+				continue
+			}
+			j.Errorf(flow.Call, "SQL query passed to %s is constructed from untrusted input; use query parameters instead", qualifiedCallName(flow.Call.Common()))
 		}
-		cond, ok := loop.Cond.(*ast.BinaryExpr)
-		if !ok {
-			return true
+	}
+}
+
+func (c *Checker) CheckTemplateInjection(j *lint.Job) {
+	for _, a := range c.taintAnalyses(j) {
+		for _, flow := range a.Flows {
+			common := flow.Call.Common()
+			switch flow.Sink.Kind {
+			case "template":
+				j.Errorf(flow.Call, "template text passed to %s is constructed from untrusted input, which allows injecting arbitrary template actions", qualifiedCallName(common))
+			case "template-data":
+				if !isHTTPResponse(common.Args[1]) {
+					continue
+				}
+				j.Errorf(flow.Call, "%s writes untrusted input to an HTTP response without escaping it; use html/template instead", qualifiedCallName(common))
+			}
 		}
-		x, ok := cond.X.(*ast.Ident)
-		if !ok {
-			return true
+	}
+}
+
+// isHTTPResponse reports whether w is an http.ResponseWriter, possibly
+// converted to a different interface.
+func isHTTPResponse(w ssa.Value) bool {
+	for {
+		switch v := w.(type) {
+		case *ssa.ChangeInterface:
+			w = v.X
+		case *ssa.MakeInterface:
+			w = v.X
+		default:
+			return types.TypeString(w.Type(), nil) == "net/http.ResponseWriter"
 		}
-		lhs, ok := init.Lhs[0].(*ast.Ident)
-		if !ok {
-			return true
+	}
+}
+
+func (c *Checker) CheckLogForging(j *lint.Job) {
+	reported := map[ssa.CallInstruction]bool{}
+	for _, a := range c.taintAnalyses(j) {
+		for _, flow := range a.Flows {
+			if flow.Sink.Kind != "log" || reported[flow.Call] {
+				continue
+			}
+			common := flow.Call.Common()
+			name := lint.CallName(common)
+			if _, ok := printfFuncs[name]; ok && flow.Arg == len(common.Args)-1 {
+				// Arguments that are formatted with %q can't
+				// contain newlines.
+				format, ok := common.Args[flow.Arg-1].(*ssa.Const)
+				if ok && format.Value != nil && format.Value.Kind() == constant.String {
+					if verbs, ok := parsePrintfVerbs(constant.StringVal(format.Value)); ok && quotesTainted(a, verbs, variadicArgs(common.Args[flow.Arg])) {
+						continue
+					}
+				}
+			}
+			reported[flow.Call] = true
+			j.Errorf(flow.Call, "%s logs untrusted input, which may contain newlines that forge log entries; quote it with %%q or strconv.Quote", qualifiedCallName(common))
 		}
-		if x.Obj != lhs.Obj {
-			return true
+	}
+}
+
+// quotesTainted reports whether all tainted arguments are formatted
+// with the %q verb.
+func quotesTainted(a *taint.Analysis, verbs []printfVerb, args []ssa.Value) bool {
+	if args == nil {
+		return false
+	}
+	for i, arg := range args {
+		if arg == nil || !a.Tainted(arg) {
+			continue
 		}
-		if _, ok := loop.Post.(*ast.IncDecStmt); !ok {
-			return true
+		for _, verb := range verbs {
+			if verb.arg == i && verb.verb != 'q' {
+				return false
+			}
 		}
+	}
+	return true
+}
 
-		ssafn := c.nodeFns[cond]
-		if ssafn == nil {
+// securityName reports whether name, the name of a variable or
+// function, suggests that it holds or produces secret data.
+func securityName(name string, words ...string) bool {
+	name = strings.ToLower(name)
+	for _, word := range words {
+		if strings.Contains(name, word) {
 			return true
 		}
-		v, isAddr := ssafn.ValueForExpr(cond.X)
-		if v == nil || isAddr {
+	}
+	return false
+}
+
+// mentionsName reports whether expr refers to a variable, field or
+// function whose name contains one of words.
+func mentionsName(expr ast.Expr, words ...string) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && securityName(ident.Name, words...) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+var weakHashes = map[string]string{
+	"MD4":     "MD4",
+	"MD5":     "MD5",
+	"MD5SHA1": "MD5",
+	"SHA1":    "SHA-1",
+}
+
+func (c *Checker) CheckWeakCrypto(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
 			return true
 		}
-		switch v := v.(type) {
-		case *ssa.Phi:
-			ops := v.Operands(nil)
-			if len(ops) != 2 {
-				return true
+		switch {
+		case j.IsFunctionCallNameAny(call, "crypto/des.NewCipher", "crypto/des.NewTripleDESCipher"):
+			j.Errorf(call, "DES is broken and must not be used to protect data; use AES instead")
+		case j.IsFunctionCallName(call, "crypto/rc4.NewCipher"):
+			j.Errorf(call, "RC4 is broken and must not be used to protect data; use AES-GCM or ChaCha20-Poly1305 instead")
+		case j.IsFunctionCallNameAny(call, "crypto/md5.Sum", "crypto/sha1.Sum"):
+			if len(call.Args) == 1 && mentionsName(call.Args[0], "passw", "secret", "token", "credential") {
+				j.Errorf(call, "%s is not suitable for hashing secrets; use a password hashing function such as bcrypt or scrypt", j.Render(call.Fun))
 			}
-			_, ok := (*ops[0]).(*ssa.Const)
-			if !ok {
+		case j.IsFunctionCallNameAny(call,
+			"crypto/rsa.SignPKCS1v15", "crypto/rsa.SignPSS",
+			"crypto/rsa.VerifyPKCS1v15", "crypto/rsa.VerifyPSS"):
+			// The hash is the third argument when signing and the
+			// second when verifying.
+			idx := 2
+			if j.IsFunctionCallNameAny(call, "crypto/rsa.VerifyPKCS1v15", "crypto/rsa.VerifyPSS") {
+				idx = 1
+			}
+			if len(call.Args) <= idx {
 				return true
 			}
-			sigma, ok := (*ops[1]).(*ssa.Sigma)
+			sel, ok := call.Args[idx].(*ast.SelectorExpr)
 			if !ok {
 				return true
 			}
-			if sigma.X != v {
+			obj, ok := j.Program.Info.ObjectOf(sel.Sel).(*types.Const)
+			if !ok || obj.Pkg() == nil || obj.Pkg().Path() != "crypto" {
 				return true
 			}
-		case *ssa.UnOp:
-			return true
+			if name, ok := weakHashes[obj.Name()]; ok {
+				j.Errorf(sel, "%s is not collision resistant and must not be used for signatures", name)
+			}
 		}
-		j.Errorf(cond, "variable in loop condition never changes")
-
 		return true
 	}
-	for _, f := range j.Program.Files {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
 
-func (c *Checker) CheckArgOverwritten(j *lint.Job) {
+func (c *Checker) CheckInsecureRandom(j *lint.Job) {
+	isMathRand := func(call *ast.CallExpr) bool {
+		fn, ok := calledFunc(j, call)
+		return ok && fn.Pkg() != nil && fn.Pkg().Path() == "math/rand"
+	}
 	fn := func(node ast.Node) bool {
-		var typ *ast.FuncType
 		var body *ast.BlockStmt
-		switch fn := node.(type) {
+		var name string
+		switch node := node.(type) {
 		case *ast.FuncDecl:
-			typ = fn.Type
-			body = fn.Body
-		case *ast.FuncLit:
-			typ = fn.Type
-			body = fn.Body
-		}
-		if body == nil {
+			body, name = node.Body, node.Name.Name
+		case *ast.CallExpr:
+			if !j.IsFunctionCallNameAny(node, "math/rand.Read", "(*math/rand.Rand).Read") || len(node.Args) != 1 {
+				return true
+			}
+			if mentionsName(node.Args[0], "key", "token", "secret", "nonce", "salt", "passw") {
+				j.Errorf(node, "math/rand is predictable and must not be used to generate %s; use crypto/rand instead", j.Render(node.Args[0]))
+			}
 			return true
-		}
-		ssafn := c.nodeFns[node]
-		if ssafn == nil {
+		default:
 			return true
 		}
-		if len(typ.Params.List) == 0 {
+		if body == nil || !securityName(name, "token", "secret", "passw", "salt", "nonce") {
 			return true
 		}
-		for _, field := range typ.Params.List {
-			for _, arg := range field.Names {
-				obj := j.Program.Info.ObjectOf(arg)
-				var ssaobj *ssa.Parameter
-				for _, param := range ssafn.Params {
-					if param.Object() == obj {
-						ssaobj = param
-						break
-					}
-				}
-				if ssaobj == nil {
-					continue
-				}
-				refs := ssaobj.Referrers()
-				if refs == nil {
-					continue
-				}
-				if len(lint.FilterDebug(*refs)) != 0 {
-					continue
-				}
-
-				assigned := false
-				ast.Inspect(body, func(node ast.Node) bool {
-					assign, ok := node.(*ast.AssignStmt)
-					if !ok {
-						return true
-					}
-					for _, lhs := range assign.Lhs {
-						ident, ok := lhs.(*ast.Ident)
-						if !ok {
-							continue
-						}
-						if j.Program.Info.ObjectOf(ident) == obj {
-							assigned = true
-							return false
-						}
-					}
-					return true
-				})
-				if assigned {
-					j.Errorf(arg, "argument %s is overwritten before first use", arg)
-				}
+		ast.Inspect(body, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || !isMathRand(call) {
+				return true
 			}
-		}
+			if j.IsFunctionCallNameAny(call, "math/rand.Read", "(*math/rand.Rand).Read") {
+				// Reported on its own if the buffer's name gives it
+				// away.
+				return true
+			}
+			j.Errorf(call, "%s uses math/rand, which is predictable; use crypto/rand to generate secrets", name)
+			return false
+		})
 		return true
 	}
-	for _, f := range j.Program.Files {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
 
-func (c *Checker) CheckIneffectiveLoop(j *lint.Job) {
-	// This check detects some, but not all unconditional loop exits.
-	// We give up in the following cases:
-	//
-	// - a goto anywhere in the loop. The goto might skip over our
-	// return, and we don't check that it doesn't.
-	//
-	// - any nested, unlabelled continue, even if it is in another
-	// loop or closure.
-	fn := func(node ast.Node) bool {
-		var body *ast.BlockStmt
-		switch fn := node.(type) {
-		case *ast.FuncDecl:
-			body = fn.Body
-		case *ast.FuncLit:
-			body = fn.Body
-		default:
-			return true
-		}
-		if body == nil {
-			return true
+func (c *Checker) CheckInsecureTLSConfig(j *lint.Job) {
+	check := func(field string, value ast.Expr) {
+		switch field {
+		case "InsecureSkipVerify":
+			if j.IsBoolConst(value) && j.BoolConst(value) {
+				j.Errorf(value, "InsecureSkipVerify disables verification of the server's certificate chain and host name, which allows man-in-the-middle attacks")
+			}
+		case "MinVersion":
+			if v, ok := j.ExprToInt(value); ok && v < tls.VersionTLS12 {
+				j.Errorf(value, "MinVersion allows TLS versions older than TLS 1.2, which have known weaknesses")
+			}
 		}
-		labels := map[*ast.Object]ast.Stmt{}
-		ast.Inspect(body, func(node ast.Node) bool {
-			label, ok := node.(*ast.LabeledStmt)
-			if !ok {
+	}
+	fn := func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.CompositeLit:
+			if !hasType(j, node, "crypto/tls.Config") {
 				return true
 			}
-			labels[label.Label.Obj] = label.Stmt
-			return true
-		})
-
-		ast.Inspect(body, func(node ast.Node) bool {
-			var loop ast.Node
-			var body *ast.BlockStmt
-			switch node := node.(type) {
-			case *ast.ForStmt:
-				body = node.Body
-				loop = node
-			case *ast.RangeStmt:
-				typ := j.Program.Info.TypeOf(node.X)
-				if _, ok := typ.Underlying().(*types.Map); ok {
-					// looping once over a map is a valid pattern for
-					// getting an arbitrary element.
-					return true
+			for _, elt := range node.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				if key, ok := kv.Key.(*ast.Ident); ok {
+					check(key.Name, kv.Value)
 				}
-				body = node.Body
-				loop = node
-			default:
-				return true
 			}
-			if len(body.List) < 2 {
-				// avoid flagging the somewhat common pattern of using
-				// a range loop to get the first element in a slice,
-				// or the first rune in a string.
+		case *ast.AssignStmt:
+			if len(node.Lhs) != len(node.Rhs) {
 				return true
 			}
-			var unconditionalExit ast.Node
-			hasBranching := false
-			for _, stmt := range body.List {
-				switch stmt := stmt.(type) {
-				case *ast.BranchStmt:
-					switch stmt.Tok {
-					case token.BREAK:
-						if stmt.Label == nil || labels[stmt.Label.Obj] == loop {
-							unconditionalExit = stmt
-						}
-					case token.CONTINUE:
-						if stmt.Label == nil || labels[stmt.Label.Obj] == loop {
-							unconditionalExit = nil
-							return false
-						}
-					}
-				case *ast.ReturnStmt:
-					unconditionalExit = stmt
-				case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.SelectStmt:
-					hasBranching = true
+			for i, lhs := range node.Lhs {
+				sel, ok := lhs.(*ast.SelectorExpr)
+				if !ok {
+					continue
 				}
-			}
-			if unconditionalExit == nil || !hasBranching {
-				return false
-			}
-			ast.Inspect(body, func(node ast.Node) bool {
-				if branch, ok := node.(*ast.BranchStmt); ok {
-
-					switch branch.Tok {
-					case token.GOTO:
-						unconditionalExit = nil
-						return false
-					case token.CONTINUE:
-						if branch.Label != nil && labels[branch.Label.Obj] != loop {
-							return true
-						}
-						unconditionalExit = nil
-						return false
-					}
+				if hasType(j, sel.X, "*crypto/tls.Config") || hasType(j, sel.X, "crypto/tls.Config") {
+					check(sel.Sel.Name, node.Rhs[i])
 				}
-				return true
-			})
-			if unconditionalExit != nil {
-				j.Errorf(unconditionalExit, "the surrounding loop is unconditionally terminated")
 			}
-			return true
-		})
+		}
 		return true
 	}
-	for _, f := range j.Program.Files {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		if j.IsInTest(f) {
+			// Tests commonly talk to servers with self-signed
+			// certificates.
+			continue
+		}
 		ast.Inspect(f, fn)
 	}
 }
 
-func (c *Checker) CheckNilContext(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
-			return true
-		}
-		if len(call.Args) == 0 {
-			return true
-		}
-		if typ, ok := j.Program.Info.TypeOf(call.Args[0]).(*types.Basic); !ok || typ.Kind() != types.UntypedNil {
-			return true
-		}
-		sig, ok := j.Program.Info.TypeOf(call.Fun).(*types.Signature)
+// nonceArgs are the functions and methods that take a nonce or IV,
+// mapped to the index of that argument. Indices of interface methods
+// don't count the receiver.
+var nonceArgs = map[string]int{
+	"(crypto/cipher.AEAD).Seal":     1,
+	"crypto/cipher.NewCBCEncrypter": 1,
+	"crypto/cipher.NewCFBEncrypter": 1,
+	"crypto/cipher.NewCTR":          1,
+	"crypto/cipher.NewOFB":          1,
+}
+
+// isStaticBuffer reports whether the byte slice v has contents that
+// are fixed at compile time: it is converted from a constant string,
+// or it is a newly allocated buffer that is only ever written
+// constants to and is used for nothing but nonces.
+func isStaticBuffer(v ssa.Value) bool {
+	var base ssa.Value
+	switch v := v.(type) {
+	case *ssa.Convert:
+		_, ok := v.X.(*ssa.Const)
+		return ok
+	case *ssa.MakeSlice:
+		base = v
+	case *ssa.Slice:
+		alloc, ok := v.X.(*ssa.Alloc)
 		if !ok {
-			return true
+			return false
 		}
-		if sig.Params().Len() == 0 {
+		base = alloc
+	default:
+		return false
+	}
+	var static func(v ssa.Value) bool
+	static = func(v ssa.Value) bool {
+		refs := v.Referrers()
+		if refs == nil {
 			return true
 		}
-		if types.TypeString(sig.Params().At(0).Type(), nil) != "context.Context" {
-			return true
+		for _, ref := range *refs {
+			switch ref := ref.(type) {
+			case *ssa.DebugRef:
+			case *ssa.Slice:
+				if !static(ref) {
+					return false
+				}
+			case *ssa.Sigma:
+				if !static(ref) {
+					return false
+				}
+			case *ssa.IndexAddr:
+				for _, ref := range *ref.Referrers() {
+					switch ref := ref.(type) {
+					case *ssa.Store:
+						if _, ok := ref.Val.(*ssa.Const); !ok {
+							return false
+						}
+					case *ssa.UnOp, *ssa.DebugRef:
+					default:
+						return false
+					}
+				}
+			case *ssa.Call:
+				if b, ok := ref.Call.Value.(*ssa.Builtin); ok && (b.Name() == "len" || b.Name() == "cap") {
+					continue
+				}
+				idx, ok := nonceArgs[qualifiedName(ref.Common())]
+				if !ok || idx >= len(ref.Call.Args) || ref.Call.Args[idx] != v {
+					return false
+				}
+			default:
+				return false
+			}
 		}
-		j.Errorf(call.Args[0],
-			"do not pass a nil Context, even if a function permits it; pass context.TODO if you are unsure about which Context to use")
 		return true
 	}
-	for _, f := range j.Program.Files {
-		ast.Inspect(f, fn)
+	return static(base)
+}
+
+// qualifiedName returns the full name of the function or interface
+// method that call calls.
+func qualifiedName(call *ssa.CallCommon) string {
+	if call.IsInvoke() {
+		return call.Method.FullName()
 	}
+	return lint.CallName(call)
 }
 
-func (c *Checker) CheckSeeker(j *lint.Job) {
-	fn := func(node ast.Node) bool {
-		call, ok := node.(*ast.CallExpr)
-		if !ok {
+func (c *Checker) CheckStaticNonce(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, b := range ssafn.Blocks {
+			for _, ins := range b.Instrs {
+				call, ok := ins.(*ssa.Call)
+				if !ok {
+					continue
+				}
+				name := qualifiedName(call.Common())
+				idx, ok := nonceArgs[name]
+				if !ok {
+					continue
+				}
+				if idx >= len(call.Common().Args) || !isStaticBuffer(call.Common().Args[idx]) {
+					continue
+				}
+				what := "an IV"
+				if call.Common().IsInvoke() {
+					what = "a nonce"
+				}
+				j.Errorf(call, "%s is called with %s that doesn't change between calls; reusing it with the same key breaks the encryption's confidentiality", qualifiedCallName(call.Common()), what)
+			}
+		}
+	}
+}
+
+// interpolated reports whether the string v is built by combining
+// constant strings with other data, for example with + or
+// fmt.Sprintf. Numbers and the results of sanitizers can't inject
+// anything and don't count.
+func (c *Checker) interpolated(v ssa.Value) bool {
+	safe := func(v ssa.Value) bool {
+		if iface, ok := v.(*ssa.MakeInterface); ok {
+			v = iface.X
+		}
+		if sigma, ok := v.(*ssa.Sigma); ok {
+			v = sigma.Value()
+		}
+		switch v := v.(type) {
+		case nil, *ssa.Const:
 			return true
+		case *ssa.Call:
+			if c.Taint.IsSanitizer(v.Common()) {
+				return true
+			}
 		}
-		sel, ok := call.Fun.(*ast.SelectorExpr)
-		if !ok {
+		basic, ok := v.Type().Underlying().(*types.Basic)
+		return ok && basic.Info()&(types.IsNumeric|types.IsBoolean) != 0
+	}
+	var parts []ssa.Value
+	switch v := v.(type) {
+	case *ssa.BinOp:
+		if v.Op != token.ADD {
+			return false
+		}
+		if c.interpolated(v.X) || c.interpolated(v.Y) {
 			return true
 		}
-		if sel.Sel.Name != "Seek" {
+		parts = []ssa.Value{v.X, v.Y}
+	case *ssa.Call:
+		switch lint.CallName(v.Common()) {
+		case "fmt.Sprintf", "fmt.Sprint", "fmt.Sprintln":
+			parts = variadicArgs(v.Common().Args[len(v.Common().Args)-1])
+		case "strings.Join":
 			return true
 		}
-		if len(call.Args) != 2 {
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			if c.interpolated(edge) {
+				return true
+			}
+		}
+	case *ssa.Sigma:
+		return c.interpolated(v.X)
+	}
+	for _, part := range parts {
+		if _, ok := part.(*ssa.BinOp); ok {
+			// Nested concatenations have already been checked.
+			continue
+		}
+		if !safe(part) {
 			return true
 		}
-		arg0, ok := call.Args[0].(*ast.SelectorExpr)
-		if !ok {
+	}
+	return false
+}
+
+func (c *Checker) CheckInterpolatedShellCommand(j *lint.Job) {
+	// Untrusted scripts are flagged by CheckCommandInjection.
+	tainted := map[*ssa.Function]*taint.Analysis{}
+	for _, a := range c.taintAnalyses(j) {
+		tainted[a.Fn] = a
+	}
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				call, ok := ins.(*ssa.Call)
+				if !ok || !lint.IsCallTo(call.Common(), "os/exec.Command") {
+					continue
+				}
+				script := shellScript(call.Common())
+				if script == nil || !c.interpolated(script) {
+					continue
+				}
+				if a := tainted[ssafn]; a != nil && a.Tainted(script) {
+					continue
+				}
+				j.Errorf(call, "the shell command executed by %s is constructed from non-constant data, which may inject additional commands; pass arguments to the program directly instead",
+					qualifiedCallName(call.Common()))
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckLoopEmptyDefault(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		loop, ok := node.(*ast.ForStmt)
+		if !ok || len(loop.Body.List) != 1 || loop.Cond != nil || loop.Init != nil {
 			return true
 		}
-		switch arg0.Sel.Name {
-		case "SeekStart", "SeekCurrent", "SeekEnd":
-		default:
+		sel, ok := loop.Body.List[0].(*ast.SelectStmt)
+		if !ok {
 			return true
 		}
-		pkg, ok := arg0.X.(*ast.Ident)
+		for _, c := range sel.Body.List {
+			if comm, ok := c.(*ast.CommClause); ok && comm.Comm == nil && len(comm.Body) == 0 {
+				j.Errorf(comm, "should not have an empty default case in a for+select loop. The loop will spin.")
+			}
+		}
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckLhsRhsIdentical(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		op, ok := node.(*ast.BinaryExpr)
 		if !ok {
 			return true
 		}
-		if pkg.Name != "io" {
+		switch op.Op {
+		case token.EQL, token.NEQ:
+			if basic, ok := j.Program.Info.TypeOf(op.X).(*types.Basic); ok {
+				if kind := basic.Kind(); kind == types.Float32 || kind == types.Float64 {
+					// f == f and f != f might be used to check for NaN
+					return true
+				}
+			}
+		case token.SUB, token.QUO, token.AND, token.REM, token.OR, token.XOR, token.AND_NOT,
+			token.LAND, token.LOR, token.LSS, token.GTR, token.LEQ, token.GEQ:
+		default:
+			// For some ops, such as + and *, it can make sense to
+			// have identical operands
 			return true
 		}
-		j.Errorf(call, "the first argument of io.Seeker is the offset, but an io.Seek* constant is being used instead")
+
+		if j.Render(op.X) != j.Render(op.Y) {
+			return true
+		}
+		j.Errorf(op, "identical expressions on the left and right side of the '%s' operator", op.Op)
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -1868,213 +2617,3477 @@ func (c *Checker) CheckSeeker(j *lint.Job) {
 	}
 }
 
-func (c *Checker) CheckConcurrentTesting(j *lint.Job) {
-	for _, ssafn := range j.Program.InitialFunctions {
-		for _, block := range ssafn.Blocks {
-			for _, ins := range block.Instrs {
-				gostmt, ok := ins.(*ssa.Go)
-				if !ok {
-					continue
+func (c *Checker) CheckScopedBreak(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		var body *ast.BlockStmt
+		switch node := node.(type) {
+		case *ast.ForStmt:
+			body = node.Body
+		case *ast.RangeStmt:
+			body = node.Body
+		default:
+			return true
+		}
+		for _, stmt := range body.List {
+			var blocks [][]ast.Stmt
+			switch stmt := stmt.(type) {
+			case *ast.SwitchStmt:
+				for _, c := range stmt.Body.List {
+					blocks = append(blocks, c.(*ast.CaseClause).Body)
 				}
-				var fn *ssa.Function
-				switch val := gostmt.Call.Value.(type) {
-				case *ssa.Function:
-					fn = val
-				case *ssa.MakeClosure:
-					fn = val.Fn.(*ssa.Function)
-				default:
-					continue
+			case *ast.SelectStmt:
+				for _, c := range stmt.Body.List {
+					blocks = append(blocks, c.(*ast.CommClause).Body)
 				}
-				if fn.Blocks == nil {
+			default:
+				continue
+			}
+
+			for _, body := range blocks {
+				if len(body) == 0 {
 					continue
 				}
-				for _, block := range fn.Blocks {
-					for _, ins := range block.Instrs {
-						call, ok := ins.(*ssa.Call)
-						if !ok {
-							continue
-						}
-						if call.Call.IsInvoke() {
-							continue
-						}
-						callee := call.Call.StaticCallee()
-						if callee == nil {
-							continue
-						}
-						recv := callee.Signature.Recv()
-						if recv == nil {
-							continue
-						}
-						if types.TypeString(recv.Type(), nil) != "*testing.common" {
-							continue
-						}
-						fn, ok := call.Call.StaticCallee().Object().(*types.Func)
-						if !ok {
-							continue
-						}
-						name := fn.Name()
-						switch name {
-						case "FailNow", "Fatal", "Fatalf", "SkipNow", "Skip", "Skipf":
-						default:
-							continue
+				lasts := []ast.Stmt{body[len(body)-1]}
+				// TODO(dh): unfold all levels of nested block
+				// statements, not just a single level if statement
+				if ifs, ok := lasts[0].(*ast.IfStmt); ok {
+					if len(ifs.Body.List) == 0 {
+						continue
+					}
+					lasts[0] = ifs.Body.List[len(ifs.Body.List)-1]
+
+					if block, ok := ifs.Else.(*ast.BlockStmt); ok {
+						if len(block.List) != 0 {
+							lasts = append(lasts, block.List[len(block.List)-1])
 						}
-						j.Errorf(gostmt, "the goroutine calls T.%s, which must be called in the same goroutine as the test", name)
 					}
 				}
+				for _, last := range lasts {
+					branch, ok := last.(*ast.BranchStmt)
+					if !ok || branch.Tok != token.BREAK || branch.Label != nil {
+						continue
+					}
+					j.Errorf(branch, "ineffective break statement. Did you mean to break out of the outer loop?")
+				}
+			}
+		}
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+// printfFuncs are the functions in the standard library that take a
+// printf-style format string, mapped to the index of that argument.
+var printfFuncs = map[string]int{
+	"fmt.Errorf":               0,
+	"fmt.Fprintf":              1,
+	"fmt.Printf":               0,
+	"fmt.Sprintf":              0,
+	"log.Fatalf":               0,
+	"log.Panicf":               0,
+	"log.Printf":               0,
+	"(*log.Logger).Fatalf":     0,
+	"(*log.Logger).Panicf":     0,
+	"(*log.Logger).Printf":     0,
+	"(*testing.common).Errorf": 0,
+	"(*testing.common).Fatalf": 0,
+	"(*testing.common).Logf":   0,
+	"(*testing.common).Skipf":  0,
+}
+
+// calledFunc returns the function or method that call calls, if it
+// can be determined statically.
+func calledFunc(j *lint.Job, call *ast.CallExpr) (*types.Func, bool) {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return nil, false
+	}
+	fn, ok := j.Program.Info.ObjectOf(ident).(*types.Func)
+	return fn, ok
+}
+
+// printfFormat returns the index of the format string argument of
+// call, which must be a call to a known printf-style function or to
+// one of the configured wrappers.
+func (c *Checker) printfFormat(j *lint.Job, call *ast.CallExpr) (*types.Func, int, bool) {
+	fn, ok := calledFunc(j, call)
+	if !ok {
+		return nil, 0, false
+	}
+	if idx, ok := printfFuncs[fn.FullName()]; ok {
+		return fn, idx, true
+	}
+	for _, name := range c.PrintfWrappers {
+		if name != fn.FullName() {
+			continue
+		}
+		sig := fn.Type().(*types.Signature)
+		n := sig.Params().Len()
+		if !sig.Variadic() || n < 2 {
+			return nil, 0, false
+		}
+		if b, ok := sig.Params().At(n - 2).Type().Underlying().(*types.Basic); !ok || b.Kind() != types.String {
+			return nil, 0, false
+		}
+		return fn, n - 2, true
+	}
+	return nil, 0, false
+}
+
+func (c *Checker) CheckUnsafePrintf(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		fn, idx, ok := c.printfFormat(j, call)
+		if !ok {
+			return true
+		}
+		switch name := fn.FullName(); name {
+		case "fmt.Printf", "fmt.Sprintf", "log.Printf":
+		default:
+			if _, ok := printfFuncs[name]; ok {
+				return true
+			}
+		}
+		if len(call.Args) != idx+1 {
+			return true
+		}
+		switch call.Args[idx].(type) {
+		case *ast.CallExpr, *ast.Ident:
+		default:
+			return true
+		}
+		j.Errorf(call.Args[idx], "printf-style function with dynamic first argument and no further arguments should use print-style function instead")
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+// A printfVerb is a verb in a printf-style format string, together
+// with the index of the argument it formats.
+type printfVerb struct {
+	verb rune
+	arg  int
+}
+
+// parsePrintfVerbs returns the verbs in format, taking explicit
+// argument indexes and arguments consumed by * into account. It
+// returns false if format is malformed.
+func parsePrintfVerbs(format string) ([]printfVerb, bool) {
+	var verbs []printfVerb
+	arg := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		// flags
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) != -1 {
+			i++
+		}
+		index := func() bool {
+			if i >= len(format) || format[i] != '[' {
+				return true
+			}
+			end := strings.IndexByte(format[i:], ']')
+			if end == -1 {
+				return false
+			}
+			n, err := strconv.Atoi(format[i+1 : i+end])
+			if err != nil || n < 1 {
+				return false
+			}
+			arg = n - 1
+			i += end + 1
+			return true
+		}
+		number := func() {
+			if i < len(format) && format[i] == '*' {
+				arg++
+				i++
+				return
+			}
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				i++
+			}
+		}
+		// width and precision
+		if !index() {
+			return nil, false
+		}
+		number()
+		if i < len(format) && format[i] == '.' {
+			i++
+			if !index() {
+				return nil, false
+			}
+			number()
+		}
+		if !index() {
+			return nil, false
+		}
+		if i >= len(format) {
+			return nil, false
+		}
+		verb, size := utf8.DecodeRuneInString(format[i:])
+		i += size - 1
+		if verb == '%' {
+			continue
+		}
+		verbs = append(verbs, printfVerb{verb, arg})
+		arg++
+	}
+	return verbs, true
+}
+
+func (c *Checker) CheckErrorfWrapVerb(j *lint.Job) {
+	errorType := types.Universe.Lookup("error").Type()
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		fn, idx, ok := c.printfFormat(j, call)
+		if !ok || len(call.Args) <= idx {
+			return true
+		}
+		tv, ok := j.Program.Info.Types[call.Args[idx]]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			return true
+		}
+		verbs, ok := parsePrintfVerbs(constant.StringVal(tv.Value))
+		if !ok {
+			return true
+		}
+		// Wrappers that return errors are assumed to pass the format
+		// on to fmt.Errorf.
+		sig := fn.Type().(*types.Signature)
+		isErrorf := fn.FullName() == "fmt.Errorf"
+		if _, ok := printfFuncs[fn.FullName()]; !ok && sig.Results().Len() == 1 {
+			isErrorf = types.Identical(sig.Results().At(0).Type(), errorType)
+		}
+		wraps := 0
+		for _, verb := range verbs {
+			if verb.verb != 'w' {
+				continue
+			}
+			wraps++
+			if !isErrorf {
+				j.Errorf(call.Args[idx], "%s does not support the %%w verb, only fmt.Errorf does", j.Render(call.Fun))
+				return true
+			}
+			if wraps > 1 {
+				j.Errorf(call.Args[idx], "%s call has more than one %%w verb", j.Render(call.Fun))
+				return true
+			}
+			if call.Ellipsis.IsValid() {
+				continue
+			}
+			n := idx + 1 + verb.arg
+			if n >= len(call.Args) {
+				j.Errorf(call.Args[idx], "%%w verb has no corresponding argument")
+				continue
+			}
+			T := j.Program.Info.TypeOf(call.Args[n])
+			if T != nil && !types.Implements(T, errorType.Underlying().(*types.Interface)) {
+				j.Errorf(call.Args[n], "argument for %%w verb has type %s, which does not implement error", T)
+			}
+		}
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckEarlyDefer(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		block, ok := node.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		if len(block.List) < 2 {
+			return true
+		}
+		for i, stmt := range block.List {
+			if i == len(block.List)-1 {
+				break
+			}
+			assign, ok := stmt.(*ast.AssignStmt)
+			if !ok {
+				continue
+			}
+			if len(assign.Rhs) != 1 {
+				continue
+			}
+			if len(assign.Lhs) < 2 {
+				continue
+			}
+			if lhs, ok := assign.Lhs[len(assign.Lhs)-1].(*ast.Ident); ok && lhs.Name == "_" {
+				continue
+			}
+			call, ok := assign.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				continue
+			}
+			sig, ok := j.Program.Info.TypeOf(call.Fun).(*types.Signature)
+			if !ok {
+				continue
+			}
+			if sig.Results().Len() < 2 {
+				continue
+			}
+			last := sig.Results().At(sig.Results().Len() - 1)
+			// FIXME(dh): check that it's error from universe, not
+			// another type of the same name
+			if last.Type().String() != "error" {
+				continue
+			}
+			lhs, ok := assign.Lhs[0].(*ast.Ident)
+			if !ok {
+				continue
+			}
+			def, ok := block.List[i+1].(*ast.DeferStmt)
+			if !ok {
+				continue
+			}
+			sel, ok := def.Call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				continue
+			}
+			ident, ok := selectorX(sel).(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if ident.Obj != lhs.Obj {
+				continue
+			}
+			if sel.Sel.Name != "Close" {
+				continue
+			}
+			j.Errorf(def, "should check returned error before deferring %s", j.Render(def.Call))
+		}
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func selectorX(sel *ast.SelectorExpr) ast.Node {
+	switch x := sel.X.(type) {
+	case *ast.SelectorExpr:
+		return selectorX(x)
+	default:
+		return x
+	}
+}
+
+func (c *Checker) CheckEmptyCriticalSection(j *lint.Job) {
+	// Initially it might seem like this check would be easier to
+	// implement in SSA. After all, we're only checking for two
+	// consecutive method calls. In reality, however, there may be any
+	// number of other instructions between the lock and unlock, while
+	// still constituting an empty critical section. For example,
+	// given `m.x().Lock(); m.x().Unlock()`, there will be a call to
+	// x(). In the AST-based approach, this has a tiny potential for a
+	// false positive (the second call to x might be doing work that
+	// is protected by the mutex). In an SSA-based approach, however,
+	// it would miss a lot of real bugs.
+
+	mutexParams := func(s ast.Stmt) (x ast.Expr, funcName string, ok bool) {
+		expr, ok := s.(*ast.ExprStmt)
+		if !ok {
+			return nil, "", false
+		}
+		call, ok := expr.X.(*ast.CallExpr)
+		if !ok {
+			return nil, "", false
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return nil, "", false
+		}
+
+		fn, ok := j.Program.Info.ObjectOf(sel.Sel).(*types.Func)
+		if !ok {
+			return nil, "", false
+		}
+		sig := fn.Type().(*types.Signature)
+		if sig.Params().Len() != 0 || sig.Results().Len() != 0 {
+			return nil, "", false
+		}
+
+		return sel.X, fn.Name(), true
+	}
+
+	fn := func(node ast.Node) bool {
+		block, ok := node.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		if len(block.List) < 2 {
+			return true
+		}
+		for i := range block.List[:len(block.List)-1] {
+			sel1, method1, ok1 := mutexParams(block.List[i])
+			sel2, method2, ok2 := mutexParams(block.List[i+1])
+
+			if !ok1 || !ok2 || j.Render(sel1) != j.Render(sel2) {
+				continue
+			}
+			if (method1 == "Lock" && method2 == "Unlock") ||
+				(method1 == "RLock" && method2 == "RUnlock") {
+				j.Errorf(block.List[i+1], "empty critical section")
+			}
+		}
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckIneffectiveCopy(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		if unary, ok := node.(*ast.UnaryExpr); ok {
+			if _, ok := unary.X.(*ast.StarExpr); ok && unary.Op == token.AND {
+				j.Errorf(unary, "&*x will be simplified to x. It will not copy x.")
+			}
+		}
+
+		if star, ok := node.(*ast.StarExpr); ok {
+			if unary, ok := star.X.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+				j.Errorf(star, "*&x will be simplified to x. It will not copy x.")
+			}
+		}
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckDiffSizeComparison(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, b := range ssafn.Blocks {
+			for _, ins := range b.Instrs {
+				binop, ok := ins.(*ssa.BinOp)
+				if !ok {
+					continue
+				}
+				if binop.Op != token.EQL && binop.Op != token.NEQ {
+					continue
+				}
+				_, ok1 := binop.X.(*ssa.Slice)
+				_, ok2 := binop.Y.(*ssa.Slice)
+				if !ok1 && !ok2 {
+					continue
+				}
+				r := c.funcDescs.Get(ssafn).Ranges
+				r1, ok1 := r.Get(binop.X).(vrp.StringInterval)
+				r2, ok2 := r.Get(binop.Y).(vrp.StringInterval)
+				if !ok1 || !ok2 {
+					continue
+				}
+				if r1.Length.Intersection(r2.Length).Empty() {
+					j.Errorf(binop, "comparing strings of different sizes for equality will always return false")
+				}
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckCanonicalHeaderKey(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignStmt)
+		if ok {
+			// TODO(dh): This risks missing some Header reads, for
+			// example in `h1["foo"] = h2["foo"]` – these edge
+			// cases are probably rare enough to ignore for now.
+			for _, expr := range assign.Lhs {
+				op, ok := expr.(*ast.IndexExpr)
+				if !ok {
+					continue
+				}
+				if hasType(j, op.X, "net/http.Header") {
+					return false
+				}
+			}
+			return true
+		}
+		op, ok := node.(*ast.IndexExpr)
+		if !ok {
+			return true
+		}
+		if !hasType(j, op.X, "net/http.Header") {
+			return true
+		}
+		s, ok := j.ExprToString(op.Index)
+		if !ok {
+			return true
+		}
+		if s == http.CanonicalHeaderKey(s) {
+			return true
+		}
+		j.Errorf(op, "keys in http.Header are canonicalized, %q is not canonical; fix the constant or use http.CanonicalHeaderKey", s)
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckBenchmarkN(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok {
+			return true
+		}
+		if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return true
+		}
+		sel, ok := assign.Lhs[0].(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if sel.Sel.Name != "N" {
+			return true
+		}
+		if !hasType(j, sel.X, "*testing.B") {
+			return true
+		}
+		j.Errorf(assign, "should not assign to %s", j.Render(sel))
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+// benchmarkParam returns the *testing.B parameter of a function with
+// the signature of a benchmark, or nil.
+func benchmarkParam(j *lint.Job, typ *ast.FuncType) *types.Var {
+	if typ.Results != nil && len(typ.Results.List) != 0 {
+		return nil
+	}
+	if len(typ.Params.List) != 1 || len(typ.Params.List[0].Names) != 1 {
+		return nil
+	}
+	name := typ.Params.List[0].Names[0]
+	param, ok := j.Program.Info.ObjectOf(name).(*types.Var)
+	if !ok || types.TypeString(param.Type(), nil) != "*testing.B" {
+		return nil
+	}
+	return param
+}
+
+// isBenchmarkLoop reports whether the loop stmt is driven by b.N or,
+// if loop is true, by b.Loop.
+func isBenchmarkLoop(j *lint.Job, stmt ast.Stmt, b *types.Var) (driven bool, loop bool) {
+	isB := func(expr ast.Expr) bool {
+		ident, ok := expr.(*ast.Ident)
+		return ok && j.Program.Info.ObjectOf(ident) == b
+	}
+	usesN := func(node ast.Node) bool {
+		found := false
+		ast.Inspect(node, func(node ast.Node) bool {
+			if sel, ok := node.(*ast.SelectorExpr); ok && sel.Sel.Name == "N" && isB(sel.X) {
+				found = true
+			}
+			return !found
+		})
+		return found
+	}
+	switch stmt := stmt.(type) {
+	case *ast.ForStmt:
+		if call, ok := stmt.Cond.(*ast.CallExpr); ok {
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Loop" && isB(sel.X) {
+				return true, true
+			}
+		}
+		if stmt.Cond != nil && usesN(stmt.Cond) {
+			return true, false
+		}
+	case *ast.RangeStmt:
+		if usesN(stmt.X) {
+			return true, false
+		}
+	}
+	return false, false
+}
+
+func (c *Checker) CheckBenchmarkUsesN(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		decl, ok := node.(*ast.FuncDecl)
+		if !ok || decl.Recv != nil || decl.Body == nil || !strings.HasPrefix(decl.Name.Name, "Benchmark") {
+			return true
+		}
+		b := benchmarkParam(j, decl.Type)
+		if b == nil {
+			return true
+		}
+		// The benchmark is fine if it uses b.N or b.Loop, runs
+		// sub-benchmarks, or passes b to code that may do either.
+		selected := map[*ast.Ident]bool{}
+		uses := false
+		ast.Inspect(decl.Body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.SelectorExpr:
+				ident, isIdent := node.X.(*ast.Ident)
+				if !isIdent || j.Program.Info.ObjectOf(ident) != b {
+					return true
+				}
+				selected[ident] = true
+				switch node.Sel.Name {
+				case "N", "Loop", "Run", "RunParallel":
+					uses = true
+				}
+			case *ast.Ident:
+				if !selected[node] && j.Program.Info.ObjectOf(node) == b {
+					uses = true
+				}
+			}
+			return !uses
+		})
+		if !uses {
+			j.Errorf(decl.Name, "%s doesn't run its code %s.N times, so the reported time per operation is meaningless", decl.Name.Name, b.Name())
+		}
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		ast.Inspect(f, fn)
+	}
+}
+
+// expensiveFuncs are functions whose cost usually dwarfs that of the
+// code being benchmarked.
+var expensiveFuncs = []string{
+	"io/ioutil.ReadAll",
+	"io/ioutil.ReadDir",
+	"io/ioutil.ReadFile",
+	"io/ioutil.WriteFile",
+	"net.Dial",
+	"net.Listen",
+	"net/http.Get",
+	"net/http.Post",
+	"os.Create",
+	"os.Open",
+	"os.OpenFile",
+	"os.ReadFile",
+	"os.WriteFile",
+	"path/filepath.Walk",
+	"time.Sleep",
+	"(*os/exec.Cmd).CombinedOutput",
+	"(*os/exec.Cmd).Output",
+	"(*os/exec.Cmd).Run",
+}
+
+func (c *Checker) CheckBenchmarkSetup(j *lint.Job) {
+	check := func(typ *ast.FuncType, body *ast.BlockStmt) {
+		b := benchmarkParam(j, typ)
+		if b == nil {
+			return
+		}
+		loop := -1
+		for i, stmt := range body.List {
+			driven, isLoop := isBenchmarkLoop(j, stmt, b)
+			if !driven {
+				continue
+			}
+			if isLoop {
+				// b.Loop resets the timer when it is first called.
+				return
+			}
+			loop = i
+			break
+		}
+		if loop == -1 {
+			return
+		}
+		var setup ast.Node
+		for _, stmt := range body.List[:loop] {
+			resets := false
+			ast.Inspect(stmt, func(node ast.Node) bool {
+				switch node := node.(type) {
+				case *ast.FuncLit:
+					return false
+				case *ast.CallExpr:
+					if j.IsFunctionCallNameAny(node,
+						"(*testing.B).ResetTimer", "(*testing.B).StopTimer",
+						"(*testing.B).Run", "(*testing.B).RunParallel") {
+						resets = true
+					} else if setup == nil && j.IsFunctionCallNameAny(node, expensiveFuncs...) {
+						setup = node
+					}
+				case *ast.ForStmt, *ast.RangeStmt:
+					if setup == nil {
+						setup = node
+					}
+				}
+				return !resets
+			})
+			if resets {
+				return
+			}
+		}
+		if setup != nil {
+			j.Errorf(setup, "setup before the %s.N loop is included in the benchmark's measurements; call %s.ResetTimer after it", b.Name(), b.Name())
+		}
+	}
+	fn := func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FuncDecl:
+			if node.Body != nil && strings.HasPrefix(node.Name.Name, "Benchmark") {
+				check(node.Type, node.Body)
+			}
+		case *ast.FuncLit:
+			check(node.Type, node.Body)
+		}
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckBenchmarkTimerBalance(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		if len(ssafn.Blocks) == 0 {
+			continue
+		}
+		// The timer state on exit of each block is the set of
+		// StopTimer calls that may have stopped the timer, with nil
+		// standing for a running timer.
+		type state map[*ssa.Call]bool
+		transfer := func(b *ssa.BasicBlock, in state) state {
+			out := in
+			for _, ins := range b.Instrs {
+				call, ok := ins.(*ssa.Call)
+				if !ok {
+					continue
+				}
+				switch {
+				case lint.IsCallTo(call.Common(), "(*testing.B).StopTimer"):
+					out = state{call: true}
+				case lint.IsCallTo(call.Common(), "(*testing.B).StartTimer"):
+					out = state{nil: true}
+				}
+			}
+			return out
+		}
+		uses := false
+		for _, b := range ssafn.Blocks {
+			for _, ins := range b.Instrs {
+				if call, ok := ins.(*ssa.Call); ok && lint.IsCallTo(call.Common(), "(*testing.B).StopTimer") {
+					uses = true
+				}
+			}
+		}
+		if !uses {
+			continue
+		}
+
+		in := map[*ssa.BasicBlock]state{ssafn.Blocks[0]: {nil: true}}
+		out := map[*ssa.BasicBlock]state{}
+		work := []*ssa.BasicBlock{ssafn.Blocks[0]}
+		for len(work) > 0 {
+			b := work[len(work)-1]
+			work = work[:len(work)-1]
+			res := transfer(b, in[b])
+			if len(res) == len(out[b]) {
+				continue
+			}
+			out[b] = res
+			for _, succ := range b.Succs {
+				merged := in[succ]
+				grew := false
+				for k := range res {
+					if !merged[k] {
+						if !grew {
+							cp := state{}
+							for k := range merged {
+								cp[k] = true
+							}
+							merged = cp
+							grew = true
+						}
+						merged[k] = true
+					}
+				}
+				if grew || out[succ] == nil {
+					in[succ] = merged
+					work = append(work, succ)
+				}
+			}
+		}
+
+		reported := map[*ssa.Call]bool{}
+		for _, b := range ssafn.Blocks {
+			st := in[b]
+			if !st[nil] || len(st) < 2 {
+				continue
+			}
+			for call := range st {
+				if call == nil || reported[call] {
+					continue
+				}
+				reported[call] = true
+				j.Errorf(call, "StopTimer is not followed by StartTimer on all paths, so whether the timer is running depends on the path taken")
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckIneffecitiveFieldAssignments(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		// fset := j.Program.SSA.Fset
+		// if fset.File(f.File.Pos()) != fset.File(ssafn.Pos()) {
+		// 	continue
+		// }
+		if ssafn.Signature.Recv() == nil {
+			continue
+		}
+
+		if len(ssafn.Blocks) == 0 {
+			// External function
+			continue
+		}
+
+		reads := map[*ssa.BasicBlock]map[ssa.Value]bool{}
+		writes := map[*ssa.BasicBlock]map[ssa.Value]bool{}
+
+		recv := ssafn.Params[0]
+		if _, ok := recv.Type().Underlying().(*types.Struct); !ok {
+			continue
+		}
+		recvPtrs := map[ssa.Value]bool{
+			recv: true,
+		}
+		if len(ssafn.Locals) == 0 || ssafn.Locals[0].Heap {
+			continue
+		}
+		blocks := ssafn.DomPreorder()
+		for _, block := range blocks {
+			if writes[block] == nil {
+				writes[block] = map[ssa.Value]bool{}
+			}
+			if reads[block] == nil {
+				reads[block] = map[ssa.Value]bool{}
+			}
+
+			for _, ins := range block.Instrs {
+				switch ins := ins.(type) {
+				case *ssa.Store:
+					if recvPtrs[ins.Val] {
+						recvPtrs[ins.Addr] = true
+					}
+					fa, ok := ins.Addr.(*ssa.FieldAddr)
+					if !ok {
+						continue
+					}
+					if !recvPtrs[fa.X] {
+						continue
+					}
+					writes[block][fa] = true
+				case *ssa.UnOp:
+					if ins.Op != token.MUL {
+						continue
+					}
+					if recvPtrs[ins.X] {
+						reads[block][ins] = true
+						continue
+					}
+					fa, ok := ins.X.(*ssa.FieldAddr)
+					if !ok {
+						continue
+					}
+					if !recvPtrs[fa.X] {
+						continue
+					}
+					reads[block][fa] = true
+				}
+			}
+		}
+
+		for block, writes := range writes {
+			seen := map[*ssa.BasicBlock]bool{}
+			var hasRead func(block *ssa.BasicBlock, write *ssa.FieldAddr) bool
+			hasRead = func(block *ssa.BasicBlock, write *ssa.FieldAddr) bool {
+				seen[block] = true
+				for read := range reads[block] {
+					switch ins := read.(type) {
+					case *ssa.FieldAddr:
+						if ins.Field == write.Field && read.Pos() > write.Pos() {
+							return true
+						}
+					case *ssa.UnOp:
+						if ins.Pos() >= write.Pos() {
+							return true
+						}
+					}
+				}
+				for _, succ := range block.Succs {
+					if !seen[succ] {
+						if hasRead(succ, write) {
+							return true
+						}
+					}
+				}
+				return false
+			}
+			for write := range writes {
+				fa := write.(*ssa.FieldAddr)
+				if !hasRead(block, fa) {
+					name := recv.Type().Underlying().(*types.Struct).Field(fa.Field).Name()
+					j.Errorf(fa, "ineffective assignment to field %s", name)
+				}
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckUnreadVariableValues(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+		default:
+			return true
+		}
+
+		ssafn := c.nodeFns[node]
+		if ssafn == nil {
+			return true
+		}
+		if lint.IsExample(ssafn) {
+			return true
+		}
+		ast.Inspect(node, func(node ast.Node) bool {
+			assign, ok := node.(*ast.AssignStmt)
+			if !ok {
+				return true
+			}
+			if len(assign.Lhs) > 1 && len(assign.Rhs) == 1 {
+				// Either a function call with multiple return values,
+				// or a comma-ok assignment
+
+				val, _ := ssafn.ValueForExpr(assign.Rhs[0])
+				if val == nil {
+					return true
+				}
+				refs := val.Referrers()
+				if refs == nil {
+					return true
+				}
+				for _, ref := range *refs {
+					ex, ok := ref.(*ssa.Extract)
+					if !ok {
+						continue
+					}
+					exrefs := ex.Referrers()
+					if exrefs == nil {
+						continue
+					}
+					if len(lint.FilterDebug(*exrefs)) == 0 {
+						lhs := assign.Lhs[ex.Index]
+						if ident, ok := lhs.(*ast.Ident); !ok || ok && ident.Name == "_" {
+							continue
+						}
+						j.Errorf(lhs, "this value of %s is never used", lhs)
+					}
+				}
+				return true
+			}
+			for i, lhs := range assign.Lhs {
+				rhs := assign.Rhs[i]
+				if ident, ok := lhs.(*ast.Ident); !ok || ok && ident.Name == "_" {
+					continue
+				}
+				val, _ := ssafn.ValueForExpr(rhs)
+				if val == nil {
+					continue
+				}
+
+				refs := val.Referrers()
+				if refs == nil {
+					// TODO investigate why refs can be nil
+					return true
+				}
+				if len(lint.FilterDebug(*refs)) == 0 {
+					j.Errorf(lhs, "this value of %s is never used", lhs)
+				}
+			}
+			return true
+		})
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckPredeterminedBooleanExprs(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				ssabinop, ok := ins.(*ssa.BinOp)
+				if !ok {
+					continue
+				}
+				switch ssabinop.Op {
+				case token.GTR, token.LSS, token.EQL, token.NEQ, token.LEQ, token.GEQ:
+				default:
+					continue
+				}
+
+				xs, ok1 := consts(ssabinop.X, nil, nil)
+				ys, ok2 := consts(ssabinop.Y, nil, nil)
+				if !ok1 || !ok2 || len(xs) == 0 || len(ys) == 0 {
+					continue
+				}
+
+				trues := 0
+				for _, x := range xs {
+					for _, y := range ys {
+						if x.Value == nil {
+							if y.Value == nil {
+								trues++
+							}
+							continue
+						}
+						if constant.Compare(x.Value, ssabinop.Op, y.Value) {
+							trues++
+						}
+					}
+				}
+				b := trues != 0
+				if trues == 0 || trues == len(xs)*len(ys) {
+					j.Errorf(ssabinop, "binary expression is always %t for all possible values (%s %s %s)",
+						b, xs, ssabinop.Op, ys)
+				}
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckPredeterminedComparison(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		ranges := c.funcDescs.Get(ssafn).Ranges
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				binop, ok := ins.(*ssa.BinOp)
+				if !ok || !binop.Pos().IsValid() {
+					// Comparisons without a position were
+					// synthesized, for example for range loops.
+					continue
+				}
+				switch binop.Op {
+				case token.GTR, token.LSS, token.EQL, token.NEQ, token.LEQ, token.GEQ:
+				default:
+					continue
+				}
+				_, ok1 := binop.X.(*ssa.Const)
+				_, ok2 := binop.Y.(*ssa.Const)
+				if ok1 && ok2 {
+					continue
+				}
+				x, ok1 := ranges.Get(binop.X).(vrp.IntInterval)
+				y, ok2 := ranges.Get(binop.Y).(vrp.IntInterval)
+				if !ok1 || !ok2 || !x.IsKnown() || !y.IsKnown() || x.Empty() || y.Empty() {
+					continue
+				}
+				b, ok := compareIntervals(x, binop.Op, y)
+				if !ok {
+					continue
+				}
+				if isOnlyUsedByIf(binop) {
+					branch := "false"
+					if !b {
+						branch = "true"
+					}
+					j.Errorf(binop, "condition is always %t, the %s branch is unreachable", b, branch)
+					continue
+				}
+				j.Errorf(binop, "comparison is always %t", b)
+			}
+		}
+	}
+}
+
+// compareIntervals returns the result of comparing all values in x
+// against all values in y, or false if the result depends on the
+// values.
+func compareIntervals(x vrp.IntInterval, op token.Token, y vrp.IntInterval) (result, ok bool) {
+	switch op {
+	case token.LSS:
+		if x.Upper.Cmp(y.Lower) == -1 {
+			return true, true
+		}
+		if x.Lower.Cmp(y.Upper) >= 0 {
+			return false, true
+		}
+	case token.LEQ:
+		if x.Upper.Cmp(y.Lower) <= 0 {
+			return true, true
+		}
+		if x.Lower.Cmp(y.Upper) == 1 {
+			return false, true
+		}
+	case token.GTR:
+		return compareIntervals(y, token.LSS, x)
+	case token.GEQ:
+		return compareIntervals(y, token.LEQ, x)
+	case token.EQL:
+		if x.Lower.Cmp(x.Upper) == 0 && y.Lower.Cmp(y.Upper) == 0 && x.Lower.Cmp(y.Lower) == 0 &&
+			!x.Lower.Infinite() {
+			return true, true
+		}
+		// The intersection also accounts for congruences, such as
+		// multiples of 4 never being equal to 6.
+		if x.Intersection(y).Empty() {
+			return false, true
+		}
+	case token.NEQ:
+		b, ok := compareIntervals(x, token.EQL, y)
+		return !b, ok
+	}
+	return false, false
+}
+
+func isOnlyUsedByIf(v ssa.Value) bool {
+	refs := v.Referrers()
+	if refs == nil {
+		return false
+	}
+	for _, ref := range *refs {
+		switch ref.(type) {
+		case *ssa.If, *ssa.DebugRef:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Checker) CheckNilMaps(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				mu, ok := ins.(*ssa.MapUpdate)
+				if !ok {
+					continue
+				}
+				c, ok := mu.Map.(*ssa.Const)
+				if !ok {
+					continue
+				}
+				if c.Value != nil {
+					continue
+				}
+				j.Errorf(mu, "assignment to nil map")
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckUnsignedComparison(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		expr, ok := node.(*ast.BinaryExpr)
+		if !ok {
+			return true
+		}
+		tx := j.Program.Info.TypeOf(expr.X)
+		basic, ok := tx.Underlying().(*types.Basic)
+		if !ok {
+			return true
+		}
+		if (basic.Info() & types.IsUnsigned) == 0 {
+			return true
+		}
+		lit, ok := expr.Y.(*ast.BasicLit)
+		if !ok || lit.Value != "0" {
+			return true
+		}
+		switch expr.Op {
+		case token.GEQ:
+			j.Errorf(expr, "unsigned values are always >= 0")
+		case token.LSS:
+			j.Errorf(expr, "unsigned values are never < 0")
+		}
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func consts(val ssa.Value, out []*ssa.Const, visitedPhis map[string]bool) ([]*ssa.Const, bool) {
+	if visitedPhis == nil {
+		visitedPhis = map[string]bool{}
+	}
+	var ok bool
+	switch val := val.(type) {
+	case *ssa.Phi:
+		if visitedPhis[val.Name()] {
+			break
+		}
+		visitedPhis[val.Name()] = true
+		vals := val.Operands(nil)
+		for _, phival := range vals {
+			out, ok = consts(*phival, out, visitedPhis)
+			if !ok {
+				return nil, false
+			}
+		}
+	case *ssa.Const:
+		out = append(out, val)
+	case *ssa.Convert:
+		out, ok = consts(val.X, out, visitedPhis)
+		if !ok {
+			return nil, false
+		}
+	case *ssa.Sigma:
+		out, ok = consts(val.X, out, visitedPhis)
+		if !ok {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	if len(out) < 2 {
+		return out, true
+	}
+	uniq := []*ssa.Const{out[0]}
+	for _, val := range out[1:] {
+		if val.Value == uniq[len(uniq)-1].Value {
+			continue
+		}
+		uniq = append(uniq, val)
+	}
+	return uniq, true
+}
+
+func (c *Checker) CheckLoopCondition(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		loop, ok := node.(*ast.ForStmt)
+		if !ok {
+			return true
+		}
+		if loop.Init == nil || loop.Cond == nil || loop.Post == nil {
+			return true
+		}
+		init, ok := loop.Init.(*ast.AssignStmt)
+		if !ok || len(init.Lhs) != 1 || len(init.Rhs) != 1 {
+			return true
+		}
+		cond, ok := loop.Cond.(*ast.BinaryExpr)
+		if !ok {
+			return true
+		}
+		x, ok := cond.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		lhs, ok := init.Lhs[0].(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if x.Obj != lhs.Obj {
+			return true
+		}
+		if _, ok := loop.Post.(*ast.IncDecStmt); !ok {
+			return true
+		}
+
+		ssafn := c.nodeFns[cond]
+		if ssafn == nil {
+			return true
+		}
+		v, isAddr := ssafn.ValueForExpr(cond.X)
+		if v == nil || isAddr {
+			return true
+		}
+		switch v := v.(type) {
+		case *ssa.Phi:
+			ops := v.Operands(nil)
+			if len(ops) != 2 {
+				return true
+			}
+			_, ok := (*ops[0]).(*ssa.Const)
+			if !ok {
+				return true
+			}
+			sigma, ok := (*ops[1]).(*ssa.Sigma)
+			if !ok {
+				return true
+			}
+			if sigma.X != v {
+				return true
+			}
+		case *ssa.UnOp:
+			return true
+		}
+		j.Errorf(cond, "variable in loop condition never changes")
+
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckArgOverwritten(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		var typ *ast.FuncType
+		var body *ast.BlockStmt
+		switch fn := node.(type) {
+		case *ast.FuncDecl:
+			typ = fn.Type
+			body = fn.Body
+		case *ast.FuncLit:
+			typ = fn.Type
+			body = fn.Body
+		}
+		if body == nil {
+			return true
+		}
+		ssafn := c.nodeFns[node]
+		if ssafn == nil {
+			return true
+		}
+		if len(typ.Params.List) == 0 {
+			return true
+		}
+		for _, field := range typ.Params.List {
+			for _, arg := range field.Names {
+				obj := j.Program.Info.ObjectOf(arg)
+				var ssaobj *ssa.Parameter
+				for _, param := range ssafn.Params {
+					if param.Object() == obj {
+						ssaobj = param
+						break
+					}
+				}
+				if ssaobj == nil {
+					continue
+				}
+				refs := ssaobj.Referrers()
+				if refs == nil {
+					continue
+				}
+				if len(lint.FilterDebug(*refs)) != 0 {
+					continue
+				}
+
+				assigned := false
+				ast.Inspect(body, func(node ast.Node) bool {
+					assign, ok := node.(*ast.AssignStmt)
+					if !ok {
+						return true
+					}
+					for _, lhs := range assign.Lhs {
+						ident, ok := lhs.(*ast.Ident)
+						if !ok {
+							continue
+						}
+						if j.Program.Info.ObjectOf(ident) == obj {
+							assigned = true
+							return false
+						}
+					}
+					return true
+				})
+				if assigned {
+					j.Errorf(arg, "argument %s is overwritten before first use", arg)
+				}
+			}
+		}
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckIneffectiveLoop(j *lint.Job) {
+	// This check detects some, but not all unconditional loop exits.
+	// We give up in the following cases:
+	//
+	// - a goto anywhere in the loop. The goto might skip over our
+	// return, and we don't check that it doesn't.
+	//
+	// - any nested, unlabelled continue, even if it is in another
+	// loop or closure.
+	fn := func(node ast.Node) bool {
+		var body *ast.BlockStmt
+		switch fn := node.(type) {
+		case *ast.FuncDecl:
+			body = fn.Body
+		case *ast.FuncLit:
+			body = fn.Body
+		default:
+			return true
+		}
+		if body == nil {
+			return true
+		}
+		labels := map[*ast.Object]ast.Stmt{}
+		ast.Inspect(body, func(node ast.Node) bool {
+			label, ok := node.(*ast.LabeledStmt)
+			if !ok {
+				return true
+			}
+			labels[label.Label.Obj] = label.Stmt
+			return true
+		})
+
+		ast.Inspect(body, func(node ast.Node) bool {
+			var loop ast.Node
+			var body *ast.BlockStmt
+			switch node := node.(type) {
+			case *ast.ForStmt:
+				body = node.Body
+				loop = node
+			case *ast.RangeStmt:
+				typ := j.Program.Info.TypeOf(node.X)
+				if _, ok := typ.Underlying().(*types.Map); ok {
+					// looping once over a map is a valid pattern for
+					// getting an arbitrary element.
+					return true
+				}
+				body = node.Body
+				loop = node
+			default:
+				return true
+			}
+			if len(body.List) < 2 {
+				// avoid flagging the somewhat common pattern of using
+				// a range loop to get the first element in a slice,
+				// or the first rune in a string.
+				return true
+			}
+			var unconditionalExit ast.Node
+			hasBranching := false
+			for _, stmt := range body.List {
+				switch stmt := stmt.(type) {
+				case *ast.BranchStmt:
+					switch stmt.Tok {
+					case token.BREAK:
+						if stmt.Label == nil || labels[stmt.Label.Obj] == loop {
+							unconditionalExit = stmt
+						}
+					case token.CONTINUE:
+						if stmt.Label == nil || labels[stmt.Label.Obj] == loop {
+							unconditionalExit = nil
+							return false
+						}
+					}
+				case *ast.ReturnStmt:
+					unconditionalExit = stmt
+				case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.SelectStmt:
+					hasBranching = true
+				}
+			}
+			if unconditionalExit == nil || !hasBranching {
+				return false
+			}
+			ast.Inspect(body, func(node ast.Node) bool {
+				if branch, ok := node.(*ast.BranchStmt); ok {
+
+					switch branch.Tok {
+					case token.GOTO:
+						unconditionalExit = nil
+						return false
+					case token.CONTINUE:
+						if branch.Label != nil && labels[branch.Label.Obj] != loop {
+							return true
+						}
+						unconditionalExit = nil
+						return false
+					}
+				}
+				return true
+			})
+			if unconditionalExit != nil {
+				j.Errorf(unconditionalExit, "the surrounding loop is unconditionally terminated")
+			}
+			return true
+		})
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckNilContext(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if len(call.Args) == 0 {
+			return true
+		}
+		if typ, ok := j.Program.Info.TypeOf(call.Args[0]).(*types.Basic); !ok || typ.Kind() != types.UntypedNil {
+			return true
+		}
+		sig, ok := j.Program.Info.TypeOf(call.Fun).(*types.Signature)
+		if !ok {
+			return true
+		}
+		if sig.Params().Len() == 0 {
+			return true
+		}
+		if types.TypeString(sig.Params().At(0).Type(), nil) != "context.Context" {
+			return true
+		}
+		j.Errorf(call.Args[0],
+			"do not pass a nil Context, even if a function permits it; pass context.TODO if you are unsure about which Context to use")
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+func (c *Checker) CheckSeeker(j *lint.Job) {
+	fn := func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if sel.Sel.Name != "Seek" {
+			return true
+		}
+		if len(call.Args) != 2 {
+			return true
+		}
+		arg0, ok := call.Args[0].(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		switch arg0.Sel.Name {
+		case "SeekStart", "SeekCurrent", "SeekEnd":
+		default:
+			return true
+		}
+		pkg, ok := arg0.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if pkg.Name != "io" {
+			return true
+		}
+		j.Errorf(call, "the first argument of io.Seeker is the offset, but an io.Seek* constant is being used instead")
+		return true
+	}
+	for _, f := range j.Program.Files {
+		ast.Inspect(f, fn)
+	}
+}
+
+// testFailNow returns the name of the method of testing.T or
+// testing.B, such as Fatal or SkipNow, that call calls to stop the
+// test, or the empty string if it doesn't.
+func testFailNow(call *ssa.Call) string {
+	if call.Call.IsInvoke() {
+		return ""
+	}
+	callee := call.Call.StaticCallee()
+	if callee == nil {
+		return ""
+	}
+	recv := callee.Signature.Recv()
+	if recv == nil {
+		return ""
+	}
+	if types.TypeString(recv.Type(), nil) != "*testing.common" {
+		return ""
+	}
+	fn, ok := callee.Object().(*types.Func)
+	if !ok {
+		return ""
+	}
+	switch fn.Name() {
+	case "FailNow", "Fatal", "Fatalf", "SkipNow", "Skip", "Skipf":
+		return fn.Name()
+	}
+	return ""
+}
+
+// findTestFailNow looks for calls that stop a test in fn and in the
+// functions it calls. It returns the name of the method and, if the
+// call isn't made by fn itself, the function that makes it.
+func findTestFailNow(fn *ssa.Function, seen map[*ssa.Function]bool) (string, *ssa.Function) {
+	if seen[fn] || fn.Blocks == nil || (fn.Pkg != nil && fn.Pkg.Pkg.Path() == "testing") {
+		return "", nil
+	}
+	seen[fn] = true
+	var callees []*ssa.Function
+	for _, block := range fn.Blocks {
+		for _, ins := range block.Instrs {
+			call, ok := ins.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			if name := testFailNow(call); name != "" {
+				return name, nil
+			}
+			if callee := call.Call.StaticCallee(); callee != nil {
+				callees = append(callees, callee)
+			}
+		}
+	}
+	for _, callee := range callees {
+		if name, via := findTestFailNow(callee, seen); name != "" {
+			if via == nil {
+				via = callee
+			}
+			return name, via
+		}
+	}
+	return "", nil
+}
+
+func (c *Checker) CheckConcurrentTesting(j *lint.Job) {
+	// isFreeVar reports whether v refers to a variable captured by
+	// the closure it is used in.
+	var isFreeVar func(v ssa.Value) bool
+	isFreeVar = func(v ssa.Value) bool {
+		switch v := v.(type) {
+		case *ssa.FreeVar:
+			return true
+		case *ssa.FieldAddr:
+			return isFreeVar(v.X)
+		case *ssa.UnOp:
+			return v.Op == token.MUL && isFreeVar(v.X)
+		}
+		return false
+	}
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				switch ins := ins.(type) {
+				case *ssa.Go:
+					var fn *ssa.Function
+					switch val := ins.Call.Value.(type) {
+					case *ssa.Function:
+						fn = val
+					case *ssa.MakeClosure:
+						fn = val.Fn.(*ssa.Function)
+					default:
+						continue
+					}
+					name, via := findTestFailNow(fn, map[*ssa.Function]bool{})
+					if name == "" {
+						continue
+					}
+					if via != nil {
+						j.Errorf(ins, "the goroutine calls T.%s through %s, which must be called in the same goroutine as the test", name, via.Name())
+						continue
+					}
+					j.Errorf(ins, "the goroutine calls T.%s, which must be called in the same goroutine as the test", name)
+				case *ssa.Call:
+					// Subtests run in their own goroutines, so they
+					// mustn't stop the parent test.
+					if !lint.IsCallTo(ins.Common(), "(*testing.T).Run") && !lint.IsCallTo(ins.Common(), "(*testing.B).Run") {
+						continue
+					}
+					closure, ok := ins.Common().Args[2].(*ssa.MakeClosure)
+					if !ok {
+						continue
+					}
+					for _, block := range closure.Fn.(*ssa.Function).Blocks {
+						for _, ins := range block.Instrs {
+							call, ok := ins.(*ssa.Call)
+							if !ok {
+								continue
+							}
+							if name := testFailNow(call); name != "" && isFreeVar(call.Call.Args[0]) {
+								j.Errorf(call, "the subtest calls T.%s on its parent test, which must be called in the same goroutine as the test it belongs to", name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckCyclicFinalizer(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		node := c.funcDescs.CallGraph.CreateNode(ssafn)
+		for _, edge := range node.Out {
+			if edge.Callee.Func.RelString(nil) != "runtime.SetFinalizer" {
+				continue
+			}
+			arg0 := edge.Site.Common().Args[0]
+			if iface, ok := arg0.(*ssa.MakeInterface); ok {
+				arg0 = iface.X
+			}
+			unop, ok := arg0.(*ssa.UnOp)
+			if !ok {
+				continue
+			}
+			v, ok := unop.X.(*ssa.Alloc)
+			if !ok {
+				continue
+			}
+			arg1 := edge.Site.Common().Args[1]
+			if iface, ok := arg1.(*ssa.MakeInterface); ok {
+				arg1 = iface.X
+			}
+			mc, ok := arg1.(*ssa.MakeClosure)
+			if !ok {
+				continue
+			}
+			for _, b := range mc.Bindings {
+				if b == v {
+					j.Errorf(edge.Site, "the finalizer closes over the object, preventing the finalizer from ever running").
+						Relate(mc.Fn.Pos(), "finalizer")
+				}
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckSliceOutOfBounds(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		ranges := c.funcDescs.Get(ssafn).Ranges
+		// length returns the range of lengths of x, which is being
+		// indexed.
+		length := func(x ssa.Value) (vrp.IntInterval, bool) {
+			switch typ := x.Type().Underlying().(type) {
+			case *types.Slice:
+				r, ok := ranges.Get(x).(vrp.SliceInterval)
+				return r.Length, ok
+			case *types.Basic:
+				r, ok := ranges.Get(x).(vrp.StringInterval)
+				return r.Length, ok
+			case *types.Array:
+				n := vrp.NewZ(typ.Len())
+				return vrp.NewIntInterval(n, n), true
+			case *types.Pointer:
+				arr, ok := typ.Elem().Underlying().(*types.Array)
+				if !ok {
+					return vrp.IntInterval{}, false
+				}
+				n := vrp.NewZ(arr.Len())
+				return vrp.NewIntInterval(n, n), true
+			}
+			return vrp.IntInterval{}, false
+		}
+		for _, block := range ssafn.Blocks {
+			if block != ssafn.Blocks[0] && block != ssafn.Recover && len(block.Preds) == 0 {
+				// Unreachable
+				continue
+			}
+			for _, ins := range block.Instrs {
+				var x, index ssa.Value
+				switch ins := ins.(type) {
+				case *ssa.IndexAddr:
+					x, index = ins.X, ins.Index
+				case *ssa.Index:
+					x, index = ins.X, ins.Index
+				case *ssa.Lookup:
+					if _, ok := ins.X.Type().Underlying().(*types.Basic); !ok {
+						continue
+					}
+					x, index = ins.X, ins.Index
+				default:
+					continue
+				}
+				if _, ok := index.(*ssa.Const); ok && !isSlice(x) {
+					// Constant indices into arrays are checked by
+					// the compiler, and constant strings are folded.
+					continue
+				}
+				lr, ok1 := length(x)
+				idxr, ok2 := ranges.Get(index).(vrp.IntInterval)
+				if !ok1 || !ok2 || !lr.IsKnown() || !idxr.IsKnown() || lr.Empty() || idxr.Empty() {
+					continue
+				}
+				if idxr.Lower.Cmp(lr.Upper) >= 0 || idxr.Upper.Sign() < 0 {
+					j.Errorf(ins, "index out of bounds")
+				}
+			}
+		}
+	}
+}
+
+func isSlice(v ssa.Value) bool {
+	_, ok := v.Type().Underlying().(*types.Slice)
+	return ok
+}
+
+func (c *Checker) CheckDeferLock(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			instrs := lint.FilterDebug(block.Instrs)
+			if len(instrs) < 2 {
+				continue
+			}
+			for i, ins := range instrs[:len(instrs)-1] {
+				call, ok := ins.(*ssa.Call)
+				if !ok {
+					continue
+				}
+				if !lint.IsCallTo(call.Common(), "(*sync.Mutex).Lock") && !lint.IsCallTo(call.Common(), "(*sync.RWMutex).RLock") {
+					continue
+				}
+				nins, ok := instrs[i+1].(*ssa.Defer)
+				if !ok {
+					continue
+				}
+				if !lint.IsCallTo(&nins.Call, "(*sync.Mutex).Lock") && !lint.IsCallTo(&nins.Call, "(*sync.RWMutex).RLock") {
+					continue
+				}
+				if call.Common().Args[0] != nins.Call.Args[0] {
+					continue
+				}
+				name := shortCallName(call.Common())
+				alt := ""
+				switch name {
+				case "Lock":
+					alt = "Unlock"
+				case "RLock":
+					alt = "RUnlock"
+				}
+				j.Errorf(nins, "deferring %s right after having locked already; did you mean to defer %s?", name, alt)
+			}
+		}
+	}
+}
+
+// concurrencyAnalyses returns the results of analyzing all functions
+// that aren't themselves started as goroutines by another function;
+// those are part of the analysis of the function that starts them.
+func (c *Checker) concurrencyAnalyses(j *lint.Job) []*concurrency.Analysis {
+	c.concurrencyOnce.Do(func() {
+		spawned := map[*ssa.Function]bool{}
+		for _, ssafn := range j.Program.InitialFunctions {
+			for _, block := range ssafn.Blocks {
+				for _, ins := range block.Instrs {
+					gostmt, ok := ins.(*ssa.Go)
+					if !ok {
+						continue
+					}
+					switch val := gostmt.Call.Value.(type) {
+					case *ssa.Function:
+						spawned[val] = true
+					case *ssa.MakeClosure:
+						spawned[val.Fn.(*ssa.Function)] = true
+					}
+				}
+			}
+		}
+		for _, ssafn := range j.Program.InitialFunctions {
+			if spawned[ssafn] || ssafn.Blocks == nil {
+				continue
+			}
+			c.concurrency = append(c.concurrency, concurrency.Analyze(ssafn))
+		}
+	})
+	return c.concurrency
+}
+
+func (c *Checker) CheckCapturedVariableRace(j *lint.Job) {
+	for _, a := range c.concurrencyAnalyses(j) {
+		if len(a.Accesses) == 0 {
+			continue
+		}
+		reported := map[*ssa.Alloc]bool{}
+		for _, x := range a.Accesses {
+			if x.Goroutine.Parent == nil || reported[x.Variable] {
+				// Only report accesses in spawned goroutines, and
+				// only once per variable.
+				continue
+			}
+			for _, y := range a.Accesses {
+				if x.Variable != y.Variable || (!x.Write && !y.Write) {
+					continue
+				}
+				if !a.Concurrent(x.Instr, y.Instr) {
+					continue
+				}
+				verb := "reads"
+				if x.Write {
+					verb = "writes"
+				}
+				other := "read"
+				if y.Write {
+					other = "written"
+				}
+				j.Errorf(x.Instr, "goroutine %s captured variable %s, which is concurrently %s without synchronization",
+					verb, x.Variable.Comment, other).
+					Relate(y.Instr.Pos(), "concurrent access")
+				reported[x.Variable] = true
+				break
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckSendOnClosedChannel(j *lint.Job) {
+	for _, a := range c.concurrencyAnalyses(j) {
+		for _, cl := range a.Ops {
+			if cl.Kind != concurrency.Close || cl.Deferred {
+				continue
+			}
+			for _, send := range a.Ops {
+				if send.Kind != concurrency.Send || send.Object != cl.Object {
+					continue
+				}
+				if _, ok := send.Instr.(*ssa.Send); !ok {
+					continue
+				}
+				if a.HappensBefore(cl.Instr, send.Instr) {
+					j.Errorf(send.Instr, "sending on a channel that has already been closed will panic").
+						Relate(cl.Instr.Pos(), "channel closed here")
+				}
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckDoubleClose(j *lint.Job) {
+	for _, a := range c.concurrencyAnalyses(j) {
+		for _, first := range a.Ops {
+			if first.Kind != concurrency.Close || first.Deferred {
+				continue
+			}
+			for _, second := range a.Ops {
+				if second.Kind != concurrency.Close || first == second || second.Object != first.Object {
+					continue
+				}
+				if second.Deferred {
+					// The deferred close runs when the function
+					// returns, no matter when it was deferred.
+					if a.Goroutine(second.Instr) != a.Goroutine(first.Instr) ||
+						(!a.HappensBefore(second.Instr, first.Instr) && !a.HappensBefore(first.Instr, second.Instr)) {
+						continue
+					}
+				} else if !a.HappensBefore(first.Instr, second.Instr) {
+					continue
+				}
+				j.Errorf(second.Instr, "closing a channel that has already been closed will panic").
+					Relate(first.Instr.Pos(), "channel closed here")
+			}
+		}
+	}
+}
+
+// isTimeoutChannel reports whether v is a channel that is used to
+// give up on waiting: the result of time.After, the C field of a
+// time.Timer, or the result of calling Done on a context.Context.
+func isTimeoutChannel(v ssa.Value) bool {
+	switch v := v.(type) {
+	case *ssa.Call:
+		call := v.Common()
+		if lint.IsCallTo(call, "time.After") {
+			return true
+		}
+		return call.IsInvoke() && call.Method.Name() == "Done" &&
+			types.TypeString(call.Value.Type(), nil) == "context.Context"
+	case *ssa.UnOp:
+		if v.Op != token.MUL {
+			return false
+		}
+		field, ok := v.X.(*ssa.FieldAddr)
+		if !ok || types.TypeString(field.X.Type(), nil) != "*time.Timer" {
+			return false
+		}
+		st := field.X.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct)
+		return st.Field(field.Field).Name() == "C"
+	}
+	return false
+}
+
+func (c *Checker) CheckAbandonedSend(j *lint.Job) {
+	// isAbandoning reports whether instr is a select, outside of
+	// loops, that receives from ch but may instead wait for a
+	// timeout.
+	isAbandoning := func(instr ssa.Instruction, ch ssa.Value) bool {
+		sel, ok := instr.(*ssa.Select)
+		if !ok || !sel.Blocking || c.isInLoop(sel.Block()) {
+			return false
+		}
+		for _, state := range sel.States {
+			if state.Dir == types.RecvOnly && state.Chan != ch && isTimeoutChannel(state.Chan) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, a := range c.concurrencyAnalyses(j) {
+		for _, send := range a.Ops {
+			if send.Kind != concurrency.Send || send.Goroutine.Parent == nil || send.Object.Path != "" {
+				continue
+			}
+			if _, ok := send.Instr.(*ssa.Send); !ok {
+				continue
+			}
+			mc, ok := send.Object.Root.(*ssa.MakeChan)
+			if !ok {
+				continue
+			}
+			if k, ok := mc.Size.(*ssa.Const); !ok || k.Int64() != 0 {
+				continue
+			}
+			var sel ssa.Instruction
+			abandoned := true
+			for _, recv := range a.Ops {
+				if recv.Kind != concurrency.Recv || recv.Object != send.Object {
+					continue
+				}
+				var ch ssa.Value
+				if s, ok := recv.Instr.(*ssa.Select); ok {
+					for _, state := range s.States {
+						if state.Dir == types.RecvOnly && a.Object(recv.Goroutine, state.Chan) == send.Object {
+							ch = state.Chan
+						}
+					}
+				}
+				if recv.Goroutine == send.Goroutine || ch == nil || !isAbandoning(recv.Instr, ch) {
+					abandoned = false
+					break
+				}
+				sel = recv.Instr
+			}
+			if sel == nil || !abandoned || a.Escapes(send.Object) {
+				continue
+			}
+			j.Errorf(send.Instr, "goroutine blocks forever sending on an unbuffered channel if the select gives up on receiving; give the channel a buffer of 1").
+				Relate(sel.Pos(), "select")
+		}
+	}
+}
+
+func (c *Checker) CheckBlockingSend(j *lint.Job) {
+	for _, a := range c.concurrencyAnalyses(j) {
+	opLoop:
+		for _, send := range a.Ops {
+			ins, ok := send.Instr.(*ssa.Send)
+			if !ok || send.Kind != concurrency.Send || send.Object.Path != "" {
+				continue
+			}
+			if _, ok := send.Object.Root.(*ssa.MakeChan); !ok {
+				continue
+			}
+			// Count the sends that happen before this one in the
+			// same goroutine. Sends in selects may not happen.
+			n := 1
+			for _, op := range a.Ops {
+				if op.Object != send.Object {
+					continue
+				}
+				switch op.Kind {
+				case concurrency.Recv, concurrency.Close:
+					// Receiving makes room in the buffer and sending on
+					// a closed channel panics instead of blocking.
+					continue opLoop
+				case concurrency.Send:
+					if _, ok := op.Instr.(*ssa.Send); ok && op != send &&
+						op.Goroutine == send.Goroutine && precedes(op.Instr, ins) {
+						n++
+					}
+				}
+			}
+			size, ok := c.funcDescs.Get(ins.Parent()).Ranges.Get(ins.Chan).(vrp.ChannelInterval)
+			if !ok || !size.IsKnown() || size.Size.Empty() || size.Size.Upper.Infinite() ||
+				vrp.NewZ(int64(n)).Cmp(size.Size.Upper) <= 0 {
+				continue
+			}
+			if a.Escapes(send.Object) {
+				continue
+			}
+			if n == 1 {
+				j.Errorf(ins, "send blocks forever, the channel is unbuffered and nothing receives from it")
+				continue
+			}
+			prev := "the preceding send"
+			if n > 2 {
+				prev = fmt.Sprintf("the %d preceding sends", n-1)
+			}
+			j.Errorf(ins, "send blocks forever, the channel's buffer is full after %s and nothing receives from it", prev)
+		}
+	}
+}
+
+func (c *Checker) CheckWaitWhileLocked(j *lint.Job) {
+	for _, a := range c.concurrencyAnalyses(j) {
+		for _, wait := range a.Ops {
+			if wait.Kind != concurrency.Wait || wait.Deferred {
+				continue
+			}
+			held := a.LocksHeld(wait.Instr)
+			if len(held) == 0 {
+				continue
+			}
+		ops:
+			for _, done := range a.Ops {
+				if done.Kind != concurrency.Done || done.Object != wait.Object || done.Goroutine == wait.Goroutine {
+					continue
+				}
+				for _, lock := range a.Ops {
+					if lock.Kind != concurrency.Lock || lock.Goroutine != done.Goroutine || lock.Deferred {
+						continue
+					}
+					if !done.Deferred && !a.HappensBefore(lock.Instr, done.Instr) {
+						continue
+					}
+					for _, obj := range held {
+						if obj != lock.Object {
+							continue
+						}
+						j.Errorf(wait.Instr, "waiting while holding a lock that a goroutine has to acquire before it can call Done will deadlock").
+							Relate(lock.Instr.Pos(), "lock acquired by the goroutine")
+						break ops
+					}
+				}
+			}
+		}
+	}
+}
+
+// containsLock reports whether values of type T contain a sync.Mutex
+// or sync.RWMutex, either directly or in fields and array elements.
+func containsLock(T types.Type) bool {
+	switch T := T.(type) {
+	case *types.Named:
+		switch types.TypeString(T, nil) {
+		case "sync.Mutex", "sync.RWMutex":
+			return true
+		}
+		return containsLock(T.Underlying())
+	case *types.Struct:
+		for i := 0; i < T.NumFields(); i++ {
+			if containsLock(T.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Array:
+		return containsLock(T.Elem())
+	}
+	return false
+}
+
+// mutexName returns a name for the mutex pointed to by v, such as
+// s.mu, or the empty string if the mutex can't be identified across
+// instructions. Two values refer to the same mutex if their names are
+// equal.
+func mutexName(v ssa.Value) string {
+	switch v := v.(type) {
+	case *ssa.FieldAddr:
+		x := mutexName(v.X)
+		if x == "" {
+			return ""
+		}
+		T := v.X.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct)
+		return x + "." + T.Field(v.Field).Name()
+	case *ssa.UnOp:
+		if v.Op == token.MUL {
+			return mutexName(v.X)
+		}
+	case *ssa.Global, *ssa.Parameter, *ssa.FreeVar, *ssa.Alloc:
+		return v.Name()
+	}
+	return ""
+}
+
+type mutexOp struct {
+	kind     concurrency.OpKind
+	name     string
+	read     bool
+	deferred bool
+}
+
+// mutexOpOf returns the operation on a mutex that ins performs, if
+// any.
+func mutexOpOf(ins ssa.Instruction) (mutexOp, bool) {
+	call, ok := ins.(ssa.CallInstruction)
+	if !ok {
+		return mutexOp{}, false
+	}
+	if _, ok := ins.(*ssa.Go); ok {
+		return mutexOp{}, false
+	}
+	var op mutexOp
+	switch lint.CallName(call.Common()) {
+	case "(*sync.Mutex).Lock", "(*sync.RWMutex).Lock":
+		op.kind = concurrency.Lock
+	case "(*sync.RWMutex).RLock":
+		op.kind, op.read = concurrency.Lock, true
+	case "(*sync.Mutex).Unlock", "(*sync.RWMutex).Unlock":
+		op.kind = concurrency.Unlock
+	case "(*sync.RWMutex).RUnlock":
+		op.kind, op.read = concurrency.Unlock, true
+	default:
+		return mutexOp{}, false
+	}
+	op.name = mutexName(call.Common().Args[0])
+	if op.name == "" {
+		return mutexOp{}, false
+	}
+	if op.read {
+		op.name += " (read)"
+	}
+	_, op.deferred = ins.(*ssa.Defer)
+	return op, true
+}
+
+func (c *Checker) CheckUnbalancedLock(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		if len(ssafn.Blocks) == 0 {
+			continue
+		}
+		locked := map[string]bool{}
+		unlocked := map[string]bool{}
+		hasOps, opaqueDefer := false, false
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				op, ok := mutexOpOf(ins)
+				if !ok {
+					if _, ok := ins.(*ssa.Defer); ok {
+						// Deferred closures may unlock anything.
+						opaqueDefer = true
+					}
+					continue
+				}
+				hasOps = true
+				if op.kind == concurrency.Lock {
+					locked[op.name] = true
+				} else {
+					unlocked[op.name] = true
+				}
+			}
+		}
+		if !hasOps || opaqueDefer {
+			continue
+		}
+
+		type state struct {
+			block *ssa.BasicBlock
+			// held maps the names of held mutexes to the Lock calls
+			// that locked them.
+			held     map[string]ssa.Instruction
+			released map[string]bool
+			deferred map[string]bool
+		}
+		key := func(s state) string {
+			var parts []string
+			for name := range s.held {
+				parts = append(parts, "h"+name)
+			}
+			for name := range s.released {
+				parts = append(parts, "r"+name)
+			}
+			for name := range s.deferred {
+				parts = append(parts, "d"+name)
+			}
+			sort.Strings(parts)
+			return fmt.Sprintf("%p %s", s.block, strings.Join(parts, ","))
+		}
+		clone := func(s state) state {
+			out := state{s.block, map[string]ssa.Instruction{}, map[string]bool{}, map[string]bool{}}
+			for k, v := range s.held {
+				out.held[k] = v
+			}
+			for k, v := range s.released {
+				out.released[k] = v
+			}
+			for k, v := range s.deferred {
+				out.deferred[k] = v
+			}
+			return out
+		}
+
+		reported := map[ssa.Instruction]bool{}
+		report := func(ins ssa.Instruction, format string, args ...interface{}) *lint.Problem {
+			if reported[ins] || !ins.Pos().IsValid() {
+				return nil
+			}
+			reported[ins] = true
+			return j.Errorf(ins, format, args...)
+		}
+		seen := map[string]bool{}
+		queue := []state{{ssafn.Blocks[0], map[string]ssa.Instruction{}, map[string]bool{}, map[string]bool{}}}
+		for len(queue) > 0 {
+			s := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			if k := key(s); seen[k] {
+				continue
+			} else {
+				seen[k] = true
+			}
+			s = clone(s)
+			for _, ins := range s.block.Instrs {
+				if op, ok := mutexOpOf(ins); ok {
+					switch {
+					case op.kind == concurrency.Lock:
+						s.held[op.name] = ins
+						delete(s.released, op.name)
+					case op.deferred:
+						s.deferred[op.name] = true
+					case s.held[op.name] != nil:
+						delete(s.held, op.name)
+						s.released[op.name] = true
+					case s.released[op.name]:
+						report(ins, "%s is unlocked twice on some paths", strings.TrimSuffix(op.name, " (read)"))
+					case !locked[op.name] && len(s.held) > 0:
+						for name := range s.held {
+							if strings.HasSuffix(name, " (read)") == op.read {
+								report(ins, "unlocking %s, but the mutex locked by this function is %s",
+									strings.TrimSuffix(op.name, " (read)"), strings.TrimSuffix(name, " (read)"))
+								break
+							}
+						}
+					}
+				}
+				if _, ok := ins.(*ssa.Return); ok {
+					for name, lock := range s.held {
+						// Functions that never unlock the mutex
+						// themselves are usually meant to return with
+						// it held.
+						if s.deferred[name] || !unlocked[name] {
+							continue
+						}
+						p := report(lock, "%s is locked here but not unlocked on some paths that return",
+							strings.TrimSuffix(name, " (read)"))
+						if p != nil && ins.Pos().IsValid() {
+							p.Relate(ins.Pos(), "return without unlocking")
+						}
+					}
+				}
+			}
+			for _, succ := range s.block.Succs {
+				next := s
+				next.block = succ
+				queue = append(queue, next)
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckCopiedLock(j *lint.Job) {
+	qualifier := func(pkg *types.Package) string { return pkg.Name() }
+	for _, ssafn := range j.Program.InitialFunctions {
+		if ssafn.Synthetic != "" {
+			continue
+		}
+		if recv := ssafn.Signature.Recv(); recv != nil && containsLock(recv.Type()) {
+			j.Errorf(ssafn, "method %s has a value receiver of type %s, which copies the lock it contains on every call",
+				ssafn.Name(), types.TypeString(recv.Type(), qualifier))
+		}
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				switch ins := ins.(type) {
+				case *ssa.Lookup:
+					T := ins.Type()
+					if ins.CommaOk {
+						T = T.(*types.Tuple).At(0).Type()
+					}
+					if _, ok := ins.X.Type().Underlying().(*types.Map); ok && containsLock(T) {
+						j.Errorf(ins, "copying a value of type %s out of a map copies the lock it contains",
+							types.TypeString(T, qualifier))
+					}
+				case *ssa.UnOp:
+					if ins.Op != token.MUL {
+						continue
+					}
+					addr, ok := ins.X.(*ssa.IndexAddr)
+					if !ok || !addr.Pos().IsValid() || !containsLock(ins.Type()) {
+						// Ranging over a slice loads elements without
+						// a position; vet's copylocks flags those.
+						continue
+					}
+					j.Errorf(addr, "copying an element of type %s out of a slice or array copies the lock it contains",
+						types.TypeString(ins.Type(), qualifier))
+				case *ssa.Extract:
+					if ins.Index != 2 {
+						continue
+					}
+					next, ok := ins.Tuple.(*ssa.Next)
+					if !ok || next.IsString || !containsLock(ins.Type()) {
+						continue
+					}
+					if rng, ok := next.Iter.(*ssa.Range); ok && rng.Pos().IsValid() {
+						j.Errorf(rng, "ranging over a map with values of type %s copies the lock they contain",
+							types.TypeString(ins.Type(), qualifier))
+					}
+				case *ssa.Go:
+					for _, arg := range ins.Call.Args {
+						if containsLock(arg.Type()) {
+							j.Errorf(ins, "passing a value of type %s to a goroutine copies the lock it contains",
+								types.TypeString(arg.Type(), qualifier))
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckNaNComparison(j *lint.Job) {
+	isNaN := func(v ssa.Value) bool {
+		call, ok := v.(*ssa.Call)
+		if !ok {
+			return false
+		}
+		return lint.IsCallTo(call.Common(), "math.NaN")
+	}
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				ins, ok := ins.(*ssa.BinOp)
+				if !ok {
+					continue
+				}
+				if isNaN(ins.X) || isNaN(ins.Y) {
+					j.Errorf(ins, "no value is equal to NaN, not even NaN itself")
+				}
+			}
+		}
+	}
+}
+
+// canReturnAvoiding reports whether fn has a path that returns
+// without passing through block.
+func canReturnAvoiding(fn *ssa.Function, block *ssa.BasicBlock) bool {
+	for _, b := range fn.Blocks {
+		if block.Dominates(b) {
+			continue
+		}
+		if len(b.Instrs) == 0 {
+			continue
+		}
+		if _, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) CheckInfiniteRecursion(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		node := c.funcDescs.CallGraph.CreateNode(ssafn)
+		for _, edge := range node.Out {
+			if canReturnAvoiding(ssafn, edge.Site.Block()) {
+				continue
+			}
+			if edge.Callee == node {
+				j.Errorf(edge.Site, "infinite recursive call")
+				continue
+			}
+			// Look for cycles of length two. Only the function
+			// declared first reports the cycle.
+			callee := edge.Callee.Func
+			if callee.Pkg != ssafn.Pkg || callee.Pos() <= ssafn.Pos() {
+				continue
+			}
+			for _, back := range edge.Callee.Out {
+				if back.Callee != node || canReturnAvoiding(callee, back.Site.Block()) {
+					continue
+				}
+				j.Errorf(edge.Site, "infinite recursive call: %s unconditionally calls %s, which unconditionally calls %s",
+					ssafn.Name(), callee.Name(), ssafn.Name())
+				break
+			}
+		}
+		c.checkSelfFormattingStringer(j, ssafn)
+	}
+}
+
+// printFuncs are the functions in the standard library that format
+// their variadic arguments with the default format.
+var printFuncs = map[string]bool{
+	"fmt.Fprint":            true,
+	"fmt.Fprintln":          true,
+	"fmt.Print":             true,
+	"fmt.Println":           true,
+	"fmt.Sprint":            true,
+	"fmt.Sprintln":          true,
+	"log.Fatal":             true,
+	"log.Fatalln":           true,
+	"log.Panic":             true,
+	"log.Panicln":           true,
+	"log.Print":             true,
+	"log.Println":           true,
+	"(*log.Logger).Fatal":   true,
+	"(*log.Logger).Fatalln": true,
+	"(*log.Logger).Panic":   true,
+	"(*log.Logger).Panicln": true,
+	"(*log.Logger).Print":   true,
+	"(*log.Logger).Println": true,
+}
+
+// isParamValue reports whether v is the value of param, either
+// directly or loaded from a spill of param that is never written to
+// again.
+func isParamValue(v ssa.Value, param *ssa.Parameter) bool {
+	if v == param {
+		return true
+	}
+	load, ok := v.(*ssa.UnOp)
+	if !ok || load.Op != token.MUL {
+		return false
+	}
+	alloc, ok := load.X.(*ssa.Alloc)
+	if !ok {
+		return false
+	}
+	spilled := false
+	for _, ref := range *alloc.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.Store:
+			if ref.Val != param {
+				return false
+			}
+			spilled = true
+		case *ssa.UnOp:
+			if ref.Op != token.MUL {
+				return false
+			}
+		case *ssa.DebugRef:
+		default:
+			return false
+		}
+	}
+	return spilled
+}
+
+// checkSelfFormattingStringer flags String and Error methods that
+// unconditionally format their own receiver in a way that calls the
+// method again.
+func (c *Checker) checkSelfFormattingStringer(j *lint.Job, fn *ssa.Function) {
+	sig := fn.Signature
+	if sig.Recv() == nil || len(fn.Params) != 1 || sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return
+	}
+	if fn.Name() != "String" && fn.Name() != "Error" {
+		return
+	}
+	if !types.Identical(sig.Results().At(0).Type(), types.Typ[types.String]) {
+		return
+	}
+	recv := fn.Params[0]
+	for _, b := range fn.Blocks {
+		if canReturnAvoiding(fn, b) {
+			continue
+		}
+		for _, ins := range b.Instrs {
+			call, ok := ins.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			callee := call.Common().StaticCallee()
+			if callee == nil || callee.Object() == nil || len(call.Common().Args) == 0 {
+				continue
+			}
+			name := callee.Object().(*types.Func).FullName()
+			offset := 0
+			if callee.Signature.Recv() != nil {
+				offset = 1
+			}
+			var verbs []printfVerb
+			idx, isPrintf := printfFuncs[name]
+			if isPrintf {
+				format, ok := call.Common().Args[idx+offset].(*ssa.Const)
+				if !ok || format.Value == nil || format.Value.Kind() != constant.String {
+					continue
+				}
+				verbs, ok = parsePrintfVerbs(constant.StringVal(format.Value))
+				if !ok {
+					continue
+				}
+			} else if !printFuncs[name] {
+				continue
+			}
+			args := variadicArgs(call.Common().Args[len(call.Common().Args)-1])
+			for i, arg := range args {
+				iface, ok := arg.(*ssa.MakeInterface)
+				if !ok || !isParamValue(iface.X, recv) {
+					continue
+				}
+				verb := 'v'
+				if isPrintf {
+					verb = 0
+					for _, v := range verbs {
+						if v.arg == i && strings.ContainsRune("vsxXq", v.verb) {
+							verb = v.verb
+							break
+						}
+					}
+					if verb == 0 {
+						continue
+					}
+				}
+				j.Errorf(call, "infinite recursive call: formatting the receiver with %%%c calls its %s method again", verb, fn.Name())
+				break
+			}
+		}
+	}
+}
+
+// precedes reports whether x, if it executes, does so before y. Both
+// instructions must belong to the same function.
+func precedes(x, y ssa.Instruction) bool {
+	if x.Block() != y.Block() {
+		return x.Block().Dominates(y.Block())
+	}
+	for _, ins := range x.Block().Instrs {
+		switch ins {
+		case x:
+			return true
+		case y:
+			return false
+		}
+	}
+	return false
+}
+
+func (c *Checker) CheckExitSkipsDefers(j *lint.Job) {
+	exits := []string{
+		"os.Exit",
+		"log.Fatal", "log.Fatalf", "log.Fatalln",
+		"(*log.Logger).Fatal", "(*log.Logger).Fatalf", "(*log.Logger).Fatalln",
+	}
+	isExit := func(call *ssa.Call) bool {
+		for _, name := range exits {
+			if lint.IsCallTo(call.Common(), name) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, ssafn := range j.Program.InitialFunctions {
+		var defers []*ssa.Defer
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				if d, ok := ins.(*ssa.Defer); ok {
+					defers = append(defers, d)
+				}
+			}
+		}
+		if len(defers) == 0 {
+			continue
+		}
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				call, ok := ins.(*ssa.Call)
+				if !ok || !isExit(call) {
+					continue
+				}
+				for _, d := range defers {
+					if !precedes(d, call) {
+						continue
+					}
+					if callee := d.Call.StaticCallee(); callee != nil && c.funcDescs.Get(callee).Pure {
+						// Skipping a call without side effects is harmless.
+						continue
+					}
+					j.Errorf(call, "%s exits the program without running a deferred function",
+						lint.CallName(call.Common())).
+						Relate(d.Pos(), "deferred call")
+					break
+				}
+			}
+		}
+	}
+}
+
+// mayHaveSpareCapacity reports whether the slice v may have a
+// capacity larger than its length, in which case appending to it
+// doesn't allocate a new backing array.
+func mayHaveSpareCapacity(v ssa.Value) bool {
+	switch v := v.(type) {
+	case *ssa.Const:
+		// nil
+		return false
+	case *ssa.MakeSlice:
+		return v.Len != v.Cap
+	case *ssa.Slice:
+		// Slice literals are slices of entire arrays.
+		_, ok := v.X.(*ssa.Alloc)
+		return !ok || v.Low != nil || v.High != nil || v.Max != nil
+	}
+	return true
+}
+
+func (c *Checker) CheckAppendAliasing(j *lint.Job) {
+	isAppend := func(ins ssa.Instruction) (*ssa.Call, bool) {
+		call, ok := ins.(*ssa.Call)
+		if !ok {
+			return nil, false
+		}
+		builtin, ok := call.Common().Value.(*ssa.Builtin)
+		return call, ok && builtin.Name() == "append"
+	}
+	// usedAfter reports whether v is used by an instruction that
+	// executes after ins.
+	usedAfter := func(v ssa.Value, ins ssa.Instruction) bool {
+		refs := v.Referrers()
+		if refs == nil {
+			return false
+		}
+		for _, ref := range *refs {
+			if _, ok := ref.(*ssa.DebugRef); ok {
+				continue
+			}
+			if ref != ins && precedes(ins, ref) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				first, ok := isAppend(ins)
+				if !ok {
+					continue
+				}
+				base := first.Common().Args[0]
+				if !mayHaveSpareCapacity(base) {
+					continue
+				}
+				refs := base.Referrers()
+				if refs == nil {
+					continue
+				}
+				for _, ref := range *refs {
+					if ref == first || !precedes(first, ref) {
+						continue
+					}
+					if second, ok := isAppend(ref); ok && second.Common().Args[0] == base {
+						if usedAfter(first, second) {
+							j.Errorf(second, "append may overwrite the elements added by an earlier append, as both can share the backing array of the slice they append to").
+								Relate(first.Pos(), "first append")
+						}
+						continue
+					}
+					addr, ok := ref.(*ssa.IndexAddr)
+					if !ok || addr.X != base {
+						continue
+					}
+					arefs := addr.Referrers()
+					if arefs == nil {
+						continue
+					}
+					for _, aref := range *arefs {
+						if store, ok := aref.(*ssa.Store); ok && store.Addr == addr && usedAfter(first, store) {
+							j.Errorf(store, "modifying the slice after appending to it may also modify the result of the append, as both can share a backing array").
+								Relate(first.Pos(), "append")
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func (c *Checker) CheckNilDereference(j *lint.Job) {
+	for _, ssafn := range j.Program.InitialFunctions {
+		a := nilness.Analyze(ssafn)
+		isNil := func(ins ssa.Instruction, v ssa.Value) bool {
+			return ins.Pos().IsValid() && a.At(ins, v) == nilness.Nil
+		}
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				switch ins := ins.(type) {
+				case *ssa.UnOp:
+					switch {
+					case ins.Op == token.MUL && isNil(ins, ins.X):
+						j.Errorf(ins, "dereferencing a pointer that is always nil")
+					case ins.Op == token.ARROW && isNil(ins, ins.X):
+						j.Errorf(ins, "receiving from a nil channel blocks forever")
+					}
+				case *ssa.FieldAddr:
+					if isNil(ins, ins.X) {
+						j.Errorf(ins, "accessing field %s of a pointer that is always nil",
+							ins.X.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct).Field(ins.Field).Name())
+					}
+				case *ssa.Store:
+					if isNil(ins, ins.Addr) {
+						j.Errorf(ins, "storing through a pointer that is always nil")
+					}
+				case *ssa.Send:
+					if isNil(ins, ins.Chan) {
+						j.Errorf(ins, "sending on a nil channel blocks forever")
+					}
+				case *ssa.Call:
+					call := ins.Common()
+					switch {
+					case call.IsInvoke():
+						if isNil(ins, call.Value) {
+							j.Errorf(ins, "calling method %s on an interface value that is always nil", call.Method.Name())
+						}
+					case isBuiltinCall(call, "close"):
+						if isNil(ins, call.Args[0]) {
+							j.Errorf(ins, "closing a nil channel")
+						}
+					case call.StaticCallee() == nil:
+						if _, ok := call.Value.(*ssa.Builtin); !ok && isNil(ins, call.Value) {
+							j.Errorf(ins, "calling a function value that is always nil")
+						}
+					}
+				case *ssa.If:
+					cond, ok := ins.Cond.(*ssa.BinOp)
+					if !ok || (cond.Op != token.EQL && cond.Op != token.NEQ) || !cond.Pos().IsValid() {
+						continue
+					}
+					v := cond.X
+					if k, ok := v.(*ssa.Const); ok && k.IsNil() {
+						v = cond.Y
+					} else if k, ok := cond.Y.(*ssa.Const); !ok || !k.IsNil() {
+						continue
+					}
+					if deref := a.DereferencedBefore(ins, v); deref != nil && deref.Pos().IsValid() {
+						j.Errorf(cond, "nil check is redundant, the value has already been dereferenced").
+							Relate(deref.Pos(), "dereference")
+					}
+				}
+			}
+		}
+	}
+}
+
+var stdlibDecoders = []string{
+	"encoding/json.Unmarshal",
+	"(*encoding/json.Decoder).Decode",
+	"encoding/xml.Unmarshal",
+	"(*encoding/xml.Decoder).Decode",
+	"(*encoding/gob.Decoder).Decode",
+	"encoding/asn1.Unmarshal",
+}
+
+// externalInput returns the interface values of fn that hold external
+// input: the parameters of exported functions, data filled in by
+// decoders, and everything extracted from either of them.
+func externalInput(fn *ssa.Function, decoders map[string]bool) map[ssa.Value]bool {
+	external := map[ssa.Value]bool{}
+	if obj := fn.Object(); obj != nil && obj.Exported() && fn.Parent() == nil {
+		params := fn.Params
+		if fn.Signature.Recv() != nil {
+			params = params[1:]
+		}
+		for _, param := range params {
+			external[param] = true
+		}
+	}
+	decoded := map[ssa.Value]bool{}
+	for _, block := range fn.Blocks {
+		for _, ins := range block.Instrs {
+			call, ok := ins.(ssa.CallInstruction)
+			if !ok || !decoders[lint.CallName(call.Common())] {
+				continue
+			}
+			for _, arg := range call.Common().Args {
+				if iface, ok := arg.(*ssa.MakeInterface); ok {
+					arg = iface.X
+				}
+				if _, ok := arg.Type().Underlying().(*types.Pointer); ok {
+					decoded[addrRoot(arg)] = true
+				}
+			}
+		}
+	}
+
+	// Phis may refer to values defined later in the function, so
+	// iterate until we reach a fixed point.
+	for changed := true; changed; {
+		changed = false
+		for _, block := range fn.Blocks {
+			for _, ins := range block.Instrs {
+				v, ok := ins.(ssa.Value)
+				if !ok || external[v] {
+					continue
+				}
+				var ext bool
+				switch v := v.(type) {
+				case *ssa.UnOp:
+					ext = v.Op == token.MUL && (decoded[addrRoot(v.X)] || external[addrRoot(v.X)])
+				case *ssa.TypeAssert:
+					ext = external[v.X]
+				case *ssa.ChangeInterface:
+					ext = external[v.X]
+				case *ssa.Extract:
+					ext = external[v.Tuple]
+				case *ssa.Lookup:
+					ext = external[v.X]
+				case *ssa.Index:
+					ext = external[v.X]
+				case *ssa.IndexAddr:
+					ext = external[v.X]
+				case *ssa.Range:
+					ext = external[v.X]
+				case *ssa.Next:
+					ext = external[v.Iter]
+				case *ssa.Sigma:
+					ext = external[v.X]
+				case *ssa.Phi:
+					for _, edge := range v.Edges {
+						if external[edge] {
+							ext = true
+							break
+						}
+					}
+				}
+				if ext {
+					external[v] = true
+					changed = true
+				}
+			}
+		}
+	}
+	return external
+}
+
+func addrRoot(addr ssa.Value) ssa.Value {
+	for {
+		switch v := addr.(type) {
+		case *ssa.FieldAddr:
+			addr = v.X
+		case *ssa.IndexAddr:
+			addr = v.X
+		default:
+			return addr
+		}
+	}
+}
+
+func (c *Checker) CheckUncheckedAssertion(j *lint.Job) {
+	decoders := map[string]bool{}
+	for _, name := range stdlibDecoders {
+		decoders[name] = true
+	}
+	for _, name := range c.Decoders {
+		decoders[name] = true
+	}
+	qualifier := func(pkg *types.Package) string { return pkg.Name() }
+	for _, ssafn := range j.Program.InitialFunctions {
+		var external map[ssa.Value]bool
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				assert, ok := ins.(*ssa.TypeAssert)
+				if !ok || assert.CommaOk || !assert.Pos().IsValid() {
+					continue
+				}
+				if external == nil {
+					external = externalInput(ssafn, decoders)
+				}
+				if !external[assert.X] {
+					continue
+				}
+				T := types.TypeString(assert.AssertedType, qualifier)
+				j.Errorf(assert, "type assertion to %s on external input panics if the assertion fails, use the comma-ok form: v, ok := x.(%s)", T, T)
+			}
+		}
+	}
+}
+
+// A Resource describes values that have to be released once they are
+// no longer needed, such as open files.
+type Resource struct {
+	// Constructor is the fully qualified name of a function or
+	// method that returns the resource as its first result, such as
+	// os.Open or (*database/sql.DB).Begin.
+	Constructor string
+	// Releasers are the names of the resource's methods that release
+	// it, such as Close.
+	Releasers []string
+}
+
+var stdlibResources = []Resource{
+	{"os.Open", []string{"Close"}},
+	{"os.OpenFile", []string{"Close"}},
+	{"os.Create", []string{"Close"}},
+	{"net.Dial", []string{"Close"}},
+	{"net.DialTimeout", []string{"Close"}},
+	{"net.Listen", []string{"Close"}},
+	{"net.ListenPacket", []string{"Close"}},
+	{"(*net.Dialer).Dial", []string{"Close"}},
+	{"(*database/sql.DB).Begin", []string{"Commit", "Rollback"}},
+	{"(*database/sql.DB).Query", []string{"Close"}},
+	{"(*database/sql.DB).Prepare", []string{"Close"}},
+}
+
+// resourceUses classifies the uses of the resource r. It returns the
+// instructions that release r, and whether r escapes the function, in
+// which case someone else may be responsible for releasing it.
+func resourceUses(r ssa.Value, releasers []string) (releases map[ssa.Instruction]bool, escapes bool) {
+	isReleaser := func(name string) bool {
+		for _, releaser := range releasers {
+			if name == releaser {
+				return true
+			}
+		}
+		return false
+	}
+	releases = map[ssa.Instruction]bool{}
+	for _, ref := range *r.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.DebugRef, *ssa.BinOp, *ssa.FieldAddr:
+		case *ssa.Call, *ssa.Defer:
+			common := ref.(ssa.CallInstruction).Common()
+			var method string
+			switch {
+			case common.IsInvoke() && common.Value == r:
+				method = common.Method.Name()
+			case common.Signature().Recv() != nil && len(common.Args) > 0 && common.Args[0] == r:
+				method = common.StaticCallee().Name()
+			default:
+				return nil, true
+			}
+			if isReleaser(method) {
+				releases[ref] = true
+			}
+		default:
+			return nil, true
+		}
+	}
+	return releases, false
+}
+
+// leaks reports whether a path leads from call, which produced the
+// resource r and optionally the error err, to a return without
+// passing through one of releases. Paths on which err is non-nil or r
+// is nil don't have to release r.
+func leaks(call ssa.Instruction, r, err ssa.Value, releases map[ssa.Instruction]bool) bool {
+	seen := map[*ssa.BasicBlock]bool{}
+	var walk func(b *ssa.BasicBlock, instrs []ssa.Instruction) bool
+	walk = func(b *ssa.BasicBlock, instrs []ssa.Instruction) bool {
+		for _, ins := range instrs {
+			if releases[ins] {
+				return false
+			}
+			switch ins := ins.(type) {
+			case *ssa.Return:
+				return true
+			case *ssa.Panic:
+				return false
+			case *ssa.If:
+				succs := b.Succs
+				if cond, ok := ins.Cond.(*ssa.BinOp); ok && (cond.Op == token.EQL || cond.Op == token.NEQ) {
+					v := cond.X
+					if k, ok := v.(*ssa.Const); ok && k.IsNil() {
+						v = cond.Y
+					} else if k, ok := cond.Y.(*ssa.Const); !ok || !k.IsNil() {
+						v = nil
+					}
+					nilSucc, nonNilSucc := b.Succs[1], b.Succs[0]
+					if cond.Op == token.EQL {
+						nilSucc, nonNilSucc = nonNilSucc, nilSucc
+					}
+					switch {
+					case v == nil:
+					case v == err:
+						// r is only valid if err is nil
+						succs = []*ssa.BasicBlock{nilSucc}
+					case v == r:
+						succs = []*ssa.BasicBlock{nonNilSucc}
+					}
+				}
+				for _, succ := range succs {
+					if seen[succ] {
+						continue
+					}
+					seen[succ] = true
+					if walk(succ, succ.Instrs) {
+						return true
+					}
+				}
+				return false
+			}
+		}
+		for _, succ := range b.Succs {
+			if seen[succ] {
+				continue
+			}
+			seen[succ] = true
+			if walk(succ, succ.Instrs) {
+				return true
+			}
+		}
+		return false
+	}
+	b := call.Block()
+	for i, ins := range b.Instrs {
+		if ins == call {
+			return walk(b, b.Instrs[i+1:])
+		}
+	}
+	return false
+}
+
+func (c *Checker) CheckResourceLeak(j *lint.Job) {
+	resources := map[string]Resource{}
+	for _, res := range stdlibResources {
+		resources[res.Constructor] = res
+	}
+	for _, res := range c.Resources {
+		resources[res.Constructor] = res
+	}
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				call, ok := ins.(*ssa.Call)
+				if !ok {
+					continue
+				}
+				res, ok := resources[lint.CallName(call.Common())]
+				if !ok {
+					continue
+				}
+				var r, err ssa.Value = call, nil
+				if tuple, ok := call.Type().(*types.Tuple); ok {
+					r = nil
+					for _, ref := range *call.Referrers() {
+						ex, ok := ref.(*ssa.Extract)
+						if !ok {
+							continue
+						}
+						switch ex.Index {
+						case 0:
+							r = ex
+						case tuple.Len() - 1:
+							err = ex
+						}
+					}
+					if r == nil {
+						// Discarding the result altogether also discards
+						// the error, which is flagged elsewhere.
+						continue
+					}
+				}
+				releases, escapes := resourceUses(r, res.Releasers)
+				if escapes || !leaks(call, r, err, releases) {
+					continue
+				}
+				j.Errorf(call, "the result of %s is not released on all paths; make sure to call %s",
+					res.Constructor, strings.Join(res.Releasers, " or "))
+			}
+		}
+	}
+}
+
+// A TagSchema describes the grammar of the value of a struct tag key,
+// such as json:"name,omitempty". The value is a comma-separated list
+// of elements.
+type TagSchema struct {
+	// Name is the tag key, such as json.
+	Name string
+	// Named is true if the first element of the value names the
+	// field, as in encoding/json.
+	Named bool
+	// Required is true if the name must not be empty. It only
+	// applies to named tags.
+	Required bool
+	// Options lists the elements allowed in addition to the name.
+	Options []string
+	// Keys lists the keys of the key=value elements allowed in
+	// addition to the name.
+	Keys []string
+}
+
+var stdlibTagSchemas = []TagSchema{
+	{Name: "json", Named: true, Options: []string{"omitempty", "string"}},
+	{Name: "xml", Named: true, Options: []string{"attr", "chardata", "cdata", "innerxml", "comment", "any", "omitempty"}},
+}
+
+type structTag struct {
+	key   string
+	value string
+}
+
+// parseStructTag splits tag into its key:"value" pairs, following the
+// conventions of reflect.StructTag.
+func parseStructTag(tag string) ([]structTag, error) {
+	var pairs []structTag
+	for tag != "" {
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 {
+			return nil, errors.New("missing key")
+		}
+		if i+1 >= len(tag) || tag[i] != ':' {
+			return nil, fmt.Errorf("missing colon after key %q", tag[:i])
+		}
+		if tag[i+1] != '"' {
+			return nil, fmt.Errorf("value of key %q is not quoted", tag[:i])
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, fmt.Errorf("value of key %q is missing its closing quote", key)
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, fmt.Errorf("value of key %q is not a valid string: %s", key, err)
+		}
+		tag = tag[i+1:]
+		pairs = append(pairs, structTag{key, value})
+	}
+	return pairs, nil
+}
+
+// validate checks value against the schema.
+func (schema TagSchema) validate(value string) error {
+	elems := strings.Split(value, ",")
+	if schema.Named {
+		if schema.Required && elems[0] == "" {
+			return fmt.Errorf("%s tag has no name", schema.Name)
+		}
+		if schema.Name == "json" && value == "-" {
+			return nil
+		}
+		elems = elems[1:]
+	}
+	seen := map[string]bool{}
+	for _, elem := range elems {
+		if elem == "" {
+			continue
+		}
+		key := elem
+		if i := strings.Index(elem, "="); i >= 0 {
+			key = elem[:i]
+			if !containsString(schema.Keys, key) {
+				return fmt.Errorf("unknown key %q in %s tag", key, schema.Name)
 			}
+		} else if !containsString(schema.Options, elem) {
+			return fmt.Errorf("unknown option %q in %s tag", elem, schema.Name)
 		}
+		if seen[key] {
+			return fmt.Errorf("duplicate %q in %s tag", key, schema.Name)
+		}
+		seen[key] = true
 	}
+	return nil
 }
 
-func (c *Checker) CheckCyclicFinalizer(j *lint.Job) {
-	for _, ssafn := range j.Program.InitialFunctions {
-		node := c.funcDescs.CallGraph.CreateNode(ssafn)
-		for _, edge := range node.Out {
-			if edge.Callee.Func.RelString(nil) != "runtime.SetFinalizer" {
-				continue
-			}
-			arg0 := edge.Site.Common().Args[0]
-			if iface, ok := arg0.(*ssa.MakeInterface); ok {
-				arg0 = iface.X
-			}
-			unop, ok := arg0.(*ssa.UnOp)
-			if !ok {
+func containsString(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) CheckStructTags(j *lint.Job) {
+	schemas := map[string]TagSchema{}
+	for _, schema := range stdlibTagSchemas {
+		schemas[schema.Name] = schema
+	}
+	for _, schema := range c.StructTags {
+		schemas[schema.Name] = schema
+	}
+	fn := func(node ast.Node) bool {
+		T, ok := node.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		for _, field := range T.Fields.List {
+			if field.Tag == nil {
 				continue
 			}
-			v, ok := unop.X.(*ssa.Alloc)
-			if !ok {
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
 				continue
 			}
-			arg1 := edge.Site.Common().Args[1]
-			if iface, ok := arg1.(*ssa.MakeInterface); ok {
-				arg1 = iface.X
-			}
-			mc, ok := arg1.(*ssa.MakeClosure)
-			if !ok {
+			pairs, err := parseStructTag(tag)
+			if err != nil {
+				j.Errorf(field.Tag, "malformed struct tag: %s", err)
 				continue
 			}
-			for _, b := range mc.Bindings {
-				if b == v {
-					pos := j.Program.SSA.Fset.Position(mc.Fn.Pos())
-					j.Errorf(edge.Site, "the finalizer closes over the object, preventing the finalizer from ever running (at %s)", pos)
+			seen := map[string]bool{}
+			for _, pair := range pairs {
+				if seen[pair.key] {
+					j.Errorf(field.Tag, "duplicate struct tag key %q", pair.key)
+					continue
+				}
+				seen[pair.key] = true
+				schema, ok := schemas[pair.key]
+				if !ok {
+					continue
+				}
+				if err := schema.validate(pair.value); err != nil {
+					j.Errorf(field.Tag, "%s", err)
 				}
 			}
 		}
+		return true
+	}
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		ast.Inspect(f, fn)
 	}
 }
 
-func (c *Checker) CheckSliceOutOfBounds(j *lint.Job) {
+func isBuiltinCall(call *ssa.CallCommon, name string) bool {
+	builtin, ok := call.Value.(*ssa.Builtin)
+	return ok && builtin.Name() == name
+}
+
+func (c *Checker) CheckDivisionByZero(j *lint.Job) {
 	for _, ssafn := range j.Program.InitialFunctions {
+		ranges := c.funcDescs.Get(ssafn).Ranges
 		for _, block := range ssafn.Blocks {
+			if block.Index != 0 && len(block.Preds) == 0 {
+				continue
+			}
 			for _, ins := range block.Instrs {
-				ia, ok := ins.(*ssa.IndexAddr)
-				if !ok {
+				binop, ok := ins.(*ssa.BinOp)
+				if !ok || (binop.Op != token.QUO && binop.Op != token.REM) {
 					continue
 				}
-				if _, ok := ia.X.Type().Underlying().(*types.Slice); !ok {
+				if _, ok := binop.Y.(*ssa.Const); ok {
+					// Constant division by zero is a compile error.
 					continue
 				}
-				sr, ok1 := c.funcDescs.Get(ssafn).Ranges[ia.X].(vrp.SliceInterval)
-				idxr, ok2 := c.funcDescs.Get(ssafn).Ranges[ia.Index].(vrp.IntInterval)
-				if !ok1 || !ok2 || !sr.IsKnown() || !idxr.IsKnown() || sr.Length.Empty() || idxr.Empty() {
+				basic, ok := binop.Y.Type().Underlying().(*types.Basic)
+				if !ok || (basic.Info()&types.IsInteger) == 0 {
 					continue
 				}
-				if idxr.Lower.Cmp(sr.Length.Upper) >= 0 {
-					j.Errorf(ia, "index out of bounds")
+				r, ok := ranges.Get(binop.Y).(vrp.IntInterval)
+				if !ok || !r.IsKnown() || r.Empty() {
+					continue
+				}
+				if r.Lower.Sign() == 0 && r.Upper.Sign() == 0 {
+					j.Errorf(binop, "integer division by zero")
 				}
 			}
 		}
 	}
 }
 
-func (c *Checker) CheckDeferLock(j *lint.Job) {
+func (c *Checker) CheckLossyIntConversion(j *lint.Job) {
+	// TODO(dh): allow users to pass in a custom build environment
+	sizes := gcsizes.ForArch(build.Default.GOARCH)
 	for _, ssafn := range j.Program.InitialFunctions {
+		ranges := c.funcDescs.Get(ssafn).Ranges
 		for _, block := range ssafn.Blocks {
-			instrs := lint.FilterDebug(block.Instrs)
-			if len(instrs) < 2 {
-				continue
-			}
-			for i, ins := range instrs[:len(instrs)-1] {
-				call, ok := ins.(*ssa.Call)
+			for _, ins := range block.Instrs {
+				conv, ok := ins.(*ssa.Convert)
 				if !ok {
 					continue
 				}
-				if !lint.IsCallTo(call.Common(), "(*sync.Mutex).Lock") && !lint.IsCallTo(call.Common(), "(*sync.RWMutex).RLock") {
+				if _, ok := conv.X.(*ssa.Const); ok {
+					// Constant conversions are checked by the compiler.
 					continue
 				}
-				nins, ok := instrs[i+1].(*ssa.Defer)
-				if !ok {
+				if b, ok := conv.X.Type().Underlying().(*types.Basic); !ok || (b.Info()&types.IsInteger) == 0 {
 					continue
 				}
-				if !lint.IsCallTo(&nins.Call, "(*sync.Mutex).Lock") && !lint.IsCallTo(&nins.Call, "(*sync.RWMutex).RLock") {
+				lower, upper, ok := vrp.TypeBounds(conv.Type(), sizes)
+				if !ok {
 					continue
 				}
-				if call.Common().Args[0] != nins.Call.Args[0] {
+				r, ok := ranges.Get(conv.X).(vrp.IntInterval)
+				if !ok || !r.IsKnown() || r.Empty() {
 					continue
 				}
-				name := shortCallName(call.Common())
-				alt := ""
-				switch name {
-				case "Lock":
-					alt = "Unlock"
-				case "RLock":
-					alt = "RUnlock"
+				if r.Lower.Cmp(upper) == 1 || r.Upper.Cmp(lower) == -1 {
+					j.Errorf(conv, "conversion to %s changes the value, which is always in the range %s", conv.Type(), r)
 				}
-				j.Errorf(nins, "deferring %s right after having locked already; did you mean to defer %s?", name, alt)
 			}
 		}
 	}
 }
 
-func (c *Checker) CheckNaNComparison(j *lint.Job) {
-	isNaN := func(v ssa.Value) bool {
-		call, ok := v.(*ssa.Call)
-		if !ok {
-			return false
-		}
-		return lint.IsCallTo(call.Common(), "math.NaN")
-	}
+func (c *Checker) CheckMakeSize(j *lint.Job) {
+	// TODO(dh): allow users to pass in a custom build environment
+	sizes := gcsizes.ForArch(build.Default.GOARCH)
+	_, maxInt, _ := vrp.TypeBounds(types.Typ[types.Int], sizes)
 	for _, ssafn := range j.Program.InitialFunctions {
-		for _, block := range ssafn.Blocks {
-			for _, ins := range block.Instrs {
-				ins, ok := ins.(*ssa.BinOp)
-				if !ok {
-					continue
-				}
-				if isNaN(ins.X) || isNaN(ins.Y) {
-					j.Errorf(ins, "no value is equal to NaN, not even NaN itself")
-				}
+		ranges := c.funcDescs.Get(ssafn).Ranges
+		known := func(v ssa.Value) (vrp.IntInterval, bool) {
+			if v == nil {
+				return vrp.IntInterval{}, false
 			}
-		}
-	}
-}
-
-func (c *Checker) CheckInfiniteRecursion(j *lint.Job) {
-	for _, ssafn := range j.Program.InitialFunctions {
-		node := c.funcDescs.CallGraph.CreateNode(ssafn)
-		for _, edge := range node.Out {
-			if edge.Callee != node {
-				continue
+			if _, ok := v.(*ssa.Const); ok {
+				// Constant sizes are checked by the compiler.
+				return vrp.IntInterval{}, false
 			}
-
-			block := edge.Site.Block()
-			canReturn := false
-			for _, b := range ssafn.Blocks {
-				if block.Dominates(b) {
-					continue
-				}
-				if len(b.Instrs) == 0 {
-					continue
-				}
-				if _, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); ok {
-					canReturn = true
-					break
+			r, ok := ranges.Get(v).(vrp.IntInterval)
+			return r, ok && r.IsKnown() && !r.Empty()
+		}
+		negative := func(v ssa.Value) bool {
+			r, ok := known(v)
+			return ok && r.Upper.Sign() < 0
+		}
+		for _, block := range ssafn.Blocks {
+			for _, ins := range block.Instrs {
+				switch ins := ins.(type) {
+				case *ssa.MakeSlice:
+					if negative(ins.Len) {
+						j.Errorf(ins, "make with negative length")
+						continue
+					}
+					if ins.Cap != ins.Len && negative(ins.Cap) {
+						j.Errorf(ins, "make with negative capacity")
+						continue
+					}
+					l, ok1 := known(ins.Len)
+					k, ok2 := known(ins.Cap)
+					if ins.Cap != ins.Len && ok1 && ok2 && l.Lower.Cmp(k.Upper) == 1 {
+						j.Errorf(ins, "make with length larger than capacity")
+						continue
+					}
+					elem := sizes.Sizeof(ins.Type().Underlying().(*types.Slice).Elem())
+					if elem == 0 {
+						continue
+					}
+					if !ok2 {
+						k, ok2 = l, ok1
+					}
+					if ok2 && k.Lower.Mul(vrp.NewZ(elem)).Cmp(maxInt) == 1 {
+						j.Errorf(ins, "make with a size that exceeds the maximum allocation")
+					}
+				case *ssa.MakeChan:
+					if negative(ins.Size) {
+						j.Errorf(ins, "make with negative buffer size")
+					}
+				case *ssa.MakeMap:
+					if negative(ins.Reserve) {
+						j.Errorf(ins, "make with negative size hint")
+					}
 				}
 			}
-			if canReturn {
-				continue
-			}
-			j.Errorf(edge.Site, "infinite recursive call")
 		}
 	}
 }
@@ -2124,6 +6137,45 @@ func (c *Checker) CheckLeakyTimeTick(j *lint.Job) {
 			}
 		}
 	}
+	for _, ssafn := range j.Program.InitialFunctions {
+		for _, block := range ssafn.Blocks {
+			if !c.isInLoop(block) {
+				continue
+			}
+			for _, ins := range block.Instrs {
+				call, ok := ins.(*ssa.Call)
+				if !ok || !lint.IsCallTo(call.Common(), "time.After") {
+					continue
+				}
+				if isTimerRace(call) {
+					j.Errorf(call, "time.After in a loop creates a timer on every iteration that isn't released until it fires, use time.NewTimer and reset or stop it instead")
+				}
+			}
+		}
+	}
+}
+
+// isTimerRace reports whether the channel returned by the time.After
+// call is only used in a single execution of a select statement that
+// also waits on other channels. If one of the other channels wins,
+// the timer lingers until it fires. Channels that are selected on
+// repeatedly, or that escape, may be waited on later and aren't
+// considered.
+func isTimerRace(call *ssa.Call) bool {
+	race := false
+	for _, ref := range *call.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.DebugRef:
+		case *ssa.Select:
+			if ref.Block() != call.Block() || (len(ref.States) < 2 && ref.Blocking) {
+				return false
+			}
+			race = true
+		default:
+			return false
+		}
+	}
+	return race
 }
 
 func (c *Checker) CheckDoubleNegation(j *lint.Job) {
@@ -2351,15 +6403,64 @@ func enclosingFunction(j *lint.Job, node ast.Node) *ast.FuncDecl {
 	return nil
 }
 
+// deprecatedFact is the deprecation notice of an object, recorded
+// for checking importers of its package without its source.
+type deprecatedFact struct {
+	Msg string
+}
+
+func (*deprecatedFact) AFact() {}
+
+func (f *deprecatedFact) String() string { return "Deprecated: " + f.Msg }
+
+// SSAMode requests σ-nodes, which vrp uses to narrow the ranges of
+// values in the branches that compare them.
+func (c *Checker) SSAMode() ssa.BuilderMode {
+	return ssa.Sigmas
+}
+
+func (c *Checker) FactTypes() []analysis.Fact {
+	return []analysis.Fact{new(deprecatedFact)}
+}
+
 func (c *Checker) isDeprecated(j *lint.Job, ident *ast.Ident) (bool, string) {
 	obj := j.Program.Info.ObjectOf(ident)
 	if obj.Pkg() == nil {
 		return false, ""
 	}
 	alt := c.deprecatedObjs[obj]
+	if alt == "" && j.Program.Facts != nil {
+		var fact deprecatedFact
+		if j.Program.Facts.ImportObjectFact(obj, &fact) {
+			alt = fact.Msg
+		}
+	}
 	return alt != "", alt
 }
 
+// deprecationKey returns the key of obj, selected by sel, in
+// deprecated.Stdlib.
+func deprecationKey(j *lint.Job, sel *ast.SelectorExpr, obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return obj.FullName()
+	case *types.Var:
+		if !obj.IsField() {
+			break
+		}
+		selection, ok := j.Program.Info.Selections[sel]
+		if !ok {
+			break
+		}
+		T := selection.Recv()
+		if ptr, ok := T.Underlying().(*types.Pointer); ok {
+			T = ptr.Elem()
+		}
+		return "(" + types.TypeString(T, nil) + ")." + obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
 func (c *Checker) CheckDeprecated(j *lint.Job) {
 	fn := func(node ast.Node) bool {
 		sel, ok := node.(*ast.SelectorExpr)
@@ -2384,6 +6485,14 @@ func (c *Checker) CheckDeprecated(j *lint.Job) {
 			return true
 		}
 		if ok, alt := c.isDeprecated(j, sel.Sel); ok {
+			if depr, ok := deprecated.Stdlib[deprecationKey(j, sel, obj)]; ok {
+				// Only flag objects that were already deprecated in
+				// the targeted version of Go, and whose alternative
+				// is available.
+				if !j.IsGoVersionAt(sel, depr.DeprecatedSince) || !j.IsGoVersionAt(sel, depr.AlternativeAvailableSince) {
+					return true
+				}
+			}
 			j.Errorf(sel, "%s is deprecated: %s", j.Render(sel), alt)
 			return true
 		}
@@ -2423,7 +6532,7 @@ func (c *Checker) checkCalls(j *lint.Job, rules map[string]CallCheck) {
 				if iarg, ok := arg.(*ssa.MakeInterface); ok {
 					arg = iarg.X
 				}
-				vr := c.funcDescs.Get(edge.Site.Parent()).Ranges[arg]
+				vr := c.funcDescs.Get(edge.Site.Parent()).Ranges.Get(arg)
 				args = append(args, &Argument{Value: Value{arg, vr}})
 			}
 			call := &Call{
@@ -2451,7 +6560,10 @@ func (c *Checker) checkCalls(j *lint.Job, rules map[string]CallCheck) {
 				}
 			}
 			for _, e := range call.invalids {
-				j.Errorf(call.Instr.Common(), "%s", e)
+				p := j.Errorf(call.Instr.Common(), "%s", e)
+				for _, edit := range call.edits {
+					p.Edit(edit.Pos, edit.End, edit.NewText)
+				}
 			}
 		}
 	}
@@ -2508,6 +6620,27 @@ func unwrapFunction(val ssa.Value) *ssa.Function {
 	}
 }
 
+// qualifiedCallName returns the name of the called function,
+// qualified by its package name or receiver type, e.g. "exec.Command"
+// or "(*sql.DB).Query".
+func qualifiedCallName(call *ssa.CallCommon) string {
+	qualifier := func(pkg *types.Package) string { return pkg.Name() }
+	if call.IsInvoke() {
+		return fmt.Sprintf("(%s).%s", types.TypeString(call.Value.Type(), qualifier), call.Method.Name())
+	}
+	fn := call.StaticCallee()
+	if fn == nil {
+		return shortCallName(call)
+	}
+	if recv := fn.Signature.Recv(); recv != nil {
+		return fmt.Sprintf("(%s).%s", types.TypeString(recv.Type(), qualifier), fn.Name())
+	}
+	if fn.Pkg == nil {
+		return fn.Name()
+	}
+	return fn.Pkg.Pkg.Name() + "." + fn.Name()
+}
+
 func shortCallName(call *ssa.CallCommon) string {
 	if call.IsInvoke() {
 		return ""
@@ -2596,6 +6729,161 @@ func (c *Checker) CheckWriterBufferModified(j *lint.Job) {
 	}
 }
 
+func repeatedCompile(name string) CallCheck {
+	return func(call *Call) {
+		if len(extractConsts(call.Args[0].Value.Value)) == 0 {
+			return
+		}
+		fn := call.Parent
+		if fn.Synthetic == "package initializer" {
+			return
+		}
+		if obj, ok := fn.Object().(*types.Func); ok && obj.Name() == "init" && fn.Signature.Recv() == nil {
+			// Code in init functions only runs once.
+			return
+		}
+		switch {
+		case call.Checker.isInLoop(call.Instr.Block()):
+			call.Invalid(fmt.Sprintf("calling %s with a constant pattern in a loop compiles it on every iteration, consider compiling it once in a package-level variable", name))
+		case call.Checker.isCalledInLoop(fn):
+			call.Invalid(fmt.Sprintf("calling %s with a constant pattern in a function that is called in a loop compiles it on every call, consider compiling it once in a package-level variable", name))
+		default:
+			return
+		}
+		if name == "regexp.MustCompile" || name == "regexp.MustCompilePOSIX" {
+			hoistRegexp(call)
+		}
+	}
+}
+
+// hoistRegexp adds edits to call that move a constant regexp to a
+// package-level variable declared after the enclosing function. The
+// pattern has to be a literal. The statement
+// 're := regexp.MustCompile("...")' is moved as a whole if re isn't
+// assigned to and no other identifier in the package is named re, so
+// that the variable can keep its name. Other uses of the expression,
+// such as 'regexp.MustCompile("...").MatchString(s)', are replaced
+// by a variable named after the enclosing function.
+func hoistRegexp(call *Call) {
+	j := call.Job
+	f := j.File(call.Instr.Common())
+	if f == nil {
+		return
+	}
+	path, _ := astutil.PathEnclosingInterval(f, call.Instr.Pos(), call.Instr.Pos())
+	if len(path) < 3 {
+		return
+	}
+	expr, ok := path[0].(*ast.CallExpr)
+	if !ok || expr.Lparen != call.Instr.Pos() || len(expr.Args) != 1 {
+		return
+	}
+	if _, ok := expr.Args[0].(*ast.BasicLit); !ok {
+		return
+	}
+	var decl *ast.FuncDecl
+	for _, node := range path {
+		if fd, ok := node.(*ast.FuncDecl); ok {
+			decl = fd
+		}
+	}
+	if decl == nil {
+		return
+	}
+	pkg := call.Parent.Pkg.Pkg
+
+	stmt, ok := path[1].(*ast.AssignStmt)
+	if !ok {
+		if _, ok := path[1].(*ast.ExprStmt); ok {
+			// the result isn't used; there's nothing to replace
+			// the call with
+			return
+		}
+		name := hoistedRegexpName(decl)
+		if isNameTaken(j, pkg, name, nil) {
+			return
+		}
+		call.Edit(expr.Pos(), expr.End(), name)
+		call.Edit(decl.End(), decl.End(), fmt.Sprintf("\n\nvar %s = %s", name, j.Render(expr)))
+		return
+	}
+	if stmt.Tok != token.DEFINE || len(stmt.Lhs) != 1 || len(stmt.Rhs) != 1 {
+		return
+	}
+	if _, ok := path[2].(*ast.BlockStmt); !ok {
+		return
+	}
+	ident, ok := stmt.Lhs[0].(*ast.Ident)
+	if !ok {
+		return
+	}
+	obj := j.Program.Info.Defs[ident]
+	if obj == nil {
+		return
+	}
+	if isNameTaken(j, pkg, obj.Name(), ident) {
+		return
+	}
+	for use, o := range j.Program.Info.Uses {
+		if o != obj {
+			continue
+		}
+		path, _ := astutil.PathEnclosingInterval(f, use.Pos(), use.End())
+		if len(path) < 2 {
+			return
+		}
+		switch parent := path[1].(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range parent.Lhs {
+				if lhs == use {
+					return
+				}
+			}
+		case *ast.UnaryExpr:
+			if parent.Op == token.AND {
+				return
+			}
+		}
+	}
+
+	call.Edit(stmt.Pos(), stmt.End(), "")
+	call.Edit(decl.End(), decl.End(), fmt.Sprintf("\n\nvar %s = %s", ident.Name, j.Render(expr)))
+}
+
+// hoistedRegexpName returns the name of the variable that a regexp
+// compiled in decl is moved to, such as isWordRe for the function
+// isWord and tMatchRe for the method T.Match. Functions and methods
+// of a package have distinct names, and so do their variables.
+func hoistedRegexpName(decl *ast.FuncDecl) string {
+	name := decl.Name.Name
+	if decl.Recv != nil && len(decl.Recv.List) == 1 {
+		typ := decl.Recv.List[0].Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		if ident, ok := typ.(*ast.Ident); ok {
+			name = ident.Name + strings.ToUpper(name[:1]) + name[1:]
+		}
+	}
+	return strings.ToLower(name[:1]) + name[1:] + "Re"
+}
+
+// isNameTaken reports whether an identifier other than except, or an
+// import, is named name anywhere in pkg.
+func isNameTaken(j *lint.Job, pkg *types.Package, name string, except *ast.Ident) bool {
+	for def, o := range j.Program.Info.Defs {
+		if def != except && o != nil && o.Pkg() == pkg && o.Name() == name {
+			return true
+		}
+	}
+	for _, o := range j.Program.Info.Implicits {
+		if pkgName, ok := o.(*types.PkgName); ok && pkgName.Pkg() == pkg && pkgName.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
 func loopedRegexp(name string) CallCheck {
 	return func(call *Call) {
 		if len(extractConsts(call.Args[0].Value.Value)) == 0 {
@@ -2631,7 +6919,7 @@ func (c *Checker) CheckEmptyBranch(j *lint.Job) {
 		j.Errorf(ifstmt, "empty branch")
 		return true
 	}
-	for _, f := range c.filterGenerated(j.Program.Files) {
+	for _, f := range j.FilterGenerated(j.Program.Files) {
 		ast.Inspect(f, fn)
 	}
 }
@@ -8,5 +8,20 @@ import (
 
 func TestAll(t *testing.T) {
 	c := NewChecker()
+	c.PrintfWrappers = []string{
+		"CheckErrorfWrapVerb.go.errorf",
+		"(*CheckErrorfWrapVerb.go.logger).logf",
+	}
+	c.Decoders = []string{"(CheckUncheckedAssertion.go.decoder).decode"}
+	c.Resources = []Resource{{"(*CheckResourceLeak.go.Pool).Get", []string{"Release"}}}
+	c.StructTags = []TagSchema{
+		{Name: "db", Named: true, Required: true, Options: []string{"pk"}},
+		{Name: "validate", Options: []string{"required"}, Keys: []string{"min", "max"}},
+	}
 	testutil.TestAll(t, c, "")
 }
+
+func TestVersions(t *testing.T) {
+	c := NewChecker()
+	testutil.TestVersions(t, c, testutil.CheckFiles(t, c))
+}
@@ -1,11 +1,14 @@
 package staticcheck
 
 import (
+	"errors"
 	"fmt"
 	"go/constant"
+	"go/token"
 	"go/types"
 	"net"
 	"net/url"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -31,12 +34,19 @@ type Call struct {
 	Parent  *ssa.Function
 
 	invalids []string
+	edits    []lint.TextEdit
 }
 
 func (c *Call) Invalid(msg string) {
 	c.invalids = append(c.invalids, msg)
 }
 
+// Edit adds an edit to the suggested fix of the problems reported by
+// Invalid.
+func (c *Call) Edit(pos, end token.Pos, text string) {
+	c.edits = append(c.edits, lint.TextEdit{Pos: pos, End: end, NewText: text})
+}
+
 type Argument struct {
 	Value    Value
 	invalids []string
@@ -99,6 +109,143 @@ func ValidateTimeLayout(v Value) error {
 	return nil
 }
 
+var strftimeVerb = regexp.MustCompile(`%[aAbBdeFHIjmMpSTyYzZ]`)
+
+// timeLayoutElement returns the part of the reference time that the
+// layout element at the start of s describes, and the element's
+// length. It returns a length of zero if s doesn't start with an
+// element. It mirrors the parsing of layouts in the time package.
+func timeLayoutElement(s string) (string, int) {
+	has := func(prefix string) bool { return strings.HasPrefix(s, prefix) }
+	startsWithLower := func(s string) bool {
+		return len(s) > 0 && 'a' <= s[0] && s[0] <= 'z'
+	}
+	switch s[0] {
+	case 'J':
+		if has("January") {
+			return "month", 7
+		}
+		if has("Jan") && !startsWithLower(s[3:]) {
+			return "month", 3
+		}
+	case 'M':
+		if has("Monday") {
+			return "weekday", 6
+		}
+		if has("Mon") && !startsWithLower(s[3:]) {
+			return "weekday", 3
+		}
+		if has("MST") {
+			return "time zone", 3
+		}
+	case '0':
+		if len(s) >= 2 && '1' <= s[1] && s[1] <= '6' {
+			return [...]string{"month", "day", "hour", "minute", "second", "year"}[s[1]-'1'], 2
+		}
+		if has("002") {
+			return "day of the year", 3
+		}
+	case '1':
+		if has("15") {
+			return "hour", 2
+		}
+		return "month", 1
+	case '2':
+		if has("2006") {
+			return "year", 4
+		}
+		return "day", 1
+	case '_':
+		if has("_2") && !has("_2006") {
+			return "day", 2
+		}
+		if has("__2") {
+			return "day of the year", 3
+		}
+	case '3':
+		return "hour", 1
+	case '4':
+		return "minute", 1
+	case '5':
+		return "second", 1
+	case 'P':
+		if has("PM") {
+			return "AM/PM", 2
+		}
+	case 'p':
+		if has("pm") {
+			return "AM/PM", 2
+		}
+	case '-', 'Z':
+		for _, zone := range []string{"070000", "07:00:00", "0700", "07:00", "07"} {
+			if strings.HasPrefix(s[1:], zone) {
+				return "time zone", len(zone) + 1
+			}
+		}
+	case '.', ',':
+		if len(s) > 1 && (s[1] == '0' || s[1] == '9') {
+			j := 1
+			for j < len(s) && s[j] == s[1] {
+				j++
+			}
+			if j == len(s) || s[j] < '0' || s[j] > '9' {
+				return "fractional second", j
+			}
+		}
+	}
+	return "", 0
+}
+
+// ValidateTimeLayoutElements checks that a layout describes each
+// part of the time at most once, only in ways the time package
+// understands.
+func ValidateTimeLayoutElements(v Value) error {
+	for _, c := range extractConsts(v.Value) {
+		if c.Value == nil {
+			continue
+		}
+		if c.Value.Kind() != constant.String {
+			continue
+		}
+		layout := constant.StringVal(c.Value)
+		if verb := strftimeVerb.FindString(layout); verb != "" {
+			return fmt.Errorf("layout uses the strftime verb %s, but Go layouts are written in terms of the reference time Mon Jan 2 15:04:05 MST 2006", verb)
+		}
+		seen := map[string]string{}
+		var prev string
+		for i := 0; i < len(layout); {
+			part, n := timeLayoutElement(layout[i:])
+			if n == 0 {
+				prev = ""
+				i++
+				continue
+			}
+			elem := layout[i : i+n]
+			switch prev {
+			case "1", "2", "3", "4", "5":
+				if elem[0] >= '0' && elem[0] <= '9' {
+					return fmt.Errorf("%s%s is not part of the reference time Mon Jan 2 15:04:05 MST 2006", prev, elem)
+				}
+			}
+			if other, ok := seen[part]; ok && part != "time zone" {
+				return fmt.Errorf("layout specifies the %s twice, as %s and %s", part, other, elem)
+			}
+			seen[part] = elem
+			prev = elem
+			i += n
+		}
+		hour, hasHour := seen["hour"]
+		_, hasAMPM := seen["AM/PM"]
+		switch {
+		case hour == "15" && hasAMPM:
+			return errors.New("layout combines the 24-hour clock (15) with an AM/PM marker")
+		case hasHour && hour != "15" && !hasAMPM:
+			return fmt.Errorf("layout uses the 12-hour clock (%s) without an AM/PM marker (PM)", hour)
+		}
+	}
+	return nil
+}
+
 func ValidateURL(v Value) error {
 	for _, c := range extractConsts(v.Value) {
 		if c.Value == nil {
@@ -181,7 +328,10 @@ func ConvertedFromInt(v Value) bool {
 	return true
 }
 
-func validEncodingBinaryType(j *lint.Job, typ types.Type) bool {
+// validEncodingBinaryType reports whether encoding/binary can encode
+// values of typ. Booleans can be encoded since Go 1.8, which bools
+// reports.
+func validEncodingBinaryType(typ types.Type, bools bool) bool {
 	typ = typ.Underlying()
 	switch typ := typ.(type) {
 	case *types.Basic:
@@ -191,19 +341,19 @@ func validEncodingBinaryType(j *lint.Job, typ types.Type) bool {
 			types.Float32, types.Float64, types.Complex64, types.Complex128, types.Invalid:
 			return true
 		case types.Bool:
-			return j.IsGoVersion(8)
+			return bools
 		}
 		return false
 	case *types.Struct:
 		n := typ.NumFields()
 		for i := 0; i < n; i++ {
-			if !validEncodingBinaryType(j, typ.Field(i).Type()) {
+			if !validEncodingBinaryType(typ.Field(i).Type(), bools) {
 				return false
 			}
 		}
 		return true
 	case *types.Array:
-		return validEncodingBinaryType(j, typ.Elem())
+		return validEncodingBinaryType(typ.Elem(), bools)
 	case *types.Interface:
 		// we can't determine if it's a valid type or not
 		return true
@@ -211,7 +361,163 @@ func validEncodingBinaryType(j *lint.Job, typ types.Type) bool {
 	return false
 }
 
-func CanBinaryMarshal(j *lint.Job, v Value) bool {
+// DeepEqualHazard reports whether values of type T contain functions
+// or instances of time.Time, which reflect.DeepEqual doesn't compare
+// meaningfully. It returns "func", "time.Time" or the empty string.
+func DeepEqualHazard(T types.Type) string {
+	return deepEqualHazard(T, map[types.Type]bool{})
+}
+
+func deepEqualHazard(T types.Type, seen map[types.Type]bool) string {
+	if seen[T] {
+		return ""
+	}
+	seen[T] = true
+	if types.TypeString(T, nil) == "time.Time" {
+		return "time.Time"
+	}
+	switch T := T.Underlying().(type) {
+	case *types.Signature:
+		return "func"
+	case *types.Struct:
+		for i := 0; i < T.NumFields(); i++ {
+			if h := deepEqualHazard(T.Field(i).Type(), seen); h != "" {
+				return h
+			}
+		}
+	case *types.Pointer:
+		return deepEqualHazard(T.Elem(), seen)
+	case *types.Slice:
+		return deepEqualHazard(T.Elem(), seen)
+	case *types.Array:
+		return deepEqualHazard(T.Elem(), seen)
+	case *types.Map:
+		if h := deepEqualHazard(T.Key(), seen); h != "" {
+			return h
+		}
+		return deepEqualHazard(T.Elem(), seen)
+	}
+	return ""
+}
+
+// ValidateErrorsAsTarget checks that v can be used as the target of
+// errors.As, which must be a non-nil pointer to an interface or to a
+// type that implements error.
+func ValidateErrorsAsTarget(v ssa.Value) error {
+	if k, ok := v.(*ssa.Const); ok && k.IsNil() {
+		return errors.New("errors.As target must be a non-nil pointer, the call panics")
+	}
+	T := v.Type()
+	ptr, ok := T.Underlying().(*types.Pointer)
+	if !ok {
+		return fmt.Errorf("errors.As target must be a non-nil pointer, not %s, the call panics", T)
+	}
+	errorType := types.Universe.Lookup("error").Type()
+	if types.Identical(ptr.Elem(), errorType) {
+		return errors.New("errors.As target of type *error matches any error, compare err against nil or use a more specific type instead")
+	}
+	if types.IsInterface(ptr.Elem()) {
+		return nil
+	}
+	if !types.Implements(ptr.Elem(), errorType.Underlying().(*types.Interface)) {
+		return fmt.Errorf("errors.As target of type %s panics, because %s doesn't implement error", T, ptr.Elem())
+	}
+	return nil
+}
+
+// IsNewError reports whether v is a newly constructed error value,
+// which can't be equal to any other error.
+func IsNewError(v ssa.Value) bool {
+	call, ok := v.(*ssa.Call)
+	if !ok {
+		return false
+	}
+	return lint.IsCallTo(call.Common(), "errors.New") || lint.IsCallTo(call.Common(), "fmt.Errorf")
+}
+
+// ValidateJSONType checks that encoding/json can represent values of
+// type T. It doesn't look at types that implement their own
+// (un)marshaling.
+func ValidateJSONType(T types.Type) error {
+	return validateJSONType(T, "", map[types.Type]bool{})
+}
+
+func hasMethod(T types.Type, names ...string) bool {
+	for _, T := range []types.Type{T, types.NewPointer(T)} {
+		ms := types.NewMethodSet(T)
+		for _, name := range names {
+			if ms.Lookup(nil, name) != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func validateJSONType(T types.Type, path string, seen map[types.Type]bool) error {
+	if seen[T] {
+		return nil
+	}
+	seen[T] = true
+	if hasMethod(T, "MarshalJSON", "UnmarshalJSON", "MarshalText", "UnmarshalText") {
+		return nil
+	}
+	at := ""
+	if path != "" {
+		at = " in " + path
+	}
+	switch U := T.Underlying().(type) {
+	case *types.Chan, *types.Signature:
+		return fmt.Errorf("unsupported type %s%s", T, at)
+	case *types.Basic:
+		if U.Info()&types.IsComplex != 0 {
+			return fmt.Errorf("unsupported type %s%s", T, at)
+		}
+	case *types.Pointer:
+		return validateJSONType(U.Elem(), path, seen)
+	case *types.Slice:
+		return validateJSONType(U.Elem(), path+"[]", seen)
+	case *types.Array:
+		return validateJSONType(U.Elem(), path+"[]", seen)
+	case *types.Map:
+		key, ok := U.Key().Underlying().(*types.Basic)
+		if (!ok || key.Info()&(types.IsString|types.IsInteger) == 0) && !hasMethod(U.Key(), "MarshalText", "UnmarshalText") {
+			return fmt.Errorf("unsupported map key type %s%s, keys must be strings, integers or implement encoding.TextMarshaler", U.Key(), at)
+		}
+		return validateJSONType(U.Elem(), path+"[]", seen)
+	case *types.Struct:
+		exported := false
+		for i := 0; i < U.NumFields(); i++ {
+			field := U.Field(i)
+			tag := reflect.StructTag(U.Tag(i)).Get("json")
+			if tag == "-" {
+				continue
+			}
+			if !field.Exported() && !field.Anonymous() {
+				if tag != "" {
+					return fmt.Errorf("unexported field %s%s has a json tag but will be ignored", field.Name(), at)
+				}
+				continue
+			}
+			exported = true
+			name := field.Name()
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := validateJSONType(field.Type(), name, seen); err != nil {
+				return err
+			}
+		}
+		if !exported && U.NumFields() > 0 {
+			return fmt.Errorf("%s%s has no exported fields and is always represented as {}", T, at)
+		}
+	}
+	return nil
+}
+
+// CanBinaryMarshal reports whether encoding/binary can encode v in
+// the package of node.
+func CanBinaryMarshal(j *lint.Job, node lint.Positioner, v Value) bool {
 	typ := v.Value.Type().Underlying()
 	if ttyp, ok := typ.(*types.Pointer); ok {
 		typ = ttyp.Elem().Underlying()
@@ -224,7 +530,7 @@ func CanBinaryMarshal(j *lint.Job, v Value) bool {
 		}
 	}
 
-	return validEncodingBinaryType(j, typ)
+	return validEncodingBinaryType(typ, j.IsGoVersionAt(node, 8))
 }
 
 func RepeatZeroTimes(name string, arg int) CallCheck {
@@ -0,0 +1,117 @@
+package pkg
+
+import (
+	"context"
+	"time"
+)
+
+func compute() int { return 0 }
+
+func fn1() int {
+	ch := make(chan int)
+	go func() {
+		ch <- compute() // MATCH /goroutine blocks forever sending on an unbuffered channel/
+	}()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		return 0
+	}
+}
+
+func fn2(ctx context.Context) int {
+	ch := make(chan int)
+	go func() {
+		ch <- compute() // MATCH /goroutine blocks forever sending on an unbuffered channel/
+	}()
+	select {
+	case v := <-ch:
+		return v
+	case <-ctx.Done():
+		return 0
+	}
+}
+
+func fn3() int {
+	ch := make(chan int, 1)
+	go func() {
+		ch <- compute()
+	}()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		return 0
+	}
+}
+
+func fn4() int {
+	ch := make(chan int)
+	go func() {
+		ch <- compute()
+	}()
+	return <-ch
+}
+
+func fn5() int {
+	ch := make(chan int)
+	go func() {
+		ch <- compute()
+	}()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+	}
+	// The value is eventually received after all.
+	return <-ch
+}
+
+func fn6() int {
+	ch := make(chan int)
+	go func() {
+		ch <- compute()
+	}()
+	drain(ch)
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		return 0
+	}
+}
+
+func fn7() int {
+	ch := make(chan int)
+	go func(ch chan int) {
+		ch <- compute() // MATCH /goroutine blocks forever sending on an unbuffered channel/
+	}(ch)
+	t := time.NewTimer(time.Second)
+	select {
+	case v := <-ch:
+		return v
+	case <-t.C:
+		return 0
+	}
+}
+
+func fn8(other chan int) int {
+	ch := make(chan int)
+	go func() {
+		ch <- compute()
+	}()
+	select {
+	case v := <-ch:
+		return v
+	case v := <-other:
+		return v
+	}
+}
+
+func drain(ch chan int) {
+	go func() {
+		for range ch {
+		}
+	}()
+}
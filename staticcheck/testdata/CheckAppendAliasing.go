@@ -0,0 +1,62 @@
+package pkg
+
+func use(...[]int) {}
+
+func fn1(base []int) {
+	a := append(base, 1)
+	b := append(base, 2) // MATCH /append may overwrite the elements added by an earlier append, as both can share the backing array/ related 6
+	use(a, b)
+}
+
+func fn2(base []int) {
+	a := append(base, 1)
+	use(a)
+	b := append(base, 2)
+	use(b)
+}
+
+func fn3() {
+	base := make([]int, 0, 10)
+	a := append(base, 1)
+	b := append(base, 2) // MATCH /append may overwrite/
+	use(a, b)
+}
+
+func fn4(n int) {
+	base := make([]int, n)
+	a := append(base, 1)
+	b := append(base, 2)
+	use(a, b)
+
+	lit := []int{1, 2}
+	c := append(lit, 1)
+	d := append(lit, 2)
+	use(c, d)
+
+	var empty []int
+	e := append(empty, 1)
+	f := append(empty, 2)
+	use(e, f)
+}
+
+func fn5(base []int) {
+	a := append(base, 1)
+	base[0] = 2 // MATCH /modifying the slice after appending to it may also modify the result of the append, as both can share a backing array/ related 43
+	use(a)
+}
+
+func fn6(base []int, b bool) {
+	var a []int
+	if b {
+		a = append(base, 1)
+	} else {
+		a = append(base, 2)
+	}
+	use(a)
+}
+
+func fn7(base []int) {
+	base = append(base, 1)
+	base = append(base, 2)
+	use(base)
+}
@@ -7,3 +7,5 @@ func foo() {
 	b.N = 1 // MATCH /should not assign to b.N/
 	_ = b
 }
+
+// MATCH:7 /accessing field N of a pointer that is always nil/
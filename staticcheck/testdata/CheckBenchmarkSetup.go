@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+)
+
+func process([]byte) {}
+func read(*os.File)  {}
+
+func BenchmarkFn1(b *testing.B) {
+	f, _ := os.Open("testdata/input") // MATCH /setup before the b.N loop is included in the benchmark's measurements; call b.ResetTimer after it/
+	for i := 0; i < b.N; i++ {
+		read(f)
+	}
+}
+
+func BenchmarkFn2(b *testing.B) {
+	f, _ := os.Open("testdata/input")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		read(f)
+	}
+}
+
+func BenchmarkFn3(b *testing.B) {
+	var data []byte
+	for i := 0; i < 1<<20; i++ { // MATCH /setup before the b.N loop/
+		data = append(data, byte(i))
+	}
+	for i := 0; i < b.N; i++ {
+		process(data)
+	}
+}
+
+func BenchmarkFn4(b *testing.B) {
+	f, _ := os.Open("testdata/input")
+	for b.Loop() {
+		read(f)
+	}
+}
+
+func BenchmarkFn5(b *testing.B) {
+	data := make([]byte, 64)
+	for i := 0; i < b.N; i++ {
+		process(data)
+	}
+}
+
+func BenchmarkFn6(b *testing.B) {
+	b.Run("sub", func(b *testing.B) {
+		f, _ := os.Open("testdata/input") // MATCH /setup before the b.N loop/
+		for range b.N {
+			read(f)
+		}
+	})
+}
+
+func BenchmarkFn7(b *testing.B) {
+	b.StopTimer()
+	f, _ := os.Open("testdata/input")
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		read(f)
+	}
+}
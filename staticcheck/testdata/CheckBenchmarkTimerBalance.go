@@ -0,0 +1,49 @@
+package pkg
+
+import "testing"
+
+func prepare() bool { return true }
+func run()          {}
+
+func BenchmarkFn1(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if !prepare() {
+			b.StartTimer()
+			continue
+		}
+		b.StartTimer()
+		run()
+	}
+}
+
+func BenchmarkFn2(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer() // MATCH /StopTimer is not followed by StartTimer on all paths/
+		if !prepare() {
+			continue
+		}
+		b.StartTimer()
+		run()
+	}
+}
+
+func BenchmarkFn3(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if i%2 == 0 {
+			b.StopTimer() // MATCH /StopTimer is not followed by StartTimer on all paths/
+			_ = prepare()
+		}
+		run()
+	}
+}
+
+func BenchmarkFn4(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		run()
+	}
+	b.StopTimer()
+	if prepare() {
+		run()
+	}
+}
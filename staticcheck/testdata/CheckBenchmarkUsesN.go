@@ -0,0 +1,56 @@
+package pkg
+
+import "testing"
+
+func work() {}
+
+func BenchmarkFn1(b *testing.B) { // MATCH /BenchmarkFn1 doesn't run its code b.N times/
+	b.ReportAllocs()
+	work()
+}
+
+func BenchmarkFn2(b *testing.B) { // MATCH /BenchmarkFn2 doesn't run its code b.N times/
+	work()
+}
+
+func BenchmarkFn3(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		work()
+	}
+}
+
+func BenchmarkFn4(b *testing.B) {
+	for b.Loop() {
+		work()
+	}
+}
+
+func BenchmarkFn5(b *testing.B) {
+	b.Run("sub", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			work()
+		}
+	})
+}
+
+func benchmarkHelper(b *testing.B, n int) {
+	for i := 0; i < b.N; i++ {
+		work()
+	}
+}
+
+func BenchmarkFn6(b *testing.B) {
+	benchmarkHelper(b, 10)
+}
+
+func BenchmarkFn7(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			work()
+		}
+	})
+}
+
+func benchmarkFn8(b *testing.B) {
+	work()
+}
@@ -0,0 +1,78 @@
+package pkg
+
+func fn1() {
+	ch := make(chan int)
+	ch <- 1 // MATCH /send blocks forever, the channel is unbuffered and nothing receives from it/
+}
+
+func fn2() {
+	ch := make(chan int, 2)
+	ch <- 1
+	ch <- 2
+	ch <- 3 // MATCH /the channel's buffer is full after the 2 preceding sends/
+}
+
+func fn3(b bool) {
+	ch := make(chan int, 1)
+	if b {
+		ch <- 1
+	}
+	ch <- 2
+	println(len(ch))
+}
+
+func fn4() {
+	ch := make(chan int, 1)
+	ch <- 1
+	ch <- 2
+	<-ch
+}
+
+func fn5() {
+	ch := make(chan int, 1)
+	go func() { <-ch }()
+	ch <- 1
+	ch <- 2
+}
+
+func fn6(n int) {
+	ch := make(chan int, n)
+	if cap(ch) == 1 {
+		ch <- 1
+		ch <- 2 // MATCH /the channel's buffer is full after the preceding send/
+	}
+	if len(ch) < cap(ch) {
+		ch <- 1
+	}
+	ch <- 1
+}
+
+func fn7() chan int {
+	ch := make(chan int, 1)
+	ch <- 1
+	ch <- 2
+	return ch
+}
+
+func fn8() {
+	ch := make(chan int, 1)
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+}
+
+func fn9() {
+	ch := make(chan int, 1)
+	ch <- 1
+	close(ch)
+	ch <- 2 // MATCH /sending on a channel that has already been closed/
+}
+
+func fn10() {
+	ch := make(chan int, 1)
+	ch <- 1
+	select {
+	case ch <- 2:
+	default:
+	}
+}
@@ -0,0 +1,72 @@
+package pkg
+
+import "sync"
+
+func fn1() {
+	x := 0
+	go func() {
+		x = 1 // MATCH /goroutine writes captured variable x, which is concurrently read without synchronization/
+	}()
+	println(x)
+}
+
+func fn2() {
+	x := 0
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		x = 1
+	}()
+	wg.Wait()
+	println(x)
+}
+
+func fn3() {
+	x := 0
+	done := make(chan struct{})
+	go func() {
+		x = 1
+		close(done)
+	}()
+	<-done
+	println(x)
+}
+
+func fn4() {
+	x := 0
+	var mu sync.Mutex
+	go func() {
+		mu.Lock()
+		x = 1
+		mu.Unlock()
+	}()
+	mu.Lock()
+	println(x)
+	mu.Unlock()
+}
+
+func fn5() {
+	x := 0
+	x = 2
+	go func() {
+		println(x)
+	}()
+}
+
+func fn6() {
+	n := 0
+	for i := 0; i < 10; i++ {
+		go func() {
+			n++ // MATCH /goroutine reads captured variable n, which is concurrently written without synchronization/
+		}()
+	}
+}
+
+func fn7() {
+	x := 0
+	go func() {
+		println(x)
+	}()
+	println(x)
+}
@@ -0,0 +1,30 @@
+package pkg
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+func fn1(r *http.Request) {
+	exec.Command(r.FormValue("cmd")) // MATCH /the program executed by exec.Command is controlled by untrusted input/
+	exec.Command("ls", r.FormValue("dir"))
+	exec.Command("sh", "-c", "ls "+r.FormValue("dir"))                              // MATCH /the shell command executed by exec.Command is constructed from untrusted input/
+	exec.Command("/bin/bash", "-c", fmt.Sprintf("ls %s", r.URL.Query().Get("dir"))) // MATCH /the shell command executed by exec.Command/
+	exec.Command("sh", "-c", "ls")
+}
+
+func fn2() {
+	exec.Command(os.Getenv("EDITOR")) // MATCH /controlled by untrusted input/
+}
+
+func fn3(r *http.Request) {
+	n, _ := strconv.Atoi(r.FormValue("n"))
+	exec.Command("sh", "-c", fmt.Sprintf("sleep %d", n))
+	exec.Command("sh", "-c", "sleep "+clean(r.FormValue("n")))
+}
+
+//taint:sanitizer
+func clean(s string) string { return s }
@@ -23,3 +23,28 @@ func fn1() {
 func fn2(t *testing.T) {
 	t.Fatal()
 }
+
+func fn3(t *testing.T) {
+	go func() { // MATCH /the goroutine calls T.FailNow through check, which must be called in the same goroutine as the test/
+		fn4(t)
+	}()
+	t.Run("", func(t2 *testing.T) {
+		t2.Fatal()
+		t.Fatal() // MATCH /the subtest calls T.Fatal on its parent test/
+		t.Log()
+	})
+	t.Run("", fn2)
+}
+
+func fn4(t *testing.T) {
+	check(t, nil)
+}
+
+func check(t *testing.T, err error) {
+	if err != nil {
+		t.FailNow()
+	}
+}
+
+// MATCH:8 /unreachable because t.Fatal never returns/
+// MATCH:33 /unreachable because t2.Fatal never returns/
@@ -0,0 +1,64 @@
+package pkg
+
+import "sync"
+
+type T1 struct {
+	sync.Mutex
+	n int
+}
+
+type T2 struct {
+	mu sync.RWMutex
+}
+
+type T3 struct {
+	t [2]T2
+}
+
+type T4 struct {
+	mu *sync.Mutex
+}
+
+func (t T1) Get() int { return t.n } // MATCH /method Get has a value receiver of type pkg.T1, which copies the lock/
+
+func (t *T1) Set(n int) { t.n = n }
+
+func (t T3) Fn() {} // MATCH /value receiver of type pkg.T3/
+
+func (t T4) Fn() {}
+
+func fn1(m map[string]T1, s []T2, a *[4]T3) {
+	v := m[""] // MATCH /copying a value of type pkg.T1 out of a map/
+	_ = v.n
+	w := s[0] // MATCH /copying an element of type pkg.T2 out of a slice or array/
+	_ = w
+	x := a[0] // MATCH /copying an element of type pkg.T3/
+	_ = x
+	s[0].mu.Lock()
+	m2 := map[string]*T1{}
+	_ = m2[""]
+}
+
+func fn2(m map[string]T1) {
+	for _, v := range m { // MATCH /ranging over a map with values of type pkg.T1/
+		_ = v.n
+	}
+	for k := range m {
+		_ = k
+	}
+}
+
+func fn3(t T1, p *T1) {
+	go func(t T1) { // MATCH /passing a value of type pkg.T1 to a goroutine/
+		_ = t.n
+	}(t)
+	go func(p *T1) {
+		p.Lock()
+	}(p)
+}
+
+func fn4(s []T1) {
+	for _, v := range s {
+		_ = v.n
+	}
+}
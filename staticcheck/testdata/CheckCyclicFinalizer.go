@@ -21,5 +21,5 @@ func fn() {
 	})
 }
 
-// MATCH:11 /the finalizer closes over the object, preventing the finalizer from ever running \(at .+:10:9/
-// MATCH:13 /the finalizer closes over the object, preventing the finalizer from ever running \(at .+:13:26/
+// MATCH:11 /the finalizer closes over the object, preventing the finalizer from ever running/ related 10:9
+// MATCH:13 /the finalizer closes over the object, preventing the finalizer from ever running/ related 13:26
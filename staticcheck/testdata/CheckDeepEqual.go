@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"reflect"
+	"time"
+)
+
+type T1 struct {
+	Name string
+	Fn   func()
+}
+
+type T2 struct {
+	Created time.Time
+}
+
+type T3 struct {
+	Children []*T3
+	Values   map[string]int
+}
+
+type T4 struct {
+	Next *T4
+	At   *T2
+}
+
+func fn() {
+	var t1a, t1b T1
+	var t2a, t2b T2
+	var t3a, t3b T3
+	var t4a, t4b T4
+	var ta, tb time.Time
+	var fa, fb func()
+	_ = reflect.DeepEqual(t1a, t1b) // MATCH /reflect.DeepEqual on values of type .+T1, which contains functions/
+	_ = reflect.DeepEqual(t2a, t2b) // MATCH /which contains time.Time/
+	_ = reflect.DeepEqual(t3a, t3b)
+	_ = reflect.DeepEqual(&t4a, &t4b)      // MATCH /reflect.DeepEqual on values of type \*.+T4, which contains time.Time/
+	_ = reflect.DeepEqual(ta, tb)          // MATCH /which contains time.Time/
+	_ = reflect.DeepEqual(fa, fb)          // MATCH /which contains functions/
+	_ = reflect.DeepEqual([]T1{}, nil)     // MATCH /which contains functions/
+	_ = reflect.DeepEqual(map[T2]int{}, 1) // MATCH /which contains time.Time/
+	_ = reflect.DeepEqual([]int{1}, []int{1})
+}
@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+func fn1(names []string) {
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		defer f.Close() // MATCH /deferred call to f.Close in a loop only runs when the surrounding function returns/
+	}
+}
+
+func fn2(names []string) {
+	for _, name := range names {
+		func() {
+			f, err := os.Open(name)
+			if err != nil {
+				return
+			}
+			defer f.Close()
+		}()
+	}
+}
+
+func fn3(mu *sync.Mutex, n int) {
+	for i := 0; i < n; i++ {
+		mu.Lock()
+		defer mu.Unlock() // MATCH /deferred call to mu.Unlock/
+	}
+}
+
+func fn4(ctx context.Context, n int) {
+	for i := 0; i < n; i++ {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel() // MATCH /deferred call to cancel/
+		_ = ctx
+	}
+}
+
+func fn5(names []string) {
+	for _, name := range names {
+		defer println(name)
+	}
+}
+
+func fn6(ch chan *os.File) {
+	for f := range ch {
+		defer f.Close() // MATCH /defers in this range loop/
+	}
+}
+
+func fn7(names []string) {
+	for _, name := range names {
+		for i := 0; i < 2; i++ {
+			f, _ := os.Open(name)
+			defer f.Close() // MATCH /deferred call to f.Close/
+		}
+	}
+}
+
+func fn8(f *os.File) {
+	for {
+		defer f.Close() // MATCH /will never run/
+	}
+}
@@ -0,0 +1,14 @@
+package pkg
+
+import (
+	"net/http"
+	"os"
+	"syscall"
+)
+
+func fn(t *http.Transport, r *http.Request) {
+	_ = syscall.StringByteSlice("") // MATCH /Use ByteSliceFromString instead/
+	_ = os.SEEK_SET
+	_ = t.Dial
+	_ = r.Cancel
+}
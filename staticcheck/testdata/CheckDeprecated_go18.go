@@ -10,7 +10,7 @@ import (
 var _ = syscall.StringByteSlice("") // MATCH /Use ByteSliceFromString instead/
 
 func fn1(err error) {
-	var r *http.Request
+	r := &http.Request{}
 	_ = r.Cancel                        // MATCH /Use the Context and WithContext methods/
 	_ = syscall.StringByteSlice("")     // MATCH /Use ByteSliceFromString instead/
 	_ = os.SEEK_SET                     // MATCH /Use io.SeekStart, io.SeekCurrent, and io.SeekEnd/
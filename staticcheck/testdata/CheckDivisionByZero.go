@@ -0,0 +1,28 @@
+package pkg
+
+func fn1(x int) {
+	var s []int
+	_ = x / len(s) // MATCH /integer division by zero/
+	_ = x % len(s) // MATCH /integer division by zero/
+
+	n := len(s) * 2
+	_ = x / n // MATCH /integer division by zero/
+
+	s = append(s, 1)
+	_ = x / len(s)
+}
+
+func fn2(x, y int) {
+	_ = x / y
+
+	m := 0
+	if y > 0 {
+		m = 1
+	}
+	_ = x / m
+}
+
+func fn3(x float64) {
+	var s []int
+	_ = x / float64(len(s))
+}
@@ -0,0 +1,40 @@
+package pkg
+
+func fn1() {
+	ch := make(chan int)
+	close(ch)
+	close(ch) // MATCH /closing a channel that has already been closed/
+}
+
+func fn2() {
+	ch := make(chan int)
+	defer close(ch) // MATCH /closing a channel that has already been closed/
+	close(ch)
+}
+
+func fn3(b bool) {
+	ch := make(chan int)
+	if b {
+		close(ch)
+	} else {
+		close(ch)
+	}
+}
+
+func fn4() {
+	ch := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		close(ch)
+		close(done)
+	}()
+	<-done
+	close(ch) // MATCH /closing a channel that has already been closed/
+}
+
+func fn5() {
+	ch := make(chan int)
+	close(ch)
+	ch = make(chan int)
+	close(ch)
+}
@@ -2,7 +2,7 @@ package pkg
 
 func fn() {
 	var ch chan int
-	for range ch {
+	for range ch { // MATCH /receiving from a nil channel blocks forever/
 		defer println() // MATCH /defers in this range loop/
 	}
 }
@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+)
+
+type myError struct{}
+
+func (myError) Error() string { return "" }
+
+type logger struct{}
+
+func (*logger) logf(format string, args ...interface{}) {}
+
+func errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(format, args...)
+}
+
+func fn(err error, args []interface{}) {
+	var l logger
+	_ = fmt.Errorf("%w", err)
+	_ = fmt.Errorf("%d: %w", 1, err)
+	_ = fmt.Errorf("%[2]w %[1]d", 1, err)
+	_ = fmt.Errorf("%*d %w", 3, 1, err)
+	_ = fmt.Errorf("%w", myError{})
+	_ = fmt.Errorf("%w", args...)
+	_ = fmt.Errorf("100%% %w", err)
+	_ = fmt.Errorf("%w %w", err, err)         // MATCH /fmt.Errorf call has more than one %w verb/
+	_ = fmt.Errorf("%w", 1)                   // MATCH /argument for %w verb has type int, which does not implement error/
+	_ = fmt.Errorf("%[2]w %[1]d", err, 1)     // MATCH /argument for %w verb has type int/
+	_ = fmt.Errorf("%d %w", 1)                // MATCH /%w verb has no corresponding argument/
+	_ = fmt.Sprintf("%w", err)                // MATCH /fmt.Sprintf does not support the %w verb, only fmt.Errorf does/
+	fmt.Printf("%v: %w\n", 1, errors.New("")) // MATCH /fmt.Printf does not support the %w verb/
+	_ = errorf("%w", err)
+	_ = errorf("%w", "") // MATCH /argument for %w verb has type string/
+	l.logf("%w", err)    // MATCH /l.logf does not support the %w verb/
+}
@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+type MyError struct{}
+
+func (*MyError) Error() string { return "" }
+
+type NotAnError struct{}
+
+var ErrSentinel = errors.New("sentinel")
+
+func fn1(err error) {
+	var e1 *MyError
+	errors.As(err, &e1)
+	errors.As(err, e1) // MATCH /errors.As target of type \*CheckErrorsIsAs.go.MyError panics, because CheckErrorsIsAs.go.MyError doesn't implement error/
+
+	var e2 MyError
+	errors.As(err, e2) // MATCH /errors.As target must be a non-nil pointer, not CheckErrorsIsAs.go.MyError/
+
+	var e3 error
+	errors.As(err, &e3) // MATCH /errors.As target of type \*error matches any error/
+
+	var e4 interface{ Timeout() bool }
+	errors.As(err, &e4)
+
+	var e5 NotAnError
+	errors.As(err, &e5) // MATCH /doesn't implement error/
+
+	errors.As(err, nil) // MATCH /errors.As target must be a non-nil pointer, the call panics/
+}
+
+func fn2(err error) {
+	errors.Is(err, errors.New("sentinel")) // MATCH /errors.Is compares against a newly constructed error/
+	errors.Is(err, fmt.Errorf("sentinel")) // MATCH /errors.Is compares against a newly constructed error/
+	errors.Is(err, ErrSentinel)
+	errors.Is(err, io.EOF)
+}
+
+func fn3() error {
+	_, err := os.Open("file")
+	if err == os.ErrNotExist { // MATCH /os.Open wraps os.ErrNotExist in another error, so comparing with == never matches; use os.IsNotExist instead/
+		return nil
+	}
+	if err := os.Remove("file"); err != os.ErrPermission { // MATCH /os.Remove wraps os.ErrPermission/
+		return err
+	}
+	return nil
+}
+
+func fn4(r io.Reader) error {
+	_, err := r.Read(nil)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		err = fmt.Errorf("reading: %w", err)
+	}
+	if err == io.EOF { // MATCH /comparing with == doesn't match errors wrapped with %w; use errors.Is instead/
+		return nil
+	}
+	err2 := fmt.Errorf("reading: %v", err)
+	if err2 == ErrSentinel {
+		return nil
+	}
+	return err
+}
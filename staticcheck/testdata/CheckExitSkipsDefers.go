@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+func fn1() {
+	f, err := os.Open("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if len(os.Args) > 1 {
+		os.Exit(1) // MATCH /os.Exit exits the program without running a deferred function/ related 14
+	}
+	log.Fatalf("") // MATCH /log.Fatalf exits the program without running a deferred function/ related 14
+}
+
+func fn2(l *log.Logger) {
+	defer func() {
+		println()
+	}()
+	l.Fatalln() // MATCH /\(\*log.Logger\).Fatalln exits the program/
+}
+
+func fn3(b bool) {
+	if b {
+		defer println()
+	}
+	os.Exit(1)
+}
+
+func fn4() {
+	defer strings.ToUpper("")
+	os.Exit(1)
+}
+
+func fn5() {
+	os.Exit(1)
+	defer println() // MATCH /unreachable because os.Exit never returns/
+}
@@ -0,0 +1,38 @@
+package pkg
+
+import "io"
+
+type I1 interface {
+	Read([]byte) (int, error)
+	Close()
+}
+
+type I2 interface {
+	Read([]byte) int
+}
+
+type I3 interface {
+	Close() error
+}
+
+func fn(x io.Reader, y I1, z interface{}) {
+	_ = x.(I2) // MATCH /impossible type assertion: io.Reader and pkg.I2 have conflicting Read methods, func\(p \[\]byte\) \(n int, err error\) and func\(\[\]byte\) int/
+	_ = x.(io.ReadCloser)
+	_, _ = y.(io.Closer) // MATCH /conflicting Close methods/
+	_ = z.(I2)
+	_ = x.(*T)
+
+	switch y.(type) {
+	case I3: // MATCH /impossible type assertion: pkg.I1 and pkg.I3 have conflicting Close methods/
+	case io.Reader:
+	case nil:
+	}
+	switch v := x.(type) {
+	case I2: // MATCH /conflicting Read methods/
+		_ = v
+	}
+}
+
+type T struct{}
+
+func (*T) Read([]byte) (int, error) { return 0, nil }
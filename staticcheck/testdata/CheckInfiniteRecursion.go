@@ -1,5 +1,7 @@
 package pkg
 
+import "fmt"
+
 func fn1(x int) bool {
 	println(x)
 	return fn1(x + 1) // MATCH /infinite recursive call/
@@ -63,3 +65,69 @@ func (t T) Fn3() {
 	}
 	t.Fn1()
 }
+
+func fn6(x int) int {
+	return fn7(x + 1) // MATCH /infinite recursive call: fn6 unconditionally calls fn7, which unconditionally calls fn6/
+}
+
+func fn7(x int) int {
+	println(x)
+	return fn6(x * 2)
+}
+
+func fn8(x int) int {
+	if x > 10 {
+		return x
+	}
+	return fn9(x + 1)
+}
+
+func fn9(x int) int {
+	return fn8(x * 2)
+}
+
+type S1 struct{ n int }
+
+func (s S1) String() string {
+	return fmt.Sprintf("S1(%v)", s) // MATCH /formatting the receiver with %v calls its String method again/
+}
+
+type S2 struct{ n int }
+
+func (s *S2) String() string {
+	return fmt.Sprint("S2", s) // MATCH /formatting the receiver with %v calls its String method again/
+}
+
+type S3 struct{ n int }
+
+func (s S3) String() string {
+	return fmt.Sprintf("S3(%d)", s.n)
+}
+
+type S4 struct{ n int }
+
+func (s S4) String() string {
+	type plain S4
+	return fmt.Sprintf("S4(%v)", plain(s))
+}
+
+type S5 struct{ n int }
+
+func (s *S5) String() string {
+	if s == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("S5(%v)", *s)
+}
+
+type E1 struct{ msg string }
+
+func (e E1) Error() string {
+	return fmt.Sprintf("%[2]d: %[1]s", e, 1) // MATCH /formatting the receiver with %s calls its Error method again/
+}
+
+type S6 struct{ n int }
+
+func (s S6) String() string {
+	return fmt.Sprintf("%T %d", s, s)
+}
@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	crand "crypto/rand"
+	"math/rand"
+)
+
+func fn1() {
+	r := rand.New(rand.NewSource(1))
+	key := make([]byte, 32)
+	r.Read(key) // MATCH /math\/rand is predictable and must not be used to generate key/
+	crand.Read(key)
+
+	buf := make([]byte, 32)
+	r.Read(buf)
+}
+
+func generateToken() string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, 16)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))] // MATCH /generateToken uses math\/rand, which is predictable/
+	}
+	return string(b)
+}
+
+func shuffle(s []int) {
+	for i := range s {
+		j := rand.Intn(i + 1)
+		s[i], s[j] = s[j], s[i]
+	}
+}
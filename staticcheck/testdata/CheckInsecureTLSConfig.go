@@ -0,0 +1,15 @@
+package pkg
+
+import "crypto/tls"
+
+func fn1() {
+	_ = &tls.Config{InsecureSkipVerify: true} // MATCH /InsecureSkipVerify disables verification/
+	_ = &tls.Config{InsecureSkipVerify: false}
+	_ = tls.Config{MinVersion: tls.VersionTLS10} // MATCH /MinVersion allows TLS versions older than TLS 1.2/
+	_ = tls.Config{MinVersion: tls.VersionTLS12}
+
+	cfg := &tls.Config{}
+	cfg.InsecureSkipVerify = true     // MATCH /InsecureSkipVerify disables verification/
+	cfg.MinVersion = tls.VersionTLS11 // MATCH /MinVersion allows TLS versions older than TLS 1.2/
+	cfg.MinVersion = tls.VersionTLS13
+}
@@ -0,0 +1,20 @@
+package pkg
+
+type Glyph rune
+
+type ID int64
+
+func fn(i int, i64 int64, u uint, r rune, b byte, g Glyph, id ID) {
+	_ = string(i)   // MATCH /conversion from int to string yields a string of one rune, not a decimal number; use strconv.Itoa/
+	_ = string(i64) // MATCH /use strconv.FormatInt/
+	_ = string(u)   // MATCH /use strconv.FormatUint/
+	_ = string(id)  // MATCH /conversion from .*ID to string/
+	_ = string(r)
+	_ = string(b)
+	_ = string(g)
+	_ = string(65)
+	_ = string(rune(i))
+
+	const c = 10
+	_ = string(c)
+}
@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func fn(dir string, files []string, verbose bool) {
+	exec.Command("sh", "-c", "ls "+dir)                        // MATCH /the shell command executed by exec.Command is constructed from non-constant data/
+	exec.Command("/bin/bash", "-c", fmt.Sprintf("ls %s", dir)) // MATCH /constructed from non-constant data/
+	exec.Command("sh", "-c", "rm "+strings.Join(files, " "))   // MATCH /constructed from non-constant data/
+	exec.Command("sh", "-c", fmt.Sprintf("ls %d", 1))
+	exec.Command("sh", "-c", "ls | wc -l")
+	exec.Command("sh", "-c", dir)
+	exec.Command("ls", dir)
+	exec.Command("sh", "-c", "ls "+os.Getenv("DIR")) // MATCH /constructed from untrusted input/
+
+	script := "make"
+	if verbose {
+		script = "make V=" + dir
+	}
+	exec.Command("sh", "-c", script) // MATCH /constructed from non-constant data/
+}
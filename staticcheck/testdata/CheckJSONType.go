@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type T1 struct {
+	A  int
+	Ch chan int
+}
+
+type T2 struct {
+	Name string `json:"name"`
+	age  int    `json:"age"`
+}
+
+type T3 struct {
+	a, b int
+}
+
+type T4 struct {
+	A  int
+	Fn func() `json:"-"`
+	b  int
+	T  time.Time
+	M  map[int]string
+	E  T5
+}
+
+type T5 struct {
+	C []complex128
+}
+
+type key struct{ a, b int }
+
+type T6 struct {
+	Next *T6
+}
+
+func fn(w io.Writer, data []byte) {
+	json.Marshal(T1{})                        // MATCH /json.Marshal: unsupported type chan int in Ch/
+	json.Marshal(T2{})                        // MATCH /json.Marshal: unexported field age has a json tag but will be ignored/
+	json.Marshal(&T3{})                       // MATCH /json.Marshal: .*T3 has no exported fields and is always represented as {}/
+	json.MarshalIndent(map[key]int{}, "", "") // MATCH /json.MarshalIndent: unsupported map key type .*key, keys must be strings, integers or implement encoding.TextMarshaler/
+	json.NewEncoder(w).Encode([]func(){})     // MATCH /Encode: unsupported type func\(\) in \[\]/
+	json.Unmarshal(data, &T4{})               // MATCH /json.Unmarshal: unsupported type complex128 in E.C\[\]/
+	json.Marshal(T6{})
+	json.Marshal(map[string]interface{}{})
+	json.Marshal(struct{}{})
+}
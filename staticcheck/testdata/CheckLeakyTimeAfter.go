@@ -0,0 +1,49 @@
+package pkg
+
+import "time"
+
+func fn1(ch chan int) {
+	for {
+		select {
+		case <-ch:
+		case <-time.After(time.Second): // MATCH /time.After in a loop creates a timer on every iteration/
+			return
+		}
+	}
+}
+
+func fn2(ch chan int) {
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+	}
+}
+
+func fn3(xs []int) {
+	for range xs {
+		<-time.After(time.Millisecond)
+	}
+}
+
+func fn4(ch chan int) {
+	for {
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case <-ch:
+				continue
+			case <-timeout:
+			}
+			break
+		}
+	}
+}
+
+func fn5(ch chan int) {
+	for {
+		select {
+		case <-time.After(time.Second):
+			return
+		}
+	}
+}
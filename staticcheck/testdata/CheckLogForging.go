@@ -0,0 +1,19 @@
+package pkg
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+)
+
+func fn1(r *http.Request, l *log.Logger) {
+	name := r.FormValue("name")
+	log.Printf("login by %s", name) // MATCH /log.Printf logs untrusted input, which may contain newlines that forge log entries/
+	log.Println("login by", name)   // MATCH /log.Println logs untrusted input/
+	l.Printf("login by %s", name)   // MATCH /\(\*log.Logger\).Printf logs untrusted input/
+	log.Printf("login by " + name)  // MATCH /log.Printf logs untrusted input/
+	log.Printf("login by %q", name)
+	log.Printf("login by %s", strconv.Quote(name))
+	log.Printf("%d bytes from %q", len(name), name)
+	log.Printf("request for %s", "static")
+}
@@ -3,13 +3,13 @@ package pkg
 func fn() {
 	var ch chan int
 	select {
-	case <-ch:
+	case <-ch: // MATCH /nil channel/
 	default:
 	}
 
 	for {
 		select {
-		case <-ch:
+		case <-ch: // MATCH /nil channel/
 		default: // MATCH /should not have an empty default case/
 		}
 	}
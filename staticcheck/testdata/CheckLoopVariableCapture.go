@@ -0,0 +1,66 @@
+package pkg
+
+import "sync"
+
+func fn1(xs []int) {
+	for _, x := range xs {
+		go func() {
+			println(x) // MATCH /loop variable x captured by func literal in go statement; all iterations share the same variable, pass it as an argument or copy it with x := x/
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		defer func() {
+			println(i) // MATCH /loop variable i captured by func literal in defer statement/
+		}()
+	}
+}
+
+func fn2(xs []int) {
+	var fns []func()
+	m := map[int]func(){}
+	for i, x := range xs {
+		fns = append(fns, func() {
+			println(x) // MATCH /loop variable x captured by func literal that is appended to a slice/
+		})
+		m[i] = func() {
+			println(i) // MATCH /loop variable i captured by func literal that is stored in m\[i\]/
+		}
+	}
+}
+
+func fn3(xs []int) {
+	for _, x := range xs {
+		x := x
+		go func() {
+			println(x)
+		}()
+	}
+	for _, x := range xs {
+		go func(x int) {
+			println(x)
+		}(x)
+	}
+	for _, x := range xs {
+		func() {
+			println(x)
+		}()
+	}
+	var x int
+	for _, x = range xs {
+		go func() {
+			println(x)
+		}()
+	}
+}
+
+func fn4(xs []int) {
+	for _, x := range xs {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			println(x)
+		}()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,23 @@
+package pkg
+
+func fn1(x int) {
+	s := make([]int, 3)
+	n := len(s) + 256
+	_ = uint8(n) // MATCH /conversion to uint8 changes the value/
+	_ = int16(n)
+
+	m := len(s) - 10
+	_ = uint(m) // MATCH /conversion to uint changes the value/
+
+	_ = uint8(x)
+	_ = uint8(255)
+}
+
+func fn2(b bool) {
+	x := 100
+	if b {
+		x = 300
+	}
+	_ = uint8(x)
+	_ = int8(x - 1000) // MATCH /conversion to int8 changes the value/
+}
@@ -0,0 +1,22 @@
+package pkg
+
+func fn1(n int) {
+	var s []int
+	neg := len(s) - 1
+	_ = make([]int, neg)       // MATCH /make with negative length/
+	_ = make([]int, 0, neg)    // MATCH /make with negative capacity/
+	_ = make(chan int, neg)    // MATCH /make with negative buffer size/
+	_ = make(map[int]int, neg) // MATCH /make with negative size hint/
+
+	big := len(s) + 10
+	small := len(s) + 5
+	_ = make([]int, big, small) // MATCH /make with length larger than capacity/
+	_ = make([]int, small, big)
+
+	huge := (len(s) + 1) << 62
+	_ = make([]int64, huge) // MATCH /make with a size that exceeds the maximum allocation/
+	_ = make([]struct{}, huge)
+
+	_ = make([]int, n)
+	_ = make([]int, n, small)
+}
@@ -0,0 +1,81 @@
+package pkg
+
+type T struct{ x int }
+
+func (T) m() {}
+
+type I interface{ m() }
+
+func fn1() {
+	var p *T
+	_ = p.x // MATCH /accessing field x of a pointer that is always nil/
+}
+
+func fn2(p *T) {
+	if p == nil {
+		println(p.x) // MATCH /accessing field x of a pointer that is always nil/
+	}
+	if p != nil {
+		println(p.x)
+	}
+}
+
+func fn3(p *int) {
+	if p != nil {
+		return
+	}
+	*p = 1 // MATCH /storing through a pointer that is always nil/
+}
+
+func fn4(p *int) int {
+	x := *p
+	if p == nil { // MATCH /nil check is redundant/
+		return 0
+	}
+	return x
+}
+
+func fn5(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+func fn7(ch chan int) {
+	if ch == nil {
+		ch <- 1   // MATCH /sending on a nil channel blocks forever/
+		<-ch      // MATCH /receiving from a nil channel blocks forever/
+		close(ch) // MATCH /closing a nil channel/
+	}
+}
+
+func fn8(i I) {
+	if i == nil {
+		i.m() // MATCH /calling method m on an interface value that is always nil/
+	}
+}
+
+func fn9(f func()) {
+	if f == nil {
+		f() // MATCH /calling a function value that is always nil/
+	}
+	f()
+}
+
+func fn10(b bool) {
+	var p *T
+	if b {
+		p = nil
+	} else {
+		p = &T{}
+	}
+	_ = p.x
+}
+
+func fn11(p *int) {
+	if p == nil {
+		p = new(int)
+	}
+	*p = 1
+}
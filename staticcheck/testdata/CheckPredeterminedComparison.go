@@ -0,0 +1,32 @@
+package pkg
+
+func fn1(n int) {
+	for i := 0; i < 10; i++ {
+		if i > 20 { // MATCH /condition is always false, the true branch is unreachable/
+			println()
+		}
+		if i >= 0 { // MATCH /condition is always true, the false branch is unreachable/
+			println()
+		}
+		if i < 5 {
+			println()
+		}
+	}
+
+	x := 0
+	if n > 0 {
+		x = 5
+	}
+	b := x > 10 // MATCH /comparison is always false/
+	println(b)
+	println(x != 7) // MATCH /comparison is always true/
+	println(x == 5)
+	println(n > 0)
+}
+
+func fn2() {
+	s := make([]int, 3)
+	if len(s) == 3 { // MATCH /condition is always true/
+		println()
+	}
+}
@@ -0,0 +1,35 @@
+package pkg
+
+func fnNeg(s string, i int) {
+	x := int(s[i])
+	println(-x > 0) // MATCH /comparison is always false/
+	println(-x == -255)
+	println(-x < -255) // MATCH /comparison is always false/
+}
+
+func fnAbs1(x int8) {
+	if x < 0 {
+		x = -x
+	}
+	println(x == -128)
+	println(x < -128) // MATCH /comparison is always false/
+}
+
+func fnAbs2(s string, i int) {
+	x := int(s[i]) - 100
+	if x < 0 {
+		x = -x
+	}
+	println(x < 0)   // MATCH /comparison is always false/
+	println(x > 155) // MATCH /comparison is always false/
+	println(x == 155)
+}
+
+func fnAbs3(s string, i int) {
+	x := int(s[i]) - 100
+	if 0 <= x {
+	} else {
+		x = -x
+	}
+	println(x < 0) // MATCH /comparison is always false/
+}
@@ -0,0 +1,21 @@
+package pkg
+
+import "math/bits"
+
+func fnBits1(x int) {
+	println(x&0xF > 15) // MATCH /comparison is always false/
+	println(x&0xF < 0)  // MATCH /comparison is always false/
+	println(x&0xF == 15)
+	println(0x3&x >= 0) // MATCH /comparison is always true/
+	println(x&-2 < 0)
+}
+
+func fnBits2(x uint32, y uint8, z uint64) {
+	println(bits.Len32(x) > 32)        // MATCH /comparison is always false/
+	println(bits.LeadingZeros8(y) > 8) // MATCH /comparison is always false/
+	println(bits.TrailingZeros64(z) == 64)
+	println(bits.OnesCount8(y) > 8) // MATCH /comparison is always false/
+	println(bits.OnesCount8(y) == 8)
+	println(bits.Len8(y&0x3) > 2) // MATCH /comparison is always false/
+	println(bits.Len8(y|0x80) == 8)
+}
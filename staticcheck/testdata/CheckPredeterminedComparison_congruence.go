@@ -0,0 +1,24 @@
+package pkg
+
+func fnCongruence(s string, i int, b bool) {
+	x := int(s[i]) * 4
+	println(x == 6)    // MATCH /comparison is always false/
+	println(x != 6)    // MATCH /comparison is always true/
+	println(x+1 == 14) // MATCH /comparison is always false/
+	println(x+1 == 1021)
+	println(x<<1 == 12) // MATCH /comparison is always false/
+	println(x<<1 == 16)
+
+	y := x + 2
+	if b {
+		y = 6
+	}
+	// y is in [2, 1022] and ≡ 2 mod 4.
+	println(y == 5) // MATCH /comparison is always false/
+	println(y == 10)
+	println(y > 1021)
+
+	z := x * 3
+	println(z == 6) // MATCH /comparison is always false/
+	println(z == 12)
+}
@@ -0,0 +1,41 @@
+package pkg
+
+func fnInduction1() {
+	for i := 0; i < 10; i++ {
+		println(i > 9)  // MATCH /comparison is always false/
+		println(i < 10) // MATCH /comparison is always true/
+		println(i >= 0) // MATCH /comparison is always true/
+		println(i == 9)
+	}
+	for i := 0; i <= 10; i++ {
+		println(i > 10) // MATCH /comparison is always false/
+		println(i == 10)
+	}
+	for i := 10; i > 0; i-- {
+		println(i < 1) // MATCH /comparison is always false/
+		println(i == 1)
+	}
+}
+
+func fnInduction2(b byte) {
+	n := int(b)
+	for i := 0; i < n; i++ {
+		println(i > 254) // MATCH /comparison is always false/
+		println(i == 254)
+	}
+	for i := 0; i <= n; i++ {
+		println(i > 255) // MATCH /comparison is always false/
+		println(i == 255)
+	}
+}
+
+func fnInduction3(b byte) {
+	for i := 0; i < 10; i += 4 {
+		println(i > 8) // MATCH /comparison is always false/
+	}
+	i := 0
+	for ; i < int(b); i++ {
+	}
+	println(i > 255) // MATCH /comparison is always false/
+	println(i == 255)
+}
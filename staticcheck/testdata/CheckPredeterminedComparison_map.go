@@ -0,0 +1,24 @@
+package pkg
+
+func fnMap(k string) {
+	// Each update adds at most one key, so m never has more than
+	// three.
+	m := map[string]int{"a": 1, "b": 2}
+	m[k] = 3
+	println(len(m) > 3) // MATCH /comparison is always false/
+	println(len(m) == 3)
+	println(len(m) >= 0) // MATCH /comparison is always true/
+
+	var nilMap map[string]int
+	println(len(nilMap) == 0) // MATCH /comparison is always true/
+
+	// The size hint doesn't add any keys.
+	made := make(map[string]int, 10)
+	println(len(made) == 0) // MATCH /comparison is always true/
+
+	grown := map[string]int{}
+	for _, c := range k {
+		grown[string(c)]++
+	}
+	println(len(grown) > 3)
+}
@@ -0,0 +1,16 @@
+package pkg
+
+func fnMinMax(s string, i int, n int) {
+	x := int(s[i])
+	println(min(x, 100) > 100) // MATCH /comparison is always false/
+	println(min(x, 100) == 100)
+	println(max(x, 10) < 10) // MATCH /comparison is always false/
+	println(max(x, 10) == 255)
+	println(max(x, 300) == 300) // MATCH /comparison is always true/
+	println(min(x, n) > 255)    // MATCH /comparison is always false/
+}
+
+func fnMinMaxUnknown(a, b int) {
+	println(min(a, b) > 0)
+	println(max(a, b) > 0)
+}
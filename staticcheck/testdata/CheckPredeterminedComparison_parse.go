@@ -0,0 +1,26 @@
+package pkg
+
+import "strconv"
+
+func fnParse(s string) {
+	i, err := strconv.ParseInt(s, 10, 8)
+	if err != nil {
+		return
+	}
+	println(i > 127)  // MATCH /comparison is always false/
+	println(i < -128) // MATCH /comparison is always false/
+	println(i == 127)
+
+	u, _ := strconv.ParseUint(s, 10, 16)
+	println(u > 65535) // MATCH /comparison is always false/
+	println(u == 65535)
+
+	j, _ := strconv.ParseInt(s, 10, 32)
+	println(j > 1<<31-1) // MATCH /comparison is always false/
+	println(j > 1<<30)
+}
+
+func fnParseBitSize(s string, bitSize int) {
+	i, _ := strconv.ParseInt(s, 10, bitSize)
+	println(i > 1<<31)
+}
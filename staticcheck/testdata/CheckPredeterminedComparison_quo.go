@@ -0,0 +1,20 @@
+package pkg
+
+func fnQuo(s string, i int, b bool) {
+	x := int(s[i]) / 4
+	println(x > 63) // MATCH /comparison is always false/
+	println(x >= 63)
+
+	y := -100
+	if b {
+		y = 50
+	}
+	z := y / 10
+	println(z < -10) // MATCH /comparison is always false/
+	println(z > 5)   // MATCH /comparison is always false/
+	println(z == 5)
+
+	// Dividing by zero panics, so the divisor is at least 1.
+	println(1000/x > 1000) // MATCH /comparison is always false/
+	println(1000/x >= 1000)
+}
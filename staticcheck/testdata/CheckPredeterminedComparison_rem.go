@@ -0,0 +1,18 @@
+package pkg
+
+func fnRem(s string, i int, b bool) {
+	x := int(s[i]) % 10
+	println(x > 9)  // MATCH /comparison is always false/
+	println(x >= 0) // MATCH /comparison is always true/
+	println(x == 9)
+
+	y := -100
+	if b {
+		y = 50
+	}
+	// The remainder has the sign of the dividend.
+	z := y % 4
+	println(z < -3) // MATCH /comparison is always false/
+	println(z > 3)  // MATCH /comparison is always false/
+	println(z < 0)
+}
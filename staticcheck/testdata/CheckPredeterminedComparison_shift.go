@@ -0,0 +1,12 @@
+package pkg
+
+func fnShift(s string, i int) {
+	x := int(s[i])
+	println(x<<2 > 1020) // MATCH /comparison is always false/
+	println(x<<2 == 1020)
+	println(x>>4 < 16) // MATCH /comparison is always true/
+	println(x>>4 == 15)
+
+	var n uint = 3
+	println(1<<n == 8) // MATCH /comparison is always true/
+}
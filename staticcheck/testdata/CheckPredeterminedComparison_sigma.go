@@ -0,0 +1,57 @@
+package pkg
+
+func fnSigma1(x int) {
+	if x > 10 {
+		println(x < 5)  // MATCH /comparison is always false/
+		println(x > 10) // MATCH /comparison is always true/
+		println(x > 20)
+	} else {
+		println(x > 10) // MATCH /comparison is always false/
+		println(x < 5)
+	}
+	println(x > 10)
+}
+
+func fnSigma2(x, y int) {
+	if x < 0 || x >= 10 {
+		return
+	}
+	println(x >= 0) // MATCH /comparison is always true/
+	println(x < 10) // MATCH /comparison is always true/
+	if y > x {
+		println(y > 0) // MATCH /comparison is always true/
+	}
+}
+
+func fnSigma3(x uint) {
+	y := x % 3
+	switch y {
+	case 0:
+	case 2:
+	default:
+		println(y == 1) // MATCH /comparison is always true/
+	}
+}
+
+func fnSigma4(s []int) {
+	if len(s) > 2 {
+		println(len(s) == 0) // MATCH /comparison is always false/
+	}
+}
+
+func fnSigma5(x uint) {
+	y := x % 10
+	switch y {
+	case 3:
+	default:
+		// intervals can't have holes, so excluding a value that
+		// isn't one of the bounds of [0, 9] doesn't narrow y
+		println(y == 3)
+		println(y > 9) // MATCH /comparison is always false/
+	}
+	switch y {
+	case 9:
+	default:
+		println(y == 9) // MATCH /comparison is always false/
+	}
+}
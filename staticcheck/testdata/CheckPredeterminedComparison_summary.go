@@ -0,0 +1,24 @@
+package pkg
+
+func digit(s string, i int) int { return int(s[i]) % 10 }
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func fnSummary(s string, i int) {
+	d := digit(s, i)
+	println(d > 9)  // MATCH /comparison is always false/
+	println(d >= 0) // MATCH /comparison is always true/
+	println(d == 9)
+
+	n := sign(i)
+	println(n < -1) // MATCH /comparison is always false/
+	println(n == 1)
+}
@@ -0,0 +1,22 @@
+package pkg
+
+import "unsafe"
+
+func fnUintptr1(p unsafe.Pointer, f float64) {
+	u := uintptr(p)
+	println(u == 0)
+	println(u > 1<<40)
+	println(u+1 == 0)
+
+	aligned := u &^ 7
+	println(aligned == 8)
+	println(aligned == 9) // MATCH /comparison is always false/
+
+	masked := u & ^uintptr(3)
+	println(masked == 4)
+	println(masked == 6) // MATCH /comparison is always false/
+
+	n := uint8(f)
+	println(n == 255)
+	println(n > 255) // MATCH /comparison is always false/
+}
@@ -0,0 +1,24 @@
+package pkg
+
+func fnWrap(s string, i int) {
+	// s[i] is a byte, and 255+1 wraps around to 0.
+	x := s[i] + 1
+	println(x > 0)
+	println(x == 0)
+
+	// Without wrapping, the sum stays in [1, 128].
+	y := s[i]/2 + 1
+	println(y > 0)   // MATCH /comparison is always true/
+	println(y > 128) // MATCH /comparison is always false/
+
+	// [0, 3] - 4 always wraps around, to [252, 255].
+	z := s[i]%4 - 4
+	println(z >= 252) // MATCH /comparison is always true/
+	println(z == 255)
+
+	// [-32, 31] * 8 overflows int8 for some values only, which
+	// could produce any int8.
+	v := int8(s[i]/4) - 32
+	println(v*8 > -128)
+	println(v*8 < 127)
+}
@@ -13,3 +13,78 @@ func fn() {
 		regexp.MatchReader("", nil) // MATCH /calling regexp.MatchReader in a loop has poor performance/
 	}
 }
+
+var re = regexp.MustCompile("a+")
+
+func init() {
+	for i := 0; i < 2; i++ {
+		regexp.MustCompile("b+")
+	}
+}
+
+func fn2(patterns []string) {
+	for _, p := range patterns {
+		regexp.MustCompile(p)
+		regexp.MustCompile("c+")  // MATCH /calling regexp.MustCompile with a constant pattern in a loop compiles it on every iteration/
+		regexp.Compile("c+")      // MATCH /calling regexp.Compile with a constant pattern in a loop/
+		regexp.CompilePOSIX("c+") // MATCH /calling regexp.CompilePOSIX with a constant pattern in a loop/
+		isWord(p)
+	}
+}
+
+func isWord(s string) bool {
+	return regexp.MustCompile(`^\w+$`).MatchString(s) // MATCH /in a function that is called in a loop compiles it on every call/ -> `return isWordRe.MatchString(s)`
+}
+
+func once(s string) bool {
+	return regexp.MustCompile(`^\w+$`).MatchString(s)
+}
+
+func fn3(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		// MATCH:47 /calling regexp.MustCompile with a constant pattern in a loop/ -> `if comment.MatchString(line) {`
+		comment := regexp.MustCompile(`^\s*#`)
+		if comment.MatchString(line) {
+			n++
+		}
+	}
+	for _, line := range lines {
+		// re is already declared at the package level.
+		re := regexp.MustCompile("d+") // MATCH /calling regexp.MustCompile with a constant pattern in a loop/
+		_ = re.MatchString(line)
+		blank := regexp.MustCompile("^$") // MATCH /calling regexp.MustCompile with a constant pattern in a loop/
+		_ = &blank
+	}
+	return n
+}
+
+func fn4(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		if regexp.MustCompile(`^\s*#`).MatchString(line) { // MATCH /calling regexp.MustCompile with a constant pattern in a loop/ -> `if fn4Re.MatchString(line) {`
+			n++
+		}
+	}
+	return n
+}
+
+type T struct{}
+
+func (*T) Match(lines []string) bool {
+	for _, line := range lines {
+		if regexp.MustCompile("e+").MatchString(line) { // MATCH /calling regexp.MustCompile with a constant pattern in a loop/ -> `if tMatchRe.MatchString(line) {`
+			return true
+		}
+	}
+	return false
+}
+
+var fn5Re = regexp.MustCompile("f+")
+
+func fn5(lines []string) {
+	for _, line := range lines {
+		// fn5Re is already declared, so there's no fix.
+		_ = regexp.MustCompile("f+").MatchString(line) // MATCH /calling regexp.MustCompile with a constant pattern in a loop/
+	}
+}
@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"database/sql"
+	"net"
+	"os"
+)
+
+func fn1() error {
+	f, err := os.Open("") // MATCH /the result of os.Open is not released on all paths; make sure to call Close/
+	if err != nil {
+		return err
+	}
+	_, err = f.Read(nil)
+	return err
+}
+
+func fn2() error {
+	f, err := os.Open("")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Read(nil)
+	return err
+}
+
+func fn3(b bool) error {
+	f, err := os.Create("") // MATCH /the result of os.Create is not released on all paths/
+	if err != nil {
+		return err
+	}
+	if b {
+		return nil
+	}
+	return f.Close()
+}
+
+func fn4() (*os.File, error) {
+	f, err := os.Open("")
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func fn5() {
+	f, _ := os.Open("")
+	if f == nil {
+		return
+	}
+	f.Close()
+}
+
+func fn6() error {
+	conn, err := net.Dial("tcp", "") // MATCH /the result of net.Dial is not released on all paths/
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(nil)
+	return err
+}
+
+func fn7(db *sql.DB) error {
+	tx, err := db.Begin() // MATCH /the result of \(\*database\/sql.DB\).Begin is not released on all paths; make sure to call Commit or Rollback/
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func fn8(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(""); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+type Pool struct{}
+type Conn struct{}
+
+func (*Pool) Get() (*Conn, error) { return nil, nil }
+func (*Conn) Release()            {}
+func (*Conn) Do()                 {}
+
+func fn9(p *Pool) {
+	c, err := p.Get() // MATCH /the result of \(\*CheckResourceLeak.go.Pool\).Get is not released on all paths; make sure to call Release/
+	if err != nil {
+		return
+	}
+	c.Do()
+}
+
+func fn10(p *Pool) {
+	c, _ := p.Get()
+	defer c.Release()
+	c.Do()
+}
+
+func fn11(files chan *os.File) {
+	f, _ := os.Open("")
+	files <- f
+}
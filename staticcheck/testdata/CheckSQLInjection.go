@@ -0,0 +1,18 @@
+package pkg
+
+import (
+	"database/sql"
+	"net/http"
+)
+
+func fn1(db *sql.DB, r *http.Request) {
+	db.Query("SELECT * FROM users WHERE name = '" + r.FormValue("name") + "'") // MATCH /SQL query passed to \(\*sql.DB\).Query is constructed from untrusted input/
+	db.Query("SELECT * FROM users WHERE name = ?", r.FormValue("name"))
+
+	q := "DELETE FROM users WHERE id = " + r.URL.Query().Get("id")
+	db.Exec(q) // MATCH /SQL query passed to \(\*sql.DB\).Exec/
+}
+
+func fn2(tx *sql.Tx, name string) {
+	tx.Query("SELECT * FROM users WHERE name = '" + name + "'")
+}
@@ -0,0 +1,36 @@
+package pkg
+
+func fn1() {
+	ch := make(chan int, 1)
+	close(ch)
+	ch <- 1 // MATCH /sending on a channel that has already been closed/
+}
+
+func fn2(b bool) {
+	ch := make(chan int, 1)
+	if b {
+		close(ch)
+	}
+	ch <- 1
+}
+
+func fn3() {
+	ch := make(chan int, 1)
+	close(ch)
+	go func() {
+		ch <- 1 // MATCH /sending on a channel that has already been closed/
+	}()
+}
+
+func fn4() {
+	ch := make(chan int, 1)
+	ch <- 1
+	close(ch)
+}
+
+func fn5() {
+	ch := make(chan int, 1)
+	close(ch)
+	ch = make(chan int, 1)
+	ch <- 1
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import "errors"
+
+func get() (int, error) { return 0, errors.New("") }
+
+func fn1(b bool) error {
+	var err error
+	if b {
+		x, err := get()
+		if err != nil {
+			println(x)
+		}
+	}
+	return err // MATCH /err is always nil here, the err declared in an inner scope shadows it; did you mean to use = instead of :=\?/ related 10:6
+}
+
+func fn2(b bool) error {
+	var err error
+	if b {
+		var x int
+		x, err = get()
+		println(x)
+	}
+	return err
+}
+
+func fn3(b bool) error {
+	_, err := get()
+	if b {
+		_, err := get()
+		if err != nil {
+			println()
+		}
+	}
+	return err
+}
+
+func fn4(xs []int) error {
+	var err error
+	for range xs {
+		_, err := get()
+		if err != nil {
+			break
+		}
+	}
+	if err != nil { // MATCH /err is always nil here/
+		return err
+	}
+	return nil
+}
+
+func fn5(b bool) error {
+	var err error
+	if b {
+		_, err := get()
+		if err != nil {
+			return err
+		}
+	}
+	err = nil
+	println(err)
+	return nil
+}
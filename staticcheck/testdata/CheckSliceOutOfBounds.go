@@ -63,3 +63,48 @@ func fn(int) {
 	println() // make it unpure
 }
 func ptr(*[]int) {}
+
+func fn11() {
+	var a [4]int
+	for i := 0; i < 5; i++ {
+		if i == 0 {
+			continue
+		}
+		_ = a[i]
+	}
+	for i := 4; i < 8; i++ {
+		_ = a[i] // MATCH /index out of bounds/
+	}
+}
+
+func fn12() {
+	s := "abc"
+	for i := 3; i < 10; i++ {
+		_ = s[i] // MATCH /index out of bounds/
+	}
+}
+
+func fn13() {
+	s := make([]int, 3)
+	_ = s[len(s)] // MATCH /index out of bounds/
+	i := len(s) - 4
+	_ = s[i] // MATCH /index out of bounds/
+}
+
+func fn14(n int) {
+	var s []int
+	for i := range s {
+		_ = s[i]
+	}
+	t := make([]int, n)
+	if len(t) == 2 {
+		_ = t[1]
+		_ = t[2] // MATCH /index out of bounds/
+	}
+}
+
+func fn15() {
+	panic("unreachable")
+	var s []int
+	_ = s[0]
+}
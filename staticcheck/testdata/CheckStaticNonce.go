@@ -0,0 +1,34 @@
+package pkg
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+)
+
+func fn1(aead cipher.AEAD, plaintext []byte) {
+	nonce := make([]byte, aead.NonceSize())
+	aead.Seal(nil, nonce, plaintext, nil) // MATCH /\(cipher.AEAD\).Seal is called with a nonce that doesn't change between calls/
+
+	aead.Seal(nil, []byte("123456789012"), plaintext, nil) // MATCH /with a nonce that doesn't change/
+
+	var fixed [12]byte
+	aead.Seal(nil, fixed[:], plaintext, nil) // MATCH /with a nonce that doesn't change/
+
+	random := make([]byte, aead.NonceSize())
+	io.ReadFull(rand.Reader, random)
+	aead.Seal(nil, random, plaintext, nil)
+}
+
+func fn2(block cipher.Block, dst, src []byte) {
+	iv := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(dst, src) // MATCH /cipher.NewCBCEncrypter is called with an IV that doesn't change between calls/
+
+	iv2 := make([]byte, block.BlockSize())
+	rand.Read(iv2)
+	cipher.NewCTR(block, iv2).XORKeyStream(dst, src)
+}
+
+func fn3(aead cipher.AEAD, nonce, plaintext []byte) {
+	aead.Seal(nil, nonce, plaintext, nil)
+}
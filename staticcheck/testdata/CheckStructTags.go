@@ -0,0 +1,25 @@
+package pkg
+
+type T1 struct {
+	A int `json:"a,omitempty"`
+	B int `json:"b,omitempty,string"`
+	C int `json:"-"`
+	D int `json:"-,"`
+	E int `json:",omitempty"`
+	F int `json:"f,omitemty"`            // MATCH /unknown option "omitemty" in json tag/
+	G int `json:"g" json:"h"`            // MATCH /duplicate struct tag key "json"/
+	H int `json:"h,omitempty,omitempty"` // MATCH /duplicate "omitempty" in json tag/
+	I int `json: "i"`                    // MATCH /malformed struct tag: value of key "json" is not quoted/
+	J int `json:"j`                      // MATCH /malformed struct tag: value of key "json" is missing its closing quote/
+	K int `xml:"k,attr" json:"k"`
+	L int `xml:"l,attribute"` // MATCH /unknown option "attribute" in xml tag/
+	M int `unknown:"whatever,foo"`
+}
+
+type T2 struct {
+	A string `db:"a"`
+	B string `db:",pk"`       // MATCH /db tag has no name/
+	C string `db:"c,pk,auto"` // MATCH /unknown option "auto" in db tag/
+	D string `validate:"required,min=3"`
+	E string `validate:"min=3,maximum=4"` // MATCH /unknown key "maximum" in validate tag/
+}
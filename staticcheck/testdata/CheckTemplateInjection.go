@@ -0,0 +1,25 @@
+package pkg
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"os"
+	"text/template"
+)
+
+func fn1(w http.ResponseWriter, r *http.Request) {
+	t := template.Must(template.New("").Parse("Hello, {{.}}"))
+	t.Execute(w, r.FormValue("name"))             // MATCH /\(\*template.Template\).Execute writes untrusted input to an HTTP response without escaping it; use html\/template instead/
+	t.ExecuteTemplate(w, "", r.FormValue("name")) // MATCH /ExecuteTemplate writes untrusted input/
+	t.Execute(os.Stdout, r.FormValue("name"))
+	t.Execute(w, "static")
+
+	ht := htmltemplate.Must(htmltemplate.New("").Parse("Hello, {{.}}"))
+	ht.Execute(w, r.FormValue("name"))
+}
+
+func fn2(w io.Writer, r *http.Request) {
+	template.New("").Parse("Hello, " + r.FormValue("name")) // MATCH /template text passed to \(\*template.Template\).Parse is constructed from untrusted input/
+	htmltemplate.New("").Parse(r.FormValue("tmpl"))         // MATCH /template text passed to \(\*template.Template\).Parse/
+}
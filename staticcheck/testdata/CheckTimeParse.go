@@ -12,3 +12,21 @@ func fn() {
 	time.Parse(time.RFC3339Nano, "")
 	time.Parse(time.Kitchen, "")
 }
+
+func fn2(t time.Time, loc *time.Location) {
+	time.Parse("%Y-%m-%d", "")                  // MATCH /strftime verb %Y/
+	time.ParseInLocation("2006-13-02", "", loc) // MATCH /month out of range/
+	t.Format("2006-13-02")                      // MATCH /13 is not part of the reference time/
+	t.Format("2006-01-02 15:04 PM")             // MATCH /combines the 24-hour clock/
+	t.Format("2006-01-02 03:04")                // MATCH /12-hour clock \(03\) without an AM\/PM marker/
+	t.Format("01/02/2006 01:04")                // MATCH /specifies the month twice, as 01 and 01/
+	t.AppendFormat(nil, "%H:%M")                // MATCH /strftime verb %H/
+	t.Format("2006-01-02T15:04:05.000Z07:00")
+	t.Format("Jan _2 3:04PM")
+	t.Format(time.ANSIC)
+	t.Format(time.RFC850)
+	t.Format(time.RFC1123Z)
+	t.Format(time.StampNano)
+	t.Format("20060102150405")
+	t.Format("100%")
+}
@@ -0,0 +1,37 @@
+package pkg
+
+import "sync/atomic"
+
+type T1 struct {
+	n int64
+	b bool
+}
+
+type T2 struct {
+	b bool
+	n int64
+}
+
+type T3 struct {
+	a, b int32
+	n    uint64
+}
+
+type T4 struct {
+	x  int32
+	t1 T1
+}
+
+type T5 struct {
+	x  int64
+	t1 T1
+}
+
+func fn(t1 *T1, t2 *T2, t3 *T3, t4 *T4, t5 *T5) {
+	atomic.AddInt64(&t1.n, 1)
+	atomic.AddInt64(&t2.n, 1) // MATCH /atomic.AddInt64 on field n, which isn't 64-bit aligned on 386 \(offset 4\) and arm \(offset 4\)/
+	atomic.LoadUint64(&t3.n)
+	atomic.StoreInt64(&t4.t1.n, 1) // MATCH /atomic.StoreInt64 on field n, which isn't 64-bit aligned/
+	atomic.CompareAndSwapInt64(&t5.t1.n, 0, 1)
+	atomic.AddInt32(&t4.x, 1)
+}
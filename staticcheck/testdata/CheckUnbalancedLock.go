@@ -0,0 +1,85 @@
+package pkg
+
+import "sync"
+
+type T struct {
+	mu  sync.Mutex
+	rw  sync.RWMutex
+	mu2 sync.Mutex
+	n   int
+}
+
+func (t *T) fn1(b bool) int {
+	t.mu.Lock() // MATCH /t.mu is locked here but not unlocked on some paths that return/ related 15
+	if b {
+		return 0
+	}
+	t.mu.Unlock()
+	return 1
+}
+
+func (t *T) fn2(b bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if b {
+		return 0
+	}
+	return 1
+}
+
+func (t *T) fn3() {
+	t.mu.Lock()
+	t.n++
+	t.mu.Unlock()
+	t.mu.Unlock() // MATCH /t.mu is unlocked twice on some paths/
+}
+
+func (t *T) fn4() {
+	t.mu.Lock()
+	t.n++
+	t.mu2.Unlock() // MATCH /unlocking t.mu2, but the mutex locked by this function is t.mu/
+}
+
+func (t *T) fn5(b bool) {
+	t.rw.RLock() // MATCH /t.rw is locked here but not unlocked/
+	if b {
+		return
+	}
+	t.rw.RUnlock()
+}
+
+func (t *T) lock() {
+	t.mu.Lock()
+}
+
+func (t *T) unlock() {
+	t.mu.Unlock()
+}
+
+func (t *T) fn6(xs []int) {
+	for range xs {
+		t.mu.Lock()
+		t.n++
+		t.mu.Unlock()
+	}
+}
+
+func (t *T) fn7(b bool) {
+	t.mu.Lock()
+	defer func() {
+		t.mu.Unlock()
+	}()
+	if b {
+		return
+	}
+}
+
+func (t *T) fn8(b bool) {
+	t.mu.Lock()
+	if b {
+		t.mu.Unlock()
+		return
+	}
+	t.n++
+	t.mu.Unlock()
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func Fn1(x interface{}) int {
+	if n, ok := x.(int); ok {
+		return n
+	}
+	switch x := x.(type) {
+	case string:
+		return len(x)
+	}
+	return x.(int) // MATCH /type assertion to int on external input panics if the assertion fails, use the comma-ok form: v, ok := x.\(int\)/
+}
+
+func fn2(x interface{}) int {
+	return x.(int)
+}
+
+type T struct{}
+
+func (T) Method(x interface{}) {
+	_ = x.(io.Reader) // MATCH /type assertion to io.Reader on external input/
+}
+
+func fn3(data []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return ""
+	}
+	m := v.(map[string]interface{}) // MATCH /type assertion to map\[string\]interface{} on external input/
+	return m["name"].(string)       // MATCH /type assertion to string on external input/
+}
+
+func fn4(r io.Reader) {
+	var s struct {
+		Items []interface{}
+	}
+	json.NewDecoder(r).Decode(&s)
+	for _, item := range s.Items {
+		_ = item.(float64) // MATCH /type assertion to float64 on external input/
+	}
+	if n, ok := s.Items[0].(float64); ok {
+		_ = n
+	}
+}
+
+func fn5() {
+	var x interface{} = 1
+	_ = x.(int)
+}
+
+type decoder struct{}
+
+func (decoder) decode(v interface{}) {}
+
+func fn6(d decoder) {
+	var v interface{}
+	d.decode(&v)
+	_ = v.(int) // MATCH /type assertion to int on external input/
+}
@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"log"
+	"os"
+)
+
+const debug = false
+
+func die(msg string) {
+	log.Println(msg)
+	os.Exit(1)
+}
+
+func fatal(msg string) {
+	panic(msg)
+}
+
+func dieTwice(msg string) {
+	die(msg)
+}
+
+func recovers() {
+	defer func() { recover() }()
+	panic("foo")
+}
+
+func fn1() {
+	log.Fatal("foo")
+	println() // MATCH /unreachable because log.Fatal never returns/
+}
+
+func fn2() {
+	die("foo")
+	println() // MATCH /unreachable because die never returns/
+}
+
+func fn3() {
+	fatal("foo")
+	println() // MATCH /unreachable because fatal never returns/
+}
+
+func fn4() {
+	dieTwice("foo")
+	println() // MATCH /unreachable because dieTwice never returns/
+}
+
+func fn5() {
+	recovers()
+	println()
+	panic("foo")
+	println()
+}
+
+func fn6() {
+	if false {
+		println()
+	}
+	if 1 > 2 { // MATCH /condition is always false/
+		println()
+	}
+	if 1 < 2 {
+		println()
+	} else { // MATCH /condition is always true, the else branch is unreachable/
+		println()
+	}
+	for 1 > 2 { // MATCH /condition is always false, the loop body/
+	}
+	for false {
+	}
+	if debug {
+		println()
+	}
+	if !debug {
+		println()
+	} else {
+		println()
+	}
+}
+
+func fn7(ch chan int) {
+	var nilch chan int
+	select {
+	case <-nilch: // MATCH /receiving from a nil channel/
+	case nilch <- 1: // MATCH /sending on a nil channel/
+	case <-ch:
+	}
+
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				ch = nil
+			}
+		}
+		if ch == nil {
+			break
+		}
+	}
+}
+
+func fn8() {
+	die("foo")
+L:
+	println()
+	goto L
+}
@@ -0,0 +1,28 @@
+package pkg
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+type T struct {
+	a, b int
+}
+
+func fn(t *T, v reflect.Value, off uintptr, addr uintptr) {
+	_ = unsafe.Pointer(uintptr(unsafe.Pointer(t)) + unsafe.Offsetof(t.b))
+	_ = unsafe.Pointer(uintptr(unsafe.Pointer(t)) + off)
+	_ = unsafe.Pointer(v.Pointer())
+	_ = unsafe.Pointer(v.UnsafeAddr() + 8)
+
+	u := uintptr(unsafe.Pointer(t))
+	_ = unsafe.Pointer(u + unsafe.Offsetof(t.b)) // MATCH /converting u, a uintptr stored in a variable, to unsafe.Pointer is invalid/
+	u2 := uintptr(unsafe.Pointer(t)) + 8
+	_ = unsafe.Pointer(u2) // MATCH /converting u2, a uintptr stored in a variable/
+
+	p := v.Pointer()
+	_ = unsafe.Pointer(p) // MATCH /the result of v.Pointer has to be converted to unsafe.Pointer in the same expression as the call; stored in p/
+
+	_ = unsafe.Pointer(addr)
+	_ = unsafe.Pointer(addr + off)
+}
@@ -0,0 +1,80 @@
+package pkg
+
+import "sync"
+
+var n int
+
+func fn1() {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		mu.Lock()
+		n++
+		mu.Unlock()
+		wg.Done()
+	}()
+	mu.Lock()
+	wg.Wait() // MATCH /waiting while holding a lock that a goroutine has to acquire/
+	mu.Unlock()
+}
+
+func fn2() {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+	}()
+	mu.Lock()
+	defer mu.Unlock()
+	wg.Wait() // MATCH /waiting while holding a lock/
+}
+
+func fn3() {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		mu.Lock()
+		n++
+		mu.Unlock()
+		wg.Done()
+	}()
+	mu.Lock()
+	n++
+	mu.Unlock()
+	wg.Wait()
+}
+
+func fn4() {
+	var mu, other sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		other.Lock()
+		n++
+		other.Unlock()
+		wg.Done()
+	}()
+	mu.Lock()
+	wg.Wait()
+	mu.Unlock()
+}
+
+func fn5() {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		wg.Done()
+		mu.Lock()
+		n++
+		mu.Unlock()
+	}()
+	mu.Lock()
+	wg.Wait()
+	mu.Unlock()
+}
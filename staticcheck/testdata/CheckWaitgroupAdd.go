@@ -16,6 +16,11 @@ func fn() {
 		wg.Done()
 	}()
 
+	go func() {
+		defer wg.Done()
+		wg.Add(1) // MATCH "should call wg.Add(1) before starting"
+	}()
+
 	wg.Add(1)
 	go func(wg sync.WaitGroup) {
 		wg.Done()
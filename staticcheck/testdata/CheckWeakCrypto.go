@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"crypto"
+	"crypto/des"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"io"
+)
+
+func fn1(key []byte) {
+	des.NewCipher(key)          // MATCH /DES is broken/
+	des.NewTripleDESCipher(key) // MATCH /DES is broken/
+	rc4.NewCipher(key)          // MATCH /RC4 is broken/
+}
+
+func fn2(password string, data []byte) {
+	md5.Sum([]byte(password))  // MATCH /md5.Sum is not suitable for hashing secrets/
+	sha1.Sum([]byte(password)) // MATCH /sha1.Sum is not suitable for hashing secrets/
+	md5.Sum(data)
+	sha256.Sum256([]byte(password))
+}
+
+func fn3(r io.Reader, priv *rsa.PrivateKey, hashed []byte, sig []byte) {
+	rsa.SignPKCS1v15(r, priv, crypto.SHA1, hashed)               // MATCH /SHA-1 is not collision resistant/
+	rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.MD5, hashed, sig) // MATCH /MD5 is not collision resistant/
+	rsa.SignPKCS1v15(r, priv, crypto.SHA256, hashed)
+}
@@ -5,8 +5,10 @@ import "io"
 func fn() {
 	const SeekStart = 0
 	var s io.Seeker
-	s.Seek(0, 0)
-	s.Seek(0, io.SeekStart)
+	s.Seek(0, 0)            // MATCH /calling method Seek on an interface value that is always nil/
+	s.Seek(0, io.SeekStart) // MATCH /calling method Seek on an interface value that is always nil/
 	s.Seek(io.SeekStart, 0) // MATCH /the first argument of io.Seeker is the offset/
-	s.Seek(SeekStart, 0)
+	s.Seek(SeekStart, 0)    // MATCH /calling method Seek on an interface value that is always nil/
 }
+
+// MATCH:10 /calling method Seek on an interface value that is always nil/
@@ -0,0 +1,130 @@
+package vrp
+
+import (
+	"fmt"
+	"go/token"
+
+	"honnef.co/go/tools/ssa"
+)
+
+// IntAbsConstraint models the absolute value idiom
+//
+//	if x < 0 {
+//		x = -x
+//	}
+//
+// which yields a φ-node of x and -x, where -x is only taken when x is
+// negative.
+type IntAbsConstraint struct {
+	aConstraint
+	X ssa.Value
+}
+
+func NewIntAbsConstraint(x, y ssa.Value) Constraint {
+	return &IntAbsConstraint{NewConstraint(y), x}
+}
+
+func (c *IntAbsConstraint) Operands() []ssa.Value { return []ssa.Value{c.X} }
+
+func (c *IntAbsConstraint) String() string {
+	return fmt.Sprintf("%s = abs(%s)", c.Y().Name(), c.X.Name())
+}
+
+func (c *IntAbsConstraint) Eval(g *Graph) Range {
+	x := g.Range(c.X).(IntInterval)
+	if !x.IsKnown() {
+		return IntInterval{}
+	}
+	if x.Empty() {
+		return EmptyIntInterval
+	}
+	var lower Z
+	switch {
+	case x.Lower.Sign() >= 0:
+		lower = x.Lower
+	case x.Upper.Sign() <= 0:
+		lower = x.Upper.Negate()
+	default:
+		lower = NewZ(0)
+	}
+	i := NewIntInterval(lower, MaxZ(x.Lower.Abs(), x.Upper.Abs()))
+	if g.Wrap {
+		// Negating the smallest value of a signed type yields that
+		// same value.
+		min, max, ok := TypeBounds(c.Y().Type(), g.Sizes)
+		if ok && min.Sign() < 0 && x.Lower.Cmp(min) <= 0 {
+			i = NewIntInterval(min, MinZ(i.Upper, max))
+		}
+	}
+	return i
+}
+
+// absConstraint returns an IntAbsConstraint for phi if it implements
+// the absolute value idiom, or nil otherwise.
+func absConstraint(phi *ssa.Phi) Constraint {
+	if len(phi.Edges) != 2 || !isInteger(phi.Type()) {
+		return nil
+	}
+	for i, edge := range phi.Edges {
+		// The negation sees x through the σ-node of the guard.
+		neg, ok := edge.(*ssa.UnOp)
+		if !ok || neg.Op != token.SUB || unsigma(neg.X) != unsigma(phi.Edges[1-i]) {
+			continue
+		}
+		x := unsigma(neg.X)
+		negPred, posPred := phi.Block().Preds[i], phi.Block().Preds[1-i]
+		for _, ref := range *x.Referrers() {
+			cond, ok := ref.(*ssa.BinOp)
+			if !ok || !isSignGuard(cond, x) {
+				continue
+			}
+			b := cond.Block()
+			ifInstr, ok := b.Instrs[len(b.Instrs)-1].(*ssa.If)
+			if !ok || ifInstr.Cond != ssa.Value(cond) || b.Succs[0] == b.Succs[1] {
+				continue
+			}
+			op := cond.Op
+			if cond.Y == x {
+				op = flipToken(op)
+			}
+			negSucc, posSucc := b.Succs[0], b.Succs[1]
+			if op == token.GTR || op == token.GEQ {
+				negSucc, posSucc = posSucc, negSucc
+			}
+			// -x must only be reachable when x isn't positive, and x
+			// only when it isn't negative.
+			if len(negSucc.Preds) != 1 || !negSucc.Dominates(negPred) {
+				continue
+			}
+			if !(posPred == b && posSucc == phi.Block()) &&
+				(len(posSucc.Preds) != 1 || !posSucc.Dominates(posPred)) {
+				continue
+			}
+			return NewIntAbsConstraint(x, phi)
+		}
+	}
+	return nil
+}
+
+// isSignGuard reports whether cond compares x against zero, such
+// that one branch only sees x ≤ 0 and the other only x ≥ 0.
+func isSignGuard(cond *ssa.BinOp, x ssa.Value) bool {
+	var other ssa.Value
+	switch x {
+	case cond.X:
+		other = cond.Y
+	case cond.Y:
+		other = cond.X
+	default:
+		return false
+	}
+	k, ok := other.(*ssa.Const)
+	if !ok || k.Value == nil || k.Value.String() != "0" {
+		return false
+	}
+	switch cond.Op {
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return true
+	}
+	return false
+}
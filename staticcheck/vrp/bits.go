@@ -0,0 +1,87 @@
+package vrp
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"honnef.co/go/tools/ssa"
+)
+
+// IntBitsConstraint models the functions of the math/bits package
+// that count bits, such as bits.Len and bits.OnesCount64. Their
+// results are bounded by the size of their argument's type, and are
+// derived from the range of the argument where possible.
+type IntBitsConstraint struct {
+	aConstraint
+	X ssa.Value
+	// Fn is the name of the function, without the size suffix: one
+	// of Len, LeadingZeros, TrailingZeros and OnesCount.
+	Fn string
+}
+
+func NewIntBitsConstraint(x ssa.Value, fn string, y ssa.Value) Constraint {
+	return &IntBitsConstraint{NewConstraint(y), x, strings.TrimRight(fn, "0123456789")}
+}
+
+func (c *IntBitsConstraint) Operands() []ssa.Value { return []ssa.Value{c.X} }
+
+func (c *IntBitsConstraint) String() string {
+	return fmt.Sprintf("%s = bits.%s(%s)", c.Y().Name(), c.Fn, c.X.Name())
+}
+
+func (c *IntBitsConstraint) Eval(g *Graph) Range {
+	x := g.Range(c.X).(IntInterval)
+	if !x.IsKnown() {
+		return IntInterval{}
+	}
+	if x.Empty() {
+		return EmptyIntInterval
+	}
+	n := int(g.Sizes.Sizeof(c.X.Type()) * 8)
+	_, max, _ := TypeBounds(c.X.Type(), g.Sizes)
+	if x.Lower.Sign() < 0 || x.Upper.Cmp(max) == 1 {
+		x = NewIntInterval(NewZ(0), max)
+	}
+	lo, hi := x.Lower.bigInt(), x.Upper.bigInt()
+	exact := lo.Cmp(hi) == 0
+	switch c.Fn {
+	case "Len":
+		return NewIntInterval(NewZ(int64(lo.BitLen())), NewZ(int64(hi.BitLen())))
+	case "LeadingZeros":
+		return NewIntInterval(NewZ(int64(n-hi.BitLen())), NewZ(int64(n-lo.BitLen())))
+	case "TrailingZeros":
+		switch {
+		case exact && lo.Sign() == 0:
+			k := NewZ(int64(n))
+			return NewIntInterval(k, k)
+		case exact:
+			k := NewZ(int64(lo.TrailingZeroBits()))
+			return NewIntInterval(k, k)
+		case lo.Sign() > 0:
+			return NewIntInterval(NewZ(0), NewZ(int64(hi.BitLen()-1)))
+		default:
+			return NewIntInterval(NewZ(0), NewZ(int64(n)))
+		}
+	case "OnesCount":
+		if exact {
+			k := NewZ(int64(onesCount(lo)))
+			return NewIntInterval(k, k)
+		}
+		l := NewZ(0)
+		if lo.Sign() > 0 {
+			l = NewZ(1)
+		}
+		return NewIntInterval(l, NewZ(int64(hi.BitLen())))
+	default:
+		return NewIntInterval(NewZ(0), NewZ(int64(n)))
+	}
+}
+
+func onesCount(x *big.Int) int {
+	n := 0
+	for i := 0; i < x.BitLen(); i++ {
+		n += int(x.Bit(i))
+	}
+	return n
+}
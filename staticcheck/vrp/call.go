@@ -0,0 +1,273 @@
+package vrp
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+	"sync"
+
+	"honnef.co/go/tools/ssa"
+)
+
+// maxSummaryDepth limits how deeply nested calls are summarized.
+// Calls beyond that depth are assumed to return any value.
+const maxSummaryDepth = 4
+
+// Summaries computes and caches summaries of functions, mapping the
+// ranges of their parameters to the ranges of their integer results.
+// Setting the Summaries field of a Graph enables interprocedural
+// range propagation.
+//
+// Summaries are computed on demand, bottom-up. Recursive functions
+// are summarized independently of their arguments, iterating until
+// their results stabilize and widening bounds that keep growing to
+// infinity.
+//
+// A Summaries is safe for concurrent use.
+type Summaries struct {
+	mu    sync.Mutex
+	cache map[summaryKey][]IntInterval
+}
+
+func NewSummaries() *Summaries {
+	return &Summaries{cache: map[summaryKey][]IntInterval{}}
+}
+
+type summaryKey struct {
+	fn   *ssa.Function
+	args string
+}
+
+// A summaryFrame is a function that is currently being summarized.
+type summaryFrame struct {
+	fn *ssa.Function
+	// approx is the current approximation of the results of a
+	// recursive function.
+	approx []IntInterval
+	// recursive is set when the function calls itself, directly or
+	// indirectly.
+	recursive bool
+	// tainted is set when the summary depends on the approximation
+	// of a function further up the stack and mustn't be cached.
+	tainted bool
+}
+
+// Results returns the ranges of fn's results when called with
+// arguments in the ranges args. It returns nil if fn cannot be
+// summarized.
+func (s *Summaries) Results(fn *ssa.Function, args []Range, sizes types.Sizes, wrap bool) []IntInterval {
+	g := &Graph{Summaries: s, Sizes: sizes, Wrap: wrap}
+	return s.results(g, fn, args)
+}
+
+func (s *Summaries) results(caller *Graph, fn *ssa.Function, args []Range) []IntInterval {
+	if len(fn.Blocks) == 0 {
+		return nil
+	}
+	stack := caller.stack
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].fn != fn {
+			continue
+		}
+		stack[i].recursive = true
+		for _, f := range stack[i+1:] {
+			f.tainted = true
+		}
+		return stack[i].approx
+	}
+	if len(stack) >= maxSummaryDepth {
+		return nil
+	}
+
+	key := summaryKey{fn, argsKey(args)}
+	s.mu.Lock()
+	res, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return res
+	}
+
+	frame := &summaryFrame{fn: fn}
+	stack = append(stack[:len(stack):len(stack)], frame)
+	res = s.summarize(caller, stack, fn, args)
+	if frame.recursive {
+		// The arguments of recursive calls differ from ours, so
+		// summarize the function for all possible arguments.
+		key = summaryKey{fn, argsKey(nil)}
+		approx := make([]IntInterval, len(res))
+		for i := range approx {
+			approx[i] = EmptyIntInterval
+		}
+		for {
+			frame.approx = approx
+			res = s.summarize(caller, stack, fn, nil)
+			next := widenResults(approx, res)
+			if equalResults(next, approx) {
+				break
+			}
+			approx = next
+		}
+		res = approx
+	}
+	if !frame.tainted {
+		s.mu.Lock()
+		s.cache[key] = res
+		if frame.recursive {
+			s.cache[summaryKey{fn, argsKey(args)}] = res
+		}
+		s.mu.Unlock()
+	} else if len(stack) > 1 {
+		stack[len(stack)-2].tainted = true
+	}
+	return res
+}
+
+// summarize solves fn's graph with its parameters limited to args and
+// returns the union of the ranges of each result over all return
+// statements.
+func (s *Summaries) summarize(caller *Graph, stack []*summaryFrame, fn *ssa.Function, args []Range) []IntInterval {
+	g := BuildGraph(fn)
+	g.Sizes = caller.Sizes
+	g.Wrap = caller.Wrap
+	g.Thresholds = caller.Thresholds
+	g.MaxIterations = caller.MaxIterations
+	g.Summaries = s
+	g.stack = stack
+	for i, p := range fn.Params {
+		if i >= len(args) {
+			break
+		}
+		if r, ok := args[i].(IntInterval); ok && r.IsKnown() {
+			g.SetRange(p, r)
+		}
+	}
+	ranges := g.Solve()
+
+	res := make([]IntInterval, fn.Signature.Results().Len())
+	for i := range res {
+		res[i] = EmptyIntInterval
+	}
+	for _, block := range fn.Blocks {
+		if len(block.Instrs) == 0 {
+			continue
+		}
+		ret, ok := block.Instrs[len(block.Instrs)-1].(*ssa.Return)
+		if !ok {
+			continue
+		}
+		for i, v := range ret.Results {
+			r, ok := ranges.Get(v).(IntInterval)
+			if !ok || !r.IsKnown() {
+				r = InfinityFor(v)
+			}
+			res[i] = res[i].Union(r).(IntInterval)
+		}
+	}
+	return res
+}
+
+func argsKey(args []Range) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		if r, ok := arg.(IntInterval); ok && r.IsKnown() {
+			parts[i] = r.String()
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// widenResults widens the bounds of old that grew in new to infinity.
+func widenResults(old, new []IntInterval) []IntInterval {
+	out := make([]IntInterval, len(old))
+	for i := range old {
+		o, n := old[i], new[i]
+		switch {
+		case !n.IsKnown():
+			out[i] = NewIntInterval(NInfinity, PInfinity)
+		case n.Empty():
+			out[i] = o
+		case o.Empty():
+			out[i] = n
+		default:
+			l, u := o.Lower, o.Upper
+			if n.Lower.Cmp(l) == -1 {
+				l = NInfinity
+			}
+			if n.Upper.Cmp(u) == 1 {
+				u = PInfinity
+			}
+			out[i] = NewIntInterval(l, u)
+		}
+	}
+	return out
+}
+
+func equalResults(a, b []IntInterval) bool {
+	for i := range a {
+		if a[i].Empty() != b[i].Empty() {
+			return false
+		}
+		if a[i].Empty() {
+			continue
+		}
+		if a[i].Lower.Cmp(b[i].Lower) != 0 || a[i].Upper.Cmp(b[i].Upper) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// CallConstraint models an integer result of a call to a function
+// with a known body. Index is the result's index in the callee's
+// results, or -1 if it has a single result.
+type CallConstraint struct {
+	aConstraint
+	Call  *ssa.Call
+	Index int
+}
+
+func NewCallConstraint(call *ssa.Call, index int, y ssa.Value) Constraint {
+	return &CallConstraint{NewConstraint(y), call, index}
+}
+
+func (c *CallConstraint) Operands() []ssa.Value {
+	var ops []ssa.Value
+	for _, arg := range c.Call.Common().Args {
+		if isInteger(arg.Type()) {
+			ops = append(ops, arg)
+		}
+	}
+	return ops
+}
+
+func (c *CallConstraint) String() string {
+	names := make([]string, len(c.Call.Common().Args))
+	for i, arg := range c.Call.Common().Args {
+		names[i] = arg.Name()
+	}
+	name := c.Call.Common().Value.Name()
+	if c.Index >= 0 {
+		return fmt.Sprintf("%s = %s(%s)#%d", c.Y().Name(), name, strings.Join(names, ", "), c.Index)
+	}
+	return fmt.Sprintf("%s = %s(%s)", c.Y().Name(), name, strings.Join(names, ", "))
+}
+
+func (c *CallConstraint) Eval(g *Graph) Range {
+	fn := c.Call.Common().StaticCallee()
+	if g.Summaries == nil || fn == nil {
+		return InfinityFor(c.Y())
+	}
+	args := make([]Range, len(c.Call.Common().Args))
+	for i, arg := range c.Call.Common().Args {
+		args[i] = g.Range(arg)
+	}
+	res := g.Summaries.results(g, fn, args)
+	idx := c.Index
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(res) {
+		return InfinityFor(c.Y())
+	}
+	return res[idx]
+}
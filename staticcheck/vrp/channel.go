@@ -2,6 +2,7 @@ package vrp
 
 import (
 	"fmt"
+	"go/token"
 
 	"honnef.co/go/tools/ssa"
 )
@@ -43,21 +44,62 @@ type ChannelChangeTypeConstraint struct {
 	X ssa.Value
 }
 
+// ChannelCapConstraint models cap(ch), which is the buffer size of
+// the channel.
+type ChannelCapConstraint struct {
+	aConstraint
+	X ssa.Value
+}
+
+// ChannelLengthConstraint models len(ch), the number of elements
+// queued in the buffer, which never exceeds its size.
+type ChannelLengthConstraint struct {
+	aConstraint
+	X ssa.Value
+}
+
+type ChannelIntersectionConstraint struct {
+	aConstraint
+	X ssa.Value
+	I IntInterval
+}
+
 func NewMakeChannelConstraint(buffer, y ssa.Value) Constraint {
 	return &MakeChannelConstraint{NewConstraint(y), buffer}
 }
 func NewChannelChangeTypeConstraint(x, y ssa.Value) Constraint {
 	return &ChannelChangeTypeConstraint{NewConstraint(y), x}
 }
+func NewChannelCapConstraint(x, y ssa.Value) Constraint {
+	return &ChannelCapConstraint{NewConstraint(y), x}
+}
+func NewChannelLengthConstraint(x, y ssa.Value) Constraint {
+	return &ChannelLengthConstraint{NewConstraint(y), x}
+}
+func NewChannelIntersectionConstraint(x ssa.Value, i IntInterval, y ssa.Value) Constraint {
+	return &ChannelIntersectionConstraint{NewConstraint(y), x, i}
+}
 
-func (c *MakeChannelConstraint) Operands() []ssa.Value       { return []ssa.Value{c.Buffer} }
-func (c *ChannelChangeTypeConstraint) Operands() []ssa.Value { return []ssa.Value{c.X} }
+func (c *MakeChannelConstraint) Operands() []ssa.Value         { return []ssa.Value{c.Buffer} }
+func (c *ChannelChangeTypeConstraint) Operands() []ssa.Value   { return []ssa.Value{c.X} }
+func (c *ChannelCapConstraint) Operands() []ssa.Value          { return []ssa.Value{c.X} }
+func (c *ChannelLengthConstraint) Operands() []ssa.Value       { return []ssa.Value{c.X} }
+func (c *ChannelIntersectionConstraint) Operands() []ssa.Value { return []ssa.Value{c.X} }
 
 func (c *MakeChannelConstraint) String() string {
-	return fmt.Sprintf("%s = make(chan, %s)", c.Y().Name, c.Buffer.Name())
+	return fmt.Sprintf("%s = make(chan, %s)", c.Y().Name(), c.Buffer.Name())
 }
 func (c *ChannelChangeTypeConstraint) String() string {
-	return fmt.Sprintf("%s = changetype(%s)", c.Y().Name, c.X.Name())
+	return fmt.Sprintf("%s = changetype(%s)", c.Y().Name(), c.X.Name())
+}
+func (c *ChannelCapConstraint) String() string {
+	return fmt.Sprintf("%s = cap(%s)", c.Y().Name(), c.X.Name())
+}
+func (c *ChannelLengthConstraint) String() string {
+	return fmt.Sprintf("%s = len(%s)", c.Y().Name(), c.X.Name())
+}
+func (c *ChannelIntersectionConstraint) String() string {
+	return fmt.Sprintf("%s = %s.%t ⊓ %s", c.Y().Name(), c.X.Name(), c.Y().(*ssa.Sigma).Branch, c.I)
 }
 
 func (c *MakeChannelConstraint) Eval(g *Graph) Range {
@@ -71,3 +113,94 @@ func (c *MakeChannelConstraint) Eval(g *Graph) Range {
 	return ChannelInterval{i}
 }
 func (c *ChannelChangeTypeConstraint) Eval(g *Graph) Range { return g.Range(c.X) }
+func (c *ChannelCapConstraint) Eval(g *Graph) Range {
+	i := g.Range(c.X).(ChannelInterval).Size
+	if !i.IsKnown() {
+		return NewIntInterval(NewZ(0), PInfinity)
+	}
+	return i
+}
+func (c *ChannelLengthConstraint) Eval(g *Graph) Range {
+	i := g.Range(c.X).(ChannelInterval).Size
+	if !i.IsKnown() || i.Empty() {
+		return NewIntInterval(NewZ(0), PInfinity)
+	}
+	return NewIntInterval(NewZ(0), i.Upper)
+}
+func (c *ChannelIntersectionConstraint) Eval(g *Graph) Range {
+	xi := g.Range(c.X).(ChannelInterval)
+	if !xi.IsKnown() {
+		return ChannelInterval{}
+	}
+	return ChannelInterval{
+		Size: xi.Size.Intersection(c.I),
+	}
+}
+
+// channelBuiltin returns the name of the builtin, len or cap, that v
+// applies to ch, or the empty string if it applies neither.
+func channelBuiltin(v, ch ssa.Value) string {
+	call, ok := v.(*ssa.Call)
+	if !ok {
+		return ""
+	}
+	builtin, ok := call.Common().Value.(*ssa.Builtin)
+	if !ok || len(call.Common().Args) != 1 || call.Common().Args[0] != ch {
+		return ""
+	}
+	switch builtin.Name() {
+	case "len", "cap":
+		return builtin.Name()
+	}
+	return ""
+}
+
+// sigmaChannel returns the constraint of a σ-node on a comparison
+// involving len(ch) or cap(ch). Comparing cap(ch) to a constant
+// limits the buffer size directly, len(ch) >= k implies a buffer of
+// at least k elements, and len(ch) < cap(ch) implies that the
+// channel is buffered.
+func sigmaChannel(g *Graph, ins *ssa.Sigma, cond *ssa.BinOp, ops []*ssa.Value) Constraint {
+	op := cond.Op
+	if !ins.Branch {
+		op = invertToken(op)
+	}
+	a, b := *ops[0], *ops[1]
+	fa, fb := channelBuiltin(a, ins.X), channelBuiltin(b, ins.X)
+	if fa == "" {
+		a, b = b, a
+		fa, fb = fb, fa
+		op = flipToken(op)
+	}
+	if fa == "" {
+		return nil
+	}
+
+	if fa == "len" && fb == "cap" {
+		if op == token.LSS || op == token.NEQ {
+			return NewChannelIntersectionConstraint(ins.X, NewIntInterval(NewZ(1), PInfinity), ins)
+		}
+		return nil
+	}
+	k, ok := b.(*ssa.Const)
+	if !ok {
+		return nil
+	}
+	v := ConstantToZ(k.Value)
+	var i IntInterval
+	switch {
+	case fa == "cap" && op == token.EQL:
+		i = NewIntInterval(v, v)
+	case op == token.GTR:
+		i = NewIntInterval(v.Add(NewZ(1)), PInfinity)
+	case op == token.GEQ:
+		i = NewIntInterval(v, PInfinity)
+	case fa == "cap" && op == token.LSS:
+		i = NewIntInterval(NInfinity, v.Sub(NewZ(1)))
+	case fa == "cap" && op == token.LEQ:
+		i = NewIntInterval(NInfinity, v)
+	default:
+		return nil
+	}
+	return NewChannelIntersectionConstraint(ins.X, i, ins)
+}
@@ -0,0 +1,160 @@
+package vrp
+
+import (
+	"fmt"
+	"go/token"
+
+	"honnef.co/go/tools/ssa"
+)
+
+// InductionConstraint models a basic induction variable, a φ-node of
+// the form i = φ(init, i+step) in a loop header that is guarded by a
+// comparison of i against a bound, such as
+//
+//	for i := 0; i < n; i++ {}
+//
+// Because the step is only taken while the guard holds, the variable
+// is limited by the bound directly, without having to widen the loop
+// to infinity first, and it is congruent to init modulo the step.
+// The φ-node also holds the value that terminates the loop, so the
+// example yields i ∈ [0, n]. In the loop body, the σ-node of the
+// guard excludes that value, honouring the guard's strictness:
+// i ∈ [0, n-1] for i < n and i ∈ [0, n] for i <= n.
+type InductionConstraint struct {
+	aConstraint
+	Init  ssa.Value
+	Bound ssa.Value
+	Step  Z
+	// Op is the comparison of the induction variable (on the left)
+	// against the bound (on the right) that keeps the loop running.
+	Op token.Token
+}
+
+func NewInductionConstraint(init, bound ssa.Value, step Z, op token.Token, y ssa.Value) Constraint {
+	return &InductionConstraint{NewConstraint(y), init, bound, step, op}
+}
+
+func (c *InductionConstraint) Operands() []ssa.Value {
+	return []ssa.Value{c.Init, c.Bound}
+}
+
+func (c *InductionConstraint) String() string {
+	return fmt.Sprintf("%s = induction(%s, %s, step %s, while %s %s %s)",
+		c.Y().Name(), c.Init.Name(), c.Bound.Name(), c.Step, c.Y().Name(), c.Op, c.Bound.Name())
+}
+
+func (c *InductionConstraint) Eval(g *Graph) Range {
+	init, bound := g.Range(c.Init).(IntInterval), g.Range(c.Bound).(IntInterval)
+	if !init.IsKnown() || !bound.IsKnown() {
+		return IntInterval{}
+	}
+	if init.Empty() || bound.Empty() {
+		return init
+	}
+	// The last value is the first one to fail the guard, which is
+	// at most one step past the bound.
+	var i IntInterval
+	switch c.Op {
+	case token.LSS:
+		i = NewIntInterval(init.Lower, MaxZ(init.Upper, bound.Upper.Sub(NewZ(1)).Add(c.Step)))
+	case token.LEQ:
+		i = NewIntInterval(init.Lower, MaxZ(init.Upper, bound.Upper.Add(c.Step)))
+	case token.GTR:
+		i = NewIntInterval(MinZ(init.Lower, bound.Lower.Add(NewZ(1)).Add(c.Step)), init.Upper)
+	case token.GEQ:
+		i = NewIntInterval(MinZ(init.Lower, bound.Lower.Add(c.Step)), init.Upper)
+	}
+	// Every value is init plus a multiple of the step.
+	m, r := init.congruence()
+	i = i.withCongruence(gcd(m, c.Step.Abs().bigInt()), r)
+	if g.Wrap {
+		// If stepping past the bound overflows, the variable wraps
+		// around and may take on any value.
+		if lower, upper, ok := TypeBounds(c.Y().Type(), g.Sizes); ok &&
+			(i.Lower.Cmp(lower) == -1 || i.Upper.Cmp(upper) == 1) {
+			return InfinityFor(c.Y())
+		}
+	}
+	return i
+}
+
+// inductionConstraint returns an InductionConstraint for phi if it is
+// a basic induction variable, or nil otherwise.
+func inductionConstraint(phi *ssa.Phi) Constraint {
+	if len(phi.Edges) != 2 || !isInteger(phi.Type()) {
+		return nil
+	}
+	header := phi.Block()
+	if len(header.Instrs) == 0 {
+		return nil
+	}
+	ifInstr, ok := header.Instrs[len(header.Instrs)-1].(*ssa.If)
+	if !ok {
+		return nil
+	}
+	cond, ok := ifInstr.Cond.(*ssa.BinOp)
+	if !ok {
+		return nil
+	}
+	op, bound := cond.Op, cond.Y
+	if cond.Y == ssa.Value(phi) {
+		op, bound = flipToken(cond.Op), cond.X
+	} else if cond.X != ssa.Value(phi) {
+		return nil
+	}
+
+	for i, edge := range phi.Edges {
+		next, ok := edge.(*ssa.BinOp)
+		if !ok {
+			continue
+		}
+		step, ok := inductionStep(phi, next)
+		if !ok {
+			continue
+		}
+		switch {
+		case step.Sign() > 0 && (op == token.LSS || op == token.LEQ):
+		case step.Sign() < 0 && (op == token.GTR || op == token.GEQ):
+		default:
+			return nil
+		}
+		// The step must only be reachable through the branch that is
+		// taken while the guard holds.
+		body := header.Succs[0]
+		if len(body.Preds) != 1 || body == header.Succs[1] || !body.Dominates(next.Block()) {
+			return nil
+		}
+		return NewInductionConstraint(phi.Edges[1-i], bound, step, op, phi)
+	}
+	return nil
+}
+
+// inductionStep returns the constant that next adds to phi.
+func inductionStep(phi *ssa.Phi, next *ssa.BinOp) (Z, bool) {
+	// In the loop body, phi is only available through the σ-node of
+	// the guard.
+	isPhi := func(v ssa.Value) bool { return unsigma(v) == ssa.Value(phi) }
+	var k ssa.Value
+	switch {
+	case next.Op == token.ADD && isPhi(next.X):
+		k = next.Y
+	case next.Op == token.ADD && isPhi(next.Y):
+		k = next.X
+	case next.Op == token.SUB && isPhi(next.X):
+		k = next.Y
+	default:
+		return Z{}, false
+	}
+	c, ok := k.(*ssa.Const)
+	if !ok || c.Value == nil {
+		return Z{}, false
+	}
+	step := ConstantToZ(c.Value)
+	if next.Op == token.SUB {
+		step = step.Negate()
+	}
+	if step.Sign() == 0 {
+		return Z{}, false
+	}
+	return step, true
+}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"go/token"
 	"go/types"
+	"math"
 	"math/big"
+	"strconv"
+	"strings"
 
 	"honnef.co/go/tools/ssa"
 )
@@ -23,17 +26,35 @@ func (zs Zs) Swap(i int, j int) {
 	zs[i], zs[j] = zs[j], zs[i]
 }
 
+// Z is an integer of arbitrary size, or positive or negative
+// infinity. Integers that fit in an int64 are stored inline, only
+// larger ones are backed by a big.Int, so that arithmetic on typical
+// values doesn't allocate.
 type Z struct {
 	infinity int8
-	integer  *big.Int
+	// small is the value if big is nil
+	small int64
+	// big is only used for values that don't fit in an int64
+	big *big.Int
 }
 
 func NewZ(n int64) Z {
-	return NewBigZ(big.NewInt(n))
+	return Z{small: n}
 }
 
 func NewBigZ(n *big.Int) Z {
-	return Z{integer: n}
+	if n.IsInt64() {
+		return Z{small: n.Int64()}
+	}
+	return Z{big: n}
+}
+
+// bigInt returns z1 as a big.Int. The result must not be modified.
+func (z1 Z) bigInt() *big.Int {
+	if z1.big != nil {
+		return z1.big
+	}
+	return big.NewInt(z1.small)
 }
 
 func (z1 Z) Infinite() bool {
@@ -55,8 +76,14 @@ func (z1 Z) Add(z2 Z) Z {
 	}
 
 	if !z1.Infinite() && !z2.Infinite() {
+		if z1.big == nil && z2.big == nil {
+			// z2 is not negative, so the sum only overflows upwards
+			if n := z1.small + z2.small; n >= z1.small {
+				return NewZ(n)
+			}
+		}
 		n := &big.Int{}
-		n.Add(z1.integer, z2.integer)
+		n.Add(z1.bigInt(), z2.bigInt())
 		return NewBigZ(n)
 	}
 
@@ -68,8 +95,15 @@ func (z1 Z) Sub(z2 Z) Z {
 		return z1.Add(z2.Negate())
 	}
 	if !z1.Infinite() && !z2.Infinite() {
+		if z1.big == nil && z2.big == nil {
+			// z2 is not negative, so the difference only overflows
+			// downwards
+			if n := z1.small - z2.small; n <= z1.small {
+				return NewZ(n)
+			}
+		}
 		n := &big.Int{}
-		n.Sub(z1.integer, z2.integer)
+		n.Sub(z1.bigInt(), z2.bigInt())
 		return NewBigZ(n)
 	}
 
@@ -86,17 +120,46 @@ func (z1 Z) Sub(z2 Z) Z {
 }
 
 func (z1 Z) Mul(z2 Z) Z {
-	if (z1.integer != nil && z1.integer.Sign() == 0) ||
-		(z2.integer != nil && z2.integer.Sign() == 0) {
-		return NewBigZ(&big.Int{})
+	if (!z1.Infinite() && z1.Sign() == 0) ||
+		(!z2.Infinite() && z2.Sign() == 0) {
+		return NewZ(0)
 	}
 
 	if z1.infinity != 0 || z2.infinity != 0 {
 		return Z{infinity: int8(z1.Sign() * z2.Sign())}
 	}
 
+	if z1.big == nil && z2.big == nil {
+		a, b := z1.small, z2.small
+		n := a * b
+		if n/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64) {
+			return NewZ(n)
+		}
+	}
 	n := &big.Int{}
-	n.Mul(z1.integer, z2.integer)
+	n.Mul(z1.bigInt(), z2.bigInt())
+	return NewBigZ(n)
+}
+
+// Quo returns the quotient z1/z2, truncated towards zero like Go's
+// integer division. A finite value divided by infinity is zero.
+// Dividing infinity by infinity yields zero, too; interval division
+// never relies on that corner for its bounds.
+func (z1 Z) Quo(z2 Z) Z {
+	if z2.Sign() == 0 {
+		panic(fmt.Sprintf("%s / %s is not defined", z1, z2))
+	}
+	if z2.Infinite() {
+		return NewZ(0)
+	}
+	if z1.Infinite() {
+		return Z{infinity: int8(z1.Sign() * z2.Sign())}
+	}
+	if z1.big == nil && z2.big == nil && !(z1.small == math.MinInt64 && z2.small == -1) {
+		return NewZ(z1.small / z2.small)
+	}
+	n := &big.Int{}
+	n.Quo(z1.bigInt(), z2.bigInt())
 	return NewBigZ(n)
 }
 
@@ -107,16 +170,74 @@ func (z1 Z) Negate() Z {
 	if z1.infinity == -1 {
 		return PInfinity
 	}
+	if z1.big == nil && z1.small != math.MinInt64 {
+		return NewZ(-z1.small)
+	}
+	n := &big.Int{}
+	n.Neg(z1.bigInt())
+	return NewBigZ(n)
+}
+
+// Lsh returns z1 << z2, i.e. z1 * 2**z2.
+func (z1 Z) Lsh(z2 Z) Z {
+	if z1.Infinite() {
+		return z1
+	}
+	if z2.Infinite() || z2.big != nil || z2.small < 0 || z2.small > 64 {
+		// Shifting by more than the width of the largest integer
+		// type; treat it like an infinitely large shift.
+		return z1.Mul(PInfinity)
+	}
+	n := &big.Int{}
+	n.Lsh(z1.bigInt(), uint(z2.small))
+	return NewBigZ(n)
+}
+
+// Rsh returns z1 >> z2, rounding towards negative infinity like Go's
+// arithmetic right shift.
+func (z1 Z) Rsh(z2 Z) Z {
+	if z1.Infinite() {
+		return z1
+	}
+	if z2.Infinite() || z2.big != nil || z2.small < 0 {
+		if z1.Sign() == -1 {
+			return NewZ(-1)
+		}
+		return NewZ(0)
+	}
+	if z1.big == nil {
+		if z2.small >= 64 {
+			return NewZ(z1.small >> 63)
+		}
+		return NewZ(z1.small >> uint(z2.small))
+	}
 	n := &big.Int{}
-	n.Neg(z1.integer)
+	n.Rsh(z1.big, uint(z2.small))
 	return NewBigZ(n)
 }
 
+func (z1 Z) Abs() Z {
+	if z1.Sign() == -1 {
+		return z1.Negate()
+	}
+	return z1
+}
+
 func (z1 Z) Sign() int {
 	if z1.infinity != 0 {
 		return int(z1.infinity)
 	}
-	return z1.integer.Sign()
+	if z1.big != nil {
+		return z1.big.Sign()
+	}
+	switch {
+	case z1.small < 0:
+		return -1
+	case z1.small > 0:
+		return 1
+	default:
+		return 0
+	}
 }
 
 func (z1 Z) String() string {
@@ -126,7 +247,10 @@ func (z1 Z) String() string {
 	if z1 == PInfinity {
 		return "∞"
 	}
-	return fmt.Sprintf("%d", z1.integer)
+	if z1.big == nil {
+		return strconv.FormatInt(z1.small, 10)
+	}
+	return z1.big.String()
 }
 
 func (z1 Z) Cmp(z2 Z) int {
@@ -145,7 +269,17 @@ func (z1 Z) Cmp(z2 Z) int {
 	if z2 == PInfinity {
 		return -1
 	}
-	return z1.integer.Cmp(z2.integer)
+	if z1.big == nil && z2.big == nil {
+		switch {
+		case z1.small < z2.small:
+			return -1
+		case z1.small > z2.small:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return z1.bigInt().Cmp(z2.bigInt())
 }
 
 func MaxZ(zs ...Z) Z {
@@ -182,7 +316,7 @@ func MinZ(zs ...Z) Z {
 
 var NInfinity = Z{infinity: -1}
 var PInfinity = Z{infinity: 1}
-var EmptyIntInterval = IntInterval{true, PInfinity, NInfinity}
+var EmptyIntInterval = IntInterval{known: true, Lower: PInfinity, Upper: NInfinity}
 
 func InfinityFor(v ssa.Value) IntInterval {
 	if b, ok := v.Type().Underlying().(*types.Basic); ok {
@@ -193,10 +327,119 @@ func InfinityFor(v ssa.Value) IntInterval {
 	return NewIntInterval(NInfinity, PInfinity)
 }
 
+// Wrapping describes whether an integer operation overflows the range
+// of its type and wraps around.
+type Wrapping int
+
+const (
+	NeverWraps Wrapping = iota
+	MayWrap
+	DefinitelyWraps
+)
+
+func (w Wrapping) String() string {
+	switch w {
+	case NeverWraps:
+		return "never wraps"
+	case MayWrap:
+		return "may wrap"
+	case DefinitelyWraps:
+		return "definitely wraps"
+	default:
+		return fmt.Sprintf("Wrapping(%d)", int(w))
+	}
+}
+
+// TypeBounds returns the smallest and largest values of the integer
+// type typ. ok is false for untyped integers, which have no bounds.
+func TypeBounds(typ types.Type, s types.Sizes) (lower, upper Z, ok bool) {
+	basic, isBasic := typ.Underlying().(*types.Basic)
+	if !isBasic || basic.Kind() == types.UntypedInt || (basic.Info()&types.IsInteger) == 0 {
+		return Z{}, Z{}, false
+	}
+	bits := uint(s.Sizeof(typ) * 8)
+	n := big.NewInt(1)
+	if (basic.Info() & types.IsUnsigned) != 0 {
+		n.Lsh(n, bits)
+		return NewZ(0), NewBigZ(n.Sub(n, big.NewInt(1))), true
+	}
+	n.Lsh(n, bits-1)
+	l := &big.Int{}
+	l.Neg(n)
+	return NewBigZ(l), NewBigZ(n.Sub(n, big.NewInt(1))), true
+}
+
+// wrapInterval maps i, computed with unbounded integers, onto the
+// values representable by typ, taking wrap-around into account.
+func wrapInterval(i IntInterval, typ types.Type, s types.Sizes) (IntInterval, Wrapping) {
+	lower, upper, ok := TypeBounds(typ, s)
+	if !ok || !i.IsKnown() || i.Empty() {
+		return i, NeverWraps
+	}
+	if i.Lower.Cmp(lower) >= 0 && i.Upper.Cmp(upper) <= 0 {
+		return i, NeverWraps
+	}
+	mod := &big.Int{}
+	mod.Sub(upper.bigInt(), lower.bigInt())
+	mod.Add(mod, big.NewInt(1))
+	full := NewIntInterval(NInfinity, PInfinity)
+	if lower.Sign() == 0 {
+		full = NewIntInterval(NewZ(0), PInfinity)
+	}
+	if m, r := i.congruence(); m.Sign() != 0 && (&big.Int{}).Mod(mod, m).Sign() == 0 {
+		// Wrapping around subtracts multiples of mod, which preserves
+		// congruences whose modulus divides it.
+		full = full.withCongruence(m, r)
+	}
+	if i.Upper.Cmp(lower) == -1 || i.Lower.Cmp(upper) == 1 {
+		if i.Lower.Infinite() || i.Upper.Infinite() {
+			return full, DefinitelyWraps
+		}
+		width := &big.Int{}
+		width.Sub(i.Upper.bigInt(), i.Lower.bigInt())
+		if width.Cmp(mod) >= 0 {
+			return full, DefinitelyWraps
+		}
+		wrap := func(z Z) Z {
+			n := &big.Int{}
+			n.Sub(z.bigInt(), lower.bigInt())
+			n.Mod(n, mod)
+			n.Add(n, lower.bigInt())
+			return NewBigZ(n)
+		}
+		wl, wu := wrap(i.Lower), wrap(i.Upper)
+		if wl.Cmp(wu) == 1 {
+			return full, DefinitelyWraps
+		}
+		m, r := i.congruence()
+		return NewIntInterval(wl, wu).withCongruence(m, r), DefinitelyWraps
+	}
+	return full, MayWrap
+}
+
+// A Congruence describes the integers x with x ≡ Residue (mod
+// Modulus), such as the values of a loop variable that is
+// incremented in steps of a constant size. A nil Modulus means that
+// no congruence is known.
+type Congruence struct {
+	Modulus *big.Int
+	Residue *big.Int
+}
+
+func (c Congruence) String() string {
+	if c.Modulus == nil {
+		return ""
+	}
+	return fmt.Sprintf("≡ %s mod %s", c.Residue, c.Modulus)
+}
+
 type IntInterval struct {
 	known bool
 	Lower Z
 	Upper Z
+	// Congruence optionally limits the interval to the integers that
+	// satisfy it.
+	Congruence Congruence
 }
 
 func NewIntInterval(l, u Z) IntInterval {
@@ -206,6 +449,94 @@ func NewIntInterval(l, u Z) IntInterval {
 	return IntInterval{known: true, Lower: l, Upper: u}
 }
 
+func gcd(a, b *big.Int) *big.Int {
+	x, y := (&big.Int{}).Abs(a), (&big.Int{}).Abs(b)
+	for y.Sign() != 0 {
+		x, y = y, x.Mod(x, y)
+	}
+	return x
+}
+
+// congruence returns the congruence of i. A modulus of 0 means that i
+// contains the single integer r, and a modulus of 1 that nothing is
+// known.
+func (i IntInterval) congruence() (m, r *big.Int) {
+	if i.Congruence.Modulus != nil {
+		return i.Congruence.Modulus, i.Congruence.Residue
+	}
+	if i.isConstant() {
+		return big.NewInt(0), i.Lower.bigInt()
+	}
+	return big.NewInt(1), big.NewInt(0)
+}
+
+func (i IntInterval) isConstant() bool {
+	return !i.Lower.Infinite() && i.Lower.Cmp(i.Upper) == 0
+}
+
+// hasCongruence reports whether anything is known about the
+// congruence of i, allowing arithmetic to skip computing it.
+func (i IntInterval) hasCongruence() bool {
+	return i.Congruence.Modulus != nil || i.isConstant()
+}
+
+// withCongruence limits i to the integers x with x ≡ r (mod m),
+// shrinking its bounds to the nearest such integers.
+func (i IntInterval) withCongruence(m, r *big.Int) IntInterval {
+	if !i.IsKnown() || i.Empty() {
+		return i
+	}
+	if m.Sign() == 0 {
+		v := NewBigZ(r)
+		if v.Cmp(i.Lower) == -1 || v.Cmp(i.Upper) == 1 {
+			return EmptyIntInterval
+		}
+		return NewIntInterval(v, v)
+	}
+	if m.Cmp(big.NewInt(1)) == 0 {
+		i.Congruence = Congruence{}
+		return i
+	}
+	r = (&big.Int{}).Mod(r, m)
+	l, u := i.Lower, i.Upper
+	if !l.Infinite() {
+		// Round up to the next value ≡ r
+		d := (&big.Int{}).Sub(r, l.bigInt())
+		d.Mod(d, m)
+		l = NewBigZ(d.Add(d, l.bigInt()))
+	}
+	if !u.Infinite() {
+		// Round down to the previous value ≡ r
+		d := (&big.Int{}).Sub(u.bigInt(), r)
+		d.Mod(d, m)
+		u = NewBigZ(d.Sub(u.bigInt(), d))
+	}
+	i = NewIntInterval(l, u)
+	if i.Empty() {
+		return i
+	}
+	if i.Lower.Cmp(i.Upper) != 0 {
+		i.Congruence = Congruence{Modulus: m, Residue: r}
+	}
+	return i
+}
+
+// unionCongruence returns the strongest congruence that holds for the
+// integers in both i1 and i2.
+func (i1 IntInterval) unionCongruence(i2 IntInterval) (m, r *big.Int) {
+	m1, r1 := i1.congruence()
+	m2, r2 := i2.congruence()
+	d := (&big.Int{}).Sub(r1, r2)
+	return gcd(gcd(m1, m2), d), r1
+}
+
+func sameCongruence(c1, c2 Congruence) bool {
+	if c1.Modulus == nil || c2.Modulus == nil {
+		return c1.Modulus == nil && c2.Modulus == nil
+	}
+	return c1.Modulus.Cmp(c2.Modulus) == 0 && c1.Residue.Cmp(c2.Residue) == 0
+}
+
 func (i IntInterval) IsKnown() bool {
 	return i.known
 }
@@ -232,7 +563,10 @@ func (i1 IntInterval) Intersection(i2 IntInterval) IntInterval {
 	if i3.Lower.Cmp(i3.Upper) == 1 {
 		return EmptyIntInterval
 	}
-	return i3
+	if i1.Congruence.Modulus != nil {
+		return i3.withCongruence(i1.congruence())
+	}
+	return i3.withCongruence(i2.congruence())
 }
 
 func (i1 IntInterval) Union(other Range) Range {
@@ -246,7 +580,11 @@ func (i1 IntInterval) Union(other Range) Range {
 	if i2.Empty() || !i2.IsKnown() {
 		return i1
 	}
-	return NewIntInterval(MinZ(i1.Lower, i2.Lower), MaxZ(i1.Upper, i2.Upper))
+	i3 := NewIntInterval(MinZ(i1.Lower, i2.Lower), MaxZ(i1.Upper, i2.Upper))
+	if !i1.hasCongruence() || !i2.hasCongruence() {
+		return i3
+	}
+	return i3.withCongruence(i1.unionCongruence(i2))
 }
 
 func (i1 IntInterval) Add(i2 IntInterval) IntInterval {
@@ -254,7 +592,13 @@ func (i1 IntInterval) Add(i2 IntInterval) IntInterval {
 		return EmptyIntInterval
 	}
 	l1, u1, l2, u2 := i1.Lower, i1.Upper, i2.Lower, i2.Upper
-	return NewIntInterval(l1.Add(l2), u1.Add(u2))
+	i3 := NewIntInterval(l1.Add(l2), u1.Add(u2))
+	if !i1.hasCongruence() || !i2.hasCongruence() {
+		return i3
+	}
+	m1, r1 := i1.congruence()
+	m2, r2 := i2.congruence()
+	return i3.withCongruence(gcd(m1, m2), (&big.Int{}).Add(r1, r2))
 }
 
 func (i1 IntInterval) Sub(i2 IntInterval) IntInterval {
@@ -262,7 +606,13 @@ func (i1 IntInterval) Sub(i2 IntInterval) IntInterval {
 		return EmptyIntInterval
 	}
 	l1, u1, l2, u2 := i1.Lower, i1.Upper, i2.Lower, i2.Upper
-	return NewIntInterval(l1.Sub(u2), u1.Sub(l2))
+	i3 := NewIntInterval(l1.Sub(u2), u1.Sub(l2))
+	if !i1.hasCongruence() || !i2.hasCongruence() {
+		return i3
+	}
+	m1, r1 := i1.congruence()
+	m2, r2 := i2.congruence()
+	return i3.withCongruence(gcd(m1, m2), (&big.Int{}).Sub(r1, r2))
 }
 
 func (i1 IntInterval) Mul(i2 IntInterval) IntInterval {
@@ -271,10 +621,177 @@ func (i1 IntInterval) Mul(i2 IntInterval) IntInterval {
 	}
 	x1, x2 := i1.Lower, i1.Upper
 	y1, y2 := i2.Lower, i2.Upper
-	return NewIntInterval(
-		MinZ(x1.Mul(y1), x1.Mul(y2), x2.Mul(y1), x2.Mul(y2)),
-		MaxZ(x1.Mul(y1), x1.Mul(y2), x2.Mul(y1), x2.Mul(y2)),
-	)
+	p1, p2, p3, p4 := x1.Mul(y1), x1.Mul(y2), x2.Mul(y1), x2.Mul(y2)
+	i3 := NewIntInterval(MinZ(p1, p2, p3, p4), MaxZ(p1, p2, p3, p4))
+	if !i1.hasCongruence() && !i2.hasCongruence() {
+		return i3
+	}
+	m1, r1 := i1.congruence()
+	m2, r2 := i2.congruence()
+	// (m1*a + r1) * (m2*b + r2) = m1*m2*a*b + m1*r2*a + m2*r1*b + r1*r2
+	m := gcd(gcd((&big.Int{}).Mul(m1, m2), (&big.Int{}).Mul(m1, r2)), (&big.Int{}).Mul(m2, r1))
+	return i3.withCongruence(m, (&big.Int{}).Mul(r1, r2))
+}
+
+// Quo returns the interval of i1/i2. Division by zero panics at
+// runtime, so zero is excluded from i2; if i2 is [0, 0], the result
+// is empty.
+func (i1 IntInterval) Quo(i2 IntInterval) IntInterval {
+	if i1.Empty() || i2.Empty() {
+		return EmptyIntInterval
+	}
+	if i2.Lower.Sign() == 0 && i2.Upper.Sign() == 0 {
+		return EmptyIntInterval
+	}
+	if i2.Lower.Sign() < 0 && i2.Upper.Sign() > 0 {
+		// Split the divisor into its negative and positive halves.
+		neg := i1.Quo(NewIntInterval(i2.Lower, NewZ(-1)))
+		pos := i1.Quo(NewIntInterval(NewZ(1), i2.Upper))
+		return neg.Union(pos).(IntInterval)
+	}
+	if i2.Lower.Sign() == 0 {
+		i2 = NewIntInterval(NewZ(1), i2.Upper)
+	}
+	if i2.Upper.Sign() == 0 {
+		i2 = NewIntInterval(i2.Lower, NewZ(-1))
+	}
+	x1, x2 := i1.Lower, i1.Upper
+	y1, y2 := i2.Lower, i2.Upper
+	q1, q2, q3, q4 := x1.Quo(y1), x1.Quo(y2), x2.Quo(y1), x2.Quo(y2)
+	return NewIntInterval(MinZ(q1, q2, q3, q4), MaxZ(q1, q2, q3, q4))
+}
+
+// Rem returns the interval of i1%i2. The result has the sign of the
+// dividend and its magnitude is smaller than that of the divisor.
+func (i1 IntInterval) Rem(i2 IntInterval) IntInterval {
+	if i1.Empty() || i2.Empty() {
+		return EmptyIntInterval
+	}
+	if i2.Lower.Sign() == 0 && i2.Upper.Sign() == 0 {
+		return EmptyIntInterval
+	}
+	// The largest and smallest magnitudes of the divisor.
+	hi := MaxZ(i2.Lower.Abs(), i2.Upper.Abs())
+	lo := NewZ(1)
+	if i2.Lower.Sign() > 0 {
+		lo = i2.Lower
+	} else if i2.Upper.Sign() < 0 {
+		lo = i2.Upper.Abs()
+	}
+	if i1.Lower.Sign() >= 0 && i1.Upper.Cmp(lo) == -1 {
+		// The dividend is always smaller than the divisor.
+		return i1
+	}
+	if i1.Upper.Sign() <= 0 && i1.Lower.Negate().Cmp(lo) == -1 {
+		return i1
+	}
+	m := hi
+	if !m.Infinite() {
+		m = m.Sub(NewZ(1))
+	}
+	lower := MaxZ(i1.Lower, m.Negate())
+	upper := MinZ(i1.Upper, m)
+	if i1.Lower.Sign() >= 0 {
+		lower = NewZ(0)
+	}
+	if i1.Upper.Sign() <= 0 {
+		upper = NewZ(0)
+	}
+	return NewIntInterval(lower, upper)
+}
+
+// shift computes the interval of shifting i1 by i2 using fn. Shifting
+// by a negative amount panics at runtime, so negative amounts are
+// excluded from i2.
+func (i1 IntInterval) shift(i2 IntInterval, fn func(Z, Z) Z) IntInterval {
+	if i1.Empty() || i2.Empty() {
+		return EmptyIntInterval
+	}
+	i2 = i2.Intersection(NewIntInterval(NewZ(0), PInfinity))
+	if i2.Empty() {
+		return EmptyIntInterval
+	}
+	x1, x2 := i1.Lower, i1.Upper
+	k1, k2 := i2.Lower, i2.Upper
+	s1, s2, s3, s4 := fn(x1, k1), fn(x1, k2), fn(x2, k1), fn(x2, k2)
+	return NewIntInterval(MinZ(s1, s2, s3, s4), MaxZ(s1, s2, s3, s4))
+}
+
+func (i1 IntInterval) Shl(i2 IntInterval) IntInterval {
+	i3 := i1.shift(i2, Z.Lsh)
+	if m2, k := i2.congruence(); m2.Sign() == 0 && k.Sign() >= 0 && k.Cmp(big.NewInt(64)) <= 0 {
+		// Shifting by a constant k multiplies by 2**k
+		n := (&big.Int{}).Lsh(big.NewInt(1), uint(k.Uint64()))
+		m1, r1 := i1.congruence()
+		return i3.withCongruence((&big.Int{}).Mul(m1, n), (&big.Int{}).Mul(r1, n))
+	}
+	return i3
+}
+
+func (i1 IntInterval) Shr(i2 IntInterval) IntInterval {
+	return i1.shift(i2, Z.Rsh)
+}
+
+// Negate computes the interval of negating the values in i.
+func (i IntInterval) Negate() IntInterval {
+	if i.Empty() {
+		return EmptyIntInterval
+	}
+	res := NewIntInterval(i.Upper.Negate(), i.Lower.Negate())
+	if c := i.Congruence; c.Modulus != nil {
+		res = res.withCongruence(c.Modulus, (&big.Int{}).Neg(c.Residue))
+	}
+	return res
+}
+
+// And computes the interval of the bitwise AND of values in i1 and
+// i2. The result is only bounded if one of them is non-negative,
+// acting as a mask.
+func (i1 IntInterval) And(i2 IntInterval) IntInterval {
+	if i1.Empty() || i2.Empty() {
+		return EmptyIntInterval
+	}
+	var res IntInterval
+	nonneg1, nonneg2 := i1.Lower.Sign() >= 0, i2.Lower.Sign() >= 0
+	switch {
+	case nonneg1 && nonneg2:
+		res = NewIntInterval(NewZ(0), MinZ(i1.Upper, i2.Upper))
+	case nonneg1:
+		res = NewIntInterval(NewZ(0), i1.Upper)
+	case nonneg2:
+		res = NewIntInterval(NewZ(0), i2.Upper)
+	default:
+		res = NewIntInterval(NInfinity, PInfinity)
+	}
+	// Masking with a constant that has its n lowest bits cleared,
+	// such as ^(align-1), yields a multiple of 2**n.
+	for _, i := range []IntInterval{i1, i2} {
+		if !i.isConstant() || i.Lower.Sign() == 0 {
+			continue
+		}
+		if n := i.Lower.bigInt().TrailingZeroBits(); n > 0 {
+			m := (&big.Int{}).Lsh(big.NewInt(1), n)
+			res = res.withCongruence(m, big.NewInt(0))
+			break
+		}
+	}
+	return res
+}
+
+// AndNot returns the interval of x &^ y. Clearing the bits of a
+// constant is the same as masking with its complement.
+func (i1 IntInterval) AndNot(i2 IntInterval) IntInterval {
+	if i1.Empty() || i2.Empty() {
+		return EmptyIntInterval
+	}
+	if i2.isConstant() {
+		c := i2.Lower.Negate().Sub(NewZ(1))
+		return i1.And(NewIntInterval(c, c))
+	}
+	if i1.Lower.Sign() >= 0 {
+		return NewIntInterval(NewZ(0), i1.Upper)
+	}
+	return NewIntInterval(NInfinity, PInfinity)
 }
 
 func (i1 IntInterval) String() string {
@@ -284,6 +801,9 @@ func (i1 IntInterval) String() string {
 	if i1.Empty() {
 		return "{}"
 	}
+	if i1.Congruence.Modulus != nil {
+		return fmt.Sprintf("[%s, %s] %s", i1.Lower, i1.Upper, i1.Congruence)
+	}
 	return fmt.Sprintf("[%s, %s]", i1.Lower, i1.Upper)
 }
 
@@ -298,6 +818,18 @@ type IntArithmeticConstraint struct {
 type IntAddConstraint struct{ *IntArithmeticConstraint }
 type IntSubConstraint struct{ *IntArithmeticConstraint }
 type IntMulConstraint struct{ *IntArithmeticConstraint }
+type IntQuoConstraint struct{ *IntArithmeticConstraint }
+type IntRemConstraint struct{ *IntArithmeticConstraint }
+type IntShlConstraint struct{ *IntArithmeticConstraint }
+type IntShrConstraint struct{ *IntArithmeticConstraint }
+type IntAndConstraint struct{ *IntArithmeticConstraint }
+type IntAndNotConstraint struct{ *IntArithmeticConstraint }
+
+// IntNegConstraint models unary negation.
+type IntNegConstraint struct {
+	aConstraint
+	X ssa.Value
+}
 
 type IntConversionConstraint struct {
 	aConstraint
@@ -331,6 +863,27 @@ func NewIntSubConstraint(a, b, y ssa.Value) Constraint {
 func NewIntMulConstraint(a, b, y ssa.Value) Constraint {
 	return &IntMulConstraint{NewIntArithmeticConstraint(a, b, y, token.MUL, IntInterval.Mul)}
 }
+func NewIntQuoConstraint(a, b, y ssa.Value) Constraint {
+	return &IntQuoConstraint{NewIntArithmeticConstraint(a, b, y, token.QUO, IntInterval.Quo)}
+}
+func NewIntRemConstraint(a, b, y ssa.Value) Constraint {
+	return &IntRemConstraint{NewIntArithmeticConstraint(a, b, y, token.REM, IntInterval.Rem)}
+}
+func NewIntShlConstraint(a, b, y ssa.Value) Constraint {
+	return &IntShlConstraint{NewIntArithmeticConstraint(a, b, y, token.SHL, IntInterval.Shl)}
+}
+func NewIntShrConstraint(a, b, y ssa.Value) Constraint {
+	return &IntShrConstraint{NewIntArithmeticConstraint(a, b, y, token.SHR, IntInterval.Shr)}
+}
+func NewIntAndConstraint(a, b, y ssa.Value) Constraint {
+	return &IntAndConstraint{NewIntArithmeticConstraint(a, b, y, token.AND, IntInterval.And)}
+}
+func NewIntAndNotConstraint(a, b, y ssa.Value) Constraint {
+	return &IntAndNotConstraint{NewIntArithmeticConstraint(a, b, y, token.AND_NOT, IntInterval.AndNot)}
+}
+func NewIntNegConstraint(x, y ssa.Value) Constraint {
+	return &IntNegConstraint{NewConstraint(y), x}
+}
 func NewIntConversionConstraint(x, y ssa.Value) Constraint {
 	return &IntConversionConstraint{NewConstraint(y), x}
 }
@@ -349,6 +902,7 @@ func NewIntIntervalConstraint(i IntInterval, y ssa.Value) Constraint {
 
 func (c *IntArithmeticConstraint) Operands() []ssa.Value   { return []ssa.Value{c.A, c.B} }
 func (c *IntConversionConstraint) Operands() []ssa.Value   { return []ssa.Value{c.X} }
+func (c *IntNegConstraint) Operands() []ssa.Value          { return []ssa.Value{c.X} }
 func (c *IntIntersectionConstraint) Operands() []ssa.Value { return []ssa.Value{c.A} }
 func (s *IntIntervalConstraint) Operands() []ssa.Value     { return nil }
 
@@ -362,21 +916,46 @@ func (c *IntIntersectionConstraint) String() string {
 	return fmt.Sprintf("%s = %s %s %s (%t branch)", c.Y().Name(), c.A.Name(), c.Op, c.B.Name(), c.Y().(*ssa.Sigma).Branch)
 }
 func (c *IntIntervalConstraint) String() string { return fmt.Sprintf("%s = %s", c.Y().Name(), c.I) }
+func (c *IntNegConstraint) String() string      { return fmt.Sprintf("%s = -%s", c.Y().Name(), c.X.Name()) }
 
 func (c *IntArithmeticConstraint) Eval(g *Graph) Range {
 	i1, i2 := g.Range(c.A).(IntInterval), g.Range(c.B).(IntInterval)
 	if !i1.IsKnown() || !i2.IsKnown() {
 		return IntInterval{}
 	}
-	return c.Fn(i1, i2)
+	i := c.Fn(i1, i2)
+	if g.Wrap {
+		var w Wrapping
+		i, w = wrapInterval(i, c.Y().Type(), g.Sizes)
+		g.wrapping[c.Y()] = w
+	}
+	return i
+}
+func (c *IntNegConstraint) Eval(g *Graph) Range {
+	x := g.Range(c.X).(IntInterval)
+	if !x.IsKnown() {
+		return IntInterval{}
+	}
+	i := x.Negate()
+	if g.Wrap {
+		var w Wrapping
+		i, w = wrapInterval(i, c.Y().Type(), g.Sizes)
+		g.wrapping[c.Y()] = w
+	}
+	return i
 }
 func (c *IntConversionConstraint) Eval(g *Graph) Range {
-	s := &types.StdSizes{
-		// XXX is it okay to assume the largest word size, or do we
-		// need to be platform specific?
-		WordSize: 8,
-		MaxAlign: 1,
+	i := c.eval(g)
+	if g.Wrap {
+		var w Wrapping
+		i, w = wrapInterval(i, c.Y().Type(), g.Sizes)
+		g.wrapping[c.Y()] = w
 	}
+	return i
+}
+
+func (c *IntConversionConstraint) eval(g *Graph) IntInterval {
+	s := g.Sizes
 	fromI := g.Range(c.X).(IntInterval)
 	toI := g.Range(c.Y()).(IntInterval)
 	fromT := c.X.Type().Underlying().(*types.Basic)
@@ -423,10 +1002,38 @@ func (c *IntConversionConstraint) Eval(g *Graph) Range {
 func (c *IntIntersectionConstraint) Eval(g *Graph) Range {
 	xi := g.Range(c.A).(IntInterval)
 	if !xi.IsKnown() {
-		return c.I
+		// A hasn't been reached yet. Assuming c.I would make it
+		// an entry of the SCC and keep the bounds it implies on
+		// the other members even if the branch is unreachable.
+		return IntInterval{}
+	}
+	if c.Op == token.NEQ {
+		return excludeConstant(xi, c.ranges[c.B])
 	}
 	return xi.Intersection(c.I)
 }
+
+// excludeConstant removes the value of r from i if r is a single
+// value at one of i's bounds. This is how the false branches of
+// switch statements and if/else chains narrow a value, one case at a
+// time. Intervals can't have holes, so values inside i, such as 3 in
+// [0, 9], aren't removed.
+func excludeConstant(i IntInterval, r Range) IntInterval {
+	k, ok := r.(IntInterval)
+	if !ok || !k.IsKnown() || k.Empty() || i.Empty() ||
+		k.Lower.Infinite() || k.Lower.Cmp(k.Upper) != 0 {
+		return i
+	}
+	switch {
+	case i.Lower.Cmp(i.Upper) == 0 && i.Lower.Cmp(k.Lower) == 0:
+		return EmptyIntInterval
+	case i.Lower.Cmp(k.Lower) == 0:
+		return NewIntInterval(i.Lower.Add(NewZ(1)), i.Upper)
+	case i.Upper.Cmp(k.Lower) == 0:
+		return NewIntInterval(i.Lower, i.Upper.Sub(NewZ(1)))
+	}
+	return i
+}
 func (c *IntIntervalConstraint) Eval(*Graph) Range { return c.I }
 
 func (c *IntIntersectionConstraint) Futures() []ssa.Value {
@@ -474,3 +1081,114 @@ func (c *IntIntersectionConstraint) MarkResolved() {
 func (c *IntIntersectionConstraint) IsResolved() bool {
 	return c.resolved
 }
+
+// IntMinMaxConstraint models calls to the min and max builtins.
+type IntMinMaxConstraint struct {
+	aConstraint
+	Vars []ssa.Value
+	Max  bool
+}
+
+type IntMinConstraint struct{ *IntMinMaxConstraint }
+type IntMaxConstraint struct{ *IntMinMaxConstraint }
+
+func NewIntMinConstraint(vars []ssa.Value, y ssa.Value) Constraint {
+	return &IntMinConstraint{&IntMinMaxConstraint{NewConstraint(y), vars, false}}
+}
+func NewIntMaxConstraint(vars []ssa.Value, y ssa.Value) Constraint {
+	return &IntMaxConstraint{&IntMinMaxConstraint{NewConstraint(y), vars, true}}
+}
+
+func (c *IntMinMaxConstraint) Operands() []ssa.Value { return c.Vars }
+
+func (c *IntMinMaxConstraint) String() string {
+	names := make([]string, len(c.Vars))
+	for i, v := range c.Vars {
+		names[i] = v.Name()
+	}
+	fn := "min"
+	if c.Max {
+		fn = "max"
+	}
+	return fmt.Sprintf("%s = %s(%s)", c.Y().Name(), fn, strings.Join(names, ", "))
+}
+
+func (c *IntMinMaxConstraint) Eval(g *Graph) Range {
+	var lowers, uppers []Z
+	var union Range
+	known := false
+	for _, v := range c.Vars {
+		i := g.Range(v).(IntInterval)
+		if !i.IsKnown() {
+			// Arguments of unknown range may be anything, but the
+			// others still bound the result from one side.
+			i = NewIntInterval(NInfinity, PInfinity)
+		} else {
+			known = true
+		}
+		if i.Empty() {
+			return EmptyIntInterval
+		}
+		lowers = append(lowers, i.Lower)
+		uppers = append(uppers, i.Upper)
+		union = i.Union(union)
+	}
+	if !known {
+		return IntInterval{}
+	}
+	var res IntInterval
+	if c.Max {
+		res = NewIntInterval(MaxZ(lowers...), MaxZ(uppers...))
+	} else {
+		res = NewIntInterval(MinZ(lowers...), MinZ(uppers...))
+	}
+	// The result is one of the arguments, so it satisfies any
+	// congruence that all of them satisfy.
+	return res.withCongruence(union.(IntInterval).congruence())
+}
+
+// IntParseConstraint models the results of strconv.ParseInt and
+// strconv.ParseUint, which always fit in BitSize bits, even when
+// parsing fails: out of range values are clamped and syntax errors
+// return 0.
+type IntParseConstraint struct {
+	aConstraint
+	BitSize  int
+	Unsigned bool
+}
+
+func NewIntParseConstraint(bitSize int, unsigned bool, y ssa.Value) Constraint {
+	return &IntParseConstraint{NewConstraint(y), bitSize, unsigned}
+}
+
+func (c *IntParseConstraint) Operands() []ssa.Value { return nil }
+
+func (c *IntParseConstraint) String() string {
+	fn := "ParseInt"
+	if c.Unsigned {
+		fn = "ParseUint"
+	}
+	return fmt.Sprintf("%s = strconv.%s(..., %d)", c.Y().Name(), fn, c.BitSize)
+}
+
+func (c *IntParseConstraint) Eval(g *Graph) Range {
+	typ := types.Typ[types.Int]
+	if c.Unsigned {
+		typ = types.Typ[types.Uint]
+	}
+	bits := c.BitSize
+	if bits == 0 {
+		// A bit size of 0 means the size of int.
+		bits = int(g.Sizes.Sizeof(typ) * 8)
+	}
+	if bits < 0 || bits > 64 {
+		return InfinityFor(c.Y())
+	}
+	n := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	if c.Unsigned {
+		return NewIntInterval(NewZ(0), NewBigZ(n.Sub(n, big.NewInt(1))))
+	}
+	n.Rsh(n, 1)
+	lower := NewBigZ(new(big.Int).Neg(n))
+	return NewIntInterval(lower, NewBigZ(n.Sub(n, big.NewInt(1))))
+}
@@ -0,0 +1,150 @@
+package vrp
+
+import (
+	"fmt"
+
+	"honnef.co/go/tools/ssa"
+)
+
+type MapInterval struct {
+	Length IntInterval
+}
+
+func (m MapInterval) Union(other Range) Range {
+	i, ok := other.(MapInterval)
+	if !ok {
+		i = MapInterval{EmptyIntInterval}
+	}
+	if m.Length.Empty() || !m.Length.IsKnown() {
+		return i
+	}
+	if i.Length.Empty() || !i.Length.IsKnown() {
+		return m
+	}
+	return MapInterval{
+		Length: m.Length.Union(i.Length).(IntInterval),
+	}
+}
+func (m MapInterval) String() string { return m.Length.String() }
+func (m MapInterval) IsKnown() bool  { return m.Length.IsKnown() }
+
+// MakeMapConstraint models the length of a map created by make or a
+// composite literal. Maps are mutated in place, so the length is
+// bounded by the number of assignments to the map, provided that it
+// doesn't escape and isn't assigned to in a loop. The size hint of
+// make only reserves space; new maps are always empty.
+type MakeMapConstraint struct {
+	aConstraint
+	// Updates is the maximum number of assignments to the map, or -1
+	// if it is unbounded.
+	Updates int
+}
+
+type MapLengthConstraint struct {
+	aConstraint
+	X ssa.Value
+}
+
+type MapIntervalConstraint struct {
+	aConstraint
+	I IntInterval
+}
+
+func NewMakeMapConstraint(updates int, y ssa.Value) Constraint {
+	return &MakeMapConstraint{NewConstraint(y), updates}
+}
+func NewMapLengthConstraint(x, y ssa.Value) Constraint {
+	return &MapLengthConstraint{NewConstraint(y), x}
+}
+func NewMapIntervalConstraint(i IntInterval, y ssa.Value) Constraint {
+	return &MapIntervalConstraint{NewConstraint(y), i}
+}
+
+func (c *MakeMapConstraint) Operands() []ssa.Value     { return nil }
+func (c *MapLengthConstraint) Operands() []ssa.Value   { return []ssa.Value{c.X} }
+func (c *MapIntervalConstraint) Operands() []ssa.Value { return nil }
+
+func (c *MakeMapConstraint) String() string {
+	return fmt.Sprintf("%s = make(map) with %d updates", c.Y().Name(), c.Updates)
+}
+func (c *MapLengthConstraint) String() string {
+	return fmt.Sprintf("%s = len(%s)", c.Y().Name(), c.X.Name())
+}
+func (c *MapIntervalConstraint) String() string { return fmt.Sprintf("%s = %s", c.Y().Name(), c.I) }
+
+func (c *MakeMapConstraint) Eval(*Graph) Range {
+	if c.Updates < 0 {
+		return MapInterval{NewIntInterval(NewZ(0), PInfinity)}
+	}
+	return MapInterval{NewIntInterval(NewZ(0), NewZ(int64(c.Updates)))}
+}
+func (c *MapLengthConstraint) Eval(g *Graph) Range {
+	i := g.Range(c.X).(MapInterval).Length
+	if !i.IsKnown() {
+		return NewIntInterval(NewZ(0), PInfinity)
+	}
+	return i
+}
+func (c *MapIntervalConstraint) Eval(*Graph) Range { return MapInterval{c.I} }
+
+// mapUpdates returns the maximum number of assignments to the map
+// created by m, or -1 if the map escapes or is assigned to in a
+// loop.
+func mapUpdates(m *ssa.MakeMap) int {
+	n := 0
+	for _, ref := range *m.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.MapUpdate:
+			if ref.Key == ssa.Value(m) || ref.Value == ssa.Value(m) || inLoop(ref.Block()) {
+				return -1
+			}
+			n++
+		case *ssa.Lookup:
+			if ref.Index == ssa.Value(m) {
+				return -1
+			}
+		case *ssa.Range, *ssa.DebugRef:
+		case *ssa.Call:
+			builtin, ok := ref.Common().Value.(*ssa.Builtin)
+			if !ok {
+				return -1
+			}
+			switch builtin.Name() {
+			case "len":
+			case "delete":
+				// Deleting entries only ever shrinks the map.
+				if ref.Common().Args[1] == ssa.Value(m) {
+					return -1
+				}
+			default:
+				return -1
+			}
+		default:
+			return -1
+		}
+	}
+	return n
+}
+
+// inLoop reports whether b is part of a cycle in the control flow
+// graph.
+func inLoop(b *ssa.BasicBlock) bool {
+	seen := map[*ssa.BasicBlock]bool{}
+	var walk func(*ssa.BasicBlock) bool
+	walk = func(x *ssa.BasicBlock) bool {
+		for _, succ := range x.Succs {
+			if succ == b {
+				return true
+			}
+			if seen[succ] {
+				continue
+			}
+			seen[succ] = true
+			if walk(succ) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(b)
+}
@@ -4,18 +4,25 @@ import (
 	"fmt"
 	"go/token"
 	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"honnef.co/go/tools/ssa"
 )
 
 type StringInterval struct {
 	Length IntInterval
+	// Prefix and Suffix are known to begin and end all values of
+	// the string. They are empty if nothing is known.
+	Prefix string
+	Suffix string
 }
 
 func (s StringInterval) Union(other Range) Range {
 	i, ok := other.(StringInterval)
 	if !ok {
-		i = StringInterval{EmptyIntInterval}
+		i = StringInterval{Length: EmptyIntInterval}
 	}
 	if s.Length.Empty() || !s.Length.IsKnown() {
 		return i
@@ -25,11 +32,99 @@ func (s StringInterval) Union(other Range) Range {
 	}
 	return StringInterval{
 		Length: s.Length.Union(i.Length).(IntInterval),
+		Prefix: commonPrefix(s.Prefix, i.Prefix),
+		Suffix: commonSuffix(s.Suffix, i.Suffix),
 	}
 }
 
 func (s StringInterval) String() string {
-	return s.Length.String()
+	str := s.Length.String()
+	if s.Prefix != "" {
+		str += fmt.Sprintf(" prefix %q", s.Prefix)
+	}
+	if s.Suffix != "" {
+		str += fmt.Sprintf(" suffix %q", s.Suffix)
+	}
+	return str
+}
+
+// Exact reports whether the string is known to always have the same
+// value, which is returned as Prefix.
+func (s StringInterval) Exact() bool {
+	return s.Length.IsKnown() && !s.Length.Lower.Infinite() &&
+		s.Length.Lower.Cmp(s.Length.Upper) == 0 &&
+		s.Length.Lower.Cmp(NewZ(int64(len(s.Prefix)))) == 0
+}
+
+// HasPrefix reports whether all values of the string begin with
+// prefix. ok is false if that depends on the value.
+func (s StringInterval) HasPrefix(prefix string) (result, ok bool) {
+	if strings.HasPrefix(s.Prefix, prefix) {
+		return true, true
+	}
+	if !strings.HasPrefix(prefix, s.Prefix) {
+		return false, true
+	}
+	if s.Length.IsKnown() && s.Length.Upper.Cmp(NewZ(int64(len(prefix)))) == -1 {
+		return false, true
+	}
+	return false, false
+}
+
+// HasSuffix reports whether all values of the string end in suffix.
+// ok is false if that depends on the value.
+func (s StringInterval) HasSuffix(suffix string) (result, ok bool) {
+	if strings.HasSuffix(s.Suffix, suffix) {
+		return true, true
+	}
+	if !strings.HasSuffix(suffix, s.Suffix) {
+		return false, true
+	}
+	if s.Length.IsKnown() && s.Length.Upper.Cmp(NewZ(int64(len(suffix)))) == -1 {
+		return false, true
+	}
+	return false, false
+}
+
+// truncateAffixes shortens the prefix and suffix of s so that they
+// aren't longer than its shortest value.
+func (s StringInterval) truncateAffixes() StringInterval {
+	if !s.Length.IsKnown() || s.Length.Empty() {
+		return s
+	}
+	l := s.Length.Lower
+	if l.Cmp(NewZ(int64(len(s.Prefix)))) >= 0 && l.Cmp(NewZ(int64(len(s.Suffix)))) >= 0 {
+		return s
+	}
+	// l is now known to be smaller than one of the affixes, so it
+	// fits in an int.
+	n := 0
+	if !l.Infinite() && l.Sign() > 0 {
+		n = int(l.small)
+	}
+	if len(s.Prefix) > n {
+		s.Prefix = s.Prefix[:n]
+	}
+	if len(s.Suffix) > n {
+		s.Suffix = s.Suffix[len(s.Suffix)-n:]
+	}
+	return s
+}
+
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+func commonSuffix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return a[len(a)-n:]
 }
 
 func (s StringInterval) IsKnown() bool {
@@ -66,7 +161,25 @@ type StringLengthConstraint struct {
 
 type StringIntervalConstraint struct {
 	aConstraint
-	I IntInterval
+	I      IntInterval
+	Prefix string
+	Suffix string
+}
+
+// StringTrimConstraint models strings.TrimPrefix and
+// strings.TrimSuffix with a constant affix.
+type StringTrimConstraint struct {
+	aConstraint
+	X      ssa.Value
+	Affix  string
+	Suffix bool
+}
+
+// StringCaseConstraint models case mappings such as strings.ToUpper,
+// which usually keep the length of a string but change its contents.
+type StringCaseConstraint struct {
+	aConstraint
+	X ssa.Value
 }
 
 func NewStringSliceConstraint(x, lower, upper, y ssa.Value) Constraint {
@@ -88,7 +201,17 @@ func NewStringLengthConstraint(x ssa.Value, y ssa.Value) Constraint {
 	return &StringLengthConstraint{NewConstraint(y), x}
 }
 func NewStringIntervalConstraint(i IntInterval, y ssa.Value) Constraint {
-	return &StringIntervalConstraint{NewConstraint(y), i}
+	return &StringIntervalConstraint{aConstraint: NewConstraint(y), I: i}
+}
+func NewStringConstantConstraint(str string, y ssa.Value) Constraint {
+	n := NewZ(int64(len(str)))
+	return &StringIntervalConstraint{NewConstraint(y), NewIntInterval(n, n), str, str}
+}
+func NewStringTrimConstraint(x ssa.Value, affix string, suffix bool, y ssa.Value) Constraint {
+	return &StringTrimConstraint{NewConstraint(y), x, affix, suffix}
+}
+func NewStringCaseConstraint(x, y ssa.Value) Constraint {
+	return &StringCaseConstraint{NewConstraint(y), x}
 }
 
 func (c *StringSliceConstraint) Operands() []ssa.Value {
@@ -105,6 +228,8 @@ func (c *StringIntersectionConstraint) Operands() []ssa.Value { return []ssa.Val
 func (c StringConcatConstraint) Operands() []ssa.Value        { return []ssa.Value{c.A, c.B} }
 func (c *StringLengthConstraint) Operands() []ssa.Value       { return []ssa.Value{c.X} }
 func (s *StringIntervalConstraint) Operands() []ssa.Value     { return nil }
+func (c *StringTrimConstraint) Operands() []ssa.Value         { return []ssa.Value{c.X} }
+func (c *StringCaseConstraint) Operands() []ssa.Value         { return []ssa.Value{c.X} }
 
 func (c *StringSliceConstraint) String() string {
 	var lname, uname string
@@ -125,7 +250,19 @@ func (c StringConcatConstraint) String() string {
 func (c *StringLengthConstraint) String() string {
 	return fmt.Sprintf("%s = len(%s)", c.Y().Name(), c.X.Name())
 }
-func (c *StringIntervalConstraint) String() string { return fmt.Sprintf("%s = %s", c.Y().Name(), c.I) }
+func (c *StringIntervalConstraint) String() string {
+	return fmt.Sprintf("%s = %s", c.Y().Name(), StringInterval{c.I, c.Prefix, c.Suffix})
+}
+func (c *StringTrimConstraint) String() string {
+	fn := "TrimPrefix"
+	if c.Suffix {
+		fn = "TrimSuffix"
+	}
+	return fmt.Sprintf("%s = %s(%s, %q)", c.Y().Name(), fn, c.X.Name(), c.Affix)
+}
+func (c *StringCaseConstraint) String() string {
+	return fmt.Sprintf("%s = case(%s)", c.Y().Name(), c.X.Name())
+}
 
 func (c *StringSliceConstraint) Eval(g *Graph) Range {
 	lr := NewIntInterval(NewZ(0), NewZ(0))
@@ -155,9 +292,24 @@ func (c *StringSliceConstraint) Eval(g *Graph) Range {
 		}
 	}
 
-	return StringInterval{
+	res := StringInterval{
 		Length: NewIntInterval(MinZ(ls...), MaxZ(ls...)),
 	}
+	// Slicing from the start keeps the prefix and slicing to the
+	// end keeps the suffix, as far as the result is long enough.
+	x := g.Range(c.X).(StringInterval)
+	if c.Lower == nil || isZero(c.Lower) {
+		res.Prefix = x.Prefix
+	}
+	if c.Upper == nil {
+		res.Suffix = x.Suffix
+	}
+	return res.truncateAffixes()
+}
+
+func isZero(v ssa.Value) bool {
+	k, ok := v.(*ssa.Const)
+	return ok && k.Value != nil && k.Value.String() == "0"
 }
 func (c *StringIntersectionConstraint) Eval(g *Graph) Range {
 	var l IntInterval
@@ -169,7 +321,7 @@ func (c *StringIntersectionConstraint) Eval(g *Graph) Range {
 	}
 
 	if !l.IsKnown() {
-		return StringInterval{c.I}
+		return StringInterval{Length: c.I}
 	}
 	return StringInterval{
 		Length: l.Intersection(c.I),
@@ -180,9 +332,18 @@ func (c StringConcatConstraint) Eval(g *Graph) Range {
 	if !i1.Length.IsKnown() || !i2.Length.IsKnown() {
 		return StringInterval{}
 	}
-	return StringInterval{
+	res := StringInterval{
 		Length: i1.Length.Add(i2.Length),
+		Prefix: i1.Prefix,
+		Suffix: i2.Suffix,
+	}
+	if i1.Exact() {
+		res.Prefix = i1.Prefix + i2.Prefix
 	}
+	if i2.Exact() {
+		res.Suffix = i1.Suffix + i2.Suffix
+	}
+	return res
 }
 func (c *StringLengthConstraint) Eval(g *Graph) Range {
 	i := g.Range(c.X).(StringInterval).Length
@@ -191,7 +352,51 @@ func (c *StringLengthConstraint) Eval(g *Graph) Range {
 	}
 	return i
 }
-func (c *StringIntervalConstraint) Eval(*Graph) Range { return StringInterval{c.I} }
+func (c *StringIntervalConstraint) Eval(*Graph) Range {
+	return StringInterval{c.I, c.Prefix, c.Suffix}
+}
+func (c *StringTrimConstraint) Eval(g *Graph) Range {
+	x := g.Range(c.X).(StringInterval)
+	if !x.IsKnown() {
+		return StringInterval{}
+	}
+	if x.Length.Empty() {
+		return x
+	}
+	var has, ok bool
+	if c.Suffix {
+		has, ok = x.HasSuffix(c.Affix)
+	} else {
+		has, ok = x.HasPrefix(c.Affix)
+	}
+	if ok && !has {
+		return x
+	}
+	n := NewZ(int64(len(c.Affix)))
+	res := StringInterval{Length: x.Length.Sub(NewIntInterval(n, n))}
+	if !ok {
+		// The affix may or may not be removed.
+		res.Length = NewIntInterval(res.Length.Lower, x.Length.Upper)
+	}
+	if res.Length.Lower.Sign() == -1 {
+		res.Length = NewIntInterval(NewZ(0), res.Length.Upper)
+	}
+	if c.Suffix {
+		res.Prefix = x.Prefix
+		if ok {
+			res.Suffix = x.Suffix[:len(x.Suffix)-len(c.Affix)]
+		}
+	} else {
+		res.Suffix = x.Suffix
+		if ok {
+			res.Prefix = x.Prefix[len(c.Affix):]
+		}
+	}
+	return res.truncateAffixes()
+}
+func (c *StringCaseConstraint) Eval(g *Graph) Range {
+	return StringInterval{Length: g.Range(c.X).(StringInterval).Length}
+}
 
 func (c *StringIntersectionConstraint) Futures() []ssa.Value {
 	return []ssa.Value{c.B}
@@ -256,3 +461,114 @@ func (c *StringIntersectionConstraint) MarkResolved() {
 func (c *StringIntersectionConstraint) IsResolved() bool {
 	return c.resolved
 }
+
+// StringConversionConstraint models conversions to strings from
+// integers, which are encoded as UTF-8, and from byte and rune
+// slices.
+type StringConversionConstraint struct {
+	aConstraint
+	X ssa.Value
+}
+
+// StringToSliceConstraint models conversions of strings to byte and
+// rune slices.
+type StringToSliceConstraint struct {
+	aConstraint
+	X ssa.Value
+}
+
+func NewStringConversionConstraint(x, y ssa.Value) Constraint {
+	return &StringConversionConstraint{NewConstraint(y), x}
+}
+func NewStringToSliceConstraint(x, y ssa.Value) Constraint {
+	return &StringToSliceConstraint{NewConstraint(y), x}
+}
+
+func (c *StringConversionConstraint) Operands() []ssa.Value { return []ssa.Value{c.X} }
+func (c *StringToSliceConstraint) Operands() []ssa.Value    { return []ssa.Value{c.X} }
+
+func (c *StringConversionConstraint) String() string {
+	return fmt.Sprintf("%s = string(%s)", c.Y().Name(), c.X.Name())
+}
+func (c *StringToSliceConstraint) String() string {
+	return fmt.Sprintf("%s = %s(%s)", c.Y().Name(), c.Y().Type(), c.X.Name())
+}
+
+func (c *StringConversionConstraint) Eval(g *Graph) Range {
+	switch r := g.Range(c.X).(type) {
+	case IntInterval:
+		if !r.IsKnown() {
+			return StringInterval{}
+		}
+		if r.Empty() {
+			return StringInterval{Length: EmptyIntInterval}
+		}
+		if !r.Lower.Infinite() && r.Lower.Cmp(r.Upper) == 0 {
+			var str string
+			if r.Lower.Cmp(NewZ(unicode.MaxRune)) <= 0 && r.Lower.Sign() >= 0 {
+				str = string(rune(r.Lower.small))
+			} else {
+				str = string(utf8.RuneError)
+			}
+			n := NewZ(int64(len(str)))
+			return StringInterval{NewIntInterval(n, n), str, str}
+		}
+		return StringInterval{Length: runeLengths(r)}
+	case SliceInterval:
+		if !r.IsKnown() || r.Length.Empty() {
+			return StringInterval{Length: r.Length}
+		}
+		if isRuneSlice(c.X.Type()) {
+			return StringInterval{Length: r.Length.Mul(NewIntInterval(NewZ(1), NewZ(utf8.UTFMax)))}
+		}
+		return StringInterval{Length: r.Length}
+	default:
+		return StringInterval{}
+	}
+}
+
+func (c *StringToSliceConstraint) Eval(g *Graph) Range {
+	r := g.Range(c.X).(StringInterval)
+	if !r.IsKnown() || r.Length.Empty() || !isRuneSlice(c.Y().Type()) {
+		return SliceInterval{r.Length}
+	}
+	// Every rune takes up between 1 and utf8.UTFMax bytes.
+	l := r.Length.Lower.Add(NewZ(utf8.UTFMax - 1)).Quo(NewZ(utf8.UTFMax))
+	return SliceInterval{NewIntInterval(l, r.Length.Upper)}
+}
+
+func isRuneSlice(typ types.Type) bool {
+	s, ok := typ.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	b, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Int32
+}
+
+// runeLengths returns the lengths of the UTF-8 encodings of the
+// integers in r. Integers that aren't valid runes are encoded as
+// utf8.RuneError.
+func runeLengths(r IntInterval) IntInterval {
+	classes := []struct {
+		lower, upper int64
+		n            int64
+	}{
+		{0, 0x7F, 1},
+		{0x80, 0x7FF, 2},
+		{0x800, 0xFFFF, 3},
+		{0x10000, unicode.MaxRune, 4},
+	}
+	res := EmptyIntInterval
+	if r.Lower.Sign() < 0 || r.Upper.Cmp(NewZ(unicode.MaxRune)) == 1 {
+		res = NewIntInterval(NewZ(3), NewZ(3))
+	}
+	for _, class := range classes {
+		if r.Upper.Cmp(NewZ(class.lower)) == -1 || r.Lower.Cmp(NewZ(class.upper)) == 1 {
+			continue
+		}
+		n := NewZ(class.n)
+		res = res.Union(NewIntInterval(n, n)).(IntInterval)
+	}
+	return res
+}
@@ -1,17 +1,28 @@
-package vrp
+// Package vrp implements value range propagation on SSA form,
+// computing the ranges of integers and of the lengths of strings,
+// slices, maps and channel buffers. For strings, it additionally
+// tracks constant prefixes and suffixes.
+//
+// ForFunction computes the ranges of a function's values, which can
+// then be queried with (*Graph).RangeOf.
+package vrp // import "honnef.co/go/tools/staticcheck/vrp"
 
 // TODO(dh) widening and narrowing have a lot of code in common. Make
 // it reusable.
 
 import (
+	"bytes"
 	"fmt"
+	"go/build"
 	"go/constant"
 	"go/token"
 	"go/types"
+	"io"
 	"math/big"
 	"sort"
 	"strings"
 
+	"honnef.co/go/tools/gcsizes"
 	"honnef.co/go/tools/ssa"
 )
 
@@ -104,19 +115,42 @@ func isSupportedType(typ types.Type) bool {
 		return true
 	case *types.Slice:
 		return true
+	case *types.Map:
+		return true
 	default:
 		return false
 	}
 	return true
 }
 
+func isInteger(typ types.Type) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && (basic.Info()&types.IsInteger) != 0
+}
+
+// unsigma returns the value that v refines if it is a σ-node, and v
+// otherwise.
+func unsigma(v ssa.Value) ssa.Value {
+	if sigma, ok := v.(*ssa.Sigma); ok {
+		return sigma.Value()
+	}
+	return v
+}
+
 func ConstantToZ(c constant.Value) Z {
+	if n, exact := constant.Int64Val(constant.ToInt(c)); exact {
+		return NewZ(n)
+	}
 	s := constant.ToInt(c).ExactString()
 	n := &big.Int{}
 	n.SetString(s, 10)
 	return NewBigZ(n)
 }
 
+// sigmaInteger returns the constraint of a σ-node on an integer
+// comparison. Switch statements are lowered to chains of equality
+// tests, so besides the true branches of ==, the false branches
+// narrow the value, one case at a time.
 func sigmaInteger(g *Graph, ins *ssa.Sigma, cond *ssa.BinOp, ops []*ssa.Value) Constraint {
 	op := cond.Op
 	if !ins.Branch {
@@ -124,7 +158,7 @@ func sigmaInteger(g *Graph, ins *ssa.Sigma, cond *ssa.BinOp, ops []*ssa.Value) C
 	}
 
 	switch op {
-	case token.EQL, token.GTR, token.GEQ, token.LSS, token.LEQ:
+	case token.EQL, token.NEQ, token.GTR, token.GEQ, token.LSS, token.LEQ:
 	default:
 		return nil
 	}
@@ -240,8 +274,10 @@ func sigmaSlice(g *Graph, ins *ssa.Sigma, cond *ssa.BinOp, ops []*ssa.Value) Con
 
 func BuildGraph(f *ssa.Function) *Graph {
 	g := &Graph{
+		Sizes:    gcsizes.ForArch(build.Default.GOARCH),
 		Vertices: map[interface{}]*Vertex{},
 		ranges:   Ranges{},
+		wrapping: map[ssa.Value]Wrapping{},
 	}
 
 	var cs []Constraint
@@ -261,6 +297,8 @@ func BuildGraph(f *ssa.Function) *Graph {
 						switch c.Type().Underlying().(type) {
 						case *types.Slice:
 							cs = append(cs, NewSliceIntervalConstraint(NewIntInterval(NewZ(0), NewZ(0)), c))
+						case *types.Map:
+							cs = append(cs, NewMapIntervalConstraint(NewIntInterval(NewZ(0), NewZ(0)), c))
 						}
 						continue
 					}
@@ -269,9 +307,7 @@ func BuildGraph(f *ssa.Function) *Graph {
 						v := ConstantToZ(c.Value)
 						cs = append(cs, NewIntIntervalConstraint(NewIntInterval(v, v), c))
 					case constant.String:
-						s := constant.StringVal(c.Value)
-						n := NewZ(int64(len(s)))
-						cs = append(cs, NewStringIntervalConstraint(NewIntInterval(n, n), c))
+						cs = append(cs, NewStringConstantConstraint(constant.StringVal(c.Value), c))
 					}
 				}
 			}
@@ -283,10 +319,33 @@ func BuildGraph(f *ssa.Function) *Graph {
 			case *ssa.Convert:
 				switch v := ins.Type().Underlying().(type) {
 				case *types.Basic:
+					if (v.Info() & types.IsString) != 0 {
+						switch ins.X.Type().Underlying().(type) {
+						case *types.Basic, *types.Slice:
+							cs = append(cs, NewStringConversionConstraint(ins.X, ins))
+						}
+						continue
+					}
 					if (v.Info() & types.IsInteger) == 0 {
 						continue
 					}
+					if b, ok := ins.X.Type().Underlying().(*types.Basic); !ok || (b.Info()&types.IsInteger) == 0 {
+						// Pointers converted to uintptr and truncated
+						// floats can hold any value of the target type.
+						l, u, _ := TypeBounds(ins.Type(), g.Sizes)
+						cs = append(cs, NewIntIntervalConstraint(NewIntInterval(l, u), ins))
+						continue
+					}
 					cs = append(cs, NewIntConversionConstraint(ins.X, ins))
+				case *types.Slice:
+					if b, ok := ins.X.Type().Underlying().(*types.Basic); ok && (b.Info()&types.IsString) != 0 {
+						cs = append(cs, NewStringToSliceConstraint(ins.X, ins))
+					}
+				}
+			case *ssa.Lookup:
+				if b, ok := ins.X.Type().Underlying().(*types.Basic); ok && (b.Info()&types.IsString) != 0 {
+					// Indexing a string yields a byte.
+					cs = append(cs, NewIntIntervalConstraint(NewIntInterval(NewZ(0), NewZ(255)), ins))
 				}
 			case *ssa.Call:
 				if static := ins.Common().StaticCallee(); static != nil {
@@ -302,12 +361,14 @@ func BuildGraph(f *ssa.Function) *Graph {
 							// limit by the upper bound of the passed
 							// string
 							cs = append(cs, NewIntIntervalConstraint(NewIntInterval(NewZ(-1), PInfinity), ins))
-						case "bytes.Title", "bytes.ToLower", "bytes.ToTitle", "bytes.ToUpper",
-							"strings.Title", "strings.ToLower", "strings.ToTitle", "strings.ToUpper":
+						case "bytes.Title", "bytes.ToLower", "bytes.ToTitle", "bytes.ToUpper":
 							cs = append(cs, NewCopyConstraint(ins.Common().Args[0], ins))
-						case "bytes.ToLowerSpecial", "bytes.ToTitleSpecial", "bytes.ToUpperSpecial",
-							"strings.ToLowerSpecial", "strings.ToTitleSpecial", "strings.ToUpperSpecial":
+						case "strings.Title", "strings.ToLower", "strings.ToTitle", "strings.ToUpper":
+							cs = append(cs, NewStringCaseConstraint(ins.Common().Args[0], ins))
+						case "bytes.ToLowerSpecial", "bytes.ToTitleSpecial", "bytes.ToUpperSpecial":
 							cs = append(cs, NewCopyConstraint(ins.Common().Args[1], ins))
+						case "strings.ToLowerSpecial", "strings.ToTitleSpecial", "strings.ToUpperSpecial":
+							cs = append(cs, NewStringCaseConstraint(ins.Common().Args[1], ins))
 						case "bytes.Compare", "strings.Compare":
 							cs = append(cs, NewIntIntervalConstraint(NewIntInterval(NewZ(-1), NewZ(1)), ins))
 						case "bytes.Count", "strings.Count":
@@ -320,12 +381,33 @@ func BuildGraph(f *ssa.Function) *Graph {
 							"strings.Map", "strings.TrimFunc", "strings.TrimLeft", "strings.TrimLeftFunc",
 							"strings.TrimRight", "strings.TrimRightFunc", "strings.TrimSpace":
 							// TODO(dh): lower = 0, upper = upper of passed string
-						case "bytes.TrimPrefix", "bytes.TrimSuffix",
-							"strings.TrimPrefix", "strings.TrimSuffix":
+						case "bytes.TrimPrefix", "bytes.TrimSuffix":
 							// TODO(dh) range between "unmodified" and len(cutset) removed
+						case "strings.TrimPrefix", "strings.TrimSuffix":
+							k, ok := ins.Common().Args[1].(*ssa.Const)
+							if !ok {
+								// TODO(dh) range between "unmodified" and len(cutset) removed
+								continue
+							}
+							suffix := fn.FullName() == "strings.TrimSuffix"
+							cs = append(cs, NewStringTrimConstraint(ins.Common().Args[0], constant.StringVal(k.Value), suffix, ins))
+						case "math/bits.Len", "math/bits.Len8", "math/bits.Len16", "math/bits.Len32", "math/bits.Len64",
+							"math/bits.LeadingZeros", "math/bits.LeadingZeros8", "math/bits.LeadingZeros16",
+							"math/bits.LeadingZeros32", "math/bits.LeadingZeros64",
+							"math/bits.TrailingZeros", "math/bits.TrailingZeros8", "math/bits.TrailingZeros16",
+							"math/bits.TrailingZeros32", "math/bits.TrailingZeros64",
+							"math/bits.OnesCount", "math/bits.OnesCount8", "math/bits.OnesCount16",
+							"math/bits.OnesCount32", "math/bits.OnesCount64":
+							cs = append(cs, NewIntBitsConstraint(ins.Common().Args[0], fn.Name(), ins))
 						case "(*bytes.Buffer).Cap", "(*bytes.Buffer).Len", "(*bytes.Reader).Len", "(*bytes.Reader).Size":
 							cs = append(cs, NewIntIntervalConstraint(NewIntInterval(NewZ(0), PInfinity), ins))
+						default:
+							if isInteger(ins.Type()) && len(static.Blocks) > 0 {
+								cs = append(cs, NewCallConstraint(ins, -1, ins))
+							}
 						}
+					} else if isInteger(ins.Type()) && len(static.Blocks) > 0 {
+						cs = append(cs, NewCallConstraint(ins, -1, ins))
 					}
 				}
 				builtin, ok := ins.Common().Value.(*ssa.Builtin)
@@ -342,10 +424,43 @@ func BuildGraph(f *ssa.Function) *Graph {
 						}
 					case *types.Slice:
 						cs = append(cs, NewSliceLengthConstraint(*ops[1], ins))
+					case *types.Map:
+						cs = append(cs, NewMapLengthConstraint(*ops[1], ins))
+					case *types.Chan:
+						cs = append(cs, NewChannelLengthConstraint(*ops[1], ins))
+					}
+				case "cap":
+					if _, ok := (*ops[1]).Type().Underlying().(*types.Chan); ok {
+						cs = append(cs, NewChannelCapConstraint(*ops[1], ins))
 					}
 
 				case "append":
 					cs = append(cs, NewSliceAppendConstraint(ins.Common().Args[0], ins.Common().Args[1], ins))
+				case "min", "max":
+					if !isInteger(ins.Type()) {
+						continue
+					}
+					if builtin.Name() == "min" {
+						cs = append(cs, NewIntMinConstraint(ins.Common().Args, ins))
+					} else {
+						cs = append(cs, NewIntMaxConstraint(ins.Common().Args, ins))
+					}
+				}
+			case *ssa.UnOp:
+				if ins.Op == token.SUB && isInteger(ins.Type()) {
+					cs = append(cs, NewIntNegConstraint(ins.X, ins))
+				}
+			case *ssa.Extract:
+				call, ok := ins.Tuple.(*ssa.Call)
+				if !ok || !isInteger(ins.Type()) {
+					continue
+				}
+				if c := parseIntConstraint(call, ins); c != nil {
+					cs = append(cs, c)
+					continue
+				}
+				if static := call.Common().StaticCallee(); static != nil && len(static.Blocks) > 0 {
+					cs = append(cs, NewCallConstraint(call, ins.Index, ins))
 				}
 			case *ssa.BinOp:
 				ops := ins.Operands(nil)
@@ -355,12 +470,17 @@ func BuildGraph(f *ssa.Function) *Graph {
 				}
 				switch basic.Kind() {
 				case types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
-					types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.UntypedInt:
+					types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Uintptr, types.UntypedInt:
 					fns := map[token.Token]func(ssa.Value, ssa.Value, ssa.Value) Constraint{
-						token.ADD: NewIntAddConstraint,
-						token.SUB: NewIntSubConstraint,
-						token.MUL: NewIntMulConstraint,
-						// XXX support QUO, REM, SHL, SHR
+						token.ADD:     NewIntAddConstraint,
+						token.SUB:     NewIntSubConstraint,
+						token.MUL:     NewIntMulConstraint,
+						token.QUO:     NewIntQuoConstraint,
+						token.REM:     NewIntRemConstraint,
+						token.SHL:     NewIntShlConstraint,
+						token.SHR:     NewIntShrConstraint,
+						token.AND:     NewIntAndConstraint,
+						token.AND_NOT: NewIntAndNotConstraint,
 					}
 					fn, ok := fns[ins.Op]
 					if ok {
@@ -390,6 +510,14 @@ func BuildGraph(f *ssa.Function) *Graph {
 				if !isSupportedType(ins.Type()) {
 					continue
 				}
+				if c := inductionConstraint(ins); c != nil {
+					cs = append(cs, c)
+					continue
+				}
+				if c := absConstraint(ins); c != nil {
+					cs = append(cs, c)
+					continue
+				}
 				ops := ins.Operands(nil)
 				dops := make([]ssa.Value, len(ops))
 				for i, op := range ops {
@@ -400,16 +528,16 @@ func BuildGraph(f *ssa.Function) *Graph {
 				pred := ins.Block().Preds[0]
 				instrs := pred.Instrs
 				cond, ok := instrs[len(instrs)-1].(*ssa.If).Cond.(*ssa.BinOp)
-				ops := cond.Operands(nil)
 				if !ok {
 					continue
 				}
+				ops := cond.Operands(nil)
 				switch typ := ins.Type().Underlying().(type) {
 				case *types.Basic:
 					var c Constraint
 					switch typ.Kind() {
 					case types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
-						types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.UntypedInt:
+						types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Uintptr, types.UntypedInt:
 						c = sigmaInteger(g, ins, cond, ops)
 					case types.String, types.UntypedString:
 						c = sigmaString(g, ins, cond, ops)
@@ -422,6 +550,11 @@ func BuildGraph(f *ssa.Function) *Graph {
 					if c != nil {
 						cs = append(cs, c)
 					}
+				case *types.Chan:
+					c := sigmaChannel(g, ins, cond, ops)
+					if c != nil {
+						cs = append(cs, c)
+					}
 				default:
 					//log.Printf("unsupported sigma type %T", typ) // XXX
 				}
@@ -429,6 +562,8 @@ func BuildGraph(f *ssa.Function) *Graph {
 				cs = append(cs, NewMakeChannelConstraint(ins.Size, ins))
 			case *ssa.MakeSlice:
 				cs = append(cs, NewMakeSliceConstraint(ins.Len, ins))
+			case *ssa.MakeMap:
+				cs = append(cs, NewMakeMapConstraint(mapUpdates(ins), ins))
 			case *ssa.ChangeType:
 				switch ins.X.Type().Underlying().(type) {
 				case *types.Chan:
@@ -459,6 +594,7 @@ func BuildGraph(f *ssa.Function) *Graph {
 	g.FindSCCs()
 	g.sccEdges = make([][]Edge, len(g.SCCs))
 	g.futures = make([][]Future, len(g.SCCs))
+	g.solved = make([]bool, len(g.SCCs))
 	for _, e := range g.Edges {
 		g.sccEdges[e.From.SCC] = append(g.sccEdges[e.From.SCC], e)
 		if !e.control {
@@ -471,8 +607,110 @@ func BuildGraph(f *ssa.Function) *Graph {
 	return g
 }
 
+// parseIntConstraint returns an IntParseConstraint if y is the
+// integer result of a call to strconv.ParseInt or strconv.ParseUint
+// with a constant bit size.
+func parseIntConstraint(call *ssa.Call, y *ssa.Extract) Constraint {
+	if y.Index != 0 {
+		return nil
+	}
+	static := call.Common().StaticCallee()
+	if static == nil {
+		return nil
+	}
+	fn, ok := static.Object().(*types.Func)
+	if !ok {
+		return nil
+	}
+	var unsigned bool
+	switch fn.FullName() {
+	case "strconv.ParseInt":
+	case "strconv.ParseUint":
+		unsigned = true
+	default:
+		return nil
+	}
+	k, ok := call.Common().Args[2].(*ssa.Const)
+	if !ok || k.Value == nil {
+		return nil
+	}
+	bitSize, ok := constant.Int64Val(k.Value)
+	if !ok {
+		return nil
+	}
+	return NewIntParseConstraint(int(bitSize), unsigned, y)
+}
+
+// Solve solves the whole graph and returns the ranges of all values.
+// See Query for solving only the parts of the graph that are needed.
 func (g *Graph) Solve() Ranges {
-	var consts []Z
+	for scc := range g.SCCs {
+		if !g.solved[scc] {
+			g.solveSCC(scc)
+		}
+	}
+
+	for v, r := range g.ranges {
+		i, ok := r.(IntInterval)
+		if !ok {
+			continue
+		}
+		g.ranges[v] = g.limitSigned(v, i)
+	}
+
+	return g.ranges
+}
+
+// Query returns the range of v. Ranges are computed on demand, from
+// v backwards: only the strongly connected components that v depends
+// on are solved, and they are solved at most once, no matter how
+// many values are queried.
+func (g *Graph) Query(v ssa.Value) Range {
+	if n, ok := g.Vertices[v]; ok && !g.solved[n.SCC] {
+		for _, scc := range g.dependencies(n.SCC) {
+			g.solveSCC(scc)
+		}
+	}
+	r := g.ranges.Get(v)
+	if i, ok := r.(IntInterval); ok {
+		return g.limitSigned(v, i)
+	}
+	return r
+}
+
+// dependencies returns scc and all the unsolved SCCs it depends on,
+// in topological order. The dependencies of solved SCCs have been
+// solved, too, so the search stops at them.
+func (g *Graph) dependencies(scc int) []int {
+	if g.sccPreds == nil {
+		g.sccPreds = make([][]int, len(g.SCCs))
+		for _, e := range g.Edges {
+			if e.From.SCC != e.To.SCC {
+				g.sccPreds[e.To.SCC] = append(g.sccPreds[e.To.SCC], e.From.SCC)
+			}
+		}
+	}
+	seen := map[int]bool{scc: true}
+	deps := []int{scc}
+	for i := 0; i < len(deps); i++ {
+		for _, pred := range g.sccPreds[deps[i]] {
+			if !seen[pred] && !g.solved[pred] {
+				seen[pred] = true
+				deps = append(deps, pred)
+			}
+		}
+	}
+	// SCCs are numbered in topological order
+	sort.Ints(deps)
+	return deps
+}
+
+// thresholds returns the values that widening stops at.
+func (g *Graph) thresholds() []Z {
+	if g.consts != nil {
+		return g.consts
+	}
+	consts := []Z{}
 	off := NewZ(1)
 	for _, n := range g.Vertices {
 		if c, ok := n.Value.(*ssa.Const); ok {
@@ -489,132 +727,183 @@ func (g *Graph) Solve() Ranges {
 		}
 
 	}
+	consts = append(consts, g.Thresholds...)
 	sort.Sort(Zs(consts))
+	g.consts = consts
+	return consts
+}
 
-	for scc, vertices := range g.SCCs {
-		n := 0
-		n = len(vertices)
-		if n == 1 {
-			g.resolveFutures(scc)
-			v := vertices[0]
-			if v, ok := v.Value.(ssa.Value); ok {
-				switch typ := v.Type().Underlying().(type) {
-				case *types.Basic:
-					switch typ.Kind() {
-					case types.String, types.UntypedString:
-						if !g.Range(v).(StringInterval).IsKnown() {
-							g.SetRange(v, StringInterval{NewIntInterval(NewZ(0), PInfinity)})
-						}
-					default:
-						if !g.Range(v).(IntInterval).IsKnown() {
-							g.SetRange(v, InfinityFor(v))
-						}
-					}
-				case *types.Chan:
-					if !g.Range(v).(ChannelInterval).IsKnown() {
-						g.SetRange(v, ChannelInterval{NewIntInterval(NewZ(0), PInfinity)})
+func (g *Graph) solveSCC(scc int) {
+	g.solved[scc] = true
+	consts := g.thresholds()
+	vertices := g.SCCs[scc]
+	n := 0
+	n = len(vertices)
+	if n == 1 {
+		g.resolveFutures(scc)
+		v := vertices[0]
+		if v, ok := v.Value.(ssa.Value); ok {
+			switch typ := v.Type().Underlying().(type) {
+			case *types.Basic:
+				switch typ.Kind() {
+				case types.String, types.UntypedString:
+					if !g.Range(v).(StringInterval).IsKnown() {
+						g.SetRange(v, StringInterval{Length: NewIntInterval(NewZ(0), PInfinity)})
 					}
-				case *types.Slice:
-					if !g.Range(v).(SliceInterval).IsKnown() {
-						g.SetRange(v, SliceInterval{NewIntInterval(NewZ(0), PInfinity)})
+				default:
+					if !g.Range(v).(IntInterval).IsKnown() {
+						g.SetRange(v, InfinityFor(v))
 					}
 				}
+			case *types.Chan:
+				if !g.Range(v).(ChannelInterval).IsKnown() {
+					g.SetRange(v, ChannelInterval{NewIntInterval(NewZ(0), PInfinity)})
+				}
+			case *types.Slice:
+				if !g.Range(v).(SliceInterval).IsKnown() {
+					g.SetRange(v, SliceInterval{NewIntInterval(NewZ(0), PInfinity)})
+				}
+			case *types.Map:
+				if !g.Range(v).(MapInterval).IsKnown() {
+					g.SetRange(v, MapInterval{NewIntInterval(NewZ(0), PInfinity)})
+				}
 			}
-			if c, ok := v.Value.(Constraint); ok {
-				g.SetRange(c.Y(), c.Eval(g))
-			}
-		} else {
-			uses := g.uses(scc)
-			entries := g.entries(scc)
-			for len(entries) > 0 {
-				v := entries[len(entries)-1]
-				entries = entries[:len(entries)-1]
-				for _, use := range uses[v] {
-					if g.widen(use, consts) {
-						entries = append(entries, use.Y())
-					}
+		}
+		if c, ok := v.Value.(Constraint); ok {
+			g.SetRange(c.Y(), c.Eval(g))
+		}
+	} else {
+		uses := g.uses(scc)
+		entries := g.entries(scc)
+		iterations := 0
+		for len(entries) > 0 {
+			v := entries[len(entries)-1]
+			entries = entries[:len(entries)-1]
+			for _, use := range uses[v] {
+				iterations++
+				thresholds := consts
+				if g.MaxIterations > 0 && iterations > g.MaxIterations {
+					// Out of budget, widen straight to infinity
+					thresholds = nil
+				}
+				if g.widen(use, thresholds) {
+					entries = append(entries, use.Y())
 				}
 			}
+		}
 
-			g.resolveFutures(scc)
+		g.resolveFutures(scc)
 
-			// XXX this seems to be necessary, but shouldn't be.
-			// removing it leads to nil pointer derefs; investigate
-			// where we're not setting values correctly.
-			for _, n := range vertices {
-				if v, ok := n.Value.(ssa.Value); ok {
-					i, ok := g.Range(v).(IntInterval)
-					if !ok {
-						continue
-					}
-					if !i.IsKnown() {
-						g.SetRange(v, InfinityFor(v))
-					}
+		// XXX this seems to be necessary, but shouldn't be.
+		// removing it leads to nil pointer derefs; investigate
+		// where we're not setting values correctly.
+		for _, n := range vertices {
+			if v, ok := n.Value.(ssa.Value); ok {
+				i, ok := g.Range(v).(IntInterval)
+				if !ok {
+					continue
 				}
-			}
-
-			actives := g.actives(scc)
-			for len(actives) > 0 {
-				v := actives[len(actives)-1]
-				actives = actives[:len(actives)-1]
-				for _, use := range uses[v] {
-					if g.narrow(use) {
-						actives = append(actives, use.Y())
-					}
+				if !i.IsKnown() {
+					g.SetRange(v, InfinityFor(v))
 				}
 			}
 		}
-		// propagate scc
-		for _, edge := range g.sccEdges[scc] {
-			if edge.control {
-				continue
-			}
-			if edge.From.SCC == edge.To.SCC {
-				continue
-			}
-			if c, ok := edge.To.Value.(Constraint); ok {
-				g.SetRange(c.Y(), c.Eval(g))
-			}
-			if c, ok := edge.To.Value.(Future); ok {
-				if !c.IsKnown() {
-					c.MarkUnresolved()
+
+		actives := g.actives(scc)
+		iterations = 0
+	narrowing:
+		for len(actives) > 0 {
+			v := actives[len(actives)-1]
+			actives = actives[:len(actives)-1]
+			for _, use := range uses[v] {
+				iterations++
+				if g.MaxIterations > 0 && iterations > g.MaxIterations {
+					// Stopping early is safe, narrowing only
+					// ever improves precision.
+					break narrowing
+				}
+				if g.narrow(use) {
+					actives = append(actives, use.Y())
 				}
 			}
 		}
 	}
-
-	for v, r := range g.ranges {
-		i, ok := r.(IntInterval)
-		if !ok {
+	// propagate scc
+	for _, edge := range g.sccEdges[scc] {
+		if edge.control {
 			continue
 		}
-		if (v.Type().Underlying().(*types.Basic).Info() & types.IsUnsigned) == 0 {
-			if i.Upper != PInfinity {
-				s := &types.StdSizes{
-					// XXX is it okay to assume the largest word size, or do we
-					// need to be platform specific?
-					WordSize: 8,
-					MaxAlign: 1,
-				}
-				bits := (s.Sizeof(v.Type()) * 8) - 1
-				n := big.NewInt(1)
-				n = n.Lsh(n, uint(bits))
-				upper, lower := &big.Int{}, &big.Int{}
-				upper.Sub(n, big.NewInt(1))
-				lower.Neg(n)
-
-				if i.Upper.Cmp(NewBigZ(upper)) == 1 {
-					i = NewIntInterval(NInfinity, PInfinity)
-				} else if i.Lower.Cmp(NewBigZ(lower)) == -1 {
-					i = NewIntInterval(NInfinity, PInfinity)
-				}
+		if edge.From.SCC == edge.To.SCC {
+			continue
+		}
+		if c, ok := edge.To.Value.(Constraint); ok {
+			g.SetRange(c.Y(), c.Eval(g))
+		}
+		if c, ok := edge.To.Value.(Future); ok {
+			if !c.IsKnown() {
+				c.MarkUnresolved()
 			}
 		}
+	}
+
+}
 
-		g.ranges[v] = i
+// limitSigned widens signed integers whose ranges exceed their types
+// to infinity.
+func (g *Graph) limitSigned(v ssa.Value, i IntInterval) IntInterval {
+	if (v.Type().Underlying().(*types.Basic).Info() & types.IsUnsigned) != 0 {
+		return i
 	}
+	if i.Upper == PInfinity {
+		return i
+	}
+	bits := (g.Sizes.Sizeof(v.Type()) * 8) - 1
+	n := big.NewInt(1)
+	n = n.Lsh(n, uint(bits))
+	upper, lower := &big.Int{}, &big.Int{}
+	upper.Sub(n, big.NewInt(1))
+	lower.Neg(n)
+
+	// Infinite bounds only mean that nothing is known about that
+	// side of the interval.
+	if i.Upper.Cmp(NewBigZ(upper)) == 1 {
+		return NewIntInterval(NInfinity, PInfinity)
+	} else if !i.Lower.Infinite() && i.Lower.Cmp(NewBigZ(lower)) == -1 {
+		return NewIntInterval(NInfinity, PInfinity)
+	}
+	return i
+}
 
-	return g.ranges
+// ForFunction builds the constraint graph of fn, which is solved on
+// demand as ranges are queried. It models integer wrap-around for the
+// architecture being built for, but doesn't propagate ranges across
+// function calls.
+func ForFunction(fn *ssa.Function) *Graph {
+	g := BuildGraph(fn)
+	g.Wrap = true
+	return g
+}
+
+// RangeOf returns the range of v. For strings, slices and maps, it is
+// the range of their lengths, and for channels the range of their buffer
+// sizes. The returned interval is not known if v isn't an integer,
+// string, slice, map or channel. Like Query, it only solves the parts
+// of the graph that v depends on.
+func (g *Graph) RangeOf(v ssa.Value) IntInterval {
+	switch r := g.Query(v).(type) {
+	case IntInterval:
+		return r
+	case StringInterval:
+		return r.Length
+	case SliceInterval:
+		return r.Length
+	case MapInterval:
+		return r.Length
+	case ChannelInterval:
+		return r.Size
+	default:
+		return IntInterval{}
+	}
 }
 
 func VertexString(v *Vertex) string {
@@ -660,37 +949,119 @@ func (r Ranges) Get(x ssa.Value) Range {
 			return ChannelInterval{}
 		case *types.Slice:
 			return SliceInterval{}
+		case *types.Map:
+			return MapInterval{}
 		}
 	}
 	return i
 }
 
 type Graph struct {
+	// Sizes determines the sizes of integer types. BuildGraph
+	// initializes it for the architecture that is being built for.
+	Sizes types.Sizes
+
 	Vertices map[interface{}]*Vertex
 	Edges    []Edge
 	SCCs     [][]*Vertex
 	ranges   Ranges
 
+	// Wrap causes the results of integer arithmetic and conversions
+	// to be limited to the values representable by their types,
+	// modeling wrap-around instead of computing with unbounded
+	// integers.
+	Wrap     bool
+	wrapping map[ssa.Value]Wrapping
+
+	// Thresholds are values that widening stops at before jumping
+	// to infinity, in addition to the constants used by the function
+	// and their neighbours.
+	Thresholds []Z
+	// MaxIterations limits the number of constraint evaluations per
+	// strongly connected component in each of the widening and
+	// narrowing phases. Once it is exceeded, widening jumps straight
+	// to infinity and narrowing stops. Zero means no limit.
+	MaxIterations int
+
+	// Summaries, if set, is used to compute the ranges of the
+	// results of calls to functions with known bodies.
+	Summaries *Summaries
+	stack     []*summaryFrame
+
 	// map SCCs to futures
 	futures [][]Future
 	// map SCCs to edges
 	sccEdges [][]Edge
+	// map SCCs to the SCCs they depend on
+	sccPreds [][]int
+	solved   []bool
+	consts   []Z
+}
+
+type byVertex []*Vertex
+
+func (vs byVertex) Len() int      { return len(vs) }
+func (vs byVertex) Swap(i, j int) { vs[i], vs[j] = vs[j], vs[i] }
+func (vs byVertex) Less(i, j int) bool {
+	if vs[i].SCC != vs[j].SCC {
+		return vs[i].SCC < vs[j].SCC
+	}
+	return VertexString(vs[i]) < VertexString(vs[j])
 }
 
+// Graphviz returns the constraint graph in the Graphviz dot format.
 func (g Graph) Graphviz() string {
+	buf := &bytes.Buffer{}
+	g.WriteDot(buf)
+	return buf.String()
+}
+
+// WriteDot writes the constraint graph in the Graphviz dot format.
+// Variables are drawn as ovals labeled with their current ranges and
+// constraints as boxes; vertices belonging to the same strongly
+// connected component are grouped in a cluster. Edges from the
+// variables that a future depends on are dashed, and unresolved
+// futures are drawn with a dashed border.
+func (g *Graph) WriteDot(w io.Writer) error {
+	ids := map[*Vertex]int{}
+	var vertices []*Vertex
+	for _, v := range g.Vertices {
+		vertices = append(vertices, v)
+	}
+	sort.Sort(byVertex(vertices))
+
 	var lines []string
 	lines = append(lines, "digraph{")
-	ids := map[interface{}]int{}
-	i := 1
-	for _, v := range g.Vertices {
-		ids[v] = i
+	for i, v := range vertices {
+		if i == 0 || vertices[i-1].SCC != v.SCC {
+			if i > 0 && len(g.SCCs[vertices[i-1].SCC]) > 1 {
+				lines = append(lines, "}")
+			}
+			if len(g.SCCs[v.SCC]) > 1 {
+				lines = append(lines, fmt.Sprintf(`subgraph cluster_%d {`, v.SCC))
+				lines = append(lines, fmt.Sprintf(`label="SCC %d"`, v.SCC))
+			}
+		}
+		ids[v] = i + 1
 		shape := "box"
-		if _, ok := v.Value.(ssa.Value); ok {
+		style := "filled"
+		label := VertexString(v)
+		switch value := v.Value.(type) {
+		case ssa.Value:
 			shape = "oval"
+			if r := g.Range(value); r != nil {
+				label = fmt.Sprintf("%s: %s", label, r)
+			}
+		case Future:
+			if !value.IsResolved() {
+				style = "filled,dashed"
+			}
 		}
-		lines = append(lines, fmt.Sprintf(`n%d [shape="%s", label=%q, colorscheme=spectral11, style="filled", fillcolor="%d"]`,
-			i, shape, VertexString(v), (v.SCC%11)+1))
-		i++
+		lines = append(lines, fmt.Sprintf(`n%d [shape="%s", label=%q, colorscheme=spectral11, style="%s", fillcolor="%d"]`,
+			ids[v], shape, label, style, (v.SCC%11)+1))
+	}
+	if len(vertices) > 0 && len(g.SCCs[vertices[len(vertices)-1].SCC]) > 1 {
+		lines = append(lines, "}")
 	}
 	for _, e := range g.Edges {
 		style := "solid"
@@ -700,7 +1071,14 @@ func (g Graph) Graphviz() string {
 		lines = append(lines, fmt.Sprintf(`n%d -> n%d [style="%s"]`, ids[e.From], ids[e.To], style))
 	}
 	lines = append(lines, "}")
-	return strings.Join(lines, "\n")
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// Wrapping reports whether the integer operation or conversion x
+// wraps around. It requires Wrap to be set before calling Solve.
+func (g *Graph) Wrapping(x ssa.Value) Wrapping {
+	return g.wrapping[x]
 }
 
 func (g *Graph) SetRange(x ssa.Value, r Range) {
@@ -711,6 +1089,14 @@ func (g *Graph) Range(x ssa.Value) Range {
 	return g.ranges.Get(x)
 }
 
+// widen updates the range of c's result after re-evaluating c. A
+// bound that grows doesn't grow one step at a time, which wouldn't
+// terminate for loops, but jumps to the nearest of the sorted
+// thresholds consts that contains the new bound: the largest
+// threshold at or below a falling lower bound, and the smallest at or
+// above a rising upper bound. Without a suitable threshold, the bound
+// jumps to infinity. Picking the nearest threshold, rather than the
+// most distant one, keeps bounds such as loop limits precise.
 func (g *Graph) widen(c Constraint, consts []Z) bool {
 	setRange := func(i Range) {
 		g.SetRange(c.Y(), i)
@@ -721,8 +1107,8 @@ func (g *Graph) widen(c Constraint, consts []Z) bool {
 		}
 		nlc := NInfinity
 		nuc := PInfinity
-		for _, co := range consts {
-			if co.Cmp(ni.Lower) <= 0 {
+		for i := len(consts) - 1; i >= 0; i-- {
+			if co := consts[i]; co.Cmp(ni.Lower) <= 0 {
 				nlc = co
 				break
 			}
@@ -737,14 +1123,29 @@ func (g *Graph) widen(c Constraint, consts []Z) bool {
 		if !oi.IsKnown() {
 			return ni, true
 		}
+		if ni.Empty() {
+			// Nothing to add, e.g. because the value is only
+			// defined on a branch that hasn't been found reachable
+			// yet.
+			return oi, false
+		}
+		if oi.Empty() {
+			return ni, true
+		}
+		// The congruence can only get weaker, which guarantees
+		// termination.
+		m, r := oi.unionCongruence(ni)
 		if ni.Lower.Cmp(oi.Lower) == -1 && ni.Upper.Cmp(oi.Upper) == 1 {
-			return NewIntInterval(nlc, nuc), true
+			return NewIntInterval(nlc, nuc).withCongruence(m, r), true
 		}
 		if ni.Lower.Cmp(oi.Lower) == -1 {
-			return NewIntInterval(nlc, oi.Upper), true
+			return NewIntInterval(nlc, oi.Upper).withCongruence(m, r), true
 		}
 		if ni.Upper.Cmp(oi.Upper) == 1 {
-			return NewIntInterval(oi.Lower, nuc), true
+			return NewIntInterval(oi.Lower, nuc).withCongruence(m, r), true
+		}
+		if si := oi.withCongruence(m, r); !sameCongruence(si.Congruence, oi.Congruence) {
+			return si, true
 		}
 		return oi, false
 	}
@@ -760,8 +1161,12 @@ func (g *Graph) widen(c Constraint, consts []Z) bool {
 	case StringInterval:
 		ni := c.Eval(g).(StringInterval)
 		si, changed := widenIntInterval(oi.Length, ni.Length)
-		if changed {
-			setRange(StringInterval{si})
+		prefix, suffix := ni.Prefix, ni.Suffix
+		if oi.Length.IsKnown() && !oi.Length.Empty() {
+			prefix, suffix = commonPrefix(oi.Prefix, prefix), commonSuffix(oi.Suffix, suffix)
+		}
+		if changed || prefix != oi.Prefix || suffix != oi.Suffix {
+			setRange(StringInterval{si, prefix, suffix})
 			return true
 		}
 		return false
@@ -773,6 +1178,14 @@ func (g *Graph) widen(c Constraint, consts []Z) bool {
 			return true
 		}
 		return false
+	case MapInterval:
+		ni := c.Eval(g).(MapInterval)
+		si, changed := widenIntInterval(oi.Length, ni.Length)
+		if changed {
+			setRange(MapInterval{si})
+			return true
+		}
+		return false
 	default:
 		return false
 	}
@@ -780,22 +1193,30 @@ func (g *Graph) widen(c Constraint, consts []Z) bool {
 
 func (g *Graph) narrow(c Constraint) bool {
 	narrowIntInterval := func(oi, ni IntInterval) (IntInterval, bool) {
+		if ni.Empty() {
+			// The value is only defined on an unreachable branch.
+			return ni, !oi.Empty()
+		}
+		if oi.Empty() {
+			return oi, false
+		}
 		oLower := oi.Lower
 		oUpper := oi.Upper
 		nLower := ni.Lower
 		nUpper := ni.Upper
 
+		m, r := ni.congruence()
 		if oLower == NInfinity && nLower != NInfinity {
-			return NewIntInterval(nLower, oUpper), true
+			return NewIntInterval(nLower, oUpper).withCongruence(m, r), true
 		}
 		if oUpper == PInfinity && nUpper != PInfinity {
-			return NewIntInterval(oLower, nUpper), true
+			return NewIntInterval(oLower, nUpper).withCongruence(m, r), true
 		}
 		if oLower.Cmp(nLower) == 1 {
-			return NewIntInterval(nLower, oUpper), true
+			return NewIntInterval(nLower, oUpper).withCongruence(m, r), true
 		}
 		if oUpper.Cmp(nUpper) == -1 {
-			return NewIntInterval(oLower, nUpper), true
+			return NewIntInterval(oLower, nUpper).withCongruence(m, r), true
 		}
 		return oi, false
 	}
@@ -812,7 +1233,7 @@ func (g *Graph) narrow(c Constraint) bool {
 		ni := c.Eval(g).(StringInterval)
 		si, changed := narrowIntInterval(oi.Length, ni.Length)
 		if changed {
-			g.SetRange(c.Y(), StringInterval{si})
+			g.SetRange(c.Y(), StringInterval{si, oi.Prefix, oi.Suffix}.truncateAffixes())
 			return true
 		}
 		return false
@@ -824,6 +1245,14 @@ func (g *Graph) narrow(c Constraint) bool {
 			return true
 		}
 		return false
+	case MapInterval:
+		ni := c.Eval(g).(MapInterval)
+		si, changed := narrowIntInterval(oi.Length, ni.Length)
+		if changed {
+			g.SetRange(c.Y(), MapInterval{si})
+			return true
+		}
+		return false
 	default:
 		return false
 	}
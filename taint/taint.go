@@ -0,0 +1,416 @@
+// Package taint implements a configurable taint analysis over SSA.
+//
+// Values originating from sources, such as HTTP requests or
+// environment variables, are tainted. Taint propagates through
+// operations on tainted values, most function calls and stores into
+// memory, until it reaches a sink, such as the command line of a
+// process or an SQL query. Calls to sanitizers produce untainted
+// values.
+//
+// The analysis is intraprocedural and flow-insensitive. Parameters
+// are untainted unless their type is a source type, and memory is
+// modeled by marking the root of an address (usually a local
+// variable) as tainted when a tainted value is stored anywhere in it.
+package taint // import "honnef.co/go/tools/taint"
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"honnef.co/go/tools/ssa"
+)
+
+// Directive marks a function as a sanitizer when it appears on its own
+// line in the function's doc comment. As it is looked up in the syntax
+// of the function, it is only recognized in programs built with
+// ssa.GlobalDebug.
+const Directive = "//taint:sanitizer"
+
+// A Sink is a function that must not be called with tainted
+// arguments.
+type Sink struct {
+	// Func is the full name of the function, as returned by
+	// types.Func.FullName, e.g. "os/exec.Command" or
+	// "(*database/sql.DB).Query".
+	Func string
+	// Args are the indices of the arguments that must not be
+	// tainted. For methods, index 0 is the receiver.
+	Args []int
+	// Kind groups related sinks, e.g. "sql" or "exec". The checks
+	// in staticcheck use the kinds "exec", "sql", "template",
+	// "template-data" and "log".
+	Kind string
+}
+
+// Config describes the sources, sinks and sanitizers of an analysis.
+type Config struct {
+	// SourceFuncs are functions whose results are tainted.
+	SourceFuncs []string
+	// SourceTypes are types, as returned by types.TypeString with
+	// full package paths, whose values are tainted wherever they
+	// come from, e.g. "*net/http.Request".
+	SourceTypes []string
+	// Sanitizers are functions whose results are never tainted.
+	Sanitizers []string
+	Sinks      []Sink
+}
+
+// DefaultConfig returns a configuration for common sources, sinks and
+// sanitizers in the standard library.
+func DefaultConfig() *Config {
+	return &Config{
+		SourceFuncs: []string{
+			"os.Getenv",
+			"os.LookupEnv",
+			"os.Environ",
+			"io/ioutil.ReadFile",
+			"io/ioutil.ReadAll",
+			"(*bufio.Reader).ReadString",
+			"(*bufio.Reader).ReadLine",
+			"(*bufio.Reader).ReadBytes",
+			"(*bufio.Scanner).Text",
+			"(*bufio.Scanner).Bytes",
+			"(*net/http.Request).FormValue",
+			"(*net/http.Request).PostFormValue",
+			"(*net/http.Request).Cookie",
+			"(*net/http.Request).Cookies",
+			"(*net/http.Request).Referer",
+			"(*net/http.Request).UserAgent",
+		},
+		SourceTypes: []string{
+			"*net/http.Request",
+		},
+		Sanitizers: []string{
+			"strconv.Atoi",
+			"strconv.ParseInt",
+			"strconv.ParseUint",
+			"strconv.ParseFloat",
+			"strconv.ParseBool",
+			"strconv.Quote",
+			"html.EscapeString",
+			"html/template.HTMLEscapeString",
+			"html/template.JSEscapeString",
+			"net/url.QueryEscape",
+			"net/url.PathEscape",
+			"path/filepath.Base",
+			"path.Base",
+		},
+		Sinks: []Sink{
+			{Func: "os/exec.Command", Args: []int{0, 1}, Kind: "exec"},
+			{Func: "os.StartProcess", Args: []int{0, 1}, Kind: "exec"},
+			{Func: "syscall.Exec", Args: []int{0, 1}, Kind: "exec"},
+
+			{Func: "(*database/sql.DB).Query", Args: []int{1}, Kind: "sql"},
+			{Func: "(*database/sql.DB).QueryRow", Args: []int{1}, Kind: "sql"},
+			{Func: "(*database/sql.DB).Exec", Args: []int{1}, Kind: "sql"},
+			{Func: "(*database/sql.DB).Prepare", Args: []int{1}, Kind: "sql"},
+			{Func: "(*database/sql.DB).QueryContext", Args: []int{2}, Kind: "sql"},
+			{Func: "(*database/sql.DB).QueryRowContext", Args: []int{2}, Kind: "sql"},
+			{Func: "(*database/sql.DB).ExecContext", Args: []int{2}, Kind: "sql"},
+			{Func: "(*database/sql.DB).PrepareContext", Args: []int{2}, Kind: "sql"},
+			{Func: "(*database/sql.Tx).Query", Args: []int{1}, Kind: "sql"},
+			{Func: "(*database/sql.Tx).QueryRow", Args: []int{1}, Kind: "sql"},
+			{Func: "(*database/sql.Tx).Exec", Args: []int{1}, Kind: "sql"},
+			{Func: "(*database/sql.Tx).Prepare", Args: []int{1}, Kind: "sql"},
+			{Func: "(*database/sql.Tx).QueryContext", Args: []int{2}, Kind: "sql"},
+			{Func: "(*database/sql.Tx).QueryRowContext", Args: []int{2}, Kind: "sql"},
+			{Func: "(*database/sql.Tx).ExecContext", Args: []int{2}, Kind: "sql"},
+			{Func: "(*database/sql.Tx).PrepareContext", Args: []int{2}, Kind: "sql"},
+
+			{Func: "(*text/template.Template).Parse", Args: []int{1}, Kind: "template"},
+			{Func: "(*html/template.Template).Parse", Args: []int{1}, Kind: "template"},
+			{Func: "(*text/template.Template).Execute", Args: []int{2}, Kind: "template-data"},
+			{Func: "(*text/template.Template).ExecuteTemplate", Args: []int{3}, Kind: "template-data"},
+
+			{Func: "log.Fatal", Args: []int{0}, Kind: "log"},
+			{Func: "log.Fatalf", Args: []int{0, 1}, Kind: "log"},
+			{Func: "log.Fatalln", Args: []int{0}, Kind: "log"},
+			{Func: "log.Panic", Args: []int{0}, Kind: "log"},
+			{Func: "log.Panicf", Args: []int{0, 1}, Kind: "log"},
+			{Func: "log.Panicln", Args: []int{0}, Kind: "log"},
+			{Func: "log.Print", Args: []int{0}, Kind: "log"},
+			{Func: "log.Printf", Args: []int{0, 1}, Kind: "log"},
+			{Func: "log.Println", Args: []int{0}, Kind: "log"},
+			{Func: "(*log.Logger).Fatal", Args: []int{1}, Kind: "log"},
+			{Func: "(*log.Logger).Fatalf", Args: []int{1, 2}, Kind: "log"},
+			{Func: "(*log.Logger).Fatalln", Args: []int{1}, Kind: "log"},
+			{Func: "(*log.Logger).Panic", Args: []int{1}, Kind: "log"},
+			{Func: "(*log.Logger).Panicf", Args: []int{1, 2}, Kind: "log"},
+			{Func: "(*log.Logger).Panicln", Args: []int{1}, Kind: "log"},
+			{Func: "(*log.Logger).Print", Args: []int{1}, Kind: "log"},
+			{Func: "(*log.Logger).Printf", Args: []int{1, 2}, Kind: "log"},
+			{Func: "(*log.Logger).Println", Args: []int{1}, Kind: "log"},
+
+			{Func: "os.Open", Args: []int{0}, Kind: "path"},
+			{Func: "os.OpenFile", Args: []int{0}, Kind: "path"},
+			{Func: "os.Create", Args: []int{0}, Kind: "path"},
+			{Func: "os.Remove", Args: []int{0}, Kind: "path"},
+			{Func: "os.RemoveAll", Args: []int{0}, Kind: "path"},
+			{Func: "io/ioutil.ReadFile", Args: []int{0}, Kind: "path"},
+			{Func: "io/ioutil.WriteFile", Args: []int{0}, Kind: "path"},
+			{Func: "net/http.ServeFile", Args: []int{2}, Kind: "path"},
+		},
+	}
+}
+
+// A Flow is a tainted value reaching a sink.
+type Flow struct {
+	Sink *Sink
+	Call ssa.CallInstruction
+	// Arg is the index of the tainted argument.
+	Arg int
+}
+
+// Analysis holds the taint of all values in a function.
+type Analysis struct {
+	Fn    *ssa.Function
+	Flows []Flow
+
+	sources     map[string]bool
+	sourceTypes map[string]bool
+	sanitizers  map[string]bool
+	sinks       map[string]*Sink
+	tainted     map[ssa.Value]bool
+}
+
+// Analyze computes the taint of all values in fn and records all flows
+// of tainted values into sinks.
+func Analyze(fn *ssa.Function, cfg *Config) *Analysis {
+	a := &Analysis{
+		Fn:          fn,
+		sources:     set(cfg.SourceFuncs),
+		sourceTypes: set(cfg.SourceTypes),
+		sanitizers:  set(cfg.Sanitizers),
+		sinks:       map[string]*Sink{},
+		tainted:     map[ssa.Value]bool{},
+	}
+	for i := range cfg.Sinks {
+		a.sinks[cfg.Sinks[i].Func] = &cfg.Sinks[i]
+	}
+	a.propagate()
+	a.findFlows()
+	return a
+}
+
+func set(l []string) map[string]bool {
+	m := make(map[string]bool, len(l))
+	for _, s := range l {
+		m[s] = true
+	}
+	return m
+}
+
+// Tainted reports whether v, a value in the analyzed function, may
+// contain tainted data.
+func (a *Analysis) Tainted(v ssa.Value) bool {
+	return a.tainted[v]
+}
+
+func (a *Analysis) isSourceType(T types.Type) bool {
+	return a.sourceTypes[types.TypeString(T, nil)]
+}
+
+// canCarry reports whether values of type T can carry tainted data.
+// Numbers and booleans can't be used to inject anything.
+func canCarry(T types.Type) bool {
+	basic, ok := T.Underlying().(*types.Basic)
+	if !ok {
+		return true
+	}
+	return basic.Info()&(types.IsNumeric|types.IsBoolean) == 0
+}
+
+// root returns the variable or global that addr points into.
+func root(addr ssa.Value) ssa.Value {
+	for {
+		switch v := addr.(type) {
+		case *ssa.FieldAddr:
+			addr = v.X
+		case *ssa.IndexAddr:
+			addr = v.X
+		case *ssa.Slice:
+			addr = v.X
+		default:
+			return addr
+		}
+	}
+}
+
+func (a *Analysis) taint(v ssa.Value) bool {
+	if v == nil || a.tainted[v] || !canCarry(v.Type()) {
+		return false
+	}
+	a.tainted[v] = true
+	return true
+}
+
+func (a *Analysis) propagate() {
+	for _, param := range a.Fn.Params {
+		if a.isSourceType(param.Type()) {
+			a.taint(param)
+		}
+	}
+	for _, fv := range a.Fn.FreeVars {
+		if a.isSourceType(fv.Type()) {
+			a.taint(fv)
+		}
+	}
+
+	// Iterate until we reach a fixed point; stores and phi nodes
+	// may propagate taint to values we've already visited.
+	for changed := true; changed; {
+		changed = false
+		for _, b := range a.Fn.Blocks {
+			for _, instr := range b.Instrs {
+				if a.visit(instr) {
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+func (a *Analysis) anyTainted(vs ...ssa.Value) bool {
+	for _, v := range vs {
+		if a.tainted[v] {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Analysis) visit(instr ssa.Instruction) bool {
+	switch v := instr.(type) {
+	case *ssa.Store:
+		if a.tainted[v.Val] {
+			return a.taint(root(v.Addr))
+		}
+		return false
+	case *ssa.Call:
+		return a.visitCall(v, v.Common())
+	case *ssa.Alloc:
+		return false
+	case *ssa.UnOp:
+		if v.Op == token.MUL && a.tainted[root(v.X)] {
+			return a.taint(v)
+		}
+	case *ssa.FieldAddr, *ssa.IndexAddr, *ssa.Slice:
+		// The address of a part of a tainted variable is tainted, so
+		// that loads from it are, too.
+		if a.tainted[root(v.(ssa.Value))] {
+			return a.taint(v.(ssa.Value))
+		}
+	}
+
+	v, ok := instr.(ssa.Value)
+	if !ok {
+		return false
+	}
+	if a.isSourceType(v.Type()) {
+		return a.taint(v)
+	}
+	for _, op := range instr.Operands(nil) {
+		if *op != nil && a.tainted[*op] {
+			return a.taint(v)
+		}
+	}
+	return false
+}
+
+func (a *Analysis) visitCall(call *ssa.Call, common *ssa.CallCommon) bool {
+	name := callName(common)
+	if a.sanitizers[name] || isSanitizerFunc(common.StaticCallee()) {
+		return false
+	}
+	if a.sources[name] {
+		return a.taint(call)
+	}
+
+	args := common.Args
+	if common.IsInvoke() {
+		args = append([]ssa.Value{common.Value}, args...)
+	}
+	if !a.anyTainted(args...) {
+		return false
+	}
+	changed := a.taint(call)
+	if b, ok := common.Value.(*ssa.Builtin); ok && b.Name() == "copy" {
+		// copy(dst, src) taints the destination.
+		if a.tainted[common.Args[1]] {
+			changed = a.taint(root(common.Args[0])) || changed
+		}
+	}
+	return changed
+}
+
+// IsSanitizer reports whether call calls one of the configured
+// sanitizers or a function marked with Directive.
+func (cfg *Config) IsSanitizer(call *ssa.CallCommon) bool {
+	name := callName(call)
+	for _, sanitizer := range cfg.Sanitizers {
+		if name == sanitizer {
+			return true
+		}
+	}
+	return isSanitizerFunc(call.StaticCallee())
+}
+
+func isSanitizerFunc(fn *ssa.Function) bool {
+	if fn == nil {
+		return false
+	}
+	decl, ok := fn.Syntax().(*ast.FuncDecl)
+	if !ok || decl.Doc == nil {
+		return false
+	}
+	for _, c := range decl.Doc.List {
+		if strings.TrimSpace(c.Text) == Directive {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Analysis) findFlows() {
+	for _, b := range a.Fn.Blocks {
+		for _, instr := range b.Instrs {
+			call, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			common := call.Common()
+			sink, ok := a.sinks[callName(common)]
+			if !ok {
+				continue
+			}
+			args := common.Args
+			if common.IsInvoke() {
+				args = append([]ssa.Value{common.Value}, args...)
+			}
+			for _, i := range sink.Args {
+				if i < len(args) && a.tainted[args[i]] {
+					a.Flows = append(a.Flows, Flow{Sink: sink, Call: call, Arg: i})
+				}
+			}
+		}
+	}
+}
+
+// callName returns the full name of the called function or method,
+// or the empty string for dynamic calls of function values.
+func callName(call *ssa.CallCommon) string {
+	if call.IsInvoke() {
+		return call.Method.FullName()
+	}
+	switch v := call.Value.(type) {
+	case *ssa.Function:
+		if fn, ok := v.Object().(*types.Func); ok {
+			return fn.FullName()
+		}
+	case *ssa.Builtin:
+		return v.Name()
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package taint_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/loader"
+	"honnef.co/go/tools/ssa"
+	"honnef.co/go/tools/ssa/ssautil"
+	"honnef.co/go/tools/taint"
+)
+
+var flowRe = regexp.MustCompile(`^flow (\d+)$`)
+
+// load loads and builds testdata/taint.go as the package main.
+func load(t *testing.T) (*loader.Program, *ast.File, *ssa.Package) {
+	conf := loader.Config{ParserMode: parser.ParseComments}
+	f, err := conf.ParseFile("testdata/taint.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// like the lint framework, keep the syntax of functions, which
+	// Directive is looked up in
+	prog := ssautil.CreateProgram(iprog, ssa.GlobalDebug)
+	pkg := prog.Package(iprog.Created[0].Pkg)
+	pkg.Build()
+	return iprog, f, pkg
+}
+
+func TestAnalyze(t *testing.T) {
+	iprog, f, pkg := load(t)
+	fset := iprog.Fset
+
+	cfg := taint.DefaultConfig()
+	cfg.SourceFuncs = append(cfg.SourceFuncs, "main.source")
+	cfg.Sanitizers = append(cfg.Sanitizers, "main.clean")
+	cfg.Sinks = append(cfg.Sinks, taint.Sink{Func: "main.sink", Args: []int{0}, Kind: "test"})
+
+	// the expected flows, as line:arg
+	want := map[string]bool{}
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if m := flowRe.FindStringSubmatch(text); m != nil {
+				want[fmt.Sprintf("%d:%s", fset.Position(c.Pos()).Line, m[1])] = true
+			}
+		}
+	}
+	if len(want) == 0 {
+		t.Fatal("no expected flows")
+	}
+
+	got := map[string]bool{}
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		fn := pkg.Func(decl.Name.Name)
+		fns := append([]*ssa.Function{fn}, fn.AnonFuncs...)
+		for _, fn := range fns {
+			a := taint.Analyze(fn, cfg)
+			for _, flow := range a.Flows {
+				line := fset.Position(flow.Call.Pos()).Line
+				got[fmt.Sprintf("%d:%d", line, flow.Arg)] = true
+				if flow.Sink == nil || flow.Sink.Kind == "" {
+					t.Errorf("%s: flow on line %d has no sink", fn, line)
+				}
+			}
+		}
+	}
+
+	for _, k := range sortedKeys(want) {
+		if !got[k] {
+			t.Errorf("missing flow at line:arg %s", k)
+		}
+	}
+	for _, k := range sortedKeys(got) {
+		if !want[k] {
+			t.Errorf("unexpected flow at line:arg %s", k)
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestIsSanitizer(t *testing.T) {
+	_, _, pkg := load(t)
+
+	cfg := taint.DefaultConfig()
+	cfg.Sanitizers = append(cfg.Sanitizers, "main.clean")
+	want := map[string]bool{
+		"main.clean":        true,
+		"main.escape":       true,
+		"main.notSanitizer": false,
+		"strconv.Atoi":      true,
+		"strconv.Itoa":      false,
+	}
+	seen := map[string]bool{}
+	for _, mem := range pkg.Members {
+		fn, ok := mem.(*ssa.Function)
+		if !ok {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				callee := call.Common().StaticCallee()
+				if callee == nil {
+					continue
+				}
+				name := callee.RelString(nil)
+				w, ok := want[name]
+				if !ok {
+					continue
+				}
+				seen[name] = true
+				if got := cfg.IsSanitizer(call.Common()); got != w {
+					t.Errorf("IsSanitizer(%s) = %t, want %t", name, got, w)
+				}
+			}
+		}
+	}
+	for name := range want {
+		if !seen[name] {
+			t.Errorf("no call of %s", name)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// +build ignore
+
+package main
+
+// This file is the input to TestAnalyze in taint_test.go, which uses
+// the default configuration extended by the source main.source, the
+// sanitizer main.clean and the sink main.sink, whose first argument
+// must not be tainted.
+//
+// A comment 'flow N' on a line expects the analysis to find a flow of
+// a tainted value into the Nth argument of a sink called on that line.
+// There must be no other flows.
+
+import (
+	"bufio"
+	"database/sql"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+func source() string { return "" }
+
+func clean(s string) string { return s }
+
+//taint:sanitizer
+func escape(s string) string { return s }
+
+func notSanitizer(s string) string { return s }
+
+func sink(s string, untainted string) {}
+
+// -------- Positive cases --------
+
+func Direct() {
+	sink(source(), "") // flow 0
+}
+
+func Concat() {
+	s := "echo " + source()
+	sink(s, "") // flow 0
+}
+
+func Env() {
+	exec.Command("sh", "-c", os.Getenv("CMD")) // flow 1
+}
+
+func Request(db *sql.DB, r *http.Request) {
+	db.Query("SELECT * FROM t WHERE name = '" + r.FormValue("name") + "'") // flow 1
+	db.Query(r.URL.Path)                                                   // flow 1
+}
+
+func Scanner(sc *bufio.Scanner) {
+	for sc.Scan() {
+		exec.Command(sc.Text()) // flow 0
+	}
+}
+
+func Memory() {
+	var parts []string
+	parts = append(parts, source())
+	sink(parts[0], "") // flow 0
+
+	var st struct{ s string }
+	st.s = source()
+	sink(st.s, "") // flow 0
+
+	buf := make([]byte, 10)
+	copy(buf, source())
+	sink(string(buf), "") // flow 0
+}
+
+func Phi(b bool) {
+	s := "default"
+	if b {
+		s = source()
+	}
+	sink(s, "") // flow 0
+}
+
+func Unknown() {
+	// calls of other functions propagate taint
+	sink(notSanitizer(source()), "") // flow 0
+}
+
+// -------- Negative cases --------
+
+func Constant() {
+	sink("ls", "")
+	exec.Command("ls", "-l")
+}
+
+func OtherArgument() {
+	sink("", source())
+}
+
+func Param(s string) {
+	// parameters are untainted unless their type is a source type
+	sink(s, "")
+}
+
+func Numbers() {
+	n := len(source())
+	sink(strconv.Itoa(n), "")
+}
+
+// -------- Sanitizers --------
+
+func Sanitized() {
+	sink(clean(source()), "")
+}
+
+func Directive() {
+	sink(escape(source()), "")
+}
+
+func Parsed() {
+	n, _ := strconv.Atoi(os.Getenv("N"))
+	exec.Command("sleep", strconv.Itoa(n))
+}
+
+func PartlySanitized() {
+	s := clean(source())
+	sink(s+source(), "") // flow 0
+}
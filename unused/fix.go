@@ -0,0 +1,214 @@
+package unused
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"honnef.co/go/tools/lint"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// removal returns the range of source that has to be deleted to
+// remove the declaration of obj, including its documentation. If all
+// names declared by obj's declaration are in unused, the whole
+// declaration is removed. ok is false if the declaration can't be
+// removed on its own without affecting other code: fields change the
+// layout and literals of their structs, specs may declare several
+// names at once, constant groups may depend on iota or implicit
+// repetition, and variable initializers may have side effects.
+func removal(j *lint.Job, obj types.Object, unused map[types.Object]bool) (pos, end token.Pos, ok bool) {
+	f := j.File(obj)
+	if f == nil {
+		return 0, 0, false
+	}
+	path, _ := astutil.PathEnclosingInterval(f, obj.Pos(), obj.Pos())
+	if len(path) < 3 {
+		return 0, 0, false
+	}
+	if ident, ok := path[0].(*ast.Ident); !ok || ident.Pos() != obj.Pos() {
+		return 0, 0, false
+	}
+
+	if decl, ok := path[1].(*ast.FuncDecl); ok {
+		pos, end = withDoc(decl.Doc, decl), decl.End()
+		return separate(j, decls(path[2]), decl, pos, end)
+	}
+	gen, ok := path[2].(*ast.GenDecl)
+	if !ok {
+		return 0, 0, false
+	}
+	switch spec := path[1].(type) {
+	case *ast.TypeSpec:
+		return removeSpec(j, path, spec, spec.Doc, spec.Comment, allUnused(j, gen, unused))
+	case *ast.ValueSpec:
+		if gen.Tok == token.VAR {
+			for _, v := range spec.Values {
+				if hasSideEffects(j, v) {
+					return 0, 0, false
+				}
+			}
+		}
+		if allUnused(j, gen, unused) && !anySideEffects(j, gen) {
+			return removeSpec(j, path, spec, spec.Doc, spec.Comment, true)
+		}
+		if len(spec.Names) != 1 {
+			return 0, 0, false
+		}
+		if gen.Tok == token.CONST {
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.ValueSpec)
+				if len(spec.Values) == 0 || usesIota(spec) {
+					return 0, 0, false
+				}
+			}
+		}
+		return removeSpec(j, path, spec, spec.Doc, spec.Comment, false)
+	}
+	return 0, 0, false
+}
+
+// removeSpec returns the range to delete to remove spec, the second
+// element of path, or its whole declaration if whole is true.
+// Removing the whole declaration for each of the names it declares
+// results in identical edits, which are only applied once.
+func removeSpec(j *lint.Job, path []ast.Node, spec ast.Spec, doc, comment *ast.CommentGroup, whole bool) (pos, end token.Pos, ok bool) {
+	gen := path[2].(*ast.GenDecl)
+	if !whole {
+		pos, end = withDoc(doc, spec), spec.End()
+		if comment != nil {
+			end = comment.End()
+		}
+		var siblings []ast.Node
+		for _, spec := range gen.Specs {
+			siblings = append(siblings, spec)
+		}
+		return separate(j, siblings, spec, pos, end)
+	}
+	pos, end = withDoc(gen.Doc, gen), gen.End()
+	if !gen.Lparen.IsValid() && comment != nil {
+		end = comment.End()
+	}
+	var parent ast.Node
+	if len(path) > 3 {
+		parent = path[3]
+	}
+	return separate(j, decls(parent), gen, pos, end)
+}
+
+// separate extends the range [pos, end) that deletes node, one of
+// siblings, to include the separator between node and a sibling on
+// the same line, so that deleting node doesn't leave a stray
+// semicolon behind.
+func separate(j *lint.Job, siblings []ast.Node, node ast.Node, pos, end token.Pos) (token.Pos, token.Pos, bool) {
+	fset := j.Program.Prog.Fset
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+	for i, sibling := range siblings {
+		if sibling != node {
+			continue
+		}
+		if i+1 < len(siblings) && line(siblings[i+1].Pos()) == line(end) {
+			return pos, siblings[i+1].Pos(), true
+		}
+		if i > 0 && line(siblings[i-1].End()) == line(pos) {
+			return siblings[i-1].End(), end, true
+		}
+		break
+	}
+	return pos, end, true
+}
+
+// allUnused reports whether all names declared by gen are in
+// unused.
+func allUnused(j *lint.Job, gen *ast.GenDecl, unused map[types.Object]bool) bool {
+	for _, spec := range gen.Specs {
+		var names []*ast.Ident
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			names = []*ast.Ident{spec.Name}
+		case *ast.ValueSpec:
+			names = spec.Names
+		default:
+			return false
+		}
+		for _, name := range names {
+			if !unused[j.Program.Info.ObjectOf(name)] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// decls returns the declarations of a file, and nil for other nodes.
+func decls(node ast.Node) []ast.Node {
+	f, ok := node.(*ast.File)
+	if !ok {
+		return nil
+	}
+	var out []ast.Node
+	for _, decl := range f.Decls {
+		out = append(out, decl)
+	}
+	return out
+}
+
+func withDoc(doc *ast.CommentGroup, node ast.Node) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return node.Pos()
+}
+
+func usesIota(spec *ast.ValueSpec) bool {
+	found := false
+	for _, v := range spec.Values {
+		ast.Inspect(v, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Name == "iota" {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+func anySideEffects(j *lint.Job, gen *ast.GenDecl) bool {
+	if gen.Tok != token.VAR {
+		return false
+	}
+	for _, spec := range gen.Specs {
+		if spec, ok := spec.(*ast.ValueSpec); ok {
+			for _, v := range spec.Values {
+				if hasSideEffects(j, v) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// hasSideEffects reports whether evaluating expr may have side
+// effects. Any call other than a conversion is assumed to have them.
+func hasSideEffects(j *lint.Job, expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.CallExpr:
+			if tv, ok := j.Program.Info.Types[node.Fun]; !ok || !tv.IsType() {
+				found = true
+			}
+		case *ast.UnaryExpr:
+			if node.Op == token.ARROW {
+				found = true
+			}
+		case *ast.FuncLit:
+			// the function's body isn't evaluated
+			return false
+		}
+		return !found
+	})
+	return found
+}
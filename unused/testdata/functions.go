@@ -16,7 +16,7 @@ type t2 struct{}
 type t3 struct{}
 
 func fn1() t1     { return t1{} } // MATCH /fn1 is unused/
-func fn2() (x t2) { return }
+func fn2() (x t2) { return }      // MATCH /named result x is never assigned/
 func fn3() *t3    { return nil }
 
 func fn4() {
@@ -0,0 +1,58 @@
+package pkg
+
+import "io"
+
+type t struct{}
+
+func (t) called()      {}
+func (t) uncalled()    {}
+func (t) converted()   {}
+func (t) asserted()    {}
+func (t) Close() error { return nil }
+
+type caller interface {
+	called()
+	uncalled() // MATCH /interface method caller.uncalled is never called/
+}
+
+type converter interface {
+	converted() // MATCH /interface method converter.converted is never called/
+	Close() error
+}
+
+type asserter interface {
+	asserted()
+}
+
+type embedder interface {
+	caller
+	extra() // MATCH /interface method embedder.extra is never called/
+}
+
+// Exposed is part of the API, callers may use its methods.
+type Exposed interface {
+	Do()
+}
+
+type exposedIndirectly interface {
+	indirect()
+}
+
+func Get() exposedIndirectly { return nil }
+
+func init() {
+	var c caller = t{}
+	c.called()
+
+	var e embedder
+	_ = e
+
+	var conv converter = t{}
+	var cl io.Closer = conv
+	_ = cl
+
+	var x interface{} = t{}
+	if a, ok := x.(asserter); ok {
+		_ = a
+	}
+}
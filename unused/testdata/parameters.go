@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func read(a, b int, _ int) int { // MATCH /parameter b is never read/
+	return a
+}
+
+func overwritten(x int) { // MATCH /parameter x is never read/
+	x = 1
+	fmt.Println()
+}
+
+func incremented(x int) int {
+	x++
+	return x
+}
+
+func stub(x int) {}
+
+func inClosure(x int) func() int {
+	return func() int { return x }
+}
+
+func result() (n int) { // MATCH /named result n is never assigned/
+	fmt.Println()
+	return
+}
+
+func explicitResult() (n int) {
+	fmt.Println()
+	return 1
+}
+
+func deferredResult() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	fmt.Println()
+	return
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w)
+}
+
+func asValue(x int) {
+	fmt.Println()
+}
+
+type T struct{}
+
+func (T) String() string {
+	return ""
+}
+
+func (T) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w)
+}
+
+func (T) method(x int) { // MATCH /parameter x is never read/
+	fmt.Println()
+}
+
+func main() {
+	read(1, 2, 3)
+	overwritten(1)
+	incremented(1)
+	stub(1)
+	inClosure(1)
+	result()
+	explicitResult()
+	deferredResult()
+	http.HandleFunc("/", handler)
+	fn := (asValue)
+	fn(1)
+	T{}.method(1)
+}
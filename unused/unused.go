@@ -7,9 +7,12 @@ import (
 	"go/types"
 	"io"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/ssa"
 
 	"golang.org/x/tools/go/loader"
 	"golang.org/x/tools/go/types/typeutil"
@@ -24,12 +27,77 @@ func NewLintChecker(c *Checker) *LintChecker {
 
 type LintChecker struct {
 	c *Checker
+
+	mu      sync.Mutex
+	fset    *token.FileSet
+	objects map[token.Position]Object
+}
+
+// An Object describes the identifier a problem is about, for
+// machine-readable output.
+type Object struct {
+	Name string `json:"name"`
+	// Kind is one of func, type, field, const and var.
+	Kind    string `json:"kind"`
+	Package string `json:"package"`
+	// Receiver is the receiver type of methods.
+	Receiver string `json:"receiver,omitempty"`
+	Exported bool   `json:"exported"`
+	// TestOnly is set if the identifier is declared in a test file.
+	TestOnly bool `json:"test_only"`
+}
+
+func (l *LintChecker) Init(prog *lint.Program) {
+	l.c.Generated = prog.Generated
+	l.fset = prog.Prog.Fset
+	l.objects = map[token.Position]Object{}
+}
+
+// Object returns a description of the identifier that the problem at
+// pos, as reported by the last run of l, is about.
+func (l *LintChecker) Object(pos token.Position) (Object, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	obj, ok := l.objects[pos]
+	return obj, ok
+}
+
+// errorf reports a problem about obj, an identifier of the given
+// kind, and records a description of obj.
+func (l *LintChecker) errorf(j *lint.Job, obj types.Object, kind string, format string, args ...interface{}) *lint.Problem {
+	p := j.Errorf(obj, format, args...)
+	pos := l.fset.Position(obj.Pos())
+	desc := Object{
+		Name:     obj.Name(),
+		Kind:     kind,
+		Exported: obj.Exported(),
+		TestOnly: strings.HasSuffix(pos.Filename, "_test.go"),
+	}
+	if obj.Pkg() != nil {
+		desc.Package = obj.Pkg().Path()
+	}
+	if sig, ok := obj.Type().(*types.Signature); ok && sig.Recv() != nil {
+		desc.Receiver = types.TypeString(sig.Recv().Type(), func(*types.Package) string { return "" })
+	}
+	l.mu.Lock()
+	l.objects[pos] = desc
+	l.mu.Unlock()
+	return p
 }
 
-func (l *LintChecker) Init(*lint.Program) {}
 func (l *LintChecker) Funcs() map[string]lint.Func {
 	return map[string]lint.Func{
 		"U1000": l.Lint,
+		"U1001": l.LintInterfaceMethods,
+		"U1002": l.LintParameters,
+	}
+}
+
+func (l *LintChecker) Versions() map[string]lint.CheckVersion {
+	return map[string]lint.CheckVersion{
+		"U1000": {Introduced: "2017.1", Changed: "2017.2"},
+		"U1001": {Introduced: "2017.2"},
+		"U1002": {Introduced: "2017.2"},
 	}
 }
 
@@ -54,9 +122,15 @@ func typString(obj types.Object) string {
 
 func (l *LintChecker) Lint(j *lint.Job) {
 	unused := l.c.Check(j.Program.Prog)
+	objs := map[types.Object]bool{}
+	for _, u := range unused {
+		objs[u.Obj] = true
+	}
 	for _, u := range unused {
 		name := u.Obj.Name()
+		kind := typString(u.Obj)
 		if sig, ok := u.Obj.Type().(*types.Signature); ok && sig.Recv() != nil {
+			kind = "method"
 			switch sig.Recv().Type().(type) {
 			case *types.Named, *types.Pointer:
 				typ := types.TypeString(sig.Recv().Type(), func(*types.Package) string { return "" })
@@ -67,10 +141,397 @@ func (l *LintChecker) Lint(j *lint.Job) {
 				}
 			}
 		}
-		j.Errorf(u.Obj, "%s %s is unused", typString(u.Obj), name)
+		p := l.errorf(j, u.Obj, kind, "%s %s is unused", typString(u.Obj), name)
+		if pos, end, ok := removal(j, u.Obj, objs); ok {
+			p.Edit(pos, end, "")
+		}
 	}
 }
 
+// LintInterfaceMethods reports methods of interfaces that are never
+// called through an interface and that no conversion relies on.
+//
+// Removing a method from an interface I doesn't affect conversions
+// of concrete types to I. It does affect conversions of I to other
+// interfaces, which require I to have their methods, and type
+// assertions to I, which depend on all of I's methods.
+func (l *LintChecker) LintInterfaceMethods(j *lint.Job) {
+	if !l.c.checkFunctions() {
+		return
+	}
+	needed := map[*types.Func]bool{}
+	// need marks the methods of from that are required to convert
+	// it to the interface to.
+	need := func(from, to types.Type) {
+		iface, ok := to.Underlying().(*types.Interface)
+		if !ok {
+			return
+		}
+		for i := 0; i < iface.NumMethods(); i++ {
+			m := iface.Method(i)
+			obj, _, _ := types.LookupFieldOrMethod(from, false, m.Pkg(), m.Name())
+			if fn, ok := obj.(*types.Func); ok {
+				needed[fn] = true
+			}
+		}
+	}
+	for _, fn := range j.Program.AllFunctions {
+		if j.Program.Generated.IgnoreUses && j.IsGenerated(fn) {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case ssa.CallInstruction:
+					if common := instr.Common(); common.IsInvoke() {
+						needed[common.Method] = true
+					}
+				case *ssa.ChangeInterface:
+					need(instr.X.Type(), instr.Type())
+				case *ssa.TypeAssert:
+					need(instr.AssertedType, instr.AssertedType)
+				}
+			}
+		}
+	}
+
+	for _, pkg := range j.Program.Prog.InitialPackages() {
+		if l.c.Importers[pkg.Pkg.Path()] {
+			continue
+		}
+		for _, obj := range pkg.Defs {
+			tname, ok := obj.(*types.TypeName)
+			if !ok {
+				continue
+			}
+			named, ok := tname.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			iface, ok := named.Underlying().(*types.Interface)
+			if !ok {
+				continue
+			}
+			if !l.c.WholeProgram && pkg.Pkg.Name() != "main" && exposed(pkg.Pkg, named) {
+				// Users of the package may call the methods.
+				continue
+			}
+			for i := 0; i < iface.NumExplicitMethods(); i++ {
+				m := iface.ExplicitMethod(i)
+				if needed[m] {
+					continue
+				}
+				l.errorf(j, m, "method", "interface method %s.%s is never called and no conversion needs it", tname.Name(), m.Name())
+			}
+		}
+	}
+}
+
+// LintParameters reports parameters that are never read and named
+// results that are never assigned.
+//
+// Functions whose signature is dictated by something other than
+// their body are skipped: methods that implement an interface,
+// functions that are used as values, cgo exports and tests. Outside
+// of whole-program mode, so are exported functions and methods of
+// packages other than main, as their signature is part of the API.
+// Functions with empty bodies are usually stubs and skipped, too.
+func (l *LintChecker) LintParameters(j *lint.Job) {
+	if !l.c.checkVariables() {
+		return
+	}
+	required := implementingMethods(j.Program.Prog)
+
+	// Functions that are used other than by calling them have to
+	// match the signature of whatever they're assigned to.
+	values := map[*types.Func]bool{}
+	for _, f := range j.Program.Files {
+		var stack []ast.Node
+		ast.Inspect(f, func(node ast.Node) bool {
+			if node == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			stack = append(stack, node)
+			var ident *ast.Ident
+			switch node := node.(type) {
+			case *ast.Ident:
+				ident = node
+			case *ast.SelectorExpr:
+				ident = node.Sel
+			default:
+				return true
+			}
+			fn, ok := j.Program.Info.Uses[ident].(*types.Func)
+			if !ok {
+				return true
+			}
+			if sel, ok := stack[len(stack)-2].(*ast.SelectorExpr); ok && sel.Sel == ident {
+				// handled by the selector
+				return true
+			}
+			expr := node.(ast.Expr)
+			i := len(stack) - 2
+			for {
+				paren, ok := stack[i].(*ast.ParenExpr)
+				if !ok {
+					break
+				}
+				expr = paren
+				i--
+			}
+			if call, ok := stack[i].(*ast.CallExpr); !ok || call.Fun != expr {
+				values[fn] = true
+			}
+			return true
+		})
+	}
+
+	for _, f := range j.FilterGenerated(j.Program.Files) {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil || len(fd.Body.List) == 0 {
+				continue
+			}
+			fn, ok := j.Program.Info.Defs[fd.Name].(*types.Func)
+			if !ok || values[fn] || required[fn] || isCgoExported(fd) || l.c.Importers[fn.Pkg().Path()] {
+				continue
+			}
+			if !l.c.WholeProgram && fn.Exported() && fn.Pkg().Name() != "main" {
+				continue
+			}
+			if isTestFunc(j, fd) {
+				continue
+			}
+			l.lintParameters(j, fd)
+		}
+	}
+}
+
+func (l *LintChecker) lintParameters(j *lint.Job, fd *ast.FuncDecl) {
+	reads := map[*types.Var]bool{}
+	uses := map[*types.Var]bool{}
+	assigned := map[*ast.Ident]bool{}
+	returnsValues := false
+	ast.Inspect(fd.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			if node.Tok == token.ASSIGN {
+				for _, lhs := range node.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						assigned[ident] = true
+					}
+				}
+			}
+		case *ast.ReturnStmt:
+			if len(node.Results) > 0 {
+				returnsValues = true
+			}
+		case *ast.FuncLit:
+			// returns in closures don't return from fd, but
+			// other uses in closures count.
+			ast.Inspect(node.Body, func(node ast.Node) bool {
+				if ident, ok := node.(*ast.Ident); ok {
+					if v, ok := j.Program.Info.Uses[ident].(*types.Var); ok {
+						uses[v] = true
+						reads[v] = true
+					}
+				}
+				return true
+			})
+			return false
+		case *ast.Ident:
+			v, ok := j.Program.Info.Uses[node].(*types.Var)
+			if !ok {
+				break
+			}
+			uses[v] = true
+			if !assigned[node] {
+				reads[v] = true
+			}
+		}
+		return true
+	})
+
+	for _, field := range fd.Type.Params.List {
+		for _, name := range field.Names {
+			v, ok := j.Program.Info.Defs[name].(*types.Var)
+			if !ok || v.Name() == "_" || reads[v] {
+				continue
+			}
+			l.errorf(j, v, "param", "parameter %s is never read", name.Name)
+		}
+	}
+	if fd.Type.Results == nil || returnsValues {
+		return
+	}
+	for _, field := range fd.Type.Results.List {
+		for _, name := range field.Names {
+			v, ok := j.Program.Info.Defs[name].(*types.Var)
+			if !ok || v.Name() == "_" || uses[v] {
+				continue
+			}
+			l.errorf(j, v, "result", "named result %s is never assigned", name.Name)
+		}
+	}
+}
+
+// implementingMethods returns the methods that named types of the
+// initial packages need to implement any of the interfaces in
+// lprog.
+func implementingMethods(lprog *loader.Program) map[*types.Func]bool {
+	var ifaces []*types.Interface
+	var seen typeutil.Map
+	add := func(iface *types.Interface) {
+		if iface.NumMethods() == 0 || seen.At(iface) != nil {
+			return
+		}
+		seen.Set(iface, true)
+		ifaces = append(ifaces, iface)
+	}
+	add(types.Universe.Lookup("error").Type().Underlying().(*types.Interface))
+	for _, pkg := range lprog.AllPackages {
+		for _, tv := range pkg.Types {
+			if iface, ok := tv.Type.(*types.Interface); ok {
+				add(iface)
+			}
+		}
+	}
+
+	out := map[*types.Func]bool{}
+	for _, pkg := range lprog.InitialPackages() {
+		for _, obj := range pkg.Defs {
+			tname, ok := obj.(*types.TypeName)
+			if !ok {
+				continue
+			}
+			if _, ok := tname.Type().Underlying().(*types.Interface); ok {
+				continue
+			}
+			T := tname.Type()
+			ptr := types.NewPointer(T)
+			ms := types.NewMethodSet(ptr)
+			if ms.Len() == 0 {
+				continue
+			}
+			for _, iface := range ifaces {
+				if !types.Implements(ptr, iface) {
+					continue
+				}
+				for i := 0; i < iface.NumMethods(); i++ {
+					m := iface.Method(i)
+					if sel := ms.Lookup(m.Pkg(), m.Name()); sel != nil {
+						out[sel.Obj().(*types.Func)] = true
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func isCgoExported(fd *ast.FuncDecl) bool {
+	if fd.Doc == nil {
+		return false
+	}
+	for _, cmt := range fd.Doc.List {
+		if strings.HasPrefix(cmt.Text, "//export ") || strings.HasPrefix(cmt.Text, "//go:cgo_export_") {
+			return true
+		}
+	}
+	return false
+}
+
+// isTestFunc reports whether fd is a test, benchmark or example
+// function, whose signature is dictated by the testing package.
+func isTestFunc(j *lint.Job, fd *ast.FuncDecl) bool {
+	name := j.Program.SSA.Fset.File(fd.Pos()).Name()
+	if !strings.HasSuffix(name, "_test.go") || fd.Recv != nil {
+		return false
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Example"} {
+		if strings.HasPrefix(fd.Name.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// exposed reports whether named can be referred to by other
+// packages, either directly or through the exported API of pkg.
+func exposed(pkg *types.Package, named *types.Named) bool {
+	if named.Obj().Exported() && named.Obj().Parent() == pkg.Scope() {
+		return true
+	}
+	seen := map[types.Type]bool{}
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		if mentions(obj.Type(), named, seen) {
+			return true
+		}
+		if tname, ok := obj.(*types.TypeName); ok {
+			ms := types.NewMethodSet(types.NewPointer(tname.Type()))
+			for i := 0; i < ms.Len(); i++ {
+				if ms.At(i).Obj().Exported() && mentions(ms.At(i).Type(), named, seen) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// mentions reports whether T refers to target in a way that other
+// packages can observe.
+func mentions(T types.Type, target *types.Named, seen map[types.Type]bool) bool {
+	if T == target {
+		return true
+	}
+	if seen[T] {
+		return false
+	}
+	seen[T] = true
+	switch T := T.(type) {
+	case *types.Named:
+		return mentions(T.Underlying(), target, seen)
+	case *types.Pointer:
+		return mentions(T.Elem(), target, seen)
+	case *types.Slice:
+		return mentions(T.Elem(), target, seen)
+	case *types.Array:
+		return mentions(T.Elem(), target, seen)
+	case *types.Chan:
+		return mentions(T.Elem(), target, seen)
+	case *types.Map:
+		return mentions(T.Key(), target, seen) || mentions(T.Elem(), target, seen)
+	case *types.Signature:
+		return mentions(T.Params(), target, seen) || mentions(T.Results(), target, seen)
+	case *types.Tuple:
+		for i := 0; i < T.Len(); i++ {
+			if mentions(T.At(i).Type(), target, seen) {
+				return true
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < T.NumFields(); i++ {
+			if f := T.Field(i); (f.Exported() || f.Anonymous()) && mentions(f.Type(), target, seen) {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < T.NumMethods(); i++ {
+			if T.Method(i).Exported() && mentions(T.Method(i).Type(), target, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type graph struct {
 	roots []*graphNode
 	nodes map[interface{}]*graphNode
@@ -149,7 +610,20 @@ type Checker struct {
 	Mode               CheckMode
 	WholeProgram       bool
 	ConsiderReflection bool
-	Debug              io.Writer
+	// Frameworks are the names of the frameworks in
+	// ReflectionFrameworks whose use of reflection is considered if
+	// ConsiderReflection is set.
+	Frameworks []string
+	// Importers are the import paths of packages that are only
+	// checked for their uses of the other packages, such as the
+	// reverse dependencies of the packages of interest. Nothing
+	// declared in them is reported. Together with WholeProgram, this
+	// reports exported identifiers that none of the importers use.
+	Importers map[string]bool
+	// Generated controls whether unused identifiers in generated
+	// files are reported, and whether uses in generated files count.
+	Generated lint.GeneratedPolicy
+	Debug     io.Writer
 
 	graph *graph
 
@@ -157,11 +631,18 @@ type Checker struct {
 	lprog        *loader.Program
 	topmostCache map[*types.Scope]*types.Scope
 	interfaces   []*types.Interface
+	generated    map[*token.File]bool
 }
 
 func NewChecker(mode CheckMode) *Checker {
+	var frameworks []string
+	for name := range ReflectionFrameworks {
+		frameworks = append(frameworks, name)
+	}
+	sort.Strings(frameworks)
 	return &Checker{
-		Mode: mode,
+		Mode:       mode,
+		Frameworks: frameworks,
 		graph: &graph{
 			nodes: make(map[interface{}]*graphNode),
 		},
@@ -198,6 +679,12 @@ func (e Error) Error() string {
 func (c *Checker) Check(lprog *loader.Program) []Unused {
 	var unused []Unused
 	c.lprog = lprog
+	c.generated = map[*token.File]bool{}
+	for _, pkg := range c.lprog.InitialPackages() {
+		for _, f := range pkg.Files {
+			c.generated[lprog.Fset.File(f.Pos())] = lint.IsGenerated(f)
+		}
+	}
 	if c.WholeProgram {
 		c.findExportedInterfaces()
 	}
@@ -249,7 +736,7 @@ func (c *Checker) Check(lprog *loader.Program) []Unused {
 				}
 			}
 		}
-		if !found {
+		if !found || c.Importers[obj.Pkg().Path()] {
 			continue
 		}
 
@@ -257,17 +744,7 @@ func (c *Checker) Check(lprog *loader.Program) []Unused {
 		if pos.Filename == "" || filepath.Base(pos.Filename) == "C" {
 			continue
 		}
-		generated := false
-		for _, file := range c.lprog.Package(obj.Pkg().Path()).Files {
-			if c.lprog.Fset.Position(file.Pos()).Filename != pos.Filename {
-				continue
-			}
-			if len(file.Comments) > 0 {
-				generated = isGenerated(file.Comments[0].Text())
-			}
-			break
-		}
-		if generated {
+		if !c.Generated.Report && c.generated[c.lprog.Fset.File(obj.Pos())] {
 			continue
 		}
 		unused = append(unused, Unused{Obj: obj, Position: pos})
@@ -336,12 +813,12 @@ func (c *Checker) processDefs(pkg *loader.PackageInfo) {
 			// operation, we have to. The user may use these fields
 			// without us knowing.
 			//
-			// TODO(dh): In whole-program mode, however, we mark them
-			// as used because of reflection (such as JSON
-			// marshaling). Strictly speaking, we would only need to
-			// mark them used if an instance of the type was
-			// accessible via an interface value.
-			if !c.WholeProgram || c.ConsiderReflection {
+			// In whole-program mode, however, exported fields are
+			// only used because of reflection (such as JSON
+			// marshaling) if values of the type are passed to one
+			// of the known users of reflection (see
+			// processReflection).
+			if !c.WholeProgram {
 				c.useExportedFields(obj.Type())
 			}
 
@@ -426,6 +903,15 @@ func (c *Checker) processUses(pkg *loader.PackageInfo) {
 		if _, ok := usedObj.(*types.PkgName); ok {
 			continue
 		}
+		if c.ignoreUse(ident.Pos()) {
+			continue
+		}
+		if c.WholeProgram && usedObj.Pkg() != nil && usedObj.Pkg() != pkg.Pkg {
+			// In whole-program mode, an identifier is used if any
+			// other package being checked refers to it, whether
+			// or not the referring code is used itself.
+			c.graph.roots = append(c.graph.roots, c.graph.getNode(usedObj))
+		}
 		pos := ident.Pos()
 		scope := pkg.Pkg.Scope().Innermost(pos)
 		scope = c.topmostScope(scope, pkg.Pkg)
@@ -468,7 +954,7 @@ func (c *Checker) findExportedInterfaces() {
 func (c *Checker) processTypes(pkg *loader.PackageInfo) {
 	named := map[*types.Named]*types.Pointer{}
 	var interfaces []*types.Interface
-	for _, tv := range pkg.Types {
+	for expr, tv := range pkg.Types {
 		if typ, ok := tv.Type.(interface {
 			Elem() types.Type
 		}); ok {
@@ -481,7 +967,7 @@ func (c *Checker) processTypes(pkg *loader.PackageInfo) {
 			c.graph.markUsedBy(obj, obj.Underlying())
 			c.graph.markUsedBy(obj.Underlying(), obj)
 		case *types.Interface:
-			if obj.NumMethods() > 0 {
+			if obj.NumMethods() > 0 && !c.ignoreUse(expr.Pos()) {
 				interfaces = append(interfaces, obj)
 			}
 		case *types.Struct:
@@ -557,6 +1043,9 @@ func (c *Checker) processSelections(pkg *loader.PackageInfo) {
 	}
 
 	for expr, sel := range pkg.Selections {
+		if c.ignoreUse(expr.Pos()) {
+			continue
+		}
 		switch sel.Kind() {
 		case types.FieldVal:
 			fn(expr, sel, 0)
@@ -747,9 +1236,123 @@ func (c *Checker) processKnownReflectMethodCallers(pkg *loader.PackageInfo, node
 	}
 }
 
+// ReflectionFrameworks lists, per framework, the functions that
+// access the fields of their arguments via reflection. The functions
+// map to the index of the first such argument; all following
+// arguments are accessed, too.
+var ReflectionFrameworks = map[string]map[string]int{
+	"json": {
+		"encoding/json.Marshal":           0,
+		"encoding/json.MarshalIndent":     0,
+		"encoding/json.Unmarshal":         1,
+		"(*encoding/json.Encoder).Encode": 0,
+		"(*encoding/json.Decoder).Decode": 0,
+	},
+	"xml": {
+		"encoding/xml.Marshal":                  0,
+		"encoding/xml.MarshalIndent":            0,
+		"encoding/xml.Unmarshal":                1,
+		"(*encoding/xml.Encoder).Encode":        0,
+		"(*encoding/xml.Encoder).EncodeElement": 0,
+		"(*encoding/xml.Decoder).Decode":        0,
+		"(*encoding/xml.Decoder).DecodeElement": 0,
+	},
+	"template": {
+		"(*text/template.Template).Execute":         1,
+		"(*text/template.Template).ExecuteTemplate": 2,
+		"(*html/template.Template).Execute":         1,
+		"(*html/template.Template).ExecuteTemplate": 2,
+	},
+	"sql": {
+		"(*database/sql.Row).Scan":  0,
+		"(*database/sql.Rows).Scan": 0,
+	},
+	"reflect": {
+		"reflect.ValueOf":   0,
+		"reflect.TypeOf":    0,
+		"reflect.DeepEqual": 0,
+	},
+}
+
+// processReflection marks the fields of types as used if values of
+// the types are passed to functions that access them via reflection.
+// Encoders and templates use exported fields, and templates also
+// exported methods; the reflect package can access all fields.
+//
+// Values whose static type is an interface type aren't tracked.
+func (c *Checker) processReflection(pkg *loader.PackageInfo, node ast.Node) {
+	if !c.ConsiderReflection {
+		return
+	}
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	case *ast.Ident:
+		ident = fun
+	default:
+		return
+	}
+	fn, ok := pkg.ObjectOf(ident).(*types.Func)
+	if !ok {
+		return
+	}
+	name := fn.FullName()
+	for _, framework := range c.Frameworks {
+		idx, ok := ReflectionFrameworks[framework][name]
+		if !ok || idx >= len(call.Args) {
+			continue
+		}
+		for _, arg := range call.Args[idx:] {
+			c.useReflectedFields(pkg.TypeOf(arg), framework, map[types.Type]bool{})
+		}
+	}
+}
+
+func (c *Checker) useReflectedFields(typ types.Type, framework string, seen map[types.Type]bool) {
+	if typ == nil || seen[typ] {
+		return
+	}
+	seen[typ] = true
+	switch T := typ.(type) {
+	case *types.Pointer:
+		c.useReflectedFields(T.Elem(), framework, seen)
+	case *types.Slice:
+		c.useReflectedFields(T.Elem(), framework, seen)
+	case *types.Array:
+		c.useReflectedFields(T.Elem(), framework, seen)
+	case *types.Map:
+		c.useReflectedFields(T.Key(), framework, seen)
+		c.useReflectedFields(T.Elem(), framework, seen)
+	case *types.Named:
+		if framework == "template" {
+			ms := typeutil.IntuitiveMethodSet(T, &c.msCache)
+			for _, sel := range ms {
+				if sel.Obj().Exported() {
+					c.graph.markUsedBy(sel.Obj(), T)
+				}
+			}
+		}
+		c.useReflectedFields(T.Underlying(), framework, seen)
+	case *types.Struct:
+		for i := 0; i < T.NumFields(); i++ {
+			field := T.Field(i)
+			if framework == "reflect" || field.Exported() || field.Anonymous() {
+				c.graph.markUsedBy(field, T)
+				c.useReflectedFields(field.Type(), framework, seen)
+			}
+		}
+	}
+}
+
 func (c *Checker) processAST(pkg *loader.PackageInfo) {
 	fn := func(node ast.Node) bool {
 		c.processConversion(pkg, node)
+		c.processReflection(pkg, node)
 		c.processKnownReflectMethodCallers(pkg, node)
 		c.processCompositeLiteral(pkg, node)
 		c.processCgoExported(pkg, node)
@@ -758,10 +1361,19 @@ func (c *Checker) processAST(pkg *loader.PackageInfo) {
 		return true
 	}
 	for _, file := range pkg.Files {
+		if c.ignoreUse(file.Pos()) {
+			continue
+		}
 		ast.Inspect(file, fn)
 	}
 }
 
+// ignoreUse reports whether uses at pos are to be disregarded
+// because they are in generated code.
+func (c *Checker) ignoreUse(pos token.Pos) bool {
+	return c.Generated.IgnoreUses && c.generated[c.lprog.Fset.File(pos)]
+}
+
 func isBasicStruct(elts []ast.Expr) bool {
 	for _, elt := range elts {
 		if _, ok := elt.(*ast.KeyValueExpr); !ok {
@@ -836,7 +1448,7 @@ func (c *Checker) checkFlags(v interface{}) bool {
 	if isFunction(obj) && !c.checkFunctions() {
 		return false
 	}
-	if isVariable(obj) && !c.checkVariables() {
+	if isVariable(obj) && !isField(obj) && !c.checkVariables() {
 		return false
 	}
 	if isConstant(obj) && !c.checkConstants() {
@@ -853,7 +1465,8 @@ func (c *Checker) checkFlags(v interface{}) bool {
 
 func (c *Checker) isRoot(obj types.Object) bool {
 	// - in local mode, main, init, tests, and non-test, non-main exported are roots
-	// - in global mode (not yet implemented), main, init and tests are roots
+	// - in global mode, main, init and tests are roots, as are
+	//   objects referred to by other packages (see processUses)
 
 	if _, ok := obj.(*types.PkgName); ok {
 		return true
@@ -1007,8 +1620,3 @@ func (c *Checker) printDebugGraph(w io.Writer) {
 	}
 	fmt.Fprintln(w, "}")
 }
-
-func isGenerated(comment string) bool {
-	return strings.Contains(comment, "Code generated by") ||
-		strings.Contains(comment, "DO NOT EDIT")
-}
@@ -9,10 +9,16 @@ package unused
 import (
 	"go/parser"
 	"go/token"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
+	"honnef.co/go/tools/lint"
 	"honnef.co/go/tools/lint/testutil"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/loader"
 )
 
 func TestAll(t *testing.T) {
@@ -21,6 +27,326 @@ func TestAll(t *testing.T) {
 	testutil.TestAll(t, l, "")
 }
 
+func TestVersions(t *testing.T) {
+	l := NewLintChecker(NewChecker(CheckAll))
+	testutil.TestVersions(t, l, testutil.CheckFiles(t, l))
+}
+
+func TestWholeProgram(t *testing.T) {
+	ctx := buildutil.FakeContext(map[string]map[string]string{
+		"a": {
+			"a.go": `package a
+
+type T struct{}
+
+func (T) Used()   {}
+func (T) Unused() {}
+
+func Exported()       {}
+func ExportedUnused() {}
+func usedInternally() {}
+func Internal()       { usedInternally() }
+
+type Unreferenced struct{}
+`,
+		},
+		"b": {
+			"b.go": `package b
+
+import "a"
+
+func Use() {
+	a.Exported()
+	var t a.T
+	t.Used()
+}
+`,
+		},
+	})
+	conf := &loader.Config{Build: ctx}
+	conf.Import("a")
+	conf.Import("b")
+	lprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := NewChecker(CheckAll)
+	checker.WholeProgram = true
+	var got []string
+	for _, u := range checker.Check(lprog) {
+		got = append(got, u.Obj.Pkg().Name()+"."+u.Obj.Name())
+	}
+	sort.Strings(got)
+	// Identifiers referred to by another package are used, even if
+	// the referring code isn't.
+	want := []string{"a.ExportedUnused", "a.Internal", "a.Unreferenced", "a.Unused", "a.usedInternally", "b.Use"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestImporters(t *testing.T) {
+	ctx := buildutil.FakeContext(map[string]map[string]string{
+		"a": {
+			"a.go": `package a
+
+func Used()   {}
+func Unused() {}
+`,
+		},
+		"b": {
+			"b.go": `package b
+
+import "a"
+
+func Exported() { a.Used() }
+func unused()   {}
+`,
+		},
+	})
+	conf := &loader.Config{Build: ctx}
+	conf.Import("a")
+	conf.Import("b")
+	lprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := NewChecker(CheckAll)
+	checker.WholeProgram = true
+	checker.Importers = map[string]bool{"b": true}
+	var got []string
+	for _, u := range checker.Check(lprog) {
+		got = append(got, u.Obj.Pkg().Name()+"."+u.Obj.Name())
+	}
+	// Nothing in the importer b is reported.
+	want := []string{"a.Unused"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReflection(t *testing.T) {
+	ctx := buildutil.FakeContext(map[string]map[string]string{
+		"encoding/json": {
+			"json.go": `package json
+
+func Marshal(v interface{}) ([]byte, error) { return nil, nil }
+`,
+		},
+		"reflect": {
+			"reflect.go": `package reflect
+
+func DeepEqual(x, y interface{}) bool { return false }
+`,
+		},
+		"main": {
+			"main.go": `package main
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+type Encoded struct {
+	Name   string
+	Nested Inner
+	hidden int
+}
+
+type Inner struct{ Value int }
+
+type NotEncoded struct{ Field int }
+
+type Compared struct{ a int }
+
+func main() {
+	json.Marshal(&Encoded{})
+	var n NotEncoded
+	_ = n
+	reflect.DeepEqual(Compared{}, Compared{})
+}
+`,
+		},
+	})
+	tests := []struct {
+		frameworks []string
+		want       []string
+	}{
+		{[]string{"json", "reflect"}, []string{"Field", "hidden"}},
+		// Inner is only used via the unused field Nested, so
+		// Value isn't reported.
+		{[]string{"reflect"}, []string{"Field", "Name", "Nested", "hidden"}},
+		{nil, []string{"Field", "Name", "Nested", "a", "hidden"}},
+	}
+	for _, tt := range tests {
+		conf := &loader.Config{Build: ctx}
+		conf.Import("main")
+		lprog, err := conf.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checker := NewChecker(CheckFields)
+		checker.WholeProgram = true
+		checker.ConsiderReflection = true
+		checker.Frameworks = tt.frameworks
+		var got []string
+		for _, u := range checker.Check(lprog) {
+			got = append(got, u.Obj.Name())
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("frameworks %v: got %v, want %v", tt.frameworks, got, tt.want)
+		}
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	ctx := buildutil.FakeContext(map[string]map[string]string{
+		"main": {
+			"main.go": `package main
+
+func main() {}
+
+func usedByGenerated() {}
+`,
+			"gen.go": `// Copyright 2017 The Authors.
+
+// Code generated by gen. DO NOT EDIT.
+
+package main
+
+func init() { usedByGenerated() }
+
+func unusedGenerated() {}
+`,
+		},
+	})
+	tests := []struct {
+		policy lint.GeneratedPolicy
+		want   []string
+	}{
+		{lint.GeneratedPolicy{}, nil},
+		{lint.GeneratedPolicy{Report: true}, []string{"unusedGenerated"}},
+		{lint.GeneratedPolicy{IgnoreUses: true}, []string{"usedByGenerated"}},
+		{lint.GeneratedPolicy{Report: true, IgnoreUses: true}, []string{"unusedGenerated", "usedByGenerated"}},
+	}
+	for _, tt := range tests {
+		conf := &loader.Config{Build: ctx, ParserMode: parser.ParseComments}
+		conf.Import("main")
+		lprog, err := conf.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checker := NewChecker(CheckAll)
+		checker.Generated = tt.policy
+		var got []string
+		for _, u := range checker.Check(lprog) {
+			got = append(got, u.Obj.Name())
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("policy %+v: got %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestFix(t *testing.T) {
+	const src = `package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() { fmt.Println(used, a, t1(0)) }
+
+var used = 1
+
+// helper is only used by unused code.
+func helper() string { return strings.ToUpper("x") }
+
+// unused calls helper.
+func unused() string { return helper() }
+
+const (
+	a = 1
+	// b is unused.
+	b = 2 // not for long
+)
+
+const (
+	c = iota
+	d
+)
+
+type t1 int; type t2 int
+
+var (
+	sideEffect = fmt.Sprint()
+	conv       = int64(1)
+)
+
+func (t1) m() {}
+`
+	const want = `package main
+
+import (
+	"fmt"
+)
+
+func main() { fmt.Println(used, a, t1(0)) }
+
+var used = 1
+
+const (
+	a = 1
+)
+
+type t1 int
+
+var (
+	sideEffect = fmt.Sprint()
+)
+`
+	ctx := buildutil.FakeContext(map[string]map[string]string{
+		"fmt": {
+			"fmt.go": `package fmt
+
+func Println(a ...interface{})      {}
+func Sprint(a ...interface{}) string { return "" }
+`,
+		},
+		"strings": {
+			"strings.go": `package strings
+
+func ToUpper(s string) string { return s }
+`,
+		},
+		"main": {"main.go": src},
+	})
+	conf := &loader.Config{Build: ctx, ParserMode: parser.ParseComments}
+	conf.Import("main")
+	lprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &lint.Linter{Checker: NewLintChecker(NewChecker(CheckAll))}
+	files, _, err := lint.ApplyFixes(lprog.Fset, l.Lint(lprog), func(string) ([]byte, error) {
+		return []byte(src), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d fixed files, want 1", len(files))
+	}
+	for _, got := range files {
+		if string(got) != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
 type instruction struct {
 	Line int // the line number this applies to
 	IDs  []string